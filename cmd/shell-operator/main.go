@@ -11,7 +11,12 @@ import (
 	"github.com/flant/kube-client/klogtologrus"
 	"github.com/flant/shell-operator/pkg/app"
 	"github.com/flant/shell-operator/pkg/debug"
+	"github.com/flant/shell-operator/pkg/exitcode"
+	"github.com/flant/shell-operator/pkg/golden"
+	"github.com/flant/shell-operator/pkg/hook_bundle"
 	"github.com/flant/shell-operator/pkg/jq"
+	"github.com/flant/shell-operator/pkg/prometheus_rule"
+	"github.com/flant/shell-operator/pkg/schema"
 	shell_operator "github.com/flant/shell-operator/pkg/shell-operator"
 	utils_signal "github.com/flant/shell-operator/pkg/utils/signal"
 )
@@ -47,14 +52,19 @@ func main() {
 			// Init logging and initialize a ShellOperator instance.
 			operator, err := shell_operator.Init()
 			if err != nil {
-				os.Exit(1)
+				exitcode.ExitWithError(err)
 			}
 			operator.Start()
 
+			// Exit on unrecoverable errors, e.g. a panic in the task handler.
+			go func() {
+				exitcode.ExitWithError(<-operator.FatalErrors())
+			}()
+
 			// Block action by waiting signals from OS.
 			utils_signal.WaitForProcessInterruption(func() {
 				operator.Shutdown()
-				os.Exit(1)
+				exitcode.Exit(exitcode.Signal, nil)
 			})
 
 			return nil
@@ -64,5 +74,19 @@ func main() {
 	debug.DefineDebugCommands(kpApp)
 	debug.DefineDebugCommandsSelf(kpApp)
 
+	schema.DefineSchemaCommand(kpApp)
+
+	prometheus_rule.DefinePrometheusRuleCommand(kpApp)
+
+	hook_bundle.DefinePullHooksCommand(kpApp)
+
+	golden.DefineVerifyGoldensCommand(kpApp)
+
+	// Use values from the config file as defaults for start command flags.
+	if err := app.ApplyConfigFile(kpApp, "start", os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", app.AppName, err)
+		os.Exit(exitcode.Code(exitcode.ConfigError))
+	}
+
 	kingpin.MustParse(kpApp.Parse(os.Args[1:]))
 }
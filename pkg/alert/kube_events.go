@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
+)
+
+// eventSource is a component name in recorded Events.
+const eventSource = "shell-operator"
+
+// KubeEventRecorder records alert events as Kubernetes Events for the operator Pod
+// or for another object, so they are visible with "kubectl describe" and "kubectl get events".
+// Methods are safe to call on a nil KubeEventRecorder.
+type KubeEventRecorder struct {
+	object      *v1.ObjectReference
+	recorder    record.EventRecorder
+	broadcaster record.EventBroadcaster
+}
+
+func NewKubeEventRecorder(client *klient.Client, object *v1.ObjectReference) *KubeEventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(object.Namespace)})
+	return &KubeEventRecorder{
+		object:      object,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventSource}),
+		broadcaster: broadcaster,
+	}
+}
+
+// Start stops recording when ctx is done.
+func (r *KubeEventRecorder) Start(ctx context.Context) {
+	if r == nil || r.broadcaster == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		r.broadcaster.Shutdown()
+	}()
+}
+
+// Record records a Warning Event with the event type as a reason.
+func (r *KubeEventRecorder) Record(event Event) {
+	if r == nil {
+		return
+	}
+	if read_only.Skip("kube_events", "record", event.Type+" Event") {
+		return
+	}
+	r.recorder.Event(r.object, v1.EventTypeWarning, event.Type, kubeEventMessage(event))
+}
+
+// kubeEventMessage prepends hook, binding and queue to the message.
+func kubeEventMessage(event Event) string {
+	parts := make([]string, 0, 3)
+	if event.Hook != "" {
+		parts = append(parts, fmt.Sprintf("hook '%s'", event.Hook))
+	}
+	if event.Binding != "" {
+		parts = append(parts, fmt.Sprintf("binding '%s'", event.Binding))
+	}
+	if event.Queue != "" {
+		parts = append(parts, fmt.Sprintf("queue '%s'", event.Queue))
+	}
+	if len(parts) == 0 {
+		return event.Message
+	}
+	return strings.Join(parts, ", ") + ": " + event.Message
+}
+
+// ResolveKubeEventsObject returns a reference to the object for Events. spec is
+// "Kind/name" or "apiVersion/Kind/name". The operator Pod is used if spec is empty.
+func ResolveKubeEventsObject(client *klient.Client, namespace string, spec string) (*v1.ObjectReference, error) {
+	apiVersion, kind, name, err := parseKubeEventsObject(spec)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required to record Events")
+	}
+
+	gvr, err := client.GroupVersionResource(apiVersion, kind)
+	if err != nil {
+		return nil, fmt.Errorf("get GroupVersionResource for '%s': %v", spec, err)
+	}
+	obj, err := client.Dynamic().Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get %s/%s in namespace '%s': %v", kind, name, namespace, err)
+	}
+
+	log.Debugf("Record Events for %s/%s in namespace '%s'", obj.GetKind(), obj.GetName(), namespace)
+	return &v1.ObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  namespace,
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}, nil
+}
+
+// parseKubeEventsObject returns apiVersion, kind and name from the spec.
+// The operator Pod name is taken from $POD_NAME or from the hostname if spec is empty.
+func parseKubeEventsObject(spec string) (string, string, string, error) {
+	if spec == "" {
+		name := os.Getenv("POD_NAME")
+		if name == "" {
+			var err error
+			name, err = os.Hostname()
+			if err != nil {
+				return "", "", "", fmt.Errorf("get Pod name from hostname: %v", err)
+			}
+		}
+		return "v1", "Pod", name, nil
+	}
+
+	parts := strings.Split(spec, "/")
+	switch len(parts) {
+	case 2:
+		if parts[0] != "" && parts[1] != "" {
+			return "", parts[0], parts[1], nil
+		}
+	case 3:
+		if parts[0] != "" && parts[1] != "" && parts[2] != "" {
+			return parts[0], parts[1], parts[2], nil
+		}
+	case 4:
+		if parts[0] != "" && parts[1] != "" && parts[2] != "" && parts[3] != "" {
+			return parts[0] + "/" + parts[1], parts[2], parts[3], nil
+		}
+	}
+	return "", "", "", fmt.Errorf("object '%s' is invalid, use 'Kind/name' or 'apiVersion/Kind/name'", spec)
+}
@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_KubeEventRecorder_Record(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	r := &KubeEventRecorder{
+		object:   &v1.ObjectReference{Kind: "Pod", Name: "shell-operator-0", Namespace: "default"},
+		recorder: fake,
+	}
+
+	r.Record(Event{Type: QueueStalled, Hook: "hook.sh", Binding: "pods", Queue: "main", Message: "Task is failed 5 times"})
+	r.Record(Event{Type: WebhookError, Message: "failed"})
+
+	assert.Equal(t, "Warning QueueStalled hook 'hook.sh', binding 'pods', queue 'main': Task is failed 5 times", <-fake.Events)
+	assert.Equal(t, "Warning WebhookError failed", <-fake.Events)
+}
+
+func Test_KubeEventRecorder_Nil(t *testing.T) {
+	var r *KubeEventRecorder
+	r.Record(Event{Type: HookFailed})
+}
+
+func Test_parseKubeEventsObject(t *testing.T) {
+	t.Setenv("POD_NAME", "shell-operator-0")
+
+	tests := []struct {
+		spec       string
+		apiVersion string
+		kind       string
+		name       string
+		wantErr    bool
+	}{
+		{spec: "", apiVersion: "v1", kind: "Pod", name: "shell-operator-0"},
+		{spec: "Deployment/shell-operator", kind: "Deployment", name: "shell-operator"},
+		{spec: "v1/ConfigMap/state", apiVersion: "v1", kind: "ConfigMap", name: "state"},
+		{spec: "apps/v1/Deployment/shell-operator", apiVersion: "apps/v1", kind: "Deployment", name: "shell-operator"},
+		{spec: "shell-operator", wantErr: true},
+		{spec: "Deployment/", wantErr: true},
+		{spec: "a/b/c/d/e", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			apiVersion, kind, name, err := parseKubeEventsObject(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.apiVersion, apiVersion)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/utils/exponential_backoff"
+)
+
+// Types of alert events.
+const (
+	HookFailed   = "HookFailed"
+	QueueStalled = "QueueStalled"
+	WebhookError = "WebhookError"
+)
+
+// queueSize is a number of events waiting to be sent. New events are dropped if the queue is full.
+const queueSize = 100
+
+// Event is a JSON notification sent to the alerting webhook.
+type Event struct {
+	Type         string    `json:"type"`
+	Time         time.Time `json:"time"`
+	Hook         string    `json:"hook,omitempty"`
+	Binding      string    `json:"binding,omitempty"`
+	Queue        string    `json:"queue,omitempty"`
+	FailureCount int       `json:"failureCount,omitempty"`
+	Message      string    `json:"message"`
+}
+
+// Notifier sends events to the external webhook with HTTP POST.
+// Events are sent in the background, methods are safe to call on a nil Notifier.
+type Notifier struct {
+	URL     string
+	Retries int
+	// RetryDelay is an initial delay between retries, it grows exponentially.
+	RetryDelay time.Duration
+
+	client *http.Client
+	events chan Event
+}
+
+func NewNotifier(url string, timeout time.Duration, retries int) *Notifier {
+	return &Notifier{
+		URL:        url,
+		Retries:    retries,
+		RetryDelay: time.Second,
+		client:     &http.Client{Timeout: timeout},
+		events:     make(chan Event, queueSize),
+	}
+}
+
+// Start sends events until ctx is done.
+func (n *Notifier) Start(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-n.events:
+				n.send(ctx, event)
+			}
+		}
+	}()
+}
+
+// Notify queues the event to send.
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case n.events <- event:
+	default:
+		log.Warnf("Alert webhook: queue is full, drop %s event: %s", event.Type, event.Message)
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("Alert webhook: marshal %s event: %v", event.Type, err)
+		return
+	}
+
+	for attempt := 0; attempt <= n.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(exponential_backoff.CalculateDelay(n.RetryDelay, attempt-1)):
+			}
+		}
+		err = n.post(ctx, data)
+		if err == nil {
+			return
+		}
+		log.Warnf("Alert webhook: send %s event, attempt %d: %v", event.Type, attempt+1, err)
+	}
+	log.Errorf("Alert webhook: %s event is dropped after %d attempts: %v", event.Type, n.Retries+1, err)
+}
+
+func (n *Notifier) post(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Notifier_SendWithRetries(t *testing.T) {
+	var attempts int32
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var event Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer srv.Close()
+
+	n := NewNotifier(srv.URL, time.Second, 3)
+	n.RetryDelay = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n.Start(ctx)
+
+	n.Notify(Event{Type: HookFailed, Hook: "hook.sh", Binding: "pods", Queue: "main", FailureCount: 1, Message: "exit status 1"})
+
+	select {
+	case event := <-received:
+		assert.Equal(t, HookFailed, event.Type)
+		assert.Equal(t, "hook.sh", event.Hook)
+		assert.Equal(t, "pods", event.Binding)
+		assert.Equal(t, "main", event.Queue)
+		assert.Equal(t, 1, event.FailureCount)
+		assert.Equal(t, "exit status 1", event.Message)
+		assert.False(t, event.Time.IsZero())
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "event is not received")
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func Test_Notifier_DropAfterRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	n := NewNotifier(srv.URL, time.Second, 2)
+	n.RetryDelay = time.Millisecond
+	n.send(context.Background(), Event{Type: WebhookError, Message: "failed"})
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func Test_Notifier_Nil(t *testing.T) {
+	var n *Notifier
+	n.Start(context.Background())
+	n.Notify(Event{Type: QueueStalled})
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	AlertWebhookURL                = ""
+	AlertWebhookTimeout            = 10 * time.Second
+	AlertWebhookRetries            = 3
+	AlertWebhookQueueStallFailures = 5
+)
+
+// Settings of Kubernetes Events about hook failures, stalled queues and webhook errors.
+var (
+	KubeEventsEnabled      = false
+	KubeEventsObject       = ""
+	KubeEventsHookFailures = 3
+)
+
+// DefineAlertFlags defines flags for notifications about failures.
+func DefineAlertFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("alert-webhook-url", "An URL to send JSON notifications about hook failures, stalled queues and webhook errors with HTTP POST. Notifications are disabled if empty. Can be set with $ALERT_WEBHOOK_URL.").
+		Envar("ALERT_WEBHOOK_URL").
+		Default(AlertWebhookURL).
+		StringVar(&AlertWebhookURL)
+	cmd.Flag("alert-webhook-timeout", "A timeout for one notification request. Can be set with $ALERT_WEBHOOK_TIMEOUT.").
+		Envar("ALERT_WEBHOOK_TIMEOUT").
+		Default(AlertWebhookTimeout.String()).
+		DurationVar(&AlertWebhookTimeout)
+	cmd.Flag("alert-webhook-retries", "A number of retries for failed notification requests. Can be set with $ALERT_WEBHOOK_RETRIES.").
+		Envar("ALERT_WEBHOOK_RETRIES").
+		Default("3").
+		IntVar(&AlertWebhookRetries)
+	cmd.Flag("alert-webhook-queue-stall-failures", "A number of failed attempts of the task to consider the queue stalled. Can be set with $ALERT_WEBHOOK_QUEUE_STALL_FAILURES.").
+		Envar("ALERT_WEBHOOK_QUEUE_STALL_FAILURES").
+		Default("5").
+		IntVar(&AlertWebhookQueueStallFailures)
+
+	cmd.Flag("kube-events", "Record Kubernetes Events about hook failures, stalled queues and webhook errors. Events are recorded for the operator Pod or for the object from --kube-events-object. Can be set with $KUBE_EVENTS.").
+		Envar("KUBE_EVENTS").
+		BoolVar(&KubeEventsEnabled)
+	cmd.Flag("kube-events-object", "An object in the operator namespace to record Events for: 'Kind/name' or 'apiVersion/Kind/name', e.g. 'apps/v1/Deployment/shell-operator'. The operator Pod is used if empty, its name is taken from $POD_NAME or from the hostname. Can be set with $KUBE_EVENTS_OBJECT.").
+		Envar("KUBE_EVENTS_OBJECT").
+		Default(KubeEventsObject).
+		StringVar(&KubeEventsObject)
+	cmd.Flag("kube-events-hook-failures", "A number of failed attempts of the task to record the HookFailed Event. A queue is considered stalled after --alert-webhook-queue-stall-failures attempts. Can be set with $KUBE_EVENTS_HOOK_FAILURES.").
+		Envar("KUBE_EVENTS_HOOK_FAILURES").
+		Default("3").
+		IntVar(&KubeEventsHookFailures)
+}
@@ -2,6 +2,8 @@ package app
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -23,10 +25,45 @@ var (
 	Namespace     = ""
 	ListenAddress = "0.0.0.0"
 	ListenPort    = "9115"
+	// ListenSocket is a path of the Unix domain socket or "systemd" to serve HTTP instead of ListenAddress:ListenPort.
+	ListenSocket = ""
 )
 
+// ClusterName and ClusterID identify the cluster in binding contexts, so hooks can branch in multi-cluster setups.
+var (
+	ClusterName = ""
+	ClusterID   = ""
+)
+
+// ReadOnly disables mutating operations: object patches, webhook configurations management and Events.
+var ReadOnly = false
+
+// QueueFairScheduling are names of queues to execute tasks of different hooks in round-robin order. "*" means all queues.
+var QueueFairScheduling = make([]string, 0)
+
+// StatusPageBasicAuth is "user:password" to protect the status page. Empty means no auth.
+var StatusPageBasicAuth = ""
+
 var PrometheusMetricsPrefix = "shell_operator_"
 
+// Static labels and label renames for all metrics: "name=value,..." and "old=new,...".
+var (
+	PrometheusStaticLabels = ""
+	PrometheusLabelRewrite = ""
+)
+
+// Built-in metrics report values of PrometheusLabelCardinalityLabels above the limit as "other". Zero means no limit.
+var (
+	PrometheusLabelCardinalityLimit  = 0
+	PrometheusLabelCardinalityLabels = []string{"queue", "hook"}
+)
+
+// Counters of hooks are saved into HookMetricsCountersFile and restored on start. Empty means no persistence.
+var (
+	HookMetricsCountersFile         = ""
+	HookMetricsCountersSaveInterval = time.Minute
+)
+
 type FlagInfo struct {
 	Name   string
 	Help   string
@@ -65,6 +102,18 @@ var CommonFlagsInfo = map[string]FlagInfo{
 		"SHELL_OPERATOR_PROMETHEUS_METRICS_PREFIX",
 		true,
 	},
+	"prometheus-static-labels": {
+		"prometheus-static-labels",
+		"Labels to add to all metrics, e.g. 'instance=first,team=infra'. Can be set with $SHELL_OPERATOR_PROMETHEUS_STATIC_LABELS.",
+		"SHELL_OPERATOR_PROMETHEUS_STATIC_LABELS",
+		true,
+	},
+	"prometheus-label-rewrite": {
+		"prometheus-label-rewrite",
+		"Labels to rename in all metrics, e.g. 'hook=shell_hook,queue=shell_queue'. Can be set with $SHELL_OPERATOR_PROMETHEUS_LABEL_REWRITE.",
+		"SHELL_OPERATOR_PROMETHEUS_LABEL_REWRITE",
+		true,
+	},
 	"hook-metrics-listen-port": {
 		"hook-metrics-listen-port",
 		"Port to use to serve hooks’ custom metrics to Prometheus. Can be set with $SHELL_OPERATOR_HOOK_METRICS_LISTEN_PORT. Equal to listen-port if empty.",
@@ -123,6 +172,22 @@ func DefineStartCommandFlags(kpApp *kingpin.Application, cmd *kingpin.CmdClause)
 			StringVar(&PrometheusMetricsPrefix)
 	}
 
+	flag = CommonFlagsInfo["prometheus-static-labels"]
+	if flag.Define {
+		cmd.Flag(flag.Name, flag.Help).
+			Envar(flag.Envar).
+			Default(PrometheusStaticLabels).
+			StringVar(&PrometheusStaticLabels)
+	}
+
+	flag = CommonFlagsInfo["prometheus-label-rewrite"]
+	if flag.Define {
+		cmd.Flag(flag.Name, flag.Help).
+			Envar(flag.Envar).
+			Default(PrometheusLabelRewrite).
+			StringVar(&PrometheusLabelRewrite)
+	}
+
 	flag = CommonFlagsInfo["namespace"]
 	if flag.Define {
 		cmd.Flag(flag.Name, flag.Help).
@@ -131,11 +196,66 @@ func DefineStartCommandFlags(kpApp *kingpin.Application, cmd *kingpin.CmdClause)
 			StringVar(&Namespace)
 	}
 
+	cmd.Flag("prometheus-label-cardinality-limit", "A maximum number of distinct values of labels from --prometheus-label-cardinality-labels in built-in metrics. New values above the limit are reported as 'other'. Zero means no limit. Can be set with $SHELL_OPERATOR_PROMETHEUS_LABEL_CARDINALITY_LIMIT.").
+		Envar("SHELL_OPERATOR_PROMETHEUS_LABEL_CARDINALITY_LIMIT").
+		Default(strconv.Itoa(PrometheusLabelCardinalityLimit)).
+		IntVar(&PrometheusLabelCardinalityLimit)
+
+	cmd.Flag("prometheus-label-cardinality-labels", "Labels of built-in metrics to limit with --prometheus-label-cardinality-limit. Can be repeated or set as a comma-separated list with $SHELL_OPERATOR_PROMETHEUS_LABEL_CARDINALITY_LABELS.").
+		Envar("SHELL_OPERATOR_PROMETHEUS_LABEL_CARDINALITY_LABELS").
+		Default(PrometheusLabelCardinalityLabels...).
+		StringsVar(&PrometheusLabelCardinalityLabels)
+
+	cmd.Flag("hook-metrics-counters-file", "A path of the file to save counters of hooks and to restore them on start, so counters are not reset by restarts. Use a persistent volume. Empty means counters are not saved. Can be set with $SHELL_OPERATOR_HOOK_METRICS_COUNTERS_FILE.").
+		Envar("SHELL_OPERATOR_HOOK_METRICS_COUNTERS_FILE").
+		Default(HookMetricsCountersFile).
+		StringVar(&HookMetricsCountersFile)
+
+	cmd.Flag("hook-metrics-counters-save-interval", "An interval to save counters of hooks into --hook-metrics-counters-file. Counters are also saved on shutdown. Can be set with $SHELL_OPERATOR_HOOK_METRICS_COUNTERS_SAVE_INTERVAL.").
+		Envar("SHELL_OPERATOR_HOOK_METRICS_COUNTERS_SAVE_INTERVAL").
+		Default(HookMetricsCountersSaveInterval.String()).
+		DurationVar(&HookMetricsCountersSaveInterval)
+
+	cmd.Flag("status-page-basic-auth", "Credentials 'user:password' to protect the status page on /status with the basic auth. Can be set with $SHELL_OPERATOR_STATUS_PAGE_BASIC_AUTH.").
+		Envar("SHELL_OPERATOR_STATUS_PAGE_BASIC_AUTH").
+		Default(StatusPageBasicAuth).
+		StringVar(&StatusPageBasicAuth)
+
+	cmd.Flag("listen-socket", "A path of the Unix domain socket to use for HTTP serving instead of the listen address and port. Use 'systemd' to serve on a socket passed with systemd socket activation. Can be set with $SHELL_OPERATOR_LISTEN_SOCKET.").
+		Envar("SHELL_OPERATOR_LISTEN_SOCKET").
+		Default(ListenSocket).
+		StringVar(&ListenSocket)
+
+	cmd.Flag("cluster-name", "A name of the cluster passed to hooks in the 'cluster' field of binding contexts. Can be set with $SHELL_OPERATOR_CLUSTER_NAME.").
+		Envar("SHELL_OPERATOR_CLUSTER_NAME").
+		Default(ClusterName).
+		StringVar(&ClusterName)
+
+	cmd.Flag("cluster-id", "A unique id of the cluster passed to hooks in the 'cluster' field of binding contexts. Can be set with $SHELL_OPERATOR_CLUSTER_ID.").
+		Envar("SHELL_OPERATOR_CLUSTER_ID").
+		Default(ClusterID).
+		StringVar(&ClusterID)
+
+	cmd.Flag("read-only", "Log and count object patches, webhook configurations management and Events creation, but do not execute them. Use it to observe a new hooks bundle safely. Can be set with $SHELL_OPERATOR_READ_ONLY.").
+		Envar("SHELL_OPERATOR_READ_ONLY").
+		BoolVar(&ReadOnly)
+
+	cmd.Flag("queue-fair-scheduling", "Names of queues to execute tasks of different hooks in round-robin order instead of FIFO, so a hook with many events does not starve other hooks. Use '*' for all queues. Can be repeated or set as a comma-separated list with $QUEUE_FAIR_SCHEDULING.").
+		Envar("QUEUE_FAIR_SCHEDULING").
+		StringsVar(&QueueFairScheduling)
+
+	DefineConfigFileFlag(cmd)
 	DefineKubeClientFlags(cmd)
 	DefineValidatingWebhookFlags(cmd)
 	DefineConversionWebhookFlags(cmd)
 	DefineJqFlags(cmd)
+	DefineHookFlags(cmd)
+	DefineHookBundleFlags(cmd)
 	DefineLoggingFlags(cmd)
+	DefineRuntimeFlags(cmd)
+	DefineAlertFlags(cmd)
+	DefineLockFlags(cmd)
+	DefineHTTPFlags(cmd)
 	DefineDebugFlags(kpApp, cmd)
 }
 
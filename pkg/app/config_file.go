@@ -0,0 +1,247 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/flant/shell-operator/pkg/config"
+)
+
+const ConfigFileEnvar = "SHELL_OPERATOR_CONFIG"
+
+var (
+	ConfigFile           = ""
+	ConfigReloadInterval = 10 * time.Second
+)
+
+// reloadableFlags maps flags that are safe to change without restart
+// to runtime config parameters.
+var reloadableFlags = map[string]string{
+	"log-level": "log.level",
+}
+
+// configFileFlags are flags which values were taken from the config file.
+var configFileFlags = map[string]struct{}{}
+
+// DefineConfigFileFlag defines the flag to load an operator configuration file.
+func DefineConfigFileFlag(cmd *kingpin.CmdClause) {
+	cmd.Flag("config", "A path to the YAML file with operator configuration. Keys are flag names, nested keys are joined with '-'. Command line flags and environment variables take precedence. Only log-level is reloaded on file change, other options require a restart. Can be set with $SHELL_OPERATOR_CONFIG.").
+		Envar(ConfigFileEnvar).
+		Default(ConfigFile).
+		StringVar(&ConfigFile)
+}
+
+// ApplyConfigFile reads the configuration file passed via --config flag or $SHELL_OPERATOR_CONFIG
+// and sets its values as defaults of the command flags, so kingpin uses them
+// when no command line flag or environment variable is set. The environment
+// of the process is not changed.
+// It should be called before kpApp.Parse.
+func ApplyConfigFile(kpApp *kingpin.Application, cmdName string, args []string) error {
+	path := configFilePath(args)
+	if path == "" {
+		return nil
+	}
+
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	cmd := kpApp.GetCommand(cmdName)
+	if cmd == nil {
+		return fmt.Errorf("command '%s' is not defined", cmdName)
+	}
+	envars := map[string]string{}
+	for _, flag := range cmd.Model().Flags {
+		envars[flag.Name] = flag.Envar
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		envar, ok := envars[key]
+		if !ok || key == "config" {
+			return fmt.Errorf("config file '%s': unknown option '%s'", path, key)
+		}
+		// A default is used by kingpin only if the flag and its envar are not set.
+		cmd.GetFlag(key).Default(values[key]...)
+		if flagInArgs(key, args) {
+			continue
+		}
+		if _, has := os.LookupEnv(envar); envar != "" && has {
+			continue
+		}
+		configFileFlags[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// WatchConfigFile periodically re-reads the configuration file and applies
+// changes of reloadable options to the runtime config.
+func WatchConfigFile(runtimeConfig *config.Config) {
+	if ConfigFile == "" {
+		return
+	}
+
+	go func() {
+		var lastModTime time.Time
+		if info, err := os.Stat(ConfigFile); err == nil {
+			lastModTime = info.ModTime()
+		}
+
+		for {
+			time.Sleep(ConfigReloadInterval)
+
+			info, err := os.Stat(ConfigFile)
+			if err != nil {
+				log.Warnf("Config file '%s' is not accessible: %v", ConfigFile, err)
+				continue
+			}
+			if !info.ModTime().After(lastModTime) {
+				continue
+			}
+			lastModTime = info.ModTime()
+
+			if err := reloadConfigFile(runtimeConfig); err != nil {
+				log.Errorf("Reload config file '%s': %v", ConfigFile, err)
+			}
+		}
+	}()
+}
+
+func reloadConfigFile(runtimeConfig *config.Config) error {
+	values, err := readConfigFile(ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	for key, items := range values {
+		param, ok := reloadableFlags[key]
+		if !ok {
+			continue
+		}
+		// Command line and environment have precedence over the file.
+		if _, fromFile := configFileFlags[key]; !fromFile {
+			continue
+		}
+		value := strings.Join(items, "\n")
+		if err := runtimeConfig.IsValid(param, value); err != nil {
+			return fmt.Errorf("option '%s': %v", key, err)
+		}
+		if runtimeConfig.Value(param) == value {
+			continue
+		}
+		log.Infof("Config file changed: set '%s' to '%s'", key, value)
+		runtimeConfig.Set(param, value)
+	}
+
+	return nil
+}
+
+// readConfigFile returns flattened values from the YAML configuration file.
+// Lists are values of repeatable flags.
+func readConfigFile(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file '%s': %v", path, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse config file '%s': %v", path, err)
+	}
+
+	values := map[string][]string{}
+	if err := flattenConfig("", raw, values); err != nil {
+		return nil, fmt.Errorf("config file '%s': %v", path, err)
+	}
+	return values, nil
+}
+
+// flattenConfig joins nested keys with '-' to get flag names:
+//
+//	validating-webhook:
+//	  service-name: name
+//
+// is the same as 'validating-webhook-service-name: name'.
+func flattenConfig(prefix string, in map[string]interface{}, out map[string][]string) error {
+	for key, value := range in {
+		name := key
+		if prefix != "" {
+			name = prefix + "-" + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(name, v, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				s, err := configValueString(item)
+				if err != nil {
+					return fmt.Errorf("option '%s': %v", name, err)
+				}
+				items = append(items, s)
+			}
+			out[name] = items
+		default:
+			s, err := configValueString(v)
+			if err != nil {
+				return fmt.Errorf("option '%s': %v", name, err)
+			}
+			out[name] = []string{s}
+		}
+	}
+	return nil
+}
+
+func configValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// configFilePath returns a path from --config flag or from the environment.
+func configFilePath(args []string) string {
+	for i, arg := range args {
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--config=") {
+			return strings.TrimPrefix(arg, "--config=")
+		}
+	}
+	return os.Getenv(ConfigFileEnvar)
+}
+
+func flagInArgs(name string, args []string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || arg == "--no-"+name || strings.HasPrefix(arg, "--"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type testFlags struct {
+	hooksDir   string
+	logLevel   string
+	listenPort string
+	clientCA   []string
+}
+
+func newTestApp() (*kingpin.Application, *testFlags) {
+	kpApp := kingpin.New("test", "")
+	cmd := kpApp.Command("start", "").Default()
+	DefineConfigFileFlag(cmd)
+	flags := &testFlags{}
+	cmd.Flag("hooks-dir", "").Envar("TEST_HOOKS_DIR").Default("hooks").StringVar(&flags.hooksDir)
+	cmd.Flag("log-level", "").Envar("TEST_LOG_LEVEL").Default("info").StringVar(&flags.logLevel)
+	cmd.Flag("listen-port", "").Default("9115").StringVar(&flags.listenPort)
+	cmd.Flag("webhook-client-ca", "").Envar("TEST_WEBHOOK_CLIENT_CA").StringsVar(&flags.clientCA)
+	return kpApp, flags
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func Test_ApplyConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+hooks-dir: /hooks
+log:
+  level: debug
+listen-port: 9200
+webhook:
+  client-ca:
+  - /ca1.crt
+  - /ca2.crt
+`)
+	t.Setenv("TEST_HOOKS_DIR", "/env-hooks")
+
+	kpApp, flags := newTestApp()
+	args := []string{"start", "--config", path}
+
+	require.NoError(t, ApplyConfigFile(kpApp, "start", args))
+	_, err := kpApp.Parse(args)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/env-hooks", flags.hooksDir, "environment should take precedence over the file")
+	assert.Equal(t, "debug", flags.logLevel)
+	assert.Equal(t, "9200", flags.listenPort, "flags without envar should be set from the file")
+	assert.Equal(t, []string{"/ca1.crt", "/ca2.crt"}, flags.clientCA)
+
+	_, has := os.LookupEnv("TEST_LOG_LEVEL")
+	assert.False(t, has, "file values should not be exported to the environment")
+}
+
+func Test_ApplyConfigFile_ArgsPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "log-level: debug\n")
+
+	kpApp, flags := newTestApp()
+	args := []string{"start", "--config", path, "--log-level", "error"}
+
+	require.NoError(t, ApplyConfigFile(kpApp, "start", args))
+	_, err := kpApp.Parse(args)
+	require.NoError(t, err)
+
+	assert.Equal(t, "error", flags.logLevel)
+}
+
+func Test_ApplyConfigFile_UnknownKey(t *testing.T) {
+	path := writeConfigFile(t, "unknown-option: value\n")
+
+	kpApp, _ := newTestApp()
+	err := ApplyConfigFile(kpApp, "start", []string{"--config=" + path})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown-option")
+}
@@ -2,6 +2,8 @@ package app
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -14,6 +16,19 @@ var DebugKeepTmpFiles = "no"
 
 var DebugKubernetesAPI = false
 
+var DebugKubeEventHistory = 0
+
+var DebugTraceSpans = false
+
+// Fault injection settings for testing of retries, allowFailure and alerts.
+var (
+	DebugFaultHookFailureRate = 0.0
+	DebugFaultHookDelayRate   = 0.0
+	DebugFaultHookDelay       = 10 * time.Second
+	DebugFaultAPIErrorRate    = 0.0
+	DebugFaultHooks           = make([]string, 0)
+)
+
 // DefineDebugFlags init global command line flags for debug.
 func DefineDebugFlags(kpApp *kingpin.Application, cmd *kingpin.CmdClause) {
 	DefineDebugUnixSocketFlag(cmd)
@@ -36,6 +51,47 @@ func DefineDebugFlags(kpApp *kingpin.Application, cmd *kingpin.CmdClause) {
 		Default("false").
 		BoolVar(&DebugKubernetesAPI)
 
+	cmd.Flag("debug-kube-event-history", "a number of last events to keep for each monitored object, 0 disables the history").
+		Envar("DEBUG_KUBE_EVENT_HISTORY").
+		Hidden().
+		Default(strconv.Itoa(DebugKubeEventHistory)).
+		IntVar(&DebugKubeEventHistory)
+
+	cmd.Flag("debug-trace-spans", "write spans of hook runs and Kubernetes operations to the log").
+		Envar("DEBUG_TRACE_SPANS").
+		Hidden().
+		Default("false").
+		BoolVar(&DebugTraceSpans)
+
+	cmd.Flag("debug-fault-hook-failure-rate", "a probability from 0 to 1 to fail the hook run without executing the hook").
+		Envar("DEBUG_FAULT_HOOK_FAILURE_RATE").
+		Hidden().
+		Default("0").
+		Float64Var(&DebugFaultHookFailureRate)
+
+	cmd.Flag("debug-fault-hook-delay-rate", "a probability from 0 to 1 to delay the hook run").
+		Envar("DEBUG_FAULT_HOOK_DELAY_RATE").
+		Hidden().
+		Default("0").
+		Float64Var(&DebugFaultHookDelayRate)
+
+	cmd.Flag("debug-fault-hook-delay", "a delay for the hook run if it is injected").
+		Envar("DEBUG_FAULT_HOOK_DELAY").
+		Hidden().
+		Default(DebugFaultHookDelay.String()).
+		DurationVar(&DebugFaultHookDelay)
+
+	cmd.Flag("debug-fault-api-error-rate", "a probability from 0 to 1 to fail Kubernetes operations returned by the hook").
+		Envar("DEBUG_FAULT_API_ERROR_RATE").
+		Hidden().
+		Default("0").
+		Float64Var(&DebugFaultAPIErrorRate)
+
+	cmd.Flag("debug-fault-hooks", "inject faults only for these hooks, all hooks are affected if not set").
+		Envar("DEBUG_FAULT_HOOKS").
+		Hidden().
+		StringsVar(&DebugFaultHooks)
+
 	// A command to show help about hidden debug-* flags
 	kpApp.Command("debug-options", "Show help for debug flags of a start command.").Hidden().PreAction(func(_ *kingpin.ParseContext) error {
 		context, err := kpApp.ParseContext([]string{"start"})
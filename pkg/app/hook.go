@@ -0,0 +1,135 @@
+package app
+
+import (
+	"strconv"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// HookResourceMetrics enables detailed resource accounting for hook executions.
+var HookResourceMetrics = false
+
+// HookOutputLimit is a maximum size in bytes of stdout and stderr of the hook run. Zero means no limit.
+var HookOutputLimit int64 = 0
+
+// HookConfigCacheDir is a directory to cache hook configs between restarts. Cache is disabled if empty.
+var HookConfigCacheDir = ""
+
+// HookConfigConcurrency is a number of hooks executed with --config at the same time on startup.
+// Hooks are executed one by one by default.
+var HookConfigConcurrency = 1
+
+// HookConfigTimeout limits the execution of 'hook --config'. Zero means no timeout.
+var HookConfigTimeout time.Duration = 0
+
+// HookKubeconfig enables generation of a kubeconfig for hooks.
+var HookKubeconfig = true
+
+// EnableHooks and DisableHooks are glob patterns for hook names to load on startup.
+var (
+	EnableHooks  = make([]string, 0)
+	DisableHooks = make([]string, 0)
+)
+
+// HookRunHistorySize is a number of last runs to keep for each hook.
+var HookRunHistorySize = 10
+
+// HooksChecksums is a manifest of SHA256 checksums of hook files. Hooks are not verified if empty.
+var HooksChecksums = ""
+
+// HooksChecksumsPublicKey is a PEM public key to verify the detached signature of HooksChecksums.
+var HooksChecksumsPublicKey = ""
+
+// ShadowHooksDir is a directory with shadow versions of hooks. Shadow hooks are disabled if empty.
+var ShadowHooksDir = ""
+
+// HookRecordDir is a directory to record inputs and outputs of hook runs. Runs are not recorded if empty.
+var HookRecordDir = ""
+
+// HookRecordMaxRuns is a number of last recorded runs to keep for each hook.
+var HookRecordMaxRuns = 100
+
+// Settings of the error budget: a hook that fails HookErrorBudget times within HookErrorBudgetWindow
+// is quarantined for HookQuarantineDuration. Zero budget disables quarantine.
+var (
+	HookErrorBudget        = 0
+	HookErrorBudgetWindow  = 10 * time.Minute
+	HookQuarantineDuration = 10 * time.Minute
+)
+
+// HookProfile is a name of the profile to select hooks on startup. Profiles are ignored if empty.
+var HookProfile = ""
+
+// DefineHookFlags defines flags for hook executions.
+func DefineHookFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("hook-resource-metrics", "Expose per-hook CPU seconds and I/O counters collected with getrusage. Can be set with $HOOK_RESOURCE_METRICS.").
+		Envar("HOOK_RESOURCE_METRICS").
+		BoolVar(&HookResourceMetrics)
+	cmd.Flag("hook-output-limit", "A maximum size in bytes of stdout and of stderr captured from each hook run. The rest of the output is discarded. Zero means no limit. Can be set with $HOOK_OUTPUT_LIMIT.").
+		Envar("HOOK_OUTPUT_LIMIT").
+		Default("0").
+		Int64Var(&HookOutputLimit)
+	cmd.Flag("hook-config-cache-dir", "A directory to cache outputs of 'hook --config' between restarts. Hooks are executed with --config only if the hook file is changed. Cache is disabled if empty. Can be set with $HOOK_CONFIG_CACHE_DIR.").
+		Envar("HOOK_CONFIG_CACHE_DIR").
+		Default(HookConfigCacheDir).
+		StringVar(&HookConfigCacheDir)
+	cmd.Flag("hook-config-concurrency", "A number of hooks executed with --config at the same time on startup. Default is 1: hooks are executed one by one. Can be set with $HOOK_CONFIG_CONCURRENCY.").
+		Envar("HOOK_CONFIG_CONCURRENCY").
+		Default("1").
+		IntVar(&HookConfigConcurrency)
+	cmd.Flag("hook-config-timeout", "A timeout for 'hook --config' execution. Zero means no timeout. Can be set with $HOOK_CONFIG_TIMEOUT.").
+		Envar("HOOK_CONFIG_TIMEOUT").
+		Default("0s").
+		DurationVar(&HookConfigTimeout)
+	cmd.Flag("hook-kubeconfig", "Generate a kubeconfig with the operator's service account and pass it to hooks as $KUBECONFIG. It is not generated outside the cluster or if $KUBECONFIG is set. Can be set with $HOOK_KUBECONFIG.").
+		Envar("HOOK_KUBECONFIG").
+		Default("true").
+		BoolVar(&HookKubeconfig)
+	cmd.Flag("enable-hooks", "Glob patterns for hook names to load, e.g. '002-monitoring/*'. Other hooks are ignored. All hooks are loaded if not set. Can be repeated or set as a comma-separated list with $ENABLE_HOOKS.").
+		Envar("ENABLE_HOOKS").
+		StringsVar(&EnableHooks)
+	cmd.Flag("disable-hooks", "Glob patterns for hook names to ignore. It takes precedence over --enable-hooks. Can be repeated or set as a comma-separated list with $DISABLE_HOOKS.").
+		Envar("DISABLE_HOOKS").
+		StringsVar(&DisableHooks)
+	cmd.Flag("hook-run-history-size", "A number of last runs to keep for each hook. Runs are available with 'shell-operator hook runs'. Can be set with $HOOK_RUN_HISTORY_SIZE.").
+		Envar("HOOK_RUN_HISTORY_SIZE").
+		Default("10").
+		IntVar(&HookRunHistorySize)
+	cmd.Flag("hooks-checksums", "A file with SHA256 checksums of hook files in the 'sha256sum' format. Hooks not listed in the file or modified are refused. Can be set with $HOOKS_CHECKSUMS.").
+		Envar("HOOKS_CHECKSUMS").
+		Default(HooksChecksums).
+		StringVar(&HooksChecksums)
+	cmd.Flag("hooks-checksums-public-key", "A path to the PEM public key. If set, the checksums file should have a valid signature in the file with the '.sig' suffix. Can be set with $HOOKS_CHECKSUMS_PUBLIC_KEY.").
+		Envar("HOOKS_CHECKSUMS_PUBLIC_KEY").
+		Default(HooksChecksumsPublicKey).
+		StringVar(&HooksChecksumsPublicKey)
+	cmd.Flag("profile", "A name of the hook profile. Only hooks of this profile and hooks without profiles are loaded. All hooks are loaded if empty. Can be set with $SHELL_OPERATOR_PROFILE.").
+		Envar("SHELL_OPERATOR_PROFILE").
+		Default(HookProfile).
+		StringVar(&HookProfile)
+	cmd.Flag("hook-record-dir", "A directory to record inputs and outputs of hook runs as test fixtures. Recorded runs are replayed with 'shell-operator verify-goldens'. Runs are not recorded if empty. Can be set with $HOOK_RECORD_DIR.").
+		Envar("HOOK_RECORD_DIR").
+		Default(HookRecordDir).
+		StringVar(&HookRecordDir)
+	cmd.Flag("hook-record-max-runs", "A number of last recorded runs to keep for each hook. Zero means no limit. Can be set with $HOOK_RECORD_MAX_RUNS.").
+		Envar("HOOK_RECORD_MAX_RUNS").
+		Default("100").
+		IntVar(&HookRecordMaxRuns)
+	cmd.Flag("hook-error-budget", "A number of failed runs of the hook within --hook-error-budget-window to quarantine the hook: its tasks are not queued until the quarantine ends. Zero disables quarantine. Can be set with $HOOK_ERROR_BUDGET.").
+		Envar("HOOK_ERROR_BUDGET").
+		Default(strconv.Itoa(HookErrorBudget)).
+		IntVar(&HookErrorBudget)
+	cmd.Flag("hook-error-budget-window", "A window to count failed runs of the hook. Can be set with $HOOK_ERROR_BUDGET_WINDOW.").
+		Envar("HOOK_ERROR_BUDGET_WINDOW").
+		Default(HookErrorBudgetWindow.String()).
+		DurationVar(&HookErrorBudgetWindow)
+	cmd.Flag("hook-quarantine-duration", "A cool-off period for the hook with exhausted error budget. Can be set with $HOOK_QUARANTINE_DURATION.").
+		Envar("HOOK_QUARANTINE_DURATION").
+		Default(HookQuarantineDuration.String()).
+		DurationVar(&HookQuarantineDuration)
+	cmd.Flag("shadow-hooks-dir", "A directory with shadow versions of hooks. A shadow hook runs after the primary hook with the same name and the same binding context. Its Kubernetes patches are only compared with the primary hook's patches and never applied. Can be set with $SHADOW_HOOKS_DIR.").
+		Envar("SHADOW_HOOKS_DIR").
+		Default(ShadowHooksDir).
+		StringVar(&ShadowHooksDir)
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// HooksOCIRef is a reference to the OCI artifact with hooks. Hooks are loaded from --hooks-dir if empty.
+var HooksOCIRef = ""
+
+// Credentials and parameters to pull the hooks bundle.
+var (
+	HooksOCIUsername  = ""
+	HooksOCIPassword  = ""
+	HooksOCIInsecure  = false
+	HooksOCIPublicKey = ""
+	HooksOCITimeout   = 5 * time.Minute
+)
+
+// DefineHookBundleFlags defines flags to pull hooks from the OCI registry.
+func DefineHookBundleFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("hooks-oci-ref", "A reference to the OCI artifact with hooks, e.g. 'registry.example.com/hooks/bundle:v1' or '...@sha256:<digest>'. The bundle is pulled on startup and used instead of --hooks-dir. Can be set with $HOOKS_OCI_REF.").
+		Envar("HOOKS_OCI_REF").
+		Default(HooksOCIRef).
+		StringVar(&HooksOCIRef)
+	cmd.Flag("hooks-oci-username", "A username for the registry with the hooks bundle. Can be set with $HOOKS_OCI_USERNAME.").
+		Envar("HOOKS_OCI_USERNAME").
+		Default(HooksOCIUsername).
+		StringVar(&HooksOCIUsername)
+	cmd.Flag("hooks-oci-password", "A password or a token for the registry with the hooks bundle. Can be set with $HOOKS_OCI_PASSWORD.").
+		Envar("HOOKS_OCI_PASSWORD").
+		Default(HooksOCIPassword).
+		StringVar(&HooksOCIPassword)
+	cmd.Flag("hooks-oci-insecure", "Use plain HTTP to pull the hooks bundle. Can be set with $HOOKS_OCI_INSECURE.").
+		Envar("HOOKS_OCI_INSECURE").
+		BoolVar(&HooksOCIInsecure)
+	cmd.Flag("hooks-oci-public-key", "A path to the PEM public key. If set, the hooks bundle should have a valid cosign signature. Can be set with $HOOKS_OCI_PUBLIC_KEY.").
+		Envar("HOOKS_OCI_PUBLIC_KEY").
+		Default(HooksOCIPublicKey).
+		StringVar(&HooksOCIPublicKey)
+	cmd.Flag("hooks-oci-timeout", "A timeout to pull the hooks bundle. Can be set with $HOOKS_OCI_TIMEOUT.").
+		Envar("HOOKS_OCI_TIMEOUT").
+		Default(HooksOCITimeout.String()).
+		DurationVar(&HooksOCITimeout)
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// CORS rules for HTTP endpoints. Lists are comma-separated.
+var (
+	CorsAllowedOrigins = ""
+	CorsAllowedMethods = "GET,POST"
+	CorsAllowedHeaders = "Content-Type,Authorization"
+)
+
+// DefineHTTPFlags defines flags for HTTP endpoints.
+func DefineHTTPFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("cors-allowed-origins", "A comma-separated list of origins allowed to call HTTP endpoints from a browser, or '*' to allow any origin. Cross-origin requests are not allowed if empty. Can be set with $SHELL_OPERATOR_CORS_ALLOWED_ORIGINS.").
+		Envar("SHELL_OPERATOR_CORS_ALLOWED_ORIGINS").
+		Default(CorsAllowedOrigins).
+		StringVar(&CorsAllowedOrigins)
+	cmd.Flag("cors-allowed-methods", "A comma-separated list of methods allowed for cross-origin requests. Can be set with $SHELL_OPERATOR_CORS_ALLOWED_METHODS.").
+		Envar("SHELL_OPERATOR_CORS_ALLOWED_METHODS").
+		Default(CorsAllowedMethods).
+		StringVar(&CorsAllowedMethods)
+	cmd.Flag("cors-allowed-headers", "A comma-separated list of request headers allowed for cross-origin requests. Can be set with $SHELL_OPERATOR_CORS_ALLOWED_HEADERS.").
+		Envar("SHELL_OPERATOR_CORS_ALLOWED_HEADERS").
+		Default(CorsAllowedHeaders).
+		StringVar(&CorsAllowedHeaders)
+}
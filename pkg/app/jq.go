@@ -1,13 +1,25 @@
 package app
 
-import "gopkg.in/alecthomas/kingpin.v2"
+import (
+	"strconv"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
 
 var JqLibraryPath = ""
 
+// JqFilterCacheSize is a number of cached jqFilter results. 0 disables the cache.
+var JqFilterCacheSize = 10000
+
 // DefineJqFlags set flag for jq library
 func DefineJqFlags(cmd *kingpin.CmdClause) {
 	cmd.Flag("jq-library-path", "Prepend directory to the search list for jq modules (-L flag). Can be set with $JQ_LIBRARY_PATH.").
 		Envar("JQ_LIBRARY_PATH").
 		Default(JqLibraryPath).
 		StringVar(&JqLibraryPath)
+
+	cmd.Flag("jq-filter-cache-size", "A number of jqFilter results cached by object's UID and resourceVersion. 0 disables the cache. Can be set with $JQ_FILTER_CACHE_SIZE.").
+		Envar("JQ_FILTER_CACHE_SIZE").
+		Default(strconv.Itoa(JqFilterCacheSize)).
+		IntVar(&JqFilterCacheSize)
 }
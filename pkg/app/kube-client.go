@@ -1,9 +1,12 @@
 package app
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
@@ -28,6 +31,70 @@ var (
 	ObjectPatcherKubeClientTimeout        time.Duration
 )
 
+// Settings to detect and smooth bursts of events after mass watch re-establishment.
+var (
+	KubeRelistStormThreshold = 0
+	KubeRelistStormWindow    = time.Minute
+	KubeRelistJitter         = time.Duration(0)
+)
+
+// Settings to smooth the load on the API server when many bindings start at once.
+var (
+	KubeMaxConcurrentInitialLists = 0
+	KubeMonitorStartBatchSize     = 0
+	KubeMonitorStartBatchDelay    = time.Duration(0)
+)
+
+// Default informer settings. Bindings can override them. Zero values mean client-go defaults,
+// the resync period is randomized between 2 and 4 hours by default.
+var (
+	KubeInformerResyncPeriod = time.Duration(0)
+	KubeListPageSize         = 0
+	KubeWatchTimeout         = time.Duration(0)
+)
+
+// KubeSnapshotMemoryLimit is a budget for full objects in snapshots, e.g. "512Mi". "0" disables the budget.
+var KubeSnapshotMemoryLimit = "0"
+
+// Orders of objects in snapshots and Synchronization binding contexts.
+const (
+	KubeSnapshotOrderNamespaceName = "namespace-name"
+	KubeSnapshotOrderNone          = "none"
+)
+
+// KubeSnapshotOrder is an order of objects in snapshots.
+var KubeSnapshotOrder = KubeSnapshotOrderNamespaceName
+
+// Backends to store snapshots.
+const (
+	KubeSnapshotStorageMemory = "memory"
+	KubeSnapshotStorageEtcd   = "etcd"
+)
+
+// Settings of the snapshot storage shared by operator replicas.
+var (
+	KubeSnapshotStorage         = KubeSnapshotStorageMemory
+	KubeSnapshotStorageEndpoint = ""
+	KubeSnapshotStoragePrefix   = "/shell-operator/snapshots/"
+	KubeSnapshotStorageTimeout  = 5 * time.Second
+)
+
+// Modes to log warnings returned by the Kubernetes API server.
+const (
+	KubeAPIWarningsLogOnce = "log-once"
+	KubeAPIWarningsLog     = "log"
+	KubeAPIWarningsSilent  = "silent"
+)
+
+// KubeAPIWarnings is a mode to log API warnings, e.g. about deprecated apiVersions.
+var KubeAPIWarnings = KubeAPIWarningsLogOnce
+
+// Settings to retry bindings failed with Forbidden errors.
+var (
+	KubeForbiddenRetry         = true
+	KubeForbiddenRetryMaxDelay = 5 * time.Minute
+)
+
 func DefineKubeClientFlags(cmd *kingpin.CmdClause) {
 	// Settings for Kubernetes connection.
 	cmd.Flag("kube-context", "The name of the kubeconfig context to use. Can be set with $KUBE_CONTEXT.").
@@ -66,4 +133,97 @@ func DefineKubeClientFlags(cmd *kingpin.CmdClause) {
 		Envar("OBJECT_PATCHER_KUBE_CLIENT_TIMEOUT").
 		Default(ObjectPatcherKubeClientTimeoutDefault).
 		DurationVar(&ObjectPatcherKubeClientTimeout)
+
+	// Settings for relist storms.
+	cmd.Flag("kube-relist-storm-threshold", "A number of failed watches within the storm window to detect a relist storm. Zero disables detection. Can be set with $KUBE_RELIST_STORM_THRESHOLD.").
+		Envar("KUBE_RELIST_STORM_THRESHOLD").
+		Default(strconv.Itoa(KubeRelistStormThreshold)).
+		IntVar(&KubeRelistStormThreshold)
+	cmd.Flag("kube-relist-storm-window", "A window to count failed watches and a duration of the relist storm. Can be set with $KUBE_RELIST_STORM_WINDOW.").
+		Envar("KUBE_RELIST_STORM_WINDOW").
+		Default(KubeRelistStormWindow.String()).
+		DurationVar(&KubeRelistStormWindow)
+	cmd.Flag("kube-relist-jitter", "A maximum delay to spread events across bindings during the relist storm. Can be set with $KUBE_RELIST_JITTER.").
+		Envar("KUBE_RELIST_JITTER").
+		Default(KubeRelistJitter.String()).
+		DurationVar(&KubeRelistJitter)
+
+	// Settings for start-up of many bindings.
+	cmd.Flag("kube-max-concurrent-initial-lists", "A maximum number of concurrent initial LIST requests of bindings. Zero means no limit. Can be set with $KUBE_MAX_CONCURRENT_INITIAL_LISTS.").
+		Envar("KUBE_MAX_CONCURRENT_INITIAL_LISTS").
+		Default(strconv.Itoa(KubeMaxConcurrentInitialLists)).
+		IntVar(&KubeMaxConcurrentInitialLists)
+	cmd.Flag("kube-monitor-start-batch-size", "A number of bindings to start informers for before waiting for --kube-monitor-start-batch-delay. Zero disables staggering. Can be set with $KUBE_MONITOR_START_BATCH_SIZE.").
+		Envar("KUBE_MONITOR_START_BATCH_SIZE").
+		Default(strconv.Itoa(KubeMonitorStartBatchSize)).
+		IntVar(&KubeMonitorStartBatchSize)
+	cmd.Flag("kube-monitor-start-batch-delay", "A delay between batches of bindings to start informers for. Can be set with $KUBE_MONITOR_START_BATCH_DELAY.").
+		Envar("KUBE_MONITOR_START_BATCH_DELAY").
+		Default(KubeMonitorStartBatchDelay.String()).
+		DurationVar(&KubeMonitorStartBatchDelay)
+
+	// Informer timings.
+	cmd.Flag("kube-informer-resync-period", "A resync period of informers. Zero means a random period between 2 and 4 hours. Can be set with $KUBE_INFORMER_RESYNC_PERIOD.").
+		Envar("KUBE_INFORMER_RESYNC_PERIOD").
+		Default(KubeInformerResyncPeriod.String()).
+		DurationVar(&KubeInformerResyncPeriod)
+	cmd.Flag("kube-list-page-size", "A number of objects in one page of LIST requests of informers. Zero means client-go defaults. Can be set with $KUBE_LIST_PAGE_SIZE.").
+		Envar("KUBE_LIST_PAGE_SIZE").
+		Default(strconv.Itoa(KubeListPageSize)).
+		IntVar(&KubeListPageSize)
+	cmd.Flag("kube-watch-timeout", "A server-side timeout of WATCH requests of informers. Zero means a random timeout between 5 and 10 minutes. Can be set with $KUBE_WATCH_TIMEOUT.").
+		Envar("KUBE_WATCH_TIMEOUT").
+		Default(KubeWatchTimeout.String()).
+		DurationVar(&KubeWatchTimeout)
+
+	cmd.Flag("kube-snapshot-memory-limit", "A memory budget for full objects in snapshots, e.g. 512Mi. Full objects are evicted from the largest snapshots when the budget is exceeded. Can be set with $KUBE_SNAPSHOT_MEMORY_LIMIT.").
+		Envar("KUBE_SNAPSHOT_MEMORY_LIMIT").
+		Default(KubeSnapshotMemoryLimit).
+		StringVar(&KubeSnapshotMemoryLimit)
+	cmd.Flag("kube-snapshot-order", "An order of objects in snapshots and Synchronization binding contexts: 'namespace-name' sorts objects by namespace and name, 'none' disables sorting. Can be set with $KUBE_SNAPSHOT_ORDER.").
+		Envar("KUBE_SNAPSHOT_ORDER").
+		Default(KubeSnapshotOrder).
+		EnumVar(&KubeSnapshotOrder, KubeSnapshotOrderNamespaceName, KubeSnapshotOrderNone)
+
+	cmd.Flag("kube-snapshot-storage", "A backend to store snapshots: 'memory' keeps snapshots in the operator process, 'etcd' also saves them in etcd, so replicas reuse jqFilter results after failover. Objects are listed again in any case. Can be set with $KUBE_SNAPSHOT_STORAGE.").
+		Envar("KUBE_SNAPSHOT_STORAGE").
+		Default(KubeSnapshotStorage).
+		EnumVar(&KubeSnapshotStorage, KubeSnapshotStorageMemory, KubeSnapshotStorageEtcd)
+	cmd.Flag("kube-snapshot-storage-endpoint", "An address of the snapshot storage, e.g. http://etcd:2379 for etcd v3 gRPC gateway. Can be set with $KUBE_SNAPSHOT_STORAGE_ENDPOINT.").
+		Envar("KUBE_SNAPSHOT_STORAGE_ENDPOINT").
+		Default(KubeSnapshotStorageEndpoint).
+		StringVar(&KubeSnapshotStorageEndpoint)
+	cmd.Flag("kube-snapshot-storage-prefix", "A prefix for keys in the snapshot storage. Use different prefixes for different operators. Can be set with $KUBE_SNAPSHOT_STORAGE_PREFIX.").
+		Envar("KUBE_SNAPSHOT_STORAGE_PREFIX").
+		Default(KubeSnapshotStoragePrefix).
+		StringVar(&KubeSnapshotStoragePrefix)
+	cmd.Flag("kube-snapshot-storage-timeout", "A timeout for requests to the snapshot storage. Can be set with $KUBE_SNAPSHOT_STORAGE_TIMEOUT.").
+		Envar("KUBE_SNAPSHOT_STORAGE_TIMEOUT").
+		Default(KubeSnapshotStorageTimeout.String()).
+		DurationVar(&KubeSnapshotStorageTimeout)
+
+	// Warnings from the API server.
+	cmd.Flag("kube-api-warnings", "How to log warnings returned by the Kubernetes API server, e.g. about deprecated apiVersions: 'log-once' logs each unique warning once, 'log' logs every warning, 'silent' disables logging. Warnings are counted in metrics in all modes. Can be set with $KUBE_API_WARNINGS.").
+		Envar("KUBE_API_WARNINGS").
+		Default(KubeAPIWarnings).
+		EnumVar(&KubeAPIWarnings, KubeAPIWarningsLogOnce, KubeAPIWarningsLog, KubeAPIWarningsSilent)
+
+	// Bindings without RBAC permissions.
+	cmd.Flag("kube-forbidden-retry", "Start other bindings if a binding has no RBAC permissions and retry it in background until permissions are granted. Disable to fail the hook start-up as before. Can be set with $KUBE_FORBIDDEN_RETRY.").
+		Envar("KUBE_FORBIDDEN_RETRY").
+		Default(strconv.FormatBool(KubeForbiddenRetry)).
+		BoolVar(&KubeForbiddenRetry)
+	cmd.Flag("kube-forbidden-retry-max-delay", "A maximum delay between retries of a binding without RBAC permissions. Can be set with $KUBE_FORBIDDEN_RETRY_MAX_DELAY.").
+		Envar("KUBE_FORBIDDEN_RETRY_MAX_DELAY").
+		Default(KubeForbiddenRetryMaxDelay.String()).
+		DurationVar(&KubeForbiddenRetryMaxDelay)
+}
+
+// KubeSnapshotMemoryLimitBytes parses KubeSnapshotMemoryLimit.
+func KubeSnapshotMemoryLimitBytes() (int64, error) {
+	q, err := resource.ParseQuantity(KubeSnapshotMemoryLimit)
+	if err != nil {
+		return 0, fmt.Errorf("kube-snapshot-memory-limit '%s' is invalid: %v", KubeSnapshotMemoryLimit, err)
+	}
+	return q.Value(), nil
 }
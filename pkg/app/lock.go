@@ -0,0 +1,41 @@
+package app
+
+import (
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Settings of distributed locks for hooks and queues. Leases are created in
+// LockNamespace or in Namespace if it is empty.
+var (
+	LockNamespace     = ""
+	LockIdentity      = ""
+	LockLeaseDuration = 30 * time.Second
+	LockQueues        = make([]string, 0)
+	// LockKubeConfig is a kubeconfig of the cluster with Leases. The main Kubernetes client is used if empty.
+	LockKubeConfig = ""
+)
+
+// DefineLockFlags defines flags for distributed locks.
+func DefineLockFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("lock-namespace", "A namespace for Leases of distributed locks. The operator namespace is used if empty. Can be set with $LOCK_NAMESPACE.").
+		Envar("LOCK_NAMESPACE").
+		Default(LockNamespace).
+		StringVar(&LockNamespace)
+	cmd.Flag("lock-identity", "A unique name of the operator instance for Leases. The hostname with a random suffix is used if empty. Can be set with $LOCK_IDENTITY.").
+		Envar("LOCK_IDENTITY").
+		Default(LockIdentity).
+		StringVar(&LockIdentity)
+	cmd.Flag("lock-lease-duration", "A duration of Leases. The Lease of a crashed instance is taken by another instance after this duration. Can be set with $LOCK_LEASE_DURATION.").
+		Envar("LOCK_LEASE_DURATION").
+		Default(LockLeaseDuration.String()).
+		DurationVar(&LockLeaseDuration)
+	cmd.Flag("lock-queues", "Names of queues to run tasks only in one operator instance at a time. Use '*' for all queues. Can be repeated or set as a comma-separated list with $LOCK_QUEUES.").
+		Envar("LOCK_QUEUES").
+		StringsVar(&LockQueues)
+	cmd.Flag("lock-kubeconfig", "A path to the kubeconfig of the cluster with Leases to coordinate instances in different clusters. The operator's cluster is used if empty. Can be set with $LOCK_KUBECONFIG.").
+		Envar("LOCK_KUBECONFIG").
+		Default(LockKubeConfig).
+		StringVar(&LockKubeConfig)
+}
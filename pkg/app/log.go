@@ -32,13 +32,16 @@ func DefineLoggingFlags(cmd *kingpin.CmdClause) {
 		Envar("LOG_LEVEL").
 		Default(LogLevel).
 		StringVar(&LogLevel)
-	cmd.Flag("log-type", "Logging formatter type: json, text or color. Default is text. Can be set with $LOG_TYPE.").
+	cmd.Flag("log-type", "Logging formatter type: json, text, color, logfmt, ecs or otel. Default is text. Can be set with $LOG_TYPE.").
 		Envar("LOG_TYPE").
 		Default(LogType).
 		StringVar(&LogType)
 	cmd.Flag("log-no-time", "Disable timestamp logging if flag is present. Useful when output is redirected to logging system that already adds timestamps. Can be set with $LOG_NO_TIME.").
 		Envar("LOG_NO_TIME").
 		BoolVar(&LogNoTime)
+	cmd.Flag("log-sampling", "Log only the first and then every Nth info or debug entry of the component, e.g. 'taskRunner=10'. Use '*' for all components. Warnings and errors are always logged. Can be repeated or set as a comma-separated list with $LOG_SAMPLING.").
+		Envar("LOG_SAMPLING").
+		StringsVar(&LogSampling)
 	cmd.Flag("log-proxy-hook-json", "Delegate hook stdout/ stderr JSON logging to the hooks and act as a proxy that adds some extra fields before just printing the output").
 		Envar("LOG_PROXY_HOOK_JSON").
 		BoolVar(&LogProxyHookJSON)
@@ -46,24 +49,20 @@ func DefineLoggingFlags(cmd *kingpin.CmdClause) {
 
 // SetupLogging sets logger formatter and level.
 func SetupLogging(runtimeConfig *config.Config) {
-	jsonFormatter := log.JSONFormatter{DisableTimestamp: LogNoTime}
-	textFormatter := log.TextFormatter{DisableTimestamp: LogNoTime, DisableColors: true}
-	colorFormatter := log.TextFormatter{DisableTimestamp: LogNoTime, ForceColors: true, FullTimestamp: true}
-	switch strings.ToLower(LogType) {
-	case "json":
-		log.SetFormatter(&jsonFormatter)
-	case "text":
-		log.SetFormatter(&textFormatter)
-	case "color":
-		log.SetFormatter(&colorFormatter)
-	default:
-		log.SetFormatter(&jsonFormatter)
-	}
+	log.SetFormatter(newLogFormatter(strings.ToLower(LogType), LogNoTime))
 	if LogProxyHookJSON {
 		formatter := log.StandardLogger().Formatter
 		log.SetFormatter(&ProxyJsonWrapperFormatter{WrappedFormatter: formatter})
 	}
 
+	samplingFormatter := &SamplingFormatter{WrappedFormatter: log.StandardLogger().Formatter}
+	log.SetFormatter(samplingFormatter)
+	samplingRules, err := ParseLogSampling(LogSampling)
+	if err != nil {
+		log.Errorf("Log sampling is disabled: %v", err)
+	}
+	samplingFormatter.SetRules(samplingRules)
+
 	setLogLevel(LogLevel)
 
 	runtimeConfig.Register("log.level",
@@ -79,6 +78,24 @@ func SetupLogging(runtimeConfig *config.Config) {
 			}
 			return 0
 		})
+
+	registerLogSampling(runtimeConfig, samplingFormatter)
+}
+
+// registerLogSampling registers a runtime parameter to change log sampling rules.
+func registerLogSampling(runtimeConfig *config.Config, formatter *SamplingFormatter) {
+	runtimeConfig.Register("log.sampling",
+		"Log sampling rules 'component=N,...': log every Nth info or debug entry of the component",
+		strings.Join(LogSampling, ","),
+		func(oldValue string, newValue string) error {
+			rules, err := ParseLogSampling([]string{newValue})
+			if err != nil {
+				return err
+			}
+			log.Infof("Set log sampling to '%s'", newValue)
+			formatter.SetRules(rules)
+			return nil
+		}, nil)
 }
 
 func setLogLevel(logLevel string) {
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Log types with a stable field mapping for log shippers.
+const (
+	LogTypeLogfmt = "logfmt"
+	LogTypeECS    = "ecs"
+	LogTypeOTEL   = "otel"
+)
+
+// ECSVersion is a version of the Elastic Common Schema reported in the "ecs.version" field.
+const ECSVersion = "8.11.0"
+
+// stableFieldNames maps fields of operator log entries to names that do not clash
+// with fields of ECS and OpenTelemetry semantic conventions.
+var stableFieldNames = map[string]string{
+	"hook":               "shell_operator.hook",
+	"binding":            "shell_operator.binding",
+	"queue":              "shell_operator.queue",
+	"task":               "shell_operator.task",
+	"task.id":            "shell_operator.task.id",
+	"event":              "shell_operator.event",
+	"event.id":           "shell_operator.event.id",
+	"operator.component": "shell_operator.component",
+}
+
+// stableFieldName returns the mapped name or the name in the "shell_operator" namespace.
+func stableFieldName(name string) string {
+	if mapped, has := stableFieldNames[name]; has {
+		return mapped
+	}
+	return "shell_operator." + name
+}
+
+// fieldValue returns errors as strings, encoding/json marshals them as empty objects.
+func fieldValue(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+// ECSFormatter formats entries as JSON in the Elastic Common Schema.
+type ECSFormatter struct {
+	DisableTimestamp bool
+}
+
+func (f *ECSFormatter) Format(entry *log.Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+4)
+	for k, v := range entry.Data {
+		switch k {
+		case "trace.id", "span.id":
+			data[k] = v
+		case log.ErrorKey:
+			data["error.message"] = fieldValue(v)
+		default:
+			data[stableFieldName(k)] = fieldValue(v)
+		}
+	}
+	if !f.DisableTimestamp {
+		data["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	}
+	data["log.level"] = entry.Level.String()
+	data["message"] = entry.Message
+	data["ecs.version"] = ECSVersion
+
+	return marshalLogLine(data)
+}
+
+// OTELFormatter formats entries as JSON in the OpenTelemetry log data model.
+type OTELFormatter struct {
+	DisableTimestamp bool
+	// Resource describes the source of logs, e.g. "service.name".
+	Resource map[string]string
+}
+
+func (f *OTELFormatter) Format(entry *log.Entry) ([]byte, error) {
+	attributes := make(map[string]interface{}, len(entry.Data))
+	data := map[string]interface{}{
+		"SeverityText":   otelSeverityText(entry.Level),
+		"SeverityNumber": otelSeverityNumber(entry.Level),
+		"Body":           entry.Message,
+		"Resource":       f.Resource,
+		"Attributes":     attributes,
+	}
+	if !f.DisableTimestamp {
+		// Nanoseconds are a string as in OTLP/JSON: JSON numbers lose precision.
+		data["Timestamp"] = strconv.FormatInt(entry.Time.UnixNano(), 10)
+	}
+	for k, v := range entry.Data {
+		switch k {
+		case "trace.id":
+			data["TraceId"] = v
+		case "span.id":
+			data["SpanId"] = v
+		case log.ErrorKey:
+			attributes["exception.message"] = fieldValue(v)
+		default:
+			attributes[stableFieldName(k)] = fieldValue(v)
+		}
+	}
+
+	return marshalLogLine(data)
+}
+
+func otelSeverityText(level log.Level) string {
+	switch level {
+	case log.TraceLevel:
+		return "TRACE"
+	case log.DebugLevel:
+		return "DEBUG"
+	case log.InfoLevel:
+		return "INFO"
+	case log.WarnLevel:
+		return "WARN"
+	case log.ErrorLevel:
+		return "ERROR"
+	}
+	return "FATAL"
+}
+
+// otelSeverityNumber returns the first number of the severity range.
+func otelSeverityNumber(level log.Level) int {
+	switch level {
+	case log.TraceLevel:
+		return 1
+	case log.DebugLevel:
+		return 5
+	case log.InfoLevel:
+		return 9
+	case log.WarnLevel:
+		return 13
+	case log.ErrorLevel:
+		return 17
+	}
+	return 21
+}
+
+func marshalLogLine(data map[string]interface{}) ([]byte, error) {
+	b := new(bytes.Buffer)
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// newLogFormatter returns a formatter for the log type. JSON is used for unknown types.
+func newLogFormatter(logType string, noTime bool) log.Formatter {
+	switch logType {
+	case "text":
+		return &log.TextFormatter{DisableTimestamp: noTime, DisableColors: true}
+	case "color":
+		return &log.TextFormatter{DisableTimestamp: noTime, ForceColors: true, FullTimestamp: true}
+	case LogTypeLogfmt:
+		return &log.TextFormatter{
+			DisableTimestamp: noTime,
+			DisableColors:    true,
+			FullTimestamp:    true,
+			TimestampFormat:  time.RFC3339Nano,
+			QuoteEmptyFields: true,
+			FieldMap:         log.FieldMap{log.FieldKeyTime: "ts"},
+		}
+	case LogTypeECS:
+		return &ECSFormatter{DisableTimestamp: noTime}
+	case LogTypeOTEL:
+		return &OTELFormatter{
+			DisableTimestamp: noTime,
+			Resource:         map[string]string{"service.name": AppName, "service.version": Version},
+		}
+	}
+	return &log.JSONFormatter{DisableTimestamp: noTime}
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogEntry() *log.Entry {
+	entry := log.NewEntry(log.New()).WithFields(log.Fields{
+		"hook":     "pods.sh",
+		"binding":  "monitor-pods",
+		"queue":    "main",
+		"task.id":  "42",
+		"trace.id": "0af7651916cd43dd8448eb211c80319c",
+		"cmd":      "/hooks/pods.sh",
+		"error":    errors.New("exit status 1"),
+	})
+	entry.Time = time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	entry.Level = log.ErrorLevel
+	entry.Message = "Hook failed"
+	return entry
+}
+
+func formatTestLogEntry(t *testing.T, logType string) map[string]interface{} {
+	out, err := newLogFormatter(logType, false).Format(newTestLogEntry())
+	require.NoError(t, err)
+	res := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(out, &res), string(out))
+	return res
+}
+
+func Test_ECSFormatter(t *testing.T) {
+	res := formatTestLogEntry(t, LogTypeECS)
+
+	assert.Equal(t, "2024-01-02T03:04:05.000000006Z", res["@timestamp"])
+	assert.Equal(t, "error", res["log.level"])
+	assert.Equal(t, "Hook failed", res["message"])
+	assert.Equal(t, ECSVersion, res["ecs.version"])
+	assert.Equal(t, "exit status 1", res["error.message"])
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", res["trace.id"])
+	assert.Equal(t, "pods.sh", res["shell_operator.hook"])
+	assert.Equal(t, "monitor-pods", res["shell_operator.binding"])
+	assert.Equal(t, "main", res["shell_operator.queue"])
+	assert.Equal(t, "42", res["shell_operator.task.id"])
+	assert.Equal(t, "/hooks/pods.sh", res["shell_operator.cmd"])
+}
+
+func Test_OTELFormatter(t *testing.T) {
+	res := formatTestLogEntry(t, LogTypeOTEL)
+
+	assert.Equal(t, "1704164645000000006", res["Timestamp"])
+	assert.Equal(t, "ERROR", res["SeverityText"])
+	assert.Equal(t, float64(17), res["SeverityNumber"])
+	assert.Equal(t, "Hook failed", res["Body"])
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", res["TraceId"])
+	assert.Equal(t, AppName, res["Resource"].(map[string]interface{})["service.name"])
+
+	attributes := res["Attributes"].(map[string]interface{})
+	assert.Equal(t, "exit status 1", attributes["exception.message"])
+	assert.Equal(t, "pods.sh", attributes["shell_operator.hook"])
+	assert.Equal(t, "42", attributes["shell_operator.task.id"])
+	assert.NotContains(t, attributes, "trace.id")
+}
+
+func Test_LogfmtFormatter(t *testing.T) {
+	entry := newTestLogEntry()
+	entry.Data["binding"] = ""
+	out, err := newLogFormatter(LogTypeLogfmt, false).Format(entry)
+	require.NoError(t, err)
+
+	line := string(out)
+	assert.True(t, strings.HasPrefix(line, "ts=\"2024-01-02T03:04:05.000000006Z\" level=error msg=\"Hook failed\""), line)
+	assert.Contains(t, line, "binding=\"\"")
+	assert.Contains(t, line, "hook=pods.sh")
+	assert.Contains(t, line, "task.id=42")
+
+	out, err = newLogFormatter(LogTypeLogfmt, true).Format(entry)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "ts=")
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogSampling are rules "component=N" to log only every Nth info and debug entry of the component.
+var LogSampling = make([]string, 0)
+
+// LogSamplingAllComponents is a component name in rules for components without own rules.
+const LogSamplingAllComponents = "*"
+
+// ParseLogSampling parses rules "component=N". Items can be comma-separated lists.
+func ParseLogSampling(items []string) (map[string]uint64, error) {
+	rules := make(map[string]uint64)
+	for _, item := range items {
+		for _, rule := range strings.Split(item, ",") {
+			rule = strings.TrimSpace(rule)
+			if rule == "" {
+				continue
+			}
+			component, nStr, found := strings.Cut(rule, "=")
+			component = strings.TrimSpace(component)
+			if !found || component == "" {
+				return nil, fmt.Errorf("log sampling rule '%s' should be in the form 'component=N'", rule)
+			}
+			n, err := strconv.ParseUint(strings.TrimSpace(nStr), 10, 64)
+			if err != nil || n == 0 {
+				return nil, fmt.Errorf("log sampling rule '%s': N should be a positive number", rule)
+			}
+			rules[component] = n
+		}
+	}
+	return rules, nil
+}
+
+// SamplingFormatter drops entries of chatty components: only the first and then every
+// Nth info or debug entry of the component is logged. Warnings and errors are always logged.
+// The component is a value of the "operator.component" field.
+type SamplingFormatter struct {
+	WrappedFormatter log.Formatter
+
+	m        sync.Mutex
+	rules    map[string]uint64
+	counters map[string]uint64
+}
+
+// SetRules replaces rules and resets counters.
+func (f *SamplingFormatter) SetRules(rules map[string]uint64) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.rules = rules
+	f.counters = make(map[string]uint64)
+}
+
+func (f *SamplingFormatter) Format(entry *log.Entry) ([]byte, error) {
+	if !f.sample(entry) {
+		// Logger writes nothing for an empty line.
+		return nil, nil
+	}
+	return f.WrappedFormatter.Format(entry)
+}
+
+// sample returns false if the entry should be dropped.
+func (f *SamplingFormatter) sample(entry *log.Entry) bool {
+	if entry.Level <= log.WarnLevel {
+		return true
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	if len(f.rules) == 0 {
+		return true
+	}
+
+	component, _ := entry.Data["operator.component"].(string)
+	n, has := f.rules[component]
+	if !has {
+		n, has = f.rules[LogSamplingAllComponents]
+	}
+	if !has || n <= 1 {
+		return true
+	}
+
+	count := f.counters[component]
+	f.counters[component] = count + 1
+	return count%n == 0
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseLogSampling(t *testing.T) {
+	rules, err := ParseLogSampling([]string{"taskRunner=10, scheduleManager=2", "*=100"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"taskRunner": 10, "scheduleManager": 2, "*": 100}, rules)
+
+	for _, item := range []string{"taskRunner", "taskRunner=0", "taskRunner=-1", "=10"} {
+		_, err = ParseLogSampling([]string{item})
+		assert.Error(t, err, item)
+	}
+}
+
+func Test_SamplingFormatter(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger := log.New()
+	logger.SetOutput(out)
+	logger.SetLevel(log.DebugLevel)
+	formatter := &SamplingFormatter{WrappedFormatter: &log.TextFormatter{DisableTimestamp: true}}
+	formatter.SetRules(map[string]uint64{"taskRunner": 3})
+	logger.SetFormatter(formatter)
+
+	taskRunner := logger.WithField("operator.component", "taskRunner")
+	for i := 0; i < 7; i++ {
+		taskRunner.Info("task processed")
+	}
+	taskRunner.Error("task failed")
+	logger.WithField("operator.component", "scheduleManager").Info("tick")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	// 1st, 4th and 7th info entries, the error and the entry of other component.
+	assert.Len(t, lines, 5)
+	assert.Contains(t, string(lines[3]), "task failed")
+
+	// Rules are changed at runtime.
+	out.Reset()
+	formatter.SetRules(nil)
+	taskRunner.Info("task processed")
+	taskRunner.Info("task processed")
+	assert.Len(t, bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")), 2)
+}
@@ -0,0 +1,40 @@
+package app
+
+import "gopkg.in/alecthomas/kingpin.v2"
+
+// Go runtime settings. Zero values mean auto-tuning from cgroup limits.
+var (
+	GoMaxProcs      = 0
+	GoMemLimit      = ""
+	GoMemLimitRatio = 0.9
+)
+
+// Rates of contention profiles. Zero values disable profiles.
+var (
+	GoMutexProfileFraction = 0
+	GoBlockProfileRate     = 0
+)
+
+// DefineRuntimeFlags defines flags to override GOMAXPROCS and GOMEMLIMIT and to enable contention profiles.
+func DefineRuntimeFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("go-max-procs", "GOMAXPROCS for the operator. Default is derived from the CPU limit of the container. Can be set with $GO_MAX_PROCS.").
+		Envar("GO_MAX_PROCS").
+		Default("0").
+		IntVar(&GoMaxProcs)
+	cmd.Flag("go-mem-limit", "A soft memory limit for the Go runtime, e.g. 900Mi. Default is derived from the memory limit of the container. Can be set with $GO_MEM_LIMIT.").
+		Envar("GO_MEM_LIMIT").
+		Default(GoMemLimit).
+		StringVar(&GoMemLimit)
+	cmd.Flag("go-mem-limit-ratio", "A part of the container memory limit to use as a soft memory limit for the Go runtime. Can be set with $GO_MEM_LIMIT_RATIO.").
+		Envar("GO_MEM_LIMIT_RATIO").
+		Default("0.9").
+		Float64Var(&GoMemLimitRatio)
+	cmd.Flag("go-mutex-profile-fraction", "Report 1/N of mutex contention events in the mutex profile on /debug/pprof/mutex. 0 disables the profile. Can be set with $GO_MUTEX_PROFILE_FRACTION.").
+		Envar("GO_MUTEX_PROFILE_FRACTION").
+		Default("0").
+		IntVar(&GoMutexProfileFraction)
+	cmd.Flag("go-block-profile-rate", "Report one blocking event per N nanoseconds spent blocked in the block profile on /debug/pprof/block. 0 disables the profile. Can be set with $GO_BLOCK_PROFILE_RATE.").
+		Envar("GO_BLOCK_PROFILE_RATE").
+		Default("0").
+		IntVar(&GoBlockProfileRate)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// GitCommit and BuildDate are set with -ldflags during the build.
+// VCS information from the Go build info is used if they are empty.
+var (
+	GitCommit = ""
+	BuildDate = ""
+)
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	GoVersion string `json:"goVersion"`
+	BuildDate string `json:"buildDate"`
+}
+
+// GetBuildInfo returns the version, the commit and the build date of the binary.
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		GitCommit: GitCommit,
+		GoVersion: runtime.Version(),
+		BuildDate: BuildDate,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.GitCommit == "" {
+					info.GitCommit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			}
+		}
+	}
+
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
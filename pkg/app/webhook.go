@@ -75,6 +75,39 @@ func DefineValidatingWebhookFlags(cmd *kingpin.CmdClause) {
 		Default(ValidatingWebhookSettings.ListenAddr).
 		Envar("VALIDATING_WEBHOOK_LISTEN_ADDRESS").
 		StringVar(&ValidatingWebhookSettings.ListenAddr)
+	cmd.Flag("validating-webhook-archive-dir",
+		"A directory to store handled AdmissionReview requests and responses for audit. Archiving is disabled if empty. "+
+			"Can be set with $VALIDATING_WEBHOOK_ARCHIVE_DIR.").
+		Envar("VALIDATING_WEBHOOK_ARCHIVE_DIR").
+		StringVar(&ValidatingWebhookSettings.ArchiveDir)
+	cmd.Flag("validating-webhook-archive-url",
+		"An HTTP endpoint to send handled AdmissionReview requests and responses to with POST requests, e.g. a log collector "+
+			"or an object store gateway. Can't be used with --validating-webhook-archive-dir. "+
+			"Can be set with $VALIDATING_WEBHOOK_ARCHIVE_URL.").
+		Envar("VALIDATING_WEBHOOK_ARCHIVE_URL").
+		StringVar(&ValidatingWebhookSettings.ArchiveURL)
+	cmd.Flag("validating-webhook-archive-redact",
+		"A dot-separated path of a request field to redact before archiving, e.g. 'object.data'. "+
+			"Can be set with $VALIDATING_WEBHOOK_ARCHIVE_REDACT.").
+		Envar("VALIDATING_WEBHOOK_ARCHIVE_REDACT").
+		StringsVar(&ValidatingWebhookSettings.ArchiveRedactPaths)
+	cmd.Flag("validating-webhook-admission-policies",
+		"Create ValidatingAdmissionPolicy resources for validating bindings with CEL validations (Kubernetes 1.30+). "+
+			"Can be set with $VALIDATING_WEBHOOK_ADMISSION_POLICIES.").
+		Envar("VALIDATING_WEBHOOK_ADMISSION_POLICIES").
+		BoolVar(&ValidatingWebhookSettings.AdmissionPolicies)
+	cmd.Flag("validating-webhook-shutdown-action",
+		"An action for webhook configurations before the webhook server stops on shutdown: 'none', 'unregister' to delete configurations "+
+			"or 'fail-open' to set failurePolicy Ignore. Can be set with $VALIDATING_WEBHOOK_SHUTDOWN_ACTION.").
+		Default(admission.ShutdownActionNone).
+		Envar("VALIDATING_WEBHOOK_SHUTDOWN_ACTION").
+		EnumVar(&ValidatingWebhookSettings.ShutdownAction, admission.ShutdownActionNone, admission.ShutdownActionUnregister, admission.ShutdownActionFailOpen)
+	cmd.Flag("validating-webhook-shutdown-drain-delay",
+		"A time to serve admission requests after the shutdown action is applied, so API servers observe updated configurations. "+
+			"Can be set with $VALIDATING_WEBHOOK_SHUTDOWN_DRAIN_DELAY.").
+		Default("5s").
+		Envar("VALIDATING_WEBHOOK_SHUTDOWN_DRAIN_DELAY").
+		DurationVar(&ValidatingWebhookSettings.ShutdownDrainDelay)
 }
 
 // DefineConversionWebhookFlags defines flags for ConversionWebhook server.
@@ -110,4 +143,10 @@ func DefineConversionWebhookFlags(cmd *kingpin.CmdClause) {
 		Default(ConversionWebhookSettings.ListenAddr).
 		Envar("CONVERSION_WEBHOOK_LISTEN_ADDRESS").
 		StringVar(&ConversionWebhookSettings.ListenAddr)
+	cmd.Flag("conversion-webhook-cache-size",
+		"A number of converted objects to cache by uid, resourceVersion and desired apiVersion. Zero disables the cache. "+
+			"Can be set with $CONVERSION_WEBHOOK_CACHE_SIZE.").
+		Envar("CONVERSION_WEBHOOK_CACHE_SIZE").
+		Default("0").
+		IntVar(&ConversionWebhookSettings.CacheSize)
 }
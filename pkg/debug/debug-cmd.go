@@ -1,12 +1,15 @@
 package debug
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/flant/shell-operator/pkg/app"
+	utils_signal "github.com/flant/shell-operator/pkg/utils/signal"
 )
 
 var (
@@ -77,6 +80,43 @@ func DefineDebugCommands(kpApp *kingpin.Application) {
 	configSetCmd.Arg("duration", "Set value for a period of time, then return a previous value. Use Go notation: 10s, 15m30s, etc.").DurationVar(&paramDuration)
 	app.DefineDebugUnixSocketFlag(configSetCmd)
 
+	// State dump and load commands.
+	stateCmd := app.CommandWithDefaultUsageTemplate(kpApp, "state", "Dump and load the state of the operator.")
+
+	var stateFile string
+	stateDumpCmd := stateCmd.Command("dump", "Save queues, hook statuses, schedules, snapshots and last runs of hooks to the archive.").
+		Action(func(c *kingpin.ParseContext) error {
+			out, err := State(DefaultClient()).Dump()
+			if err != nil {
+				return err
+			}
+			if _, err := ReadStateArchive(bytes.NewReader(out)); err != nil {
+				return fmt.Errorf("bad response from debug endpoint: %w", err)
+			}
+			if stateFile == "-" {
+				_, err = os.Stdout.Write(out)
+				return err
+			}
+			err = os.WriteFile(stateFile, out, 0o600)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("State is saved to %s\n", stateFile)
+			return nil
+		})
+	stateDumpCmd.Flag("file", "A path to the archive, '-' to write to stdout.").Short('f').
+		Default("shell-operator-state.tar.gz").
+		StringVar(&stateFile)
+	app.DefineDebugUnixSocketFlag(stateDumpCmd)
+
+	var stateArchive string
+	stateLoadCmd := stateCmd.Command("load", "Serve the archive on the debug endpoint to inspect it with 'queue' and 'hook' commands.").
+		Action(func(c *kingpin.ParseContext) error {
+			return serveStateArchive(stateArchive, app.DebugUnixSocket)
+		})
+	stateLoadCmd.Arg("archive", "A path to the archive made with 'state dump'.").Required().StringVar(&stateArchive)
+	app.DefineDebugUnixSocketFlag(stateLoadCmd)
+
 	// Raw request command
 	var rawUrl string
 	rawCommand := app.CommandWithDefaultUsageTemplate(kpApp, "raw", "Make a raw request to debug endpoint.").
@@ -122,6 +162,167 @@ func DefineDebugCommandsSelf(kpApp *kingpin.Application) {
 	hookSnapshotCmd.Arg("hook_name", "").Required().StringVar(&hookName)
 	AddOutputJsonYamlTextFlag(hookSnapshotCmd)
 	app.DefineDebugUnixSocketFlag(hookSnapshotCmd)
+
+	// Get event history for monitored objects
+	hookHistoryCmd := hookCmd.Command("history", "Dump last events for objects monitored by the hook.").
+		Action(func(c *kingpin.ParseContext) error {
+			outBytes, err := Hook(DefaultClient()).Name(hookName).History(outputFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(outBytes))
+			return nil
+		})
+	hookHistoryCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	AddOutputJsonYamlTextFlag(hookHistoryCmd)
+	app.DefineDebugUnixSocketFlag(hookHistoryCmd)
+
+	// Get results of last runs
+	hookRunsCmd := hookCmd.Command("runs", "Dump results of last hook runs.").
+		Action(func(c *kingpin.ParseContext) error {
+			outBytes, err := Hook(DefaultClient()).Name(hookName).Runs(outputFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(outBytes))
+			return nil
+		})
+	hookRunsCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	AddOutputJsonYamlTextFlag(hookRunsCmd)
+	app.DefineDebugUnixSocketFlag(hookRunsCmd)
+
+	// Get comparisons of the hook with its shadow
+	hookShadowCmd := hookCmd.Command("shadow", "Dump last comparisons of Kubernetes patches from the hook and its shadow.").
+		Action(func(c *kingpin.ParseContext) error {
+			outBytes, err := Hook(DefaultClient()).Name(hookName).Shadow(outputFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(outBytes))
+			return nil
+		})
+	hookShadowCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	AddOutputJsonYamlTextFlag(hookShadowCmd)
+	app.DefineDebugUnixSocketFlag(hookShadowCmd)
+
+	// Enable and disable hooks at runtime
+	hookEnableCmd := hookCmd.Command("enable", "Resume execution of the hook disabled at runtime.").
+		Action(func(c *kingpin.ParseContext) error {
+			out, err := Hook(DefaultClient()).Name(hookName).Enable()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		})
+	hookEnableCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	app.DefineDebugUnixSocketFlag(hookEnableCmd)
+
+	hookDisableCmd := hookCmd.Command("disable", "Skip execution of the hook until it is enabled or the operator is restarted.").
+		Action(func(c *kingpin.ParseContext) error {
+			out, err := Hook(DefaultClient()).Name(hookName).Disable()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		})
+	hookDisableCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	app.DefineDebugUnixSocketFlag(hookDisableCmd)
+
+	hookUnquarantineCmd := hookCmd.Command("unquarantine", "Release the hook from quarantine and reset its error budget.").
+		Action(func(c *kingpin.ParseContext) error {
+			out, err := Hook(DefaultClient()).Name(hookName).Unquarantine()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		})
+	hookUnquarantineCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	app.DefineDebugUnixSocketFlag(hookUnquarantineCmd)
+
+	// Run the self-check of the hook on demand
+	hookCheckCmd := hookCmd.Command("check", "Run the hook with --check and print the result. A failed check marks the hook as degraded.").
+		Action(func(c *kingpin.ParseContext) error {
+			out, err := Hook(DefaultClient()).Name(hookName).Check()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		})
+	hookCheckCmd.Arg("hook_name", "").Required().StringVar(&hookName)
+	app.DefineDebugUnixSocketFlag(hookCheckCmd)
+
+	// Get event bus stats for shared informers
+	kubeCmd := app.CommandWithDefaultUsageTemplate(kpApp, "kube", "Inspect Kubernetes informers.")
+	kubeEventBusCmd := kubeCmd.Command("event-bus", "Dump subscriptions of shared informers.").
+		Action(func(c *kingpin.ParseContext) error {
+			outBytes, err := Kube(DefaultClient()).EventBus(outputFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(outBytes))
+			return nil
+		})
+	AddOutputJsonYamlTextFlag(kubeEventBusCmd)
+	app.DefineDebugUnixSocketFlag(kubeEventBusCmd)
+
+	kubeAPIWarningsCmd := kubeCmd.Command("api-warnings", "Dump warnings returned by the Kubernetes API server.").
+		Action(func(c *kingpin.ParseContext) error {
+			outBytes, err := Kube(DefaultClient()).APIWarnings(outputFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(outBytes))
+			return nil
+		})
+	AddOutputJsonYamlTextFlag(kubeAPIWarningsCmd)
+	app.DefineDebugUnixSocketFlag(kubeAPIWarningsCmd)
+
+	kubeBindingsCmd := kubeCmd.Command("bindings", "Dump states of monitors for 'kubernetes' bindings, e.g. bindings waiting for RBAC permissions.").
+		Action(func(c *kingpin.ParseContext) error {
+			outBytes, err := Kube(DefaultClient()).Bindings(outputFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(outBytes))
+			return nil
+		})
+	AddOutputJsonYamlTextFlag(kubeBindingsCmd)
+	app.DefineDebugUnixSocketFlag(kubeBindingsCmd)
+}
+
+// serveStateArchive runs the debug server with routes from the archive until the process is interrupted.
+func serveStateArchive(archivePath string, socketPath string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	archive, err := ReadStateArchive(f)
+	if err != nil {
+		return err
+	}
+	info, err := archive.Info()
+	if err != nil {
+		return err
+	}
+
+	dbgSrv := NewServer("/debug", socketPath, "")
+	archive.RegisterRoutes(dbgSrv)
+	if err := dbgSrv.Init(); err != nil {
+		return err
+	}
+	fmt.Printf("Serving the state of %s %s dumped at %s with %d hooks.\n", info.Hostname, info.Version, info.GeneratedAt.Format(time.RFC3339), len(info.Hooks))
+	fmt.Printf("Use commands with --debug-unix-socket=%s, e.g. 'queue list' or 'hook snapshot HOOK_NAME'. Press Ctrl+C to stop.\n", socketPath)
+
+	utils_signal.WaitForProcessInterruption(func() {
+		_ = os.Remove(socketPath)
+		os.Exit(0)
+	})
+	return nil
 }
 
 func AddOutputJsonYamlTextFlag(cmd *kingpin.CmdClause) {
@@ -174,6 +375,76 @@ func (r *HookRequest) Snapshots(format string) ([]byte, error) {
 	return r.client.Get(url)
 }
 
+func (r *HookRequest) History(format string) ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/history.%s", r.name, format)
+	return r.client.Get(url)
+}
+
+func (r *HookRequest) Runs(format string) ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/runs.%s", r.name, format)
+	return r.client.Get(url)
+}
+
+func (r *HookRequest) Shadow(format string) ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/shadow.%s", r.name, format)
+	return r.client.Get(url)
+}
+
+func (r *HookRequest) Enable() ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/enable", r.name)
+	return r.client.Post(url, nil)
+}
+
+func (r *HookRequest) Disable() ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/disable", r.name)
+	return r.client.Post(url, nil)
+}
+
+func (r *HookRequest) Unquarantine() ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/unquarantine", r.name)
+	return r.client.Post(url, nil)
+}
+
+func (r *HookRequest) Check() ([]byte, error) {
+	url := fmt.Sprintf("http://unix/hook/%s/check", r.name)
+	return r.client.Post(url, nil)
+}
+
+type KubeRequest struct {
+	client *Client
+}
+
+func Kube(client *Client) *KubeRequest {
+	return &KubeRequest{client: client}
+}
+
+func (r *KubeRequest) EventBus(format string) ([]byte, error) {
+	url := fmt.Sprintf("http://unix/kube/event-bus.%s", format)
+	return r.client.Get(url)
+}
+
+func (r *KubeRequest) APIWarnings(format string) ([]byte, error) {
+	url := fmt.Sprintf("http://unix/kube/api-warnings.%s", format)
+	return r.client.Get(url)
+}
+
+func (r *KubeRequest) Bindings(format string) ([]byte, error) {
+	url := fmt.Sprintf("http://unix/kube/bindings.%s", format)
+	return r.client.Get(url)
+}
+
+type StateRequest struct {
+	client *Client
+}
+
+func State(client *Client) *StateRequest {
+	return &StateRequest{client: client}
+}
+
+func (r *StateRequest) Dump() ([]byte, error) {
+	return r.client.Get("http://unix/state/dump")
+}
+
 type ConfigRequest struct {
 	client *Client
 }
@@ -0,0 +1,94 @@
+package debug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RouteLimits are limits for requests to one route. Zero values mean no limit.
+type RouteLimits struct {
+	// MaxBodyBytes is a maximum size of the request body. Requests with bigger bodies get 413.
+	MaxBodyBytes int64
+	// Timeout is a maximum duration of the handler. Slower requests get 503.
+	// The request context is canceled on timeout, handlers should stop then.
+	Timeout time.Duration
+}
+
+// DefaultPostLimits are limits for POST routes registered with RegisterHandler.
+var DefaultPostLimits = RouteLimits{
+	MaxBodyBytes: 1 << 20,
+	Timeout:      60 * time.Second,
+}
+
+// ErrorResponse is a body of responses for requests rejected by limits.
+type ErrorResponse struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+func errorResponseBody(status int, msg string) string {
+	data, _ := json.Marshal(ErrorResponse{Status: status, Error: msg})
+	return string(data)
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(errorResponseBody(status, msg)))
+}
+
+// Limits returns a middleware to apply limits to the route.
+func Limits(limits RouteLimits) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		h := next
+		if limits.MaxBodyBytes > 0 {
+			h = maxBodyHandler(limits.MaxBodyBytes, h)
+		}
+		if limits.Timeout > 0 {
+			h = timeoutHandler(limits.Timeout, h)
+		}
+		return h
+	}
+}
+
+// timeoutHandler runs the handler with a deadline in the request context
+// and responds with the JSON error when the deadline is exceeded.
+func timeoutHandler(timeout time.Duration, next http.Handler) http.Handler {
+	msg := fmt.Sprintf("request is not handled in %s", timeout)
+	h := http.TimeoutHandler(next, timeout, errorResponseBody(http.StatusServiceUnavailable, msg))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(timeoutErrorWriter{w}, r)
+	})
+}
+
+// timeoutErrorWriter sets the Content-Type for the error body of http.TimeoutHandler.
+type timeoutErrorWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutErrorWriter) WriteHeader(code int) {
+	if code == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func maxBodyHandler(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", maxBytes))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isBodyTooLarge returns true if the handler failed to read the body limited with MaxBodyBytes.
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
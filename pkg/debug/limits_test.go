@@ -0,0 +1,72 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer() *Server {
+	return NewServer("/debug", "", "")
+}
+
+func Test_RegisterHandlerWithLimits_BodySize(t *testing.T) {
+	s := newTestServer()
+	s.RegisterHandlerWithLimits(http.MethodPost, "/config/set", RouteLimits{MaxBodyBytes: 16}, func(r *http.Request) (interface{}, error) {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		return r.PostForm.Get("name"), nil
+	})
+
+	post := func(body string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/config/set", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		s.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("name=log.level", false)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "log.level", rec.Body.String())
+
+	for _, chunked := range []bool{false, true} {
+		rec = post("name="+strings.Repeat("a", 32), chunked)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "chunked=%v", chunked)
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Status)
+		assert.NotEmpty(t, resp.Error)
+	}
+}
+
+func Test_RegisterHandlerWithLimits_Timeout(t *testing.T) {
+	s := newTestServer()
+	hasDeadline := make(chan bool, 1)
+	s.RegisterHandlerWithLimits(http.MethodPost, "/slow", RouteLimits{Timeout: 10 * time.Millisecond}, func(r *http.Request) (interface{}, error) {
+		_, ok := r.Context().Deadline()
+		hasDeadline <- ok
+		<-r.Context().Done()
+		return "done", nil
+	})
+
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/slow", nil))
+
+	assert.True(t, <-hasDeadline, "handler should get a context with a deadline")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Status)
+}
@@ -14,6 +14,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
+	"github.com/flant/shell-operator/pkg/utils/compress"
 	utils "github.com/flant/shell-operator/pkg/utils/file"
 	structured_logger "github.com/flant/shell-operator/pkg/utils/structured-logger"
 )
@@ -30,6 +31,7 @@ func NewServer(prefix, socketPath, httpAddr string) *Server {
 	router := chi.NewRouter()
 	router.Use(structured_logger.NewStructuredLogger(log.StandardLogger(), "debugEndpoint"))
 	router.Use(middleware.Recoverer)
+	router.Use(compress.Middleware())
 
 	return &Server{
 		Prefix:     prefix,
@@ -89,8 +91,19 @@ func (s *Server) Init() (err error) {
 	return nil
 }
 
-// RegisterHandler registers http handler for unix/http debug server
+// RegisterHandler registers http handler for unix/http debug server.
+// POST routes are registered with DefaultPostLimits.
 func (s *Server) RegisterHandler(method, pattern string, handler func(request *http.Request) (interface{}, error)) {
+	limits := RouteLimits{}
+	if method == http.MethodPost {
+		limits = DefaultPostLimits
+	}
+	s.RegisterHandlerWithLimits(method, pattern, limits, handler)
+}
+
+// RegisterHandlerWithLimits registers http handler with limits for the request body size and the handler duration.
+// The request context has a deadline if the duration is limited.
+func (s *Server) RegisterHandlerWithLimits(method, pattern string, limits RouteLimits, handler func(request *http.Request) (interface{}, error)) {
 	if method == "" {
 		return
 	}
@@ -101,14 +114,19 @@ func (s *Server) RegisterHandler(method, pattern string, handler func(request *h
 		return
 	}
 
+	router := s.Router
+	if limits != (RouteLimits{}) {
+		router = s.Router.With(Limits(limits))
+	}
+
 	switch method {
 	case http.MethodGet:
-		s.Router.Get(pattern, func(writer http.ResponseWriter, request *http.Request) {
+		router.Get(pattern, func(writer http.ResponseWriter, request *http.Request) {
 			handleFormattedOutput(writer, request, handler)
 		})
 
 	case http.MethodPost:
-		s.Router.Post(pattern, func(writer http.ResponseWriter, request *http.Request) {
+		router.Post(pattern, func(writer http.ResponseWriter, request *http.Request) {
 			handleFormattedOutput(writer, request, handler)
 		})
 	}
@@ -116,7 +134,15 @@ func (s *Server) RegisterHandler(method, pattern string, handler func(request *h
 
 func handleFormattedOutput(writer http.ResponseWriter, request *http.Request, handler func(request *http.Request) (interface{}, error)) {
 	out, err := handler(request)
+	// The response is already sent on timeout, do not format the output.
+	if request.Context().Err() != nil {
+		return
+	}
 	if err != nil {
+		if isBodyTooLarge(err) {
+			writeErrorResponse(writer, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
 		if _, ok := err.(*BadRequestError); ok {
 			http.Error(writer, err.Error(), http.StatusBadRequest)
 			return
@@ -0,0 +1,253 @@
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Names of files in the state archive.
+const (
+	StateInfoFile      = "state.json"
+	StateStatusFile    = "status.json"
+	StateQueuesFile    = "queues.json"
+	StateQueuesText    = "queues.txt"
+	StateMainQueueFile = "queue-main.json"
+	StateMainQueueText = "queue-main.txt"
+	StateEventBusFile  = "kube/event-bus.json"
+	StateWarningsFile  = "kube/api-warnings.json"
+)
+
+// StateHookFile returns a name of the file with a dump of the hook, e.g. "hooks/NAME/snapshots.json".
+func StateHookFile(hookName string, dump string) string {
+	return path.Join("hooks", hookName, dump+".json")
+}
+
+// stateArchiveMaxBytes limits the size of an unpacked archive.
+const stateArchiveMaxBytes = 1 << 30
+
+// StateInfo describes the operator the archive was made from.
+type StateInfo struct {
+	Version     string    `json:"version"`
+	Hostname    string    `json:"hostname,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Hooks       []string  `json:"hooks"`
+}
+
+// StateArchive is a set of dumps of the operator in-memory state: queues, hook statuses,
+// schedules, snapshots and event histories. It is stored as a tar.gz archive.
+type StateArchive struct {
+	files map[string][]byte
+}
+
+func NewStateArchive() *StateArchive {
+	return &StateArchive{files: make(map[string][]byte)}
+}
+
+// Add stores the file in the archive.
+func (a *StateArchive) Add(name string, data []byte) {
+	a.files[name] = data
+}
+
+// AddJSON stores the value as a JSON file.
+func (a *StateArchive) AddJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal '%s': %w", name, err)
+	}
+	a.Add(name, data)
+	return nil
+}
+
+// File returns the content of the file and false if the file is not in the archive.
+func (a *StateArchive) File(name string) ([]byte, bool) {
+	data, has := a.files[name]
+	return data, has
+}
+
+// Files returns sorted names of files in the archive.
+func (a *StateArchive) Files() []string {
+	names := make([]string, 0, len(a.files))
+	for name := range a.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Info returns the content of the state.json file.
+func (a *StateArchive) Info() (StateInfo, error) {
+	var info StateInfo
+	data, has := a.File(StateInfoFile)
+	if !has {
+		return info, fmt.Errorf("'%s' is not found in the archive", StateInfoFile)
+	}
+	err := json.Unmarshal(data, &info)
+	if err != nil {
+		return info, fmt.Errorf("parse '%s': %w", StateInfoFile, err)
+	}
+	return info, nil
+}
+
+// Write writes the archive as tar.gz.
+func (a *StateArchive) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range a.Files() {
+		data := a.files[name]
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			ModTime:  now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadStateArchive reads the tar.gz archive. Only regular files are loaded.
+func ReadStateArchive(r io.Reader) (*StateArchive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read state archive: %w", err)
+	}
+	defer gz.Close()
+
+	a := NewStateArchive()
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read state archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		total += hdr.Size
+		if total > stateArchiveMaxBytes {
+			return nil, fmt.Errorf("read state archive: unpacked size is more than %d bytes", stateArchiveMaxBytes)
+		}
+		buf := new(bytes.Buffer)
+		if _, err := io.Copy(buf, tr); err != nil {
+			return nil, fmt.Errorf("read state archive: '%s': %w", hdr.Name, err)
+		}
+		a.Add(path.Clean(strings.TrimPrefix(hdr.Name, "./")), buf.Bytes())
+	}
+
+	if _, err := a.Info(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// RegisterRoutes registers read-only routes of the operator that serve dumps from the archive.
+// Commands like 'queue list' or 'hook snapshot' work with such a server as with the live operator.
+func (a *StateArchive) RegisterRoutes(dbgSrv *Server) {
+	dbgSrv.RegisterHandler(http.MethodGet, "/", func(_ *http.Request) (interface{}, error) {
+		info, err := a.Info()
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("debug endpoint serves the state of %s %s dumped at %s", info.Hostname, info.Version, info.GeneratedAt.Format(time.RFC3339)), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/state/info.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return a.formatted(r, StateInfoFile, "")
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/state/files.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		if FormatFromRequest(r) == "text" {
+			return strings.Join(a.Files(), "\n"), nil
+		}
+		return a.Files(), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/status.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return a.formatted(r, StateStatusFile, "")
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/queue/main.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return a.formatted(r, StateMainQueueFile, StateMainQueueText)
+	})
+
+	// Empty queues are always in the archive.
+	dbgSrv.RegisterHandler(http.MethodGet, "/queue/list.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return a.formatted(r, StateQueuesFile, StateQueuesText)
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/hook/list.{format:(json|yaml|text)}", func(_ *http.Request) (interface{}, error) {
+		info, err := a.Info()
+		if err != nil {
+			return nil, err
+		}
+		return info.Hooks, nil
+	})
+
+	for _, dump := range []string{"snapshots", "history", "runs"} {
+		dump := dump
+		dbgSrv.RegisterHandler(http.MethodGet, "/hook/{name}/"+dump+".{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+			return a.formatted(r, StateHookFile(chi.URLParam(r, "name"), dump), "")
+		})
+	}
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/kube/event-bus.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return a.formatted(r, StateEventBusFile, "")
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/kube/api-warnings.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return a.formatted(r, StateWarningsFile, "")
+	})
+}
+
+// formatted returns the file for the format of the request. The text file is used for
+// the "text" format if it is in the archive, JSON is returned as is otherwise.
+func (a *StateArchive) formatted(r *http.Request, jsonFile string, textFile string) (interface{}, error) {
+	format := FormatFromRequest(r)
+	if format == "text" && textFile != "" {
+		if data, has := a.File(textFile); has {
+			return data, nil
+		}
+	}
+
+	data, has := a.File(jsonFile)
+	if !has {
+		return nil, &BadRequestError{Msg: fmt.Sprintf("'%s' is not found in the archive", jsonFile)}
+	}
+	switch format {
+	case "text":
+		return data, nil
+	case "yaml":
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("parse '%s': %w", jsonFile, err)
+		}
+		return v, nil
+	}
+	return json.RawMessage(data), nil
+}
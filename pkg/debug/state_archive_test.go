@@ -0,0 +1,89 @@
+package debug
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStateArchive(t *testing.T) *StateArchive {
+	a := NewStateArchive()
+	require.NoError(t, a.AddJSON(StateInfoFile, StateInfo{
+		Version:     "v1.2.3",
+		GeneratedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Hooks:       []string{"hook.sh"},
+	}))
+	require.NoError(t, a.AddJSON(StateQueuesFile, map[string]interface{}{"active": []string{"main"}}))
+	a.Add(StateQueuesText, []byte("Queue 'main': length 0, status: ''\n"))
+	require.NoError(t, a.AddJSON(StateHookFile("hook.sh", "snapshots"), map[string]interface{}{"pods": []string{"pod-a"}}))
+	return a
+}
+
+func Test_StateArchive_WriteRead(t *testing.T) {
+	a := newTestStateArchive(t)
+	buf := new(bytes.Buffer)
+	require.NoError(t, a.Write(buf))
+
+	loaded, err := ReadStateArchive(buf)
+	require.NoError(t, err)
+	assert.Equal(t, a.Files(), loaded.Files())
+	for _, name := range a.Files() {
+		expected, _ := a.File(name)
+		actual, has := loaded.File(name)
+		assert.True(t, has, name)
+		assert.Equal(t, expected, actual, name)
+	}
+
+	info, err := loaded.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, []string{"hook.sh"}, info.Hooks)
+}
+
+func Test_ReadStateArchive_Errors(t *testing.T) {
+	_, err := ReadStateArchive(bytes.NewReader([]byte("not an archive")))
+	assert.Error(t, err)
+
+	// The archive without state.json is not a state archive.
+	a := NewStateArchive()
+	a.Add("file.txt", []byte("data"))
+	buf := new(bytes.Buffer)
+	require.NoError(t, a.Write(buf))
+	_, err = ReadStateArchive(buf)
+	assert.Error(t, err)
+}
+
+func Test_StateArchive_Routes(t *testing.T) {
+	s := newTestServer()
+	newTestStateArchive(t).RegisterRoutes(s)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	rec := get("/queue/list.text")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Queue 'main': length 0, status: ''\n", rec.Body.String())
+
+	rec = get("/queue/list.json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"active":["main"]}`, rec.Body.String())
+
+	rec = get("/hook/hook.sh/snapshots.yaml")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "pods:\n  - pod-a\n", rec.Body.String())
+
+	rec = get("/hook/list.json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `["hook.sh"]`, rec.Body.String())
+
+	rec = get("/hook/absent.sh/snapshots.json")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -2,11 +2,14 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,10 +19,19 @@ import (
 	utils "github.com/flant/shell-operator/pkg/utils/labels"
 )
 
+// ErrExecutionTimeout is returned if the command is terminated on timeout.
+var ErrExecutionTimeout = errors.New("execution timeout exceeded")
+
+// ErrExecutionCanceled is returned if the command is terminated because the context is done.
+var ErrExecutionCanceled = errors.New("execution canceled")
+
 type CmdUsage struct {
 	Sys    time.Duration
 	User   time.Duration
 	MaxRss int64
+	// Number of 512-byte blocks read from and written to the filesystem.
+	InBlock  int64
+	OutBlock int64
 }
 
 func Run(cmd *exec.Cmd) error {
@@ -30,31 +42,34 @@ func Run(cmd *exec.Cmd) error {
 	return cmd.Run()
 }
 
-func RunAndLogLines(cmd *exec.Cmd, logLabels map[string]string) (*CmdUsage, error) {
+func RunAndLogLines(cmd *exec.Cmd, logLabels map[string]string, opts ...RunOption) (*CmdUsage, error) {
 	// TODO observability
-	stdErr := bytes.NewBuffer(nil)
+	runOpts := newRunOptions(opts)
 	logEntry := log.WithFields(utils.LabelsToLogFields(logLabels))
-	stdoutLogEntry := logEntry.WithField("output", "stdout")
-	stderrLogEntry := logEntry.WithField("output", "stderr")
 
 	logEntry.Debugf("Executing command '%s' in '%s' dir", strings.Join(cmd.Args, " "), cmd.Dir)
 
-	if app.LogProxyHookJSON {
-		plo := &proxyJSONLogger{stdoutLogEntry, make([]byte, 0)}
-		ple := &proxyJSONLogger{stderrLogEntry, make([]byte, 0)}
-		cmd.Stdout = plo
-		cmd.Stderr = io.MultiWriter(ple, stdErr)
-	} else {
-		cmd.Stdout = stdoutLogEntry.Writer()
-		cmd.Stderr = io.MultiWriter(stderrLogEntry.Writer(), stdErr)
-	}
+	out := runOpts.newCommandOutput(logEntry)
+	cmd.Stdout = out.stdout
+	cmd.Stderr = out.stderr
 
-	err := cmd.Run()
-	if err != nil {
-		if len(stdErr.Bytes()) > 0 {
-			return nil, fmt.Errorf("%s", stdErr.String())
+	// Do not wait for background processes that inherit stdout and stderr.
+	cmd.WaitDelay = TerminateGracePeriod
+
+	err := runOpts.start(cmd)
+	if err == nil {
+		err = wait(runOpts.ctx, cmd, runOpts.timeout, logEntry)
+	}
+	if runOpts.runInfo != nil {
+		if cmd.ProcessState != nil {
+			runOpts.runInfo.ExitCode = cmd.ProcessState.ExitCode()
+		}
+		if out.tail != nil {
+			runOpts.runInfo.StderrTail = out.tail.String()
 		}
-		return nil, err
+	}
+	if err != nil {
+		return nil, out.error(err)
 	}
 
 	var usage *CmdUsage
@@ -67,13 +82,110 @@ func RunAndLogLines(cmd *exec.Cmd, logLabels map[string]string) (*CmdUsage, erro
 		sysUsage := cmd.ProcessState.SysUsage()
 		if v, ok := sysUsage.(*syscall.Rusage); ok {
 			// v.Maxrss is int32 on arm/v7
-			usage.MaxRss = int64(v.Maxrss) //nolint:unconvert
+			usage.MaxRss = int64(v.Maxrss)    //nolint:unconvert
+			usage.InBlock = int64(v.Inblock)  //nolint:unconvert
+			usage.OutBlock = int64(v.Oublock) //nolint:unconvert
 		}
 	}
 
 	return usage, err
 }
 
+// commandOutput logs stdout and stderr of the command with the output limit applied.
+type commandOutput struct {
+	stdout io.Writer
+	stderr io.Writer
+	// limitedStderr and stderrBuf are used to return stderr as an error.
+	limitedStderr *limitedWriter
+	stderrBuf     *bytes.Buffer
+	// tail is not nil if RunInfo is requested.
+	tail *tailWriter
+}
+
+func (o *runOptions) newCommandOutput(logEntry *log.Entry) *commandOutput {
+	out := &commandOutput{stderrBuf: bytes.NewBuffer(nil)}
+	stdoutLogEntry := logEntry.WithField("output", "stdout")
+	stderrLogEntry := logEntry.WithField("output", "stderr")
+
+	var stdout *limitedWriter
+	if app.LogProxyHookJSON {
+		plo := &proxyJSONLogger{stdoutLogEntry, make([]byte, 0)}
+		ple := &proxyJSONLogger{stderrLogEntry, make([]byte, 0)}
+		stdout = o.limitOutput("stdout", plo, logEntry)
+		out.limitedStderr = o.limitOutput("stderr", io.MultiWriter(ple, out.stderrBuf), logEntry)
+	} else {
+		stdout = o.limitOutput("stdout", stdoutLogEntry.Writer(), logEntry)
+		out.limitedStderr = o.limitOutput("stderr", io.MultiWriter(stderrLogEntry.Writer(), out.stderrBuf), logEntry)
+	}
+	out.stdout = stdout
+	out.stderr = out.limitedStderr
+
+	// The tail is collected before the output limit to keep the last lines.
+	if o.runInfo != nil && o.stderrTail > 0 {
+		out.tail = &tailWriter{size: o.stderrTail}
+		out.stderr = io.MultiWriter(out.limitedStderr, out.tail)
+	}
+	return out
+}
+
+// error returns stderr as an error of the failed command if it is not terminated on timeout or cancellation.
+func (out *commandOutput) error(err error) error {
+	if out.stderrBuf.Len() > 0 && !errors.Is(err, ErrExecutionTimeout) && !errors.Is(err, ErrExecutionCanceled) {
+		msg := strings.ToValidUTF8(out.stderrBuf.String(), "\uFFFD")
+		if out.limitedStderr.Truncated() {
+			msg += " ... (truncated)"
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return err
+}
+
+// wait waits for the command and terminates its process group on timeout or when ctx is done.
+// Processes left in the group after the terminated command are killed. Background processes
+// of the command that exits by itself are not touched.
+func wait(ctx context.Context, cmd *exec.Cmd, timeout time.Duration, logEntry *log.Entry) error {
+	pgid := cmd.Process.Pid
+	runningGroups.add(pgid)
+	defer runningGroups.remove(pgid)
+
+	done := make(chan struct{})
+	var timedOut atomic.Bool
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			logEntry.Warnf("Execution timeout %s exceeded, terminate process group %d", timeout, pgid)
+			terminateGroup(pgid, TerminateGracePeriod, done)
+		})
+		defer timer.Stop()
+	}
+	var canceled atomic.Bool
+	stop := context.AfterFunc(ctx, func() {
+		canceled.Store(true)
+		logEntry.Warnf("Execution is canceled: %v, terminate process group %d", context.Cause(ctx), pgid)
+		terminateGroup(pgid, TerminateGracePeriod, done)
+	})
+	defer stop()
+
+	err := cmd.Wait()
+	close(done)
+
+	if timedOut.Load() || canceled.Load() {
+		// Reap descendants left by the terminated command.
+		signalGroup(pgid, syscall.SIGKILL)
+	}
+	if timedOut.Load() {
+		return fmt.Errorf("%w: %s", ErrExecutionTimeout, timeout)
+	}
+	if canceled.Load() {
+		return fmt.Errorf("%w: %v", ErrExecutionCanceled, context.Cause(ctx))
+	}
+	if errors.Is(err, exec.ErrWaitDelay) {
+		logEntry.Warnf("Background processes in group %d kept output open after exit, their output is not captured", pgid)
+		return nil
+	}
+	return err
+}
+
 type proxyJSONLogger struct {
 	*log.Entry
 
@@ -121,6 +233,37 @@ func Output(cmd *exec.Cmd) (output []byte, err error) {
 	return
 }
 
+// OutputWithTimeout runs the command and returns its standard output as Output does.
+// The process group of the command is terminated if the timeout is exceeded.
+func OutputWithTimeout(cmd *exec.Cmd, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		return Output(cmd)
+	}
+
+	logEntry := log.WithField("cmd", strings.Join(cmd.Args, " "))
+	logEntry.Debugf("Executing command in '%s' dir with timeout %s", cmd.Dir, timeout)
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.WaitDelay = TerminateGracePeriod
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	err := wait(context.Background(), cmd, timeout, logEntry)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
 func MakeCommand(dir string, entrypoint string, args []string, envs []string) *exec.Cmd {
 	cmd := exec.Command(entrypoint, args...)
 	cmd.Env = append(cmd.Env, envs...)
@@ -2,10 +2,13 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"math/rand"
 	"os"
 	"os/exec"
+	"syscall"
 	"testing"
 	"time"
 
@@ -32,6 +35,49 @@ func TestRunAndLogLines(t *testing.T) {
 		buf.Reset()
 	})
 
+	t.Run("run info", func(t *testing.T) {
+		app.LogProxyHookJSON = false
+		info := &RunInfo{}
+		cmd := exec.Command("sh", "-c", "echo first >&2; echo second >&2; exit 3")
+		_, err := RunAndLogLines(cmd, map[string]string{"a": "b"}, WithRunInfo(info, 7))
+		assert.Error(t, err)
+		assert.Equal(t, 3, info.ExitCode)
+		assert.Equal(t, "second\n", info.StderrTail)
+
+		buf.Reset()
+	})
+
+	t.Run("umask", func(t *testing.T) {
+		app.LogProxyHookJSON = false
+		operatorUmask := syscall.Umask(0o022)
+		syscall.Umask(operatorUmask)
+
+		cmd := exec.Command("sh", "-c", "umask")
+		_, err := RunAndLogLines(cmd, map[string]string{"a": "b"}, WithUmask(0o077))
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), `msg=0077`)
+
+		// Umask of the operator is not changed.
+		assert.Equal(t, operatorUmask, syscall.Umask(operatorUmask))
+
+		buf.Reset()
+	})
+
+	t.Run("context", func(t *testing.T) {
+		app.LogProxyHookJSON = false
+		ctx, cancel := context.WithCancelCause(context.Background())
+		time.AfterFunc(100*time.Millisecond, func() { cancel(errors.New("lease is lost")) })
+
+		start := time.Now()
+		cmd := exec.Command("sleep", "10")
+		_, err := RunAndLogLines(cmd, map[string]string{"a": "b"}, WithContext(ctx))
+		assert.ErrorIs(t, err, ErrExecutionCanceled)
+		assert.ErrorContains(t, err, "lease is lost")
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		buf.Reset()
+	})
+
 	t.Run("not json log", func(t *testing.T) {
 		app.LogProxyHookJSON = false
 		// time="2023-07-10T18:14:25+04:00" level=info msg=foobar a=b output=stdout
@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RunOption changes how the command is started.
+type RunOption func(o *runOptions)
+
+type runOptions struct {
+	umask      *int
+	credential *syscall.Credential
+	timeout    time.Duration
+	ctx        context.Context
+
+	outputLimit       int64
+	onOutputTruncated func(output string)
+
+	runInfo    *RunInfo
+	stderrTail int
+}
+
+// RunInfo is filled with details about the finished command. See WithRunInfo.
+type RunInfo struct {
+	// ExitCode is an exit code of the process, -1 if the process is terminated by a signal.
+	ExitCode int
+	// StderrTail is the last bytes of stderr.
+	StderrTail string
+}
+
+// WithUmask sets a file mode creation mask for the started process.
+func WithUmask(umask int) RunOption {
+	return func(o *runOptions) {
+		o.umask = &umask
+	}
+}
+
+// WithCredential runs the process with specified user and group ids.
+func WithCredential(uid uint32, gid uint32) RunOption {
+	return func(o *runOptions) {
+		o.credential = &syscall.Credential{
+			Uid:         uid,
+			Gid:         gid,
+			NoSetGroups: true,
+		}
+	}
+}
+
+// WithTimeout limits the execution time. The process group is terminated on timeout.
+func WithTimeout(timeout time.Duration) RunOption {
+	return func(o *runOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithContext terminates the process group when ctx is done.
+func WithContext(ctx context.Context) RunOption {
+	return func(o *runOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithOutputLimit limits the size of stdout and stderr passed to the log.
+// The rest of the output is discarded, and onTruncated is called with the output name.
+func WithOutputLimit(limit int64, onTruncated func(output string)) RunOption {
+	return func(o *runOptions) {
+		o.outputLimit = limit
+		o.onOutputTruncated = onTruncated
+	}
+}
+
+// WithRunInfo fills info with the exit code and the last tailSize bytes of stderr.
+func WithRunInfo(info *RunInfo, tailSize int) RunOption {
+	return func(o *runOptions) {
+		o.runInfo = info
+		o.stderrTail = tailSize
+	}
+}
+
+func newRunOptions(opts []RunOption) *runOptions {
+	o := &runOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// start starts the command and applies options that should be set at fork time.
+// The command is started in its own process group to signal all its descendants at once.
+func (o *runOptions) start(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	if o.credential != nil {
+		cmd.SysProcAttr.Credential = o.credential
+	}
+	if o.umask != nil {
+		withUmask(cmd, *o.umask)
+	}
+	return cmd.Start()
+}
+
+// withUmask wraps the command with a shell that sets the umask and execs the command.
+// Umask is a process-wide attribute, so it is not changed in the operator process:
+// that would affect files created by the operator concurrently.
+func withUmask(cmd *exec.Cmd, umask int) {
+	args := []string{"/bin/sh", "-c", fmt.Sprintf(`umask %04o; exec "$0" "$@"`, umask), cmd.Path}
+	if len(cmd.Args) > 1 {
+		args = append(args, cmd.Args[1:]...)
+	}
+	cmd.Path = "/bin/sh"
+	cmd.Args = args
+}
+
+// limitOutput wraps the writer to apply the output limit.
+func (o *runOptions) limitOutput(output string, w io.Writer, logEntry *log.Entry) *limitedWriter {
+	return &limitedWriter{
+		w:     w,
+		limit: o.outputLimit,
+		onTruncate: func() {
+			logEntry.WithField("output", output).Warnf("Output is truncated: limit of %d bytes is exceeded", o.outputLimit)
+			if o.onOutputTruncated != nil {
+				o.onOutputTruncated(output)
+			}
+		},
+	}
+}
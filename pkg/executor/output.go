@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"io"
+	"strings"
+)
+
+// limitedWriter passes at most limit bytes to the underlying writer and discards the rest.
+// Output is discarded without errors to not block the process on a full pipe.
+// Zero limit means no limit.
+type limitedWriter struct {
+	w          io.Writer
+	limit      int64
+	written    int64
+	truncated  bool
+	onTruncate func()
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.limit <= 0 {
+		return l.w.Write(p)
+	}
+	if l.truncated {
+		return len(p), nil
+	}
+
+	rest := l.limit - l.written
+	if int64(len(p)) <= rest {
+		n, err := l.w.Write(p)
+		l.written += int64(n)
+		return n, err
+	}
+
+	n, err := l.w.Write(p[:rest])
+	l.written += int64(n)
+	l.truncated = true
+	if l.onTruncate != nil {
+		l.onTruncate()
+	}
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
+// Truncated returns true if some output was discarded.
+func (l *limitedWriter) Truncated() bool {
+	return l.truncated
+}
+
+// tailWriter keeps the last size bytes written to it.
+type tailWriter struct {
+	size int
+	buf  []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	if len(p) >= t.size {
+		t.buf = append(t.buf[:0], p[len(p)-t.size:]...)
+		return len(p), nil
+	}
+	if extra := len(t.buf) + len(p) - t.size; extra > 0 {
+		t.buf = append(t.buf[:0], t.buf[extra:]...)
+	}
+	t.buf = append(t.buf, p...)
+	return len(p), nil
+}
+
+func (t *tailWriter) String() string {
+	return strings.ToValidUTF8(string(t.buf), "\uFFFD")
+}
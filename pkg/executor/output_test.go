@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitedWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	truncated := 0
+	w := &limitedWriter{w: buf, limit: 5, onTruncate: func() { truncated++ }}
+
+	n, err := w.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, w.Truncated())
+
+	// Discarded bytes are reported as written.
+	n, err = w.Write([]byte("def\x00\xff"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	n, err = w.Write([]byte("ghi"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	assert.True(t, w.Truncated())
+	assert.Equal(t, 1, truncated)
+	assert.Equal(t, "abcde", buf.String())
+}
+
+func TestLimitedWriter_NoLimit(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := &limitedWriter{w: buf}
+
+	_, err := w.Write(bytes.Repeat([]byte("a"), 1024))
+	assert.NoError(t, err)
+	assert.False(t, w.Truncated())
+	assert.Equal(t, 1024, buf.Len())
+}
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{size: 5}
+
+	_, _ = w.Write([]byte("abc"))
+	assert.Equal(t, "abc", w.String())
+
+	_, _ = w.Write([]byte("def"))
+	assert.Equal(t, "bcdef", w.String())
+
+	n, err := w.Write([]byte("0123456789"))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+	assert.Equal(t, "56789", w.String())
+}
@@ -0,0 +1,403 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	utils "github.com/flant/shell-operator/pkg/utils/labels"
+)
+
+// WarmPoolEnv is set for processes started by Pool. A hook should check it
+// to switch into the serve mode instead of handling a single binding context.
+const WarmPoolEnv = "SHELL_OPERATOR_WARM_POOL"
+
+// WarmPoolResponseFd is a file descriptor in the pooled process to write responses to.
+const WarmPoolResponseFd = 3
+
+// PoolRequest is sent to the stdin of the pooled process as a single JSON line.
+type PoolRequest struct {
+	Env map[string]string `json:"env"`
+}
+
+// PoolResponse is read from the WarmPoolResponseFd of the pooled process as a single JSON line.
+type PoolResponse struct {
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Pool keeps a number of pre-started processes of the same executable
+// to amortize interpreter startup cost. The protocol is line based:
+//   - the process is started with WarmPoolEnv=yes,
+//   - operator writes PoolRequest to the stdin,
+//   - process handles request using environment variables from the request,
+//   - process writes PoolResponse to the WarmPoolResponseFd.
+//
+// Process is restarted on the next Run if it exits or breaks the protocol.
+type Pool struct {
+	dir        string
+	entrypoint string
+	envs       []string
+	logLabels  map[string]string
+	runOpts    *runOptions
+
+	// slots is a buffered channel with pool capacity.
+	// A nil slot means that the process should be started.
+	slots chan *poolWorker
+
+	m       sync.Mutex
+	stopped bool
+	workers map[*poolWorker]struct{}
+}
+
+type poolWorker struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses *bufio.Reader
+	respFile  *os.File
+	killOnce  sync.Once
+
+	// Output of the process is logged with labels of the current request.
+	stdout     *switchWriter
+	stderr     *switchWriter
+	idleStdout io.Writer
+	idleStderr io.Writer
+}
+
+// switchWriter passes the output to the writer of the current request.
+type switchWriter struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.w.Write(p)
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.w = w
+}
+
+func NewPool(dir string, entrypoint string, envs []string, size int, logLabels map[string]string, opts ...RunOption) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &Pool{
+		dir:        dir,
+		entrypoint: entrypoint,
+		envs:       envs,
+		logLabels:  logLabels,
+		runOpts:    newRunOptions(opts),
+		slots:      make(chan *poolWorker, size),
+		workers:    make(map[*poolWorker]struct{}),
+	}
+	for i := 0; i < size; i++ {
+		p.slots <- nil
+	}
+	return p
+}
+
+// Size returns a maximum number of processes in the pool.
+func (p *Pool) Size() int {
+	return cap(p.slots)
+}
+
+// Run sends request to a free process and waits for response.
+// It blocks if all processes are busy. Options are applied as in RunAndLogLines
+// on top of options of the pool, output of the process is logged with logLabels.
+func (p *Pool) Run(env map[string]string, logLabels map[string]string, opts ...RunOption) (*CmdUsage, error) {
+	runOpts := *p.runOpts
+	for _, opt := range opts {
+		opt(&runOpts)
+	}
+
+	w := <-p.slots
+
+	var err error
+	if w == nil {
+		w, err = p.startWorker()
+		if err != nil {
+			p.slots <- nil
+			return nil, fmt.Errorf("start pooled process: %v", err)
+		}
+	}
+
+	logEntry := log.WithFields(utils.LabelsToLogFields(logLabels)).WithField("warmPool", "true")
+	out := runOpts.newCommandOutput(logEntry)
+	w.stdout.set(out.stdout)
+	w.stderr.set(out.stderr)
+	defer func() {
+		w.stdout.set(w.idleStdout)
+		w.stderr.set(w.idleStderr)
+	}()
+
+	// The read deadline interrupts waiting for the response on timeout or cancellation.
+	if runOpts.timeout > 0 {
+		_ = w.respFile.SetReadDeadline(time.Now().Add(runOpts.timeout))
+	}
+	var canceled atomic.Bool
+	stop := context.AfterFunc(runOpts.ctx, func() {
+		canceled.Store(true)
+		_ = w.respFile.SetReadDeadline(time.Now())
+	})
+
+	pid := w.cmd.Process.Pid
+	before, statErr := readProcStat(pid)
+	resp, err := w.handle(PoolRequest{Env: env})
+	stop()
+	_ = w.respFile.SetReadDeadline(time.Time{})
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		if canceled.Load() {
+			err = fmt.Errorf("%w: %v", ErrExecutionCanceled, context.Cause(runOpts.ctx))
+		} else {
+			err = fmt.Errorf("%w: %s", ErrExecutionTimeout, runOpts.timeout)
+		}
+	}
+	if err != nil {
+		p.killWorker(w)
+		p.slots <- nil
+		return nil, fmt.Errorf("pooled process: %w", err)
+	}
+
+	var usage *CmdUsage
+	if statErr == nil {
+		if after, err := readProcStat(pid); err == nil {
+			usage = after.usageSince(before)
+		}
+	}
+	p.release(w)
+
+	if runOpts.runInfo != nil {
+		runOpts.runInfo.ExitCode = resp.ExitCode
+		if out.tail != nil {
+			runOpts.runInfo.StderrTail = out.tail.String()
+		}
+	}
+	if resp.ExitCode != 0 {
+		if resp.Error != "" {
+			return nil, fmt.Errorf("%s", resp.Error)
+		}
+		return nil, out.error(fmt.Errorf("exit code %d", resp.ExitCode))
+	}
+
+	return usage, nil
+}
+
+// Stop kills idle processes. Busy processes are killed when they finish the request,
+// so running hooks can be terminated gracefully with TerminateAll. Run returns error after Stop.
+func (p *Pool) Stop() {
+	p.m.Lock()
+	p.stopped = true
+	p.m.Unlock()
+
+	idle := make([]*poolWorker, 0, cap(p.slots))
+	for i := 0; i < cap(p.slots); i++ {
+		select {
+		case w := <-p.slots:
+			idle = append(idle, w)
+		default:
+		}
+	}
+	for _, w := range idle {
+		if w != nil {
+			p.killWorker(w)
+		}
+		p.slots <- nil
+	}
+}
+
+// release returns the process to the pool or kills it if the pool is stopped.
+func (p *Pool) release(w *poolWorker) {
+	p.m.Lock()
+	stopped := p.stopped
+	p.m.Unlock()
+	if stopped {
+		p.killWorker(w)
+		p.slots <- nil
+		return
+	}
+	p.slots <- w
+}
+
+func (p *Pool) startWorker() (*poolWorker, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.stopped {
+		return nil, fmt.Errorf("pool is stopped")
+	}
+
+	logEntry := log.WithFields(utils.LabelsToLogFields(p.logLabels)).WithField("warmPool", "true")
+
+	envs := append([]string{}, p.envs...)
+	envs = append(envs, fmt.Sprintf("%s=yes", WarmPoolEnv))
+	cmd := MakeCommand(p.dir, p.entrypoint, []string{}, envs)
+	// Output between requests is logged with labels of the pool.
+	idleStdout := logEntry.WithField("output", "stdout").Writer()
+	idleStderr := logEntry.WithField("output", "stderr").Writer()
+	stdout := &switchWriter{w: idleStdout}
+	stderr := &switchWriter{w: idleStderr}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	respRead, respWrite, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	// ExtraFiles[0] becomes fd 3 in the child process.
+	cmd.ExtraFiles = []*os.File{respWrite}
+
+	logEntry.Debugf("Start pooled process '%s' in '%s' dir", p.entrypoint, p.dir)
+	err = p.runOpts.start(cmd)
+	// The write end is owned by the child process now.
+	_ = respWrite.Close()
+	if err != nil {
+		_ = respRead.Close()
+		return nil, err
+	}
+
+	w := &poolWorker{
+		cmd:        cmd,
+		stdin:      stdin,
+		responses:  bufio.NewReader(respRead),
+		respFile:   respRead,
+		stdout:     stdout,
+		stderr:     stderr,
+		idleStdout: idleStdout,
+		idleStderr: idleStderr,
+	}
+	p.workers[w] = struct{}{}
+	runningGroups.add(cmd.Process.Pid)
+	return w, nil
+}
+
+func (p *Pool) killWorker(w *poolWorker) {
+	p.m.Lock()
+	delete(p.workers, w)
+	p.m.Unlock()
+
+	w.killOnce.Do(func() {
+		_ = w.stdin.Close()
+		if w.cmd.Process != nil {
+			// Kill the whole process group to not leave orphaned children.
+			signalGroup(w.cmd.Process.Pid, syscall.SIGKILL)
+			runningGroups.remove(w.cmd.Process.Pid)
+		}
+		_ = w.cmd.Wait()
+		_ = w.respFile.Close()
+	})
+}
+
+func (w *poolWorker) handle(req PoolRequest) (*PoolResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, '\n')
+	if _, err := w.stdin.Write(data); err != nil {
+		return nil, fmt.Errorf("send request: %v", err)
+	}
+
+	line, err := w.responses.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	resp := new(PoolResponse)
+	if err := json.Unmarshal(line, resp); err != nil {
+		return nil, fmt.Errorf("parse response '%s': %v", string(line), err)
+	}
+	return resp, nil
+}
+
+// procClockTicks is USER_HZ, the unit of CPU times in /proc/<pid>/stat.
+const procClockTicks = 100
+
+// procStat is a resource usage of the running process. Usage of the pooled process
+// is not available from wait4, so it is read from /proc before and after the request.
+type procStat struct {
+	user, sys             time.Duration
+	maxRssKb              int64
+	readBytes, writeBytes int64
+}
+
+func readProcStat(pid int) (procStat, error) {
+	var st procStat
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return st, err
+	}
+	// The command name can contain spaces, fields are counted after it.
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	if len(fields) < 15 {
+		return st, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	// utime, stime, cutime and cstime: waited children are counted as in wait4.
+	var ticks [4]int64
+	for i := range ticks {
+		ticks[i], err = strconv.ParseInt(fields[11+i], 10, 64)
+		if err != nil {
+			return st, err
+		}
+	}
+	st.user = time.Duration(ticks[0]+ticks[2]) * time.Second / procClockTicks
+	st.sys = time.Duration(ticks[1]+ticks[3]) * time.Second / procClockTicks
+
+	// Memory and IO counters are optional.
+	if status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid)); err == nil {
+		st.maxRssKb = procField(string(status), "VmHWM:")
+	}
+	if ioStat, err := os.ReadFile(fmt.Sprintf("/proc/%d/io", pid)); err == nil {
+		st.readBytes = procField(string(ioStat), "read_bytes:")
+		st.writeBytes = procField(string(ioStat), "write_bytes:")
+	}
+	return st, nil
+}
+
+// procField returns a number after the name in the "name: value [kB]" line.
+func procField(content string, name string) int64 {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, name) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, name))
+		if len(fields) == 0 {
+			return 0
+		}
+		v, _ := strconv.ParseInt(fields[0], 10, 64)
+		return v
+	}
+	return 0
+}
+
+// usageSince returns the usage of the request. MaxRss is the peak of the process.
+func (st procStat) usageSince(prev procStat) *CmdUsage {
+	return &CmdUsage{
+		User:     st.user - prev.user,
+		Sys:      st.sys - prev.sys,
+		MaxRss:   st.maxRssKb,
+		InBlock:  (st.readBytes - prev.readBytes) / 512,
+		OutBlock: (st.writeBytes - prev.writeBytes) / 512,
+	}
+}
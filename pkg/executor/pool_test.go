@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const poolTestScript = `#!/bin/bash
+while read -r line; do
+  case "$line" in
+    *exit-now*) exit 1 ;;
+    *fail*) echo '{"exitCode":2,"error":"hook failed"}' >&3 ;;
+    *) echo '{"exitCode":0}' >&3 ;;
+  esac
+done
+`
+
+func TestPool_Run(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte(poolTestScript), 0o755))
+
+	p := NewPool(dir, script, os.Environ(), 1, map[string]string{"hook": "hook.sh"})
+	defer p.Stop()
+
+	usage, err := p.Run(map[string]string{"MODE": "ok"}, map[string]string{"hook": "hook.sh"})
+	assert.NoError(t, err)
+	assert.NotNil(t, usage)
+	// The same process should handle the next request.
+	_, err = p.Run(map[string]string{"MODE": "ok"}, map[string]string{"hook": "hook.sh"})
+	assert.NoError(t, err)
+
+	_, err = p.Run(map[string]string{"MODE": "fail"}, map[string]string{"hook": "hook.sh"})
+	assert.EqualError(t, err, "hook failed")
+
+	// Process exits without response, it should be restarted on the next run.
+	_, err = p.Run(map[string]string{"MODE": "exit-now"}, map[string]string{"hook": "hook.sh"})
+	assert.Error(t, err)
+	_, err = p.Run(map[string]string{"MODE": "ok"}, map[string]string{"hook": "hook.sh"})
+	assert.NoError(t, err)
+
+	p.Stop()
+	_, err = p.Run(map[string]string{"MODE": "ok"}, map[string]string{"hook": "hook.sh"})
+	assert.Error(t, err)
+}
+
+func TestPool_Stop(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte(poolTestScript), 0o755))
+
+	p := NewPool(dir, script, os.Environ(), 2, map[string]string{"hook": "hook.sh"})
+	_, err := p.Run(map[string]string{"MODE": "ok"}, map[string]string{"hook": "hook.sh"})
+	require.NoError(t, err)
+	require.Len(t, p.workers, 1)
+
+	// Idle processes are killed and not waited by TerminateAll.
+	p.Stop()
+	assert.Empty(t, p.workers)
+	assert.Empty(t, runningGroups.list())
+	assert.Len(t, p.slots, 2)
+}
+
+func TestPool_Run_Options(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/bash
+while read -r line; do
+  case "$line" in
+    *sleep*) sleep 10 ;;
+    *) echo "request error" >&2; echo '{"exitCode":3}' >&3 ;;
+  esac
+done
+`), 0o755))
+
+	p := NewPool(dir, script, os.Environ(), 1, map[string]string{"hook": "hook.sh"})
+	defer p.Stop()
+
+	info := &RunInfo{}
+	_, err := p.Run(map[string]string{"MODE": "fail"}, map[string]string{"hook": "hook.sh"}, WithRunInfo(info, 100))
+	assert.Error(t, err)
+	assert.Equal(t, 3, info.ExitCode)
+
+	_, err = p.Run(map[string]string{"MODE": "sleep"}, map[string]string{"hook": "hook.sh"}, WithTimeout(100*time.Millisecond))
+	assert.ErrorIs(t, err, ErrExecutionTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	_, err = p.Run(map[string]string{"MODE": "sleep"}, map[string]string{"hook": "hook.sh"}, WithContext(ctx))
+	assert.ErrorIs(t, err, ErrExecutionCanceled)
+}
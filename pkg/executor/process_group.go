@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TerminateGracePeriod is a time between SIGTERM and SIGKILL sent to a process group
+// on execution timeout or on shutdown.
+const TerminateGracePeriod = 5 * time.Second
+
+// runningGroups contains process groups of running hooks.
+var runningGroups = &processGroups{pgids: make(map[int]struct{})}
+
+type processGroups struct {
+	m     sync.Mutex
+	pgids map[int]struct{}
+}
+
+func (g *processGroups) add(pgid int) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.pgids[pgid] = struct{}{}
+}
+
+func (g *processGroups) remove(pgid int) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	delete(g.pgids, pgid)
+}
+
+func (g *processGroups) list() []int {
+	g.m.Lock()
+	defer g.m.Unlock()
+	res := make([]int, 0, len(g.pgids))
+	for pgid := range g.pgids {
+		res = append(res, pgid)
+	}
+	return res
+}
+
+// signalGroup sends a signal to all processes in the group.
+func signalGroup(pgid int, sig syscall.Signal) {
+	if pgid <= 0 {
+		return
+	}
+	err := syscall.Kill(-pgid, sig)
+	if err != nil && err != syscall.ESRCH {
+		log.Warnf("Send %s to process group %d: %v", sig, pgid, err)
+	}
+}
+
+// terminateGroup sends SIGTERM to the process group and SIGKILL after the grace period
+// if the done channel is not closed.
+func terminateGroup(pgid int, gracePeriod time.Duration, done <-chan struct{}) {
+	signalGroup(pgid, syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(gracePeriod):
+		signalGroup(pgid, syscall.SIGKILL)
+	}
+}
+
+// TerminateAll forwards SIGTERM to process groups of all running hooks
+// and kills remaining processes after the grace period.
+func TerminateAll(gracePeriod time.Duration) {
+	pgids := runningGroups.list()
+	if len(pgids) == 0 {
+		return
+	}
+	log.Infof("Terminate %d running hook process groups", len(pgids))
+	for _, pgid := range pgids {
+		signalGroup(pgid, syscall.SIGTERM)
+	}
+
+	deadline := time.Now().Add(gracePeriod)
+	for time.Now().Before(deadline) {
+		if len(runningGroups.list()) == 0 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, pgid := range runningGroups.list() {
+		signalGroup(pgid, syscall.SIGKILL)
+	}
+}
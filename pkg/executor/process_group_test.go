@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAndLogLines_Timeout(t *testing.T) {
+	// A background child keeps stdout open, it should not block the execution.
+	cmd := exec.Command("bash", "-c", "sleep 60 & sleep 60")
+
+	start := time.Now()
+	_, err := RunAndLogLines(cmd, map[string]string{}, WithTimeout(200*time.Millisecond))
+	assert.ErrorIs(t, err, ErrExecutionTimeout)
+	assert.Less(t, time.Since(start), TerminateGracePeriod+time.Second)
+	assert.Empty(t, runningGroups.list())
+}
+
+func TestOutputWithTimeout(t *testing.T) {
+	out, err := OutputWithTimeout(exec.Command("bash", "-c", "echo configVersion: v1"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "configVersion: v1\n", string(out))
+
+	start := time.Now()
+	_, err = OutputWithTimeout(exec.Command("bash", "-c", "sleep 60"), 200*time.Millisecond)
+	assert.ErrorIs(t, err, ErrExecutionTimeout)
+	assert.Less(t, time.Since(start), TerminateGracePeriod+time.Second)
+}
+
+func TestRunAndLogLines_BackgroundProcess(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	// A background child of the hook that exits by itself is not killed.
+	cmd := exec.Command("bash", "-c", "sleep 60 >/dev/null 2>&1 & echo $! > "+pidFile)
+
+	_, err := RunAndLogLines(cmd, map[string]string{})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(pidFile)
+	assert.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	assert.NoError(t, err)
+	assert.NoError(t, syscall.Kill(pid, 0), "background process should be running")
+	_ = syscall.Kill(pid, syscall.SIGKILL)
+}
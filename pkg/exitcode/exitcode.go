@@ -0,0 +1,101 @@
+package exitcode
+
+import (
+	"errors"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Reason is a machine-readable reason of the process shutdown.
+type Reason string
+
+const (
+	// Signal is a shutdown after SIGTERM or SIGINT.
+	Signal Reason = "Signal"
+	// Unknown is a failure without a specific reason.
+	Unknown Reason = "Unknown"
+	// ConfigError is an invalid flag, config file, directory or hook configuration.
+	ConfigError Reason = "ConfigError"
+	// KubeConnectionError is a failure to create a client for the Kubernetes API server.
+	KubeConnectionError Reason = "KubeConnectionError"
+	// WebhookBootstrapError is a failure to configure or serve validating or conversion webhooks.
+	WebhookBootstrapError Reason = "WebhookBootstrapError"
+	// QueueFatalError is an unrecoverable error in the task queue, e.g. a panic in the task handler.
+	QueueFatalError Reason = "QueueFatalError"
+	// DebugServerError is a failure to start the debug server, e.g. to listen on the debug socket.
+	DebugServerError Reason = "DebugServerError"
+)
+
+// Exit codes for shutdown reasons. Codes start from 10 to not clash with
+// the code 2 of Go panics and codes 128+N for signals.
+var codes = map[Reason]int{
+	Signal:                1,
+	Unknown:               1,
+	ConfigError:           10,
+	KubeConnectionError:   11,
+	WebhookBootstrapError: 12,
+	QueueFatalError:       13,
+	DebugServerError:      14,
+}
+
+// Code returns an exit code for the reason.
+func Code(reason Reason) int {
+	if code, has := codes[reason]; has {
+		return code
+	}
+	return codes[Unknown]
+}
+
+// Error is an error with the shutdown reason.
+type Error struct {
+	Reason Reason
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns an error with the shutdown reason. It returns nil if err is nil.
+// The reason of an already wrapped error is kept.
+func Wrap(reason Reason, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *Error
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	return &Error{Reason: reason, Err: err}
+}
+
+// ReasonOf returns the shutdown reason of the error or Unknown.
+func ReasonOf(err error) Reason {
+	var exitErr *Error
+	if errors.As(err, &exitErr) {
+		return exitErr.Reason
+	}
+	return Unknown
+}
+
+// Exit logs the shutdown reason and exits with the code for the reason.
+func Exit(reason Reason, err error) {
+	entry := log.WithField("shutdown.reason", string(reason)).
+		WithField("exit.code", Code(reason))
+	if err != nil {
+		entry.Errorf("Shutdown: %v", err)
+	} else {
+		entry.Infof("Shutdown")
+	}
+	os.Exit(Code(reason))
+}
+
+// ExitWithError exits with the reason of the error.
+func ExitWithError(err error) {
+	Exit(ReasonOf(err), err)
+}
@@ -0,0 +1,33 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Wrap(t *testing.T) {
+	assert.NoError(t, Wrap(ConfigError, nil))
+
+	err := Wrap(KubeConnectionError, errors.New("connection refused"))
+	assert.Equal(t, KubeConnectionError, ReasonOf(err))
+	assert.Equal(t, "connection refused", err.Error())
+
+	// The first reason is kept.
+	err = Wrap(ConfigError, fmt.Errorf("init: %w", err))
+	assert.Equal(t, KubeConnectionError, ReasonOf(err))
+
+	assert.Equal(t, Unknown, ReasonOf(errors.New("some error")))
+}
+
+func Test_Code(t *testing.T) {
+	assert.Equal(t, 1, Code(Signal))
+	assert.Equal(t, 10, Code(ConfigError))
+	assert.Equal(t, 11, Code(KubeConnectionError))
+	assert.Equal(t, 12, Code(WebhookBootstrapError))
+	assert.Equal(t, 13, Code(QueueFatalError))
+	assert.Equal(t, 14, Code(DebugServerError))
+	assert.Equal(t, 1, Code("NoSuchReason"))
+}
@@ -0,0 +1,57 @@
+package golden
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+// DefineVerifyGoldensCommand defines a command to replay recorded hook runs,
+// e.g. in CI of the hooks repository.
+func DefineVerifyGoldensCommand(kpApp *kingpin.Application) {
+	hooksDir := app.HooksDir
+	recordDir := ""
+	timeout := time.Minute
+
+	cmd := app.CommandWithDefaultUsageTemplate(kpApp, "verify-goldens", "Replay hook runs recorded with --hook-record-dir and compare outputs.").
+		Action(func(c *kingpin.ParseContext) error {
+			results, err := Verify(context.Background(), hooksDir, recordDir, timeout)
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, res := range results {
+				if res.Passed() {
+					fmt.Printf("PASS %s %s\n", res.Hook, res.Run)
+					continue
+				}
+				failed++
+				fmt.Printf("FAIL %s %s\n", res.Hook, res.Run)
+				if res.Error != "" {
+					fmt.Printf("  error: %s\n", res.Error)
+				}
+				for _, diff := range res.Diffs {
+					fmt.Printf("  %s:\n    expected: %s\n    actual:   %s\n", diff.Output, diff.Expected, diff.Actual)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d recorded runs failed", failed, len(results))
+			}
+			return nil
+		})
+	cmd.Flag("hooks-dir", "A directory with hooks to verify. Can be set with $SHELL_OPERATOR_HOOKS_DIR.").
+		Envar("SHELL_OPERATOR_HOOKS_DIR").
+		Default(hooksDir).
+		StringVar(&hooksDir)
+	cmd.Flag("record-dir", "A directory with recorded hook runs. Can be set with $HOOK_RECORD_DIR.").
+		Envar("HOOK_RECORD_DIR").
+		Required().
+		StringVar(&recordDir)
+	cmd.Flag("timeout", "A timeout for each hook run.").
+		Default("1m").
+		DurationVar(&timeout)
+}
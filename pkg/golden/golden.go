@@ -0,0 +1,181 @@
+// Package golden records inputs and outputs of hook runs as test fixtures
+// and replays recorded inputs to verify outputs of hooks.
+//
+// Each run is saved into its own directory:
+//
+//	RECORD_DIR/HOOK_NAME/RUN_ID/
+//	  run.json                       - meta information, see Meta
+//	  binding-context.json           - $BINDING_CONTEXT_PATH
+//	  kubernetes-patch-results.json  - $KUBERNETES_PATCH_RESULTS_PATH
+//	  values.json                    - $HOOK_VALUES_PATH, if set
+//	  metrics.json                   - $METRICS_PATH
+//	  kubernetes-patch.json          - $KUBERNETES_PATCH_PATH
+//	  admission-response.json        - $ADMISSION_RESPONSE_PATH
+//	  conversion-response.json       - $CONVERSION_RESPONSE_PATH
+//
+// Empty outputs are not saved.
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MetaFileName                   = "run.json"
+	BindingContextFileName         = "binding-context.json"
+	KubernetesPatchResultsFileName = "kubernetes-patch-results.json"
+	ValuesFileName                 = "values.json"
+
+	MetricsFileName            = "metrics.json"
+	KubernetesPatchFileName    = "kubernetes-patch.json"
+	AdmissionResponseFileName  = "admission-response.json"
+	ConversionResponseFileName = "conversion-response.json"
+)
+
+// OutputFileNames are files with outputs of the hook compared by Verify.
+var OutputFileNames = []string{
+	MetricsFileName,
+	KubernetesPatchFileName,
+	AdmissionResponseFileName,
+	ConversionResponseFileName,
+}
+
+// Meta describes the recorded run.
+type Meta struct {
+	Hook    string    `json:"hook"`
+	Binding string    `json:"binding"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	// BindingContextFormat is a value of $BINDING_CONTEXT_FORMAT.
+	BindingContextFormat string `json:"bindingContextFormat,omitempty"`
+	// Command is an inline command of the command hook.
+	Command  string `json:"command,omitempty"`
+	ExitCode int    `json:"exitCode"`
+}
+
+// Run is a hook run to record. Files are paths to files with inputs and outputs
+// by file names in the recorded layout. Missing and empty files are skipped.
+type Run struct {
+	Meta  Meta
+	Files map[string]string
+}
+
+// Recorder saves hook runs into the directory. It keeps last maxRuns runs for each hook.
+type Recorder struct {
+	dir     string
+	maxRuns int
+
+	mu sync.Mutex
+	// seq makes run ids unique for runs in the same nanosecond.
+	seq int
+}
+
+func NewRecorder(dir string, maxRuns int) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create directory for recorded hook runs: %v", err)
+	}
+	return &Recorder{dir: dir, maxRuns: maxRuns}, nil
+}
+
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// Record saves the run. It is safe for concurrent use.
+func (r *Recorder) Record(run Run) error {
+	r.mu.Lock()
+	r.seq++
+	id := fmt.Sprintf("%s-%06d-%s", run.Meta.Time.UTC().Format("20060102T150405.000000000"), r.seq%1000000, safeName(run.Meta.Binding))
+	r.mu.Unlock()
+
+	hookDir := filepath.Join(r.dir, filepath.FromSlash(run.Meta.Hook))
+	runDir := filepath.Join(hookDir, id)
+	if err := os.MkdirAll(runDir, 0o700); err != nil {
+		return err
+	}
+
+	metaBytes, err := json.MarshalIndent(run.Meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(runDir, MetaFileName), metaBytes, 0o600); err != nil {
+		return err
+	}
+
+	for name, path := range run.Files {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(runDir, name), data, 0o600); err != nil {
+			return err
+		}
+	}
+
+	return r.prune(hookDir)
+}
+
+// prune removes the oldest runs of the hook above maxRuns.
+func (r *Recorder) prune(hookDir string) error {
+	if r.maxRuns <= 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs, err := runDirs(hookDir)
+	if err != nil {
+		return err
+	}
+	for len(runs) > r.maxRuns {
+		if err := os.RemoveAll(runs[0]); err != nil {
+			return err
+		}
+		runs = runs[1:]
+	}
+	return nil
+}
+
+// runDirs returns sorted directories with recorded runs in the hook directory.
+// Run ids start with the time, so the oldest run is the first.
+func runDirs(hookDir string) ([]string, error) {
+	entries, err := os.ReadDir(hookDir)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(hookDir, entry.Name(), MetaFileName)); err != nil {
+			continue
+		}
+		dirs = append(dirs, filepath.Join(hookDir, entry.Name()))
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func safeName(s string) string {
+	if s == "" {
+		return "run"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,117 @@
+package golden
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, content string, perm os.FileMode) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+	return path
+}
+
+func Test_Record_and_Verify(t *testing.T) {
+	tmp := t.TempDir()
+	recordDir := filepath.Join(tmp, "record")
+	hooksDir := filepath.Join(tmp, "hooks")
+
+	rec, err := NewRecorder(recordDir, 0)
+	require.NoError(t, err)
+
+	// Outputs of the original run.
+	err = rec.Record(Run{
+		Meta: Meta{Hook: "002-app/hook.sh", Binding: "pods", Type: "kubernetes", Time: time.Now()},
+		Files: map[string]string{
+			BindingContextFileName:         writeFile(t, filepath.Join(tmp, "in", "context"), `[{"binding":"pods"}]`, 0o644),
+			KubernetesPatchResultsFileName: writeFile(t, filepath.Join(tmp, "in", "results"), `[]`, 0o644),
+			MetricsFileName:                writeFile(t, filepath.Join(tmp, "in", "metrics"), `{"name":"pods","action":"set","value":1}`+"\n", 0o644),
+			KubernetesPatchFileName:        writeFile(t, filepath.Join(tmp, "in", "patch"), "", 0o644),
+		},
+	})
+	require.NoError(t, err)
+
+	runs, err := runDirs(filepath.Join(recordDir, "002-app", "hook.sh"))
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.FileExists(t, filepath.Join(runs[0], MetaFileName))
+	assert.FileExists(t, filepath.Join(runs[0], MetricsFileName))
+	assert.NoFileExists(t, filepath.Join(runs[0], KubernetesPatchFileName), "empty outputs should not be saved")
+
+	// Recorded snapshots and values may contain secrets.
+	for _, path := range []string{recordDir, filepath.Join(recordDir, "002-app"), runs[0]} {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o700), info.Mode().Perm(), path)
+	}
+	for _, name := range []string{MetaFileName, BindingContextFileName, MetricsFileName} {
+		info, err := os.Stat(filepath.Join(runs[0], name))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), name)
+	}
+
+	// The hook writes the same metric with another order of keys.
+	hookPath := writeFile(t, filepath.Join(hooksDir, "002-app", "hook.sh"), `#!/bin/sh
+grep -q pods "$BINDING_CONTEXT_PATH" || exit 1
+echo '{"value":1, "action":"set", "name":"pods"}' > "$METRICS_PATH"
+`, 0o755)
+
+	results, err := Verify(context.Background(), hooksDir, recordDir, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed(), "%+v", results[0])
+	assert.Equal(t, "002-app/hook.sh", results[0].Hook)
+
+	// The hook writes another metric and a patch.
+	writeFile(t, hookPath, `#!/bin/sh
+echo '{"name":"pods","action":"set","value":2}' > "$METRICS_PATH"
+echo '{"operation":"Delete","kind":"Pod","name":"a"}' > "$KUBERNETES_PATCH_PATH"
+`, 0o755)
+
+	results, err = Verify(context.Background(), hooksDir, recordDir, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Passed())
+	outputs := make([]string, 0)
+	for _, diff := range results[0].Diffs {
+		outputs = append(outputs, diff.Output)
+	}
+	assert.Equal(t, []string{MetricsFileName, KubernetesPatchFileName}, outputs)
+
+	// The hook fails.
+	writeFile(t, hookPath, "#!/bin/sh\nexit 3\n", 0o755)
+
+	results, err = Verify(context.Background(), hooksDir, recordDir, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, results[0].Diffs)
+	assert.Equal(t, "exitCode", results[0].Diffs[0].Output)
+}
+
+func Test_Recorder_maxRuns(t *testing.T) {
+	recordDir := t.TempDir()
+	rec, err := NewRecorder(recordDir, 2)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		err := rec.Record(Run{Meta: Meta{Hook: "hook.sh", Binding: "schedule", Time: start.Add(time.Duration(i) * time.Second)}})
+		require.NoError(t, err)
+	}
+
+	runs, err := runDirs(filepath.Join(recordDir, "hook.sh"))
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Contains(t, runs[0], start.Add(2*time.Second).UTC().Format("20060102T150405"))
+}
+
+func Test_Verify_no_runs(t *testing.T) {
+	_, err := Verify(context.Background(), t.TempDir(), t.TempDir(), time.Minute)
+	assert.Error(t, err)
+}
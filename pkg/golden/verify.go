@@ -0,0 +1,208 @@
+package golden
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// CommandHookShell runs inline commands of command hooks.
+const CommandHookShell = "/bin/sh"
+
+// Result is a result of the replay of the recorded run.
+type Result struct {
+	Hook string `json:"hook"`
+	// Run is a path to the recorded run.
+	Run string `json:"run"`
+	// Diffs are names of outputs that differ from recorded ones.
+	Diffs []Diff `json:"diffs,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (r Result) Passed() bool {
+	return r.Error == "" && len(r.Diffs) == 0
+}
+
+// Diff is a difference in the output of the hook.
+type Diff struct {
+	Output   string `json:"output"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Verify replays all runs recorded in recordDir with hooks from hooksDir
+// and compares outputs with recorded ones. JSON outputs are compared
+// regardless of formatting and the order of keys.
+func Verify(ctx context.Context, hooksDir string, recordDir string, timeout time.Duration) ([]Result, error) {
+	runs := make([]string, 0)
+	err := filepath.WalkDir(recordDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == MetaFileName {
+			runs = append(runs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no recorded runs in '%s'", recordDir)
+	}
+
+	results := make([]Result, 0, len(runs))
+	for _, runDir := range runs {
+		results = append(results, verifyRun(ctx, hooksDir, runDir, timeout))
+	}
+	return results, nil
+}
+
+func verifyRun(ctx context.Context, hooksDir string, runDir string, timeout time.Duration) Result {
+	res := Result{Run: runDir}
+
+	metaBytes, err := os.ReadFile(filepath.Join(runDir, MetaFileName))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	var meta Meta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		res.Error = fmt.Sprintf("parse %s: %v", MetaFileName, err)
+		return res
+	}
+	res.Hook = meta.Hook
+
+	tmpDir, err := os.MkdirTemp("", "golden-")
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer os.RemoveAll(tmpDir)
+
+	envs := map[string]string{
+		"BINDING_CONTEXT_PATH":          filepath.Join(runDir, BindingContextFileName),
+		"BINDING_CONTEXT_FORMAT":        meta.BindingContextFormat,
+		"KUBERNETES_PATCH_RESULTS_PATH": filepath.Join(runDir, KubernetesPatchResultsFileName),
+		"METRICS_PATH":                  filepath.Join(tmpDir, MetricsFileName),
+		"KUBERNETES_PATCH_PATH":         filepath.Join(tmpDir, KubernetesPatchFileName),
+		"ADMISSION_RESPONSE_PATH":       filepath.Join(tmpDir, AdmissionResponseFileName),
+		"VALIDATING_RESPONSE_PATH":      filepath.Join(tmpDir, AdmissionResponseFileName),
+		"CONVERSION_RESPONSE_PATH":      filepath.Join(tmpDir, ConversionResponseFileName),
+	}
+	if _, err := os.Stat(filepath.Join(runDir, ValuesFileName)); err == nil {
+		envs["HOOK_VALUES_PATH"] = filepath.Join(runDir, ValuesFileName)
+	}
+	for _, name := range OutputFileNames {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte{}, 0o644); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	hookPath := filepath.Join(hooksDir, filepath.FromSlash(meta.Hook))
+	cmd := exec.CommandContext(ctx, hookPath)
+	if meta.Command != "" {
+		cmd = exec.CommandContext(ctx, CommandHookShell, "-c", meta.Command)
+	}
+	cmd.Dir = filepath.Dir(hookPath)
+	cmd.Env = os.Environ()
+	for name, value := range envs {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	cmd.Stdout = io.Discard
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		exitCode = -1
+		if cmd.ProcessState != nil {
+			exitCode = cmd.ProcessState.ExitCode()
+		}
+		if exitCode == -1 {
+			res.Error = fmt.Sprintf("run hook: %v", err)
+			return res
+		}
+	}
+	if exitCode != meta.ExitCode {
+		res.Diffs = append(res.Diffs, Diff{
+			Output:   "exitCode",
+			Expected: fmt.Sprintf("%d", meta.ExitCode),
+			Actual:   fmt.Sprintf("%d %s", exitCode, bytes.TrimSpace(stderr.Bytes())),
+		})
+	}
+
+	for _, name := range OutputFileNames {
+		expected, err := readOptional(filepath.Join(runDir, name))
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		actual, err := readOptional(filepath.Join(tmpDir, name))
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		if !equalOutputs(expected, actual) {
+			res.Diffs = append(res.Diffs, Diff{Output: name, Expected: string(expected), Actual: string(actual)})
+		}
+	}
+
+	return res
+}
+
+func readOptional(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []byte{}, nil
+	}
+	return data, err
+}
+
+// equalOutputs compares outputs as streams of JSON values if both are valid JSON
+// and as text with trimmed spaces otherwise.
+func equalOutputs(expected, actual []byte) bool {
+	expectedJSON, expectedErr := normalizeJSONStream(expected)
+	actualJSON, actualErr := normalizeJSONStream(actual)
+	if expectedErr == nil && actualErr == nil {
+		return bytes.Equal(expectedJSON, actualJSON)
+	}
+	return bytes.Equal(bytes.TrimSpace(expected), bytes.TrimSpace(actual))
+}
+
+// normalizeJSONStream re-encodes JSON values with sorted keys and without spaces.
+func normalizeJSONStream(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,21 @@
+package hook
+
+import (
+	"strconv"
+
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+)
+
+// backpressureEnvs returns variables with a load of the queue. Combined binding
+// contexts are from the same queue, so the first context is used.
+func backpressureEnvs(context []BindingContext) map[string]string {
+	if len(context) == 0 || context[0].Metadata.Backpressure == nil {
+		return nil
+	}
+	bp := context[0].Metadata.Backpressure
+	return map[string]string{
+		"QUEUE_NAME":              bp.Queue,
+		"QUEUE_LENGTH":            strconv.Itoa(bp.QueueLength),
+		"QUEUE_TASK_WAIT_SECONDS": strconv.FormatFloat(bp.TaskWaitSeconds, 'f', 3, 64),
+	}
+}
@@ -0,0 +1,23 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+)
+
+func Test_backpressureEnvs(t *testing.T) {
+	require.Nil(t, backpressureEnvs(nil))
+	require.Nil(t, backpressureEnvs([]BindingContext{{Binding: "schedule"}}))
+
+	bc := BindingContext{Binding: "schedule"}
+	bc.Metadata.Backpressure = &Backpressure{Queue: "main", QueueLength: 42, TaskWaitSeconds: 1.5}
+
+	require.Equal(t, map[string]string{
+		"QUEUE_NAME":              "main",
+		"QUEUE_LENGTH":            "42",
+		"QUEUE_TASK_WAIT_SECONDS": "1.500",
+	}, backpressureEnvs([]BindingContext{bc}))
+}
@@ -2,6 +2,8 @@ package binding_context
 
 import (
 	"encoding/json"
+	"io"
+
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/admission/v1"
 	apixv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -19,6 +21,12 @@ type BindingContext struct {
 		IncludeSnapshots    []string
 		IncludeAllSnapshots bool
 		Group               string
+		// Backpressure is a load of the queue at the start of the hook run.
+		Backpressure *Backpressure
+		// Cluster describes the cluster and the operator. It is nil if unknown.
+		Cluster *Cluster
+		// EnvFromObject maps names of environment variables to fields of the object from the event.
+		EnvFromObject map[string]FieldPath
 	}
 
 	// name of a binding or a group or kubeEventType if binding has no 'name' field
@@ -32,6 +40,36 @@ type BindingContext struct {
 	ConversionReview *apixv1.ConversionReview
 	FromVersion      string
 	ToVersion        string
+	// additional field for 'schedule' binding: crontabs that triggered this context
+	Crontabs []string
+	// additional fields from the external source of the trigger binding, e.g. 'message' for 'onMessage'
+	TriggerData map[string]interface{}
+}
+
+// Backpressure describes a load of the queue, so hooks can self-throttle
+// or switch to batch mode when the operator is under load.
+type Backpressure struct {
+	Queue           string  `json:"queue"`
+	QueueLength     int     `json:"queueLength"`
+	TaskWaitSeconds float64 `json:"taskWaitSeconds"`
+}
+
+// Cluster is metadata of the cluster and the operator, so hooks
+// running in several clusters can branch without API calls.
+type Cluster struct {
+	Name              string `json:"name,omitempty"`
+	ID                string `json:"id,omitempty"`
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	OperatorVersion   string `json:"operatorVersion,omitempty"`
+}
+
+// triggerContextTypes are values of the 'type' field for bindings with external sources.
+var triggerContextTypes = map[BindingType]string{
+	OnMessage:    "Message",
+	OnWebhook:    "Webhook",
+	OnFileChange: "FileChange",
+	OnSignal:     "Signal",
+	OnAlert:      "Alert",
 }
 
 func (bc BindingContext) IsSynchronization() bool {
@@ -58,6 +96,14 @@ func (bc BindingContext) MapV1() map[string]interface{} {
 	res := make(map[string]interface{})
 	res["binding"] = bc.Binding
 
+	if bc.Metadata.Backpressure != nil {
+		res["backpressure"] = bc.Metadata.Backpressure
+	}
+
+	if bc.Metadata.Cluster != nil {
+		res["cluster"] = bc.Metadata.Cluster
+	}
+
 	if bc.Metadata.BindingType == OnStartup {
 		return res
 	}
@@ -92,10 +138,21 @@ func (bc BindingContext) MapV1() map[string]interface{} {
 		return res
 	}
 
+	if bc.Metadata.BindingType == Composite {
+		res["type"] = "Composite"
+		return res
+	}
+
 	// Group is always has "type: Group", even for Synchronization.
 	if bc.Metadata.Group != "" {
 		res["type"] = "Group"
 		res["groupName"] = bc.Metadata.Group
+		if bc.Metadata.BindingType == Schedule && len(bc.Crontabs) > 0 {
+			res["crontabs"] = bc.Crontabs
+		}
+		for k, v := range bc.TriggerData {
+			res[k] = v
+		}
 		return res
 	}
 
@@ -104,6 +161,14 @@ func (bc BindingContext) MapV1() map[string]interface{} {
 		return res
 	}
 
+	if contextType, ok := triggerContextTypes[bc.Metadata.BindingType]; ok {
+		res["type"] = contextType
+		for k, v := range bc.TriggerData {
+			res[k] = v
+		}
+		return res
+	}
+
 	// A short way for addon-operator's hooks.
 	if bc.Metadata.BindingType != OnKubernetesEvent || bc.Type == "" {
 		return res
@@ -184,3 +249,45 @@ func (b BindingContextList) Json() ([]byte, error) {
 	data, err := json.MarshalIndent(b, "", "  ")
 	return data, err
 }
+
+// SynchronizationObjectType is a type of JSON Lines entries with objects
+// of the Synchronization binding context.
+const SynchronizationObjectType = "SynchronizationObject"
+
+// WriteJsonLines writes each binding context as a compact JSON object on a separate line.
+// Objects of Synchronization are not included into the context: the context
+// has an 'objectsCount' field and is followed by a line for each object, so
+// hooks can process objects one by one.
+func (b BindingContextList) WriteJsonLines(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, context := range b {
+		if context["type"] != TypeSynchronization {
+			if err := enc.Encode(context); err != nil {
+				return err
+			}
+			continue
+		}
+
+		objects, _ := context["objects"].([]ObjectAndFilterResult)
+		header := make(map[string]interface{}, len(context))
+		for k, v := range context {
+			if k != "objects" {
+				header[k] = v
+			}
+		}
+		header["objectsCount"] = len(objects)
+		if err := enc.Encode(header); err != nil {
+			return err
+		}
+
+		for _, obj := range objects {
+			line := obj.Map()
+			line["binding"] = context["binding"]
+			line["type"] = SynchronizationObjectType
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
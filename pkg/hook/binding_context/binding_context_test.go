@@ -1,6 +1,8 @@
 package binding_context
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -346,6 +348,32 @@ func Test_ConvertBindingContextList_v1(t *testing.T) {
 				{`.[1].objects[0].object.metadata.name`, `"pod-qwe"`},
 			},
 		},
+		{
+			"grouped Schedule with crontabs",
+			func() []BindingContext {
+				bc := BindingContext{
+					Binding:   "every-minute",
+					Snapshots: map[string][]ObjectAndFilterResult{},
+					Crontabs:  []string{"* * * * *", "*/5 * * * *"},
+				}
+				bc.Metadata.BindingType = Schedule
+				bc.Metadata.Group = "reconcile"
+				bc.Metadata.IncludeAllSnapshots = true
+				return []BindingContext{bc}
+			},
+			func() {
+				g.Expect(bcList[0]["type"]).Should(Equal("Group"))
+				g.Expect(bcList[0]["groupName"]).Should(Equal("reconcile"))
+				g.Expect(bcList[0]["crontabs"]).Should(Equal([]string{"* * * * *", "*/5 * * * *"}))
+			},
+			[][]string{
+				// binding, type, groupName, snapshots and crontabs
+				{`.[0] | length`, `5`},
+				{`.[0].type`, `"Group"`},
+				{`.[0].crontabs | length`, `2`},
+				{`.[0].crontabs[1]`, `"*/5 * * * *"`},
+			},
+		},
 		{
 			"kubernetes Synchronization with empty objects",
 			func() []BindingContext {
@@ -374,6 +402,44 @@ func Test_ConvertBindingContextList_v1(t *testing.T) {
 				{`.[0].objects | length`, `0`},
 			},
 		},
+		{
+			"Schedule with backpressure",
+			func() []BindingContext {
+				bc := BindingContext{
+					Binding: "every-minute",
+				}
+				bc.Metadata.BindingType = Schedule
+				bc.Metadata.Backpressure = &Backpressure{Queue: "main", QueueLength: 12, TaskWaitSeconds: 3.5}
+				return []BindingContext{bc}
+			},
+			func() {},
+			[][]string{
+				{`.[0] | length`, `3`},
+				{`.[0].type`, `"Schedule"`},
+				{`.[0].backpressure.queue`, `"main"`},
+				{`.[0].backpressure.queueLength`, `12`},
+				{`.[0].backpressure.taskWaitSeconds`, `3.5`},
+			},
+		},
+		{
+			"OnStartup with cluster metadata",
+			func() []BindingContext {
+				bc := BindingContext{
+					Binding: "onStartup",
+				}
+				bc.Metadata.BindingType = OnStartup
+				bc.Metadata.Cluster = &Cluster{Name: "prod-eu", KubernetesVersion: "v1.29.2", OperatorVersion: "v1.2.0"}
+				return []BindingContext{bc}
+			},
+			func() {},
+			[][]string{
+				{`.[0] | length`, `2`},
+				{`.[0].cluster.name`, `"prod-eu"`},
+				{`.[0].cluster | has("id")`, `false`},
+				{`.[0].cluster.kubernetesVersion`, `"v1.29.2"`},
+				{`.[0].cluster.operatorVersion`, `"v1.2.0"`},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -484,3 +550,35 @@ func Test_ConvertBindingContextList_v0(t *testing.T) {
 		})
 	}
 }
+
+func Test_BindingContextList_WriteJsonLines(t *testing.T) {
+	bc := BindingContext{
+		Binding: "monitor-pods",
+		Type:    TypeSynchronization,
+		Objects: []ObjectAndFilterResult{
+			{Object: &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "pod-1"}}}},
+			{Object: &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "pod-2"}}}},
+		},
+	}
+	bc.Metadata.BindingType = OnKubernetesEvent
+	onStartup := BindingContext{Binding: "onStartup"}
+	onStartup.Metadata.BindingType = OnStartup
+
+	bcList := ConvertBindingContextList("v1", []BindingContext{bc, onStartup})
+
+	var buf bytes.Buffer
+	assert.NoError(t, bcList.WriteJsonLines(&buf))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if assert.Len(t, lines, 4) {
+		JqEqual(t, []byte(lines[0]), `.binding`, `"monitor-pods"`)
+		JqEqual(t, []byte(lines[0]), `.type`, `"Synchronization"`)
+		JqEqual(t, []byte(lines[0]), `.objectsCount`, `2`)
+		JqEqual(t, []byte(lines[0]), `has("objects")`, `false`)
+		JqEqual(t, []byte(lines[1]), `.type`, `"SynchronizationObject"`)
+		JqEqual(t, []byte(lines[1]), `.binding`, `"monitor-pods"`)
+		JqEqual(t, []byte(lines[1]), `.object.metadata.name`, `"pod-1"`)
+		JqEqual(t, []byte(lines[2]), `.object.metadata.name`, `"pod-2"`)
+		JqEqual(t, []byte(lines[3]), `.binding`, `"onStartup"`)
+	}
+}
@@ -0,0 +1,121 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/executor"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
+)
+
+// CheckArg is an argument to run the hook in the self-check mode.
+const CheckArg = "--check"
+
+// CheckStatus is a result of the last self-check of the hook.
+type CheckStatus struct {
+	// Degraded is true if the last check is failed.
+	Degraded  bool      `json:"degraded"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Duration  string    `json:"duration"`
+	Error     string    `json:"error,omitempty"`
+	// ExitCode is an exit code of the check process.
+	ExitCode int `json:"exitCode"`
+	// StderrTail is the last bytes of the check stderr.
+	StderrTail string `json:"stderrTail,omitempty"`
+}
+
+// hookCheck serializes self-checks of the hook and keeps the last result.
+type hookCheck struct {
+	runLock sync.Mutex
+
+	lock sync.Mutex
+	last *CheckStatus
+}
+
+// HasCheck returns true if the hook implements the self-check contract.
+func (h *Hook) HasCheck() bool {
+	return h.Config.Settings != nil && h.Config.Settings.Check != nil
+}
+
+// Check runs the hook with --check. A non-zero exit code marks the hook as degraded.
+// The result does not affect execution of hook tasks.
+func (h *Hook) Check() (*CheckStatus, error) {
+	if !h.HasCheck() {
+		return nil, fmt.Errorf("hook '%s' has no check: settings.check is not set", h.Name)
+	}
+
+	h.check.runLock.Lock()
+	defer h.check.runLock.Unlock()
+
+	status := &CheckStatus{CheckedAt: time.Now()}
+	err := h.checksums.Verify(h.Path)
+	if err == nil {
+		var runInfo executor.RunInfo
+		opts := h.runOptions()
+		opts = append(opts,
+			executor.WithTimeout(h.Config.Settings.Check.Timeout),
+			executor.WithRunInfo(&runInfo, StderrTailSize),
+		)
+		cmd := executor.MakeCommand(h.workingDir(), h.Path, []string{CheckArg}, h.checkEnvs())
+		_, err = executor.RunAndLogLines(cmd, map[string]string{"hook": h.Name, "phase": "check"}, opts...)
+		status.ExitCode = runInfo.ExitCode
+		status.StderrTail = runInfo.StderrTail
+	}
+	status.Duration = time.Since(status.CheckedAt).String()
+	if err != nil {
+		status.Degraded = true
+		status.Error = err.Error()
+	}
+
+	h.check.lock.Lock()
+	wasDegraded := h.check.last != nil && h.check.last.Degraded
+	h.check.last = status
+	h.check.lock.Unlock()
+
+	logEntry := log.WithField("hook", h.Name).WithField("phase", "check")
+	switch {
+	case status.Degraded && !wasDegraded:
+		logEntry.Warnf("Hook check failed, hook is degraded: %s", status.Error)
+	case !status.Degraded && wasDegraded:
+		logEntry.Infof("Hook check succeeded, hook is not degraded")
+	}
+
+	return status, nil
+}
+
+// LastCheck returns the result of the last self-check or nil if the hook was not checked.
+func (h *Hook) LastCheck() *CheckStatus {
+	h.check.lock.Lock()
+	defer h.check.lock.Unlock()
+	if h.check.last == nil {
+		return nil
+	}
+	status := *h.check.last
+	return &status
+}
+
+// IsDegraded returns true if the last self-check of the hook is failed.
+func (h *Hook) IsDegraded() bool {
+	last := h.LastCheck()
+	return last != nil && last.Degraded
+}
+
+// checkEnvs returns environment variables for the check. Binding context
+// and output files are not passed: the check should not change anything.
+func (h *Hook) checkEnvs() []string {
+	envs := append([]string{}, h.environ()...)
+	if h.KubeconfigPath != "" && os.Getenv("KUBECONFIG") == "" {
+		envs = append(envs, "KUBECONFIG="+h.KubeconfigPath)
+	}
+	if h.ValuesPath != "" {
+		envs = append(envs, "HOOK_VALUES_PATH="+h.ValuesPath)
+	}
+	if read_only.Enabled() {
+		envs = append(envs, read_only.EnvName+"=true")
+	}
+	return envs
+}
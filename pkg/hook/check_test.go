@@ -0,0 +1,50 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	. "github.com/flant/shell-operator/pkg/hook/types"
+)
+
+func Test_Hook_Check(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	hookPath := filepath.Join(dir, "hook.sh")
+	markerPath := filepath.Join(dir, "broken")
+	script := `#!/bin/sh
+if [ "$1" != "--check" ]; then exit 0; fi
+if [ -f ` + markerPath + ` ]; then echo "jq not found" >&2; exit 1; fi
+`
+	g.Expect(os.WriteFile(hookPath, []byte(script), 0o755)).To(Succeed())
+
+	h := NewHook("hook.sh", hookPath)
+	_, err := h.Check()
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(h.LastCheck()).To(BeNil())
+
+	h.Config.Settings = &Settings{Check: &CheckSettings{Interval: time.Minute, Timeout: 10 * time.Second}}
+	status, err := h.Check()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Degraded).To(BeFalse())
+	g.Expect(h.IsDegraded()).To(BeFalse())
+
+	g.Expect(os.WriteFile(markerPath, nil, 0o644)).To(Succeed())
+	status, err = h.Check()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Degraded).To(BeTrue())
+	g.Expect(status.ExitCode).To(Equal(1))
+	g.Expect(status.StderrTail).To(ContainSubstring("jq not found"))
+	g.Expect(h.IsDegraded()).To(BeTrue())
+
+	// The hook recovers with the next successful check.
+	g.Expect(os.Remove(markerPath)).To(Succeed())
+	_, err = h.Check()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(h.IsDegraded()).To(BeFalse())
+}
@@ -0,0 +1,124 @@
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flant/shell-operator/pkg/hook_bundle"
+)
+
+// ChecksumsSignatureSuffix is a suffix of the detached signature file of the checksums manifest.
+const ChecksumsSignatureSuffix = ".sig"
+
+// Checksums is a manifest of SHA256 checksums of hook files in the format of 'sha256sum'.
+// Hooks not listed in the manifest and modified hooks are refused.
+type Checksums struct {
+	dir  string
+	sums map[string]string
+}
+
+// LoadChecksums reads the manifest. Paths in the manifest are relative to the hooks dir.
+// If publicKeyPath is set, the manifest should have a valid detached signature in the
+// file with the '.sig' suffix, e.g. made by 'cosign sign-blob'.
+func LoadChecksums(hooksDir string, path string, publicKeyPath string) (*Checksums, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read checksums: %v", err)
+	}
+
+	if publicKeyPath != "" {
+		key, err := hook_bundle.LoadPublicKey(publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		sigData, err := os.ReadFile(path + ChecksumsSignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("read checksums signature: %v", err)
+		}
+		sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sigData)))
+		if err != nil {
+			// Signature in the raw format.
+			sig = sigData
+		}
+		if !hook_bundle.VerifyBytes(key, data, sig) {
+			return nil, fmt.Errorf("checksums signature is not valid for the public key")
+		}
+	}
+
+	c := &Checksums{dir: hooksDir, sums: make(map[string]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum, name, found := strings.Cut(line, " ")
+		// Binary mode of sha256sum marks names with '*'.
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		if !found || len(sum) != sha256.Size*2 || name == "" {
+			return nil, fmt.Errorf("checksums line %d: expect '<sha256> <path>'", lineNo)
+		}
+		c.sums[filepath.Clean(name)] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read checksums: %v", err)
+	}
+	return c, nil
+}
+
+// VerifyAll checks all files listed in the manifest, e.g. libraries sourced by hooks.
+func (c *Checksums) VerifyAll() error {
+	if c == nil {
+		return nil
+	}
+	for name := range c.sums {
+		if err := c.verify(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks the file of the hook. It is a no-op if checksums are not loaded.
+func (c *Checksums) Verify(hookPath string) error {
+	if c == nil {
+		return nil
+	}
+	name, err := filepath.Rel(c.dir, hookPath)
+	if err != nil {
+		return err
+	}
+	return c.verify(filepath.Clean(name))
+}
+
+func (c *Checksums) verify(name string) error {
+	expected, has := c.sums[name]
+	if !has {
+		return fmt.Errorf("file '%s' is not listed in checksums", name)
+	}
+
+	f, err := os.Open(filepath.Join(c.dir, name))
+	if err != nil {
+		return fmt.Errorf("verify '%s': %v", name, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("verify '%s': %v", name, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != expected {
+		return fmt.Errorf("file '%s' is modified: checksum mismatch", name)
+	}
+	return nil
+}
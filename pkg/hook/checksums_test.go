@@ -0,0 +1,80 @@
+package hook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_Checksums(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hook.sh"), []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib/common.sh"), []byte("echo\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unsigned.sh"), []byte("#!/bin/sh\n"), 0o755))
+
+	manifest := sha256Hex("#!/bin/sh\n") + "  hook.sh\n" + sha256Hex("echo\n") + " *lib/common.sh\n"
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	require.NoError(t, os.WriteFile(path, []byte(manifest), 0o644))
+
+	c, err := LoadChecksums(dir, path, "")
+	require.NoError(t, err)
+	assert.NoError(t, c.VerifyAll())
+	assert.NoError(t, c.Verify(filepath.Join(dir, "hook.sh")))
+	assert.ErrorContains(t, c.Verify(filepath.Join(dir, "unsigned.sh")), "not listed")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib/common.sh"), []byte("rm -rf /\n"), 0o644))
+	assert.ErrorContains(t, c.VerifyAll(), "checksum mismatch")
+
+	var nilChecksums *Checksums
+	assert.NoError(t, nilChecksums.Verify(filepath.Join(dir, "unsigned.sh")), "verification is disabled")
+
+	require.NoError(t, os.WriteFile(path, []byte("abc hook.sh\n"), 0o644))
+	_, err = LoadChecksums(dir, path, "")
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func Test_Checksums_Signature(t *testing.T) {
+	dir := t.TempDir()
+	manifest := []byte(sha256Hex("#!/bin/sh\n") + "  hook.sh\n")
+	path := filepath.Join(dir, "SHA256SUMS")
+	require.NoError(t, os.WriteFile(path, manifest, 0o644))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "cosign.pub")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+
+	_, err = LoadChecksums(dir, path, keyPath)
+	assert.ErrorContains(t, err, "signature", "signature file is required")
+
+	hashed := sha256.Sum256(manifest)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hashed[:])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path+ChecksumsSignatureSuffix, []byte(base64.StdEncoding.EncodeToString(sig)), 0o644))
+
+	_, err = LoadChecksums(dir, path, keyPath)
+	assert.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, append(manifest, []byte(sha256Hex("x")+"  evil.sh\n")...), 0o644))
+	_, err = LoadChecksums(dir, path, keyPath)
+	assert.ErrorContains(t, err, "not valid")
+}
@@ -8,7 +8,7 @@ import (
 	. "github.com/flant/shell-operator/pkg/hook/types"
 )
 
-var validBindingTypes = []BindingType{OnStartup, Schedule, OnKubernetesEvent, KubernetesValidating, KubernetesMutating, KubernetesConversion}
+var validBindingTypes = []BindingType{OnStartup, Schedule, OnKubernetesEvent, KubernetesValidating, KubernetesMutating, KubernetesConversion, OnMessage, OnWebhook, OnFileChange, OnSignal, OnAlert}
 
 // HookConfig is a structure with versioned hook configuration
 type HookConfig struct {
@@ -26,7 +26,19 @@ type HookConfig struct {
 	KubernetesValidating []ValidatingConfig
 	KubernetesMutating   []MutatingConfig
 	KubernetesConversion []ConversionConfig
+	Composite            []CompositeConfig
+	OnMessage            []TriggerConfig
+	OnWebhook            []TriggerConfig
+	OnFileChange         []TriggerConfig
+	OnSignal             []TriggerConfig
+	OnAlert              []TriggerConfig
 	Settings             *Settings
+	// Disabled hook is not loaded.
+	Disabled bool
+	// Profiles are names of hook profiles the hook belongs to.
+	Profiles []string
+	// Command is an inline shell command of a command hook.
+	Command string
 }
 
 // LoadAndValidate loads config from bytes and validate it. Returns multierror.
@@ -119,10 +131,31 @@ func (c *HookConfig) HasBinding(binding BindingType) bool {
 		return len(c.KubernetesMutating) > 0
 	case KubernetesConversion:
 		return len(c.KubernetesConversion) > 0
+	case OnMessage:
+		return len(c.OnMessage) > 0
+	case OnWebhook:
+		return len(c.OnWebhook) > 0
+	case OnFileChange:
+		return len(c.OnFileChange) > 0
+	case OnSignal:
+		return len(c.OnSignal) > 0
+	case OnAlert:
+		return len(c.OnAlert) > 0
 	}
 	return false
 }
 
+// Triggers returns all bindings that are triggered by external sources.
+func (c *HookConfig) Triggers() []TriggerConfig {
+	res := make([]TriggerConfig, 0, len(c.OnMessage)+len(c.OnWebhook)+len(c.OnFileChange)+len(c.OnSignal)+len(c.OnAlert))
+	res = append(res, c.OnMessage...)
+	res = append(res, c.OnWebhook...)
+	res = append(res, c.OnFileChange...)
+	res = append(res, c.OnSignal...)
+	res = append(res, c.OnAlert...)
+	return res
+}
+
 func (c *HookConfig) ConvertOnStartup(value interface{}) (*OnStartupConfig, error) {
 	floatValue, err := ConvertFloatForBinding(value, "onStartup")
 	if err != nil || floatValue == nil {
@@ -8,6 +8,11 @@ import (
 	"github.com/hashicorp/go-multierror"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/admissionregistration/v1"
+
+	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/kube_events_manager"
+	kubetypes "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 )
 
 func Test_HookConfig_VersionedConfig_LoadAndValidate(t *testing.T) {
@@ -251,6 +256,38 @@ func Test_HookConfig_VersionedConfig_LoadAndValidate(t *testing.T) {
 				g.Expect(sch7sec.Queue).To(Equal("off-schedule"))
 			},
 		},
+		{
+			"v1 schedule with skipIfSnapshotsUnchanged",
+			`{
+              "configVersion":"v1",
+			  "kubernetes":[
+			    {"name":"pods", "apiVersion":"v1", "kind":"Pod"}
+              ],
+			  "schedule":[
+			    {"name":"reconcile", "crontab":"*/5 * * * *", "includeSnapshotsFrom":["pods"], "skipIfSnapshotsUnchanged":true},
+			    {"name":"each 5 min", "crontab":"*/5 * * * *"}
+			  ]
+            }`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Schedules).Should(HaveLen(2))
+				g.Expect(hookConfig.Schedules[0].SkipIfSnapshotsUnchanged).To(BeTrue())
+				g.Expect(hookConfig.Schedules[1].SkipIfSnapshotsUnchanged).To(BeFalse())
+			},
+		},
+		{
+			"v1 schedule with skipIfSnapshotsUnchanged without snapshots",
+			`{
+              "configVersion":"v1",
+			  "schedule":[
+			    {"name":"reconcile", "crontab":"*/5 * * * *", "skipIfSnapshotsUnchanged":true}
+			  ]
+            }`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+				g.Expect(err.Error()).Should(ContainSubstring("skipIfSnapshotsUnchanged requires"))
+			},
+		},
 		{
 			"v1 yaml full config",
 			`
@@ -429,6 +466,37 @@ schedule:
 				g.Expect(kPods.IncludeSnapshotsFrom).Should(HaveLen(2))
 			},
 		},
+		{
+			"v1 includeAllSnapshots",
+			`
+              configVersion: v1
+              schedule:
+              - crontab: "* * * * *"
+                includeAllSnapshots: true
+              kubernetes:
+              - name: monitor_pods
+                apiVersion: v1
+                kind: Pod
+                includeSnapshotsFrom:
+                - monitor_pods
+              - name: monitor_configmaps
+                apiVersion: v1
+                kind: ConfigMap
+                includeAllSnapshots: true
+            `,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+
+				s := hookConfig.Schedules[0]
+				g.Expect(s.IncludeAllSnapshots).To(BeTrue())
+				g.Expect(s.IncludeSnapshotsFrom).To(Equal([]string{"monitor_pods", "monitor_configmaps"}))
+
+				kPods := hookConfig.OnKubernetesEvents[0]
+				g.Expect(kPods.IncludeSnapshotsFrom).To(Equal([]string{"monitor_pods"}))
+				kConfigMaps := hookConfig.OnKubernetesEvents[1]
+				g.Expect(kConfigMaps.IncludeSnapshotsFrom).To(Equal([]string{"monitor_pods", "monitor_configmaps"}))
+			},
+		},
 		{
 			"v1 kubernetes error on group and includeSnapshotsFrom",
 			`
@@ -642,6 +710,52 @@ kubernetesValidating:
     apiVersions: ["v1"]
     resources: ["pods"]
   timeoutSeconds: 32
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 kubernetesValidating with policy",
+			`
+configVersion: v1
+kubernetesValidating:
+- name: replicas.example.com
+  rules:
+  - operations: ["CREATE", "UPDATE"]
+    apiGroups: ["apps"]
+    apiVersions: ["v1"]
+    resources: ["deployments"]
+  policy:
+    skipWebhook: true
+    validations:
+    - expression: "object.spec.replicas <= 5"
+      message: "replicas should be less than 6"
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.KubernetesValidating).Should(HaveLen(1))
+				policy := hookConfig.KubernetesValidating[0].Webhook.Metadata.Policy
+				g.Expect(policy).ShouldNot(BeNil())
+				g.Expect(policy.SkipWebhook).To(BeTrue())
+				g.Expect(policy.Validations).Should(HaveLen(1))
+				g.Expect(policy.Validations[0].Expression).To(Equal("object.spec.replicas <= 5"))
+			},
+		},
+		{
+			"v1 kubernetesValidating with policy without expression",
+			`
+configVersion: v1
+kubernetesValidating:
+- name: replicas.example.com
+  rules:
+  - operations: ["CREATE", "UPDATE"]
+    apiGroups: ["apps"]
+    apiVersions: ["v1"]
+    resources: ["deployments"]
+  policy:
+    validations:
+    - message: "replicas should be less than 6"
 `,
 			func() {
 				g.Expect(err).Should(HaveOccurred())
@@ -760,6 +874,275 @@ settings:
 				g.Expect(hookConfig.Settings.ExecutionBurst).To(Equal(1))
 			},
 		},
+		{
+			"v1 settings with warmPoolSize",
+			`
+configVersion: v1
+settings:
+  warmPoolSize: 2
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Settings).NotTo(BeNil())
+				g.Expect(hookConfig.Settings.WarmPoolSize).To(Equal(2))
+				g.Expect(hookConfig.Settings.ExecutionMinInterval).To(Equal(time.Duration(0)))
+			},
+		},
+		{
+			"v1 disabled hook",
+			`
+configVersion: v1
+disabled: true
+onStartup: 10
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Disabled).To(BeTrue())
+			},
+		},
+		{
+			"v1 settings with bindingContextFormat",
+			`
+configVersion: v1
+settings:
+  bindingContextFormat: JSONLines
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Settings.BindingContextFormat).To(Equal(types.BindingContextJSONLines))
+			},
+		},
+		{
+			"v1 settings with unknown bindingContextFormat",
+			`
+configVersion: v1
+settings:
+  bindingContextFormat: yaml
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 kubernetes with nameSelector matchExpressions",
+			`
+configVersion: v1
+kubernetes:
+- kind: ConfigMap
+  nameSelector:
+    matchExpressions:
+    - operator: NotIn
+      values: ["kube-root-ca.crt"]
+  namespace:
+    nameSelector:
+      matchExpressions:
+      - operator: Regex
+        values: ["^app-"]
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.OnKubernetesEvents).To(HaveLen(1))
+				monitor := hookConfig.OnKubernetesEvents[0].Monitor
+				g.Expect(monitor.NameSelector.MatchExpressions).To(HaveLen(1))
+				g.Expect(monitor.NamespaceSelector.NameSelector.MatchExpressions).To(HaveLen(1))
+			},
+		},
+		{
+			"v1 kubernetes with invalid nameSelector regex",
+			`
+configVersion: v1
+kubernetes:
+- kind: ConfigMap
+  nameSelector:
+    matchExpressions:
+    - operator: Regex
+      values: ["("]
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 kubernetes with onAnnotationChange",
+			`
+configVersion: v1
+kubernetes:
+- kind: Deployment
+  onAnnotationChange: ["my.io/config-hash", "app"]
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.OnKubernetesEvents).To(HaveLen(1))
+				monitor := hookConfig.OnKubernetesEvents[0].Monitor
+				g.Expect(monitor.OnAnnotationChange).To(Equal([]string{"my.io/config-hash", "app"}))
+			},
+		},
+		{
+			"v1 kubernetes with informer timings",
+			`
+configVersion: v1
+kubernetes:
+- kind: ConfigMap
+  resynchronizationPeriod: 1h
+  listPageSize: 500
+  watchTimeout: 10m
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.OnKubernetesEvents).To(HaveLen(1))
+				monitor := hookConfig.OnKubernetesEvents[0].Monitor
+				g.Expect(monitor.ResyncPeriod).To(Equal(time.Hour))
+				g.Expect(monitor.ListPageSize).To(Equal(int64(500)))
+				g.Expect(monitor.WatchTimeout).To(Equal(10 * time.Minute))
+			},
+		},
+		{
+			"v1 kubernetes with invalid watchTimeout",
+			`
+configVersion: v1
+kubernetes:
+- kind: ConfigMap
+  watchTimeout: ten
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 kubernetes with empty onAnnotationChange",
+			`
+configVersion: v1
+kubernetes:
+- kind: Deployment
+  onAnnotationChange: []
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 command hook",
+			`
+configVersion: v1
+kubernetes:
+- kind: Namespace
+  executeHookOnSynchronization: false
+command: |
+  kubectl label namespace --overwrite "$(jq -r '.[0].object.metadata.name' "$BINDING_CONTEXT_PATH")" team=none
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Command).To(HavePrefix("kubectl label namespace"))
+			},
+		},
+		{
+			"v1 command hook with empty command",
+			`
+configVersion: v1
+onStartup: 1
+command: ""
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 settings with values schema",
+			`
+configVersion: v1
+settings:
+  valuesFile: values.yaml
+  schema:
+    type: object
+    required: ["replicas"]
+    properties:
+      replicas:
+        type: integer
+        minimum: 1
+      mode:
+        type: string
+        default: safe
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Settings.ValuesFile).To(Equal("values.yaml"))
+				g.Expect(hookConfig.Settings.ValuesSchema).NotTo(BeNil())
+				g.Expect(hookConfig.Settings.ValuesSchema.Required).To(Equal([]string{"replicas"}))
+				g.Expect(hookConfig.Settings.ValuesSchema.Properties).To(HaveKey("mode"))
+			},
+		},
+		{
+			"v1 settings with impersonate",
+			`
+configVersion: v1
+settings:
+  impersonate:
+    serviceAccount: ns/hook-sa
+    groups: ["hooks"]
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Settings.Impersonate).NotTo(BeNil())
+				g.Expect(hookConfig.Settings.Impersonate.User).To(Equal("system:serviceaccount:ns:hook-sa"))
+				g.Expect(hookConfig.Settings.Impersonate.Groups).To(Equal([]string{"hooks"}))
+			},
+		},
+		{
+			"v1 settings with impersonate user and serviceAccount",
+			`
+configVersion: v1
+settings:
+  impersonate:
+    user: admin
+    serviceAccount: ns/hook-sa
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
+		{
+			"v1 settings with lock",
+			`
+configVersion: v1
+settings:
+  lock:
+    name: sync-external-dns
+    namespace: shared-locks
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Settings.Lock).NotTo(BeNil())
+				g.Expect(hookConfig.Settings.Lock.Name).To(Equal("sync-external-dns"))
+				g.Expect(hookConfig.Settings.Lock.Namespace).To(Equal("shared-locks"))
+			},
+		},
+		{
+			"v1 settings with check",
+			`
+configVersion: v1
+settings:
+  check:
+    interval: 30s
+`,
+			func() {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(hookConfig.Settings.Check).NotTo(BeNil())
+				g.Expect(hookConfig.Settings.Check.Interval).To(Equal(30 * time.Second))
+				g.Expect(hookConfig.Settings.Check.Timeout).To(Equal(DefaultCheckTimeout))
+			},
+		},
+		{
+			"v1 settings with invalid check interval",
+			`
+configVersion: v1
+settings:
+  check:
+    interval: 0s
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+			},
+		},
 		{
 			"v1 settings with error",
 			`
@@ -772,6 +1155,19 @@ settings:
 				g.Expect(err).Should(HaveOccurred())
 			},
 		},
+		{
+			"v1 settings without executionBurst",
+			`
+configVersion: v1
+settings:
+  executionMinInterval: 3s
+  warmPoolSize: 2
+`,
+			func() {
+				g.Expect(err).Should(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("should be set together"))
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -783,6 +1179,410 @@ settings:
 	}
 }
 
+func Test_HookConfig_V1_Composite(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetes:
+- name: cm
+  kind: ConfigMap
+  nameSelector:
+    matchNames: ["x"]
+- name: deploy
+  kind: Deployment
+  nameSelector:
+    matchNames: ["y"]
+composite:
+- name: cm-changed-while-ready
+  triggers: ["cm"]
+  conditions:
+  - binding: cm
+  - binding: deploy
+    jqFilter: '.[0].object.status.readyReplicas > 0'
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.Composite).To(HaveLen(1))
+	composite := hookConfig.Composite[0]
+	g.Expect(composite.BindingName).To(Equal("cm-changed-while-ready"))
+	g.Expect(composite.Triggers).To(Equal([]string{"cm"}))
+	g.Expect(composite.Conditions).To(HaveLen(2))
+	g.Expect(composite.IncludeSnapshotsFrom).To(Equal([]string{"cm", "deploy"}))
+	g.Expect(composite.Queue).To(Equal("main"))
+
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetes:
+- name: cm
+  kind: ConfigMap
+composite:
+- name: unknown-binding
+  conditions:
+  - binding: deploy
+`))
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_HookConfig_V1_OnMessage(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetes:
+- name: cm
+  kind: ConfigMap
+onMessage:
+- name: orders
+  nats:
+    url: nats://nats:4222
+    subject: orders.>
+    queueGroup: shell-operator
+  includeSnapshotsFrom: ["cm"]
+- name: events
+  kafka:
+    restProxyURL: http://kafka-rest-proxy:8082
+    topic: events
+    consumerGroup: shell-operator
+  queue: events
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.HasBinding(types.OnMessage)).To(BeTrue())
+	g.Expect(hookConfig.OnMessage).To(HaveLen(2))
+	g.Expect(hookConfig.Triggers()).To(HaveLen(2))
+
+	orders := hookConfig.OnMessage[0]
+	g.Expect(orders.BindingName).To(Equal("orders"))
+	g.Expect(orders.BindingType).To(Equal(types.OnMessage))
+	g.Expect(orders.Id).ToNot(BeEmpty())
+	g.Expect(orders.Queue).To(Equal("main"))
+	g.Expect(orders.IncludeSnapshotsFrom).To(Equal([]string{"cm"}))
+	g.Expect(orders.Source).To(Equal(&trigger_manager.NatsSource{
+		URL:        "nats://nats:4222",
+		Subject:    "orders.>",
+		QueueGroup: "shell-operator",
+	}))
+
+	events := hookConfig.OnMessage[1]
+	g.Expect(events.Queue).To(Equal("events"))
+	g.Expect(events.Source).To(Equal(&trigger_manager.KafkaSource{
+		RestProxyURL:  "http://kafka-rest-proxy:8082",
+		Topic:         "events",
+		ConsumerGroup: "shell-operator",
+	}))
+
+	// Source is required.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onMessage:
+- name: orders
+  queue: main
+`))
+	g.Expect(err).Should(HaveOccurred())
+
+	// Only one source is allowed.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onMessage:
+- name: orders
+  nats:
+    url: nats://nats:4222
+    subject: orders
+  kafka:
+    restProxyURL: http://kafka-rest-proxy:8082
+    topic: orders
+    consumerGroup: shell-operator
+`))
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_HookConfig_V1_OnWebhook(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onWebhook:
+- name: github-push
+  tokenEnv: GITHUB_WEBHOOK_SECRET
+- name: alerts
+  path: /alertmanager/critical/
+  tokenFile: /etc/tokens/alertmanager
+  maxBodySize: 65536
+  queue: alerts
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.HasBinding(types.OnWebhook)).To(BeTrue())
+	g.Expect(hookConfig.OnWebhook).To(HaveLen(2))
+
+	g.Expect(hookConfig.OnWebhook[0].BindingType).To(Equal(types.OnWebhook))
+	g.Expect(hookConfig.OnWebhook[0].Queue).To(Equal("main"))
+	g.Expect(hookConfig.OnWebhook[0].Source).To(Equal(&trigger_manager.WebhookSource{
+		Path:     "github-push",
+		TokenEnv: "GITHUB_WEBHOOK_SECRET",
+	}))
+	g.Expect(hookConfig.OnWebhook[1].Queue).To(Equal("alerts"))
+	g.Expect(hookConfig.OnWebhook[1].Source).To(Equal(&trigger_manager.WebhookSource{
+		Path:        "alertmanager/critical",
+		TokenFile:   "/etc/tokens/alertmanager",
+		MaxBodySize: 65536,
+	}))
+
+	// Token is required.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onWebhook:
+- name: github-push
+`))
+	g.Expect(err).Should(HaveOccurred())
+
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onWebhook:
+- name: github-push
+  path: "../status"
+  tokenEnv: GITHUB_WEBHOOK_SECRET
+`))
+	g.Expect(err).Should(HaveOccurred())
+
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onWebhook:
+- name: github push
+  tokenEnv: GITHUB_WEBHOOK_SECRET
+`))
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_HookConfig_V1_OnFileChange(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onFileChange:
+- name: configs
+  paths: ["/host/etc/myapp/conf.d/*.yaml"]
+  events: ["Create", "Write"]
+  debounce: 2s
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.HasBinding(types.OnFileChange)).To(BeTrue())
+	g.Expect(hookConfig.OnFileChange).To(HaveLen(1))
+	g.Expect(hookConfig.OnFileChange[0].BindingType).To(Equal(types.OnFileChange))
+	g.Expect(hookConfig.OnFileChange[0].Source).To(Equal(&trigger_manager.FileWatchSource{
+		Paths:      []string{"/host/etc/myapp/conf.d/*.yaml"},
+		Operations: []string{"Create", "Write"},
+		Debounce:   2 * time.Second,
+	}))
+
+	for _, cfg := range []string{
+		// Relative path.
+		`paths: ["conf.d/*.yaml"]`,
+		// Malformed glob.
+		`paths: ["/etc/[conf.yaml"]`,
+		// Unknown event.
+		`paths: ["/etc/*.yaml"]
+  events: ["Open"]`,
+		`paths: ["/etc/*.yaml"]
+  debounce: soon`,
+	} {
+		hookConfig = &HookConfig{}
+		err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onFileChange:
+- name: configs
+  ` + cfg + `
+`))
+		g.Expect(err).Should(HaveOccurred(), cfg)
+	}
+}
+
+func Test_HookConfig_V1_OnSignal(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onSignal:
+- name: reconcile
+  signal: SIGUSR1
+  queue: reconcile
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.HasBinding(types.OnSignal)).To(BeTrue())
+	g.Expect(hookConfig.OnSignal).To(HaveLen(1))
+	g.Expect(hookConfig.OnSignal[0].Queue).To(Equal("reconcile"))
+	g.Expect(hookConfig.OnSignal[0].Source).To(Equal(&trigger_manager.SignalSource{Signal: "SIGUSR1"}))
+
+	// SIGTERM is reserved for the graceful shutdown.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onSignal:
+- name: reconcile
+  signal: SIGTERM
+`))
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_HookConfig_V1_KubernetesEvents(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetesEvents:
+- name: failed-mounts
+  type: Warning
+  involvedObjectKind: Pod
+  reasons: ["FailedMount", "FailedAttachVolume"]
+  dedupWindow: 10m
+  rate: 2
+  namespace:
+    nameSelector:
+      matchNames: ["default"]
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.OnKubernetesEvents).To(HaveLen(1))
+
+	cfg := hookConfig.OnKubernetesEvents[0]
+	g.Expect(cfg.BindingName).To(Equal("failed-mounts"))
+	g.Expect(cfg.ExecuteHookOnSynchronization).To(BeFalse())
+	g.Expect(cfg.Monitor.Kind).To(Equal("Event"))
+	g.Expect(cfg.Monitor.EventTypes).To(Equal([]kubetypes.WatchEventType{kubetypes.WatchEventAdded, kubetypes.WatchEventModified}))
+	g.Expect(cfg.Monitor.FieldSelector.MatchExpressions).To(HaveLen(2))
+	g.Expect(cfg.Monitor.EventsFilter).To(Equal(&kube_events_manager.EventsFilterConfig{
+		Reasons:     []string{"FailedMount", "FailedAttachVolume"},
+		DedupWindow: 10 * time.Minute,
+		Rate:        2,
+		Burst:       DefaultKubernetesEventsBurst,
+	}))
+
+	// Single reason is filtered by the API server.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetesEvents:
+- name: oom
+  reasons: ["OOMKilling"]
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.OnKubernetesEvents[0].Monitor.FieldSelector.MatchExpressions).To(Equal([]kubetypes.FieldSelectorRequirement{
+		{Field: "reason", Operator: "=", Value: "OOMKilling"},
+	}))
+	g.Expect(hookConfig.OnKubernetesEvents[0].Monitor.EventsFilter.DedupWindow).To(Equal(DefaultKubernetesEventsDedupWindow))
+
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetesEvents:
+- name: oom
+  dedupWindow: often
+`))
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_HookConfig_V1_EnvFromObject(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetes:
+- name: pods
+  kind: Pod
+  envFromObject:
+    NODE_NAME: .spec.nodeName
+    APP: .metadata.labels["app.kubernetes.io/name"]
+kubernetesEvents:
+- name: oom
+  envFromObject:
+    POD_NAME: .involvedObject.name
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.OnKubernetesEvents).To(HaveLen(2))
+	g.Expect(hookConfig.OnKubernetesEvents[0].EnvFromObject).To(HaveLen(2))
+	g.Expect(hookConfig.OnKubernetesEvents[0].EnvFromObject["NODE_NAME"].String()).To(Equal(".spec.nodeName"))
+	g.Expect(hookConfig.OnKubernetesEvents[1].EnvFromObject).To(HaveKey("POD_NAME"))
+
+	for _, invalid := range []string{
+		"NODE-NAME: .spec.nodeName",
+		"NODE_NAME: spec.nodeName",
+		"NODE_NAME: .spec.containers[x]",
+		"PATH: .spec.nodeName",
+		"LD_PRELOAD: .spec.nodeName",
+		"KUBECONFIG: .spec.nodeName",
+		"KUBERNETES_SERVICE_HOST: .spec.nodeName",
+		"BINDING_CONTEXT_PATH: .spec.nodeName",
+	} {
+		hookConfig = &HookConfig{}
+		err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+kubernetes:
+- kind: Pod
+  envFromObject:
+    ` + invalid + `
+`))
+		g.Expect(err).Should(HaveOccurred(), invalid)
+	}
+}
+
+func Test_HookConfig_V1_OnAlert(t *testing.T) {
+	g := NewWithT(t)
+
+	hookConfig := &HookConfig{}
+	err := hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onAlert:
+- name: disk
+  path: /alerts/disk/
+  tokenEnv: ALERTMANAGER_TOKEN
+  alertNames: ["NodeDiskFull"]
+  matchLabels:
+    severity: critical
+  status: ["firing"]
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookConfig.HasBinding(types.OnAlert)).To(BeTrue())
+	g.Expect(hookConfig.OnAlert).To(HaveLen(1))
+	g.Expect(hookConfig.OnAlert[0].Queue).To(Equal("main"))
+	g.Expect(hookConfig.OnAlert[0].Source).To(Equal(&trigger_manager.AlertSource{
+		Path:        "alerts/disk",
+		TokenEnv:    "ALERTMANAGER_TOKEN",
+		AlertNames:  []string{"NodeDiskFull"},
+		MatchLabels: map[string]string{"severity": "critical"},
+		Statuses:    []string{"firing"},
+	}))
+
+	// Token is required.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onAlert:
+- name: disk
+`))
+	g.Expect(err).Should(HaveOccurred())
+
+	// Unknown status.
+	hookConfig = &HookConfig{}
+	err = hookConfig.LoadAndValidate([]byte(`
+configVersion: v1
+onAlert:
+- name: disk
+  tokenEnv: ALERTMANAGER_TOKEN
+  status: ["pending"]
+`))
+	g.Expect(err).Should(HaveOccurred())
+}
+
 // load kubernetes configs with errors
 func Test_HookConfig_V1_Kubernetes_Validate(t *testing.T) {
 	g := NewWithT(t)
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -16,6 +19,7 @@ import (
 	"github.com/flant/shell-operator/pkg/kube_events_manager"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 	. "github.com/flant/shell-operator/pkg/schedule_manager/types"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
 	"github.com/flant/shell-operator/pkg/webhook/conversion"
 	"github.com/flant/shell-operator/pkg/webhook/validating/validation"
@@ -26,10 +30,20 @@ type HookConfigV1 struct {
 	OnStartup            interface{}                    `json:"onStartup"`
 	Schedule             []ScheduleConfigV1             `json:"schedule"`
 	OnKubernetesEvent    []OnKubernetesEventConfigV1    `json:"kubernetes"`
+	KubernetesEvents     []KubernetesEventsConfigV1     `json:"kubernetesEvents"`
 	KubernetesValidating []KubernetesAdmissionConfigV1  `json:"kubernetesValidating"`
 	KubernetesMutating   []KubernetesAdmissionConfigV1  `json:"kubernetesMutating"`
 	KubernetesConversion []KubernetesConversionConfigV1 `json:"kubernetesCustomResourceConversion"`
+	Composite            []CompositeConfigV1            `json:"composite"`
+	OnMessage            []OnMessageConfigV1            `json:"onMessage"`
+	OnWebhook            []OnWebhookConfigV1            `json:"onWebhook"`
+	OnFileChange         []OnFileChangeConfigV1         `json:"onFileChange"`
+	OnSignal             []OnSignalConfigV1             `json:"onSignal"`
+	OnAlert              []OnAlertConfigV1              `json:"onAlert"`
 	Settings             *SettingsV1                    `json:"settings"`
+	Disabled             bool                           `json:"disabled,omitempty"`
+	Profiles             []string                       `json:"profiles,omitempty"`
+	Command              string                         `json:"command,omitempty"`
 }
 
 // Schedule configuration
@@ -38,8 +52,107 @@ type ScheduleConfigV1 struct {
 	Crontab              string   `json:"crontab"`
 	AllowFailure         bool     `json:"allowFailure"`
 	IncludeSnapshotsFrom []string `json:"includeSnapshotsFrom"`
+	IncludeAllSnapshots  bool     `json:"includeAllSnapshots,omitempty"`
 	Queue                string   `json:"queue"`
 	Group                string   `json:"group,omitempty"`
+	// SkipIfSnapshotsUnchanged skips the run if snapshots are not changed since the last successful run.
+	SkipIfSnapshotsUnchanged bool `json:"skipIfSnapshotsUnchanged,omitempty"`
+}
+
+// Composite trigger configuration
+type CompositeConfigV1 struct {
+	Name                 string                 `json:"name"`
+	Triggers             []string               `json:"triggers,omitempty"`
+	Conditions           []CompositeConditionV1 `json:"conditions"`
+	IncludeSnapshotsFrom []string               `json:"includeSnapshotsFrom,omitempty"`
+	Queue                string                 `json:"queue,omitempty"`
+	AllowFailure         bool                   `json:"allowFailure,omitempty"`
+}
+
+// Message queue binding configuration
+type OnMessageConfigV1 struct {
+	Name                 string         `json:"name"`
+	Nats                 *NatsConfigV1  `json:"nats,omitempty"`
+	Kafka                *KafkaConfigV1 `json:"kafka,omitempty"`
+	IncludeSnapshotsFrom []string       `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool           `json:"includeAllSnapshots,omitempty"`
+	Queue                string         `json:"queue,omitempty"`
+	Group                string         `json:"group,omitempty"`
+	AllowFailure         bool           `json:"allowFailure,omitempty"`
+}
+
+type NatsConfigV1 struct {
+	URL        string `json:"url"`
+	Subject    string `json:"subject"`
+	QueueGroup string `json:"queueGroup,omitempty"`
+}
+
+// KafkaConfigV1 is a subscription to a Kafka topic. The native Kafka protocol is not
+// supported, records are consumed via the Confluent REST Proxy.
+type KafkaConfigV1 struct {
+	RestProxyURL  string `json:"restProxyURL"`
+	Topic         string `json:"topic"`
+	ConsumerGroup string `json:"consumerGroup"`
+}
+
+// HTTP webhook binding configuration
+type OnWebhookConfigV1 struct {
+	Name                 string   `json:"name"`
+	Path                 string   `json:"path,omitempty"`
+	TokenEnv             string   `json:"tokenEnv,omitempty"`
+	TokenFile            string   `json:"tokenFile,omitempty"`
+	MaxBodySize          int64    `json:"maxBodySize,omitempty"`
+	IncludeSnapshotsFrom []string `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool     `json:"includeAllSnapshots,omitempty"`
+	Queue                string   `json:"queue,omitempty"`
+	Group                string   `json:"group,omitempty"`
+	AllowFailure         bool     `json:"allowFailure,omitempty"`
+}
+
+// File watch binding configuration
+type OnFileChangeConfigV1 struct {
+	Name                 string   `json:"name"`
+	Paths                []string `json:"paths"`
+	Events               []string `json:"events,omitempty"`
+	Debounce             string   `json:"debounce,omitempty"`
+	IncludeSnapshotsFrom []string `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool     `json:"includeAllSnapshots,omitempty"`
+	Queue                string   `json:"queue,omitempty"`
+	Group                string   `json:"group,omitempty"`
+	AllowFailure         bool     `json:"allowFailure,omitempty"`
+}
+
+// Signal binding configuration
+type OnSignalConfigV1 struct {
+	Name                 string   `json:"name"`
+	Signal               string   `json:"signal"`
+	IncludeSnapshotsFrom []string `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool     `json:"includeAllSnapshots,omitempty"`
+	Queue                string   `json:"queue,omitempty"`
+	Group                string   `json:"group,omitempty"`
+	AllowFailure         bool     `json:"allowFailure,omitempty"`
+}
+
+// Alertmanager receiver binding configuration
+type OnAlertConfigV1 struct {
+	Name                 string            `json:"name"`
+	Path                 string            `json:"path,omitempty"`
+	TokenEnv             string            `json:"tokenEnv,omitempty"`
+	TokenFile            string            `json:"tokenFile,omitempty"`
+	MaxBodySize          int64             `json:"maxBodySize,omitempty"`
+	AlertNames           []string          `json:"alertNames,omitempty"`
+	MatchLabels          map[string]string `json:"matchLabels,omitempty"`
+	Status               []string          `json:"status,omitempty"`
+	IncludeSnapshotsFrom []string          `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool              `json:"includeAllSnapshots,omitempty"`
+	Queue                string            `json:"queue,omitempty"`
+	Group                string            `json:"group,omitempty"`
+	AllowFailure         bool              `json:"allowFailure,omitempty"`
+}
+
+type CompositeConditionV1 struct {
+	Binding  string `json:"binding"`
+	JqFilter string `json:"jqFilter,omitempty"`
 }
 
 // version 1 of kubernetes event configuration
@@ -61,10 +174,45 @@ type OnKubernetesEventConfigV1 struct {
 	AllowFailure                 bool                     `json:"allowFailure,omitempty"`
 	ResynchronizationPeriod      string                   `json:"resynchronizationPeriod,omitempty"`
 	IncludeSnapshotsFrom         []string                 `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots          bool                     `json:"includeAllSnapshots,omitempty"`
+	Queue                        string                   `json:"queue,omitempty"`
+	Group                        string                   `json:"group,omitempty"`
+	Debounce                     string                   `json:"debounce,omitempty"`
+	AbsentAfter                  string                   `json:"absentAfter,omitempty"`
+	OnAnnotationChange           []string                 `json:"onAnnotationChange,omitempty"`
+	ListPageSize                 int64                    `json:"listPageSize,omitempty"`
+	WatchTimeout                 string                   `json:"watchTimeout,omitempty"`
+	EnvFromObject                map[string]string        `json:"envFromObject,omitempty"`
+}
+
+// KubernetesEventsConfigV1 is a kubernetes binding for core Event objects
+// with deduplication and rate limiting.
+type KubernetesEventsConfigV1 struct {
+	Name                         string                   `json:"name"`
+	Namespace                    *KubeNamespaceSelectorV1 `json:"namespace,omitempty"`
+	Type                         string                   `json:"type,omitempty"`
+	Reasons                      []string                 `json:"reasons,omitempty"`
+	InvolvedObjectKind           string                   `json:"involvedObjectKind,omitempty"`
+	JqFilter                     string                   `json:"jqFilter,omitempty"`
+	DedupWindow                  string                   `json:"dedupWindow,omitempty"`
+	Rate                         float64                  `json:"rate,omitempty"`
+	Burst                        int                      `json:"burst,omitempty"`
+	ExecuteHookOnSynchronization string                   `json:"executeHookOnSynchronization,omitempty"`
+	IncludeSnapshotsFrom         []string                 `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots          bool                     `json:"includeAllSnapshots,omitempty"`
 	Queue                        string                   `json:"queue,omitempty"`
 	Group                        string                   `json:"group,omitempty"`
+	AllowFailure                 bool                     `json:"allowFailure,omitempty"`
+	EnvFromObject                map[string]string        `json:"envFromObject,omitempty"`
 }
 
+// Defaults for kubernetesEvents bindings.
+const (
+	DefaultKubernetesEventsDedupWindow = 5 * time.Minute
+	DefaultKubernetesEventsRate        = 10
+	DefaultKubernetesEventsBurst       = 50
+)
+
 type KubeNameSelectorV1 NameSelector
 
 type KubeFieldSelectorV1 FieldSelector
@@ -75,6 +223,7 @@ type KubeNamespaceSelectorV1 NamespaceSelector
 type KubernetesAdmissionConfigV1 struct {
 	Name                 string                   `json:"name,omitempty"`
 	IncludeSnapshotsFrom []string                 `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool                     `json:"includeAllSnapshots,omitempty"`
 	Group                string                   `json:"group,omitempty"`
 	Rules                []v1.RuleWithOperations  `json:"rules,omitempty"`
 	FailurePolicy        *v1.FailurePolicyType    `json:"failurePolicy"`
@@ -82,12 +231,72 @@ type KubernetesAdmissionConfigV1 struct {
 	Namespace            *KubeNamespaceSelectorV1 `json:"namespace,omitempty"`
 	SideEffects          *v1.SideEffectClass      `json:"sideEffects"`
 	TimeoutSeconds       *int32                   `json:"timeoutSeconds,omitempty"`
+	TLS                  *AdmissionTLSConfigV1    `json:"tls,omitempty"`
+	Policy               *AdmissionPolicyConfigV1 `json:"policy,omitempty"`
+	Limits               *AdmissionLimitsConfigV1 `json:"limits,omitempty"`
+}
+
+// AdmissionLimitsConfigV1 defines concurrency, rate and circuit breaker limits for the webhook.
+type AdmissionLimitsConfigV1 struct {
+	MaxConcurrent    int     `json:"maxConcurrent,omitempty"`
+	Rate             float64 `json:"rate,omitempty"`
+	Burst            int     `json:"burst,omitempty"`
+	FailureThreshold int     `json:"failureThreshold,omitempty"`
+	OpenDuration     string  `json:"openDuration,omitempty"`
+}
+
+func (c *AdmissionLimitsConfigV1) toLimitsConfig() (*admission.LimitsConfig, error) {
+	if c == nil {
+		return nil, nil
+	}
+	res := &admission.LimitsConfig{
+		MaxConcurrent:    c.MaxConcurrent,
+		Rate:             c.Rate,
+		Burst:            c.Burst,
+		FailureThreshold: c.FailureThreshold,
+		OpenDuration:     30 * time.Second,
+	}
+	if c.OpenDuration != "" {
+		d, err := time.ParseDuration(c.OpenDuration)
+		if err != nil {
+			return nil, fmt.Errorf("limits.openDuration is invalid: %v", err)
+		}
+		res.OpenDuration = d
+	}
+	return res, nil
+}
+
+// AdmissionPolicyConfigV1 defines CEL validations to generate a ValidatingAdmissionPolicy.
+type AdmissionPolicyConfigV1 struct {
+	Validations []admission.PolicyValidation `json:"validations"`
+	SkipWebhook bool                         `json:"skipWebhook,omitempty"`
+}
+
+// AdmissionTLSConfigV1 defines a dedicated Service and serving certificate for the webhook.
+type AdmissionTLSConfigV1 struct {
+	ServiceName string `json:"serviceName"`
+	CertFile    string `json:"certFile"`
+	KeyFile     string `json:"keyFile"`
+	CAFile      string `json:"caFile"`
+}
+
+func (c *AdmissionTLSConfigV1) toServingConfig() *admission.ServingConfig {
+	if c == nil {
+		return nil
+	}
+	return &admission.ServingConfig{
+		ServiceName:    c.ServiceName,
+		ServerCertPath: c.CertFile,
+		ServerKeyPath:  c.KeyFile,
+		CAPath:         c.CAFile,
+	}
 }
 
 // version 1 of kubernetes conversion configuration
 type KubernetesConversionConfigV1 struct {
 	Name                 string            `json:"name,omitempty"`
 	IncludeSnapshotsFrom []string          `json:"includeSnapshotsFrom,omitempty"`
+	IncludeAllSnapshots  bool              `json:"includeAllSnapshots,omitempty"`
 	Group                string            `json:"group,omitempty"`
 	CrdName              string            `json:"crdName,omitempty"`
 	Conversions          []conversion.Rule `json:"conversions,omitempty"`
@@ -95,12 +304,56 @@ type KubernetesConversionConfigV1 struct {
 
 // version 1 of hook settings
 type SettingsV1 struct {
-	ExecutionMinInterval string `json:"executionMinInterval,omitempty"`
-	ExecutionBurst       string `json:"executionBurst,omitempty"`
+	ExecutionMinInterval string   `json:"executionMinInterval,omitempty"`
+	ExecutionBurst       string   `json:"executionBurst,omitempty"`
+	ExecutionTimeout     string   `json:"executionTimeout,omitempty"`
+	WarmPoolSize         string   `json:"warmPoolSize,omitempty"`
+	WorkingDir           string   `json:"workingDir,omitempty"`
+	Umask                string   `json:"umask,omitempty"`
+	AllowedEnvPrefixes   []string `json:"allowedEnvPrefixes,omitempty"`
+	RunAsUser            string   `json:"runAsUser,omitempty"`
+	RunAsGroup           string   `json:"runAsGroup,omitempty"`
+	// OutputParseErrorPolicy is one of Fail, Retry or Ignore.
+	OutputParseErrorPolicy string         `json:"outputParseErrorPolicy,omitempty"`
+	Impersonate            *ImpersonateV1 `json:"impersonate,omitempty"`
+	// BindingContextFormat is one of JSON or JSONLines.
+	BindingContextFormat string `json:"bindingContextFormat,omitempty"`
+	// Schema is an OpenAPI schema to validate user values of the hook.
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	// ValuesFile is a path of the YAML file with user values.
+	ValuesFile string `json:"valuesFile,omitempty"`
+	// Lock enables a distributed lock for the hook.
+	Lock *LockV1 `json:"lock,omitempty"`
+	// Check enables self-checks: the hook is executed with --check.
+	Check *CheckV1 `json:"check,omitempty"`
+}
+
+// CheckV1 defines self-checks of the hook. Empty fields get default values.
+type CheckV1 struct {
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+// LockV1 defines a Lease of the distributed lock. Default name is derived from the hook name.
+type LockV1 struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ImpersonateV1 defines a user to impersonate for API operations of the hook.
+type ImpersonateV1 struct {
+	User   string   `json:"user,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	// ServiceAccount is a 'namespace/name' of the ServiceAccount, an alternative to User.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
 }
 
 // ConvertAndCheck fills non-versioned structures and run inter-field checks not covered by OpenAPI schemas.
 func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
+	c.Disabled = cv1.Disabled
+	c.Profiles = cv1.Profiles
+	c.Command = cv1.Command
+
 	c.Settings, err = cv1.CheckAndConvertSettings(cv1.Settings)
 	if err != nil {
 		return err
@@ -131,6 +384,32 @@ func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
 		monitor.WithNamespaceSelector((*NamespaceSelector)(kubeCfg.Namespace))
 		monitor.WithLabelSelector(kubeCfg.LabelSelector)
 		monitor.JqFilter = kubeCfg.JqFilter
+		if kubeCfg.Debounce != "" {
+			monitor.DebounceWindow, err = time.ParseDuration(kubeCfg.Debounce)
+			if err != nil {
+				return fmt.Errorf("invalid kubernetes config [%d]: debounce is invalid: %v", i, err)
+			}
+		}
+		if kubeCfg.AbsentAfter != "" {
+			monitor.AbsentAfter, err = time.ParseDuration(kubeCfg.AbsentAfter)
+			if err != nil {
+				return fmt.Errorf("invalid kubernetes config [%d]: absentAfter is invalid: %v", i, err)
+			}
+		}
+		monitor.OnAnnotationChange = kubeCfg.OnAnnotationChange
+		if kubeCfg.ResynchronizationPeriod != "" {
+			monitor.ResyncPeriod, err = time.ParseDuration(kubeCfg.ResynchronizationPeriod)
+			if err != nil {
+				return fmt.Errorf("invalid kubernetes config [%d]: resynchronizationPeriod is invalid: %v", i, err)
+			}
+		}
+		monitor.ListPageSize = kubeCfg.ListPageSize
+		if kubeCfg.WatchTimeout != "" {
+			monitor.WatchTimeout, err = time.ParseDuration(kubeCfg.WatchTimeout)
+			if err != nil {
+				return fmt.Errorf("invalid kubernetes config [%d]: watchTimeout is invalid: %v", i, err)
+			}
+		}
 		// executeHookOnEvent is a priority
 		if kubeCfg.ExecuteHookOnEvents != nil {
 			monitor.WithEventTypes(kubeCfg.ExecuteHookOnEvents)
@@ -151,6 +430,7 @@ func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
 			kubeConfig.BindingName = kubeCfg.Name
 		}
 		kubeConfig.IncludeSnapshotsFrom = kubeCfg.IncludeSnapshotsFrom
+		kubeConfig.IncludeAllSnapshots = kubeCfg.IncludeAllSnapshots
 		if kubeCfg.Queue == "" {
 			kubeConfig.Queue = "main"
 		} else {
@@ -177,9 +457,22 @@ func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
 		}
 		kubeConfig.Monitor.KeepFullObjectsInMemory = kubeConfig.KeepFullObjectsInMemory
 
+		kubeConfig.EnvFromObject, err = ConvertEnvFromObject(kubeCfg.EnvFromObject)
+		if err != nil {
+			return fmt.Errorf("invalid kubernetes config [%d]: %v", i, err)
+		}
+
 		c.OnKubernetesEvents = append(c.OnKubernetesEvents, kubeConfig)
 	}
 
+	for i, rawEvents := range cv1.KubernetesEvents {
+		err := cv1.CheckKubernetesEvents(rawEvents)
+		if err != nil {
+			return fmt.Errorf("invalid kubernetesEvents config [%d]: %v", i, err)
+		}
+		c.OnKubernetesEvents = append(c.OnKubernetesEvents, cv1.ConvertKubernetesEvents(rawEvents, i))
+	}
+
 	// Chsck snapshots in result config.
 	for i, kubeCfg := range c.OnKubernetesEvents {
 		if len(kubeCfg.IncludeSnapshotsFrom) > 0 {
@@ -258,6 +551,68 @@ func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
 		c.KubernetesConversion = append(c.KubernetesConversion, conversionConfig)
 	}
 
+	// Composite triggers depend on kubernetes bindings.
+	c.Composite = []CompositeConfig{}
+	for i, rawComposite := range cv1.Composite {
+		err := cv1.CheckComposite(c.OnKubernetesEvents, rawComposite)
+		if err != nil {
+			return fmt.Errorf("invalid composite config [%d]: %v", i, err)
+		}
+		c.Composite = append(c.Composite, cv1.ConvertComposite(rawComposite))
+	}
+
+	// Message bindings with includeSnapshotsFrom depend on kubernetes bindings.
+	c.OnMessage = []TriggerConfig{}
+	for i, rawMessage := range cv1.OnMessage {
+		err := cv1.CheckOnMessage(c.OnKubernetesEvents, rawMessage)
+		if err != nil {
+			return fmt.Errorf("invalid onMessage config [%d]: %v", i, err)
+		}
+		c.OnMessage = append(c.OnMessage, cv1.ConvertOnMessage(rawMessage))
+	}
+
+	// Webhook bindings with includeSnapshotsFrom depend on kubernetes bindings.
+	c.OnWebhook = []TriggerConfig{}
+	for i, rawWebhook := range cv1.OnWebhook {
+		err := cv1.CheckOnWebhook(c.OnKubernetesEvents, rawWebhook)
+		if err != nil {
+			return fmt.Errorf("invalid onWebhook config [%d]: %v", i, err)
+		}
+		c.OnWebhook = append(c.OnWebhook, cv1.ConvertOnWebhook(rawWebhook))
+	}
+
+	// File watch bindings with includeSnapshotsFrom depend on kubernetes bindings.
+	c.OnFileChange = []TriggerConfig{}
+	for i, rawFileChange := range cv1.OnFileChange {
+		err := cv1.CheckOnFileChange(c.OnKubernetesEvents, rawFileChange)
+		if err != nil {
+			return fmt.Errorf("invalid onFileChange config [%d]: %v", i, err)
+		}
+		c.OnFileChange = append(c.OnFileChange, cv1.ConvertOnFileChange(rawFileChange))
+	}
+
+	// Signal bindings with includeSnapshotsFrom depend on kubernetes bindings.
+	c.OnSignal = []TriggerConfig{}
+	for i, rawSignal := range cv1.OnSignal {
+		if len(rawSignal.IncludeSnapshotsFrom) > 0 {
+			err := CheckIncludeSnapshots(c.OnKubernetesEvents, rawSignal.IncludeSnapshotsFrom...)
+			if err != nil {
+				return fmt.Errorf("invalid onSignal config [%d]: includeSnapshotsFrom is invalid: %v", i, err)
+			}
+		}
+		c.OnSignal = append(c.OnSignal, cv1.ConvertOnSignal(rawSignal))
+	}
+
+	// Alert bindings with includeSnapshotsFrom depend on kubernetes bindings.
+	c.OnAlert = []TriggerConfig{}
+	for i, rawAlert := range cv1.OnAlert {
+		err := cv1.CheckOnAlert(c.OnKubernetesEvents, rawAlert)
+		if err != nil {
+			return fmt.Errorf("invalid onAlert config [%d]: %v", i, err)
+		}
+		c.OnAlert = append(c.OnAlert, cv1.ConvertOnAlert(rawAlert))
+	}
+
 	// Update IncludeSnapshotsFrom for every binding with a group.
 	// Merge binding's IncludeSnapshotsFrom with snapshots list calculated for group.
 	groupSnapshots := make(map[string][]string)
@@ -288,6 +643,32 @@ func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
 	}
 	c.Schedules = newSchedules
 
+	for i, cfg := range c.OnMessage {
+		if snapshots, ok := groupSnapshots[cfg.Group]; ok {
+			c.OnMessage[i].IncludeSnapshotsFrom = MergeArrays(cfg.IncludeSnapshotsFrom, snapshots)
+		}
+	}
+	for i, cfg := range c.OnWebhook {
+		if snapshots, ok := groupSnapshots[cfg.Group]; ok {
+			c.OnWebhook[i].IncludeSnapshotsFrom = MergeArrays(cfg.IncludeSnapshotsFrom, snapshots)
+		}
+	}
+	for i, cfg := range c.OnFileChange {
+		if snapshots, ok := groupSnapshots[cfg.Group]; ok {
+			c.OnFileChange[i].IncludeSnapshotsFrom = MergeArrays(cfg.IncludeSnapshotsFrom, snapshots)
+		}
+	}
+	for i, cfg := range c.OnSignal {
+		if snapshots, ok := groupSnapshots[cfg.Group]; ok {
+			c.OnSignal[i].IncludeSnapshotsFrom = MergeArrays(cfg.IncludeSnapshotsFrom, snapshots)
+		}
+	}
+	for i, cfg := range c.OnAlert {
+		if snapshots, ok := groupSnapshots[cfg.Group]; ok {
+			c.OnAlert[i].IncludeSnapshotsFrom = MergeArrays(cfg.IncludeSnapshotsFrom, snapshots)
+		}
+	}
+
 	newValidating := make([]ValidatingConfig, 0)
 	for _, cfg := range c.KubernetesValidating {
 		if snapshots, ok := groupSnapshots[cfg.Group]; ok {
@@ -315,6 +696,62 @@ func (cv1 *HookConfigV1) ConvertAndCheck(c *HookConfig) (err error) {
 	}
 	c.KubernetesConversion = newConversion
 
+	// Expand includeAllSnapshots to the full list of kubernetes bindings.
+	allSnapshots := make([]string, 0, len(c.OnKubernetesEvents))
+	for _, kubeCfg := range c.OnKubernetesEvents {
+		allSnapshots = append(allSnapshots, kubeCfg.BindingName)
+	}
+	for i := range c.OnKubernetesEvents {
+		if c.OnKubernetesEvents[i].IncludeAllSnapshots {
+			c.OnKubernetesEvents[i].IncludeSnapshotsFrom = MergeArrays(c.OnKubernetesEvents[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.Schedules {
+		if c.Schedules[i].IncludeAllSnapshots {
+			c.Schedules[i].IncludeSnapshotsFrom = MergeArrays(c.Schedules[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.OnMessage {
+		if c.OnMessage[i].IncludeAllSnapshots {
+			c.OnMessage[i].IncludeSnapshotsFrom = MergeArrays(c.OnMessage[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.OnWebhook {
+		if c.OnWebhook[i].IncludeAllSnapshots {
+			c.OnWebhook[i].IncludeSnapshotsFrom = MergeArrays(c.OnWebhook[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.OnFileChange {
+		if c.OnFileChange[i].IncludeAllSnapshots {
+			c.OnFileChange[i].IncludeSnapshotsFrom = MergeArrays(c.OnFileChange[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.OnSignal {
+		if c.OnSignal[i].IncludeAllSnapshots {
+			c.OnSignal[i].IncludeSnapshotsFrom = MergeArrays(c.OnSignal[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.OnAlert {
+		if c.OnAlert[i].IncludeAllSnapshots {
+			c.OnAlert[i].IncludeSnapshotsFrom = MergeArrays(c.OnAlert[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.KubernetesValidating {
+		if c.KubernetesValidating[i].IncludeAllSnapshots {
+			c.KubernetesValidating[i].IncludeSnapshotsFrom = MergeArrays(c.KubernetesValidating[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.KubernetesMutating {
+		if c.KubernetesMutating[i].IncludeAllSnapshots {
+			c.KubernetesMutating[i].IncludeSnapshotsFrom = MergeArrays(c.KubernetesMutating[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+	for i := range c.KubernetesConversion {
+		if c.KubernetesConversion[i].IncludeAllSnapshots {
+			c.KubernetesConversion[i].IncludeSnapshotsFrom = MergeArrays(c.KubernetesConversion[i].IncludeSnapshotsFrom, allSnapshots)
+		}
+	}
+
 	return nil
 }
 
@@ -333,6 +770,7 @@ func (cv1 *HookConfigV1) ConvertSchedule(schV1 ScheduleConfigV1) (ScheduleConfig
 		Id:      ScheduleID(),
 	}
 	res.IncludeSnapshotsFrom = schV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = schV1.IncludeAllSnapshots
 
 	if schV1.Queue == "" {
 		res.Queue = "main"
@@ -340,6 +778,7 @@ func (cv1 *HookConfigV1) ConvertSchedule(schV1 ScheduleConfigV1) (ScheduleConfig
 		res.Queue = schV1.Queue
 	}
 	res.Group = schV1.Group
+	res.SkipIfSnapshotsUnchanged = schV1.SkipIfSnapshotsUnchanged
 
 	return res, nil
 }
@@ -358,9 +797,298 @@ func (cv1 *HookConfigV1) CheckSchedule(kubeConfigs []OnKubernetesEventConfig, sc
 		}
 	}
 
+	if schV1.SkipIfSnapshotsUnchanged && len(schV1.IncludeSnapshotsFrom) == 0 && !schV1.IncludeAllSnapshots && schV1.Group == "" {
+		allErr = multierror.Append(allErr, fmt.Errorf("skipIfSnapshotsUnchanged requires includeSnapshotsFrom, includeAllSnapshots or group"))
+	}
+
+	return allErr
+}
+
+func (cv1 *HookConfigV1) ConvertOnMessage(cfgV1 OnMessageConfigV1) TriggerConfig {
+	res := TriggerConfig{}
+	res.BindingName = cfgV1.Name
+	res.BindingType = OnMessage
+	res.AllowFailure = cfgV1.AllowFailure
+	res.Id = TriggerID()
+	res.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
+	res.Group = cfgV1.Group
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+
+	if cfgV1.Nats != nil {
+		res.Source = &trigger_manager.NatsSource{
+			URL:        cfgV1.Nats.URL,
+			Subject:    cfgV1.Nats.Subject,
+			QueueGroup: cfgV1.Nats.QueueGroup,
+		}
+	}
+	if cfgV1.Kafka != nil {
+		res.Source = &trigger_manager.KafkaSource{
+			RestProxyURL:  cfgV1.Kafka.RestProxyURL,
+			Topic:         cfgV1.Kafka.Topic,
+			ConsumerGroup: cfgV1.Kafka.ConsumerGroup,
+		}
+	}
+
+	return res
+}
+
+func (cv1 *HookConfigV1) CheckOnMessage(kubeConfigs []OnKubernetesEventConfig, cfgV1 OnMessageConfigV1) (allErr error) {
+	if (cfgV1.Nats == nil) == (cfgV1.Kafka == nil) {
+		allErr = multierror.Append(allErr, fmt.Errorf("exactly one of nats or kafka should be specified"))
+	}
+	if cfgV1.Nats != nil && strings.ContainsAny(cfgV1.Nats.Subject+cfgV1.Nats.QueueGroup, " \t\r\n") {
+		allErr = multierror.Append(allErr, fmt.Errorf("nats subject and queueGroup should not contain whitespaces"))
+	}
+
+	if len(cfgV1.IncludeSnapshotsFrom) > 0 {
+		err := CheckIncludeSnapshots(kubeConfigs, cfgV1.IncludeSnapshotsFrom...)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("includeSnapshotsFrom is invalid: %v", err))
+		}
+	}
+
+	return allErr
+}
+
+func (cv1 *HookConfigV1) ConvertOnWebhook(cfgV1 OnWebhookConfigV1) TriggerConfig {
+	res := TriggerConfig{}
+	res.BindingName = cfgV1.Name
+	res.BindingType = OnWebhook
+	res.AllowFailure = cfgV1.AllowFailure
+	res.Id = TriggerID()
+	res.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
+	res.Group = cfgV1.Group
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+
+	res.Source = &trigger_manager.WebhookSource{
+		Path:        webhookPath(cfgV1.Path, cfgV1.Name),
+		TokenEnv:    cfgV1.TokenEnv,
+		TokenFile:   cfgV1.TokenFile,
+		MaxBodySize: cfgV1.MaxBodySize,
+	}
+
+	return res
+}
+
+func (cv1 *HookConfigV1) CheckOnWebhook(kubeConfigs []OnKubernetesEventConfig, cfgV1 OnWebhookConfigV1) (allErr error) {
+	if cfgV1.TokenEnv == "" && cfgV1.TokenFile == "" {
+		allErr = multierror.Append(allErr, fmt.Errorf("tokenEnv or tokenFile should be specified"))
+	}
+
+	if err := checkWebhookPath(webhookPath(cfgV1.Path, cfgV1.Name)); err != nil {
+		allErr = multierror.Append(allErr, err)
+	}
+
+	if len(cfgV1.IncludeSnapshotsFrom) > 0 {
+		err := CheckIncludeSnapshots(kubeConfigs, cfgV1.IncludeSnapshotsFrom...)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("includeSnapshotsFrom is invalid: %v", err))
+		}
+	}
+
 	return allErr
 }
 
+func (cv1 *HookConfigV1) ConvertOnFileChange(cfgV1 OnFileChangeConfigV1) TriggerConfig {
+	res := TriggerConfig{}
+	res.BindingName = cfgV1.Name
+	res.BindingType = OnFileChange
+	res.AllowFailure = cfgV1.AllowFailure
+	res.Id = TriggerID()
+	res.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
+	res.Group = cfgV1.Group
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+
+	source := &trigger_manager.FileWatchSource{
+		Paths:      cfgV1.Paths,
+		Operations: cfgV1.Events,
+	}
+	// Debounce is validated in CheckOnFileChange.
+	if cfgV1.Debounce != "" {
+		source.Debounce, _ = time.ParseDuration(cfgV1.Debounce)
+	}
+	res.Source = source
+
+	return res
+}
+
+func (cv1 *HookConfigV1) CheckOnFileChange(kubeConfigs []OnKubernetesEventConfig, cfgV1 OnFileChangeConfigV1) (allErr error) {
+	for _, path := range cfgV1.Paths {
+		if !filepath.IsAbs(path) {
+			allErr = multierror.Append(allErr, fmt.Errorf("path '%s' should be absolute", path))
+		}
+		if _, err := filepath.Match(path, ""); err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("path '%s' is invalid: %v", path, err))
+		}
+	}
+
+	if cfgV1.Debounce != "" {
+		if _, err := time.ParseDuration(cfgV1.Debounce); err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("debounce is invalid: %v", err))
+		}
+	}
+
+	if len(cfgV1.IncludeSnapshotsFrom) > 0 {
+		err := CheckIncludeSnapshots(kubeConfigs, cfgV1.IncludeSnapshotsFrom...)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("includeSnapshotsFrom is invalid: %v", err))
+		}
+	}
+
+	return allErr
+}
+
+func (cv1 *HookConfigV1) ConvertOnSignal(cfgV1 OnSignalConfigV1) TriggerConfig {
+	res := TriggerConfig{}
+	res.BindingName = cfgV1.Name
+	res.BindingType = OnSignal
+	res.AllowFailure = cfgV1.AllowFailure
+	res.Id = TriggerID()
+	res.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
+	res.Group = cfgV1.Group
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+	res.Source = &trigger_manager.SignalSource{Signal: cfgV1.Signal}
+	return res
+}
+
+func (cv1 *HookConfigV1) ConvertOnAlert(cfgV1 OnAlertConfigV1) TriggerConfig {
+	res := TriggerConfig{}
+	res.BindingName = cfgV1.Name
+	res.BindingType = OnAlert
+	res.AllowFailure = cfgV1.AllowFailure
+	res.Id = TriggerID()
+	res.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
+	res.Group = cfgV1.Group
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+	res.Source = &trigger_manager.AlertSource{
+		Path:        webhookPath(cfgV1.Path, cfgV1.Name),
+		TokenEnv:    cfgV1.TokenEnv,
+		TokenFile:   cfgV1.TokenFile,
+		MaxBodySize: cfgV1.MaxBodySize,
+		AlertNames:  cfgV1.AlertNames,
+		MatchLabels: cfgV1.MatchLabels,
+		Statuses:    cfgV1.Status,
+	}
+	return res
+}
+
+func (cv1 *HookConfigV1) CheckOnAlert(kubeConfigs []OnKubernetesEventConfig, cfgV1 OnAlertConfigV1) (allErr error) {
+	if cfgV1.TokenEnv == "" && cfgV1.TokenFile == "" {
+		allErr = multierror.Append(allErr, fmt.Errorf("tokenEnv or tokenFile should be specified"))
+	}
+
+	if err := checkWebhookPath(webhookPath(cfgV1.Path, cfgV1.Name)); err != nil {
+		allErr = multierror.Append(allErr, err)
+	}
+
+	if len(cfgV1.IncludeSnapshotsFrom) > 0 {
+		err := CheckIncludeSnapshots(kubeConfigs, cfgV1.IncludeSnapshotsFrom...)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("includeSnapshotsFrom is invalid: %v", err))
+		}
+	}
+
+	return allErr
+}
+
+// webhookPath returns a path of the webhook or alert binding. The binding name is used by default.
+func webhookPath(path string, name string) string {
+	if path == "" {
+		path = name
+	}
+	return strings.Trim(path, "/")
+}
+
+func checkWebhookPath(path string) error {
+	if !webhookPathRe.MatchString(path) {
+		return fmt.Errorf("path '%s' is invalid: only letters, digits, '.', '_', '-' and '/' are allowed", path)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("path '%s' is invalid: relative segments are not allowed", path)
+		}
+	}
+	return nil
+}
+
+var webhookPathRe = regexp.MustCompile(`^[a-zA-Z0-9._-]+(/[a-zA-Z0-9._-]+)*$`)
+
+func (cv1 *HookConfigV1) CheckComposite(kubeConfigs []OnKubernetesEventConfig, cfgV1 CompositeConfigV1) (allErr error) {
+	for _, cond := range cfgV1.Conditions {
+		err := CheckIncludeSnapshots(kubeConfigs, cond.Binding)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("conditions is invalid: %v", err))
+		}
+	}
+
+	if len(cfgV1.Triggers) > 0 {
+		err := CheckIncludeSnapshots(kubeConfigs, cfgV1.Triggers...)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("triggers is invalid: %v", err))
+		}
+	}
+
+	if len(cfgV1.IncludeSnapshotsFrom) > 0 {
+		err := CheckIncludeSnapshots(kubeConfigs, cfgV1.IncludeSnapshotsFrom...)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("includeSnapshotsFrom is invalid: %v", err))
+		}
+	}
+
+	return allErr
+}
+
+// ConvertComposite returns a trigger config. Events of all bindings from conditions
+// trigger the evaluation if triggers are not set. Snapshots of these bindings
+// are always included into the binding context.
+func (cv1 *HookConfigV1) ConvertComposite(cfgV1 CompositeConfigV1) CompositeConfig {
+	res := CompositeConfig{}
+	res.BindingName = cfgV1.Name
+	res.AllowFailure = cfgV1.AllowFailure
+
+	conditionBindings := make([]string, 0, len(cfgV1.Conditions))
+	for _, cond := range cfgV1.Conditions {
+		res.Conditions = append(res.Conditions, CompositeCondition{
+			Binding:  cond.Binding,
+			JqFilter: cond.JqFilter,
+		})
+		conditionBindings = append(conditionBindings, cond.Binding)
+	}
+
+	res.Triggers = cfgV1.Triggers
+	if len(res.Triggers) == 0 {
+		res.Triggers = MergeArrays(nil, conditionBindings)
+	}
+	res.IncludeSnapshotsFrom = MergeArrays(cfgV1.IncludeSnapshotsFrom, conditionBindings)
+
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+
+	return res
+}
+
 func (cv1 *HookConfigV1) CheckOnKubernetesEvent(kubeCfg OnKubernetesEventConfigV1, _ string) (allErr error) {
 	if kubeCfg.ApiVersion != "" {
 		_, err := schema.ParseGroupVersion(kubeCfg.ApiVersion)
@@ -383,6 +1111,15 @@ func (cv1 *HookConfigV1) CheckOnKubernetesEvent(kubeCfg OnKubernetesEventConfigV
 		}
 	}
 
+	if err := kube_events_manager.ValidateNameSelector((*NameSelector)(kubeCfg.NameSelector)); err != nil {
+		allErr = multierror.Append(allErr, fmt.Errorf("nameSelector is invalid: %v", err))
+	}
+	if kubeCfg.Namespace != nil {
+		if err := kube_events_manager.ValidateNameSelector(kubeCfg.Namespace.NameSelector); err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("namespace.nameSelector is invalid: %v", err))
+		}
+	}
+
 	if kubeCfg.NameSelector != nil && len(kubeCfg.NameSelector.MatchNames) > 0 {
 		if kubeCfg.FieldSelector != nil && len(kubeCfg.FieldSelector.MatchExpressions) > 0 {
 			for _, expr := range kubeCfg.FieldSelector.MatchExpressions {
@@ -396,6 +1133,151 @@ func (cv1 *HookConfigV1) CheckOnKubernetesEvent(kubeCfg OnKubernetesEventConfigV
 	return allErr
 }
 
+func (cv1 *HookConfigV1) CheckKubernetesEvents(cfgV1 KubernetesEventsConfigV1) (allErr error) {
+	if cfgV1.DedupWindow != "" {
+		if _, err := time.ParseDuration(cfgV1.DedupWindow); err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("dedupWindow is invalid: %v", err))
+		}
+	}
+	if cfgV1.Namespace != nil {
+		if err := kube_events_manager.ValidateNameSelector(cfgV1.Namespace.NameSelector); err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("namespace.nameSelector is invalid: %v", err))
+		}
+	}
+	if _, err := ConvertEnvFromObject(cfgV1.EnvFromObject); err != nil {
+		allErr = multierror.Append(allErr, err)
+	}
+	return allErr
+}
+
+// ConvertKubernetesEvents returns a kubernetes binding for Event objects. Fields are
+// translated into the field selector, the reasons filter is applied in memory for
+// several reasons. Existing Event objects do not run the hook at start by default.
+func (cv1 *HookConfigV1) ConvertKubernetesEvents(cfgV1 KubernetesEventsConfigV1, idx int) OnKubernetesEventConfig {
+	monitor := &kube_events_manager.MonitorConfig{}
+	monitor.Metadata.DebugName = fmt.Sprintf("kubernetesEvents[%d]{%s}", idx, cfgV1.Name)
+	monitor.Metadata.MonitorId = MonitorConfigID()
+	monitor.Metadata.LogLabels = map[string]string{}
+	monitor.Metadata.MetricLabels = map[string]string{}
+	monitor.WithMode(ModeIncremental)
+	monitor.ApiVersion = "v1"
+	monitor.Kind = "Event"
+	monitor.WithNamespaceSelector((*NamespaceSelector)(cfgV1.Namespace))
+	monitor.JqFilter = cfgV1.JqFilter
+	// Event objects are modified when the same event occurs again.
+	monitor.WithEventTypes([]WatchEventType{WatchEventAdded, WatchEventModified})
+
+	fieldSelector := &FieldSelector{MatchExpressions: []FieldSelectorRequirement{}}
+	if cfgV1.Type != "" {
+		fieldSelector.MatchExpressions = append(fieldSelector.MatchExpressions, FieldSelectorRequirement{Field: "type", Operator: "=", Value: cfgV1.Type})
+	}
+	if cfgV1.InvolvedObjectKind != "" {
+		fieldSelector.MatchExpressions = append(fieldSelector.MatchExpressions, FieldSelectorRequirement{Field: "involvedObject.kind", Operator: "=", Value: cfgV1.InvolvedObjectKind})
+	}
+	if len(cfgV1.Reasons) == 1 {
+		fieldSelector.MatchExpressions = append(fieldSelector.MatchExpressions, FieldSelectorRequirement{Field: "reason", Operator: "=", Value: cfgV1.Reasons[0]})
+	}
+	if len(fieldSelector.MatchExpressions) > 0 {
+		monitor.WithFieldSelector(fieldSelector)
+	}
+
+	filter := &kube_events_manager.EventsFilterConfig{
+		Reasons:     cfgV1.Reasons,
+		DedupWindow: DefaultKubernetesEventsDedupWindow,
+		Rate:        DefaultKubernetesEventsRate,
+		Burst:       DefaultKubernetesEventsBurst,
+	}
+	// DedupWindow is validated in CheckKubernetesEvents.
+	if cfgV1.DedupWindow != "" {
+		filter.DedupWindow, _ = time.ParseDuration(cfgV1.DedupWindow)
+	}
+	if cfgV1.Rate > 0 {
+		filter.Rate = cfgV1.Rate
+	}
+	if cfgV1.Burst > 0 {
+		filter.Burst = cfgV1.Burst
+	}
+	monitor.EventsFilter = filter
+
+	res := OnKubernetesEventConfig{}
+	res.Monitor = monitor
+	res.BindingName = cfgV1.Name
+	res.AllowFailure = cfgV1.AllowFailure
+	res.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	res.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
+	res.Group = cfgV1.Group
+	res.Queue = cfgV1.Queue
+	if res.Queue == "" {
+		res.Queue = "main"
+	}
+	res.ExecuteHookOnSynchronization = cfgV1.ExecuteHookOnSynchronization == "true"
+	res.WaitForSynchronization = true
+	res.KeepFullObjectsInMemory = true
+	res.Monitor.KeepFullObjectsInMemory = true
+	// EnvFromObject is validated in CheckKubernetesEvents.
+	res.EnvFromObject, _ = ConvertEnvFromObject(cfgV1.EnvFromObject)
+
+	return res
+}
+
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Environment variables of the object are set after variables of the operator process,
+// so names that change how the hook is executed or where it writes results are reserved.
+var (
+	reservedEnvNames = map[string]bool{
+		"PATH":       true,
+		"HOME":       true,
+		"SHELL":      true,
+		"IFS":        true,
+		"ENV":        true,
+		"BASH_ENV":   true,
+		"KUBECONFIG": true,
+	}
+	reservedEnvPrefixes = []string{"LD_", "BASH_FUNC_", "KUBERNETES_", "SHELL_OPERATOR_", "BINDING_CONTEXT_", "QUEUE_"}
+	reservedEnvSuffixes = []string{"_PATH"}
+)
+
+func isReservedEnvName(name string) bool {
+	name = strings.ToUpper(name)
+	if reservedEnvNames[name] {
+		return true
+	}
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range reservedEnvSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertEnvFromObject validates names of environment variables and parses paths of fields.
+func ConvertEnvFromObject(envFromObject map[string]string) (map[string]FieldPath, error) {
+	if len(envFromObject) == 0 {
+		return nil, nil
+	}
+	res := make(map[string]FieldPath, len(envFromObject))
+	for name, path := range envFromObject {
+		if !envNameRe.MatchString(name) {
+			return nil, fmt.Errorf("envFromObject: '%s' is not a valid name of the environment variable", name)
+		}
+		if isReservedEnvName(name) {
+			return nil, fmt.Errorf("envFromObject: '%s' is a reserved name of the environment variable", name)
+		}
+		fieldPath, err := ParseFieldPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("envFromObject %s: %v", name, err)
+		}
+		res[name] = fieldPath
+	}
+	return res, nil
+}
+
 func (cv1 *HookConfigV1) CheckAdmission(kubeConfigs []OnKubernetesEventConfig, cfgV1 KubernetesAdmissionConfigV1) (allErr error) {
 	var err error
 
@@ -428,6 +1310,7 @@ func convertValidating(cfgV1 KubernetesAdmissionConfigV1) (ValidatingConfig, err
 
 	cfg.Group = cfgV1.Group
 	cfg.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	cfg.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
 	cfg.BindingName = cfgV1.Name
 
 	DefaultSideEffects := v1.SideEffectClassNone
@@ -465,6 +1348,18 @@ func convertValidating(cfgV1 KubernetesAdmissionConfigV1) (ValidatingConfig, err
 	}
 	cfg.Webhook.Metadata.LogLabels = map[string]string{}
 	cfg.Webhook.Metadata.MetricLabels = map[string]string{}
+	cfg.Webhook.Metadata.Serving = cfgV1.TLS.toServingConfig()
+	limits, err := cfgV1.Limits.toLimitsConfig()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Webhook.Metadata.Limits = limits
+	if cfgV1.Policy != nil {
+		cfg.Webhook.Metadata.Policy = &admission.PolicyConfig{
+			Validations: cfgV1.Policy.Validations,
+			SkipWebhook: cfgV1.Policy.SkipWebhook,
+		}
+	}
 
 	return cfg, nil
 }
@@ -474,6 +1369,7 @@ func convertMutating(cfgV1 KubernetesAdmissionConfigV1) (MutatingConfig, error)
 
 	cfg.Group = cfgV1.Group
 	cfg.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	cfg.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
 	cfg.BindingName = cfgV1.Name
 
 	DefaultFailurePolicy := v1.Fail
@@ -511,6 +1407,12 @@ func convertMutating(cfgV1 KubernetesAdmissionConfigV1) (MutatingConfig, error)
 	}
 	cfg.Webhook.Metadata.LogLabels = map[string]string{}
 	cfg.Webhook.Metadata.MetricLabels = map[string]string{}
+	cfg.Webhook.Metadata.Serving = cfgV1.TLS.toServingConfig()
+	limits, err := cfgV1.Limits.toLimitsConfig()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Webhook.Metadata.Limits = limits
 
 	return cfg, nil
 }
@@ -533,6 +1435,7 @@ func (cv1 *HookConfigV1) ConvertConversion(cfgV1 KubernetesConversionConfigV1) (
 
 	cfg.Group = cfgV1.Group
 	cfg.IncludeSnapshotsFrom = cfgV1.IncludeSnapshotsFrom
+	cfg.IncludeAllSnapshots = cfgV1.IncludeAllSnapshots
 	cfg.BindingName = cfgV1.Name
 
 	cfg.Webhook = &conversion.WebhookConfig{
@@ -552,21 +1455,160 @@ func (cv1 *HookConfigV1) CheckAndConvertSettings(settings *SettingsV1) (out *Set
 		return nil, nil
 	}
 
-	interval, err := time.ParseDuration(settings.ExecutionMinInterval)
-	if err != nil {
-		allErr = multierror.Append(allErr, fmt.Errorf("executionMinInterval is invalid: %v", err))
+	out = &Settings{}
+
+	if settings.ExecutionMinInterval != "" {
+		interval, err := time.ParseDuration(settings.ExecutionMinInterval)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("executionMinInterval is invalid: %v", err))
+		}
+		out.ExecutionMinInterval = interval
 	}
 
-	burst, err := strconv.ParseInt(settings.ExecutionBurst, 10, 32)
-	if err != nil {
-		allErr = multierror.Append(allErr, fmt.Errorf("executionMinInterval is invalid: %v", err))
+	if settings.ExecutionBurst != "" {
+		burst, err := strconv.ParseInt(settings.ExecutionBurst, 10, 32)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("executionBurst is invalid: %v", err))
+		}
+		out.ExecutionBurst = int(burst)
+	}
+
+	if (settings.ExecutionMinInterval == "") != (settings.ExecutionBurst == "") {
+		allErr = multierror.Append(allErr, fmt.Errorf("executionMinInterval and executionBurst should be set together"))
+	}
+
+	if settings.ExecutionTimeout != "" {
+		timeout, err := time.ParseDuration(settings.ExecutionTimeout)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("executionTimeout is invalid: %v", err))
+		}
+		out.ExecutionTimeout = timeout
+	}
+
+	if settings.WarmPoolSize != "" {
+		size, err := strconv.ParseInt(settings.WarmPoolSize, 10, 32)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("warmPoolSize is invalid: %v", err))
+		}
+		out.WarmPoolSize = int(size)
+	}
+
+	if settings.Umask != "" {
+		umask, err := strconv.ParseInt(settings.Umask, 8, 32)
+		if err != nil || umask < 0 || umask > 0o777 {
+			allErr = multierror.Append(allErr, fmt.Errorf("umask '%s' is invalid: should be an octal number from 0 to 0777", settings.Umask))
+		}
+		umaskInt := int(umask)
+		out.Umask = &umaskInt
+	}
+
+	if settings.RunAsUser != "" {
+		uid, err := strconv.ParseUint(settings.RunAsUser, 10, 32)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("runAsUser is invalid: %v", err))
+		}
+		uid32 := uint32(uid)
+		out.RunAsUser = &uid32
+	}
+
+	if settings.RunAsGroup != "" {
+		gid, err := strconv.ParseUint(settings.RunAsGroup, 10, 32)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("runAsGroup is invalid: %v", err))
+		}
+		gid32 := uint32(gid)
+		out.RunAsGroup = &gid32
+	}
+
+	out.WorkingDir = settings.WorkingDir
+	out.AllowedEnvPrefixes = settings.AllowedEnvPrefixes
+	out.OutputParseErrorPolicy = OutputParseErrorPolicy(settings.OutputParseErrorPolicy)
+	out.BindingContextFormat = BindingContextFormat(settings.BindingContextFormat)
+	out.ValuesFile = settings.ValuesFile
+
+	if settings.Schema != nil {
+		valuesSchema, err := valuesSchemaFromMap(settings.Schema)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("schema is invalid: %v", err))
+		}
+		out.ValuesSchema = valuesSchema
+	}
+
+	if settings.Impersonate != nil {
+		imp, err := settings.Impersonate.toImpersonation()
+		if err != nil {
+			allErr = multierror.Append(allErr, err)
+		}
+		out.Impersonate = imp
+	}
+
+	if settings.Lock != nil {
+		out.Lock = &LockSettings{
+			Name:      settings.Lock.Name,
+			Namespace: settings.Lock.Namespace,
+		}
+	}
+
+	if settings.Check != nil {
+		check, err := settings.Check.toCheckSettings()
+		if err != nil {
+			allErr = multierror.Append(allErr, err)
+		}
+		out.Check = check
 	}
+
 	if allErr != nil {
 		return nil, allErr
 	}
 
-	return &Settings{
-		ExecutionMinInterval: interval,
-		ExecutionBurst:       int(burst),
+	return out, nil
+}
+
+// Defaults for self-checks of the hook.
+const (
+	DefaultCheckInterval = time.Minute
+	DefaultCheckTimeout  = 10 * time.Second
+)
+
+func (c *CheckV1) toCheckSettings() (*CheckSettings, error) {
+	out := &CheckSettings{
+		Interval: DefaultCheckInterval,
+		Timeout:  DefaultCheckTimeout,
+	}
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("check: interval '%s' is invalid: should be a positive duration", c.Interval)
+		}
+		out.Interval = interval
+	}
+	if c.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("check: timeout '%s' is invalid: should be a positive duration", c.Timeout)
+		}
+		out.Timeout = timeout
+	}
+	return out, nil
+}
+
+func (i *ImpersonateV1) toImpersonation() (*Impersonation, error) {
+	user := i.User
+	if i.ServiceAccount != "" {
+		if user != "" {
+			return nil, fmt.Errorf("impersonate: user and serviceAccount are mutually exclusive")
+		}
+		parts := strings.Split(i.ServiceAccount, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("impersonate: serviceAccount '%s' should be in the 'namespace/name' form", i.ServiceAccount)
+		}
+		user = fmt.Sprintf("system:serviceaccount:%s:%s", parts[0], parts[1])
+	}
+	if user == "" {
+		return nil, fmt.Errorf("impersonate: user or serviceAccount is required")
+	}
+	return &Impersonation{
+		User:   user,
+		Groups: i.Groups,
 	}, nil
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/go-openapi/spec"
 	"github.com/go-openapi/swag"
@@ -14,14 +15,34 @@ definitions:
   nameSelector:
     type: object
     additionalProperties: false
-    required:
-    - matchNames
+    minProperties: 1
     properties:
       matchNames:
         type: array
         additionalItems: false
         items:
           type: string
+      matchExpressions:
+        type: array
+        items:
+          type: object
+          additionalProperties: false
+          required:
+          - operator
+          - values
+          properties:
+            operator:
+              type: string
+              enum:
+              - In
+              - NotIn
+              - Regex
+              - NotRegex
+            values:
+              type: array
+              minItems: 1
+              items:
+                type: string
   labelSelector:
     type: object
     additionalProperties: false
@@ -65,6 +86,16 @@ properties:
     type: string
     enum:
     - v1
+  disabled:
+    type: boolean
+  command:
+    type: string
+    minLength: 1
+  profiles:
+    type: array
+    items:
+      type: string
+      minLength: 1
   settings:
     type: object
     additionalProperties: false
@@ -73,6 +104,72 @@ properties:
         type: string
       executionBurst:
         type: integer
+      executionTimeout:
+        type: string
+      warmPoolSize:
+        type: integer
+        minimum: 1
+      workingDir:
+        type: string
+      umask:
+        type: string
+        pattern: "^0?[0-7]{1,3}$"
+      allowedEnvPrefixes:
+        type: array
+        items:
+          type: string
+      runAsUser:
+        type: integer
+        minimum: 0
+      runAsGroup:
+        type: integer
+        minimum: 0
+      outputParseErrorPolicy:
+        type: string
+        enum:
+        - Fail
+        - Retry
+        - Ignore
+      bindingContextFormat:
+        type: string
+        enum:
+        - JSON
+        - JSONLines
+      impersonate:
+        type: object
+        additionalProperties: false
+        properties:
+          user:
+            type: string
+          groups:
+            type: array
+            items:
+              type: string
+          serviceAccount:
+            type: string
+            pattern: "^[^/]+/[^/]+$"
+      schema:
+        type: object
+        additionalProperties: true
+      valuesFile:
+        type: string
+      lock:
+        type: object
+        additionalProperties: false
+        properties:
+          name:
+            type: string
+            pattern: "^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$"
+          namespace:
+            type: string
+      check:
+        type: object
+        additionalProperties: false
+        properties:
+          interval:
+            type: string
+          timeout:
+            type: string
   onStartup:
     title: onStartup binding
     description: |
@@ -105,10 +202,16 @@ properties:
           minItems: 1
           items:
             type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
         queue:
           type: string
         group:
           type: string
+        skipIfSnapshotsUnchanged:
+          type: boolean
+          default: false
   kubernetes:
     title: kubernetes event bindings
     type: array
@@ -143,6 +246,9 @@ properties:
           minItems: 1
           items:
             type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
         queue:
           type: string
         jqFilter:
@@ -158,6 +264,27 @@ properties:
           type: boolean
         resynchronizationPeriod:
           type: string
+        listPageSize:
+          type: integer
+          minimum: 1
+        watchTimeout:
+          type: string
+        debounce:
+          type: string
+        envFromObject:
+          type: object
+          additionalProperties:
+            type: string
+            pattern: "^\\."
+        absentAfter:
+          type: string
+        onAnnotationChange:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+            minLength: 1
         nameSelector:
           "$ref": "#/definitions/nameSelector"
         labelSelector:
@@ -195,6 +322,381 @@ properties:
               "$ref": "#/definitions/nameSelector"
             labelSelector:
               "$ref": "#/definitions/labelSelector"
+  kubernetesEvents:
+    title: kubernetes Events bindings
+    description: |
+      run hook on core Events with deduplication by reason and involvedObject and rate limiting
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      properties:
+        name:
+          type: string
+        type:
+          type: string
+          enum:
+          - Normal
+          - Warning
+        reasons:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+            minLength: 1
+        involvedObjectKind:
+          type: string
+          example: "Pod"
+        jqFilter:
+          type: string
+        dedupWindow:
+          type: string
+        rate:
+          type: number
+          minimum: 0
+        burst:
+          type: integer
+          minimum: 1
+        executeHookOnSynchronization:
+          type: boolean
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
+        queue:
+          type: string
+        group:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
+        envFromObject:
+          type: object
+          additionalProperties:
+            type: string
+            pattern: "^\\."
+        namespace:
+          type: object
+          additionalProperties: false
+          minProperties: 1
+          maxProperties: 2
+          properties:
+            nameSelector:
+              "$ref": "#/definitions/nameSelector"
+            labelSelector:
+              "$ref": "#/definitions/labelSelector"
+  composite:
+    title: composite triggers
+    description: |
+      run hook on kubernetes events when conditions across bindings hold
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      - conditions
+      properties:
+        name:
+          type: string
+        triggers:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        conditions:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: object
+            additionalProperties: false
+            required:
+            - binding
+            properties:
+              binding:
+                type: string
+              jqFilter:
+                type: string
+                example: ".[0].object.status.readyReplicas > 0"
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        queue:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
+  onMessage:
+    title: message queue bindings
+    description: |
+      run hook on messages from a NATS subject or a Kafka topic
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      minProperties: 2
+      required:
+      - name
+      properties:
+        name:
+          type: string
+        nats:
+          type: object
+          additionalProperties: false
+          required:
+          - url
+          - subject
+          properties:
+            url:
+              type: string
+              example: "nats://nats:4222"
+            subject:
+              type: string
+            queueGroup:
+              type: string
+        kafka:
+          type: object
+          additionalProperties: false
+          required:
+          - restProxyURL
+          - topic
+          - consumerGroup
+          properties:
+            restProxyURL:
+              type: string
+              example: "http://kafka-rest-proxy:8082"
+            topic:
+              type: string
+            consumerGroup:
+              type: string
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
+        queue:
+          type: string
+        group:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
+  onWebhook:
+    title: HTTP webhook bindings
+    description: |
+      run hook on HTTP requests to /webhooks/{path}
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      properties:
+        name:
+          type: string
+        path:
+          type: string
+          example: "github/push"
+        tokenEnv:
+          type: string
+        tokenFile:
+          type: string
+        maxBodySize:
+          type: integer
+          minimum: 1
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
+        queue:
+          type: string
+        group:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
+  onFileChange:
+    title: file watch bindings
+    description: |
+      run hook on changes of files matching glob patterns
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      - paths
+      properties:
+        name:
+          type: string
+        paths:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+            example: "/host/etc/myapp/conf.d/*.yaml"
+        events:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+            enum:
+            - Create
+            - Write
+            - Remove
+            - Rename
+            - Chmod
+        debounce:
+          type: string
+          example: "1s"
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
+        queue:
+          type: string
+        group:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
+  onSignal:
+    title: signal bindings
+    description: |
+      run hook when the operator process receives the signal
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      - signal
+      properties:
+        name:
+          type: string
+        signal:
+          type: string
+          enum:
+          - SIGUSR1
+          - SIGUSR2
+          - SIGHUP
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
+        queue:
+          type: string
+        group:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
+  onAlert:
+    title: Alertmanager receiver bindings
+    description: |
+      run hook on alerts sent by Alertmanager to /webhooks/{path}
+    type: array
+    additionalItems: false
+    minItems: 1
+    items:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      properties:
+        name:
+          type: string
+        path:
+          type: string
+          example: "alerts/disk"
+        tokenEnv:
+          type: string
+        tokenFile:
+          type: string
+        maxBodySize:
+          type: integer
+          minimum: 1
+        alertNames:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        matchLabels:
+          type: object
+          additionalProperties:
+            type: string
+        status:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+            enum:
+            - firing
+            - resolved
+        includeSnapshotsFrom:
+          type: array
+          additionalItems: false
+          minItems: 1
+          items:
+            type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
+        queue:
+          type: string
+        group:
+          type: string
+        allowFailure:
+          type: boolean
+          default: false
   kubernetesMutating:
     title: kubernetesMutatingConfiguration handlers
     type: array
@@ -216,6 +718,9 @@ properties:
           minItems: 1
           items:
             type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
         failurePolicy:
           type: string
           enum:
@@ -229,6 +734,41 @@ properties:
         timeoutSeconds:
           type: integer
           example: 10
+        tls:
+          type: object
+          additionalProperties: false
+          required:
+          - serviceName
+          - certFile
+          - keyFile
+          - caFile
+          properties:
+            serviceName:
+              type: string
+            certFile:
+              type: string
+            keyFile:
+              type: string
+            caFile:
+              type: string
+        limits:
+          type: object
+          additionalProperties: false
+          properties:
+            maxConcurrent:
+              type: integer
+              minimum: 1
+            rate:
+              type: number
+              minimum: 0
+            burst:
+              type: integer
+              minimum: 1
+            failureThreshold:
+              type: integer
+              minimum: 1
+            openDuration:
+              type: string
         labelSelector:
           "$ref": "#/definitions/labelSelector"
         namespace:
@@ -305,6 +845,9 @@ properties:
           minItems: 1
           items:
             type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
         failurePolicy:
           type: string
           enum:
@@ -318,6 +861,65 @@ properties:
         timeoutSeconds:
           type: integer
           example: 10
+        tls:
+          type: object
+          additionalProperties: false
+          required:
+          - serviceName
+          - certFile
+          - keyFile
+          - caFile
+          properties:
+            serviceName:
+              type: string
+            certFile:
+              type: string
+            keyFile:
+              type: string
+            caFile:
+              type: string
+        limits:
+          type: object
+          additionalProperties: false
+          properties:
+            maxConcurrent:
+              type: integer
+              minimum: 1
+            rate:
+              type: number
+              minimum: 0
+            burst:
+              type: integer
+              minimum: 1
+            failureThreshold:
+              type: integer
+              minimum: 1
+            openDuration:
+              type: string
+        policy:
+          type: object
+          additionalProperties: false
+          required:
+          - validations
+          properties:
+            validations:
+              type: array
+              minItems: 1
+              items:
+                type: object
+                additionalProperties: false
+                required:
+                - expression
+                properties:
+                  expression:
+                    type: string
+                  message:
+                    type: string
+                  reason:
+                    type: string
+            skipWebhook:
+              type: boolean
+              default: false
         labelSelector:
           "$ref": "#/definitions/labelSelector"
         namespace:
@@ -396,6 +998,9 @@ properties:
           minItems: 1
           items:
             type: string
+        includeAllSnapshots:
+          type: boolean
+          default: false
         crdName:
           type: string
         conversions:
@@ -437,8 +1042,14 @@ properties:
 
 var SchemasCache = map[string]*spec.Schema{}
 
+// schemasCacheMu guards SchemasCache as hook configs are loaded concurrently.
+var schemasCacheMu sync.Mutex
+
 // GetSchema returns loaded schema.
 func GetSchema(name string) *spec.Schema {
+	schemasCacheMu.Lock()
+	defer schemasCacheMu.Unlock()
+
 	if s, ok := SchemasCache[name]; ok {
 		return s
 	}
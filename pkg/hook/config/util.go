@@ -57,3 +57,7 @@ func MonitorConfigID() string {
 func ScheduleID() string {
 	return uuid.Must(uuid.NewV4()).String()
 }
+
+func TriggerID() string {
+	return uuid.Must(uuid.NewV4()).String()
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-openapi/spec"
+)
+
+// valuesSchemaFromMap converts the 'schema' block of settings into an OpenAPI schema.
+func valuesSchemaFromMap(in map[string]interface{}) (*spec.Schema, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	s := new(spec.Schema)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateValues fills missing fields with defaults from the schema and validates values.
+func ValidateValues(values map[string]interface{}, s *spec.Schema) error {
+	if s == nil {
+		return nil
+	}
+	applyDefaults(values, s)
+	if err := ValidateConfig(values, s, "values"); err != nil {
+		return fmt.Errorf("values are not valid: %v", err)
+	}
+	return nil
+}
+
+// applyDefaults sets default values for missing properties of objects.
+func applyDefaults(obj map[string]interface{}, s *spec.Schema) {
+	for name, prop := range s.Properties {
+		prop := prop
+		val, has := obj[name]
+		if !has {
+			if prop.Default == nil {
+				continue
+			}
+			val = deepCopyJSON(prop.Default)
+			obj[name] = val
+		}
+		if nested, ok := val.(map[string]interface{}); ok {
+			applyDefaults(nested, &prop)
+		}
+	}
+}
+
+// deepCopyJSON copies a JSON-compatible value, so defaults in the schema are not modified.
+func deepCopyJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			res[k] = deepCopyJSON(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = deepCopyJSON(item)
+		}
+		return res
+	default:
+		return val
+	}
+}
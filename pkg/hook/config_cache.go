@@ -0,0 +1,193 @@
+package hook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const configCacheFileSuffix = ".config"
+
+// configCacheIgnoredEnv are variables that differ between Pods of the same operator.
+var configCacheIgnoredEnv = map[string]bool{
+	"HOSTNAME": true,
+}
+
+// ConfigCache stores outputs of 'hook --config' in a directory.
+// Outputs are keyed by a hash of the hook name, the hook file content, all files
+// in the hooks directory and the environment, so hooks are executed again if
+// any file they can source or any variable is changed.
+type ConfigCache struct {
+	dir      string
+	hooksDir string
+	env      []string
+
+	// digest of the hooks directory and the environment, it is calculated once.
+	digest     string
+	digestErr  error
+	digestOnce sync.Once
+
+	// used keys to prune stale entries.
+	used map[string]struct{}
+	m    sync.Mutex
+}
+
+func NewConfigCache(dir string, hooksDir string, env []string) *ConfigCache {
+	return &ConfigCache{
+		dir:      dir,
+		hooksDir: hooksDir,
+		env:      env,
+		used:     make(map[string]struct{}),
+	}
+}
+
+// Key returns a cache key for the hook file.
+func (c *ConfigCache) Key(hookName string, hookPath string) (string, error) {
+	c.digestOnce.Do(func() {
+		c.digest, c.digestErr = c.commonDigest()
+	})
+	if c.digestErr != nil {
+		return "", c.digestErr
+	}
+
+	f, err := os.Open(hookPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, _ = io.WriteString(h, c.digest)
+	_, _ = io.WriteString(h, hookName)
+	_, _ = h.Write([]byte{0})
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// commonDigest returns a hash of files in the hooks directory and the environment.
+func (c *ConfigCache) commonDigest() (string, error) {
+	h := sha256.New()
+
+	env := make([]string, 0, len(c.env))
+	for _, e := range c.env {
+		name, _, _ := strings.Cut(e, "=")
+		if !configCacheIgnoredEnv[name] {
+			env = append(env, e)
+		}
+	}
+	sort.Strings(env)
+	for _, e := range env {
+		_, _ = io.WriteString(h, e)
+		_, _ = h.Write([]byte{0})
+	}
+
+	if c.hooksDir == "" {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	cacheDir, _ := filepath.Abs(c.dir)
+	// WalkDir visits files in the lexical order, so the digest is stable.
+	err := filepath.WalkDir(c.hooksDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if abs, _ := filepath.Abs(path); abs == cacheDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Follow symlinks to files, broken symlinks and other files cannot be sourced.
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, _ = io.WriteString(h, path)
+		_, _ = h.Write([]byte{0})
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("hash hooks directory: %v", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get returns a cached config output. The second value is false on a cache miss.
+func (c *ConfigCache) Get(key string) ([]byte, bool) {
+	c.markUsed(key)
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put saves the config output.
+func (c *ConfigCache) Put(key string, configOutput []byte) error {
+	c.markUsed(key)
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("create config cache directory: %v", err)
+	}
+	// Write into a temporary file first to not leave partial entries.
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create config cache entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(configOutput); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write config cache entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write config cache entry: %v", err)
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Prune removes entries that were not requested since the cache creation.
+func (c *ConfigCache) Prune() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, configCacheFileSuffix) {
+			continue
+		}
+		if _, ok := c.used[strings.TrimSuffix(name, configCacheFileSuffix)]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, name)); err != nil {
+			log.Warnf("Remove stale hook config cache entry '%s': %v", name, err)
+		}
+	}
+}
+
+func (c *ConfigCache) markUsed(key string) {
+	c.m.Lock()
+	c.used[key] = struct{}{}
+	c.m.Unlock()
+}
+
+func (c *ConfigCache) path(key string) string {
+	return filepath.Join(c.dir, key+configCacheFileSuffix)
+}
@@ -0,0 +1,56 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConfigCache(t *testing.T) {
+	hooksDir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	hookPath := filepath.Join(hooksDir, "hook.sh")
+	require.NoError(t, os.WriteFile(hookPath, []byte("#!/bin/bash\necho v1\n"), 0o755))
+
+	cache := NewConfigCache(cacheDir, hooksDir, []string{"A=1"})
+	key, err := cache.Key("hook.sh", hookPath)
+	require.NoError(t, err)
+
+	_, ok := cache.Get(key)
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Put(key, []byte("configVersion: v1")))
+	data, ok := cache.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, "configVersion: v1", string(data))
+
+	// Changed hook should have another key.
+	require.NoError(t, os.WriteFile(hookPath, []byte("#!/bin/bash\necho v2\n"), 0o755))
+	newKey, err := cache.Key("hook.sh", hookPath)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, newKey)
+
+	// Sourced files and the environment are in the key too.
+	libKey := func(env ...string) string {
+		key, err := NewConfigCache(cacheDir, hooksDir, env).Key("hook.sh", hookPath)
+		require.NoError(t, err)
+		return key
+	}
+	baseKey := libKey("A=1")
+	assert.Equal(t, baseKey, libKey("A=1", "HOSTNAME=pod-2"))
+	assert.NotEqual(t, baseKey, libKey("A=2"))
+	require.NoError(t, os.MkdirAll(filepath.Join(hooksDir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "lib", "common.sh"), []byte("X=1\n"), 0o644))
+	assert.NotEqual(t, baseKey, libKey("A=1"))
+
+	// Entries not used by a new cache instance are pruned.
+	cache = NewConfigCache(cacheDir, hooksDir, nil)
+	_, ok = cache.Get(newKey)
+	assert.False(t, ok)
+	cache.Prune()
+	_, err = os.Stat(filepath.Join(cacheDir, key+configCacheFileSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
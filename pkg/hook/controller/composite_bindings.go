@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/app"
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+	. "github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/jq"
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+// handleCompositeTriggers returns execution infos for composite triggers
+// that are triggered by the binding and have all conditions met.
+func (hc *HookController) handleCompositeTriggers(bindingName string) []BindingExecutionInfo {
+	infos := make([]BindingExecutionInfo, 0)
+	if hc.KubernetesController == nil {
+		return infos
+	}
+
+	for _, composite := range hc.compositeBindings {
+		if !hasString(composite.Triggers, bindingName) {
+			continue
+		}
+		if !hc.compositeConditionsMet(composite) {
+			continue
+		}
+
+		bc := BindingContext{
+			Binding: composite.BindingName,
+		}
+		bc.Metadata.BindingType = Composite
+		bc.Metadata.IncludeSnapshots = composite.IncludeSnapshotsFrom
+
+		infos = append(infos, BindingExecutionInfo{
+			BindingContext:   []BindingContext{bc},
+			IncludeSnapshots: composite.IncludeSnapshotsFrom,
+			AllowFailure:     composite.AllowFailure,
+			QueueName:        composite.Queue,
+			Binding:          composite.BindingName,
+		})
+	}
+
+	return infos
+}
+
+// compositeConditionsMet evaluates all conditions against current snapshots.
+func (hc *HookController) compositeConditionsMet(composite CompositeConfig) bool {
+	for _, cond := range composite.Conditions {
+		ok, err := evaluateCompositeCondition(cond, hc.KubernetesController.SnapshotsFor(cond.Binding))
+		if err != nil {
+			log.Errorf("composite '%s': evaluate condition for binding '%s': %v", composite.BindingName, cond.Binding, err)
+			return false
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateCompositeCondition applies jqFilter to the snapshot. Condition holds
+// if the filter returns true. Without jqFilter the snapshot should not be empty.
+func evaluateCompositeCondition(cond CompositeCondition, objects []ObjectAndFilterResult) (bool, error) {
+	if cond.JqFilter == "" {
+		return len(objects) > 0, nil
+	}
+
+	if objects == nil {
+		objects = make([]ObjectAndFilterResult, 0)
+	}
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := jq.ApplyJqFilter(cond.JqFilter, data, app.JqLibraryPath)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(res) == "true", nil
+}
+
+func hasString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
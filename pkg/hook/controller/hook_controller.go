@@ -8,6 +8,7 @@ import (
 	"github.com/flant/shell-operator/pkg/kube_events_manager"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 	"github.com/flant/shell-operator/pkg/schedule_manager"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
 	"github.com/flant/shell-operator/pkg/webhook/conversion"
 )
@@ -43,11 +44,14 @@ type HookController struct {
 	ScheduleController   ScheduleBindingsController
 	AdmissionController  *AdmissionBindingsController
 	ConversionController *ConversionBindingsController
+	TriggerController    TriggerBindingsController
 	kubernetesBindings   []OnKubernetesEventConfig
 	scheduleBindings     []ScheduleConfig
 	validatingBindings   []ValidatingConfig
 	mutatingBindings     []MutatingConfig
 	conversionBindings   []ConversionConfig
+	compositeBindings    []CompositeConfig
+	triggerBindings      []TriggerConfig
 }
 
 func (hc *HookController) InitKubernetesBindings(bindings []OnKubernetesEventConfig, kubeEventMgr kube_events_manager.KubeEventsManager) {
@@ -113,6 +117,24 @@ func (hc *HookController) InitConversionBindings(bindings []ConversionConfig, we
 	hc.conversionBindings = bindings
 }
 
+// InitCompositeBindings stores composite triggers. They are evaluated on events
+// of kubernetes bindings, so InitKubernetesBindings should be called too.
+func (hc *HookController) InitCompositeBindings(bindings []CompositeConfig) {
+	hc.compositeBindings = bindings
+}
+
+func (hc *HookController) InitTriggerBindings(bindings []TriggerConfig, triggerMgr trigger_manager.TriggerManager) {
+	if len(bindings) == 0 || triggerMgr == nil {
+		return
+	}
+
+	bindingCtrl := NewTriggerBindingsController()
+	bindingCtrl.WithTriggerManager(triggerMgr)
+	bindingCtrl.WithTriggerBindings(bindings)
+	hc.TriggerController = bindingCtrl
+	hc.triggerBindings = bindings
+}
+
 func (hc *HookController) CanHandleKubeEvent(kubeEvent KubeEvent) bool {
 	if hc.KubernetesController != nil {
 		return hc.KubernetesController.CanHandleEvent(kubeEvent)
@@ -141,6 +163,13 @@ func (hc *HookController) CanHandleConversionEvent(crdName string, event *v1.Con
 	return false
 }
 
+func (hc *HookController) CanHandleTriggerEvent(event trigger_manager.Event) bool {
+	if hc.TriggerController != nil {
+		return hc.TriggerController.CanHandleEvent(event)
+	}
+	return false
+}
+
 func (hc *HookController) HandleEnableKubernetesBindings(createTasksFn func(BindingExecutionInfo)) error {
 	if hc.KubernetesController != nil {
 		execInfos, err := hc.KubernetesController.EnableKubernetesBindings()
@@ -163,6 +192,15 @@ func (hc *HookController) HandleKubeEvent(event KubeEvent, createTasksFn func(Bi
 		if createTasksFn != nil {
 			createTasksFn(execInfo)
 		}
+		// Synchronization does not trigger composite bindings.
+		if event.Type != TypeEvent || len(hc.compositeBindings) == 0 {
+			return
+		}
+		for _, info := range hc.handleCompositeTriggers(execInfo.Binding) {
+			if createTasksFn != nil {
+				createTasksFn(info)
+			}
+		}
 	}
 }
 
@@ -199,6 +237,16 @@ func (hc *HookController) HandleScheduleEvent(crontab string, createTasksFn func
 	}
 }
 
+func (hc *HookController) HandleTriggerEvent(event trigger_manager.Event, createTasksFn func(BindingExecutionInfo)) {
+	if hc.TriggerController == nil {
+		return
+	}
+	execInfo := hc.TriggerController.HandleEvent(event)
+	if createTasksFn != nil {
+		createTasksFn(execInfo)
+	}
+}
+
 func (hc *HookController) UnlockKubernetesEvents() {
 	if hc.KubernetesController != nil {
 		hc.KubernetesController.UnlockEvents()
@@ -236,6 +284,18 @@ func (hc *HookController) DisableScheduleBindings() {
 	}
 }
 
+func (hc *HookController) EnableTriggerBindings() {
+	if hc.TriggerController != nil {
+		hc.TriggerController.EnableTriggerBindings()
+	}
+}
+
+func (hc *HookController) DisableTriggerBindings() {
+	if hc.TriggerController != nil {
+		hc.TriggerController.DisableTriggerBindings()
+	}
+}
+
 func (hc *HookController) EnableAdmissionBindings() {
 	if hc.AdmissionController != nil {
 		hc.AdmissionController.EnableValidatingBindings()
@@ -298,6 +358,20 @@ func (hc *HookController) getIncludeSnapshotsFrom(bindingType BindingType, bindi
 				break
 			}
 		}
+	case Composite:
+		for _, binding := range hc.compositeBindings {
+			if bindingName == binding.BindingName {
+				includeSnapshotsFrom = binding.IncludeSnapshotsFrom
+				break
+			}
+		}
+	default:
+		for _, binding := range hc.triggerBindings {
+			if bindingType == binding.BindingType && bindingName == binding.BindingName {
+				includeSnapshotsFrom = binding.IncludeSnapshotsFrom
+				break
+			}
+		}
 	}
 
 	return includeSnapshotsFrom
@@ -366,3 +440,11 @@ func (hc *HookController) SnapshotsDump() map[string]interface{} {
 
 	return hc.KubernetesController.SnapshotsDump()
 }
+
+func (hc *HookController) EventHistoryDump() map[string]interface{} {
+	if hc.KubernetesController == nil {
+		return nil
+	}
+
+	return hc.KubernetesController.EventHistoryDump()
+}
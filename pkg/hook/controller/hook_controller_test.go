@@ -78,3 +78,25 @@ kubernetes:
 	g.Expect(bc.Snapshots).Should(HaveKey("binding_2"))
 	g.Expect(bc.Snapshots).Should(HaveKey("binding_3"))
 }
+
+func Test_evaluateCompositeCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	ok, err := evaluateCompositeCondition(types.CompositeCondition{Binding: "deploy"}, nil)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+
+	objects := []types2.ObjectAndFilterResult{{FilterResult: map[string]interface{}{"ready": true}}}
+
+	ok, err = evaluateCompositeCondition(types.CompositeCondition{Binding: "deploy"}, objects)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ok).To(BeTrue())
+
+	ok, err = evaluateCompositeCondition(types.CompositeCondition{Binding: "deploy", JqFilter: ".[0].filterResult.ready"}, objects)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ok).To(BeTrue())
+
+	ok, err = evaluateCompositeCondition(types.CompositeCondition{Binding: "deploy", JqFilter: "length > 1"}, objects)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+}
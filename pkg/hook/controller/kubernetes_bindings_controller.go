@@ -36,6 +36,7 @@ type KubernetesBindingsController interface {
 	Snapshots() map[string][]ObjectAndFilterResult
 	SnapshotsInfo() []string
 	SnapshotsDump() map[string]interface{}
+	EventHistoryDump() map[string]interface{}
 }
 
 // kubernetesHooksController is a main implementation of KubernetesHooksController
@@ -76,13 +77,18 @@ func (c *kubernetesBindingsController) EnableKubernetesBindings() ([]BindingExec
 
 	for _, config := range c.KubernetesBindings {
 		err := c.kubeEventsManager.AddMonitor(config.Monitor)
-		if err != nil {
+		if err != nil && !c.kubeEventsManager.RetryForbiddenMonitor(config.Monitor, err) {
 			return nil, fmt.Errorf("run monitor: %s", err)
 		}
 		c.BindingMonitorLinks[config.Monitor.Metadata.MonitorId] = &KubernetesBindingToMonitorLink{
 			MonitorId:     config.Monitor.Metadata.MonitorId,
 			BindingConfig: config,
 		}
+		if err != nil {
+			// Monitor is started in background when RBAC is fixed.
+			// Synchronization is emitted by KubeEventsManager then.
+			continue
+		}
 		// Start monitor's informers to fill the cache.
 		c.kubeEventsManager.StartMonitor(config.Monitor.Metadata.MonitorId)
 
@@ -148,6 +154,10 @@ func (c *kubernetesBindingsController) UpdateMonitor(monitorId string, kind, api
 func (c *kubernetesBindingsController) UnlockEvents() {
 	for monitorID := range c.BindingMonitorLinks {
 		m := c.kubeEventsManager.GetMonitor(monitorID)
+		if m == nil {
+			// Monitor is waiting for RBAC permissions.
+			continue
+		}
 		m.EnableKubeEventCb()
 	}
 }
@@ -295,6 +305,19 @@ func (c *kubernetesBindingsController) SnapshotsDump() map[string]interface{} {
 	return dumps
 }
 
+// EventHistoryDump returns the last events for monitored objects of each binding.
+func (c *kubernetesBindingsController) EventHistoryDump() map[string]interface{} {
+	dumps := make(map[string]interface{})
+	for _, binding := range c.KubernetesBindings {
+		monitorID := binding.Monitor.Metadata.MonitorId
+		if c.kubeEventsManager.HasMonitor(monitorID) {
+			dumps[binding.BindingName] = c.kubeEventsManager.GetMonitor(monitorID).EventHistory()
+		}
+	}
+
+	return dumps
+}
+
 func ConvertKubeEventToBindingContext(kubeEvent KubeEvent, link *KubernetesBindingToMonitorLink) []BindingContext {
 	bindingContexts := make([]BindingContext, 0)
 
@@ -324,6 +347,7 @@ func ConvertKubeEventToBindingContext(kubeEvent KubeEvent, link *KubernetesBindi
 			bc.Metadata.BindingType = OnKubernetesEvent
 			bc.Metadata.IncludeSnapshots = link.BindingConfig.IncludeSnapshotsFrom
 			bc.Metadata.Group = link.BindingConfig.Group
+			bc.Metadata.EnvFromObject = link.BindingConfig.EnvFromObject
 
 			bindingContexts = append(bindingContexts, bc)
 		}
@@ -72,7 +72,8 @@ func (c *scheduleBindingsController) HandleEvent(crontab string) []BindingExecut
 	for _, link := range c.ScheduleLinks {
 		if link.Crontab == crontab {
 			bc := BindingContext{
-				Binding: link.BindingName,
+				Binding:  link.BindingName,
+				Crontabs: []string{crontab},
 			}
 			bc.Metadata.BindingType = Schedule
 			bc.Metadata.IncludeSnapshots = link.IncludeSnapshots
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"sync"
+
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+	. "github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
+)
+
+// TriggerBindingsController handles bindings with external sources for one hook.
+type TriggerBindingsController interface {
+	WithTriggerBindings([]TriggerConfig)
+	WithTriggerManager(trigger_manager.TriggerManager)
+	EnableTriggerBindings()
+	DisableTriggerBindings()
+	CanHandleEvent(event trigger_manager.Event) bool
+	HandleEvent(event trigger_manager.Event) BindingExecutionInfo
+}
+
+type triggerBindingsController struct {
+	// Enabled bindings by source id. Links are read by the events handler
+	// while sources are enabled in the queue, so access is guarded.
+	linksMu      sync.RWMutex
+	TriggerLinks map[string]TriggerConfig
+
+	// bindings configurations
+	TriggerBindings []TriggerConfig
+
+	// dependencies
+	triggerManager trigger_manager.TriggerManager
+}
+
+var _ TriggerBindingsController = &triggerBindingsController{}
+
+// NewTriggerBindingsController returns an implementation of TriggerBindingsController
+var NewTriggerBindingsController = func() *triggerBindingsController {
+	return &triggerBindingsController{
+		TriggerLinks: make(map[string]TriggerConfig),
+	}
+}
+
+func (c *triggerBindingsController) WithTriggerBindings(bindings []TriggerConfig) {
+	c.TriggerBindings = bindings
+}
+
+func (c *triggerBindingsController) WithTriggerManager(triggerManager trigger_manager.TriggerManager) {
+	c.triggerManager = triggerManager
+}
+
+func (c *triggerBindingsController) CanHandleEvent(event trigger_manager.Event) bool {
+	c.linksMu.RLock()
+	defer c.linksMu.RUnlock()
+	_, has := c.TriggerLinks[event.SourceId]
+	return has
+}
+
+func (c *triggerBindingsController) HandleEvent(event trigger_manager.Event) BindingExecutionInfo {
+	c.linksMu.RLock()
+	link, has := c.TriggerLinks[event.SourceId]
+	c.linksMu.RUnlock()
+	if !has {
+		return BindingExecutionInfo{}
+	}
+
+	bc := BindingContext{
+		Binding:     link.BindingName,
+		TriggerData: event.Data,
+	}
+	bc.Metadata.BindingType = link.BindingType
+	bc.Metadata.IncludeSnapshots = link.IncludeSnapshotsFrom
+	bc.Metadata.Group = link.Group
+
+	return BindingExecutionInfo{
+		BindingContext:   []BindingContext{bc},
+		IncludeSnapshots: link.IncludeSnapshotsFrom,
+		AllowFailure:     link.AllowFailure,
+		QueueName:        link.Queue,
+		Binding:          link.BindingName,
+		Group:            link.Group,
+	}
+}
+
+func (c *triggerBindingsController) EnableTriggerBindings() {
+	for _, config := range c.TriggerBindings {
+		if config.Source == nil {
+			continue
+		}
+		c.linksMu.Lock()
+		c.TriggerLinks[config.Id] = config
+		c.linksMu.Unlock()
+		c.triggerManager.Add(config.Id, config.Source)
+	}
+}
+
+func (c *triggerBindingsController) DisableTriggerBindings() {
+	for _, config := range c.TriggerBindings {
+		c.triggerManager.Remove(config.Id)
+		c.linksMu.Lock()
+		delete(c.TriggerLinks, config.Id)
+		c.linksMu.Unlock()
+	}
+}
@@ -0,0 +1,23 @@
+package hook
+
+import (
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+)
+
+// objectEnvs returns variables with fields of the object from the event for bindings with envFromObject.
+// Several events can be combined into one run, so the last event with the object is used.
+func objectEnvs(context []BindingContext) map[string]string {
+	for i := len(context) - 1; i >= 0; i-- {
+		bc := context[i]
+		if len(bc.Metadata.EnvFromObject) == 0 || len(bc.Objects) == 0 || bc.Objects[0].Object == nil {
+			continue
+		}
+		obj := bc.Objects[0].Object.Object
+		envs := make(map[string]string, len(bc.Metadata.EnvFromObject))
+		for name, path := range bc.Metadata.EnvFromObject {
+			envs[name] = path.LookupString(obj)
+		}
+		return envs
+	}
+	return nil
+}
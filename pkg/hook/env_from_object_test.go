@@ -0,0 +1,58 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+	. "github.com/flant/shell-operator/pkg/hook/types"
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func newPodEventContext(t *testing.T, podName string, nodeName string) BindingContext {
+	nodePath, err := ParseFieldPath(".spec.nodeName")
+	require.NoError(t, err)
+	appPath, err := ParseFieldPath(`.metadata.labels["app.kubernetes.io/name"]`)
+	require.NoError(t, err)
+	portsPath, err := ParseFieldPath(".spec.containers[0].ports")
+	require.NoError(t, err)
+
+	bc := BindingContext{Binding: "pods", Type: TypeEvent}
+	bc.Metadata.EnvFromObject = map[string]FieldPath{
+		"NODE_NAME": nodePath,
+		"APP_NAME":  appPath,
+		"PORTS":     portsPath,
+	}
+	bc.Objects = []ObjectAndFilterResult{{
+		Object: &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":   podName,
+				"labels": map[string]interface{}{"app.kubernetes.io/name": "web"},
+			},
+			"spec": map[string]interface{}{
+				"nodeName": nodeName,
+				"containers": []interface{}{
+					map[string]interface{}{"ports": []interface{}{int64(80), int64(443)}},
+				},
+			},
+		}},
+	}}
+	return bc
+}
+
+func Test_objectEnvs(t *testing.T) {
+	require.Nil(t, objectEnvs(nil))
+	require.Nil(t, objectEnvs([]BindingContext{{Binding: "schedule"}}))
+
+	require.Equal(t, map[string]string{
+		"NODE_NAME": "node-2",
+		"APP_NAME":  "web",
+		"PORTS":     "[80,443]",
+	}, objectEnvs([]BindingContext{
+		newPodEventContext(t, "pod-1", "node-1"),
+		newPodEventContext(t, "pod-2", "node-2"),
+		{Binding: "schedule"},
+	}))
+}
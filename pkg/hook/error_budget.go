@@ -0,0 +1,122 @@
+package hook
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+// errorBudget counts failed runs of the hook within the window. The hook is
+// quarantined for a cool-off period when the number of failures reaches the budget.
+type errorBudget struct {
+	failures         []time.Time
+	quarantinedUntil time.Time
+	// resyncMonitors are monitors with Synchronization or events dropped during the quarantine.
+	resyncMonitors map[string]struct{}
+}
+
+// observe registers the result of the run. It returns true when the budget
+// is exhausted and a new quarantine is started.
+func (b *errorBudget) observe(now time.Time, failed bool, budget int, window time.Duration, cooloff time.Duration) bool {
+	if budget <= 0 || !failed {
+		return false
+	}
+
+	// Drop failures outside the window.
+	recent := b.failures[:0]
+	for _, t := range b.failures {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	b.failures = append(recent, now)
+
+	if len(b.failures) < budget || now.Before(b.quarantinedUntil) {
+		return false
+	}
+
+	b.quarantinedUntil = now.Add(cooloff)
+	b.failures = nil
+	return true
+}
+
+func (b *errorBudget) quarantined(now time.Time) bool {
+	return now.Before(b.quarantinedUntil)
+}
+
+func (b *errorBudget) reset() {
+	b.failures = nil
+	b.quarantinedUntil = time.Time{}
+}
+
+// ObserveRunResult counts the failed run in the error budget of the hook.
+// It returns true if the hook is quarantined because of this failure.
+func (h *Hook) ObserveRunResult(failed bool) bool {
+	h.errorBudgetLock.Lock()
+	defer h.errorBudgetLock.Unlock()
+	started := h.errorBudget.observe(time.Now(), failed, app.HookErrorBudget, app.HookErrorBudgetWindow, app.HookQuarantineDuration)
+	if started {
+		log.WithField("hook", h.Name).Warnf("Hook failed %d times within %s, quarantine it for %s",
+			app.HookErrorBudget, app.HookErrorBudgetWindow.String(), app.HookQuarantineDuration.String())
+	}
+	return started
+}
+
+// IsQuarantined returns true if tasks for the hook should not be queued.
+func (h *Hook) IsQuarantined() bool {
+	h.errorBudgetLock.Lock()
+	defer h.errorBudgetLock.Unlock()
+	return h.errorBudget.quarantined(time.Now())
+}
+
+// QuarantinedUntil returns the end of the quarantine or zero time if the hook is not quarantined.
+func (h *Hook) QuarantinedUntil() time.Time {
+	h.errorBudgetLock.Lock()
+	defer h.errorBudgetLock.Unlock()
+	if !h.errorBudget.quarantined(time.Now()) {
+		return time.Time{}
+	}
+	return h.errorBudget.quarantinedUntil
+}
+
+// Unquarantine ends the quarantine and resets the error budget of the hook.
+func (h *Hook) Unquarantine() {
+	h.errorBudgetLock.Lock()
+	defer h.errorBudgetLock.Unlock()
+	h.errorBudget.reset()
+	log.WithField("hook", h.Name).Infof("Hook is released from quarantine")
+}
+
+// AddQuarantineResync remembers monitors of 'kubernetes' bindings with tasks
+// dropped because of the quarantine. The hook gets a Synchronization for them
+// when the quarantine ends, see TakeQuarantineResync.
+func (h *Hook) AddQuarantineResync(monitorIDs ...string) {
+	h.errorBudgetLock.Lock()
+	defer h.errorBudgetLock.Unlock()
+	if h.errorBudget.resyncMonitors == nil {
+		h.errorBudget.resyncMonitors = make(map[string]struct{})
+	}
+	for _, id := range monitorIDs {
+		h.errorBudget.resyncMonitors[id] = struct{}{}
+	}
+}
+
+// TakeQuarantineResync returns monitors to resynchronize and forgets them.
+// It returns nil while the hook is quarantined.
+func (h *Hook) TakeQuarantineResync() []string {
+	h.errorBudgetLock.Lock()
+	defer h.errorBudgetLock.Unlock()
+	if h.errorBudget.quarantined(time.Now()) || len(h.errorBudget.resyncMonitors) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(h.errorBudget.resyncMonitors))
+	for id := range h.errorBudget.resyncMonitors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h.errorBudget.resyncMonitors = nil
+	return ids
+}
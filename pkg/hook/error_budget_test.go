@@ -0,0 +1,51 @@
+package hook
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ErrorBudget(t *testing.T) {
+	g := NewWithT(t)
+
+	b := &errorBudget{}
+	now := time.Now()
+
+	// Disabled budget never quarantines.
+	g.Expect(b.observe(now, true, 0, time.Minute, time.Minute)).To(BeFalse())
+
+	// Successful runs are not counted.
+	g.Expect(b.observe(now, false, 2, time.Minute, time.Minute)).To(BeFalse())
+	g.Expect(b.observe(now, true, 2, time.Minute, time.Minute)).To(BeFalse())
+
+	// Failure outside the window is dropped.
+	now = now.Add(2 * time.Minute)
+	g.Expect(b.observe(now, true, 2, time.Minute, time.Minute)).To(BeFalse())
+	g.Expect(b.quarantined(now)).To(BeFalse())
+
+	// Budget is exhausted.
+	g.Expect(b.observe(now.Add(time.Second), true, 2, time.Minute, time.Minute)).To(BeTrue())
+	g.Expect(b.quarantined(now.Add(30 * time.Second))).To(BeTrue())
+	g.Expect(b.quarantined(now.Add(2 * time.Minute))).To(BeFalse())
+
+	b.reset()
+	g.Expect(b.quarantined(now.Add(30 * time.Second))).To(BeFalse())
+}
+
+func Test_Hook_QuarantineResync(t *testing.T) {
+	g := NewWithT(t)
+
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	h.errorBudget.quarantinedUntil = time.Now().Add(time.Minute)
+	h.AddQuarantineResync("monitor-2", "monitor-1")
+	h.AddQuarantineResync("monitor-1")
+
+	// Monitors are resynchronized only after the quarantine.
+	g.Expect(h.TakeQuarantineResync()).To(BeNil())
+
+	h.Unquarantine()
+	g.Expect(h.TakeQuarantineResync()).To(Equal([]string{"monitor-1", "monitor-2"}))
+	g.Expect(h.TakeQuarantineResync()).To(BeNil())
+}
@@ -1,12 +1,17 @@
 package hook
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	uuid "github.com/gofrs/uuid/v5"
 	"github.com/kennygrant/sanitize"
@@ -14,10 +19,13 @@ import (
 
 	"github.com/flant/shell-operator/pkg/app"
 	"github.com/flant/shell-operator/pkg/executor"
+	"github.com/flant/shell-operator/pkg/golden"
 	. "github.com/flant/shell-operator/pkg/hook/binding_context"
 	"github.com/flant/shell-operator/pkg/hook/config"
 	"github.com/flant/shell-operator/pkg/hook/controller"
 	. "github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
 	"github.com/flant/shell-operator/pkg/metric_storage/operation"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
 	"github.com/flant/shell-operator/pkg/webhook/conversion"
@@ -27,14 +35,46 @@ type CommonHook interface {
 	Name() string
 }
 
+// ErrHookTimeout is returned by Run if the hook process is terminated on executionTimeout.
+var ErrHookTimeout = executor.ErrExecutionTimeout
+
+// CommandHookShell runs the inline command of a command hook.
+const CommandHookShell = "/bin/sh"
+
+// ShadowEnvName is passed to shadow hooks to distinguish them from primary hooks.
+const ShadowEnvName = "SHELL_OPERATOR_SHADOW"
+
 type Result struct {
 	Usage                *executor.CmdUsage
 	Metrics              []operation.MetricOperation
 	ConversionResponse   *conversion.Response
 	AdmissionResponse    *admission.Response
 	KubernetesPatchBytes []byte
+	// TruncatedOutputs contains names of outputs that exceeded the output limit.
+	TruncatedOutputs []string
+	// OutputParseErrors contains names of malformed outputs, including ones from retried runs.
+	OutputParseErrors []string
+	// ExitCode and StderrTail are details of the hook process. They are not set for warm pool runs.
+	ExitCode   int
+	StderrTail string
+	// Phases are durations of phases of the run. Phases after the failed one are not set.
+	Phases map[RunPhase]time.Duration
+	// BindingContexts are binding contexts passed to the hook with refreshed snapshots.
+	BindingContexts []BindingContext
 }
 
+// RunPhase is a part of the hook run measured separately.
+type RunPhase string
+
+const (
+	// RunPhaseContextBuild is an update of snapshots and preparation of the binding context and temporary files.
+	RunPhaseContextBuild RunPhase = "context_build"
+	// RunPhaseExec is an execution of the hook process.
+	RunPhaseExec RunPhase = "exec"
+	// RunPhaseOutputParse is reading of metrics, webhook responses and object patches written by the hook.
+	RunPhaseOutputParse RunPhase = "output_parse"
+)
+
 type Hook struct {
 	Name   string // The unique name like '002-prometheus-hooks/startup_hook'.
 	Path   string // The absolute path to the executable file.
@@ -42,8 +82,42 @@ type Hook struct {
 
 	HookController *controller.HookController
 	RateLimiter    *rate.Limiter
+	// Pool is a set of warm hook processes. It is nil if warmPoolSize is not set.
+	Pool *executor.Pool
 
 	TmpDir string
+	// KubeconfigPath is passed to the hook as $KUBECONFIG if the operator's environment has no KUBECONFIG.
+	KubeconfigPath string
+	// Cluster is passed to the hook in the 'cluster' field of binding contexts. Nil means no field.
+	Cluster *Cluster
+	// ValuesPath is a JSON file with validated user values passed to the hook as $HOOK_VALUES_PATH.
+	ValuesPath string
+
+	lastRunLock sync.Mutex
+	runs        *runHistory
+	// snapshotsChecksums are checksums of snapshots for the last successful runs of schedule bindings.
+	snapshotsChecksums map[string]string
+	// patchResults is a JSON with results of Create operations from the last run.
+	patchResults []byte
+
+	// paused is set at runtime to skip hook runs. See SetPaused.
+	paused atomic.Bool
+
+	// errorBudget quarantines the hook after too many failures. See ObserveRunResult.
+	errorBudget     errorBudget
+	errorBudgetLock sync.Mutex
+
+	// check keeps results of self-checks. See Check.
+	check hookCheck
+
+	// checksums verify the hook file before each run. It is nil if verification is disabled.
+	checksums *Checksums
+
+	// recorder saves inputs and outputs of runs. It is nil if recording is disabled.
+	recorder *golden.Recorder
+
+	// Shadow is set for hooks from the shadow hooks directory. Their outputs are never applied.
+	Shadow bool
 }
 
 func NewHook(name, path string) *Hook {
@@ -58,6 +132,22 @@ func (h *Hook) WithTmpDir(dir string) {
 	h.TmpDir = dir
 }
 
+func (h *Hook) WithChecksums(checksums *Checksums) {
+	h.checksums = checksums
+}
+
+func (h *Hook) WithRecorder(recorder *golden.Recorder) {
+	h.recorder = recorder
+}
+
+func (h *Hook) WithKubeconfig(path string) {
+	h.KubeconfigPath = path
+}
+
+func (h *Hook) WithCluster(cluster *Cluster) {
+	h.Cluster = cluster
+}
+
 func (h *Hook) LoadConfig(configOutput []byte) (hook *Hook, err error) {
 	err = h.Config.LoadAndValidate(configOutput)
 	if err != nil {
@@ -66,9 +156,21 @@ func (h *Hook) LoadConfig(configOutput []byte) (hook *Hook, err error) {
 
 	h.RateLimiter = CreateRateLimiter(h.Config)
 
+	if h.Config.Settings != nil && h.Config.Settings.WarmPoolSize > 0 {
+		if h.IsCommandHook() {
+			return h, fmt.Errorf("load hook '%s' config: warmPoolSize is not supported for command hooks", h.Name)
+		}
+		h.Pool = executor.NewPool(h.workingDir(), h.Path, h.environ(), h.Config.Settings.WarmPoolSize, map[string]string{"hook": h.Name}, h.runOptions()...)
+	}
+
 	return h, nil
 }
 
+// IsCommandHook returns true if the hook runs an inline command from its config.
+func (h *Hook) IsCommandHook() bool {
+	return h.Config != nil && h.Config.Command != ""
+}
+
 func (h *Hook) GetConfig() *config.HookConfig {
 	return h.Config
 }
@@ -81,81 +183,202 @@ func (h *Hook) WithHookController(hookController *controller.HookController) {
 	h.HookController = hookController
 }
 
-func (h *Hook) Run(_ BindingType, context []BindingContext, logLabels map[string]string) (*Result, error) {
-	// Refresh snapshots
-	freshBindingContext := h.HookController.UpdateSnapshots(context)
+func (h *Hook) Run(bindingType BindingType, bindingContext []BindingContext, logLabels map[string]string) (*Result, error) {
+	return h.RunContext(context.Background(), bindingType, bindingContext, logLabels)
+}
+
+// RunContext executes the hook as Run does. The hook process is terminated when ctx is done.
+func (h *Hook) RunContext(ctx context.Context, bindingType BindingType, context []BindingContext, logLabels map[string]string) (*Result, error) {
+	return h.runWithOutputRetries(func() (*Result, error) {
+		return h.run(ctx, bindingType, context, logLabels)
+	}, logLabels)
+}
+
+func (h *Hook) run(ctx context.Context, bindingType BindingType, context []BindingContext, logLabels map[string]string) (*Result, error) {
+	if err := h.checksums.Verify(h.Path); err != nil {
+		return nil, fmt.Errorf("%s refused: %w", h.Name, err)
+	}
+
+	contextBuildStart := time.Now()
+	// Refresh snapshots. Shadow hooks get binding contexts of the primary run
+	// with snapshots already refreshed, so both hooks have the same input.
+	freshBindingContext := context
+	if !h.Shadow {
+		freshBindingContext = h.HookController.UpdateSnapshots(context)
+		for i := range freshBindingContext {
+			freshBindingContext[i].Metadata.Cluster = h.Cluster
+		}
+	}
 
 	versionedContextList := ConvertBindingContextList(h.Config.Version, freshBindingContext)
 
+	var contextPath, metricsPath, admissionPath, conversionPath, kubernetesPatchPath, kubernetesPatchResultsPath string
+	// Remove tmp files on hook exit. The cleanup is registered before files are
+	// created, so files are not left if preparing or chown fails.
+	defer func() {
+		if app.DebugKeepTmpFiles == "yes" {
+			return
+		}
+		for _, p := range []string{contextPath, metricsPath, conversionPath, admissionPath, kubernetesPatchPath, kubernetesPatchResultsPath} {
+			if p != "" {
+				_ = os.Remove(p)
+			}
+		}
+	}()
+
 	contextPath, err := h.prepareBindingContextJsonFile(versionedContextList)
 	if err != nil {
 		return nil, err
 	}
 
-	metricsPath, err := h.prepareMetricsFile()
+	metricsPath, err = h.prepareMetricsFile()
 	if err != nil {
 		return nil, err
 	}
 
-	admissionPath, err := h.prepareAdmissionResponseFile()
+	admissionPath, err = h.prepareAdmissionResponseFile()
 	if err != nil {
 		return nil, err
 	}
 
-	conversionPath, err := h.prepareConversionResponseFile()
+	conversionPath, err = h.prepareConversionResponseFile()
 	if err != nil {
 		return nil, err
 	}
 
-	kubernetesPatchPath, err := h.prepareObjectPatchFile()
+	kubernetesPatchPath, err = h.prepareObjectPatchFile()
 	if err != nil {
 		return nil, err
 	}
 
-	// remove tmp file on hook exit
-	defer func() {
-		if app.DebugKeepTmpFiles != "yes" {
-			_ = os.Remove(contextPath)
-			_ = os.Remove(metricsPath)
-			_ = os.Remove(conversionPath)
-			_ = os.Remove(admissionPath)
-			_ = os.Remove(kubernetesPatchPath)
-		}
-	}()
+	kubernetesPatchResultsPath, err = h.preparePatchResultsFile()
+	if err != nil {
+		return nil, err
+	}
 
-	envs := make([]string, 0)
-	envs = append(envs, os.Environ()...)
+	err = h.chownTmpFiles(contextPath, metricsPath, admissionPath, conversionPath, kubernetesPatchPath, kubernetesPatchResultsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	runEnvs := make(map[string]string)
+	// Variables below override fields of the object. Fields are passed after variables of the
+	// operator process, so ConvertEnvFromObject rejects reserved names like PATH or LD_PRELOAD.
+	for name, value := range objectEnvs(context) {
+		runEnvs[name] = value
+	}
 	if contextPath != "" {
-		envs = append(envs, fmt.Sprintf("BINDING_CONTEXT_PATH=%s", contextPath))
-		envs = append(envs, fmt.Sprintf("METRICS_PATH=%s", metricsPath))
-		envs = append(envs, fmt.Sprintf("CONVERSION_RESPONSE_PATH=%s", conversionPath))
-		envs = append(envs, fmt.Sprintf("VALIDATING_RESPONSE_PATH=%s", admissionPath))
-		envs = append(envs, fmt.Sprintf("ADMISSION_RESPONSE_PATH=%s", admissionPath))
-		envs = append(envs, fmt.Sprintf("KUBERNETES_PATCH_PATH=%s", kubernetesPatchPath))
+		runEnvs["BINDING_CONTEXT_PATH"] = contextPath
+		runEnvs["BINDING_CONTEXT_FORMAT"] = string(h.bindingContextFormat())
+		runEnvs["METRICS_PATH"] = metricsPath
+		runEnvs["CONVERSION_RESPONSE_PATH"] = conversionPath
+		runEnvs["VALIDATING_RESPONSE_PATH"] = admissionPath
+		runEnvs["ADMISSION_RESPONSE_PATH"] = admissionPath
+		runEnvs["KUBERNETES_PATCH_PATH"] = kubernetesPatchPath
+		runEnvs["KUBERNETES_PATCH_RESULTS_PATH"] = kubernetesPatchResultsPath
+	}
+	switch {
+	case h.Shadow:
+		// The kubeconfig of the shadow hook has no credentials and no reachable server,
+		// so the shadow can't change the cluster even if the operator has KUBECONFIG.
+		runEnvs["KUBECONFIG"] = h.KubeconfigPath
+	case h.KubeconfigPath != "" && os.Getenv("KUBECONFIG") == "":
+		runEnvs["KUBECONFIG"] = h.KubeconfigPath
+	}
+	if h.ValuesPath != "" {
+		runEnvs["HOOK_VALUES_PATH"] = h.ValuesPath
+	}
+	if read_only.Enabled() {
+		runEnvs[read_only.EnvName] = "true"
+	}
+	if h.Shadow {
+		runEnvs[ShadowEnvName] = "true"
+	}
+	for name, value := range backpressureEnvs(context) {
+		runEnvs[name] = value
 	}
 
-	hookCmd := executor.MakeCommand(path.Dir(h.Path), h.Path, []string{}, envs)
+	result := &Result{
+		Phases:          map[RunPhase]time.Duration{RunPhaseContextBuild: time.Since(contextBuildStart)},
+		BindingContexts: freshBindingContext,
+	}
 
-	result := &Result{}
+	// Record before tmp files are removed.
+	if h.recorder != nil {
+		defer h.recordRun(bindingType, context, result, map[string]string{
+			golden.BindingContextFileName:         contextPath,
+			golden.KubernetesPatchResultsFileName: kubernetesPatchResultsPath,
+			golden.MetricsFileName:                metricsPath,
+			golden.KubernetesPatchFileName:        kubernetesPatchPath,
+			golden.AdmissionResponseFileName:      admissionPath,
+			golden.ConversionResponseFileName:     conversionPath,
+		})
+	}
 
-	result.Usage, err = executor.RunAndLogLines(hookCmd, logLabels)
+	// Options of the run. Options of the hook are set by runOptions, the pool has them already.
+	opts := []executor.RunOption{executor.WithContext(ctx)}
+	if app.HookOutputLimit > 0 {
+		// Callback is called from goroutines that copy stdout and stderr.
+		var mu sync.Mutex
+		opts = append(opts, executor.WithOutputLimit(app.HookOutputLimit, func(output string) {
+			mu.Lock()
+			defer mu.Unlock()
+			result.TruncatedOutputs = append(result.TruncatedOutputs, output)
+		}))
+	}
+	var runInfo executor.RunInfo
+	opts = append(opts, executor.WithRunInfo(&runInfo, StderrTailSize))
+
+	execStart := time.Now()
+	if h.Pool != nil {
+		result.Usage, err = h.Pool.Run(runEnvs, logLabels, opts...)
+	} else {
+		envs := make([]string, 0)
+		envs = append(envs, h.environ()...)
+		for _, name := range sortedEnvNames(runEnvs) {
+			envs = append(envs, fmt.Sprintf("%s=%s", name, runEnvs[name]))
+		}
+
+		hookCmd := executor.MakeCommand(h.workingDir(), h.Path, []string{}, envs)
+		if h.IsCommandHook() {
+			hookCmd = executor.MakeCommand(h.workingDir(), CommandHookShell, []string{"-c", h.Config.Command}, envs)
+		}
+		result.Usage, err = executor.RunAndLogLines(hookCmd, logLabels, append(h.runOptions(), opts...)...)
+	}
+	result.ExitCode = runInfo.ExitCode
+	result.StderrTail = runInfo.StderrTail
+	result.Phases[RunPhaseExec] = time.Since(execStart)
 	if err != nil {
-		return result, fmt.Errorf("%s FAILED: %s", h.Name, err)
+		return result, fmt.Errorf("%s FAILED: %w", h.Name, err)
 	}
 
+	outputParseStart := time.Now()
+	defer func() {
+		result.Phases[RunPhaseOutputParse] = time.Since(outputParseStart)
+	}()
+
 	result.Metrics, err = operation.MetricOperationsFromFile(metricsPath)
 	if err != nil {
-		return result, fmt.Errorf("got bad metrics: %s", err)
+		result.Metrics = nil
+		if err := h.handleOutputParseError(result, OutputMetrics, err, logLabels); err != nil {
+			return result, err
+		}
 	}
 
 	result.AdmissionResponse, err = admission.ResponseFromFile(admissionPath)
 	if err != nil {
-		return result, fmt.Errorf("got bad validating response: %s", err)
+		result.AdmissionResponse = nil
+		if err := h.handleOutputParseError(result, OutputAdmission, err, logLabels); err != nil {
+			return result, err
+		}
 	}
 
 	result.ConversionResponse, err = conversion.ResponseFromFile(conversionPath)
 	if err != nil {
-		return result, fmt.Errorf("got bad conversion response: %s", err)
+		result.ConversionResponse = nil
+		if err := h.handleOutputParseError(result, OutputConversion, err, logLabels); err != nil {
+			return result, err
+		}
 	}
 
 	result.KubernetesPatchBytes, err = os.ReadFile(kubernetesPatchPath)
@@ -163,9 +386,116 @@ func (h *Hook) Run(_ BindingType, context []BindingContext, logLabels map[string
 		return result, fmt.Errorf("can't read object patch file: %s", err)
 	}
 
+	if len(result.KubernetesPatchBytes) > 0 {
+		if _, err := object_patch.ParseOperations(result.KubernetesPatchBytes); err != nil {
+			result.KubernetesPatchBytes = nil
+			if err := h.handleOutputParseError(result, OutputKubernetesPatch, err, logLabels); err != nil {
+				return result, err
+			}
+		}
+	}
+
 	return result, nil
 }
 
+// StopPool kills warm processes if pool is enabled for the hook.
+func (h *Hook) StopPool() {
+	if h.Pool != nil {
+		h.Pool.Stop()
+	}
+}
+
+// workingDir returns a directory to run the hook in: the directory
+// with the hook file or a workingDir from settings.
+func (h *Hook) workingDir() string {
+	hookDir := path.Dir(h.Path)
+	if h.Config.Settings == nil || h.Config.Settings.WorkingDir == "" {
+		return hookDir
+	}
+	if filepath.IsAbs(h.Config.Settings.WorkingDir) {
+		return h.Config.Settings.WorkingDir
+	}
+	return filepath.Join(hookDir, h.Config.Settings.WorkingDir)
+}
+
+// environ returns operator environment variables filtered with allowedEnvPrefixes.
+// PATH is always passed to the hook.
+func (h *Hook) environ() []string {
+	if h.Config.Settings == nil || len(h.Config.Settings.AllowedEnvPrefixes) == 0 {
+		return os.Environ()
+	}
+
+	envs := make([]string, 0)
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, "PATH=") {
+			envs = append(envs, env)
+			continue
+		}
+		for _, prefix := range h.Config.Settings.AllowedEnvPrefixes {
+			if strings.HasPrefix(env, prefix) {
+				envs = append(envs, env)
+				break
+			}
+		}
+	}
+	return envs
+}
+
+func (h *Hook) runOptions() []executor.RunOption {
+	opts := make([]executor.RunOption, 0)
+	if h.Config.Settings != nil && h.Config.Settings.Umask != nil {
+		opts = append(opts, executor.WithUmask(*h.Config.Settings.Umask))
+	}
+	if uid, gid, ok := h.credential(); ok {
+		opts = append(opts, executor.WithCredential(uid, gid))
+	}
+	if h.Config.Settings != nil && h.Config.Settings.ExecutionTimeout > 0 {
+		opts = append(opts, executor.WithTimeout(h.Config.Settings.ExecutionTimeout))
+	}
+	return opts
+}
+
+// credential returns ids from runAsUser and runAsGroup settings.
+// Operator's ids are used for missing settings.
+func (h *Hook) credential() (uid uint32, gid uint32, ok bool) {
+	if h.Config.Settings == nil || (h.Config.Settings.RunAsUser == nil && h.Config.Settings.RunAsGroup == nil) {
+		return 0, 0, false
+	}
+	uid = uint32(os.Getuid())
+	gid = uint32(os.Getgid())
+	if h.Config.Settings.RunAsUser != nil {
+		uid = *h.Config.Settings.RunAsUser
+	}
+	if h.Config.Settings.RunAsGroup != nil {
+		gid = *h.Config.Settings.RunAsGroup
+	}
+	return uid, gid, true
+}
+
+// chownTmpFiles gives the ownership of temporary files to the hook user,
+// so the hook can write its output if runAsUser or runAsGroup is set.
+func (h *Hook) chownTmpFiles(paths ...string) error {
+	uid, gid, ok := h.credential()
+	if !ok {
+		return nil
+	}
+	for _, p := range paths {
+		if err := os.Chown(p, int(uid), int(gid)); err != nil {
+			return fmt.Errorf("change owner of '%s' to %d:%d: %v", p, uid, gid, err)
+		}
+	}
+	return nil
+}
+
+func sortedEnvNames(envs map[string]string) []string {
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (h *Hook) SafeName() string {
 	return sanitize.BaseName(h.Name)
 }
@@ -250,11 +580,18 @@ func (h *Hook) GetConfigDescription() string {
 	}
 	if h.Config.Settings != nil {
 		msgs = append(msgs, fmt.Sprintf("Rate: %s/%d", h.Config.Settings.ExecutionMinInterval.String(), h.Config.Settings.ExecutionBurst))
+		if h.Config.Settings.WarmPoolSize > 0 {
+			msgs = append(msgs, fmt.Sprintf("Warm pool: %d", h.Config.Settings.WarmPoolSize))
+		}
 	}
 	return strings.Join(msgs, ", ")
 }
 
 func (h *Hook) prepareBindingContextJsonFile(context BindingContextList) (string, error) {
+	if h.bindingContextFormat() == BindingContextJSONLines {
+		return h.prepareBindingContextJsonLinesFile(context)
+	}
+
 	var err error
 	data, err := context.Json()
 	if err != nil {
@@ -271,6 +608,39 @@ func (h *Hook) prepareBindingContextJsonFile(context BindingContextList) (string
 	return bindingContextPath, nil
 }
 
+// prepareBindingContextJsonLinesFile streams binding contexts to the file
+// without building one big JSON document in memory.
+func (h *Hook) prepareBindingContextJsonLinesFile(context BindingContextList) (string, error) {
+	bindingContextPath := filepath.Join(h.TmpDir, fmt.Sprintf("hook-%s-binding-context-%s.jsonl", h.SafeName(), uuid.Must(uuid.NewV4()).String()))
+
+	f, err := os.OpenFile(bindingContextPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	err = context.WriteJsonLines(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(bindingContextPath)
+		return "", err
+	}
+
+	return bindingContextPath, nil
+}
+
+// bindingContextFormat returns a format of the binding context file from the hook settings.
+func (h *Hook) bindingContextFormat() BindingContextFormat {
+	if h.Config != nil && h.Config.Settings != nil && h.Config.Settings.BindingContextFormat != "" {
+		return h.Config.Settings.BindingContextFormat
+	}
+	return BindingContextJSON
+}
+
 func (h *Hook) prepareMetricsFile() (string, error) {
 	metricsPath := filepath.Join(h.TmpDir, fmt.Sprintf("hook-%s-metrics-%s.json", h.SafeName(), uuid.Must(uuid.NewV4()).String()))
 
@@ -0,0 +1,92 @@
+package hook
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// filterHookPaths drops hooks that do not match enableHooks or match disableHooks patterns.
+// Patterns are matched against hook names, i.e. paths relative to the working directory.
+func (hm *Manager) filterHookPaths(hookPaths []string) ([]string, error) {
+	if len(hm.enableHooks) == 0 && len(hm.disableHooks) == 0 {
+		return hookPaths, nil
+	}
+
+	res := make([]string, 0, len(hookPaths))
+	for _, hookPath := range hookPaths {
+		hookName, err := filepath.Rel(hm.workingDir, hookPath)
+		if err != nil {
+			return nil, err
+		}
+		enabled, err := hookNameEnabled(hookName, hm.enableHooks, hm.disableHooks)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			log.WithField("hook", hookName).Infof("Hook is disabled by --enable-hooks or --disable-hooks, skip it")
+			continue
+		}
+		res = append(res, hookPath)
+	}
+	return res, nil
+}
+
+// hookNameEnabled returns true if the name matches one of enable patterns
+// or enable patterns are empty, and the name matches no disable patterns.
+func hookNameEnabled(hookName string, enable []string, disable []string) (bool, error) {
+	disabled, err := matchAny(hookName, disable)
+	if err != nil || disabled {
+		return false, err
+	}
+	if len(enable) == 0 {
+		return true, nil
+	}
+	return matchAny(hookName, enable)
+}
+
+func matchAny(name string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("bad hook name pattern '%s': %v", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// splitPatterns splits comma-separated values and drops empty ones.
+func splitPatterns(values []string) []string {
+	res := make([]string, 0)
+	for _, value := range values {
+		for _, pattern := range strings.Split(value, ",") {
+			pattern = strings.TrimSpace(pattern)
+			if pattern != "" {
+				res = append(res, pattern)
+			}
+		}
+	}
+	return res
+}
+
+// SetPaused disables or enables the hook at runtime. Bindings of the paused hook
+// are still monitored, but the hook is not executed.
+func (h *Hook) SetPaused(paused bool) {
+	h.paused.Store(paused)
+	if paused {
+		log.WithField("hook", h.Name).Infof("Hook is disabled at runtime")
+	} else {
+		log.WithField("hook", h.Name).Infof("Hook is enabled at runtime")
+	}
+}
+
+// IsPaused returns true if the hook is disabled at runtime.
+func (h *Hook) IsPaused() bool {
+	return h.paused.Load()
+}
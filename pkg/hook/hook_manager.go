@@ -7,29 +7,68 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	"github.com/flant/shell-operator/pkg/executor"
+	"github.com/flant/shell-operator/pkg/golden"
+	"github.com/flant/shell-operator/pkg/hook/binding_context"
 	"github.com/flant/shell-operator/pkg/hook/controller"
 	. "github.com/flant/shell-operator/pkg/hook/types"
 	"github.com/flant/shell-operator/pkg/kube_events_manager"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 	"github.com/flant/shell-operator/pkg/schedule_manager"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 	utils_file "github.com/flant/shell-operator/pkg/utils/file"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
 	"github.com/flant/shell-operator/pkg/webhook/conversion"
 )
 
+// HookManager is an interface of Manager for programs that embed ShellOperator.
+// The ShellOperator.HookManager field keeps the *Manager type for compatibility.
+type HookManager interface {
+	Init() error
+	Stop()
+	GetHook(name string) *Hook
+	GetHookNames() []string
+	GetHooksInOrder(bindingType BindingType) ([]string, error)
+	SetHookPaused(name string, paused bool) error
+	HandleKubeEvent(kubeEvent KubeEvent, createTaskFn func(*Hook, controller.BindingExecutionInfo))
+	HandleScheduleEvent(crontab string, createTaskFn func(*Hook, controller.BindingExecutionInfo))
+	HandleTriggerEvent(event trigger_manager.Event, createTaskFn func(*Hook, controller.BindingExecutionInfo))
+	HandleAdmissionEvent(event admission.Event, createTaskFn func(*Hook, controller.BindingExecutionInfo))
+	DetectAdmissionEventType(event admission.Event) BindingType
+	HandleConversionEvent(crdName string, request *v1.ConversionRequest, rule conversion.Rule, createTaskFn func(*Hook, controller.BindingExecutionInfo))
+	FindConversionChain(crdName string, rule conversion.Rule) []conversion.Rule
+}
+
+var _ HookManager = &Manager{}
+
 type Manager struct {
 	// dependencies
 	workingDir               string
 	tempDir                  string
 	kubeEventsManager        kube_events_manager.KubeEventsManager
 	scheduleManager          schedule_manager.ScheduleManager
+	triggerManager           trigger_manager.TriggerManager
 	conversionWebhookManager *conversion.WebhookManager
 	admissionWebhookManager  *admission.WebhookManager
+	configCache              *ConfigCache
+	configConcurrency        int
+	configTimeout            time.Duration
+	kubeconfigPath           string
+	cluster                  *binding_context.Cluster
+	namespace                string
+	enableHooks              []string
+	disableHooks             []string
+	profile                  string
+	checksumsPath            string
+	checksumsPublicKey       string
+	checksums                *Checksums
+	recorder                 *golden.Recorder
 
 	// sorted hook names
 	hookNamesInOrder []string
@@ -49,11 +88,42 @@ type ManagerConfig struct {
 	TempDir    string
 	Kmgr       kube_events_manager.KubeEventsManager
 	Smgr       schedule_manager.ScheduleManager
+	Tmgr       trigger_manager.TriggerManager
 	Wmgr       *admission.WebhookManager
 	Cmgr       *conversion.WebhookManager
+	// ConfigCacheDir is a directory to cache 'hook --config' outputs. Cache is disabled if empty.
+	ConfigCacheDir string
+	// ConfigConcurrency is a number of hooks executed with --config at the same time.
+	ConfigConcurrency int
+	// ConfigTimeout limits 'hook --config' execution. No timeout if zero.
+	ConfigTimeout time.Duration
+	// KubeconfigPath is a kubeconfig file for hooks. See GenerateKubeconfig.
+	KubeconfigPath string
+	// Cluster is metadata of the cluster for binding contexts. The 'cluster' field is not set if nil.
+	Cluster *binding_context.Cluster
+	// Namespace is a default namespace in kubeconfigs for hooks.
+	Namespace string
+	// EnableHooks are glob patterns for hook names to load. All hooks are loaded if empty.
+	EnableHooks []string
+	// DisableHooks are glob patterns for hook names to ignore. It takes precedence over EnableHooks.
+	DisableHooks []string
+	// Profile selects hooks of the profile and hooks without profiles. All hooks are loaded if empty.
+	Profile string
+	// ChecksumsPath is a manifest of checksums of hook files. Hooks are not verified if empty.
+	ChecksumsPath string
+	// ChecksumsPublicKey is a PEM public key to verify the signature of the checksums manifest.
+	ChecksumsPublicKey string
+	// Recorder saves inputs and outputs of hook runs. Runs are not recorded if nil.
+	Recorder *golden.Recorder
 }
 
 func NewHookManager(config *ManagerConfig) *Manager {
+	var configCache *ConfigCache
+	if config.ConfigCacheDir != "" {
+		// Hooks are executed with --config in the operator environment.
+		configCache = NewConfigCache(config.ConfigCacheDir, config.WorkingDir, os.Environ())
+	}
+
 	return &Manager{
 		hooksByName:      make(map[string]*Hook),
 		hookNamesInOrder: make([]string, 0),
@@ -64,8 +134,21 @@ func NewHookManager(config *ManagerConfig) *Manager {
 		tempDir:                  config.TempDir,
 		kubeEventsManager:        config.Kmgr,
 		scheduleManager:          config.Smgr,
+		triggerManager:           config.Tmgr,
 		admissionWebhookManager:  config.Wmgr,
 		conversionWebhookManager: config.Cmgr,
+		configCache:              configCache,
+		configConcurrency:        config.ConfigConcurrency,
+		configTimeout:            config.ConfigTimeout,
+		kubeconfigPath:           config.KubeconfigPath,
+		cluster:                  config.Cluster,
+		namespace:                config.Namespace,
+		enableHooks:              splitPatterns(config.EnableHooks),
+		disableHooks:             splitPatterns(config.DisableHooks),
+		profile:                  config.Profile,
+		checksumsPath:            config.ChecksumsPath,
+		checksumsPublicKey:       config.ChecksumsPublicKey,
+		recorder:                 config.Recorder,
 	}
 }
 
@@ -78,12 +161,25 @@ func (hm *Manager) TempDir() string {
 }
 
 // Init finds executables in WorkingDir, execute them with --config argument and add them into indices.
+// Command hooks are loaded from '*.hook.yaml' files without execution.
 func (hm *Manager) Init() error {
 	log.Info("Initialize hooks manager. Search for and load all hooks.")
 
 	hm.hooksInOrder = make(map[BindingType][]*Hook)
 	hm.hooksByName = make(map[string]*Hook)
 
+	if hm.checksumsPath != "" {
+		checksums, err := LoadChecksums(hm.workingDir, hm.checksumsPath, hm.checksumsPublicKey)
+		if err != nil {
+			return err
+		}
+		if err := checksums.VerifyAll(); err != nil {
+			return fmt.Errorf("verify hooks: %v", err)
+		}
+		hm.checksums = checksums
+		log.Infof("Hook files are verified with checksums from '%s'", hm.checksumsPath)
+	}
+
 	if err := utils_file.RecursiveCheckLibDirectory(hm.workingDir); err != nil {
 		log.Errorf("failed to check lib directory %s: %v", hm.workingDir, err)
 	}
@@ -93,12 +189,38 @@ func (hm *Manager) Init() error {
 		return err
 	}
 
+	commandHookPaths, err := utils_file.RecursiveGetCommandHookPaths(hm.workingDir)
+	if err != nil {
+		return err
+	}
+	hooksRelativePaths = append(hooksRelativePaths, commandHookPaths...)
+
 	// sort hooks by path
 	sort.Strings(hooksRelativePaths)
 	log.Debugf("  Search hooks in this paths: %+v", hooksRelativePaths)
 
-	for _, hookPath := range hooksRelativePaths {
-		hook, err := hm.loadHook(hookPath)
+	hooksRelativePaths, err = hm.filterHookPaths(hooksRelativePaths)
+	if err != nil {
+		return err
+	}
+
+	hooks, err := hm.loadHookConfigs(hooksRelativePaths)
+	if err != nil {
+		return err
+	}
+
+	hooks, err = hm.filterHooksByProfile(hooks)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		if hook.Config.Disabled {
+			log.WithField("hook", hook.Name).Infof("Hook is disabled in config, skip it")
+			continue
+		}
+
+		hook, err := hm.initHook(hook)
 		if err != nil {
 			return err
 		}
@@ -111,6 +233,10 @@ func (hm *Manager) Init() error {
 		hm.hookNamesInOrder = append(hm.hookNamesInOrder, hook.Name)
 	}
 
+	if hm.configCache != nil {
+		hm.configCache.Prune()
+	}
+
 	// Validate conversion chains and create index with conversion paths.
 	err = hm.UpdateConversionChains()
 	if err != nil {
@@ -120,27 +246,95 @@ func (hm *Manager) Init() error {
 	return nil
 }
 
+// loadHookConfigs executes hooks with --config concurrently and returns hooks in the order of paths.
+func (hm *Manager) loadHookConfigs(hookPaths []string) ([]*Hook, error) {
+	workers := hm.configConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	hooks := make([]*Hook, len(hookPaths))
+	errs := make([]error, len(hookPaths))
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				hooks[i], errs[i] = hm.loadHookConfig(hookPaths[i])
+			}
+		}()
+	}
+	for i := range hookPaths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	// Report the error of the first failed hook to be consistent between restarts.
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hooks, nil
+}
+
 // TODO move --config execution to a Hook method
-func (hm *Manager) loadHook(hookPath string) (hook *Hook, err error) {
+func (hm *Manager) loadHookConfig(hookPath string) (hook *Hook, err error) {
 	hookName, err := filepath.Rel(hm.workingDir, hookPath)
 	if err != nil {
 		return nil, err
 	}
 	hook = NewHook(hookName, hookPath)
 
+	// Refuse unknown or modified hooks before execution.
+	if err := hm.checksums.Verify(hookPath); err != nil {
+		return nil, fmt.Errorf("refuse hook '%s': %v", hookName, err)
+	}
+	hook.WithChecksums(hm.checksums)
+
 	hookEntry := log.WithField("hook", hook.Name).
 		WithField("phase", "config")
 
-	hookEntry.Infof("Load config from '%s'", hookPath)
+	isCommandHook := utils_file.IsCommandHookFile(hookPath)
 
-	envs := make([]string, 0)
-	configOutput, err := hm.execCommandOutput(hook.Name, hm.workingDir, hookPath, envs, []string{"--config"})
-	if err != nil {
-		hookEntry.Errorf("Hook config output:\n%s", string(configOutput))
-		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
-			hookEntry.Errorf("Hook config stderr:\n%s", string(ee.Stderr))
+	var cacheKey string
+	var configOutput []byte
+	var cached bool
+	if hm.configCache != nil && !isCommandHook {
+		cacheKey, err = hm.configCache.Key(hook.Name, hookPath)
+		if err != nil {
+			hookEntry.Warnf("Hook config cache is not used: %v", err)
+		} else {
+			configOutput, cached = hm.configCache.Get(cacheKey)
+		}
+	}
+
+	switch {
+	case isCommandHook:
+		hookEntry.Infof("Load command hook config from '%s'", hookPath)
+
+		configOutput, err = os.ReadFile(hookPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config for hook '%s': %s", hookPath, err)
+		}
+	case cached:
+		hookEntry.Infof("Load config for '%s' from cache", hookPath)
+	default:
+		hookEntry.Infof("Load config from '%s'", hookPath)
+
+		envs := make([]string, 0)
+		configOutput, err = hm.execCommandOutput(hook.Name, hm.workingDir, hookPath, envs, []string{"--config"}, hm.configTimeout)
+		if err != nil {
+			hookEntry.Errorf("Hook config output:\n%s", string(configOutput))
+			if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+				hookEntry.Errorf("Hook config stderr:\n%s", string(ee.Stderr))
+			}
+			return nil, fmt.Errorf("cannot get config for hook '%s': %s", hookPath, err)
 		}
-		return nil, fmt.Errorf("cannot get config for hook '%s': %s", hookPath, err)
 	}
 
 	_, err = hook.LoadConfig(configOutput)
@@ -148,6 +342,22 @@ func (hm *Manager) loadHook(hookPath string) (hook *Hook, err error) {
 		return nil, fmt.Errorf("creating hook '%s': %s", hookName, err.Error())
 	}
 
+	if isCommandHook && !hook.IsCommandHook() {
+		return nil, fmt.Errorf("creating hook '%s': command is required for command hooks", hookName)
+	}
+	if !isCommandHook && hook.IsCommandHook() {
+		return nil, fmt.Errorf("creating hook '%s': command is allowed only in files with %s suffixes", hookName, strings.Join(utils_file.CommandHookSuffixes, ", "))
+	}
+	if isCommandHook && hook.HasCheck() {
+		return nil, fmt.Errorf("creating hook '%s': check is not supported for command hooks", hookName)
+	}
+
+	if !cached && cacheKey != "" {
+		if err := hm.configCache.Put(cacheKey, configOutput); err != nil {
+			hookEntry.Warnf("Save hook config into cache: %v", err)
+		}
+	}
+
 	// Add hook info as log labels, update MetricLabels
 	for _, kubeCfg := range hook.GetConfig().OnKubernetesEvents {
 		kubeCfg.Monitor.Metadata.LogLabels["hook"] = hook.Name
@@ -182,16 +392,44 @@ func (hm *Manager) loadHook(hookPath string) (hook *Hook, err error) {
 		mutatingCfg.Webhook.UpdateIds("", mutatingCfg.BindingName)
 	}
 
+	return hook, nil
+}
+
+// initHook creates controllers for hook bindings. Controllers register
+// bindings in managers, so hooks should be initialized sequentially.
+func (hm *Manager) initHook(hook *Hook) (*Hook, error) {
+	hookEntry := log.WithField("hook", hook.Name).
+		WithField("phase", "config")
+
 	hookCtrl := controller.NewHookController()
 	hookCtrl.InitKubernetesBindings(hook.GetConfig().OnKubernetesEvents, hm.kubeEventsManager)
 	hookCtrl.InitScheduleBindings(hook.GetConfig().Schedules, hm.scheduleManager)
 	hookCtrl.InitConversionBindings(hook.GetConfig().KubernetesConversion, hm.conversionWebhookManager)
 	hookCtrl.InitAdmissionBindings(hook.GetConfig().KubernetesValidating, hook.GetConfig().KubernetesMutating, hm.admissionWebhookManager)
+	hookCtrl.InitCompositeBindings(hook.GetConfig().Composite)
+	hookCtrl.InitTriggerBindings(hook.GetConfig().Triggers(), hm.triggerManager)
 	// TODO
 	// hookCtrl.InitMutatingBindings(hook.GetConfig().KubernetesMutating, hm.admissionWebhookManager)
 
 	hook.WithHookController(hookCtrl)
 	hook.WithTmpDir(hm.TempDir())
+	hook.WithKubeconfig(hm.kubeconfigPath)
+	hook.WithCluster(hm.cluster)
+	hook.WithRecorder(hm.recorder)
+
+	if err := hook.LoadValues(); err != nil {
+		return nil, fmt.Errorf("hook '%s': %v", hook.Name, err)
+	}
+
+	// Hook with impersonation gets its own kubeconfig.
+	if hm.kubeconfigPath != "" && hook.Config.Settings != nil && hook.Config.Settings.Impersonate != nil {
+		path := filepath.Join(hm.tempDir, KubeconfigFileName+"-"+hook.SafeName())
+		path, err := GenerateKubeconfig(path, hm.namespace, hook.Config.Settings.Impersonate)
+		if err != nil {
+			return nil, fmt.Errorf("hook '%s': %v", hook.Name, err)
+		}
+		hook.WithKubeconfig(path)
+	}
 
 	if hook.Config == nil {
 		return nil, fmt.Errorf("hook %q is marked as executable but doesn't contain config section", hook.Path)
@@ -202,7 +440,14 @@ func (hm *Manager) loadHook(hookPath string) (hook *Hook, err error) {
 	return hook, nil
 }
 
-func (hm *Manager) execCommandOutput(hookName string, dir string, entrypoint string, envs []string, args []string) ([]byte, error) {
+// Stop releases resources held by hooks, e.g. warm process pools.
+func (hm *Manager) Stop() {
+	for _, hookName := range hm.hookNamesInOrder {
+		hm.hooksByName[hookName].StopPool()
+	}
+}
+
+func (hm *Manager) execCommandOutput(hookName string, dir string, entrypoint string, envs []string, args []string, timeout time.Duration) ([]byte, error) {
 	envs = append(os.Environ(), envs...)
 	cmd := executor.MakeCommand(dir, entrypoint, args, envs)
 	cmd.Stdout = nil
@@ -213,7 +458,7 @@ func (hm *Manager) execCommandOutput(hookName string, dir string, entrypoint str
 
 	debugEntry.Debugf("Executing hook in %s", cmd.Dir)
 
-	output, err := executor.Output(cmd)
+	output, err := executor.OutputWithTimeout(cmd, timeout)
 	if err != nil {
 		return output, err
 	}
@@ -232,6 +477,16 @@ func (hm *Manager) GetHook(name string) *Hook {
 	return nil
 }
 
+// SetHookPaused pauses or resumes runs of the loaded hook.
+func (hm *Manager) SetHookPaused(name string, paused bool) error {
+	hook, exists := hm.hooksByName[name]
+	if !exists {
+		return fmt.Errorf("hook '%s' not found", name)
+	}
+	hook.SetPaused(paused)
+	return nil
+}
+
 func (hm *Manager) GetHookNames() []string {
 	return hm.hookNamesInOrder
 }
@@ -294,6 +549,20 @@ func (hm *Manager) HandleScheduleEvent(crontab string, createTaskFn func(*Hook,
 	}
 }
 
+// HandleTriggerEvent finds a hook with the binding for the source of the event.
+func (hm *Manager) HandleTriggerEvent(event trigger_manager.Event, createTaskFn func(*Hook, controller.BindingExecutionInfo)) {
+	for _, hookName := range hm.hookNamesInOrder {
+		h := hm.GetHook(hookName)
+		if h.HookController.CanHandleTriggerEvent(event) {
+			h.HookController.HandleTriggerEvent(event, func(info controller.BindingExecutionInfo) {
+				if createTaskFn != nil {
+					createTaskFn(h, info)
+				}
+			})
+		}
+	}
+}
+
 func (hm *Manager) HandleAdmissionEvent(event admission.Event, createTaskFn func(*Hook, controller.BindingExecutionInfo)) {
 	vHooks, _ := hm.GetHooksInOrder(KubernetesValidating)
 	for _, hookName := range vHooks {
@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/flant/shell-operator/pkg/app"
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
 	"github.com/flant/shell-operator/pkg/hook/controller"
 	"github.com/flant/shell-operator/pkg/hook/types"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
@@ -80,6 +81,42 @@ func Test_HookManager_GetHookNames(t *testing.T) {
 	}
 }
 
+func Test_HookManager_EnableDisableHooks(t *testing.T) {
+	g := NewWithT(t)
+
+	hm := newHookManager(t, "testdata/hook_manager")
+	hm.enableHooks = splitPatterns([]string{"podHooks/*,hook.sh"})
+	hm.disableHooks = splitPatterns([]string{"podHooks/hook2.sh"})
+
+	err := hm.Init()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hm.GetHookNames()).To(Equal([]string{"hook.sh", "podHooks/hook.sh"}))
+
+	g.Expect(hm.SetHookPaused("hook.sh", true)).To(Succeed())
+	g.Expect(hm.GetHook("hook.sh").IsPaused()).To(BeTrue())
+	g.Expect(hm.SetHookPaused("hook.sh", false)).To(Succeed())
+	g.Expect(hm.GetHook("hook.sh").IsPaused()).To(BeFalse())
+
+	g.Expect(hm.SetHookPaused("podHooks/hook2.sh", true)).ShouldNot(Succeed())
+}
+
+func Test_HookNameEnabled(t *testing.T) {
+	g := NewWithT(t)
+
+	enabled, err := hookNameEnabled("002-monitoring/pods.sh", nil, nil)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(enabled).To(BeTrue())
+
+	enabled, _ = hookNameEnabled("002-monitoring/pods.sh", []string{"001-*/*"}, nil)
+	g.Expect(enabled).To(BeFalse())
+
+	enabled, _ = hookNameEnabled("002-monitoring/pods.sh", []string{"002-*/*"}, []string{"*/pods.sh"})
+	g.Expect(enabled).To(BeFalse())
+
+	_, err = hookNameEnabled("hook.sh", []string{"[a-"}, nil)
+	g.Expect(err).Should(HaveOccurred())
+}
+
 func TestHookController_HandleValidatingEvent(t *testing.T) {
 	g := NewWithT(t)
 
@@ -382,3 +419,23 @@ func Test_HookManager_onstartup_order(t *testing.T) {
 		g.Expect(hookName).To(Equal(expectNames[i]))
 	}
 }
+
+func Test_HookManager_command_hooks(t *testing.T) {
+	g := NewWithT(t)
+
+	hm := newHookManager(t, "testdata/hook_manager_command_hooks")
+
+	err := hm.Init()
+	g.Expect(err).ShouldNot(HaveOccurred(), "Hook manager Init should not fail: %v", err)
+	g.Expect(hm.GetHookNames()).To(Equal([]string{"set-metric.hook.yaml"}))
+
+	h := hm.GetHook("set-metric.hook.yaml")
+	g.Expect(h.IsCommandHook()).To(BeTrue())
+
+	bc := BindingContext{Binding: string(types.OnStartup)}
+	bc.Metadata.BindingType = types.OnStartup
+	res, err := h.Run(types.OnStartup, []BindingContext{bc}, map[string]string{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(res.Metrics).To(HaveLen(1))
+	g.Expect(res.Metrics[0].Name).To(Equal("command_hook"))
+}
@@ -1,6 +1,8 @@
 package hook
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -8,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 	"golang.org/x/time/rate"
 
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
 	"github.com/flant/shell-operator/pkg/hook/config"
 	. "github.com/flant/shell-operator/pkg/hook/types"
 )
@@ -141,3 +144,80 @@ func Test_Hook_WithConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_Hook_WorkingDirAndEnviron(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv("HOOK_TEST_ALLOWED", "yes")
+	t.Setenv("HOOK_TEST_SECRET", "no")
+
+	h := NewHook("002-cool-hooks/hook.sh", "/hooks/002-cool-hooks/hook.sh")
+	g.Expect(h.workingDir()).To(Equal("/hooks/002-cool-hooks"))
+	g.Expect(h.environ()).To(ContainElement("HOOK_TEST_SECRET=no"))
+
+	h.Config.Settings = &Settings{
+		WorkingDir:         "data",
+		AllowedEnvPrefixes: []string{"HOOK_TEST_ALLOWED"},
+	}
+	g.Expect(h.workingDir()).To(Equal("/hooks/002-cool-hooks/data"))
+	g.Expect(h.environ()).To(ContainElement("HOOK_TEST_ALLOWED=yes"))
+	g.Expect(h.environ()).NotTo(ContainElement("HOOK_TEST_SECRET=no"))
+
+	h.Config.Settings.WorkingDir = "/mnt/secrets"
+	g.Expect(h.workingDir()).To(Equal("/mnt/secrets"))
+}
+
+func Test_Hook_OutputParseErrorPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	runs := 0
+	badRun := func() (*Result, error) {
+		runs++
+		result := &Result{}
+		return result, h.handleOutputParseError(result, OutputMetrics, fmt.Errorf("invalid character"), map[string]string{})
+	}
+
+	// Fail is the default: no retries.
+	res, err := h.runWithOutputRetries(badRun, map[string]string{})
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(runs).To(Equal(1))
+	g.Expect(res.OutputParseErrors).To(Equal([]string{OutputMetrics}))
+
+	runs = 0
+	h.Config.Settings = &Settings{OutputParseErrorPolicy: OutputParseErrorRetry}
+	res, err = h.runWithOutputRetries(badRun, map[string]string{})
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(runs).To(Equal(1 + OutputParseRetries))
+	g.Expect(res.OutputParseErrors).To(HaveLen(1 + OutputParseRetries))
+
+	runs = 0
+	h.Config.Settings.OutputParseErrorPolicy = OutputParseErrorIgnore
+	res, err = h.runWithOutputRetries(badRun, map[string]string{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(runs).To(Equal(1))
+	g.Expect(res.OutputParseErrors).To(Equal([]string{OutputMetrics}))
+}
+
+func Test_Hook_PrepareBindingContextJsonLinesFile(t *testing.T) {
+	g := NewWithT(t)
+
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	h.TmpDir = t.TempDir()
+	g.Expect(h.bindingContextFormat()).To(Equal(BindingContextJSON))
+
+	h.Config.Settings = &Settings{BindingContextFormat: BindingContextJSONLines}
+	g.Expect(h.bindingContextFormat()).To(Equal(BindingContextJSONLines))
+
+	bcList := BindingContextList{
+		{"binding": "first"},
+		{"binding": "second"},
+	}
+	path, err := h.prepareBindingContextJsonFile(bcList)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(path).To(HaveSuffix(".jsonl"))
+
+	data, err := os.ReadFile(path)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal("{\"binding\":\"first\"}\n{\"binding\":\"second\"}\n"))
+}
@@ -0,0 +1,134 @@
+package hook
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+
+	. "github.com/flant/shell-operator/pkg/hook/types"
+)
+
+const (
+	KubeconfigFileName = "kubeconfig"
+
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// kubeconfig is a minimal kubectl-compatible configuration.
+type kubeconfig struct {
+	APIVersion     string              `json:"apiVersion"`
+	Kind           string              `json:"kind"`
+	Clusters       []kubeconfigCluster `json:"clusters"`
+	Users          []kubeconfigUser    `json:"users"`
+	Contexts       []kubeconfigContext `json:"contexts"`
+	CurrentContext string              `json:"current-context"`
+}
+
+type kubeconfigCluster struct {
+	Name    string `json:"name"`
+	Cluster struct {
+		Server               string `json:"server"`
+		CertificateAuthority string `json:"certificate-authority"`
+	} `json:"cluster"`
+}
+
+type kubeconfigUser struct {
+	Name string `json:"name"`
+	User struct {
+		TokenFile string   `json:"tokenFile"`
+		As        string   `json:"as,omitempty"`
+		AsGroups  []string `json:"as-groups,omitempty"`
+	} `json:"user"`
+}
+
+type kubeconfigContext struct {
+	Name    string `json:"name"`
+	Context struct {
+		Cluster   string `json:"cluster"`
+		User      string `json:"user"`
+		Namespace string `json:"namespace,omitempty"`
+	} `json:"context"`
+}
+
+// GenerateKubeconfig writes a kubeconfig with the operator's service account token and CA
+// into the file. The token is referenced by path, so kubectl reads a rotated token.
+// Requests are made as the impersonated user if impersonate is not nil.
+// It returns an empty path if the operator is not running in a cluster.
+func GenerateKubeconfig(path string, namespace string, impersonate *Impersonation) (string, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	tokenFile := filepath.Join(serviceAccountDir, "token")
+	if host == "" || port == "" {
+		return "", nil
+	}
+	if _, err := os.Stat(tokenFile); err != nil {
+		return "", nil
+	}
+
+	const name = "shell-operator"
+	cluster := kubeconfigCluster{Name: name}
+	cluster.Cluster.Server = "https://" + net.JoinHostPort(host, port)
+	cluster.Cluster.CertificateAuthority = filepath.Join(serviceAccountDir, "ca.crt")
+	user := kubeconfigUser{Name: name}
+	user.User.TokenFile = tokenFile
+	if impersonate != nil {
+		user.User.As = impersonate.User
+		user.User.AsGroups = impersonate.Groups
+	}
+	context := kubeconfigContext{Name: name}
+	context.Context.Cluster = name
+	context.Context.User = name
+	context.Context.Namespace = namespace
+
+	data, err := yaml.Marshal(kubeconfig{
+		APIVersion:     "v1",
+		Kind:           "Config",
+		Clusters:       []kubeconfigCluster{cluster},
+		Users:          []kubeconfigUser{user},
+		Contexts:       []kubeconfigContext{context},
+		CurrentContext: name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal kubeconfig: %v", err)
+	}
+
+	// The file has no secrets, it should be readable for hooks with runAsUser.
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write kubeconfig: %v", err)
+	}
+	return path, nil
+}
+
+// NoopKubeconfigServer is an address that never resolves, see RFC 6761.
+const NoopKubeconfigServer = "https://shadow.invalid"
+
+// GenerateNoopKubeconfig writes a kubeconfig without credentials and with an unreachable
+// server into the file. It is used for hooks that should not access the cluster.
+func GenerateNoopKubeconfig(path string) (string, error) {
+	const name = "noop"
+	cluster := kubeconfigCluster{Name: name}
+	cluster.Cluster.Server = NoopKubeconfigServer
+	user := kubeconfigUser{Name: name}
+	context := kubeconfigContext{Name: name}
+	context.Context.Cluster = name
+	context.Context.User = name
+
+	data, err := yaml.Marshal(kubeconfig{
+		APIVersion:     "v1",
+		Kind:           "Config",
+		Clusters:       []kubeconfigCluster{cluster},
+		Users:          []kubeconfigUser{user},
+		Contexts:       []kubeconfigContext{context},
+		CurrentContext: name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal kubeconfig: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write kubeconfig: %v", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,18 @@
+package hook
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GenerateKubeconfig_OutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	path, err := GenerateKubeconfig(filepath.Join(t.TempDir(), KubeconfigFileName), "default", nil)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+}
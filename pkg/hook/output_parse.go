@@ -0,0 +1,80 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/flant/shell-operator/pkg/hook/types"
+	utils "github.com/flant/shell-operator/pkg/utils/labels"
+)
+
+// Names of hook outputs that are parsed after a successful run.
+const (
+	OutputMetrics         = "metrics"
+	OutputAdmission       = "admission"
+	OutputConversion      = "conversion"
+	OutputKubernetesPatch = "kubernetesPatch"
+)
+
+// OutputParseRetries is a number of additional hook runs for the Retry policy.
+var OutputParseRetries = 3
+
+// OutputParseError is returned if the hook exits successfully, but one of its outputs is malformed.
+type OutputParseError struct {
+	Output string
+	Err    error
+}
+
+func (e *OutputParseError) Error() string {
+	return fmt.Sprintf("got bad %s output: %v", e.Output, e.Err)
+}
+
+func (e *OutputParseError) Unwrap() error {
+	return e.Err
+}
+
+func (h *Hook) outputParseErrorPolicy() OutputParseErrorPolicy {
+	if h.Config == nil || h.Config.Settings == nil || h.Config.Settings.OutputParseErrorPolicy == "" {
+		return OutputParseErrorFail
+	}
+	return h.Config.Settings.OutputParseErrorPolicy
+}
+
+// handleOutputParseError records the malformed output in the result. It returns nil
+// if the output should be ignored according to the hook settings.
+func (h *Hook) handleOutputParseError(result *Result, output string, err error, logLabels map[string]string) error {
+	result.OutputParseErrors = append(result.OutputParseErrors, output)
+	parseErr := &OutputParseError{Output: output, Err: err}
+	if h.outputParseErrorPolicy() == OutputParseErrorIgnore {
+		log.WithFields(utils.LabelsToLogFields(logLabels)).
+			Warnf("Ignore %v", parseErr)
+		return nil
+	}
+	return parseErr
+}
+
+// runWithOutputRetries runs the hook again if its outputs are malformed and the Retry policy is set.
+func (h *Hook) runWithOutputRetries(run func() (*Result, error), logLabels map[string]string) (*Result, error) {
+	attempts := 1
+	if h.outputParseErrorPolicy() == OutputParseErrorRetry {
+		attempts += OutputParseRetries
+	}
+
+	var parseErrors []string
+	for attempt := 1; ; attempt++ {
+		result, err := run()
+		if result != nil {
+			result.OutputParseErrors = append(parseErrors, result.OutputParseErrors...)
+			parseErrors = result.OutputParseErrors
+		}
+
+		var parseErr *OutputParseError
+		if !errors.As(err, &parseErr) || attempt >= attempts {
+			return result, err
+		}
+		log.WithFields(utils.LabelsToLogFields(logLabels)).
+			Warnf("Run hook again, attempt %d of %d: %v", attempt+1, attempts, err)
+	}
+}
@@ -0,0 +1,48 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+)
+
+// SetPatchResults saves results of Create operations from $KUBERNETES_PATCH_PATH.
+// They are passed to the next run of the hook in $KUBERNETES_PATCH_RESULTS_PATH.
+func (h *Hook) SetPatchResults(results []object_patch.OperationResult) error {
+	if results == nil {
+		results = []object_patch.OperationResult{}
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("marshal kubernetes patch results: %v", err)
+	}
+	h.lastRunLock.Lock()
+	defer h.lastRunLock.Unlock()
+	h.patchResults = data
+	return nil
+}
+
+// preparePatchResultsFile writes results of operations from the previous run.
+// The file contains an empty JSON array before the first run with operations.
+func (h *Hook) preparePatchResultsFile() (string, error) {
+	h.lastRunLock.Lock()
+	data := h.patchResults
+	h.lastRunLock.Unlock()
+	if data == nil {
+		data = []byte("[]")
+	}
+
+	patchResultsPath := filepath.Join(h.TmpDir, fmt.Sprintf("%s-object-patch-results-%s.json", h.SafeName(), uuid.Must(uuid.NewV4()).String()))
+
+	err := os.WriteFile(patchResultsPath, data, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	return patchResultsPath, nil
+}
@@ -0,0 +1,40 @@
+package hook
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+)
+
+func Test_Hook_PatchResults(t *testing.T) {
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	h.WithTmpDir(t.TempDir())
+
+	// No results before the first run with operations.
+	path, err := h.preparePatchResultsFile()
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+
+	err = h.SetPatchResults([]object_patch.OperationResult{{
+		Operation:    object_patch.Create,
+		ApiVersion:   "batch/v1",
+		Kind:         "Job",
+		Namespace:    "default",
+		Name:         "job-x7k2p",
+		GenerateName: "job-",
+		Created:      true,
+	}})
+	require.NoError(t, err)
+
+	path, err = h.preparePatchResultsFile()
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"operation":"Create","apiVersion":"batch/v1","kind":"Job","namespace":"default","name":"job-x7k2p","generateName":"job-","created":true}]`, string(data))
+}
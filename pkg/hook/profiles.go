@@ -0,0 +1,119 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// ProfilesFileName is a file in the hooks directory with hook profiles.
+const ProfilesFileName = "profiles.yaml"
+
+// ProfilesFile declares profiles in the hooks directory, e.g.:
+//
+//	profiles:
+//	  minimal:
+//	    hooks:
+//	    - 001-core/*
+type ProfilesFile struct {
+	Profiles map[string]ProfileSpec `json:"profiles"`
+}
+
+// ProfileSpec is a list of glob patterns for hook names.
+type ProfileSpec struct {
+	Hooks []string `json:"hooks"`
+}
+
+// loadProfilesFile returns profiles from the hooks directory or nil if there is no file.
+func loadProfilesFile(dir string) (*ProfilesFile, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProfilesFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	res := &ProfilesFile{}
+	if err := yaml.UnmarshalStrict(data, res); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", ProfilesFileName, err)
+	}
+	return res, nil
+}
+
+// hookProfiles returns all profiles of the hook: from its config and from the profiles file.
+func hookProfiles(hook *Hook, file *ProfilesFile) ([]string, error) {
+	profiles := make(map[string]struct{})
+	for _, name := range hook.Config.Profiles {
+		profiles[name] = struct{}{}
+	}
+	if file != nil {
+		for name, spec := range file.Profiles {
+			matched, err := matchAny(hook.Name, spec.Hooks)
+			if err != nil {
+				return nil, fmt.Errorf("profile '%s': %v", name, err)
+			}
+			if matched {
+				profiles[name] = struct{}{}
+			}
+		}
+	}
+	res := make([]string, 0, len(profiles))
+	for name := range profiles {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// filterHooksByProfile returns hooks of the profile and hooks without profiles.
+// It is an error if no hook and no profiles file declares the profile.
+func (hm *Manager) filterHooksByProfile(hooks []*Hook) ([]*Hook, error) {
+	if hm.profile == "" {
+		return hooks, nil
+	}
+
+	file, err := loadProfilesFile(hm.workingDir)
+	if err != nil {
+		return nil, err
+	}
+	declared := false
+	if file != nil {
+		_, declared = file.Profiles[hm.profile]
+	}
+
+	res := make([]*Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		profiles, err := hookProfiles(hook, file)
+		if err != nil {
+			return nil, err
+		}
+		if len(profiles) == 0 {
+			res = append(res, hook)
+			continue
+		}
+		if hasString(profiles, hm.profile) {
+			declared = true
+			res = append(res, hook)
+			continue
+		}
+		log.WithField("hook", hook.Name).Infof("Hook is not in the profile '%s', skip it. Hook profiles: %v", hm.profile, profiles)
+	}
+
+	if !declared {
+		return nil, fmt.Errorf("profile '%s' is not declared in hooks or in %s", hm.profile, ProfilesFileName)
+	}
+	return res, nil
+}
+
+func hasString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func newProfileTestHooks() []*Hook {
+	core := NewHook("001-core/hook.sh", "/hooks/001-core/hook.sh")
+	monitoring := NewHook("002-monitoring/hook.sh", "/hooks/002-monitoring/hook.sh")
+	monitoring.Config.Profiles = []string{"full"}
+	backup := NewHook("003-backup/hook.sh", "/hooks/003-backup/hook.sh")
+	return []*Hook{core, monitoring, backup}
+}
+
+func hookNames(hooks []*Hook) []string {
+	names := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func Test_FilterHooksByProfile(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, ProfilesFileName), []byte(`
+profiles:
+  minimal:
+    hooks:
+    - 001-core/*
+  full:
+    hooks:
+    - 001-*/*
+    - 003-backup/*
+`), 0o644)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	hm := &Manager{workingDir: dir}
+
+	// All hooks are loaded without profile.
+	hooks, err := hm.filterHooksByProfile(newProfileTestHooks())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hooks).To(HaveLen(3))
+
+	hm.profile = "minimal"
+	hooks, err = hm.filterHooksByProfile(newProfileTestHooks())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hookNames(hooks)).To(Equal([]string{"001-core/hook.sh"}))
+
+	hm.profile = "full"
+	hooks, err = hm.filterHooksByProfile(newProfileTestHooks())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hooks).To(HaveLen(3))
+
+	hm.profile = "unknown"
+	_, err = hm.filterHooksByProfile(newProfileTestHooks())
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_FilterHooksByProfile_WithoutFile(t *testing.T) {
+	g := NewWithT(t)
+
+	hm := &Manager{workingDir: t.TempDir(), profile: "full"}
+
+	hooks, err := hm.filterHooksByProfile(newProfileTestHooks())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	// Hooks without profiles are loaded in every profile.
+	g.Expect(hookNames(hooks)).To(Equal([]string{"001-core/hook.sh", "002-monitoring/hook.sh", "003-backup/hook.sh"}))
+}
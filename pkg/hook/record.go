@@ -0,0 +1,39 @@
+package hook
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/golden"
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+	. "github.com/flant/shell-operator/pkg/hook/types"
+)
+
+// recordRun saves inputs and outputs of the run with the recorder.
+// Errors are logged, so the run is not affected by the recording.
+func (h *Hook) recordRun(bindingType BindingType, context []BindingContext, result *Result, files map[string]string) {
+	binding := ""
+	if len(context) > 0 {
+		binding = context[0].Binding
+	}
+	if h.ValuesPath != "" {
+		files[golden.ValuesFileName] = h.ValuesPath
+	}
+
+	run := golden.Run{
+		Meta: golden.Meta{
+			Hook:                 h.Name,
+			Binding:              binding,
+			Type:                 string(bindingType),
+			Time:                 time.Now(),
+			BindingContextFormat: string(h.bindingContextFormat()),
+			Command:              h.Config.Command,
+			ExitCode:             result.ExitCode,
+		},
+		Files: files,
+	}
+	if err := h.recorder.Record(run); err != nil {
+		log.WithField("hook", h.Name).Warnf("Record hook run into '%s': %v", h.recorder.Dir(), err)
+	}
+}
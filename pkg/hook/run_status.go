@@ -0,0 +1,99 @@
+package hook
+
+import (
+	"time"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/hook/types"
+)
+
+// StderrTailSize is a number of last stderr bytes saved in the run history.
+const StderrTailSize = 2048
+
+// RunStatus is a result of the hook run.
+type RunStatus struct {
+	Binding     string            `json:"binding"`
+	BindingType types.BindingType `json:"bindingType"`
+	StartedAt   time.Time         `json:"startedAt"`
+	Duration    string            `json:"duration"`
+	Error       string            `json:"error,omitempty"`
+	// ExitCode is an exit code of the hook process. It is zero for warm pool runs.
+	ExitCode int `json:"exitCode"`
+	// StderrTail is the last bytes of the hook stderr.
+	StderrTail string `json:"stderrTail,omitempty"`
+	// PatchOperations is a number of operations from $KUBERNETES_PATCH_PATH.
+	PatchOperations int `json:"patchOperations"`
+}
+
+// runHistory is a ring buffer with last runs of the hook.
+type runHistory struct {
+	runs []RunStatus
+	// next is an index to write the next run.
+	next int
+	full bool
+}
+
+func newRunHistory(size int) *runHistory {
+	if size < 1 {
+		size = 1
+	}
+	return &runHistory{runs: make([]RunStatus, size)}
+}
+
+func (r *runHistory) add(status RunStatus) {
+	r.runs[r.next] = status
+	r.next = (r.next + 1) % len(r.runs)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// last returns the latest run or nil if there are no runs.
+func (r *runHistory) last() *RunStatus {
+	if r.next == 0 && !r.full {
+		return nil
+	}
+	idx := (r.next - 1 + len(r.runs)) % len(r.runs)
+	status := r.runs[idx]
+	return &status
+}
+
+// list returns runs from the oldest to the latest.
+func (r *runHistory) list() []RunStatus {
+	if !r.full {
+		return append([]RunStatus{}, r.runs[:r.next]...)
+	}
+	res := make([]RunStatus, 0, len(r.runs))
+	res = append(res, r.runs[r.next:]...)
+	return append(res, r.runs[:r.next]...)
+}
+
+// SetLastRun saves the result of the hook run into the history.
+func (h *Hook) SetLastRun(status RunStatus) {
+	h.lastRunLock.Lock()
+	defer h.lastRunLock.Unlock()
+	if h.runs == nil {
+		h.runs = newRunHistory(app.HookRunHistorySize)
+	}
+	h.runs.add(status)
+}
+
+// LastRun returns the result of the last hook run or nil if hook was not executed yet.
+func (h *Hook) LastRun() *RunStatus {
+	h.lastRunLock.Lock()
+	defer h.lastRunLock.Unlock()
+	if h.runs == nil {
+		return nil
+	}
+	return h.runs.last()
+}
+
+// Runs returns results of last hook runs from the oldest to the latest.
+func (h *Hook) Runs() []RunStatus {
+	h.lastRunLock.Lock()
+	defer h.lastRunLock.Unlock()
+	if h.runs == nil {
+		return []RunStatus{}
+	}
+	return h.runs.list()
+}
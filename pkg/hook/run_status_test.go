@@ -0,0 +1,51 @@
+package hook
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_RunHistory(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newRunHistory(3)
+	g.Expect(r.last()).To(BeNil())
+	g.Expect(r.list()).To(BeEmpty())
+
+	r.add(RunStatus{Binding: "1"})
+	r.add(RunStatus{Binding: "2"})
+	g.Expect(r.last().Binding).To(Equal("2"))
+	g.Expect(bindings(r.list())).To(Equal([]string{"1", "2"}))
+
+	r.add(RunStatus{Binding: "3"})
+	r.add(RunStatus{Binding: "4"})
+	r.add(RunStatus{Binding: "5"})
+	g.Expect(r.last().Binding).To(Equal("5"))
+	g.Expect(bindings(r.list())).To(Equal([]string{"3", "4", "5"}))
+}
+
+func Test_Hook_Runs(t *testing.T) {
+	g := NewWithT(t)
+
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	g.Expect(h.LastRun()).To(BeNil())
+	g.Expect(h.Runs()).To(BeEmpty())
+
+	h.SetLastRun(RunStatus{Binding: "first", ExitCode: 1, StderrTail: "error"})
+	h.SetLastRun(RunStatus{Binding: "second", PatchOperations: 2})
+
+	g.Expect(h.LastRun().Binding).To(Equal("second"))
+	runs := h.Runs()
+	g.Expect(bindings(runs)).To(Equal([]string{"first", "second"}))
+	g.Expect(runs[0].ExitCode).To(Equal(1))
+	g.Expect(runs[1].PatchOperations).To(Equal(2))
+}
+
+func bindings(runs []RunStatus) []string {
+	res := make([]string, 0, len(runs))
+	for _, run := range runs {
+		res = append(res, run.Binding)
+	}
+	return res
+}
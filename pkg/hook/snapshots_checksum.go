@@ -0,0 +1,55 @@
+package hook
+
+import (
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+	utils_checksum "github.com/flant/shell-operator/pkg/utils/checksum"
+)
+
+// SkipIfSnapshotsUnchanged returns true if the schedule binding should not be
+// executed when snapshots are not changed since the last successful run.
+func (h *Hook) SkipIfSnapshotsUnchanged(bindingName string) bool {
+	for _, sch := range h.GetConfig().Schedules {
+		if sch.BindingName == bindingName {
+			return sch.SkipIfSnapshotsUnchanged
+		}
+	}
+	return false
+}
+
+// SnapshotsUnchanged calculates a checksum of fresh snapshots for binding contexts and
+// compares it with the checksum saved after the last successful run of the binding.
+func (h *Hook) SnapshotsUnchanged(bindingName string, bcs []BindingContext) (checksum string, unchanged bool) {
+	if h.HookController == nil {
+		return "", false
+	}
+	checksum = SnapshotsChecksum(h.HookController.UpdateSnapshots(bcs))
+
+	h.lastRunLock.Lock()
+	defer h.lastRunLock.Unlock()
+	last, has := h.snapshotsChecksums[bindingName]
+	return checksum, has && last == checksum
+}
+
+// SetSnapshotsChecksum saves the checksum of snapshots after the successful run of the binding.
+func (h *Hook) SetSnapshotsChecksum(bindingName string, checksum string) {
+	h.lastRunLock.Lock()
+	defer h.lastRunLock.Unlock()
+	if h.snapshotsChecksums == nil {
+		h.snapshotsChecksums = make(map[string]string)
+	}
+	h.snapshotsChecksums[bindingName] = checksum
+}
+
+// SnapshotsChecksum returns a checksum over checksums of all objects in snapshots.
+// It does not depend on the order of objects.
+func SnapshotsChecksum(bcs []BindingContext) string {
+	parts := make([]string, 0)
+	for _, bc := range bcs {
+		for snapshotName, objects := range bc.Snapshots {
+			for _, obj := range objects {
+				parts = append(parts, snapshotName+"/"+obj.Metadata.ResourceId+"/"+obj.Metadata.Checksum+"\n")
+			}
+		}
+	}
+	return utils_checksum.CalculateChecksum(parts...)
+}
@@ -0,0 +1,63 @@
+package hook
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	. "github.com/flant/shell-operator/pkg/hook/binding_context"
+	"github.com/flant/shell-operator/pkg/hook/controller"
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func snapshotObj(id, checksum string) ObjectAndFilterResult {
+	obj := ObjectAndFilterResult{}
+	obj.Metadata.ResourceId = id
+	obj.Metadata.Checksum = checksum
+	return obj
+}
+
+func Test_SnapshotsChecksum(t *testing.T) {
+	g := NewWithT(t)
+
+	bc := func(objs ...ObjectAndFilterResult) []BindingContext {
+		return []BindingContext{{
+			Binding:   "reconcile",
+			Snapshots: map[string][]ObjectAndFilterResult{"pods": objs},
+		}}
+	}
+
+	a := snapshotObj("default/Pod/a", "1")
+	b := snapshotObj("default/Pod/b", "2")
+
+	// The order of objects is not important.
+	g.Expect(SnapshotsChecksum(bc(a, b))).To(Equal(SnapshotsChecksum(bc(b, a))))
+	// Changed object changes the checksum.
+	g.Expect(SnapshotsChecksum(bc(a, b))).ToNot(Equal(SnapshotsChecksum(bc(a, snapshotObj("default/Pod/b", "3")))))
+	// Deleted object changes the checksum.
+	g.Expect(SnapshotsChecksum(bc(a, b))).ToNot(Equal(SnapshotsChecksum(bc(a))))
+}
+
+func Test_Hook_SnapshotsUnchanged(t *testing.T) {
+	g := NewWithT(t)
+
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	h.WithHookController(controller.NewHookController())
+
+	bcs := []BindingContext{{
+		Binding:   "reconcile",
+		Snapshots: map[string][]ObjectAndFilterResult{"pods": {snapshotObj("default/Pod/a", "1")}},
+	}}
+
+	// No successful runs yet.
+	checksum, unchanged := h.SnapshotsUnchanged("reconcile", bcs)
+	g.Expect(unchanged).To(BeFalse())
+
+	h.SetSnapshotsChecksum("reconcile", checksum)
+	_, unchanged = h.SnapshotsUnchanged("reconcile", bcs)
+	g.Expect(unchanged).To(BeTrue())
+
+	// Checksums are saved per binding.
+	_, unchanged = h.SnapshotsUnchanged("other", bcs)
+	g.Expect(unchanged).To(BeFalse())
+}
@@ -15,6 +15,9 @@ const (
 	HookRun                  task.TaskType = "HookRun"
 	EnableKubernetesBindings task.TaskType = "EnableKubernetesBindings"
 	EnableScheduleBindings   task.TaskType = "EnableScheduleBindings"
+	EnableTriggerBindings    task.TaskType = "EnableTriggerBindings"
+	// a task to run a built-in no-op hook for /selftest
+	SelfTest task.TaskType = "SelfTest"
 )
 
 type HookNameAccessor interface {
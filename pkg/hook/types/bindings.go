@@ -3,8 +3,11 @@ package types
 import (
 	"time"
 
+	"github.com/go-openapi/spec"
+
 	"github.com/flant/shell-operator/pkg/kube_events_manager"
 	. "github.com/flant/shell-operator/pkg/schedule_manager/types"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
 	"github.com/flant/shell-operator/pkg/webhook/conversion"
 )
@@ -18,6 +21,12 @@ const (
 	KubernetesConversion BindingType = "kubernetesCustomResourceConversion"
 	KubernetesValidating BindingType = "kubernetesValidating"
 	KubernetesMutating   BindingType = "kubernetesMutating"
+	Composite            BindingType = "composite"
+	OnMessage            BindingType = "onMessage"
+	OnWebhook            BindingType = "onWebhook"
+	OnFileChange         BindingType = "onFileChange"
+	OnSignal             BindingType = "onSignal"
+	OnAlert              BindingType = "onAlert"
 )
 
 // Types for effective binding configs
@@ -35,24 +44,31 @@ type ScheduleConfig struct {
 	CommonBindingConfig
 	ScheduleEntry        ScheduleEntry
 	IncludeSnapshotsFrom []string
+	IncludeAllSnapshots  bool
 	Queue                string
 	Group                string
+	// SkipIfSnapshotsUnchanged skips the run if snapshots are not changed since the last successful run.
+	SkipIfSnapshotsUnchanged bool
 }
 
 type OnKubernetesEventConfig struct {
 	CommonBindingConfig
 	Monitor                      *kube_events_manager.MonitorConfig
 	IncludeSnapshotsFrom         []string
+	IncludeAllSnapshots          bool
 	Queue                        string
 	Group                        string
 	ExecuteHookOnSynchronization bool
 	WaitForSynchronization       bool
 	KeepFullObjectsInMemory      bool
+	// EnvFromObject maps names of environment variables to fields of the object from the event.
+	EnvFromObject map[string]FieldPath
 }
 
 type ConversionConfig struct {
 	CommonBindingConfig
 	IncludeSnapshotsFrom []string
+	IncludeAllSnapshots  bool
 	Group                string
 	Webhook              *conversion.WebhookConfig
 }
@@ -60,6 +76,7 @@ type ConversionConfig struct {
 type ValidatingConfig struct {
 	CommonBindingConfig
 	IncludeSnapshotsFrom []string
+	IncludeAllSnapshots  bool
 	Group                string
 	Webhook              *admission.ValidatingWebhookConfig
 }
@@ -67,11 +84,110 @@ type ValidatingConfig struct {
 type MutatingConfig struct {
 	CommonBindingConfig
 	IncludeSnapshotsFrom []string
+	IncludeAllSnapshots  bool
 	Group                string
 	Webhook              *admission.MutatingWebhookConfig
 }
 
+// CompositeCondition holds when jqFilter returns true for the snapshot
+// of the kubernetes binding or when the snapshot is not empty if jqFilter is not set.
+type CompositeCondition struct {
+	Binding  string
+	JqFilter string
+}
+
+// CompositeConfig is a trigger that runs a hook on events of kubernetes
+// bindings listed in Triggers when all Conditions hold.
+type CompositeConfig struct {
+	CommonBindingConfig
+	Triggers             []string
+	Conditions           []CompositeCondition
+	IncludeSnapshotsFrom []string
+	Queue                string
+}
+
+// TriggerConfig is a binding that runs a hook on events from an external source,
+// e.g. on messages from a NATS subject or a Kafka topic.
+type TriggerConfig struct {
+	CommonBindingConfig
+	BindingType BindingType
+	// Id is a unique id of the source in the trigger manager.
+	Id                   string
+	Source               trigger_manager.Source
+	IncludeSnapshotsFrom []string
+	IncludeAllSnapshots  bool
+	Queue                string
+	Group                string
+}
+
 type Settings struct {
 	ExecutionMinInterval time.Duration
 	ExecutionBurst       int
+	// ExecutionTimeout limits the hook execution time. Zero means no limit.
+	ExecutionTimeout time.Duration
+	// WarmPoolSize is a number of pre-started hook processes. Zero means exec-per-run.
+	WarmPoolSize int
+	// WorkingDir is a directory to run hook in. Relative path is resolved against the hook directory.
+	WorkingDir string
+	// Umask is a file mode creation mask for hook processes. Nil means the operator's umask.
+	Umask *int
+	// AllowedEnvPrefixes limits operator environment variables passed to the hook. Empty means no limits.
+	AllowedEnvPrefixes []string
+	// RunAsUser and RunAsGroup are numeric ids to run hook processes with. Nil means the operator's ids.
+	RunAsUser  *uint32
+	RunAsGroup *uint32
+	// OutputParseErrorPolicy defines what to do if the hook succeeds but its outputs are malformed.
+	OutputParseErrorPolicy OutputParseErrorPolicy
+	// Impersonate is a user for API operations performed on behalf of the hook. Nil means the operator's account.
+	Impersonate *Impersonation
+	// BindingContextFormat is a format of the $BINDING_CONTEXT_PATH file. Empty means JSON.
+	BindingContextFormat BindingContextFormat
+	// ValuesSchema is an OpenAPI schema for user values of the hook. Nil means the hook has no values.
+	ValuesSchema *spec.Schema
+	// ValuesFile is a path of the file with user values. Relative path is resolved against the hook directory.
+	ValuesFile string
+	// Lock is a Lease to run the hook only in one operator instance at a time. Nil means no lock.
+	Lock *LockSettings
+	// Check enables periodic runs of the hook with --check. Nil means the hook has no self-check.
+	Check *CheckSettings
 }
+
+// CheckSettings define periodic self-checks of the hook.
+type CheckSettings struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// LockSettings is a Lease for the distributed lock of the hook. Empty fields are set by the operator.
+type LockSettings struct {
+	Name      string
+	Namespace string
+}
+
+// Impersonation is a Kubernetes user and groups to impersonate.
+type Impersonation struct {
+	User   string
+	Groups []string
+}
+
+// BindingContextFormat is a format of the file with binding contexts.
+type BindingContextFormat string
+
+const (
+	// BindingContextJSON is a JSON array of binding contexts. It is the default.
+	BindingContextJSON BindingContextFormat = "JSON"
+	// BindingContextJSONLines is one binding context per line, so huge contexts can be processed in a stream.
+	BindingContextJSONLines BindingContextFormat = "JSONLines"
+)
+
+// OutputParseErrorPolicy is a reaction on malformed metrics, patch or webhook response files.
+type OutputParseErrorPolicy string
+
+const (
+	// OutputParseErrorFail fails the task, it is retried after a delay as other hook errors. It is the default.
+	OutputParseErrorFail OutputParseErrorPolicy = "Fail"
+	// OutputParseErrorRetry runs the hook again immediately several times before failing the task.
+	OutputParseErrorRetry OutputParseErrorPolicy = "Retry"
+	// OutputParseErrorIgnore drops malformed outputs with a warning and applies the rest.
+	OutputParseErrorIgnore OutputParseErrorPolicy = "Ignore"
+)
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FieldPath is a parsed path to the field of the object, e.g. '.spec.nodeName',
+// '.spec.containers[0].image' or '.metadata.labels["app.kubernetes.io/name"]'.
+type FieldPath struct {
+	path     string
+	segments []fieldPathSegment
+}
+
+type fieldPathSegment struct {
+	key   string
+	index int
+	// isIndex is true for array items.
+	isIndex bool
+}
+
+// ParseFieldPath parses a path to the field in the jq-like syntax.
+func ParseFieldPath(path string) (FieldPath, error) {
+	res := FieldPath{path: path}
+	if !strings.HasPrefix(path, ".") {
+		return res, fmt.Errorf("path '%s' should start with '.'", path)
+	}
+	rest := path
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[\""):
+			end := strings.Index(rest[2:], "\"]")
+			if end < 0 {
+				return res, fmt.Errorf("path '%s': unterminated key", path)
+			}
+			res.segments = append(res.segments, fieldPathSegment{key: rest[2 : 2+end]})
+			rest = rest[2+end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return res, fmt.Errorf("path '%s': unterminated index", path)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return res, fmt.Errorf("path '%s': index '%s' should be a non-negative number", path, rest[1:end])
+			}
+			res.segments = append(res.segments, fieldPathSegment{index: idx, isIndex: true})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				if rest == "" && len(res.segments) == 0 {
+					// '.' is the whole object.
+					return res, nil
+				}
+				if !strings.HasPrefix(rest, "[") {
+					return res, fmt.Errorf("path '%s': empty key", path)
+				}
+				continue
+			}
+			res.segments = append(res.segments, fieldPathSegment{key: rest[:end]})
+			rest = rest[end:]
+		default:
+			return res, fmt.Errorf("path '%s': unexpected '%s'", path, rest)
+		}
+	}
+	return res, nil
+}
+
+func (p FieldPath) String() string {
+	return p.path
+}
+
+// Lookup returns the field from the object. It returns false if the field is not found.
+func (p FieldPath) Lookup(obj map[string]interface{}) (interface{}, bool) {
+	var cur interface{} = obj
+	for _, seg := range p.segments {
+		if seg.isIndex {
+			arr, ok := cur.([]interface{})
+			if !ok || seg.index >= len(arr) {
+				return nil, false
+			}
+			cur = arr[seg.index]
+			continue
+		}
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[seg.key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// LookupString returns the field as a string: strings are returned as is,
+// other values are formatted as JSON. Missing fields and nulls are empty strings.
+func (p FieldPath) LookupString(obj map[string]interface{}) string {
+	value, found := p.Lookup(obj)
+	if !found || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
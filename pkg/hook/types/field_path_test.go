@@ -0,0 +1,50 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseFieldPath(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "pod-1",
+			"labels": map[string]interface{}{"app.kubernetes.io/name": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   nil,
+			"containers": []interface{}{
+				map[string]interface{}{"image": "nginx"},
+			},
+		},
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{".metadata.name", "pod-1"},
+		{`.metadata.labels["app.kubernetes.io/name"]`, "web"},
+		{".spec.containers[0].image", "nginx"},
+		{".spec.containers[1].image", ""},
+		{".spec.replicas", "3"},
+		{".spec.paused", ""},
+		{".status.phase", ""},
+		{".metadata.labels", `{"app.kubernetes.io/name":"web"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := ParseFieldPath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.LookupString(obj))
+		})
+	}
+
+	for _, path := range []string{"spec.nodeName", ".spec..name", ".spec.", ".items[-1]", ".items[a]", `.labels["app`} {
+		_, err := ParseFieldPath(path)
+		assert.Error(t, err, path)
+	}
+}
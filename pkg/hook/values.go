@@ -0,0 +1,77 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flant/shell-operator/pkg/hook/config"
+)
+
+// ValuesFileSuffix is a suffix of the default values file: hooks/my-hook.sh.values.yaml.
+const ValuesFileSuffix = ".values.yaml"
+
+// valuesFilePath returns a path of the values file and true if the file is set explicitly.
+func (h *Hook) valuesFilePath() (string, bool) {
+	settings := h.Config.Settings
+	if settings.ValuesFile == "" {
+		return h.Path + ValuesFileSuffix, false
+	}
+	if filepath.IsAbs(settings.ValuesFile) {
+		return settings.ValuesFile, true
+	}
+	return filepath.Join(filepath.Dir(h.Path), settings.ValuesFile), true
+}
+
+// LoadValues reads user values, validates them against the schema from settings
+// and saves them as JSON into the temp directory. The path is passed to the hook
+// in $HOOK_VALUES_PATH. Hooks without schema and values file have no values.
+func (h *Hook) LoadValues() error {
+	if h.Config == nil || h.Config.Settings == nil {
+		return nil
+	}
+	settings := h.Config.Settings
+	if settings.ValuesSchema == nil && settings.ValuesFile == "" {
+		return nil
+	}
+
+	values := map[string]interface{}{}
+
+	valuesPath, explicit := h.valuesFilePath()
+	data, err := os.ReadFile(valuesPath)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("parse values file '%s': %v", valuesPath, err)
+		}
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+	case os.IsNotExist(err) && !explicit:
+		// Values from schema defaults only.
+	default:
+		return fmt.Errorf("read values file: %v", err)
+	}
+
+	if err := config.ValidateValues(values, settings.ValuesSchema); err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	h.ValuesPath = filepath.Join(h.TmpDir, fmt.Sprintf("hook-%s-values.json", h.SafeName()))
+	// Values may contain secrets, so the file is readable only by the owner.
+	// Chmod is needed for the file left from the previous run.
+	if err := os.WriteFile(h.ValuesPath, out, 0o600); err != nil {
+		return fmt.Errorf("save values: %v", err)
+	}
+	if err := os.Chmod(h.ValuesPath, 0o600); err != nil {
+		return fmt.Errorf("save values: %v", err)
+	}
+	return h.chownTmpFiles(h.ValuesPath)
+}
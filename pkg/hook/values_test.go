@@ -0,0 +1,73 @@
+package hook
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const valuesHookConfig = `
+configVersion: v1
+onStartup: 1
+settings:
+  schema:
+    type: object
+    required: ["replicas"]
+    properties:
+      replicas:
+        type: integer
+        minimum: 1
+      mode:
+        type: string
+        default: safe
+`
+
+func newValuesHook(t *testing.T, values string) *Hook {
+	dir := t.TempDir()
+	h := NewHook("hook.sh", filepath.Join(dir, "hook.sh"))
+	h.WithTmpDir(dir)
+	_, err := h.LoadConfig([]byte(valuesHookConfig))
+	require.NoError(t, err)
+	if values != "" {
+		require.NoError(t, os.WriteFile(h.Path+ValuesFileSuffix, []byte(values), 0o644))
+	}
+	return h
+}
+
+func Test_Hook_LoadValues(t *testing.T) {
+	h := newValuesHook(t, "replicas: 3\n")
+	require.NoError(t, h.LoadValues())
+	require.NotEmpty(t, h.ValuesPath)
+
+	data, err := os.ReadFile(h.ValuesPath)
+	require.NoError(t, err)
+	values := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &values))
+	assert.Equal(t, float64(3), values["replicas"])
+	assert.Equal(t, "safe", values["mode"], "should set default")
+
+	info, err := os.Stat(h.ValuesPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func Test_Hook_LoadValues_Invalid(t *testing.T) {
+	h := newValuesHook(t, "replicas: 0\n")
+	assert.Error(t, h.LoadValues())
+
+	// Required field without values file.
+	h = newValuesHook(t, "")
+	assert.Error(t, h.LoadValues())
+}
+
+func Test_Hook_LoadValues_NoSettings(t *testing.T) {
+	h := NewHook("hook.sh", "/hooks/hook.sh")
+	_, err := h.LoadConfig([]byte("configVersion: v1\nonStartup: 1\n"))
+	require.NoError(t, err)
+	require.NoError(t, h.LoadValues())
+	assert.Empty(t, h.ValuesPath)
+}
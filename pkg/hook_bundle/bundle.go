@@ -0,0 +1,318 @@
+package hook_bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultPullTimeout limits the time to pull the bundle if Options.Timeout is not set.
+const DefaultPullTimeout = 5 * time.Minute
+
+// DigestFileName is a file in the bundle directory with the digest of the pulled manifest.
+// Hidden files are ignored by the hooks search.
+const DigestFileName = ".bundle-digest"
+
+// Options are parameters to pull the bundle.
+type Options struct {
+	Username string
+	Password string
+	// Insecure enables plain HTTP requests to the registry.
+	Insecure bool
+	// PublicKeyPath is a PEM file with the public key. If set, the bundle
+	// should have a valid cosign signature made with the private key.
+	PublicKeyPath string
+	// Timeout limits the whole pull. DefaultPullTimeout if zero.
+	Timeout time.Duration
+	// HTTPClient is used for requests to the registry. A client with Timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Pull downloads layers of the OCI artifact and extracts them into the dir.
+// Layers should be tar archives, optionally gzipped. Digests of the manifest
+// and of layers are verified before extraction. The content of the dir is replaced only if
+// the whole bundle is pulled successfully. Pull returns the manifest digest.
+func Pull(ctx context.Context, refStr string, dir string, opts Options) (string, error) {
+	ref, err := ParseReference(refStr)
+	if err != nil {
+		return "", err
+	}
+
+	var pubKey crypto.PublicKey
+	if opts.PublicKeyPath != "" {
+		pubKey, err = LoadPublicKey(opts.PublicKeyPath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultPullTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	client := newRegistryClient(opts)
+
+	manifest, digest, err := client.getManifest(ctx, ref, ref.Manifest())
+	if err != nil {
+		return "", fmt.Errorf("pull '%s': %v", ref, err)
+	}
+	if ref.Digest != "" && ref.Digest != digest {
+		return "", fmt.Errorf("pull '%s': manifest digest mismatch: got %s", ref, digest)
+	}
+
+	if pubKey != nil {
+		if err := verifySignature(ctx, client, ref, digest, pubKey); err != nil {
+			return "", fmt.Errorf("pull '%s': verify signature: %v", ref, err)
+		}
+	}
+
+	staging := dir + ".new"
+	if err := os.RemoveAll(staging); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(staging, 0o755); err != nil {
+		return "", fmt.Errorf("create directory for bundle: %v", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if err := pullLayer(ctx, client, ref, layer, staging); err != nil {
+			_ = os.RemoveAll(staging)
+			return "", fmt.Errorf("pull '%s': layer %s: %v", ref, layer.Digest, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(staging, DigestFileName), []byte(digest+"\n"), 0o644); err != nil {
+		_ = os.RemoveAll(staging)
+		return "", err
+	}
+
+	if err := replaceDir(staging, dir); err != nil {
+		return "", fmt.Errorf("pull '%s': %v", ref, err)
+	}
+
+	log.Infof("Hooks bundle '%s' with digest %s is extracted into '%s'", ref, digest, dir)
+	return digest, nil
+}
+
+// PulledDigest returns the digest of the bundle in the dir or an empty string.
+func PulledDigest(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, DigestFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func pullLayer(ctx context.Context, client *registryClient, ref Reference, layer Descriptor, dir string) error {
+	var gzipped bool
+	switch {
+	case strings.HasSuffix(layer.MediaType, "tar+gzip"), strings.HasSuffix(layer.MediaType, "tar.gzip"):
+		gzipped = true
+	case strings.HasSuffix(layer.MediaType, "tar"):
+	default:
+		return fmt.Errorf("unsupported media type '%s', only tar layers are supported", layer.MediaType)
+	}
+	if !digestRe.MatchString(layer.Digest) {
+		return fmt.Errorf("unsupported digest")
+	}
+
+	// The layer is downloaded and verified before extraction: entries
+	// of an archive with a wrong digest should not be written at all.
+	f, err := os.CreateTemp(filepath.Dir(dir), "hooks-bundle-layer-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	body, err := client.getBlob(ctx, ref, layer.Digest)
+	if err != nil {
+		return err
+	}
+	err = downloadBlob(body, f, layer)
+	_ = body.Close()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return extractTar(r, dir)
+}
+
+// downloadBlob copies the blob into w and compares the digest.
+func downloadBlob(body io.Reader, w io.Writer, layer Descriptor) error {
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hasher), io.LimitReader(body, layer.Size+1)); err != nil {
+		return err
+	}
+	digest := "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+	if digest != layer.Digest {
+		return fmt.Errorf("digest mismatch: got %s", digest)
+	}
+	return nil
+}
+
+// extractTar extracts files, directories and symlinks. Entries outside the dir are not allowed.
+// Parent directories of each entry are resolved with symlinks created by previous entries,
+// so a chain of symlinks cannot lead outside the dir either.
+func extractTar(r io.Reader, dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := securePath(root, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if target == root {
+			continue
+		}
+		parent, err := resolveInside(root, filepath.Dir(target))
+		if err != nil {
+			return fmt.Errorf("entry '%s': %v", hdr.Name, err)
+		}
+		target = filepath.Join(parent, filepath.Base(target))
+
+		// Replace symlinks instead of writing through them.
+		if fi, err := os.Lstat(target); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(parent, 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode)&0o777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			closeErr := f.Close()
+			if err != nil {
+				return err
+			}
+			if closeErr != nil {
+				return closeErr
+			}
+		case tar.TypeSymlink:
+			if filepath.IsAbs(hdr.Linkname) || !isInside(root, filepath.Join(parent, hdr.Linkname)) {
+				return fmt.Errorf("symlink '%s' points outside of the bundle", hdr.Name)
+			}
+			if err := os.MkdirAll(parent, 0o755); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			log.Warnf("Hooks bundle: entry '%s' of type '%c' is skipped", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+// securePath returns a path of the entry inside the dir.
+func securePath(dir string, name string) (string, error) {
+	clean := filepath.Clean(name)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("entry '%s' is outside of the bundle", name)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+// resolveInside resolves symlinks in the existing part of the path. Missing
+// directories are kept as is: they are created inside the resolved part.
+// An error is returned if the resolved path is outside the root.
+func resolveInside(root string, path string) (string, error) {
+	existing := path
+	var missing []string
+	for {
+		_, err := os.Lstat(existing)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(existing)}, missing...)
+		existing = filepath.Dir(existing)
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if !isInside(root, resolved) {
+		return "", fmt.Errorf("path is outside of the bundle")
+	}
+	return filepath.Join(append([]string{resolved}, missing...)...), nil
+}
+
+// isInside returns true if the path is the root or is under the root.
+func isInside(root string, path string) bool {
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// replaceDir replaces the content of the dir with the staging directory.
+func replaceDir(staging string, dir string) error {
+	old := dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.Rename(dir, old); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return err
+	}
+	return os.RemoveAll(old)
+}
@@ -0,0 +1,225 @@
+package hook_bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegistry serves manifests by tags and digests and blobs by digests.
+// Requests require a bearer token.
+type testRegistry struct {
+	server    *httptest.Server
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{manifests: map[string][]byte{}, blobs: map[string][]byte{}}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+r.server.URL+`/token",service="test",scope="repository:hooks:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var data []byte
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/v2/hooks/manifests/"):
+			data = r.manifests[strings.TrimPrefix(req.URL.Path, "/v2/hooks/manifests/")]
+		case strings.HasPrefix(req.URL.Path, "/v2/hooks/blobs/"):
+			data = r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/hooks/blobs/")]
+		}
+		if data == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func (r *testRegistry) addBlob(data []byte) string {
+	d := sha256Digest(data)
+	r.blobs[d] = data
+	return d
+}
+
+// addManifest adds the manifest with layers by the tag and by the digest.
+func (r *testRegistry) addManifest(tag string, layers ...Descriptor) string {
+	data, _ := json.Marshal(Manifest{MediaType: MediaTypeOCIManifest, Layers: layers})
+	d := sha256Digest(data)
+	r.manifests[tag] = data
+	r.manifests[d] = data
+	return d
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+func makeTarGz(t *testing.T, entries ...tarEntry) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: e.mode, Size: int64(len(e.content)), Linkname: e.linkname}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func (r *testRegistry) addTarLayer(t *testing.T, entries ...tarEntry) Descriptor {
+	data := makeTarGz(t, entries...)
+	return Descriptor{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: r.addBlob(data), Size: int64(len(data))}
+}
+
+func Test_Pull(t *testing.T) {
+	reg := newTestRegistry(t)
+	layer := reg.addTarLayer(t,
+		tarEntry{name: "hooks/", typeflag: tar.TypeDir, mode: 0o755},
+		tarEntry{name: "hooks/hook.sh", typeflag: tar.TypeReg, mode: 0o755, content: "#!/bin/sh\n"},
+		tarEntry{name: "hooks/link.sh", typeflag: tar.TypeSymlink, linkname: "hook.sh"},
+	)
+	digest := reg.addManifest("v1", layer)
+
+	dir := filepath.Join(t.TempDir(), "bundle")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.sh"), []byte("old"), 0o755))
+
+	got, err := Pull(context.Background(), reg.host()+"/hooks:v1", dir, Options{Insecure: true})
+	require.NoError(t, err)
+	assert.Equal(t, digest, got)
+	assert.Equal(t, digest, PulledDigest(dir))
+
+	info, err := os.Stat(filepath.Join(dir, "hooks/hook.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	assert.NoFileExists(t, filepath.Join(dir, "stale.sh"), "content should be replaced")
+
+	// Pinned digest should match.
+	_, err = Pull(context.Background(), reg.host()+"/hooks@"+digest, dir, Options{Insecure: true})
+	require.NoError(t, err)
+	// Registry returns another manifest for the digest.
+	wrongDigest := "sha256:" + strings.Repeat("0", 64)
+	reg.manifests[wrongDigest] = reg.manifests["v1"]
+	_, err = Pull(context.Background(), reg.host()+"/hooks@"+wrongDigest, dir, Options{Insecure: true})
+	assert.ErrorContains(t, err, "digest mismatch")
+}
+
+func Test_Pull_Errors(t *testing.T) {
+	reg := newTestRegistry(t)
+
+	corrupted := reg.addTarLayer(t, tarEntry{name: "hook.sh", typeflag: tar.TypeReg, mode: 0o755, content: "#!/bin/sh\n"})
+	reg.blobs[corrupted.Digest] = makeTarGz(t, tarEntry{name: "hook.sh", typeflag: tar.TypeReg, mode: 0o755, content: "#!/bin/sh\nrm -rf /\n"})
+	corrupted.Size = int64(len(reg.blobs[corrupted.Digest]))
+	reg.addManifest("corrupted", corrupted)
+
+	reg.addManifest("traversal", reg.addTarLayer(t, tarEntry{name: "../evil.sh", typeflag: tar.TypeReg, mode: 0o755, content: "x"}))
+	reg.addManifest("symlink", reg.addTarLayer(t, tarEntry{name: "passwd", typeflag: tar.TypeSymlink, linkname: "../../etc/passwd"}))
+	// Each symlink is inside the bundle lexically, but the chain leads outside.
+	reg.addManifest("symlink-chain", reg.addTarLayer(t,
+		tarEntry{name: "s/", typeflag: tar.TypeDir, mode: 0o755},
+		tarEntry{name: "s/l", typeflag: tar.TypeSymlink, linkname: ".."},
+		tarEntry{name: "s/l/m", typeflag: tar.TypeSymlink, linkname: ".."},
+		tarEntry{name: "s/l/m/evil", typeflag: tar.TypeReg, mode: 0o755, content: "x"},
+	))
+
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "bundle")
+	for tag, msg := range map[string]string{
+		"corrupted":     "digest mismatch",
+		"traversal":     "outside of the bundle",
+		"symlink":       "outside of the bundle",
+		"symlink-chain": "outside of the bundle",
+		"absent":        "404",
+	} {
+		_, err := Pull(context.Background(), reg.host()+"/hooks:"+tag, dir, Options{Insecure: true})
+		assert.ErrorContains(t, err, msg, tag)
+		assert.NoDirExists(t, dir, "dir should not be created on error")
+		assert.NoFileExists(t, filepath.Join(tmpDir, "evil"), tag)
+		assert.NoFileExists(t, filepath.Join(tmpDir, "hook.sh"), tag)
+	}
+}
+
+func Test_Pull_Signature(t *testing.T) {
+	reg := newTestRegistry(t)
+	digest := reg.addManifest("v1", reg.addTarLayer(t, tarEntry{name: "hook.sh", typeflag: tar.TypeReg, mode: 0o755, content: "#!/bin/sh\n"}))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}), 0o644))
+
+	dir := filepath.Join(t.TempDir(), "bundle")
+	opts := Options{Insecure: true, PublicKeyPath: keyPath}
+
+	_, err = Pull(context.Background(), reg.host()+"/hooks:v1", dir, opts)
+	assert.ErrorContains(t, err, "no signature")
+
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"hooks"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"}}`)
+	hashed := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hashed[:])
+	require.NoError(t, err)
+	reg.addManifest(strings.Replace(digest, ":", "-", 1)+".sig", Descriptor{
+		MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+		Digest:      reg.addBlob(payload),
+		Size:        int64(len(payload)),
+		Annotations: map[string]string{CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+
+	got, err := Pull(context.Background(), reg.host()+"/hooks:v1", dir, opts)
+	require.NoError(t, err)
+	assert.Equal(t, digest, got)
+
+	// Signature of another key is not accepted.
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherDer, err := x509.MarshalPKIXPublicKey(&otherKey.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: otherDer}), 0o644))
+	_, err = Pull(context.Background(), reg.host()+"/hooks:v1", dir, opts)
+	assert.ErrorContains(t, err, "no valid signatures")
+}
@@ -0,0 +1,50 @@
+package hook_bundle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+// OptionsFromFlags returns options from --hooks-oci-* flags.
+func OptionsFromFlags() Options {
+	return Options{
+		Username:      app.HooksOCIUsername,
+		Password:      app.HooksOCIPassword,
+		Insecure:      app.HooksOCIInsecure,
+		PublicKeyPath: app.HooksOCIPublicKey,
+		Timeout:       app.HooksOCITimeout,
+	}
+}
+
+// DefinePullHooksCommand defines a command to pull the hooks bundle into the directory,
+// e.g. in the init container or to update a shared volume.
+func DefinePullHooksCommand(kpApp *kingpin.Application) {
+	dir := app.HooksDir
+
+	cmd := app.CommandWithDefaultUsageTemplate(kpApp, "pull-hooks", "Pull the hooks bundle from the OCI registry into the directory.").
+		Action(func(c *kingpin.ParseContext) error {
+			if app.HooksOCIRef == "" {
+				return fmt.Errorf("--hooks-oci-ref is required")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			digest, err := Pull(ctx, app.HooksOCIRef, dir, OptionsFromFlags())
+			if err != nil {
+				return err
+			}
+			fmt.Println(digest)
+			return nil
+		})
+	cmd.Flag("hooks-dir", "A directory to extract the bundle into. Its content is replaced. Can be set with $SHELL_OPERATOR_HOOKS_DIR.").
+		Envar("SHELL_OPERATOR_HOOKS_DIR").
+		Default(dir).
+		StringVar(&dir)
+	app.DefineHookBundleFlags(cmd)
+}
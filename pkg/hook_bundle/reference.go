@@ -0,0 +1,86 @@
+package hook_bundle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultRegistry = "registry-1.docker.io"
+	DefaultTag      = "latest"
+)
+
+var digestRe = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Reference is a parsed reference to the OCI artifact, e.g.
+// 'registry.example.com/hooks/bundle:v1' or 'registry.example.com/hooks/bundle@sha256:...'.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	// Digest pins the manifest. The pulled manifest should have this digest.
+	Digest string
+}
+
+// ParseReference parses the reference. The registry is 'registry-1.docker.io'
+// if the first part has no dots or port, the tag is 'latest' if not set.
+func ParseReference(s string) (Reference, error) {
+	ref := Reference{}
+	if s == "" {
+		return ref, fmt.Errorf("reference is empty")
+	}
+
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !digestRe.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("reference '%s': digest should be 'sha256:' and 64 hex characters", s)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = DefaultRegistry
+		ref.Repository = name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return ref, fmt.Errorf("reference '%s': repository should be a non-empty lowercase name", s)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+	return ref, nil
+}
+
+// Manifest returns a tag or a digest to get the manifest. Digest takes precedence.
+func (r Reference) Manifest() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
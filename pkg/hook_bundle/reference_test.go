@@ -0,0 +1,36 @@
+package hook_bundle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		in   string
+		want Reference
+	}{
+		{"registry.example.com/hooks/bundle:v1", Reference{Registry: "registry.example.com", Repository: "hooks/bundle", Tag: "v1"}},
+		{"localhost:5000/bundle", Reference{Registry: "localhost:5000", Repository: "bundle", Tag: "latest"}},
+		{"localhost/bundle@" + digest, Reference{Registry: "localhost", Repository: "bundle", Digest: digest}},
+		{"flant/hooks:v1@" + digest, Reference{Registry: DefaultRegistry, Repository: "flant/hooks", Tag: "v1", Digest: digest}},
+		{"hooks", Reference{Registry: DefaultRegistry, Repository: "library/hooks", Tag: "latest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ref, err := ParseReference(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+		})
+	}
+
+	for _, in := range []string{"", "registry.example.com/hooks@sha256:123", "registry.example.com/Hooks:v1"} {
+		_, err := ParseReference(in)
+		assert.Error(t, err, in)
+	}
+}
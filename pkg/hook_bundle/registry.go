@@ -0,0 +1,218 @@
+package hook_bundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	MediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// maxManifestSize limits the size of manifests and of signature payloads.
+	maxManifestSize = 4 << 20
+)
+
+// Manifest is an OCI image manifest. Only fields used to pull the bundle are declared.
+type Manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    Descriptor   `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+}
+
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// registryClient makes requests to the registry API. Bearer tokens are requested
+// on the 401 response as described in the Docker registry token authentication.
+type registryClient struct {
+	httpClient *http.Client
+	scheme     string
+	username   string
+	password   string
+
+	mu         sync.Mutex
+	authHeader string
+}
+
+func newRegistryClient(opts Options) *registryClient {
+	c := &registryClient{
+		httpClient: opts.HTTPClient,
+		scheme:     "https",
+		username:   opts.Username,
+		password:   opts.Password,
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: opts.Timeout}
+	}
+	if opts.Insecure {
+		c.scheme = "http"
+	}
+	return c
+}
+
+// getManifest returns the manifest and its digest.
+func (c *registryClient) getManifest(ctx context.Context, ref Reference, tagOrDigest string) (*Manifest, string, error) {
+	resp, err := c.get(ctx, ref, "manifests/"+tagOrDigest, MediaTypeOCIManifest+", "+MediaTypeDockerManifest)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("read manifest '%s': %v", tagOrDigest, err)
+	}
+	if len(data) > maxManifestSize {
+		return nil, "", fmt.Errorf("manifest '%s' is larger than %d bytes", tagOrDigest, maxManifestSize)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, "", fmt.Errorf("parse manifest '%s': %v", tagOrDigest, err)
+	}
+	return &m, digest, nil
+}
+
+// getBlob returns a body of the blob. The caller should verify the digest and close the body.
+func (c *registryClient) getBlob(ctx context.Context, ref Reference, digest string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, ref, "blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *registryClient) get(ctx context.Context, ref Reference, path string, accept string) (*http.Response, error) {
+	u := fmt.Sprintf("%s://%s/v2/%s/%s", c.scheme, ref.Registry, ref.Repository, path)
+
+	resp, err := c.do(ctx, u, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authorize(ctx, challenge); err != nil {
+			return nil, fmt.Errorf("authorize in '%s': %v", ref.Registry, err)
+		}
+		resp, err = c.do(ctx, u, accept)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *registryClient) do(ctx context.Context, u string, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	c.mu.Lock()
+	if c.authHeader != "" {
+		req.Header.Set("Authorization", c.authHeader)
+	}
+	c.mu.Unlock()
+	return c.httpClient.Do(req)
+}
+
+var challengeParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authorize sets the Authorization header for the challenge from the WWW-Authenticate header.
+func (c *registryClient) authorize(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.username == "" {
+			return fmt.Errorf("registry requires credentials")
+		}
+		c.setAuthHeader("Basic " + base64.StdEncoding.EncodeToString([]byte(c.username+":"+c.password)))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported challenge '%s'", challenge)
+	}
+
+	values := map[string]string{}
+	for _, m := range challengeParamRe.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(m[1])] = m[2]
+	}
+	if values["realm"] == "" {
+		return fmt.Errorf("no realm in challenge '%s'", challenge)
+	}
+
+	query := url.Values{}
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+	if values["scope"] != "" {
+		query.Set("scope", values["scope"])
+	}
+	tokenURL := values["realm"]
+	if len(query) > 0 {
+		tokenURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get token: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return fmt.Errorf("parse token: %v", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("token is empty")
+	}
+	c.setAuthHeader("Bearer " + token.Token)
+	return nil
+}
+
+func (c *registryClient) setAuthHeader(value string) {
+	c.mu.Lock()
+	c.authHeader = value
+	c.mu.Unlock()
+}
@@ -0,0 +1,115 @@
+package hook_bundle
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CosignSignatureAnnotation is an annotation of the signature layer with the base64 signature of the payload.
+const CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// LoadPublicKey reads the PEM public key, e.g. cosign.pub generated by 'cosign generate-key-pair'.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read public key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("public key '%s' is not in PEM format", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key '%s': %v", path, err)
+	}
+	return key, nil
+}
+
+// signaturePayload is a 'simple signing' payload signed by cosign.
+type signaturePayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifySignature finds a cosign signature of the manifest digest made with the key.
+// Signatures are stored in the same repository with the 'sha256-<hex>.sig' tag.
+func verifySignature(ctx context.Context, client *registryClient, ref Reference, digest string, key crypto.PublicKey) error {
+	sigTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	manifest, _, err := client.getManifest(ctx, ref, sigTag)
+	if err != nil {
+		return fmt.Errorf("no signature: %v", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		sig, err := base64.StdEncoding.DecodeString(layer.Annotations[CosignSignatureAnnotation])
+		if err != nil || len(sig) == 0 {
+			continue
+		}
+
+		payload, err := getPayload(ctx, client, ref, layer)
+		if err != nil {
+			return err
+		}
+		if !VerifyBytes(key, payload, sig) {
+			continue
+		}
+
+		var p signaturePayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return fmt.Errorf("parse signature payload: %v", err)
+		}
+		if p.Critical.Image.DockerManifestDigest != digest {
+			return fmt.Errorf("signature is made for %s", p.Critical.Image.DockerManifestDigest)
+		}
+		return nil
+	}
+	return fmt.Errorf("no valid signatures for the public key")
+}
+
+func getPayload(ctx context.Context, client *registryClient, ref Reference, layer Descriptor) ([]byte, error) {
+	body, err := client.getBlob(ctx, ref, layer.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(body, maxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(payload)
+	if "sha256:"+hex.EncodeToString(sum[:]) != layer.Digest {
+		return nil, fmt.Errorf("signature payload digest mismatch")
+	}
+	return payload, nil
+}
+
+// VerifyBytes verifies the signature of the payload made with SHA256, e.g. by 'cosign sign-blob'.
+func VerifyBytes(key crypto.PublicKey, payload []byte, sig []byte) bool {
+	hashed := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, hashed[:], sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, sig)
+	}
+	return false
+}
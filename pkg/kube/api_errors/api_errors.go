@@ -0,0 +1,66 @@
+package api_errors
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// Errors to check results of Kubernetes API calls with errors.Is.
+var (
+	// ErrNotFound is returned if the object or the resource is not found.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned if the request is forbidden by RBAC.
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflictRetriesExceeded is returned if the object is modified
+	// concurrently and all retries are failed with conflicts.
+	ErrConflictRetriesExceeded = errors.New("conflict retries exceeded")
+)
+
+// Error wraps an error from the Kubernetes API. Both the sentinel error and
+// the original error are available for errors.Is and errors.As, so checks
+// like apierrors.IsNotFound keep working.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Wrap adds a sentinel error to NotFound and Forbidden API errors.
+// Other errors are returned as is.
+func Wrap(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err):
+		return wrapKind(ErrNotFound, err)
+	case apierrors.IsForbidden(err):
+		return wrapKind(ErrForbidden, err)
+	}
+	return err
+}
+
+// RetryOnConflict is retry.RetryOnConflict with the default backoff. The last
+// conflict error is wrapped with ErrConflictRetriesExceeded.
+func RetryOnConflict(fn func() error) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, fn)
+	if apierrors.IsConflict(err) {
+		return wrapKind(ErrConflictRetriesExceeded, err)
+	}
+	return err
+}
+
+func wrapKind(kind error, err error) error {
+	if errors.Is(err, kind) {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
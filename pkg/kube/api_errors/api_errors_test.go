@@ -0,0 +1,58 @@
+package api_errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_Wrap(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	err := Wrap(apierrors.NewNotFound(gr, "web"))
+	require.ErrorIs(t, err, ErrNotFound)
+	require.True(t, apierrors.IsNotFound(err))
+	require.Equal(t, `deployments.apps "web" not found`, err.Error())
+
+	// Wrapped with fmt.Errorf.
+	err = fmt.Errorf("patch: %w", Wrap(apierrors.NewForbidden(gr, "web", errors.New("no RBAC"))))
+	require.ErrorIs(t, err, ErrForbidden)
+	require.NotErrorIs(t, err, ErrNotFound)
+	require.True(t, apierrors.IsForbidden(err))
+
+	// Not wrapped twice.
+	err = Wrap(apierrors.NewNotFound(gr, "web"))
+	require.Equal(t, err, Wrap(err))
+
+	err = errors.New("connection refused")
+	require.Equal(t, err, Wrap(err))
+	require.NoError(t, Wrap(nil))
+}
+
+func Test_RetryOnConflict(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	calls := 0
+	err := RetryOnConflict(func() error {
+		calls++
+		return apierrors.NewConflict(gr, "web", errors.New("object is modified"))
+	})
+	require.ErrorIs(t, err, ErrConflictRetriesExceeded)
+	require.True(t, apierrors.IsConflict(err))
+	require.Greater(t, calls, 1)
+
+	calls = 0
+	err = RetryOnConflict(func() error {
+		calls++
+		if calls < 3 {
+			return apierrors.NewConflict(gr, "web", errors.New("object is modified"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+}
@@ -0,0 +1,114 @@
+package api_warnings
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// MaxWarnings is a maximum number of unique warnings to keep. Other warnings
+// are counted with the OtherWarning text to limit the metric cardinality.
+const MaxWarnings = 100
+
+// OtherWarning is used for warnings above the MaxWarnings limit.
+const OtherWarning = "other"
+
+// DefaultHandler collects warnings for all Kubernetes clients in the process.
+var DefaultHandler = NewHandler()
+
+// Warning is a unique warning returned by the API server.
+type Warning struct {
+	Text      string    `json:"text"`
+	Agent     string    `json:"agent,omitempty"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// Handler implements rest.WarningHandler. It logs warnings according to the mode,
+// counts them in metrics and keeps them for the debug endpoint.
+type Handler struct {
+	m             sync.Mutex
+	mode          string
+	warnings      map[string]*Warning
+	metricStorage *metric_storage.MetricStorage
+}
+
+var _ rest.WarningHandler = (*Handler)(nil)
+
+func NewHandler() *Handler {
+	return &Handler{
+		mode:     app.KubeAPIWarningsLogOnce,
+		warnings: make(map[string]*Warning),
+	}
+}
+
+// Setup sets the logging mode and installs the handler as a default
+// warning handler for all clients without their own handler.
+func (h *Handler) Setup(mode string, metricStorage *metric_storage.MetricStorage) {
+	h.m.Lock()
+	h.mode = mode
+	h.metricStorage = metricStorage
+	h.m.Unlock()
+
+	rest.SetDefaultWarningHandler(h)
+}
+
+// HandleWarningHeader is called for each Warning header in API responses,
+// including watch streams.
+func (h *Handler) HandleWarningHeader(code int, agent string, text string) {
+	// Only 299 warn-code is used by the API server, see rest.WarningLogger.
+	if code != 299 || len(text) == 0 {
+		return
+	}
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	now := time.Now()
+	w, seen := h.warnings[text]
+	if !seen {
+		if len(h.warnings) >= MaxWarnings {
+			w = h.warnings[OtherWarning]
+		}
+		if w == nil {
+			w = &Warning{Text: text, Agent: agent, FirstSeen: now}
+			if len(h.warnings) >= MaxWarnings {
+				w.Text = OtherWarning
+				w.Agent = ""
+			}
+			h.warnings[w.Text] = w
+		}
+	}
+	w.Count++
+	w.LastSeen = now
+
+	h.metricStorage.CounterAdd("{PREFIX}kube_api_warnings_total", 1.0, map[string]string{"warning": w.Text})
+
+	if h.mode == app.KubeAPIWarningsSilent || (h.mode == app.KubeAPIWarningsLogOnce && seen) {
+		return
+	}
+	log.WithField("operator.component", "kubeAPIWarnings").
+		Warnf("Kubernetes API server returned a warning: %s", text)
+}
+
+// List returns collected warnings sorted by text.
+func (h *Handler) List() []Warning {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	res := make([]Warning, 0, len(h.warnings))
+	for _, w := range h.warnings {
+		res = append(res, *w)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Text < res[j].Text
+	})
+	return res
+}
@@ -0,0 +1,51 @@
+package api_warnings
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+func Test_Handler_CountsUniqueWarnings(t *testing.T) {
+	h := NewHandler()
+	h.mode = app.KubeAPIWarningsSilent
+
+	h.HandleWarningHeader(299, "", "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+, unavailable in v1.25+")
+	h.HandleWarningHeader(299, "", "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+, unavailable in v1.25+")
+	h.HandleWarningHeader(299, "", "autoscaling/v2beta2 HorizontalPodAutoscaler is deprecated in v1.23+, unavailable in v1.26+")
+	// Not a warning from the API server.
+	h.HandleWarningHeader(199, "", "miscellaneous warning")
+	h.HandleWarningHeader(299, "", "")
+
+	list := h.List()
+	require.Len(t, list, 2)
+	require.Equal(t, "autoscaling/v2beta2 HorizontalPodAutoscaler is deprecated in v1.23+, unavailable in v1.26+", list[0].Text)
+	require.Equal(t, 1, list[0].Count)
+	require.Equal(t, "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+, unavailable in v1.25+", list[1].Text)
+	require.Equal(t, 2, list[1].Count)
+	require.False(t, list[1].LastSeen.Before(list[1].FirstSeen))
+}
+
+func Test_Handler_LimitsUniqueWarnings(t *testing.T) {
+	h := NewHandler()
+	h.mode = app.KubeAPIWarningsSilent
+
+	for i := 0; i < MaxWarnings+10; i++ {
+		h.HandleWarningHeader(299, "", fmt.Sprintf("warning %d", i))
+	}
+	// Known warning is still counted.
+	h.HandleWarningHeader(299, "", "warning 0")
+
+	list := h.List()
+	require.Len(t, list, MaxWarnings+1)
+
+	counts := map[string]int{}
+	for _, w := range list {
+		counts[w.Text] = w.Count
+	}
+	require.Equal(t, 2, counts["warning 0"])
+	require.Equal(t, 10, counts[OtherWarning])
+}
@@ -0,0 +1,314 @@
+package lease_lock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	log "github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flant/shell-operator/pkg/kube/read_only"
+)
+
+// DefaultRetryPeriod is a delay between attempts to take the Lease held by another instance.
+const DefaultRetryPeriod = 2 * time.Second
+
+// Locker runs critical sections in one operator instance at a time. The lock is a Lease
+// from the coordination.k8s.io API, so instances in different namespaces or clusters are
+// coordinated if they use the same Lease. Methods are safe to call on a nil Locker: locks
+// are not acquired.
+type Locker struct {
+	client        kubernetes.Interface
+	namespace     string
+	identity      string
+	leaseDuration time.Duration
+	retryPeriod   time.Duration
+	now           func() time.Time
+}
+
+func NewLocker(client kubernetes.Interface, namespace string, identity string, leaseDuration time.Duration) *Locker {
+	return &Locker{
+		client:        client,
+		namespace:     namespace,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		retryPeriod:   DefaultRetryPeriod,
+		now:           time.Now,
+	}
+}
+
+// DefaultIdentity returns the hostname with a random suffix: Pods in different clusters may have the same names.
+func DefaultIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "shell-operator"
+	}
+	return hostname + "_" + uuid.Must(uuid.NewV4()).String()[:8]
+}
+
+// LeaseName returns a valid name of the Lease for the hook or queue name.
+func LeaseName(prefix string, name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(prefix + name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	res := b.String()
+	if len(res) > 253 {
+		res = res[:253]
+	}
+	return strings.Trim(res, "-.")
+}
+
+// Lock is an acquired Lease. It is renewed in background until Release.
+type Lock struct {
+	locker    *Locker
+	namespace string
+	name      string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	m    sync.Mutex
+	lost bool
+	// lostCh is closed when the Lease is lost.
+	lostCh chan struct{}
+}
+
+// Acquire waits until the Lease is free or expired and takes it. The Locker's namespace is used
+// if namespace is empty. It returns an error only if ctx is done or the namespace is not set.
+func (l *Locker) Acquire(ctx context.Context, namespace string, name string) (*Lock, error) {
+	if l == nil {
+		return nil, nil
+	}
+	if namespace == "" {
+		namespace = l.namespace
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace for Lease '%s' is not set", name)
+	}
+	if read_only.Skip("lease_lock", "acquire", fmt.Sprintf("Lease %s/%s", namespace, name)) {
+		return nil, nil
+	}
+
+	logged := false
+	for {
+		holder, acquired, err := l.tryAcquire(ctx, namespace, name)
+		if err != nil {
+			log.Warnf("Acquire Lease %s/%s: %v", namespace, name, err)
+		}
+		if acquired {
+			break
+		}
+		if !logged && holder != "" {
+			log.Infof("Wait for Lease %s/%s held by '%s'", namespace, name, holder)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retryPeriod):
+		}
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	lock := &Lock{
+		locker:    l,
+		namespace: namespace,
+		name:      name,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		lostCh:    make(chan struct{}),
+	}
+	go lock.renew(renewCtx)
+	return lock, nil
+}
+
+// tryAcquire takes the Lease if it is free, expired or already held by this instance.
+// It returns the current holder if the Lease is held by another instance.
+func (l *Locker) tryAcquire(ctx context.Context, namespace string, name string) (string, bool, error) {
+	leases := l.client.CoordinationV1().Leases(namespace)
+	now := metav1.NewMicroTime(l.now())
+	durationSeconds := int32(l.leaseDuration.Seconds())
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &l.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return "", false, nil
+		}
+		return "", err == nil, err
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	if holder != "" && holder != l.identity && !l.expired(lease) {
+		return holder, false, nil
+	}
+
+	if holder != l.identity {
+		lease.Spec.AcquireTime = &now
+		transitions := int32(1)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.HolderIdentity = &l.identity
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		// Another instance has updated the Lease first.
+		return "", false, nil
+	}
+	return "", err == nil, err
+}
+
+func (l *Locker) expired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiresAt := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return l.now().After(expiresAt)
+}
+
+// Lost returns true if the Lease is taken by another instance or is not renewed in time.
+func (lk *Lock) Lost() bool {
+	if lk == nil {
+		return false
+	}
+	lk.m.Lock()
+	defer lk.m.Unlock()
+	return lk.lost
+}
+
+// Release stops the renewal and frees the Lease, so other instances can take it without waiting for the expiration.
+func (lk *Lock) Release() {
+	if lk == nil {
+		return
+	}
+	lk.cancel()
+	<-lk.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), lk.locker.leaseDuration)
+	defer cancel()
+	leases := lk.locker.client.CoordinationV1().Leases(lk.namespace)
+	lease, err := leases.Get(ctx, lk.name, metav1.GetOptions{})
+	if err != nil {
+		log.Warnf("Release Lease %s/%s: %v", lk.namespace, lk.name, err)
+		return
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != lk.locker.identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if err != nil {
+		log.Warnf("Release Lease %s/%s: %v", lk.namespace, lk.name, err)
+	}
+}
+
+// renewDeadline is a time to renew the Lease before the Lock is considered lost.
+// It is shorter than the Lease duration, so the hook is terminated before another
+// instance can take the expired Lease.
+func (l *Locker) renewDeadline() time.Duration {
+	return l.leaseDuration * 2 / 3
+}
+
+// renew updates the Lease every third of its duration. The Lock is lost if the Lease
+// is taken by another instance or is not renewed during the renew deadline.
+func (lk *Lock) renew(ctx context.Context) {
+	defer close(lk.done)
+	ticker := time.NewTicker(lk.locker.leaseDuration / 3)
+	defer ticker.Stop()
+	renewDeadline := lk.locker.renewDeadline()
+	deadline := time.NewTimer(renewDeadline)
+	defer deadline.Stop()
+	lastRenew := lk.locker.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			if !lk.Lost() {
+				log.Errorf("Lease %s/%s is lost: it is not renewed for %s", lk.namespace, lk.name, renewDeadline)
+				lk.setLost()
+			}
+		case <-ticker.C:
+			// The request should not outlive the deadline.
+			tryCtx, cancel := context.WithTimeout(ctx, renewDeadline-lk.locker.now().Sub(lastRenew))
+			holder, renewed, err := lk.locker.tryAcquire(tryCtx, lk.namespace, lk.name)
+			cancel()
+			if renewed {
+				lastRenew = lk.locker.now()
+				if !deadline.Stop() {
+					select {
+					case <-deadline.C:
+					default:
+					}
+				}
+				deadline.Reset(renewDeadline)
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Warnf("Renew Lease %s/%s: %v", lk.namespace, lk.name, err)
+			}
+			if lk.Lost() {
+				continue
+			}
+			if holder != "" {
+				log.Errorf("Lease %s/%s is lost: it is taken by '%s'", lk.namespace, lk.name, holder)
+				lk.setLost()
+			} else if lk.locker.now().Sub(lastRenew) >= renewDeadline {
+				log.Errorf("Lease %s/%s is lost: it is not renewed for %s", lk.namespace, lk.name, renewDeadline)
+				lk.setLost()
+			}
+		}
+	}
+}
+
+func (lk *Lock) setLost() {
+	lk.m.Lock()
+	defer lk.m.Unlock()
+	if !lk.lost {
+		close(lk.lostCh)
+	}
+	lk.lost = true
+}
+
+// LostCh returns a channel that is closed when the Lease is lost. It returns nil for a nil Lock.
+func (lk *Lock) LostCh() <-chan struct{} {
+	if lk == nil {
+		return nil
+	}
+	return lk.lostCh
+}
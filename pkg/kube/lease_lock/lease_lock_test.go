@@ -0,0 +1,110 @@
+package lease_lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flant/kube-client/fake"
+)
+
+func newTestLocker(fc *fake.Cluster, identity string) *Locker {
+	l := NewLocker(fc.Client, "default", identity, 3*time.Second)
+	l.retryPeriod = 10 * time.Millisecond
+	return l
+}
+
+func Test_Locker_Exclusive(t *testing.T) {
+	fc := fake.NewFakeCluster(fake.ClusterVersionV121)
+	first := newTestLocker(fc, "first")
+	second := newTestLocker(fc, "second")
+
+	lock, err := first.Acquire(context.Background(), "", "hook-sync")
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	// The Lease is held by the first instance.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = second.Acquire(ctx, "", "hook-sync")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	lock.Release()
+	assert.False(t, lock.Lost())
+
+	secondLock, err := second.Acquire(context.Background(), "", "hook-sync")
+	require.NoError(t, err)
+	defer secondLock.Release()
+
+	lease, err := fc.Client.CoordinationV1().Leases("default").Get(context.Background(), "hook-sync", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "second", *lease.Spec.HolderIdentity)
+	assert.Equal(t, int32(1), *lease.Spec.LeaseTransitions)
+}
+
+func Test_Locker_ExpiredLease(t *testing.T) {
+	fc := fake.NewFakeCluster(fake.ClusterVersionV121)
+	crashed := newTestLocker(fc, "crashed")
+	second := newTestLocker(fc, "second")
+
+	// The crashed instance does not renew the Lease.
+	_, acquired, err := crashed.tryAcquire(context.Background(), "default", "queue-main")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	holder, acquired, err := second.tryAcquire(context.Background(), "default", "queue-main")
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, "crashed", holder)
+
+	second.now = func() time.Time { return time.Now().Add(time.Minute) }
+	_, acquired, err = second.tryAcquire(context.Background(), "default", "queue-main")
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func Test_Locker_Nil(t *testing.T) {
+	var l *Locker
+	lock, err := l.Acquire(context.Background(), "default", "hook-sync")
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+	lock.Release()
+	assert.False(t, lock.Lost())
+}
+
+func Test_LeaseName(t *testing.T) {
+	assert.Equal(t, "shell-operator-hook-002-app-sync.sh", LeaseName("shell-operator-hook-", "002-app/sync.sh"))
+	assert.Equal(t, "shell-operator-queue-main", LeaseName("shell-operator-queue-", "main"))
+	assert.Equal(t, "hooks-sync", LeaseName("", "_Hooks/Sync_"))
+}
+
+func Test_Lock_RenewDeadline(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	l := NewLocker(client, "default", "first", 600*time.Millisecond)
+
+	lock, err := l.Acquire(context.Background(), "", "hook-sync")
+	require.NoError(t, err)
+	defer lock.Release()
+	start := time.Now()
+
+	// The API server is unavailable, the Lease can't be renewed.
+	client.PrependReactor("*", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	// The Lock should be lost before the Lease expires and another instance can take it.
+	select {
+	case <-lock.LostCh():
+	case <-time.After(time.Second):
+		t.Fatal("Lock should be lost")
+	}
+	assert.Less(t, time.Since(start), 600*time.Millisecond)
+}
@@ -0,0 +1,75 @@
+package object_patch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flant/shell-operator/pkg/kube/api_errors"
+)
+
+func Test_EvictOperation(t *testing.T) {
+	const namespace = "default"
+
+	cluster := newFakeClusterWithNamespaceAndObjects(t, namespace)
+	clientset := cluster.Client.Interface.(*k8sfake.Clientset)
+	patcher := NewObjectPatcher(cluster.Client)
+
+	// Emulate Eviction API: "protected" Pod is guarded by PodDisruptionBudget,
+	// other Pods are deleted.
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		if eviction.Name == "protected" {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+		}
+		err := clientset.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+		return true, nil, err
+	})
+
+	createPod := func(name string) {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid-" + name)}}
+		_, err := clientset.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	podExists := func(name string) bool {
+		_, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	createPod("worker")
+	createPod("protected")
+
+	err := patcher.ExecuteOperation(NewEvictOperation(namespace, "worker", WithWait(10*time.Second)))
+	require.NoError(t, err)
+	require.False(t, podExists("worker"))
+
+	err = patcher.ExecuteOperation(NewEvictOperation(namespace, "protected"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "PodDisruptionBudget")
+	require.True(t, podExists("protected"))
+
+	// Missing Pod.
+	err = patcher.ExecuteOperation(NewEvictOperation(namespace, "worker"))
+	require.ErrorIs(t, err, api_errors.ErrNotFound)
+	require.True(t, apierrors.IsNotFound(err))
+
+	err = patcher.ExecuteOperation(NewEvictOperation(namespace, "worker", IgnoreMissingObject()))
+	require.NoError(t, err)
+}
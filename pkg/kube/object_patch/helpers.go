@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -65,6 +68,32 @@ func unmarshalFromYaml(yamlSpecs []byte) ([]OperationSpec, error) {
 	return specSlice, nil
 }
 
+// NormalizeOperations returns a canonical JSON line for each operation in specBytes.
+// Lines are sorted, so outputs of two hooks can be compared regardless of the order
+// of operations and of the format of the patch file.
+func NormalizeOperations(specBytes []byte) ([]string, error) {
+	if len(bytes.TrimSpace(specBytes)) == 0 {
+		return []string{}, nil
+	}
+
+	specs, err := unmarshalFromJSONOrYAML(specBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		line, err := json.Marshal(spec)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, string(line))
+	}
+	sort.Strings(lines)
+
+	return lines, nil
+}
+
 func applyJQPatch(jqFilter string, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	objBytes, err := obj.MarshalJSON()
 	if err != nil {
@@ -86,6 +115,30 @@ func applyJQPatch(jqFilter string, obj *unstructured.Unstructured) (*unstructure
 	return retObj, nil
 }
 
+// applyJQScale returns replicas calculated by jqFilter from the Scale object.
+func applyJQScale(jqFilter string, scale *unstructured.Unstructured) (int32, error) {
+	scaleBytes, err := scale.MarshalJSON()
+	if err != nil {
+		return 0, err
+	}
+
+	filterResult, err := jq.ApplyJqFilter(jqFilter, scaleBytes, app.JqLibraryPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply jqFilter:\n%s\nto Scale:\n%s\nerror: %s", jqFilter, scaleBytes, err)
+	}
+
+	var replicas float64
+	err = json.Unmarshal([]byte(strings.TrimSpace(filterResult)), &replicas)
+	if err != nil {
+		return 0, fmt.Errorf("jqFilter '%s' should return a number, got '%s'", jqFilter, strings.TrimSpace(filterResult))
+	}
+	if replicas != math.Trunc(replicas) || replicas > math.MaxInt32 {
+		return 0, fmt.Errorf("jqFilter '%s' should return an integer, got %v", jqFilter, replicas)
+	}
+
+	return int32(replicas), nil
+}
+
 func generateSubresources(subresource string) (ret []string) {
 	if subresource != "" {
 		ret = append(ret, subresource)
@@ -142,3 +195,46 @@ func convertPatchToBytes(patch interface{}) ([]byte, error) {
 	}
 	return patchBytes, nil
 }
+
+// RestartedAtAnnotation is set in the Pod template by 'kubectl rollout restart'.
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// rolloutComplete checks the status of Deployment, StatefulSet or DaemonSet
+// the same way as 'kubectl rollout status'.
+func rolloutComplete(obj *unstructured.Unstructured, generation int64) bool {
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observedGeneration < generation {
+		return false
+	}
+
+	status := func(field string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+		return v
+	}
+
+	switch obj.GetKind() {
+	case "Deployment":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		return status("updatedReplicas") >= replicas &&
+			status("replicas") == status("updatedReplicas") &&
+			status("availableReplicas") == status("updatedReplicas")
+	case "StatefulSet":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		currentRevision, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		updateRevision, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		return status("updatedReplicas") >= replicas &&
+			status("readyReplicas") >= replicas &&
+			currentRevision == updateRevision
+	case "DaemonSet":
+		desired := status("desiredNumberScheduled")
+		return status("updatedNumberScheduled") >= desired &&
+			status("numberAvailable") >= desired
+	}
+	return true
+}
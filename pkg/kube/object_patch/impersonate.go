@@ -0,0 +1,65 @@
+package object_patch
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// restConfigProvider is implemented by clients that can share their configuration.
+type restConfigProvider interface {
+	RestConfig() *rest.Config
+}
+
+// impersonatedClient sends requests as another user. Discovery is
+// delegated to the base client to share its cache.
+type impersonatedClient struct {
+	kubernetes.Interface
+	dynamic dynamic.Interface
+	base    KubeClient
+}
+
+func (c *impersonatedClient) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
+func (c *impersonatedClient) GroupVersionResource(apiVersion string, kind string) (schema.GroupVersionResource, error) {
+	return c.base.GroupVersionResource(apiVersion, kind)
+}
+
+// Impersonate returns an ObjectPatcher that executes operations as the user with groups.
+func (o *ObjectPatcher) Impersonate(user string, groups []string) (*ObjectPatcher, error) {
+	provider, ok := o.kubeClient.(restConfigProvider)
+	if !ok || provider.RestConfig() == nil {
+		return nil, fmt.Errorf("impersonate '%s': kube client has no rest config", user)
+	}
+
+	config := rest.CopyConfig(provider.RestConfig())
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   groups,
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate '%s': %v", user, err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate '%s': %v", user, err)
+	}
+
+	return &ObjectPatcher{
+		kubeClient: &impersonatedClient{
+			Interface: clientset,
+			dynamic:   dynamicClient,
+			base:      o.kubeClient,
+		},
+		logger: o.logger.WithField("impersonate", user),
+	}, nil
+}
+
+var _ KubeClient = (*impersonatedClient)(nil)
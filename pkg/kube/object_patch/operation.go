@@ -2,6 +2,7 @@ package object_patch
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	log "github.com/sirupsen/logrus"
@@ -23,6 +24,11 @@ type OperationSpec struct {
 	JQFilter   string      `json:"jqFilter,omitempty" yaml:"jqFilter,omitempty"`
 	MergePatch interface{} `json:"mergePatch,omitempty" yaml:"mergePatch,omitempty"`
 	JSONPatch  interface{} `json:"jsonPatch,omitempty" yaml:"jsonPatch,omitempty"`
+	Replicas   *int32      `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+
+	// Wait for the result of the operation, e.g. for the Pod deletion after eviction.
+	Wait        bool   `json:"wait,omitempty" yaml:"wait,omitempty"`
+	WaitTimeout string `json:"waitTimeout,omitempty" yaml:"waitTimeout,omitempty"`
 
 	IgnoreMissingObject bool `json:"ignoreMissingObject" yaml:"ignoreMissingObject"`
 	IgnoreHookError     bool `json:"ignoreHookError" yaml:"ignoreHookError"`
@@ -42,6 +48,11 @@ const (
 	JQPatch    OperationType = "JQPatch"
 	MergePatch OperationType = "MergePatch"
 	JSONPatch  OperationType = "JSONPatch"
+
+	Scale OperationType = "Scale"
+	Evict OperationType = "Evict"
+
+	RolloutRestart OperationType = "RolloutRestart"
 )
 
 // GetPatchStatusOperationsOnHookError returns list of Patch/Filter operations eligible for execution on Hook Error
@@ -96,6 +107,12 @@ func ParseOperations(specBytes []byte) ([]Operation, error) {
 // - patchOperation to modify object via Patch API call. patchType should be set. patch can be string, []byte or map[string]interface{}
 //
 // - filterOperation to modify object via Get-filter-Update process. filterFunc should be set.
+//
+// - scaleOperation to change replicas via the scale subresource. replicasFunc should be set.
+//
+// - evictOperation to evict Pod via Eviction API.
+//
+// - rolloutRestartOperation to restart Pods of Deployment, StatefulSet or DaemonSet.
 type Operation interface {
 	Description() string
 }
@@ -106,6 +123,9 @@ type createOperation struct {
 
 	ignoreIfExists bool
 	updateIfExists bool
+
+	// resultCh receives the result of the operation. It is optional.
+	resultCh chan<- OperationResult
 }
 
 func (op *createOperation) Description() string {
@@ -165,6 +185,54 @@ func (op *filterOperation) Description() string {
 	return fmt.Sprintf("Filter object %s/%s/%s/%s", op.apiVersion, op.kind, op.namespace, op.name)
 }
 
+type scaleOperation struct {
+	// Object coordinates.
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+
+	// replicasFunc returns desired replicas for the current autoscaling/v1 Scale object.
+	replicasFunc        func(scale *unstructured.Unstructured) (int32, error)
+	ignoreMissingObject bool
+}
+
+func (op *scaleOperation) Description() string {
+	return fmt.Sprintf("Scale object %s/%s/%s/%s", op.apiVersion, op.kind, op.namespace, op.name)
+}
+
+type evictOperation struct {
+	// Pod coordinates.
+	namespace string
+	name      string
+
+	// Wait for the Pod deletion.
+	wait                bool
+	waitTimeout         time.Duration
+	ignoreMissingObject bool
+}
+
+func (op *evictOperation) Description() string {
+	return fmt.Sprintf("Evict Pod %s/%s", op.namespace, op.name)
+}
+
+type rolloutRestartOperation struct {
+	// Object coordinates.
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+
+	// Wait for the rollout completion.
+	wait                bool
+	waitTimeout         time.Duration
+	ignoreMissingObject bool
+}
+
+func (op *rolloutRestartOperation) Description() string {
+	return fmt.Sprintf("Rollout restart object %s/%s/%s/%s", op.apiVersion, op.kind, op.namespace, op.name)
+}
+
 func NewFromOperationSpec(spec OperationSpec) Operation {
 	switch spec.Operation {
 	case Create:
@@ -213,6 +281,32 @@ func NewFromOperationSpec(spec OperationSpec) Operation {
 			WithIgnoreMissingObject(spec.IgnoreMissingObject),
 			WithIgnoreHookError(spec.IgnoreHookError),
 		)
+	case Scale:
+		if spec.Replicas != nil {
+			return NewScaleOperation(*spec.Replicas,
+				spec.ApiVersion, spec.Kind, spec.Namespace, spec.Name,
+				WithIgnoreMissingObject(spec.IgnoreMissingObject),
+			)
+		}
+		return NewScaleFilterOperation(
+			func(scale *unstructured.Unstructured) (int32, error) {
+				return applyJQScale(spec.JQFilter, scale)
+			},
+			spec.ApiVersion, spec.Kind, spec.Namespace, spec.Name,
+			WithIgnoreMissingObject(spec.IgnoreMissingObject),
+		)
+	case Evict:
+		options := []EvictOption{WithIgnoreMissingObject(spec.IgnoreMissingObject)}
+		if spec.Wait {
+			options = append(options, WithWait(parseWaitTimeout(spec.WaitTimeout)))
+		}
+		return NewEvictOperation(spec.Namespace, spec.Name, options...)
+	case RolloutRestart:
+		options := []RolloutRestartOption{WithIgnoreMissingObject(spec.IgnoreMissingObject)}
+		if spec.Wait {
+			options = append(options, WithWait(parseWaitTimeout(spec.WaitTimeout)))
+		}
+		return NewRolloutRestartOperation(spec.ApiVersion, spec.Kind, spec.Namespace, spec.Name, options...)
 	}
 
 	// Should not be reached!
@@ -286,3 +380,55 @@ func NewFilterPatchOperation(filter func(*unstructured.Unstructured) (*unstructu
 	}
 	return op
 }
+
+// NewScaleOperation sets replicas of Deployment, StatefulSet, ReplicaSet or
+// any custom resource with the scale subresource.
+func NewScaleOperation(replicas int32, apiVersion, kind, namespace, name string, options ...ScaleOption) Operation {
+	return NewScaleFilterOperation(func(_ *unstructured.Unstructured) (int32, error) {
+		return replicas, nil
+	}, apiVersion, kind, namespace, name, options...)
+}
+
+// NewScaleFilterOperation sets replicas calculated from the current autoscaling/v1 Scale object.
+func NewScaleFilterOperation(replicasFunc func(scale *unstructured.Unstructured) (int32, error), apiVersion, kind, namespace, name string, options ...ScaleOption) Operation {
+	op := &scaleOperation{
+		apiVersion:   apiVersion,
+		kind:         kind,
+		namespace:    namespace,
+		name:         name,
+		replicasFunc: replicasFunc,
+	}
+	for _, option := range options {
+		option.applyToScale(op)
+	}
+	return op
+}
+
+// NewEvictOperation evicts the Pod. Eviction respects PodDisruptionBudgets:
+// an error is returned if the eviction is not allowed.
+func NewEvictOperation(namespace, name string, options ...EvictOption) Operation {
+	op := &evictOperation{
+		namespace: namespace,
+		name:      name,
+	}
+	for _, option := range options {
+		option.applyToEvict(op)
+	}
+	return op
+}
+
+// NewRolloutRestartOperation restarts Pods of Deployment, StatefulSet or DaemonSet
+// the same way as 'kubectl rollout restart': it sets the restartedAt annotation
+// in the Pod template.
+func NewRolloutRestartOperation(apiVersion, kind, namespace, name string, options ...RolloutRestartOption) Operation {
+	op := &rolloutRestartOperation{
+		apiVersion: apiVersion,
+		kind:       kind,
+		namespace:  namespace,
+		name:       name,
+	}
+	for _, option := range options {
+		option.applyToRolloutRestart(op)
+	}
+	return op
+}
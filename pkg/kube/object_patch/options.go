@@ -1,6 +1,8 @@
 package object_patch
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -20,6 +22,18 @@ type FilterOption interface {
 	applyToFilter(operation *filterOperation)
 }
 
+type ScaleOption interface {
+	applyToScale(operation *scaleOperation)
+}
+
+type EvictOption interface {
+	applyToEvict(operation *evictOperation)
+}
+
+type RolloutRestartOption interface {
+	applyToRolloutRestart(operation *rolloutRestartOperation)
+}
+
 type subresourceHolder struct {
 	subresource string
 }
@@ -70,7 +84,7 @@ func (i *ignoreHookError) applyToFilter(operation *filterOperation) {
 	operation.ignoreHookError = i.ignoreError
 }
 
-// IgnoreMissingObject do not return error if object exists for Patch and Filter operations.
+// IgnoreMissingObject do not return error if object exists for Patch, Filter, Scale, Evict and RolloutRestart operations.
 func IgnoreMissingObject() *ignoreMissingObject {
 	return WithIgnoreMissingObject(true)
 }
@@ -87,6 +101,18 @@ func (i *ignoreMissingObject) applyToFilter(operation *filterOperation) {
 	operation.ignoreMissingObject = i.ignore
 }
 
+func (i *ignoreMissingObject) applyToScale(operation *scaleOperation) {
+	operation.ignoreMissingObject = i.ignore
+}
+
+func (i *ignoreMissingObject) applyToEvict(operation *evictOperation) {
+	operation.ignoreMissingObject = i.ignore
+}
+
+func (i *ignoreMissingObject) applyToRolloutRestart(operation *rolloutRestartOperation) {
+	operation.ignoreMissingObject = i.ignore
+}
+
 type ignoreIfExists struct {
 	ignore bool
 }
@@ -135,3 +161,39 @@ func InBackground() DeleteOption {
 func NonCascading() DeleteOption {
 	return &deletePropogation{propagation: metav1.DeletePropagationOrphan}
 }
+
+// DefaultWaitTimeout is used if the timeout is not set for WithWait.
+const DefaultWaitTimeout = 5 * time.Minute
+
+type waitOption struct {
+	timeout time.Duration
+}
+
+// WithWait is an option for Evict to wait for the Pod deletion and
+// for RolloutRestart to wait for the rollout completion.
+// DefaultWaitTimeout is used if timeout is zero.
+func WithWait(timeout time.Duration) *waitOption {
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+	return &waitOption{timeout: timeout}
+}
+
+func (w *waitOption) applyToEvict(operation *evictOperation) {
+	operation.wait = true
+	operation.waitTimeout = w.timeout
+}
+
+func (w *waitOption) applyToRolloutRestart(operation *rolloutRestartOperation) {
+	operation.wait = true
+	operation.waitTimeout = w.timeout
+}
+
+// parseWaitTimeout returns zero for empty or invalid value. Value is validated by schema.
+func parseWaitTimeout(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(s)
+	return d
+}
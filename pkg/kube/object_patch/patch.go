@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
 	gerror "github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,7 +20,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/util/retry"
+
+	"github.com/flant/shell-operator/pkg/kube/api_errors"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
+	"github.com/flant/shell-operator/pkg/tracing"
 )
 
 type ObjectPatcher struct {
@@ -40,82 +45,160 @@ func NewObjectPatcher(kubeClient KubeClient) *ObjectPatcher {
 }
 
 func (o *ObjectPatcher) ExecuteOperations(ops []Operation) error {
+	return o.ExecuteOperationsContext(context.Background(), ops)
+}
+
+// ExecuteOperationsContext executes operations with a span for each operation.
+// Spans are children of the span in ctx.
+func (o *ObjectPatcher) ExecuteOperationsContext(ctx context.Context, ops []Operation) error {
+	_, err := o.ExecuteOperationsWithResults(ctx, ops)
+	return err
+}
+
+// ExecuteOperationsWithResults executes operations and returns results of Create operations,
+// e.g. to report names generated by the server for objects with metadata.generateName.
+func (o *ObjectPatcher) ExecuteOperationsWithResults(ctx context.Context, ops []Operation) ([]OperationResult, error) {
 	log.Debug("Starting execute operations process")
 	defer log.Debug("Finished execute operations process")
 
+	results := make([]OperationResult, 0)
 	applyErrors := &multierror.Error{}
 	for _, op := range ops {
 		log.Debugf("Applying operation: %s", op.Description())
-		if err := o.ExecuteOperation(op); err != nil {
+		var attrs map[string]string
+		if tracing.Enabled() {
+			attrs = operationSpanAttributes(op)
+		}
+		_, span := tracing.Start(ctx, operationSpanName(op), attrs)
+		if read_only.Skip("object_patch", strings.TrimPrefix(operationSpanName(op), "object_patch."), op.Description()) {
+			if createOp, ok := op.(*createOperation); ok {
+				createOp.sendResult(OperationResult{Operation: Create, Error: "skipped in the read-only mode"})
+			}
+			span.End()
+			continue
+		}
+		result, err := o.executeOperation(op)
+		if err != nil {
 			err = gerror.WithMessage(err, op.Description())
+			span.RecordError(err)
 			applyErrors = multierror.Append(applyErrors, err)
 		}
+		if result != nil {
+			if err != nil {
+				result.Error = err.Error()
+			}
+			if createOp, ok := op.(*createOperation); ok {
+				createOp.sendResult(*result)
+			}
+			results = append(results, *result)
+		}
+		span.End()
 	}
 
-	return applyErrors.ErrorOrNil()
+	return results, applyErrors.ErrorOrNil()
 }
 
 func (o *ObjectPatcher) ExecuteOperation(operation Operation) error {
+	_, err := o.executeOperation(operation)
+	return err
+}
+
+// executeOperation returns a result for Create operations. NotFound and Forbidden
+// API errors are wrapped with api_errors.ErrNotFound and api_errors.ErrForbidden.
+func (o *ObjectPatcher) executeOperation(operation Operation) (*OperationResult, error) {
 	if operation == nil {
-		return nil
+		return nil, nil
 	}
 
 	switch v := operation.(type) {
 	case *createOperation:
-		return o.executeCreateOperation(v)
+		result, err := o.executeCreateOperation(v)
+		return result, api_errors.Wrap(err)
+	}
+	return nil, api_errors.Wrap(o.executeModifyOperation(operation))
+}
+
+func (o *ObjectPatcher) executeModifyOperation(operation Operation) error {
+	switch v := operation.(type) {
 	case *deleteOperation:
 		return o.executeDeleteOperation(v)
 	case *patchOperation:
 		return o.executePatchOperation(v)
 	case *filterOperation:
 		return o.executeFilterOperation(v)
+	case *scaleOperation:
+		return o.executeScaleOperation(v)
+	case *evictOperation:
+		return o.executeEvictOperation(v)
+	case *rolloutRestartOperation:
+		return o.executeRolloutRestartOperation(v)
 	}
 
 	return nil
 }
 
-func (o *ObjectPatcher) executeCreateOperation(op *createOperation) error {
+// executeCreateOperation creates the object. Objects with metadata.generateName and
+// without metadata.name get a name from the server, it is returned in the result.
+func (o *ObjectPatcher) executeCreateOperation(op *createOperation) (*OperationResult, error) {
 	if op.object == nil {
-		return fmt.Errorf("cannot create empty object")
+		return nil, fmt.Errorf("cannot create empty object")
 	}
 
 	// Convert object from interface{}.
 	object, err := toUnstructured(op.object)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	apiVersion := object.GetAPIVersion()
 	kind := object.GetKind()
 
+	result := &OperationResult{
+		Operation:    op.operationType(),
+		ApiVersion:   apiVersion,
+		Kind:         kind,
+		Namespace:    object.GetNamespace(),
+		Name:         object.GetName(),
+		GenerateName: object.GetGenerateName(),
+	}
+
 	wrapErr := func(e error) error {
-		objectID := fmt.Sprintf("%s/%s/%s/%s", apiVersion, kind, object.GetNamespace(), object.GetName())
+		name := object.GetName()
+		if name == "" && object.GetGenerateName() != "" {
+			name = object.GetGenerateName() + "*"
+		}
+		objectID := fmt.Sprintf("%s/%s/%s/%s", apiVersion, kind, object.GetNamespace(), name)
 		return gerror.WithMessage(e, objectID)
 	}
 
 	gvk, err := o.kubeClient.GroupVersionResource(apiVersion, kind)
 	if err != nil {
-		return wrapErr(err)
+		return result, wrapErr(err)
 	}
 
 	log.Debug("Started Create API call")
-	_, err = o.kubeClient.Dynamic().
+	created, err := o.kubeClient.Dynamic().
 		Resource(gvk).
 		Namespace(object.GetNamespace()).
 		Create(context.TODO(), object, metav1.CreateOptions{}, generateSubresources(op.subresource)...)
 	log.Debug("Finished Create API call")
+	if err == nil && created != nil {
+		result.Name = created.GetName()
+		result.UID = string(created.GetUID())
+		result.Created = true
+	}
 
 	objectExists := errors.IsAlreadyExists(err)
 
 	if objectExists && op.ignoreIfExists {
 		log.Debug("resource already exists, exiting without error")
-		return nil
+		return result, nil
 	}
 
 	if objectExists && op.updateIfExists {
 		log.Debug("Object already exists, attempting to Update it with optimistic lock")
 
-		return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		return result, api_errors.RetryOnConflict(func() error {
 			log.Debug("Started Get API call")
 			existingObj, err := o.kubeClient.Dynamic().
 				Resource(gvk).
@@ -130,17 +213,20 @@ func (o *ObjectPatcher) executeCreateOperation(op *createOperation) error {
 			objCopy.SetResourceVersion(existingObj.GetResourceVersion())
 
 			log.Debug("Started Update API call")
-			_, err = o.kubeClient.Dynamic().
+			updated, err := o.kubeClient.Dynamic().
 				Resource(gvk).
 				Namespace(objCopy.GetNamespace()).
 				Update(context.TODO(), objCopy, metav1.UpdateOptions{}, generateSubresources(op.subresource)...)
 			log.Debug("Finished Update API call")
+			if err == nil && updated != nil {
+				result.UID = string(updated.GetUID())
+			}
 			return wrapErr(err)
 		})
 	}
 
 	// Simply return result of a Create call if no ignore options are in play.
-	return wrapErr(err)
+	return result, wrapErr(err)
 }
 
 // executePatchOperation applies a patch to the specified object using API call Patch.
@@ -208,7 +294,7 @@ func (o *ObjectPatcher) executeFilterOperation(op *filterOperation) error {
 		return err
 	}
 
-	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+	err = api_errors.RetryOnConflict(func() error {
 		log.Debug("Started Get API call")
 		obj, err := o.kubeClient.Dynamic().
 			Resource(gvk).
@@ -255,6 +341,203 @@ func (o *ObjectPatcher) executeFilterOperation(op *filterOperation) error {
 	return err
 }
 
+// executeScaleOperation gets the scale subresource of the object, calculates
+// desired replicas and updates the subresource if replicas are changed.
+//
+// Options:
+// - IgnoreMissingObject — do not return error if the specified object is missing.
+func (o *ObjectPatcher) executeScaleOperation(op *scaleOperation) error {
+	if op.replicasFunc == nil {
+		return fmt.Errorf("ReplicasFunc is nil")
+	}
+
+	gvk, err := o.kubeClient.GroupVersionResource(op.apiVersion, op.kind)
+	if err != nil {
+		return err
+	}
+
+	return api_errors.RetryOnConflict(func() error {
+		log.Debug("Started Get API call")
+		scale, err := o.kubeClient.Dynamic().
+			Resource(gvk).
+			Namespace(op.namespace).
+			Get(context.TODO(), op.name, metav1.GetOptions{}, "scale")
+		log.Debug("Finished Get API call")
+		if op.ignoreMissingObject && errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		replicas, err := op.replicasFunc(scale)
+		if err != nil {
+			return err
+		}
+		if replicas < 0 {
+			return fmt.Errorf("replicas should not be negative, got %d", replicas)
+		}
+
+		current, _, err := unstructured.NestedInt64(scale.Object, "spec", "replicas")
+		if err != nil {
+			return fmt.Errorf("get current replicas: %v", err)
+		}
+		if current == int64(replicas) {
+			return nil
+		}
+
+		err = unstructured.SetNestedField(scale.Object, int64(replicas), "spec", "replicas")
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Started Update API call")
+		_, err = o.kubeClient.Dynamic().
+			Resource(gvk).
+			Namespace(op.namespace).
+			Update(context.TODO(), scale, metav1.UpdateOptions{}, "scale")
+		log.Debug("Finished Update API call")
+		return err
+	})
+}
+
+// executeEvictOperation creates an Eviction for the Pod. The API server rejects
+// the eviction with 429 status if it violates a PodDisruptionBudget.
+//
+// Options:
+// - WithWait — wait until the Pod is deleted or recreated with another UID.
+// - IgnoreMissingObject — do not return error if the specified Pod is missing.
+func (o *ObjectPatcher) executeEvictOperation(op *evictOperation) error {
+	log.Debug("Started Get API call")
+	pod, err := o.kubeClient.CoreV1().Pods(op.namespace).Get(context.TODO(), op.name, metav1.GetOptions{})
+	log.Debug("Finished Get API call")
+	if op.ignoreMissingObject && errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: op.namespace,
+			Name:      op.name,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &pod.UID},
+		},
+	}
+
+	log.Debug("Started Evict API call")
+	err = o.kubeClient.PolicyV1().Evictions(op.namespace).Evict(context.TODO(), eviction)
+	log.Debug("Finished Evict API call")
+	if op.ignoreMissingObject && errors.IsNotFound(err) {
+		return nil
+	}
+	if errors.IsTooManyRequests(err) {
+		return fmt.Errorf("eviction is not allowed, possibly by PodDisruptionBudget: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !op.wait {
+		return nil
+	}
+
+	log.Debug("Waiting for Pod deletion")
+
+	err = wait.PollUntilContextTimeout(context.TODO(), time.Second, op.waitTimeout, true, func(ctx context.Context) (done bool, err error) {
+		log.Debug("Started Get API call")
+		current, err := o.kubeClient.CoreV1().Pods(op.namespace).Get(ctx, op.name, metav1.GetOptions{})
+		log.Debug("Finished Get API call")
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		// Pod is recreated with the same name, e.g. by StatefulSet.
+		return current.UID != pod.UID, nil
+	})
+	if err != nil {
+		return fmt.Errorf("wait for Pod deletion: %v", err)
+	}
+	return nil
+}
+
+// executeRolloutRestartOperation sets the restartedAt annotation in the Pod
+// template to trigger a rollout.
+//
+// Options:
+// - WithWait — wait until the rollout is complete.
+// - IgnoreMissingObject — do not return error if the specified object is missing.
+func (o *ObjectPatcher) executeRolloutRestartOperation(op *rolloutRestartOperation) error {
+	switch op.kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return fmt.Errorf("rollout restart is not supported for kind '%s'", op.kind)
+	}
+
+	gvk, err := o.kubeClient.GroupVersionResource(op.apiVersion, op.kind)
+	if err != nil {
+		return err
+	}
+
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						RestartedAtAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	}
+	patchBytes, err := convertPatchToBytes(patch)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Started Patch API call")
+	obj, err := o.kubeClient.Dynamic().
+		Resource(gvk).
+		Namespace(op.namespace).
+		Patch(context.TODO(), op.name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	log.Debug("Finished Patch API call")
+	if op.ignoreMissingObject && errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !op.wait {
+		return nil
+	}
+
+	log.Debug("Waiting for rollout completion")
+
+	generation := obj.GetGeneration()
+	err = wait.PollUntilContextTimeout(context.TODO(), time.Second, op.waitTimeout, true, func(ctx context.Context) (done bool, err error) {
+		log.Debug("Started Get API call")
+		obj, err := o.kubeClient.Dynamic().
+			Resource(gvk).
+			Namespace(op.namespace).
+			Get(ctx, op.name, metav1.GetOptions{})
+		log.Debug("Finished Get API call")
+		if err != nil {
+			return false, err
+		}
+		return rolloutComplete(obj, generation), nil
+	})
+	if err != nil {
+		return fmt.Errorf("wait for rollout of %s/%s/%s: %v", op.kind, op.namespace, op.name, err)
+	}
+	return nil
+}
+
 func (o *ObjectPatcher) executeDeleteOperation(op *deleteOperation) error {
 	gvk, err := o.kubeClient.GroupVersionResource(op.apiVersion, op.kind)
 	if err != nil {
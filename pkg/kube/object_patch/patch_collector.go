@@ -21,6 +21,7 @@ func NewPatchCollector() *PatchCollector {
 //   - WithSubresource - create a specified subresource
 //   - IgnoreIfExists - do not return error if the specified object exists
 //   - UpdateIfExists - call Update if the specified object exists
+//   - WithResultChannel - receive the result of the operation, e.g. a name generated for metadata.generateName
 func (dop *PatchCollector) Create(object interface{}, options ...CreateOption) {
 	dop.add(NewCreateOperation(object, options...))
 }
@@ -75,6 +76,46 @@ func (dop *PatchCollector) Filter(
 	dop.add(NewFilterPatchOperation(filterFunc, apiVersion, kind, namespace, name, options...))
 }
 
+// Scale sets replicas of the object using the scale subresource.
+//
+// Options:
+//   - IgnoreMissingObject — do not return error if the specified object is missing.
+func (dop *PatchCollector) Scale(replicas int32, apiVersion, kind, namespace, name string, options ...ScaleOption) {
+	dop.add(NewScaleOperation(replicas, apiVersion, kind, namespace, name, options...))
+}
+
+// ScaleFilter sets replicas calculated by replicasFunc from the current
+// autoscaling/v1 Scale object, e.g. to add one replica.
+//
+// Options:
+//   - IgnoreMissingObject — do not return error if the specified object is missing.
+func (dop *PatchCollector) ScaleFilter(
+	replicasFunc func(scale *unstructured.Unstructured) (int32, error),
+	apiVersion, kind, namespace, name string, options ...ScaleOption,
+) {
+	dop.add(NewScaleFilterOperation(replicasFunc, apiVersion, kind, namespace, name, options...))
+}
+
+// Evict evicts the Pod using Eviction API. PodDisruptionBudgets are respected:
+// an error is returned if the eviction is not allowed.
+//
+// Options:
+//   - WithWait — wait for the Pod deletion.
+//   - IgnoreMissingObject — do not return error if the specified Pod is missing.
+func (dop *PatchCollector) Evict(namespace, name string, options ...EvictOption) {
+	dop.add(NewEvictOperation(namespace, name, options...))
+}
+
+// RolloutRestart restarts Pods of Deployment, StatefulSet or DaemonSet
+// like 'kubectl rollout restart'.
+//
+// Options:
+//   - WithWait — wait for the rollout completion.
+//   - IgnoreMissingObject — do not return error if the specified object is missing.
+func (dop *PatchCollector) RolloutRestart(apiVersion, kind, namespace, name string, options ...RolloutRestartOption) {
+	dop.add(NewRolloutRestartOperation(apiVersion, kind, namespace, name, options...))
+}
+
 // Operations returns all collected operations
 func (dop *PatchCollector) Operations() []Operation {
 	return dop.patchOperations
@@ -65,6 +65,36 @@ func Test_ParseOperations(t *testing.T) {
 			"testdata/serialized_operations/invalid_patch.yaml",
 			shouldBeError,
 		},
+		{
+			"valid scale",
+			"testdata/serialized_operations/valid_scale.yaml",
+			shouldNotBeError,
+		},
+		{
+			"invalid scale",
+			"testdata/serialized_operations/invalid_scale.yaml",
+			shouldBeError,
+		},
+		{
+			"valid evict",
+			"testdata/serialized_operations/valid_evict.yaml",
+			shouldNotBeError,
+		},
+		{
+			"invalid evict",
+			"testdata/serialized_operations/invalid_evict.yaml",
+			shouldBeError,
+		},
+		{
+			"valid rollout restart",
+			"testdata/serialized_operations/valid_rollout_restart.yaml",
+			shouldNotBeError,
+		},
+		{
+			"invalid rollout restart",
+			"testdata/serialized_operations/invalid_rollout_restart.yaml",
+			shouldBeError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -946,3 +976,24 @@ func existObject(t *testing.T, cluster *fake.Cluster, ns string, objYAML string)
 	require.NoError(t, err)
 	return obj != nil
 }
+
+func Test_OperationSpanAttributes(t *testing.T) {
+	op := NewMergePatchOperation(map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"a": "b"}}},
+		"v1", "Pod", "default", "pod-1", WithSubresource("status"))
+	require.Equal(t, "object_patch.patch", operationSpanName(op))
+	require.Equal(t, map[string]string{
+		"apiVersion":  "v1",
+		"kind":        "Pod",
+		"namespace":   "default",
+		"name":        "pod-1",
+		"subresource": "status",
+	}, operationSpanAttributes(op))
+
+	create := NewCreateOperation(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": "ns-1"},
+	})
+	require.Equal(t, "object_patch.create", operationSpanName(create))
+	require.Equal(t, map[string]string{"apiVersion": "v1", "kind": "Namespace", "name": "ns-1"}, operationSpanAttributes(create))
+}
@@ -0,0 +1,57 @@
+package object_patch
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// OperationResult is a result of the Create operation. Name is the name of the
+// created object, it is generated by the server if metadata.generateName is used.
+type OperationResult struct {
+	Operation    OperationType `json:"operation"`
+	ApiVersion   string        `json:"apiVersion"`
+	Kind         string        `json:"kind"`
+	Namespace    string        `json:"namespace,omitempty"`
+	Name         string        `json:"name,omitempty"`
+	GenerateName string        `json:"generateName,omitempty"`
+	UID          string        `json:"uid,omitempty"`
+	// Created is false if the object already exists.
+	Created bool   `json:"created"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (op *createOperation) operationType() OperationType {
+	switch {
+	case op.ignoreIfExists:
+		return CreateIfNotExists
+	case op.updateIfExists:
+		return CreateOrUpdate
+	}
+	return Create
+}
+
+// sendResult sends the result to the channel without blocking.
+func (op *createOperation) sendResult(result OperationResult) {
+	if op.resultCh == nil {
+		return
+	}
+	select {
+	case op.resultCh <- result:
+	default:
+		log.Warnf("Result of %s %s/%s is dropped: channel is full", result.Operation, result.Kind, result.Name)
+	}
+}
+
+type resultChannel struct {
+	ch chan<- OperationResult
+}
+
+// WithResultChannel is an option for Create to receive the result of the operation,
+// e.g. the name generated by the server for an object with metadata.generateName.
+// The result is dropped if the channel is not ready, so use a buffered channel.
+func WithResultChannel(ch chan<- OperationResult) CreateOption {
+	return &resultChannel{ch: ch}
+}
+
+func (r *resultChannel) applyToCreate(operation *createOperation) {
+	operation.resultCh = r.ch
+}
@@ -0,0 +1,84 @@
+package object_patch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_CreateOperation_Results(t *testing.T) {
+	const namespace = "default"
+	cluster := newFakeClusterWithNamespaceAndObjects(t, namespace, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+data:
+  foo: "bar"
+`)
+
+	// Fake client does not generate names, so emulate the API server.
+	cluster.Client.Dynamic().(*fakedynamic.FakeDynamicClient).PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if obj.GetName() == "" && obj.GetGenerateName() != "" {
+			obj.SetName(obj.GetGenerateName() + "x7k2p")
+		}
+		return false, nil, nil
+	})
+
+	resultCh := make(chan OperationResult, 1)
+
+	operations, err := ParseOperations([]byte(`
+- operation: Create
+  object:
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      namespace: default
+      generateName: job-
+- operation: CreateIfNotExists
+  object:
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      namespace: default
+      name: existing
+- operation: MergePatch
+  kind: ConfigMap
+  namespace: default
+  name: existing
+  mergePatch:
+    data:
+      foo: baz
+`))
+	require.NoError(t, err)
+	operations = append(operations, NewCreateOperation(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"namespace": namespace, "generateName": "go-"},
+	}, WithResultChannel(resultCh)))
+
+	patcher := NewObjectPatcher(cluster.Client)
+	results, err := patcher.ExecuteOperationsWithResults(context.Background(), operations)
+	require.NoError(t, err)
+
+	// Only Create operations have results.
+	require.Len(t, results, 3)
+
+	require.Equal(t, Create, results[0].Operation)
+	require.Equal(t, "job-", results[0].GenerateName)
+	require.Equal(t, "job-x7k2p", results[0].Name)
+	require.True(t, results[0].Created)
+
+	require.Equal(t, CreateIfNotExists, results[1].Operation)
+	require.Equal(t, "existing", results[1].Name)
+	require.False(t, results[1].Created)
+
+	require.Equal(t, "go-x7k2p", results[2].Name)
+	require.Equal(t, results[2], <-resultCh)
+}
@@ -0,0 +1,157 @@
+package object_patch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/flant/kube-client/manifest"
+)
+
+func Test_RolloutRestartOperation(t *testing.T) {
+	const (
+		namespace  = "default"
+		deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+status:
+  replicas: 2
+  updatedReplicas: 2
+  availableReplicas: 2
+`
+	)
+
+	cluster := newFakeClusterWithNamespaceAndObjects(t, namespace, deployment)
+	patcher := NewObjectPatcher(cluster.Client)
+
+	restartedAt := func() string {
+		gvr := cluster.MustFindGVR("apps/v1", "Deployment")
+		obj, err := cluster.Client.Dynamic().Resource(*gvr).Namespace(namespace).Get(context.TODO(), "web", metav1.GetOptions{})
+		require.NoError(t, err)
+		annotations, _, err := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "annotations")
+		require.NoError(t, err)
+		return annotations[RestartedAtAnnotation]
+	}
+
+	require.Empty(t, restartedAt())
+
+	operations, err := ParseOperations([]byte(`
+operation: RolloutRestart
+apiVersion: apps/v1
+kind: Deployment
+namespace: default
+name: web
+wait: true
+waitTimeout: 10s
+`))
+	require.NoError(t, err)
+	require.NoError(t, patcher.ExecuteOperations(operations))
+	require.NotEmpty(t, restartedAt())
+
+	// Missing object.
+	err = patcher.ExecuteOperation(NewRolloutRestartOperation("apps/v1", "Deployment", namespace, "api"))
+	require.Error(t, err)
+
+	err = patcher.ExecuteOperation(NewRolloutRestartOperation("apps/v1", "Deployment", namespace, "api", IgnoreMissingObject()))
+	require.NoError(t, err)
+
+	// Unsupported kind.
+	err = patcher.ExecuteOperation(NewRolloutRestartOperation("v1", "ConfigMap", namespace, "web"))
+	require.Error(t, err)
+}
+
+func Test_rolloutComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      string
+		expected bool
+	}{
+		{
+			"deployment is updated",
+			`
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, generation: 2}
+spec: {replicas: 3}
+status: {observedGeneration: 2, replicas: 3, updatedReplicas: 3, availableReplicas: 3}
+`,
+			true,
+		},
+		{
+			"deployment has old replicas",
+			`
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, generation: 2}
+spec: {replicas: 3}
+status: {observedGeneration: 2, replicas: 4, updatedReplicas: 3, availableReplicas: 3}
+`,
+			false,
+		},
+		{
+			"generation is not observed",
+			`
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, generation: 2}
+spec: {replicas: 3}
+status: {observedGeneration: 1, replicas: 3, updatedReplicas: 3, availableReplicas: 3}
+`,
+			false,
+		},
+		{
+			"statefulset revision is not updated",
+			`
+apiVersion: apps/v1
+kind: StatefulSet
+metadata: {name: db, generation: 2}
+spec: {replicas: 2}
+status: {observedGeneration: 2, updatedReplicas: 2, readyReplicas: 2, currentRevision: db-1, updateRevision: db-2}
+`,
+			false,
+		},
+		{
+			"daemonset is updated",
+			`
+apiVersion: apps/v1
+kind: DaemonSet
+metadata: {name: agent, generation: 2}
+status: {observedGeneration: 2, desiredNumberScheduled: 5, updatedNumberScheduled: 5, numberAvailable: 5}
+`,
+			true,
+		},
+		{
+			"daemonset is not available",
+			`
+apiVersion: apps/v1
+kind: DaemonSet
+metadata: {name: agent, generation: 2}
+status: {observedGeneration: 2, desiredNumberScheduled: 5, updatedNumberScheduled: 5, numberAvailable: 4}
+`,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := manifest.MustFromYAML(tt.obj).Unstructured()
+			require.Equal(t, tt.expected, rolloutComplete(obj, 2))
+		})
+	}
+}
+
+func Test_WithWait_DefaultTimeout(t *testing.T) {
+	op := NewRolloutRestartOperation("apps/v1", "Deployment", "default", "web", WithWait(0)).(*rolloutRestartOperation)
+	require.True(t, op.wait)
+	require.Equal(t, DefaultWaitTimeout, op.waitTimeout)
+
+	op = NewRolloutRestartOperation("apps/v1", "Deployment", "default", "web", WithWait(time.Minute)).(*rolloutRestartOperation)
+	require.Equal(t, time.Minute, op.waitTimeout)
+}
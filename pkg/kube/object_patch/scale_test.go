@@ -0,0 +1,93 @@
+package object_patch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/flant/shell-operator/pkg/kube/api_errors"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
+)
+
+func Test_ScaleOperation(t *testing.T) {
+	const (
+		namespace  = "default"
+		deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`
+	)
+
+	cluster := newFakeClusterWithNamespaceAndObjects(t, namespace, deployment)
+	patcher := NewObjectPatcher(cluster.Client)
+
+	replicas := func() int64 {
+		gvr := cluster.MustFindGVR("apps/v1", "Deployment")
+		obj, err := cluster.Client.Dynamic().Resource(*gvr).Namespace(namespace).Get(context.TODO(), "web", metav1.GetOptions{})
+		require.NoError(t, err)
+		res, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		require.NoError(t, err)
+		return res
+	}
+
+	operations, err := ParseOperations([]byte(`
+operation: Scale
+apiVersion: apps/v1
+kind: Deployment
+namespace: default
+name: web
+replicas: 3
+`))
+	require.NoError(t, err)
+	require.NoError(t, patcher.ExecuteOperations(operations))
+	require.Equal(t, int64(3), replicas())
+
+	operations, err = ParseOperations([]byte(`
+operation: Scale
+kind: Deployment
+namespace: default
+name: web
+jqFilter: .spec.replicas + 1
+`))
+	require.NoError(t, err)
+	require.NoError(t, patcher.ExecuteOperations(operations))
+	require.Equal(t, int64(4), replicas())
+
+	// Missing object.
+	// Errors are aggregated, but still can be checked with errors.Is.
+	err = patcher.ExecuteOperations([]Operation{NewScaleOperation(1, "apps/v1", "Deployment", namespace, "missing")})
+	require.ErrorIs(t, err, api_errors.ErrNotFound)
+	require.NoError(t, patcher.ExecuteOperation(NewScaleOperation(1, "apps/v1", "Deployment", namespace, "missing", IgnoreMissingObject())))
+
+	// Operations are skipped in the read-only mode.
+	read_only.Setup(true, nil)
+	defer read_only.Setup(false, nil)
+	require.NoError(t, patcher.ExecuteOperations([]Operation{NewScaleOperation(10, "apps/v1", "Deployment", namespace, "web")}))
+	require.Equal(t, int64(4), replicas())
+}
+
+func Test_applyJQScale(t *testing.T) {
+	scale := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v1",
+		"kind":       "Scale",
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+		"status":     map[string]interface{}{"replicas": int64(2)},
+	}}
+
+	replicas, err := applyJQScale(".spec.replicas * 2", scale)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), replicas)
+
+	_, err = applyJQScale(".spec", scale)
+	require.Error(t, err, "object is not a number")
+
+	_, err = applyJQScale(".spec.replicas / 3", scale)
+	require.Error(t, err, "fraction is not an integer")
+}
@@ -0,0 +1,59 @@
+package object_patch
+
+// operationSpanName returns a span name for the operation type.
+func operationSpanName(op Operation) string {
+	switch op.(type) {
+	case *createOperation:
+		return "object_patch.create"
+	case *deleteOperation:
+		return "object_patch.delete"
+	case *patchOperation:
+		return "object_patch.patch"
+	case *filterOperation:
+		return "object_patch.filter"
+	case *scaleOperation:
+		return "object_patch.scale"
+	case *evictOperation:
+		return "object_patch.evict"
+	case *rolloutRestartOperation:
+		return "object_patch.rollout_restart"
+	}
+	return "object_patch.operation"
+}
+
+// operationSpanAttributes returns coordinates of the object as span attributes.
+func operationSpanAttributes(op Operation) map[string]string {
+	var apiVersion, kind, namespace, name, subresource string
+	switch v := op.(type) {
+	case *createOperation:
+		subresource = v.subresource
+		if obj, err := toUnstructured(v.object); err == nil {
+			apiVersion, kind, namespace, name = obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName()
+		}
+	case *deleteOperation:
+		apiVersion, kind, namespace, name, subresource = v.apiVersion, v.kind, v.namespace, v.name, v.subresource
+	case *patchOperation:
+		apiVersion, kind, namespace, name, subresource = v.apiVersion, v.kind, v.namespace, v.name, v.subresource
+	case *filterOperation:
+		apiVersion, kind, namespace, name, subresource = v.apiVersion, v.kind, v.namespace, v.name, v.subresource
+	case *scaleOperation:
+		apiVersion, kind, namespace, name, subresource = v.apiVersion, v.kind, v.namespace, v.name, "scale"
+	case *evictOperation:
+		apiVersion, kind, namespace, name, subresource = "v1", "Pod", v.namespace, v.name, "eviction"
+	case *rolloutRestartOperation:
+		apiVersion, kind, namespace, name = v.apiVersion, v.kind, v.namespace, v.name
+	}
+
+	attrs := map[string]string{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"name":       name,
+	}
+	if namespace != "" {
+		attrs["namespace"] = namespace
+	}
+	if subresource != "" {
+		attrs["subresource"] = subresource
+	}
+	return attrs
+}
@@ -15,6 +15,9 @@ import (
 var Schemas = map[string]string{
 	"v0": `
 definitions:
+  duration:
+    type: string
+    pattern: "^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
   common:
     type: object
     properties:
@@ -74,6 +77,9 @@ properties:
   mergePatch: {}
   ignoreMissingObject: {}
   ignoreHookError: {}
+  replicas: {}
+  wait: {}
+  waitTimeout: {}
 
 oneOf:
 - allOf:
@@ -139,6 +145,68 @@ oneOf:
           - type: string
   - "$ref": "#/definitions/common"
   - "$ref": "#/definitions/patch"
+- allOf:
+  - oneOf:
+    - required:
+      - operation
+      - replicas
+      properties:
+        operation:
+          type: string
+          enum: ["Scale"]
+        replicas:
+          type: integer
+          minimum: 0
+    - required:
+      - operation
+      - jqFilter
+      properties:
+        operation:
+          type: string
+          enum: ["Scale"]
+        jqFilter:
+          type: string
+          minLength: 1
+  - "$ref": "#/definitions/patch"
+- required:
+  - operation
+  - name
+  properties:
+    operation:
+      type: string
+      enum: ["Evict"]
+    kind:
+      type: string
+      enum: ["Pod"]
+    name:
+      type: string
+    ignoreMissingObject:
+      type: boolean
+    wait:
+      type: boolean
+    waitTimeout:
+      "$ref": "#/definitions/duration"
+- required:
+  - operation
+  - kind
+  - name
+  properties:
+    operation:
+      type: string
+      enum: ["RolloutRestart"]
+    apiVersion:
+      type: string
+    kind:
+      type: string
+      enum: ["Deployment", "StatefulSet", "DaemonSet"]
+    name:
+      type: string
+    ignoreMissingObject:
+      type: boolean
+    wait:
+      type: boolean
+    waitTimeout:
+      "$ref": "#/definitions/duration"
 `,
 }
 
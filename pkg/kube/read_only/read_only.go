@@ -0,0 +1,54 @@
+package read_only
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// EnvName is an environment variable passed to hooks in the read-only mode.
+const EnvName = "SHELL_OPERATOR_READ_ONLY"
+
+var (
+	m             sync.RWMutex
+	enabled       bool
+	metricStorage *metric_storage.MetricStorage
+)
+
+// Setup enables or disables the read-only mode for the process.
+func Setup(readOnly bool, storage *metric_storage.MetricStorage) {
+	m.Lock()
+	defer m.Unlock()
+	enabled = readOnly
+	metricStorage = storage
+	if readOnly {
+		log.Warnf("Read-only mode is enabled: object patches, webhook configurations and Events are not applied")
+	}
+}
+
+// Enabled returns true in the read-only mode.
+func Enabled() bool {
+	m.RLock()
+	defer m.RUnlock()
+	return enabled
+}
+
+// Skip returns true if mutating operations are disabled. The skipped
+// operation is logged and counted in the metric.
+func Skip(component string, operation string, description string) bool {
+	m.RLock()
+	defer m.RUnlock()
+	if !enabled {
+		return false
+	}
+
+	log.WithField("operator.component", component).
+		Infof("Read-only mode: skip %s %s", operation, description)
+	metricStorage.CounterAdd("{PREFIX}read_only_skipped_operations_total", 1.0, map[string]string{
+		"component": component,
+		"operation": operation,
+	})
+	return true
+}
@@ -0,0 +1,18 @@
+package read_only
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Skip(t *testing.T) {
+	t.Cleanup(func() { Setup(false, nil) })
+
+	assert.False(t, Enabled())
+	assert.False(t, Skip("object_patch", "create", "Pod/test"))
+
+	Setup(true, nil)
+	assert.True(t, Enabled())
+	assert.True(t, Skip("object_patch", "create", "Pod/test"), "should skip with nil metric storage")
+}
@@ -0,0 +1,68 @@
+package kube_events_manager
+
+import (
+	"context"
+	"time"
+)
+
+// absenceCheckMinInterval limits how often the watchdog checks the cache.
+const absenceCheckMinInterval = time.Second
+
+// absenceWatchdog detects that no objects are cached for longer than the threshold.
+// It reports once per absence period and rearms when objects appear again.
+type absenceWatchdog struct {
+	threshold time.Duration
+	count     func() int
+	emit      func()
+
+	absentSince time.Time
+	reported    bool
+}
+
+func newAbsenceWatchdog(threshold time.Duration, count func() int, emit func()) *absenceWatchdog {
+	return &absenceWatchdog{
+		threshold: threshold,
+		count:     count,
+		emit:      emit,
+	}
+}
+
+// check returns true if objects are absent for longer than the threshold
+// and the absence is not reported yet.
+func (w *absenceWatchdog) check(now time.Time) bool {
+	if w.count() > 0 {
+		w.absentSince = time.Time{}
+		w.reported = false
+		return false
+	}
+	if w.absentSince.IsZero() {
+		w.absentSince = now
+	}
+	if w.reported || now.Sub(w.absentSince) < w.threshold {
+		return false
+	}
+	w.reported = true
+	return true
+}
+
+// run checks the cache periodically until ctx is done.
+func (w *absenceWatchdog) run(ctx context.Context) {
+	interval := w.threshold / 2
+	if interval < absenceCheckMinInterval {
+		interval = absenceCheckMinInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	w.check(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if w.check(now) {
+				w.emit()
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package kube_events_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_absenceWatchdog_check(t *testing.T) {
+	count := 0
+	w := newAbsenceWatchdog(time.Minute, func() int { return count }, func() {})
+
+	start := time.Now()
+	assert.False(t, w.check(start), "absence should start the period")
+	assert.False(t, w.check(start.Add(30*time.Second)))
+	assert.True(t, w.check(start.Add(time.Minute)), "absence should be reported after threshold")
+	assert.False(t, w.check(start.Add(2*time.Minute)), "absence should be reported once")
+
+	// Object appears and then disappears again.
+	count = 1
+	assert.False(t, w.check(start.Add(3*time.Minute)))
+	count = 0
+	assert.False(t, w.check(start.Add(4*time.Minute)))
+	assert.True(t, w.check(start.Add(5*time.Minute)), "watchdog should rearm after objects appear")
+}
@@ -0,0 +1,37 @@
+package kube_events_manager
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	utils_checksum "github.com/flant/shell-operator/pkg/utils/checksum"
+)
+
+// annotationsChecksum returns a checksum of annotations and labels with keys
+// from onAnnotationChange. Missing keys are also taken into account.
+func annotationsChecksum(keys []string, obj *unstructured.Unstructured) string {
+	annotations := obj.GetAnnotations()
+	labels := obj.GetLabels()
+
+	var b strings.Builder
+	for _, key := range keys {
+		if value, has := annotations[key]; has {
+			b.WriteString("annotation:" + key + "=" + value + "\n")
+		}
+		if value, has := labels[key]; has {
+			b.WriteString("label:" + key + "=" + value + "\n")
+		}
+	}
+	return utils_checksum.CalculateChecksum(b.String())
+}
+
+// annotationsChanged saves a checksum of listed annotations and labels for
+// the object. It returns true if the checksum is changed or the object is new.
+// cacheLock should be held.
+func (ei *resourceInformer) annotationsChanged(resourceId string, obj *unstructured.Unstructured) bool {
+	checksum := annotationsChecksum(ei.Monitor.OnAnnotationChange, obj)
+	prev, has := ei.annotationChecksums[resourceId]
+	ei.annotationChecksums[resourceId] = checksum
+	return !has || prev != checksum
+}
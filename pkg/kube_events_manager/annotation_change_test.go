@@ -0,0 +1,72 @@
+package kube_events_manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_resourceInformer_OnAnnotationChange(t *testing.T) {
+	monitorCfg := &MonitorConfig{}
+	monitorCfg.WithEventTypes(nil)
+	monitorCfg.OnAnnotationChange = []string{"my.io/config-hash"}
+	monitorCfg.KeepFullObjectsInMemory = true
+
+	events := make([]KubeEvent, 0)
+	informer := newResourceInformer("ns", "", &resourceInformerConfig{
+		monitor: monitorCfg,
+		eventCb: func(ev KubeEvent) {
+			events = append(events, ev)
+		},
+	})
+	informer.eventCbEnabled = true
+
+	newObj := func(hash string, replicas int64, labelHash string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("apps/v1")
+		obj.SetKind("Deployment")
+		obj.SetNamespace("ns")
+		obj.SetName("web")
+		if hash != "" {
+			obj.SetAnnotations(map[string]string{"my.io/config-hash": hash, "other": "value"})
+		}
+		if labelHash != "" {
+			obj.SetLabels(map[string]string{"my.io/config-hash": labelHash})
+		}
+		_ = unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas")
+		return obj
+	}
+
+	informer.handleWatchEvent(newObj("1", 1, ""), WatchEventAdded)
+	assert.Len(t, events, 1, "Added should be fired for a new object")
+
+	informer.handleWatchEvent(newObj("1", 3, ""), WatchEventModified)
+	assert.Len(t, events, 1, "Modified should not be fired if annotation is not changed")
+	assert.Equal(t, int64(3), mustReplicas(t, informer), "snapshot should be updated")
+
+	informer.handleWatchEvent(newObj("2", 3, ""), WatchEventModified)
+	assert.Len(t, events, 2, "Modified should be fired on annotation change")
+
+	informer.handleWatchEvent(newObj("2", 3, "x"), WatchEventModified)
+	assert.Len(t, events, 3, "Modified should be fired on label change")
+
+	informer.handleWatchEvent(newObj("", 3, ""), WatchEventModified)
+	assert.Len(t, events, 4, "Modified should be fired if annotation is removed")
+
+	informer.handleWatchEvent(newObj("", 3, ""), WatchEventDeleted)
+	assert.Len(t, events, 5, "Deleted should be always fired")
+	assert.Empty(t, informer.annotationChecksums)
+}
+
+func mustReplicas(t *testing.T, informer *resourceInformer) int64 {
+	t.Helper()
+	objects := informer.getCachedObjects()
+	if !assert.Len(t, objects, 1) {
+		return 0
+	}
+	replicas, _, _ := unstructured.NestedInt64(objects[0].Object.Object, "spec", "replicas")
+	return replicas
+}
@@ -0,0 +1,80 @@
+package kube_events_manager
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+// pendingEvent is a sequence of events for one object received within the debounce window.
+type pendingEvent struct {
+	first  WatchEventType
+	last   WatchEventType
+	object *ObjectAndFilterResult
+}
+
+// eventDebouncer delays object events for the window and emits one event with the final state.
+type eventDebouncer struct {
+	window time.Duration
+	emit   func(resourceId string, eventType WatchEventType, obj *ObjectAndFilterResult)
+
+	m       sync.Mutex
+	pending map[string]*pendingEvent
+}
+
+func newEventDebouncer(window time.Duration, emit func(string, WatchEventType, *ObjectAndFilterResult)) *eventDebouncer {
+	return &eventDebouncer{
+		window:  window,
+		emit:    emit,
+		pending: make(map[string]*pendingEvent),
+	}
+}
+
+// add delays the event. The first event for the object starts the window,
+// next events within the window replace the object.
+func (d *eventDebouncer) add(resourceId string, eventType WatchEventType, obj *ObjectAndFilterResult) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if p, ok := d.pending[resourceId]; ok {
+		p.last = eventType
+		p.object = obj
+		return
+	}
+	d.pending[resourceId] = &pendingEvent{first: eventType, last: eventType, object: obj}
+	time.AfterFunc(d.window, func() {
+		d.flush(resourceId)
+	})
+}
+
+func (d *eventDebouncer) flush(resourceId string) {
+	d.m.Lock()
+	p, ok := d.pending[resourceId]
+	delete(d.pending, resourceId)
+	d.m.Unlock()
+
+	if !ok {
+		return
+	}
+	if eventType, ok := coalesceEvents(p.first, p.last); ok {
+		d.emit(resourceId, eventType, p.object)
+	}
+}
+
+// coalesceEvents returns a type of one event that replaces a sequence of events.
+// It is false for objects that were created and deleted within the window.
+func coalesceEvents(first, last WatchEventType) (WatchEventType, bool) {
+	existedBefore := first != WatchEventAdded
+	existsAfter := last != WatchEventDeleted
+
+	switch {
+	case !existedBefore && !existsAfter:
+		return "", false
+	case !existedBefore:
+		return WatchEventAdded, true
+	case !existsAfter:
+		return WatchEventDeleted, true
+	}
+	return WatchEventModified, true
+}
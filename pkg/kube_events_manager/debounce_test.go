@@ -0,0 +1,57 @@
+package kube_events_manager
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_coalesceEvents(t *testing.T) {
+	tests := []struct {
+		first, last WatchEventType
+		expected    WatchEventType
+		fire        bool
+	}{
+		{WatchEventAdded, WatchEventModified, WatchEventAdded, true},
+		{WatchEventAdded, WatchEventDeleted, "", false},
+		{WatchEventModified, WatchEventModified, WatchEventModified, true},
+		{WatchEventModified, WatchEventDeleted, WatchEventDeleted, true},
+		{WatchEventDeleted, WatchEventAdded, WatchEventModified, true},
+		{WatchEventDeleted, WatchEventDeleted, WatchEventDeleted, true},
+	}
+	for _, tt := range tests {
+		eventType, fire := coalesceEvents(tt.first, tt.last)
+		assert.Equal(t, tt.fire, fire, "%s..%s", tt.first, tt.last)
+		assert.Equal(t, tt.expected, eventType, "%s..%s", tt.first, tt.last)
+	}
+}
+
+func Test_eventDebouncer(t *testing.T) {
+	var m sync.Mutex
+	emitted := map[string]WatchEventType{}
+	d := newEventDebouncer(50*time.Millisecond, func(id string, eventType WatchEventType, _ *ObjectAndFilterResult) {
+		m.Lock()
+		emitted[id] = eventType
+		m.Unlock()
+	})
+
+	d.add("pod-a", WatchEventAdded, &ObjectAndFilterResult{})
+	d.add("pod-a", WatchEventModified, &ObjectAndFilterResult{})
+	d.add("pod-b", WatchEventAdded, &ObjectAndFilterResult{})
+	d.add("pod-b", WatchEventDeleted, &ObjectAndFilterResult{})
+
+	assert.Eventually(t, func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return len(emitted) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	m.Lock()
+	defer m.Unlock()
+	assert.Equal(t, map[string]WatchEventType{"pod-a": WatchEventAdded}, emitted)
+}
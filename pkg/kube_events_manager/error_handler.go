@@ -52,6 +52,11 @@ func (weh *WatchErrorHandler) handler(_ *cache.Reflector, err error) {
 	if weh.metricStorage != nil {
 		weh.metricStorage.CounterAdd("{PREFIX}kubernetes_client_watch_errors_total", 1.0, map[string]string{"error_type": errorType})
 	}
+
+	// Normally closed watch is restarted without relist.
+	if err != nil && err != io.EOF {
+		observeFailedWatch(weh.metricStorage)
+	}
 }
 
 // IsExpiredError is a private method from k8s.io/client-go/tools/cache.
@@ -0,0 +1,241 @@
+package kube_events_manager
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+// busEvent is a watch event from the shared informer.
+type busEvent struct {
+	kind    string
+	obj     interface{}
+	oldObj  interface{}
+	initial bool
+}
+
+const (
+	busEventAdd    = "add"
+	busEventUpdate = "update"
+	busEventDelete = "delete"
+)
+
+// eventSource is a shared informer to subscribe to.
+type eventSource interface {
+	AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error)
+	RemoveEventHandler(handle cache.ResourceEventHandlerRegistration) error
+}
+
+// eventBus delivers events of a shared informer to subscriptions. Each subscription
+// is registered in the informer, so existing objects are replayed to a new
+// subscription consistently with events, as the informer does for every handler.
+// Each subscription has its own queue, so a slow handler does not delay events
+// for other bindings. The bus itself is registered as a handler to count events.
+type eventBus struct {
+	published uint64
+
+	source eventSource
+
+	mu            sync.RWMutex
+	subscriptions map[string]*busSubscription
+}
+
+var _ cache.ResourceEventHandler = &eventBus{}
+
+func newEventBus(source eventSource) *eventBus {
+	return &eventBus{
+		source:        source,
+		subscriptions: make(map[string]*busSubscription),
+	}
+}
+
+func (b *eventBus) OnAdd(_ interface{}, _ bool) {
+	atomic.AddUint64(&b.published, 1)
+}
+
+func (b *eventBus) OnUpdate(_, _ interface{}) {
+	atomic.AddUint64(&b.published, 1)
+}
+
+func (b *eventBus) OnDelete(_ interface{}) {
+	atomic.AddUint64(&b.published, 1)
+}
+
+// subscribe adds a handler to the bus. Existing objects are replayed to the new
+// handler as initial Add events before other events.
+func (b *eventBus) subscribe(id string, handler cache.ResourceEventHandler) error {
+	sub := newBusSubscription(id, handler)
+	registration, err := b.source.AddEventHandler(sub)
+	if err != nil {
+		return err
+	}
+	sub.registration = registration
+
+	b.mu.Lock()
+	old, has := b.subscriptions[id]
+	b.subscriptions[id] = sub
+	b.mu.Unlock()
+	if has {
+		b.remove(old)
+	}
+
+	go sub.run()
+	return nil
+}
+
+// unsubscribe removes a handler from the bus and returns the number of remaining subscriptions.
+func (b *eventBus) unsubscribe(id string) int {
+	b.mu.Lock()
+	sub, has := b.subscriptions[id]
+	delete(b.subscriptions, id)
+	remaining := len(b.subscriptions)
+	b.mu.Unlock()
+	if has {
+		b.remove(sub)
+	}
+	return remaining
+}
+
+func (b *eventBus) remove(sub *busSubscription) {
+	if err := b.source.RemoveEventHandler(sub.registration); err != nil {
+		log.Warnf("Event bus: couldn't remove subscription '%s' from the informer: %v", sub.id, err)
+	}
+	sub.stop()
+}
+
+func (b *eventBus) stats() ([]SubscriptionStats, uint64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	res := make([]SubscriptionStats, 0, len(b.subscriptions))
+	for _, sub := range b.subscriptions {
+		res = append(res, sub.stats())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, atomic.LoadUint64(&b.published)
+}
+
+// namedHandler is implemented by handlers with a human-readable name for stats.
+type namedHandler interface {
+	subscriptionName() string
+}
+
+// busSubscription is an unbounded queue of events for one handler.
+type busSubscription struct {
+	id           string
+	name         string
+	handler      cache.ResourceEventHandler
+	registration cache.ResourceEventHandlerRegistration
+
+	delivered uint64
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []busEvent
+	stopped bool
+}
+
+func newBusSubscription(id string, handler cache.ResourceEventHandler) *busSubscription {
+	sub := &busSubscription{
+		id:      id,
+		handler: handler,
+	}
+	if named, ok := handler.(namedHandler); ok {
+		sub.name = named.subscriptionName()
+	}
+	sub.cond = sync.NewCond(&sub.mu)
+	return sub
+}
+
+func (s *busSubscription) OnAdd(obj interface{}, isInInitialList bool) {
+	s.push(busEvent{kind: busEventAdd, obj: obj, initial: isInInitialList})
+}
+
+func (s *busSubscription) OnUpdate(oldObj, newObj interface{}) {
+	s.push(busEvent{kind: busEventUpdate, obj: newObj, oldObj: oldObj})
+}
+
+func (s *busSubscription) OnDelete(obj interface{}) {
+	s.push(busEvent{kind: busEventDelete, obj: obj})
+}
+
+func (s *busSubscription) push(ev busEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.queue = append(s.queue, ev)
+	s.cond.Signal()
+}
+
+func (s *busSubscription) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	s.queue = nil
+	s.cond.Signal()
+}
+
+// run delivers queued events to the handler until the subscription is stopped.
+func (s *busSubscription) run() {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.stopped {
+			s.cond.Wait()
+		}
+		if s.stopped {
+			s.mu.Unlock()
+			return
+		}
+		ev := s.queue[0]
+		s.queue[0] = busEvent{}
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		switch ev.kind {
+		case busEventAdd:
+			s.handler.OnAdd(ev.obj, ev.initial)
+		case busEventUpdate:
+			s.handler.OnUpdate(ev.oldObj, ev.obj)
+		case busEventDelete:
+			s.handler.OnDelete(ev.obj)
+		}
+		atomic.AddUint64(&s.delivered, 1)
+	}
+}
+
+func (s *busSubscription) stats() SubscriptionStats {
+	s.mu.Lock()
+	pending := len(s.queue)
+	s.mu.Unlock()
+	return SubscriptionStats{
+		ID:        s.id,
+		Name:      s.name,
+		Delivered: atomic.LoadUint64(&s.delivered),
+		Pending:   pending,
+	}
+}
+
+// SubscriptionStats describes a binding subscribed to the event bus.
+type SubscriptionStats struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Delivered uint64 `json:"delivered"`
+	Pending   int    `json:"pending"`
+}
+
+// EventBusStats describes the event bus of one shared informer.
+type EventBusStats struct {
+	Resource      string              `json:"resource"`
+	Namespace     string              `json:"namespace,omitempty"`
+	FieldSelector string              `json:"fieldSelector,omitempty"`
+	LabelSelector string              `json:"labelSelector,omitempty"`
+	Published     uint64              `json:"published"`
+	Subscriptions []SubscriptionStats `json:"subscriptions"`
+}
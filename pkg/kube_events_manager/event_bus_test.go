@@ -0,0 +1,159 @@
+package kube_events_manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type recordingHandler struct {
+	m      sync.Mutex
+	events []string
+}
+
+func (h *recordingHandler) handler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			h.record("add:" + obj.(*unstructured.Unstructured).GetName())
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			h.record("update:" + newObj.(*unstructured.Unstructured).GetName())
+		},
+		DeleteFunc: func(obj interface{}) {
+			h.record("delete:" + obj.(*unstructured.Unstructured).GetName())
+		},
+	}
+}
+
+func (h *recordingHandler) record(ev string) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.events = append(h.events, ev)
+}
+
+func (h *recordingHandler) get() []string {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return append([]string{}, h.events...)
+}
+
+func testPod(name string, resourceVersion int) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetResourceVersion(strconv.Itoa(resourceVersion))
+	return obj
+}
+
+// runTestInformer starts an informer with events from the returned watcher.
+func runTestInformer(t *testing.T) (cache.SharedIndexInformer, *watch.FakeWatcher) {
+	watcher := watch.NewFakeWithChanSize(100, false)
+	lw := &cache.ListWatch{
+		ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+			list := &unstructured.UnstructuredList{}
+			list.SetResourceVersion("1")
+			return list, nil
+		},
+		WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	}
+	informer := cache.NewSharedIndexInformer(lw, &unstructured.Unstructured{}, 0, cache.Indexers{})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go informer.Run(ctx.Done())
+	require.Eventually(t, informer.HasSynced, time.Second, 10*time.Millisecond)
+	return informer, watcher
+}
+
+func Test_EventBus_Subscriptions(t *testing.T) {
+	informer, watcher := runTestInformer(t)
+	bus := newEventBus(informer)
+	_, err := informer.AddEventHandler(bus)
+	require.NoError(t, err)
+
+	first := &recordingHandler{}
+	require.NoError(t, bus.subscribe("first", first.handler()))
+
+	watcher.Add(testPod("pod-1", 2))
+	assert.Eventually(t, func() bool { return len(first.get()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// Late subscriber receives existing objects before published events.
+	second := &recordingHandler{}
+	require.NoError(t, bus.subscribe("second", second.handler()))
+
+	watcher.Modify(testPod("pod-1", 3))
+	watcher.Delete(testPod("pod-1", 4))
+
+	assert.Eventually(t, func() bool {
+		return len(first.get()) == 3 && len(second.get()) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"add:pod-1", "update:pod-1", "delete:pod-1"}, first.get())
+	assert.Equal(t, []string{"add:pod-1", "update:pod-1", "delete:pod-1"}, second.get())
+
+	subscriptions, published := bus.stats()
+	assert.Equal(t, uint64(3), published)
+	assert.Len(t, subscriptions, 2)
+	assert.Equal(t, "first", subscriptions[0].ID)
+	assert.Equal(t, uint64(3), subscriptions[0].Delivered)
+	assert.Equal(t, 0, subscriptions[0].Pending)
+
+	assert.Equal(t, 1, bus.unsubscribe("first"))
+	watcher.Add(testPod("pod-2", 5))
+	assert.Eventually(t, func() bool {
+		return len(second.get()) == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, first.get(), 3)
+
+	assert.Equal(t, 0, bus.unsubscribe("second"))
+}
+
+// Objects changed while a handler subscribes should be delivered exactly once:
+// either in the replay or as an event.
+func Test_EventBus_Subscribe_Concurrent_Events(t *testing.T) {
+	informer, watcher := runTestInformer(t)
+	bus := newEventBus(informer)
+
+	const objects = 50
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < objects; i++ {
+			watcher.Add(testPod(fmt.Sprintf("pod-%d", i), i+2))
+		}
+	}()
+
+	handlers := make([]*recordingHandler, 10)
+	for i := range handlers {
+		handlers[i] = &recordingHandler{}
+		require.NoError(t, bus.subscribe(fmt.Sprintf("sub-%d", i), handlers[i].handler()))
+	}
+	<-done
+
+	for _, h := range handlers {
+		assert.Eventually(t, func() bool { return len(h.get()) >= objects }, time.Second, 10*time.Millisecond)
+		// Wait for possible duplicates.
+		time.Sleep(50 * time.Millisecond)
+		seen := make(map[string]int)
+		for _, ev := range h.get() {
+			seen[ev]++
+		}
+		assert.Len(t, seen, objects)
+		for ev, count := range seen {
+			assert.Equal(t, 1, count, ev)
+		}
+	}
+}
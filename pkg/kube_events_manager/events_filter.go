@@ -0,0 +1,101 @@
+package kube_events_manager
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// eventsFilter deduplicates Event objects by reason and involvedObject and limits
+// a rate of Event objects for kubernetesEvents bindings. It is shared by all informers of the monitor.
+type eventsFilter struct {
+	reasons map[string]struct{}
+	window  time.Duration
+	limiter *rate.Limiter
+
+	m         sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+// newEventsFilter returns nil if the filter is not configured.
+func newEventsFilter(cfg *EventsFilterConfig) *eventsFilter {
+	if cfg == nil {
+		return nil
+	}
+	f := &eventsFilter{
+		window: cfg.DedupWindow,
+		seen:   make(map[string]time.Time),
+	}
+	if len(cfg.Reasons) > 0 {
+		f.reasons = make(map[string]struct{}, len(cfg.Reasons))
+		for _, reason := range cfg.Reasons {
+			f.reasons[reason] = struct{}{}
+		}
+	}
+	if cfg.Rate > 0 {
+		burst := cfg.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		f.limiter = rate.NewLimiter(rate.Limit(cfg.Rate), burst)
+	}
+	return f
+}
+
+// matchReason returns true if the reason of the Event object is listed in the binding.
+// Nil filter matches all objects.
+func (f *eventsFilter) matchReason(obj *unstructured.Unstructured) bool {
+	if f == nil || f.reasons == nil {
+		return true
+	}
+	reason, _, _ := unstructured.NestedString(obj.Object, "reason")
+	_, has := f.reasons[reason]
+	return has
+}
+
+// check returns a reason to drop the Event object or an empty string if the object should be delivered to the hook.
+func (f *eventsFilter) check(obj *unstructured.Unstructured, now time.Time) string {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	key := eventDedupKey(obj)
+	if f.window > 0 {
+		f.prune(now)
+		if last, has := f.seen[key]; has && now.Sub(last) < f.window {
+			return EventSkippedDuplicate
+		}
+	}
+	if f.limiter != nil && !f.limiter.AllowN(now, 1) {
+		return EventSkippedRateLimit
+	}
+	if f.window > 0 {
+		f.seen[key] = now
+	}
+	return ""
+}
+
+// prune forgets keys older than the window. It runs at most once per window.
+// f.m should be held.
+func (f *eventsFilter) prune(now time.Time) {
+	if now.Sub(f.lastPrune) < f.window {
+		return
+	}
+	f.lastPrune = now
+	for key, last := range f.seen {
+		if now.Sub(last) >= f.window {
+			delete(f.seen, key)
+		}
+	}
+}
+
+// eventDedupKey returns a key of the Event object: the reason and the involvedObject.
+func eventDedupKey(obj *unstructured.Unstructured) string {
+	reason, _, _ := unstructured.NestedString(obj.Object, "reason")
+	kind, _, _ := unstructured.NestedString(obj.Object, "involvedObject", "kind")
+	namespace, _, _ := unstructured.NestedString(obj.Object, "involvedObject", "namespace")
+	name, _, _ := unstructured.NestedString(obj.Object, "involvedObject", "name")
+	return reason + "/" + kind + "/" + namespace + "/" + name
+}
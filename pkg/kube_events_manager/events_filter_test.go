@@ -0,0 +1,60 @@
+package kube_events_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestEvent(name string, reason string, podName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"reason": reason,
+		"involvedObject": map[string]interface{}{
+			"kind":      "Pod",
+			"namespace": "default",
+			"name":      podName,
+		},
+	}}
+}
+
+func Test_eventsFilter_Dedup(t *testing.T) {
+	f := newEventsFilter(&EventsFilterConfig{DedupWindow: time.Minute})
+	now := time.Now()
+
+	assert.Equal(t, "", f.check(newTestEvent("e1", "BackOff", "app-1"), now))
+	// Another Event object for the same pod and reason.
+	assert.Equal(t, EventSkippedDuplicate, f.check(newTestEvent("e2", "BackOff", "app-1"), now.Add(10*time.Second)))
+	assert.Equal(t, "", f.check(newTestEvent("e3", "Unhealthy", "app-1"), now.Add(10*time.Second)))
+	assert.Equal(t, "", f.check(newTestEvent("e4", "BackOff", "app-2"), now.Add(10*time.Second)))
+	// The window is over.
+	assert.Equal(t, "", f.check(newTestEvent("e1", "BackOff", "app-1"), now.Add(2*time.Minute)))
+	// Expired keys are pruned.
+	assert.Len(t, f.seen, 1)
+}
+
+func Test_eventsFilter_RateLimit(t *testing.T) {
+	f := newEventsFilter(&EventsFilterConfig{Rate: 1, Burst: 2})
+	now := time.Now()
+
+	assert.Equal(t, "", f.check(newTestEvent("e1", "BackOff", "app-1"), now))
+	assert.Equal(t, "", f.check(newTestEvent("e2", "BackOff", "app-2"), now))
+	assert.Equal(t, EventSkippedRateLimit, f.check(newTestEvent("e3", "BackOff", "app-3"), now))
+	assert.Equal(t, "", f.check(newTestEvent("e3", "BackOff", "app-3"), now.Add(time.Second)))
+}
+
+func Test_eventsFilter_MatchReason(t *testing.T) {
+	var f *eventsFilter
+	assert.True(t, f.matchReason(newTestEvent("e1", "BackOff", "app-1")))
+
+	f = newEventsFilter(&EventsFilterConfig{Reasons: []string{"FailedMount"}})
+	assert.True(t, f.matchReason(newTestEvent("e1", "FailedMount", "app-1")))
+	assert.False(t, f.matchReason(newTestEvent("e2", "BackOff", "app-1")))
+}
@@ -2,6 +2,7 @@ package kube_events_manager
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -28,13 +29,20 @@ type FactoryIndex struct {
 	Namespace     string
 	FieldSelector string
 	LabelSelector string
+	// Informer timings. Bindings with different timings do not share informers.
+	ResyncPeriod time.Duration
+	ListPageSize int64
+	WatchTimeout time.Duration
 }
 
+// Factory is a shared informer for one watch spec. Informer events are
+// delivered to bindings subscribed to the bus.
 type Factory struct {
-	shared               dynamicinformer.DynamicSharedInformerFactory
-	handlerRegistrations map[string]cache.ResourceEventHandlerRegistration
-	ctx                  context.Context
-	cancel               context.CancelFunc
+	shared              dynamicinformer.DynamicSharedInformerFactory
+	bus                 *eventBus
+	handlerRegistration cache.ResourceEventHandlerRegistration
+	ctx                 context.Context
+	cancel              context.CancelFunc
 }
 
 type FactoryStore struct {
@@ -50,11 +58,18 @@ func NewFactoryStore() *FactoryStore {
 
 func (c *FactoryStore) add(index FactoryIndex, f dynamicinformer.DynamicSharedInformerFactory) {
 	ctx, cancel := context.WithCancel(context.Background())
+	informer := f.ForResource(index.GVR).Informer()
+	bus := newEventBus(informer)
+	registration, err := informer.AddEventHandler(bus)
+	if err != nil {
+		log.Warnf("Factory store: couldn't add event bus to the %v factory's informer: %v", index, err)
+	}
 	c.data[index] = Factory{
-		shared:               f,
-		handlerRegistrations: make(map[string]cache.ResourceEventHandlerRegistration, 0),
-		ctx:                  ctx,
-		cancel:               cancel,
+		shared:              f,
+		bus:                 bus,
+		handlerRegistration: registration,
+		ctx:                 ctx,
+		cancel:              cancel,
 	}
 	log.Debugf("Factory store: added a new factory for %v index", index)
 }
@@ -67,7 +82,10 @@ func (c *FactoryStore) get(client dynamic.Interface, index FactoryIndex) Factory
 	}
 
 	// define resyncPeriod for informer
-	resyncPeriod := randomizedResyncPeriod()
+	resyncPeriod := index.ResyncPeriod
+	if resyncPeriod == 0 {
+		resyncPeriod = randomizedResyncPeriod()
+	}
 
 	tweakListOptions := func(options *metav1.ListOptions) {
 		if index.FieldSelector != "" {
@@ -76,6 +94,15 @@ func (c *FactoryStore) get(client dynamic.Interface, index FactoryIndex) Factory
 		if index.LabelSelector != "" {
 			options.LabelSelector = index.LabelSelector
 		}
+		// Reflector sets a timeout only for WATCH requests.
+		isWatch := options.TimeoutSeconds != nil
+		if isWatch && index.WatchTimeout > 0 {
+			timeoutSeconds := int64(index.WatchTimeout.Seconds())
+			options.TimeoutSeconds = &timeoutSeconds
+		}
+		if !isWatch && index.ListPageSize > 0 {
+			options.Limit = index.ListPageSize
+		}
 	}
 
 	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
@@ -95,12 +122,12 @@ func (c *FactoryStore) Start(ctx context.Context, informerId string, client dyna
 	informer := factory.shared.ForResource(index.GVR).Informer()
 	// Add error handler, ignore "already started" error.
 	_ = informer.SetWatchErrorHandler(errorHandler.handler)
-	registration, err := informer.AddEventHandler(handler)
-	if err != nil {
-		log.Warnf("Factory store: couldn't add event handler to the %v factory's informer: %v", index, err)
+	// Existing objects are replayed to the new subscriber by the informer.
+	if err := factory.bus.subscribe(informerId, handler); err != nil {
+		return err
 	}
-	factory.handlerRegistrations[informerId] = registration
-	log.Debugf("Factory store: increased usage counter to %d of the factory with %v index", len(factory.handlerRegistrations), index)
+	subscriptions, _ := factory.bus.stats()
+	log.Debugf("Factory store: increased usage counter to %d of the factory with %v index", len(subscriptions), index)
 
 	if !informer.HasSynced() {
 		go informer.Run(factory.ctx.Done())
@@ -125,17 +152,53 @@ func (c *FactoryStore) Stop(informerId string, index FactoryIndex) {
 		return
 	}
 
-	if handlerRegistration, found := f.handlerRegistrations[informerId]; found {
-		err := f.shared.ForResource(index.GVR).Informer().RemoveEventHandler(handlerRegistration)
+	remaining := f.bus.unsubscribe(informerId)
+	log.Debugf("Factory store: decreased usage counter to %d of the factory with %v index", remaining, index)
+	if remaining == 0 {
+		err := f.shared.ForResource(index.GVR).Informer().RemoveEventHandler(f.handlerRegistration)
 		if err != nil {
-			log.Warnf("Factory store: couldn't remove event handler from the %v factory's informer: %v", index, err)
-		}
-		delete(f.handlerRegistrations, informerId)
-		log.Debugf("Factory store: decreased usage counter to %d of the factory with %v index", len(f.handlerRegistrations), index)
-		if len(f.handlerRegistrations) == 0 {
-			f.cancel()
-			delete(c.data, index)
-			log.Debugf("Factory store: deleted factory for %v index", index)
+			log.Warnf("Factory store: couldn't remove event bus from the %v factory's informer: %v", index, err)
 		}
+		f.cancel()
+		delete(c.data, index)
+		log.Debugf("Factory store: deleted factory for %v index", index)
+	}
+}
+
+// Stats returns event bus stats for all shared informers sorted by watch spec.
+func (c *FactoryStore) Stats() []EventBusStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]EventBusStats, 0, len(c.data))
+	for index, f := range c.data {
+		subscriptions, published := f.bus.stats()
+		res = append(res, EventBusStats{
+			Resource:      index.GVR.String(),
+			Namespace:     index.Namespace,
+			FieldSelector: index.FieldSelector,
+			LabelSelector: index.LabelSelector,
+			Published:     published,
+			Subscriptions: subscriptions,
+		})
 	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.LabelSelector != b.LabelSelector {
+			return a.LabelSelector < b.LabelSelector
+		}
+		return a.FieldSelector < b.FieldSelector
+	})
+	return res
+}
+
+// EventBusStatsDump returns stats of the default factory store.
+func EventBusStatsDump() []EventBusStats {
+	return DefaultFactoryStore.Stats()
 }
@@ -19,6 +19,7 @@ import (
 // applyFilter filters object json representation with jq expression, calculate checksum
 // over result and return ObjectAndFilterResult. If jqFilter is empty, no filter
 // is required and checksum is calculated over full json representation of the object.
+// Results for the jqFilter are cached by object's UID and resourceVersion.
 func applyFilter(jqFilter string, filterFn func(obj *unstructured.Unstructured) (result interface{}, err error), obj *unstructured.Unstructured) (*ObjectAndFilterResult, error) {
 	defer trace.StartRegion(context.Background(), "ApplyJqFilter").End()
 
@@ -46,12 +47,27 @@ func applyFilter(jqFilter string, filterFn func(obj *unstructured.Unstructured)
 		return res, nil
 	}
 
+	// Object with the same UID and resourceVersion has the same filter result.
+	cacheKey, cacheable := newFilterCacheKey(jqFilter, obj)
+	if cacheable {
+		if cached, ok := DefaultFilterCache.get(cacheKey); ok {
+			res.Metadata.ObjectSize = cached.objectSize
+			res.Metadata.Checksum = cached.checksum
+			if jqFilter != "" {
+				res.FilterResult = cached.filterResult
+			}
+			return res, nil
+		}
+	}
+
 	// Render obj to JSON text to apply jq filter.
 	data, err := json.Marshal(obj)
 	if err != nil {
 		return nil, err
 	}
 
+	res.Metadata.ObjectSize = len(data)
+
 	if jqFilter == "" {
 		res.Metadata.Checksum = utils_checksum.CalculateChecksum(string(data))
 	} else {
@@ -66,5 +82,17 @@ func applyFilter(jqFilter string, filterFn func(obj *unstructured.Unstructured)
 		res.Metadata.Checksum = utils_checksum.CalculateChecksum(filtered)
 	}
 
+	if cacheable {
+		entry := filterCacheEntry{
+			key:        cacheKey,
+			checksum:   res.Metadata.Checksum,
+			objectSize: res.Metadata.ObjectSize,
+		}
+		if filtered, ok := res.FilterResult.(string); ok {
+			entry.filterResult = filtered
+		}
+		DefaultFilterCache.add(entry)
+	}
+
 	return res, nil
 }
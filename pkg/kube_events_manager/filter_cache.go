@@ -0,0 +1,118 @@
+package kube_events_manager
+
+import (
+	"container/list"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// DefaultFilterCache is shared by all informers.
+var DefaultFilterCache = NewFilterCache()
+
+// filterCacheKey identifies a state of the object for the jqFilter.
+// An object with the same UID and resourceVersion is not changed,
+// so the result of the filter is the same.
+type filterCacheKey struct {
+	jqFilter        string
+	uid             string
+	resourceVersion string
+}
+
+type filterCacheEntry struct {
+	key          filterCacheKey
+	filterResult string
+	checksum     string
+	objectSize   int
+}
+
+// FilterCache keeps results of jqFilter for objects. It saves jq runs on
+// re-synchronizations and when many bindings monitor the same objects.
+// The least recently used results are evicted when the size limit is reached.
+type FilterCache struct {
+	m             sync.Mutex
+	limit         int
+	entries       map[filterCacheKey]*list.Element
+	lru           *list.List
+	metricStorage *metric_storage.MetricStorage
+}
+
+func NewFilterCache() *FilterCache {
+	return &FilterCache{
+		entries: make(map[filterCacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// SetLimit sets the maximum number of cached results. Zero disables the cache.
+func (c *FilterCache) SetLimit(limit int, metricStorage *metric_storage.MetricStorage) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.limit = limit
+	c.metricStorage = metricStorage
+	c.evict()
+	c.metricStorage.GaugeSet("{PREFIX}kube_jq_filter_cache_limit", float64(limit), map[string]string{})
+}
+
+// newFilterCacheKey returns false if the object has no UID or resourceVersion.
+func newFilterCacheKey(jqFilter string, obj *unstructured.Unstructured) (filterCacheKey, bool) {
+	key := filterCacheKey{
+		jqFilter:        jqFilter,
+		uid:             string(obj.GetUID()),
+		resourceVersion: obj.GetResourceVersion(),
+	}
+	return key, key.uid != "" && key.resourceVersion != ""
+}
+
+func (c *FilterCache) get(key filterCacheKey) (filterCacheEntry, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.limit <= 0 {
+		return filterCacheEntry{}, false
+	}
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.metricStorage.CounterAdd("{PREFIX}kube_jq_filter_cache_misses_total", 1.0, map[string]string{})
+		return filterCacheEntry{}, false
+	}
+	c.lru.MoveToFront(el)
+	c.metricStorage.CounterAdd("{PREFIX}kube_jq_filter_cache_hits_total", 1.0, map[string]string{})
+	return *el.Value.(*filterCacheEntry), true
+}
+
+func (c *FilterCache) add(entry filterCacheEntry) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.limit <= 0 {
+		return
+	}
+
+	if el, ok := c.entries[entry.key]; ok {
+		el.Value = &entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(&entry)
+	c.evict()
+}
+
+// evict removes the least recently used entries over the limit.
+func (c *FilterCache) evict() {
+	for c.lru.Len() > 0 && c.lru.Len() > c.limit {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*filterCacheEntry).key)
+	}
+	c.metricStorage.GaugeSet("{PREFIX}kube_jq_filter_cache_entries", float64(c.lru.Len()), map[string]string{})
+}
+
+func (c *FilterCache) len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.lru.Len()
+}
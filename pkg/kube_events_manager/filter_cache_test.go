@@ -0,0 +1,74 @@
+package kube_events_manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_FilterCache_LRU(t *testing.T) {
+	c := NewFilterCache()
+
+	key1 := filterCacheKey{jqFilter: ".a", uid: "uid-1", resourceVersion: "1"}
+	key2 := filterCacheKey{jqFilter: ".a", uid: "uid-2", resourceVersion: "1"}
+	key3 := filterCacheKey{jqFilter: ".a", uid: "uid-3", resourceVersion: "1"}
+
+	// Disabled cache keeps nothing.
+	c.add(filterCacheEntry{key: key1, checksum: "c1"})
+	_, ok := c.get(key1)
+	assert.False(t, ok)
+
+	c.SetLimit(2, nil)
+	c.add(filterCacheEntry{key: key1, checksum: "c1"})
+	c.add(filterCacheEntry{key: key2, checksum: "c2"})
+
+	// key1 is used recently, key2 should be evicted.
+	entry, ok := c.get(key1)
+	assert.True(t, ok)
+	assert.Equal(t, "c1", entry.checksum)
+	c.add(filterCacheEntry{key: key3, checksum: "c3"})
+
+	_, ok = c.get(key2)
+	assert.False(t, ok)
+	_, ok = c.get(key3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.len())
+
+	// Lower limit evicts entries.
+	c.SetLimit(1, nil)
+	assert.Equal(t, 1, c.len())
+}
+
+func Test_ApplyFilter_Cache(t *testing.T) {
+	DefaultFilterCache = NewFilterCache()
+	DefaultFilterCache.SetLimit(10, nil)
+	defer func() {
+		DefaultFilterCache = NewFilterCache()
+	}()
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Pod",
+		"metadata": map[string]interface{}{
+			"name":            "pod-1",
+			"uid":             "uid-1",
+			"resourceVersion": "100",
+		},
+	}}
+
+	first, err := applyFilter("", nil, obj)
+	assert.NoError(t, err)
+
+	// Same resourceVersion: the result is taken from the cache.
+	obj.Object["spec"] = map[string]interface{}{"a": "b"}
+	cached, err := applyFilter("", nil, obj)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Metadata.Checksum, cached.Metadata.Checksum)
+	assert.Equal(t, first.Metadata.ObjectSize, cached.Metadata.ObjectSize)
+
+	// New resourceVersion: the filter is applied again.
+	obj.SetResourceVersion("101")
+	changed, err := applyFilter("", nil, obj)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Metadata.Checksum, changed.Metadata.Checksum)
+}
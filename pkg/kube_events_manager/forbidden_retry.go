@@ -0,0 +1,226 @@
+package kube_events_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	authv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/kube/api_errors"
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+	"github.com/flant/shell-operator/pkg/utils/exponential_backoff"
+	utils "github.com/flant/shell-operator/pkg/utils/labels"
+)
+
+// MonitorConditionReady is a type of the condition that reports if the monitor is started.
+const MonitorConditionReady = "Ready"
+
+// Reasons of the Ready condition.
+const (
+	MonitorReasonStarted   = "Started"
+	MonitorReasonForbidden = "Forbidden"
+)
+
+// ForbiddenRetryInitialDelay is a delay before the first retry of the monitor failed with Forbidden.
+var ForbiddenRetryInitialDelay = 5 * time.Second
+
+// MonitorCondition is a state of the monitor of the 'kubernetes' binding.
+type MonitorCondition struct {
+	MonitorID          string                 `json:"monitorId"`
+	Binding            string                 `json:"binding"`
+	Type               string                 `json:"type"`
+	Status             metav1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime time.Time              `json:"lastTransitionTime"`
+	// Retries is a number of failed attempts to start the monitor since the Forbidden error.
+	Retries int `json:"retries,omitempty"`
+}
+
+// IsForbidden returns true if the monitor is not created because of RBAC.
+func IsForbidden(err error) bool {
+	return errors.Is(err, api_errors.ErrForbidden) || apierrors.IsForbidden(err)
+}
+
+// setMonitorCondition updates the Ready condition of the monitor. The transition time
+// is changed only if the status or the reason is changed.
+func (mgr *kubeEventsManager) setMonitorCondition(config *MonitorConfig, status metav1.ConditionStatus, reason string, message string, retries int) {
+	mgr.conditionsLock.Lock()
+	defer mgr.conditionsLock.Unlock()
+
+	id := config.Metadata.MonitorId
+	cond := MonitorCondition{
+		MonitorID:          id,
+		Binding:            config.Metadata.DebugName,
+		Type:               MonitorConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: time.Now(),
+		Retries:            retries,
+	}
+	if prev, has := mgr.conditions[id]; has && prev.Status == status && prev.Reason == reason {
+		cond.LastTransitionTime = prev.LastTransitionTime
+	}
+	mgr.conditions[id] = cond
+
+	if mgr.metricStorage != nil {
+		ready := 0.0
+		if status == metav1.ConditionTrue {
+			ready = 1.0
+		}
+		mgr.metricStorage.GaugeSet("{PREFIX}kube_binding_ready", ready, config.Metadata.MetricLabels)
+	}
+}
+
+// MonitorConditions returns conditions of monitors sorted by bindings.
+func (mgr *kubeEventsManager) MonitorConditions() []MonitorCondition {
+	mgr.conditionsLock.Lock()
+	defer mgr.conditionsLock.Unlock()
+	res := make([]MonitorCondition, 0, len(mgr.conditions))
+	for _, cond := range mgr.conditions {
+		res = append(res, cond)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Binding != res[j].Binding {
+			return res[i].Binding < res[j].Binding
+		}
+		return res[i].MonitorID < res[j].MonitorID
+	})
+	return res
+}
+
+// MonitorCondition returns the condition of the monitor or nil if the monitor was never added.
+func (mgr *kubeEventsManager) MonitorCondition(monitorID string) *MonitorCondition {
+	mgr.conditionsLock.Lock()
+	defer mgr.conditionsLock.Unlock()
+	cond, has := mgr.conditions[monitorID]
+	if !has {
+		return nil
+	}
+	return &cond
+}
+
+// RetryForbiddenMonitor starts the monitor in background after the RBAC is fixed.
+// It returns false if err is not Forbidden or retries are disabled, so the caller
+// should handle the error. The Synchronization event is emitted when the monitor is started.
+func (mgr *kubeEventsManager) RetryForbiddenMonitor(config *MonitorConfig, err error) bool {
+	if !app.KubeForbiddenRetry || !IsForbidden(err) {
+		return false
+	}
+
+	mgr.conditionsLock.Lock()
+	if _, has := mgr.forbiddenRetries[config.Metadata.MonitorId]; has {
+		mgr.conditionsLock.Unlock()
+		return true
+	}
+	ctx, cancel := context.WithCancel(mgr.ctx)
+	mgr.forbiddenRetries[config.Metadata.MonitorId] = cancel
+	mgr.conditionsLock.Unlock()
+
+	logEntry := log.WithFields(utils.LabelsToLogFields(config.Metadata.LogLabels)).
+		WithField("binding.name", config.Metadata.DebugName)
+	logEntry.Warnf("Monitor is not started: %v. Retry until RBAC is fixed", err)
+
+	go mgr.retryForbiddenMonitor(ctx, config, logEntry)
+	return true
+}
+
+func (mgr *kubeEventsManager) retryForbiddenMonitor(ctx context.Context, config *MonitorConfig, logEntry *log.Entry) {
+	defer mgr.cancelForbiddenRetry(config.Metadata.MonitorId)
+
+	for retry := 0; ; retry++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(exponential_backoff.CalculateDelayWithMax(ForbiddenRetryInitialDelay, app.KubeForbiddenRetryMaxDelay, retry)):
+		}
+
+		allowed, err := mgr.accessAllowed(ctx, config)
+		if !allowed {
+			if err != nil {
+				mgr.setMonitorCondition(config, metav1.ConditionFalse, MonitorReasonForbidden, err.Error(), retry+1)
+			}
+			continue
+		}
+
+		err = mgr.AddMonitor(config)
+		if err != nil {
+			if !IsForbidden(err) {
+				logEntry.Errorf("Start monitor after RBAC is fixed: %v", err)
+			}
+			mgr.setMonitorCondition(config, metav1.ConditionFalse, MonitorReasonForbidden, err.Error(), retry+1)
+			continue
+		}
+
+		logEntry.Infof("RBAC is fixed, monitor is started after %d retries", retry+1)
+		mgr.StartMonitor(config.Metadata.MonitorId)
+		select {
+		case <-ctx.Done():
+		case mgr.KubeEventCh <- KubeEvent{MonitorId: config.Metadata.MonitorId, Type: TypeSynchronization}:
+		}
+		return
+	}
+}
+
+func (mgr *kubeEventsManager) cancelForbiddenRetry(monitorID string) {
+	mgr.conditionsLock.Lock()
+	defer mgr.conditionsLock.Unlock()
+	if cancel, has := mgr.forbiddenRetries[monitorID]; has {
+		cancel()
+		delete(mgr.forbiddenRetries, monitorID)
+	}
+}
+
+// deleteMonitorCondition forgets the condition of the stopped monitor.
+func (mgr *kubeEventsManager) deleteMonitorCondition(monitorID string) {
+	mgr.conditionsLock.Lock()
+	defer mgr.conditionsLock.Unlock()
+	delete(mgr.conditions, monitorID)
+}
+
+// monitorAccessAllowed checks with SelfSubjectAccessReview that the operator
+// can list and watch objects of the monitor. Namespaces selected by labels
+// are not known in advance, so the monitor is retried without the check.
+func (mgr *kubeEventsManager) monitorAccessAllowed(ctx context.Context, config *MonitorConfig) (bool, error) {
+	namespaces := config.namespaces()
+	if len(namespaces) == 0 {
+		return true, nil
+	}
+
+	gvr, err := mgr.KubeClient.GroupVersionResource(config.ApiVersion, config.Kind)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ns := range namespaces {
+		for _, verb := range []string{"list", "watch"} {
+			review := &authv1.SelfSubjectAccessReview{
+				Spec: authv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authv1.ResourceAttributes{
+						Namespace: ns,
+						Verb:      verb,
+						Group:     gvr.Group,
+						Version:   gvr.Version,
+						Resource:  gvr.Resource,
+					},
+				},
+			}
+			res, err := mgr.KubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				return false, err
+			}
+			if !res.Status.Allowed {
+				return false, fmt.Errorf("%s %s in namespace '%s' is not allowed: %s", verb, gvr.String(), ns, res.Status.Reason)
+			}
+		}
+	}
+	return true, nil
+}
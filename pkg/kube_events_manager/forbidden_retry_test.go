@@ -0,0 +1,91 @@
+package kube_events_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+
+	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/kube/api_errors"
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_IsForbidden(t *testing.T) {
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("no RBAC"))
+	assert.True(t, IsForbidden(forbidden))
+	assert.True(t, IsForbidden(fmt.Errorf("create informer: %w", api_errors.Wrap(forbidden))))
+	assert.False(t, IsForbidden(errors.New("connection refused")))
+}
+
+func Test_RetryForbiddenMonitor(t *testing.T) {
+	defaultDelay, defaultMaxDelay := ForbiddenRetryInitialDelay, app.KubeForbiddenRetryMaxDelay
+	ForbiddenRetryInitialDelay, app.KubeForbiddenRetryMaxDelay = 10*time.Millisecond, 20*time.Millisecond
+	defer func() {
+		ForbiddenRetryInitialDelay, app.KubeForbiddenRetryMaxDelay = defaultDelay, defaultMaxDelay
+	}()
+
+	kubeClient := klient.NewFake(map[schema.GroupVersionResource]string{
+		{Group: "", Version: "v1", Resource: "pods"}: "PodList",
+	})
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Kind: "Pod", Name: "pods", Verbs: metav1.Verbs{"get", "list", "watch"}, Version: "v1"},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mgr := NewKubeEventsManager(ctx, kubeClient)
+
+	// RBAC is fixed after the second check.
+	var checks int32
+	mgr.accessAllowed = func(_ context.Context, _ *MonitorConfig) (bool, error) {
+		if atomic.AddInt32(&checks, 1) < 3 {
+			return false, errors.New("list pods is not allowed")
+		}
+		return true, nil
+	}
+
+	config := &MonitorConfig{ApiVersion: "v1", Kind: "Pod"}
+	config.Metadata.MonitorId = "pods-monitor"
+	config.Metadata.DebugName = "pods"
+
+	forbidden := api_errors.Wrap(apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("no RBAC")))
+	assert.False(t, mgr.RetryForbiddenMonitor(config, errors.New("connection refused")))
+	mgr.setMonitorCondition(config, metav1.ConditionFalse, MonitorReasonForbidden, forbidden.Error(), 0)
+	require.True(t, mgr.RetryForbiddenMonitor(config, forbidden))
+	// Only one retry is started for the monitor.
+	require.True(t, mgr.RetryForbiddenMonitor(config, forbidden))
+
+	select {
+	case ev := <-mgr.KubeEventCh:
+		assert.Equal(t, KubeEvent{MonitorId: "pods-monitor", Type: TypeSynchronization}, ev)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no Synchronization event after RBAC is fixed")
+	}
+
+	assert.True(t, mgr.HasMonitor("pods-monitor"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&checks))
+	cond := mgr.MonitorCondition("pods-monitor")
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, MonitorReasonStarted, cond.Reason)
+	assert.Len(t, mgr.MonitorConditions(), 1)
+
+	require.NoError(t, mgr.StopMonitor("pods-monitor"))
+	assert.Nil(t, mgr.MonitorCondition("pods-monitor"))
+}
@@ -0,0 +1,107 @@
+package kube_events_manager
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+// eventHistoryMaxObjects limits the number of objects with history in one informer.
+// Objects with the oldest events are evicted first.
+const eventHistoryMaxObjects = 1000
+
+// Reasons to not deliver an event to the hook.
+const (
+	EventSkippedChecksum   = "checksum is not changed"
+	EventSkippedNotEnabled = "not in executeHookOnEvent"
+	EventSkippedAnnotation = "annotations from onAnnotationChange are not changed"
+	EventSkippedDuplicate  = "the same reason and involvedObject within dedupWindow"
+	EventSkippedRateLimit  = "rate limit of Events is exceeded"
+)
+
+// EventHistoryEntry is a record about a watch event for the object.
+type EventHistoryEntry struct {
+	Time         time.Time      `json:"time"`
+	WatchEvent   WatchEventType `json:"watchEvent"`
+	Checksum     string         `json:"checksum"`
+	FilterResult interface{}    `json:"filterResult,omitempty"`
+	// Skipped is a reason why the event was not delivered to the hook.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// ObjectHistory is a bounded list of the last events for the object.
+type ObjectHistory struct {
+	ResourceId string              `json:"resourceId"`
+	LastSeen   time.Time           `json:"lastSeen"`
+	Events     []EventHistoryEntry `json:"events"`
+}
+
+// eventHistory keeps the last events for each object, including deleted ones.
+type eventHistory struct {
+	size int
+
+	m       sync.Mutex
+	objects map[string]*ObjectHistory
+}
+
+func newEventHistory(size int) *eventHistory {
+	return &eventHistory{
+		size:    size,
+		objects: make(map[string]*ObjectHistory),
+	}
+}
+
+func (h *eventHistory) record(resourceId string, entry EventHistoryEntry) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	obj, ok := h.objects[resourceId]
+	if !ok {
+		if len(h.objects) >= eventHistoryMaxObjects {
+			h.evictOldest()
+		}
+		obj = &ObjectHistory{ResourceId: resourceId}
+		h.objects[resourceId] = obj
+	}
+
+	obj.LastSeen = entry.Time
+	obj.Events = append(obj.Events, entry)
+	if len(obj.Events) > h.size {
+		obj.Events = obj.Events[len(obj.Events)-h.size:]
+	}
+}
+
+func (h *eventHistory) evictOldest() {
+	oldestId := ""
+	var oldest time.Time
+	for id, obj := range h.objects {
+		if oldestId == "" || obj.LastSeen.Before(oldest) {
+			oldestId = id
+			oldest = obj.LastSeen
+		}
+	}
+	delete(h.objects, oldestId)
+}
+
+// dump returns copies of object histories sorted by resource id.
+func (h *eventHistory) dump() []ObjectHistory {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	res := make([]ObjectHistory, 0, len(h.objects))
+	for _, obj := range h.objects {
+		events := make([]EventHistoryEntry, len(obj.Events))
+		copy(events, obj.Events)
+		res = append(res, ObjectHistory{
+			ResourceId: obj.ResourceId,
+			LastSeen:   obj.LastSeen,
+			Events:     events,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ResourceId < res[j].ResourceId
+	})
+	return res
+}
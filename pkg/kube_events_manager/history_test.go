@@ -0,0 +1,40 @@
+package kube_events_manager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_eventHistory(t *testing.T) {
+	h := newEventHistory(2)
+	now := time.Now()
+
+	h.record("ns/Pod/pod-1", EventHistoryEntry{Time: now, WatchEvent: WatchEventAdded, Checksum: "1"})
+	h.record("ns/Pod/pod-1", EventHistoryEntry{Time: now.Add(time.Second), WatchEvent: WatchEventModified, Checksum: "1", Skipped: EventSkippedChecksum})
+	h.record("ns/Pod/pod-1", EventHistoryEntry{Time: now.Add(2 * time.Second), WatchEvent: WatchEventDeleted, Checksum: "1"})
+
+	dump := h.dump()
+	assert.Len(t, dump, 1)
+	assert.Equal(t, now.Add(2*time.Second), dump[0].LastSeen)
+	assert.Len(t, dump[0].Events, 2, "history should be bounded")
+	assert.Equal(t, WatchEventModified, dump[0].Events[0].WatchEvent)
+	assert.Equal(t, WatchEventDeleted, dump[0].Events[1].WatchEvent)
+}
+
+func Test_eventHistory_EvictOldest(t *testing.T) {
+	h := newEventHistory(1)
+	now := time.Now()
+
+	for i := 0; i < eventHistoryMaxObjects+1; i++ {
+		h.record(fmt.Sprintf("ns/Pod/pod-%04d", i), EventHistoryEntry{Time: now.Add(time.Duration(i) * time.Second), WatchEvent: WatchEventAdded})
+	}
+
+	dump := h.dump()
+	assert.Len(t, dump, eventHistoryMaxObjects)
+	assert.Equal(t, "ns/Pod/pod-0001", dump[0].ResourceId, "the oldest object should be evicted")
+}
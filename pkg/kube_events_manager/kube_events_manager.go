@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	klient "github.com/flant/kube-client/client"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
@@ -19,6 +20,9 @@ type KubeEventsManager interface {
 	GetMonitor(monitorID string) Monitor
 	StartMonitor(monitorID string)
 	StopMonitor(monitorID string) error
+	RetryForbiddenMonitor(monitorConfig *MonitorConfig, err error) bool
+	MonitorCondition(monitorID string) *MonitorCondition
+	MonitorConditions() []MonitorCondition
 
 	Ch() chan KubeEvent
 	PauseHandleEvents()
@@ -37,6 +41,13 @@ type kubeEventsManager struct {
 
 	m        sync.RWMutex
 	Monitors map[string]Monitor
+
+	// conditions are states of monitors. See RetryForbiddenMonitor.
+	conditionsLock   sync.Mutex
+	conditions       map[string]MonitorCondition
+	forbiddenRetries map[string]context.CancelFunc
+	// accessAllowed checks RBAC before the retry of the Forbidden monitor.
+	accessAllowed func(ctx context.Context, config *MonitorConfig) (bool, error)
 }
 
 // kubeEventsManager should implement KubeEventsManager.
@@ -52,7 +63,11 @@ func NewKubeEventsManager(ctx context.Context, client *klient.Client) *kubeEvent
 		m:           sync.RWMutex{},
 		Monitors:    make(map[string]Monitor),
 		KubeEventCh: make(chan KubeEvent, 1),
+
+		conditions:       make(map[string]MonitorCondition),
+		forbiddenRetries: make(map[string]context.CancelFunc),
 	}
+	em.accessAllowed = em.monitorAccessAllowed
 	return em
 }
 
@@ -77,8 +92,12 @@ func (mgr *kubeEventsManager) AddMonitor(monitorConfig *MonitorConfig) error {
 
 	err := monitor.CreateInformers()
 	if err != nil {
+		if IsForbidden(err) {
+			mgr.setMonitorCondition(monitorConfig, metav1.ConditionFalse, MonitorReasonForbidden, err.Error(), 0)
+		}
 		return err
 	}
+	mgr.setMonitorCondition(monitorConfig, metav1.ConditionTrue, MonitorReasonStarted, "", 0)
 
 	mgr.m.Lock()
 	mgr.Monitors[monitorConfig.Metadata.MonitorId] = monitor
@@ -103,15 +122,19 @@ func (mgr *kubeEventsManager) GetMonitor(monitorID string) Monitor {
 }
 
 // StartMonitor starts all informers for the monitor.
+// It may wait after the monitor is started to stagger start-up of many monitors.
 func (mgr *kubeEventsManager) StartMonitor(monitorID string) {
 	mgr.m.RLock()
 	monitor := mgr.Monitors[monitorID]
 	mgr.m.RUnlock()
 	monitor.Start(mgr.ctx)
+	DefaultStartupThrottle.waitMonitorStart(mgr.ctx)
 }
 
 // StopMonitor stops monitor and removes it from the index.
 func (mgr *kubeEventsManager) StopMonitor(monitorID string) error {
+	mgr.cancelForbiddenRetry(monitorID)
+	mgr.deleteMonitorCondition(monitorID)
 	mgr.m.RLock()
 	monitor, ok := mgr.Monitors[monitorID]
 	mgr.m.RUnlock()
@@ -0,0 +1,119 @@
+package kube_events_manager
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// DefaultSnapshotMemoryBudget is shared by all informers.
+var DefaultSnapshotMemoryBudget = NewSnapshotMemoryBudget()
+
+// SnapshotMemoryBudget limits memory used by full objects in snapshots.
+// Memory is estimated as a size of JSON representation of cached objects.
+// When the limit is exceeded, full objects are evicted from the largest
+// informers first. Evicted informers keep only filter results, events are
+// still delivered with full objects received from the watch. Informers without
+// a filter are not evicted: their snapshots have no filter results to keep.
+// Full objects are cached again when the usage drops below a half of the limit.
+type SnapshotMemoryBudget struct {
+	m             sync.Mutex
+	limit         int64
+	usage         map[*resourceInformer]int64
+	total         int64
+	metricStorage *metric_storage.MetricStorage
+}
+
+func NewSnapshotMemoryBudget() *SnapshotMemoryBudget {
+	return &SnapshotMemoryBudget{
+		usage: make(map[*resourceInformer]int64),
+	}
+}
+
+// SetLimit sets the limit in bytes. Zero disables the budget.
+func (b *SnapshotMemoryBudget) SetLimit(limit int64, metricStorage *metric_storage.MetricStorage) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.limit = limit
+	b.metricStorage = metricStorage
+	b.metricStorage.GaugeSet("{PREFIX}kube_snapshot_memory_limit_bytes", float64(limit), map[string]string{})
+}
+
+func (b *SnapshotMemoryBudget) enabled() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.limit > 0
+}
+
+// update stores the usage of the informer and evicts full objects if the budget is exceeded.
+func (b *SnapshotMemoryBudget) update(ei *resourceInformer, bytes int64) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.limit <= 0 {
+		return
+	}
+
+	b.total += bytes - b.usage[ei]
+	b.usage[ei] = bytes
+
+	for b.total > b.limit {
+		victim := b.largest()
+		if victim == nil {
+			break
+		}
+		freed := victim.evictFullObjects()
+		b.total -= freed
+		b.usage[victim] -= freed
+		log.Warnf("%s: snapshot memory budget %d bytes is exceeded, evict full objects: %d bytes freed",
+			victim.Monitor.Metadata.DebugName, b.limit, freed)
+		b.metricStorage.CounterAdd("{PREFIX}kube_snapshot_evictions_total", 1.0, victim.Monitor.Metadata.MetricLabels)
+	}
+
+	b.restoreEvicted()
+	b.metricStorage.GaugeSet("{PREFIX}kube_snapshot_memory_bytes", float64(b.total), map[string]string{})
+}
+
+// restoreEvicted caches full objects again for evicted informers if the usage
+// is below a half of the limit. The gap prevents evictions on every update.
+// Objects get full objects back with the next watch event or resync. Lock should be held.
+func (b *SnapshotMemoryBudget) restoreEvicted() {
+	if b.total > b.limit/2 {
+		return
+	}
+	for ei := range b.usage {
+		if ei.restoreFullObjects() {
+			log.Infof("%s: snapshot memory usage %d bytes is below a half of the budget %d bytes, cache full objects again",
+				ei.Monitor.Metadata.DebugName, b.total, b.limit)
+		}
+	}
+}
+
+// remove forgets the informer, e.g. when its namespace is deleted.
+func (b *SnapshotMemoryBudget) remove(ei *resourceInformer) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.total -= b.usage[ei]
+	delete(b.usage, ei)
+	if b.limit > 0 {
+		b.restoreEvicted()
+	}
+	b.metricStorage.GaugeSet("{PREFIX}kube_snapshot_memory_bytes", float64(b.total), map[string]string{})
+}
+
+// largest returns an informer with the largest usage that keeps full objects
+// and has filter results to keep instead.
+func (b *SnapshotMemoryBudget) largest() *resourceInformer {
+	var victim *resourceInformer
+	var max int64
+	for ei, bytes := range b.usage {
+		if bytes > max && ei.hasFilter() && !ei.isFullObjectsEvicted() {
+			victim = ei
+			max = bytes
+		}
+	}
+	return victim
+}
@@ -0,0 +1,94 @@
+package kube_events_manager
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_SnapshotMemoryBudget_EvictsLargest(t *testing.T) {
+	budget := NewSnapshotMemoryBudget()
+	budget.SetLimit(200, nil)
+
+	newInformer := func(objects int, jqFilter string) *resourceInformer {
+		monitorCfg := &MonitorConfig{JqFilter: jqFilter}
+		monitorCfg.WithEventTypes(nil)
+		ei := newResourceInformer("ns", "", &resourceInformerConfig{monitor: monitorCfg})
+		for i := 0; i < objects; i++ {
+			obj := &ObjectAndFilterResult{Object: &unstructured.Unstructured{}, FilterResult: "{}"}
+			obj.Metadata.ObjectSize = 50
+			ei.storeCachedObject(fmt.Sprintf("ns/Pod/pod-%d", i), obj)
+		}
+		return ei
+	}
+
+	small := newInformer(1, ".metadata")
+	large := newInformer(4, ".metadata")
+	// Snapshots without a filter have only full objects, they are not evicted.
+	unfiltered := newInformer(6, "")
+	budget.update(unfiltered, unfiltered.cachedBytes)
+	assert.False(t, unfiltered.isFullObjectsEvicted())
+	budget.remove(unfiltered)
+
+	budget.update(small, small.cachedBytes)
+	assert.False(t, small.isFullObjectsEvicted())
+
+	budget.update(large, large.cachedBytes)
+	assert.True(t, large.isFullObjectsEvicted(), "the largest snapshot should be evicted")
+	assert.False(t, small.isFullObjectsEvicted())
+	assert.Equal(t, int64(50), budget.total)
+
+	for _, obj := range large.getCachedObjects() {
+		assert.Nil(t, obj.Object)
+		assert.Equal(t, "{}", obj.FilterResult, "filter results should be kept")
+	}
+
+	// New objects are cached without full objects.
+	obj := &ObjectAndFilterResult{Object: &unstructured.Unstructured{}}
+	obj.Metadata.ObjectSize = 50
+	large.storeCachedObject("ns/Pod/pod-new", obj)
+	assert.Equal(t, int64(0), large.cachedBytes)
+	assert.NotNil(t, obj.Object, "object passed to the cache should not be modified")
+
+	// Full objects are cached again when the usage is below a half of the limit.
+	budget.remove(small)
+	assert.False(t, large.isFullObjectsEvicted())
+	large.storeCachedObject("ns/Pod/pod-new", obj)
+	assert.Equal(t, int64(50), large.cachedBytes)
+}
+
+func Test_SnapshotMemoryBudget_MonitorStop(t *testing.T) {
+	budget := NewSnapshotMemoryBudget()
+	budget.SetLimit(1000, nil)
+	defaultBudget := DefaultSnapshotMemoryBudget
+	DefaultSnapshotMemoryBudget = budget
+	defer func() { DefaultSnapshotMemoryBudget = defaultBudget }()
+
+	monitorCfg := &MonitorConfig{JqFilter: ".metadata"}
+	monitorCfg.WithEventTypes(nil)
+	newInformer := func() *resourceInformer {
+		ei := newResourceInformer("ns", "", &resourceInformerConfig{monitor: monitorCfg})
+		obj := &ObjectAndFilterResult{Object: &unstructured.Unstructured{}, FilterResult: "{}"}
+		obj.Metadata.ObjectSize = 100
+		ei.storeCachedObject("ns/Pod/pod", obj)
+		ei.updateMemoryBudget()
+		return ei
+	}
+
+	stopped := &monitor{
+		ResourceInformers: []*resourceInformer{newInformer()},
+		VaryingInformers:  map[string][]*resourceInformer{"ns-1": {newInformer()}},
+	}
+	live := newInformer()
+	assert.Equal(t, int64(300), budget.total)
+
+	// Stopped informers should not count toward the budget anymore.
+	stopped.Stop()
+	assert.Equal(t, int64(100), budget.total)
+	assert.Len(t, budget.usage, 1)
+	assert.Contains(t, budget.usage, live)
+}
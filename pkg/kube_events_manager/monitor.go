@@ -8,6 +8,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/app"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 	"github.com/flant/shell-operator/pkg/metric_storage"
 	utils "github.com/flant/shell-operator/pkg/utils/labels"
@@ -22,6 +23,7 @@ type Monitor interface {
 	EnableKubeEventCb()
 	GetConfig() *MonitorConfig
 	SnapshotOperations() (total *CachedObjectsInfo, last *CachedObjectsInfo)
+	EventHistory() []ObjectHistory
 }
 
 // Monitor holds informers for resources and a namespace informer
@@ -43,6 +45,9 @@ type monitor struct {
 
 	cancelForNs map[string]context.CancelFunc
 
+	// eventsFilter is shared by informers to apply the rate limit to the whole binding.
+	eventsFilter *eventsFilter
+
 	ctx           context.Context
 	cancel        context.CancelFunc
 	metricStorage *metric_storage.MetricStorage
@@ -62,6 +67,7 @@ func NewMonitor(ctx context.Context, client *klient.Client, mstor *metric_storag
 		VaryingInformers:  make(map[string][]*resourceInformer),
 		cancelForNs:       make(map[string]context.CancelFunc),
 		staticNamespaces:  make(map[string]bool),
+		eventsFilter:      newEventsFilter(config.EventsFilter),
 	}
 }
 
@@ -153,6 +159,12 @@ func (m *monitor) CreateInformers() error {
 					return
 				}
 
+				// Deliver Deleted events for objects left in the snapshot and free the cache
+				// before informers are stopped: watch events may not arrive for them.
+				for _, informer := range m.VaryingInformers[nsName] {
+					informer.purgeCachedObjects()
+				}
+
 				m.cancelForNs[nsName]()
 
 				// TODO wait
@@ -162,7 +174,7 @@ func (m *monitor) CreateInformers() error {
 			},
 		)
 		if err != nil {
-			return fmt.Errorf("create namespace informer: %v", err)
+			return fmt.Errorf("create namespace informer: %w", err)
 		}
 		for nsName := range m.NamespaceInformer.getExistedObjects() {
 			logEntry.Infof("got ns/%s, create dynamic ResourceInformers", nsName)
@@ -197,8 +209,10 @@ func (m *monitor) Snapshot() []ObjectAndFilterResult {
 		}
 	}
 
-	// Sort objects by namespace and name
-	sort.Sort(ByNamespaceAndName(objects))
+	// Sort objects by namespace and name to make the order reproducible.
+	if app.KubeSnapshotOrder != app.KubeSnapshotOrderNone {
+		sort.Sort(ByNamespaceAndName(objects))
+	}
 
 	return objects
 }
@@ -225,10 +239,12 @@ func (m *monitor) EnableKubeEventCb() {
 func (m *monitor) CreateInformersForNamespace(namespace string) (informers []*resourceInformer, err error) {
 	informers = make([]*resourceInformer, 0)
 	cfg := &resourceInformerConfig{
-		client:  m.KubeClient,
-		mstor:   m.metricStorage,
-		eventCb: m.eventCb,
-		monitor: m.Config,
+		client:       m.KubeClient,
+		mstor:        m.metricStorage,
+		eventCb:      m.eventCb,
+		monitor:      m.Config,
+		eventsFilter: m.eventsFilter,
+		ctx:          m.ctx,
 	}
 
 	objNames := []string{""}
@@ -274,11 +290,27 @@ func (m *monitor) Start(parentCtx context.Context) {
 	}
 }
 
-// Stop stops all informers
+// Stop stops all informers and removes their objects from the snapshot storage
+// and the memory budget.
+// Stop is called for the monitor that is not needed anymore, so saved objects are garbage.
 func (m *monitor) Stop() {
 	if m.cancel != nil {
 		m.cancel()
 	}
+
+	informers := append([]*resourceInformer{}, m.ResourceInformers...)
+	for _, nsInformers := range m.VaryingInformers {
+		informers = append(informers, nsInformers...)
+	}
+	// Release the share of the memory budget, so live informers are not evicted for it.
+	for _, informer := range informers {
+		DefaultSnapshotMemoryBudget.remove(informer)
+	}
+	go func() {
+		for _, informer := range informers {
+			informer.stopStoredObjects()
+		}
+	}()
 }
 
 // PauseHandleEvents set flags for all informers to ignore incoming events.
@@ -318,3 +350,24 @@ func (m *monitor) SnapshotOperations() (total *CachedObjectsInfo, last *CachedOb
 
 	return total, last
 }
+
+// EventHistory returns the last events for objects from all informers.
+func (m *monitor) EventHistory() []ObjectHistory {
+	res := make([]ObjectHistory, 0)
+
+	for _, informer := range m.ResourceInformers {
+		res = append(res, informer.getEventHistory()...)
+	}
+
+	for nsName := range m.VaryingInformers {
+		for _, informer := range m.VaryingInformers[nsName] {
+			res = append(res, informer.getEventHistory()...)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ResourceId < res[j].ResourceId
+	})
+
+	return res
+}
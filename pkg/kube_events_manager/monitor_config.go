@@ -1,10 +1,13 @@
 package kube_events_manager
 
 import (
+	"time"
+
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/flant/shell-operator/pkg/app"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 )
 
@@ -29,6 +32,30 @@ type MonitorConfig struct {
 	Mode                    KubeEventMode
 	KeepFullObjectsInMemory bool
 	FilterFunc              func(*unstructured.Unstructured) (interface{}, error)
+	// DebounceWindow coalesces events for the same object received within the window. Zero disables debouncing.
+	DebounceWindow time.Duration
+	// AbsentAfter enables "Absent" events when no objects match the binding for the duration. Zero disables the watchdog.
+	AbsentAfter time.Duration
+	// OnAnnotationChange is a list of annotation and label keys. If set, Added and Modified events
+	// are fired only if values of these keys are changed.
+	OnAnnotationChange []string
+	// ResyncPeriod, ListPageSize and WatchTimeout override global informer settings. Zero values mean global settings.
+	ResyncPeriod time.Duration
+	ListPageSize int64
+	WatchTimeout time.Duration
+	// EventsFilter deduplicates and rate limits Event objects for kubernetesEvents bindings. Nil disables the filter.
+	EventsFilter *EventsFilterConfig
+}
+
+// EventsFilterConfig is a configuration of the filter for Event objects.
+type EventsFilterConfig struct {
+	// Reasons filters Event objects by the reason. All reasons are passed if empty.
+	Reasons []string
+	// DedupWindow drops Event objects with the same reason and involvedObject received within the window.
+	DedupWindow time.Duration
+	// Rate is a maximum number of Event objects per second for the binding. Zero disables the limit.
+	Rate  float64
+	Burst int
 }
 
 func (c *MonitorConfig) WithEventTypes(types []WatchEventType) *MonitorConfig {
@@ -49,7 +76,8 @@ func (c *MonitorConfig) WithEventTypes(types []WatchEventType) *MonitorConfig {
 func (c *MonitorConfig) WithNameSelector(nSel *NameSelector) {
 	if nSel != nil {
 		c.NameSelector = &NameSelector{
-			MatchNames: nSel.MatchNames,
+			MatchNames:       nSel.MatchNames,
+			MatchExpressions: nSel.MatchExpressions,
 		}
 	}
 }
@@ -60,7 +88,8 @@ func (c *MonitorConfig) WithNamespaceSelector(nsSel *NamespaceSelector) {
 		c.NamespaceSelector = &NamespaceSelector{}
 		if nsSel.NameSelector != nil {
 			c.NamespaceSelector.NameSelector = &NameSelector{
-				MatchNames: nsSel.NameSelector.MatchNames,
+				MatchNames:       nsSel.NameSelector.MatchNames,
+				MatchExpressions: nsSel.NameSelector.MatchExpressions,
 			}
 		}
 		if nsSel.LabelSelector != nil {
@@ -91,6 +120,38 @@ func (c *MonitorConfig) WithLabelSelector(labelSel *metav1.LabelSelector) {
 	}
 }
 
+// informerTimings returns informer settings of the binding or global settings if they are not set.
+func (c *MonitorConfig) informerTimings() (resyncPeriod time.Duration, listPageSize int64, watchTimeout time.Duration) {
+	resyncPeriod, listPageSize, watchTimeout = app.KubeInformerResyncPeriod, int64(app.KubeListPageSize), app.KubeWatchTimeout
+	if c.ResyncPeriod > 0 {
+		resyncPeriod = c.ResyncPeriod
+	}
+	if c.ListPageSize > 0 {
+		listPageSize = c.ListPageSize
+	}
+	if c.WatchTimeout > 0 {
+		watchTimeout = c.WatchTimeout
+	}
+	return resyncPeriod, listPageSize, watchTimeout
+}
+
+// nameMatchers returns matchers for matchExpressions of nameSelector and namespace.nameSelector.
+// Expressions are validated with the hook config, so errors are only logged.
+func (c *MonitorConfig) nameMatchers() (names *nameMatcher, namespaces *nameMatcher) {
+	var err error
+	names, err = newNameMatcher(c.NameSelector)
+	if err != nil {
+		log.Errorf("%s: nameSelector: %v", c.Metadata.DebugName, err)
+	}
+	if c.NamespaceSelector != nil {
+		namespaces, err = newNameMatcher(c.NamespaceSelector.NameSelector)
+		if err != nil {
+			log.Errorf("%s: namespace.nameSelector: %v", c.Metadata.DebugName, err)
+		}
+	}
+	return names, namespaces
+}
+
 // names returns names of monitored objects if nameSelector.matchNames is defined in config.
 func (c *MonitorConfig) names() []string {
 	res := make([]string, 0)
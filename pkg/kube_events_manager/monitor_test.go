@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/flant/kube-client/fake"
 	"github.com/flant/kube-client/manifest"
+	"github.com/flant/shell-operator/pkg/app"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 )
 
@@ -145,3 +147,26 @@ func snapshotResourceIDs(snap []ObjectAndFilterResult) []string {
 	}
 	return ids
 }
+
+func Test_MonitorConfig_InformerTimings(t *testing.T) {
+	g := NewWithT(t)
+
+	defer func(resync time.Duration, pageSize int, timeout time.Duration) {
+		app.KubeInformerResyncPeriod, app.KubeListPageSize, app.KubeWatchTimeout = resync, pageSize, timeout
+	}(app.KubeInformerResyncPeriod, app.KubeListPageSize, app.KubeWatchTimeout)
+	app.KubeInformerResyncPeriod = time.Hour
+	app.KubeListPageSize = 100
+	app.KubeWatchTimeout = 0
+
+	// Global settings are used by default.
+	resync, pageSize, timeout := (&MonitorConfig{}).informerTimings()
+	g.Expect(resync).To(Equal(time.Hour))
+	g.Expect(pageSize).To(Equal(int64(100)))
+	g.Expect(timeout).To(Equal(time.Duration(0)))
+
+	// Binding settings override global settings.
+	resync, pageSize, timeout = (&MonitorConfig{ResyncPeriod: time.Minute, ListPageSize: 10, WatchTimeout: 5 * time.Minute}).informerTimings()
+	g.Expect(resync).To(Equal(time.Minute))
+	g.Expect(pageSize).To(Equal(int64(10)))
+	g.Expect(timeout).To(Equal(5 * time.Minute))
+}
@@ -0,0 +1,96 @@
+package kube_events_manager
+
+import (
+	"fmt"
+	"regexp"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+// nameMatcher checks names against matchExpressions of the nameSelector.
+// matchNames are used to start informers and are not checked here.
+// A nil nameMatcher matches all names.
+type nameMatcher struct {
+	requirements []nameRequirement
+}
+
+type nameRequirement struct {
+	operator NameSelectorOperator
+	values   map[string]struct{}
+	regexps  []*regexp.Regexp
+}
+
+// newNameMatcher compiles matchExpressions. It returns nil if selector has no expressions.
+func newNameMatcher(selector *NameSelector) (*nameMatcher, error) {
+	if selector == nil || len(selector.MatchExpressions) == 0 {
+		return nil, nil
+	}
+
+	m := &nameMatcher{}
+	for i, expr := range selector.MatchExpressions {
+		req := nameRequirement{operator: expr.Operator}
+		switch expr.Operator {
+		case NameIn, NameNotIn:
+			req.values = make(map[string]struct{}, len(expr.Values))
+			for _, v := range expr.Values {
+				req.values[v] = struct{}{}
+			}
+		case NameRegex, NameNotRegex:
+			for _, v := range expr.Values {
+				re, err := regexp.Compile(v)
+				if err != nil {
+					return nil, fmt.Errorf("matchExpressions[%d]: invalid regular expression '%s': %v", i, v, err)
+				}
+				req.regexps = append(req.regexps, re)
+			}
+		default:
+			return nil, fmt.Errorf("matchExpressions[%d]: unknown operator '%s'", i, expr.Operator)
+		}
+		m.requirements = append(m.requirements, req)
+	}
+	return m, nil
+}
+
+// Match returns true if name satisfies all requirements.
+func (m *nameMatcher) Match(name string) bool {
+	if m == nil {
+		return true
+	}
+	for _, req := range m.requirements {
+		if !req.match(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r nameRequirement) match(name string) bool {
+	switch r.operator {
+	case NameIn:
+		_, has := r.values[name]
+		return has
+	case NameNotIn:
+		_, has := r.values[name]
+		return !has
+	case NameRegex:
+		return r.matchAnyRegexp(name)
+	case NameNotRegex:
+		return !r.matchAnyRegexp(name)
+	}
+	return false
+}
+
+func (r nameRequirement) matchAnyRegexp(name string) bool {
+	for _, re := range r.regexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateNameSelector checks operators and regular expressions in matchExpressions.
+func ValidateNameSelector(selector *NameSelector) error {
+	_, err := newNameMatcher(selector)
+	return err
+}
@@ -0,0 +1,54 @@
+package kube_events_manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_nameMatcher(t *testing.T) {
+	m, err := newNameMatcher(&NameSelector{
+		MatchExpressions: []NameSelectorRequirement{
+			{Operator: NameNotIn, Values: []string{"kube-root-ca.crt"}},
+			{Operator: NameNotRegex, Values: []string{"^sh\\.helm\\."}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, m.Match("app-config"))
+	assert.False(t, m.Match("kube-root-ca.crt"))
+	assert.False(t, m.Match("sh.helm.release.v1.app.v1"))
+
+	m, err = newNameMatcher(&NameSelector{
+		MatchExpressions: []NameSelectorRequirement{
+			{Operator: NameRegex, Values: []string{"^cm-", "-config$"}},
+			{Operator: NameIn, Values: []string{"cm-a", "app-config", "other"}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, m.Match("cm-a"))
+	assert.True(t, m.Match("app-config"))
+	assert.False(t, m.Match("other"))
+	assert.False(t, m.Match("cm-b"))
+}
+
+func Test_nameMatcher_Nil(t *testing.T) {
+	m, err := newNameMatcher(&NameSelector{MatchNames: []string{"a"}})
+	require.NoError(t, err)
+	assert.Nil(t, m)
+	assert.True(t, m.Match("anything"))
+}
+
+func Test_ValidateNameSelector(t *testing.T) {
+	assert.Error(t, ValidateNameSelector(&NameSelector{
+		MatchExpressions: []NameSelectorRequirement{{Operator: NameRegex, Values: []string{"("}}},
+	}))
+	assert.Error(t, ValidateNameSelector(&NameSelector{
+		MatchExpressions: []NameSelectorRequirement{{Operator: "Exists", Values: []string{"a"}}},
+	}))
+	assert.NoError(t, ValidateNameSelector(nil))
+}
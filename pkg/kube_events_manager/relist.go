@@ -0,0 +1,81 @@
+package kube_events_manager
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// relistStorms is shared by all informers: a mass relist affects all watches at once.
+var relistStorms = newRelistStormDetector()
+
+// relistStormDetector counts failed watches. Every failed watch leads to a relist,
+// so many failures in a short window mean a thundering herd of events is coming.
+type relistStormDetector struct {
+	m           sync.Mutex
+	failures    []time.Time
+	activeUntil time.Time
+}
+
+func newRelistStormDetector() *relistStormDetector {
+	return &relistStormDetector{}
+}
+
+// observe registers a failed watch. It returns true when a new storm is detected.
+func (d *relistStormDetector) observe(now time.Time, threshold int, window time.Duration) bool {
+	if threshold <= 0 {
+		return false
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	// Drop failures outside the window.
+	recent := d.failures[:0]
+	for _, t := range d.failures {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	d.failures = append(recent, now)
+
+	if len(d.failures) < threshold {
+		return false
+	}
+
+	started := !now.Before(d.activeUntil)
+	d.activeUntil = now.Add(window)
+	return started
+}
+
+// active returns true if events are received during the storm.
+func (d *relistStormDetector) active(now time.Time) bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return now.Before(d.activeUntil)
+}
+
+// jitter returns a random delay for events during the storm.
+func (d *relistStormDetector) jitter(now time.Time, maxJitter time.Duration) time.Duration {
+	if maxJitter <= 0 || !d.active(now) {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxJitter)))
+}
+
+// observeFailedWatch is called by the watch error handler.
+func observeFailedWatch(metricStorage *metric_storage.MetricStorage) {
+	if !relistStorms.observe(time.Now(), app.KubeRelistStormThreshold, app.KubeRelistStormWindow) {
+		return
+	}
+	log.Warnf("Relist storm detected: %d or more watches failed within %s, spread events with jitter up to %s",
+		app.KubeRelistStormThreshold, app.KubeRelistStormWindow.String(), app.KubeRelistJitter.String())
+	if metricStorage != nil {
+		metricStorage.CounterAdd("{PREFIX}kubernetes_client_relist_storms_total", 1.0, map[string]string{})
+	}
+}
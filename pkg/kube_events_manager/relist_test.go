@@ -0,0 +1,30 @@
+package kube_events_manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_relistStormDetector(t *testing.T) {
+	d := newRelistStormDetector()
+	now := time.Now()
+
+	assert.False(t, d.observe(now, 0, time.Minute), "detection should be disabled with zero threshold")
+	assert.False(t, d.active(now))
+
+	assert.False(t, d.observe(now, 3, time.Minute))
+	// Failure outside the window is not counted.
+	assert.False(t, d.observe(now.Add(2*time.Minute), 3, time.Minute))
+	assert.False(t, d.observe(now.Add(2*time.Minute+time.Second), 3, time.Minute))
+	assert.True(t, d.observe(now.Add(2*time.Minute+2*time.Second), 3, time.Minute), "storm should be detected")
+	assert.False(t, d.observe(now.Add(2*time.Minute+3*time.Second), 3, time.Minute), "storm should be reported once")
+
+	assert.True(t, d.active(now.Add(3*time.Minute)))
+	assert.Less(t, d.jitter(now.Add(3*time.Minute), time.Second), time.Second)
+	assert.Equal(t, time.Duration(0), d.jitter(now.Add(3*time.Minute), 0))
+
+	assert.False(t, d.active(now.Add(4*time.Minute)))
+	assert.Equal(t, time.Duration(0), d.jitter(now.Add(4*time.Minute), time.Second))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/trace"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,8 +16,11 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/kube/api_errors"
 	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
 	"github.com/flant/shell-operator/pkg/metric_storage"
+	utils "github.com/flant/shell-operator/pkg/utils/labels"
 	"github.com/flant/shell-operator/pkg/utils/measure"
 )
 
@@ -37,6 +41,10 @@ type resourceInformer struct {
 	// A cache of objects and filterResults. It is a part of the Monitor's snapshot.
 	cachedObjects map[string]*ObjectAndFilterResult
 	cacheLock     sync.RWMutex
+	// Estimated size of full objects in the cache.
+	cachedBytes int64
+	// Full objects are evicted from the cache by the memory budget.
+	fullObjectsEvicted bool
 
 	// Cached objects operations since start
 	cachedObjectsInfo *CachedObjectsInfo
@@ -55,11 +63,41 @@ type resourceInformer struct {
 	// TODO resourceInformer should be stoppable (think of deleted namespaces and disabled modules in addon-operator)
 	ctx    context.Context
 	cancel context.CancelFunc
+	// listCtx is a context of the monitor to interrupt the initial LIST.
+	listCtx context.Context
 
 	metricStorage *metric_storage.MetricStorage
 
 	// a flag to stop handle events after Stop()
 	stopped bool
+
+	// debouncer coalesces events for the same object. It is nil if debounce is not configured.
+	debouncer *eventDebouncer
+
+	// absence reports "Absent" events when no objects are cached. It is nil if absentAfter is not configured.
+	absence *absenceWatchdog
+
+	// history keeps the last events for each object. It is nil if history is disabled.
+	history *eventHistory
+
+	// Events held during the relist storm to spread hook executions.
+	heldEvents     []KubeEvent
+	heldEventsLock sync.Mutex
+
+	// Matchers for matchExpressions of nameSelector and namespace.nameSelector. Nil matches all.
+	nameMatcher      *nameMatcher
+	namespaceMatcher *nameMatcher
+
+	// storage saves the cache outside of the process. It is nil if snapshots are kept only in memory.
+	storage       SnapshotStorage
+	storageKey    string
+	storageWriter *snapshotStorageWriter
+
+	// annotationChecksums are checksums of keys from onAnnotationChange for cached objects.
+	annotationChecksums map[string]string
+
+	// eventsFilter drops duplicated Event objects. It is nil for bindings other than kubernetesEvents.
+	eventsFilter *eventsFilter
 }
 
 // resourceInformer should implement ResourceInformer
@@ -68,6 +106,10 @@ type resourceInformerConfig struct {
 	mstor   *metric_storage.MetricStorage
 	eventCb func(KubeEvent)
 	monitor *MonitorConfig
+	// eventsFilter is shared by informers of the monitor.
+	eventsFilter *eventsFilter
+	// ctx interrupts the initial LIST on shutdown.
+	ctx context.Context
 }
 
 func newResourceInformer(ns, name string, cfg *resourceInformerConfig) *resourceInformer {
@@ -80,10 +122,40 @@ func newResourceInformer(ns, name string, cfg *resourceInformerConfig) *resource
 		eventCb:                cfg.eventCb,
 		Monitor:                cfg.monitor,
 		cachedObjects:          make(map[string]*ObjectAndFilterResult),
+		annotationChecksums:    make(map[string]string),
 		cacheLock:              sync.RWMutex{},
 		eventBufLock:           sync.Mutex{},
 		cachedObjectsInfo:      &CachedObjectsInfo{},
 		cachedObjectsIncrement: &CachedObjectsInfo{},
+		storage:                DefaultSnapshotStorage,
+		storageWriter:          newSnapshotStorageWriter(),
+		eventsFilter:           cfg.eventsFilter,
+		listCtx:                cfg.ctx,
+	}
+	if informer.listCtx == nil {
+		informer.listCtx = context.Background()
+	}
+	if cfg.monitor != nil && cfg.monitor.DebounceWindow > 0 {
+		informer.debouncer = newEventDebouncer(cfg.monitor.DebounceWindow, func(resourceId string, eventType WatchEventType, obj *ObjectAndFilterResult) {
+			if informer.stopped {
+				return
+			}
+			informer.sendEvent(resourceId, eventType, obj)
+		})
+	}
+	if app.DebugKubeEventHistory > 0 {
+		informer.history = newEventHistory(app.DebugKubeEventHistory)
+	}
+	if cfg.monitor != nil {
+		informer.nameMatcher, informer.namespaceMatcher = cfg.monitor.nameMatchers()
+	}
+	if cfg.monitor != nil && cfg.monitor.AbsentAfter > 0 {
+		informer.absence = newAbsenceWatchdog(cfg.monitor.AbsentAfter, informer.cachedObjectsCount, func() {
+			if informer.stopped {
+				return
+			}
+			informer.sendAbsentEvent()
+		})
 	}
 	return informer
 }
@@ -104,7 +176,7 @@ func (ei *resourceInformer) createSharedInformer() (err error) {
 	ei.GroupVersionResource, err = ei.KubeClient.GroupVersionResource(ei.Monitor.ApiVersion, ei.Monitor.Kind)
 	if err != nil {
 		log.Errorf("%s: Cannot get GroupVersionResource info for apiVersion '%s' kind '%s' from api-server. Possibly CRD is not created before informers are started. Error was: %v", ei.Monitor.Metadata.DebugName, ei.Monitor.ApiVersion, ei.Monitor.Kind, err)
-		return err
+		return api_errors.Wrap(err)
 	}
 	log.Debugf("%s: GVR for kind '%s' is '%s'", ei.Monitor.Metadata.DebugName, ei.Monitor.Kind, ei.GroupVersionResource.String())
 
@@ -125,12 +197,19 @@ func (ei *resourceInformer) createSharedInformer() (err error) {
 		LabelSelector: fmtLabelSelector,
 	}
 
+	resyncPeriod, listPageSize, watchTimeout := ei.Monitor.informerTimings()
+	ei.ListOptions.Limit = listPageSize
+
 	ei.FactoryIndex = FactoryIndex{
 		GVR:           ei.GroupVersionResource,
 		Namespace:     ei.Namespace,
 		FieldSelector: ei.ListOptions.FieldSelector,
 		LabelSelector: ei.ListOptions.LabelSelector,
+		ResyncPeriod:  resyncPeriod,
+		ListPageSize:  listPageSize,
+		WatchTimeout:  watchTimeout,
 	}
+	ei.storageKey = snapshotStorageKey(ei.Monitor, ei.FactoryIndex, ei.Name)
 
 	err = ei.loadExistedObjects()
 	if err != nil {
@@ -178,17 +257,23 @@ func (ei *resourceInformer) enableKubeEventCb() {
 func (ei *resourceInformer) loadExistedObjects() error {
 	defer trace.StartRegion(context.Background(), "loadExistedObjects").End()
 
-	objList, err := ei.KubeClient.Dynamic().
-		Resource(ei.GroupVersionResource).
-		Namespace(ei.Namespace).
-		List(context.TODO(), ei.ListOptions)
+	stored := ei.loadStoredObjects()
+
+	release, err := DefaultStartupThrottle.acquireList(ei.listCtx)
 	if err != nil {
-		log.Errorf("%s: initial list resources of kind '%s': %v", ei.Monitor.Metadata.DebugName, ei.Monitor.Kind, err)
 		return err
 	}
+	objList, err := ei.listObjects(ei.listCtx)
+	release()
+	if err != nil {
+		// Saved objects can be stale, so they are not used as a snapshot.
+		log.Errorf("%s: initial list resources of kind '%s': %v", ei.Monitor.Metadata.DebugName, ei.Monitor.Kind, err)
+		return api_errors.Wrap(err)
+	}
 
 	if objList == nil || len(objList.Items) == 0 {
 		log.Debugf("%s: Got no existing '%s' resources", ei.Monitor.Metadata.DebugName, ei.Monitor.Kind)
+		ei.syncStoredObjects(stored, nil)
 		return nil
 	}
 
@@ -197,19 +282,31 @@ func (ei *resourceInformer) loadExistedObjects() error {
 	log.Debugf("%s: '%s' initial list: Got %d existing resources", ei.Monitor.Metadata.DebugName, ei.Monitor.Kind, len(objList.Items))
 
 	filteredObjects := make(map[string]*ObjectAndFilterResult)
+	listedObjects := make(map[string]StoredObject)
 
 	for _, item := range objList.Items {
 		// copy loop var to avoid duplication of pointer in filteredObjects
 		obj := item
 
+		if !ei.matchName(&obj) {
+			continue
+		}
+
 		var objFilterRes *ObjectAndFilterResult
 		var err error
-		func() {
-			defer measure.Duration(func(d time.Duration) {
-				ei.metricStorage.HistogramObserve("{PREFIX}kube_jq_filter_duration_seconds", d.Seconds(), ei.Monitor.Metadata.MetricLabels, nil)
-			})()
-			objFilterRes, err = applyFilter(ei.Monitor.JqFilter, ei.Monitor.FilterFunc, &obj)
-		}()
+		if storedObj, has := stored[resourceId(&obj)]; has && storedObj.matches(ei.Monitor.JqFilter, &obj) {
+			// The object is not changed since it was saved, so the saved filter result is actual.
+			objFilterRes = storedObj.objectAndFilterResult()
+			objFilterRes.Object = &obj
+			objFilterRes.Metadata.RemoveObject = false
+		} else {
+			func() {
+				defer measure.Duration(func(d time.Duration) {
+					ei.metricStorage.HistogramObserve("{PREFIX}kube_jq_filter_duration_seconds", d.Seconds(), ei.Monitor.Metadata.MetricLabels, nil)
+				})()
+				objFilterRes, err = applyFilter(ei.Monitor.JqFilter, ei.Monitor.FilterFunc, &obj)
+			}()
+		}
 
 		if err != nil {
 			return err
@@ -219,6 +316,8 @@ func (ei *resourceInformer) loadExistedObjects() error {
 			objFilterRes.RemoveFullObject()
 		}
 
+		listedObjects[objFilterRes.Metadata.ResourceId] = newStoredObject(&obj, objFilterRes)
+
 		filteredObjects[objFilterRes.Metadata.ResourceId] = objFilterRes
 
 		log.Debugf("%s: initial list: '%s' is cached with checksum %s",
@@ -229,17 +328,52 @@ func (ei *resourceInformer) loadExistedObjects() error {
 
 	// Save objects to the cache.
 	ei.cacheLock.Lock()
-	defer ei.cacheLock.Unlock()
 	for k, v := range filteredObjects {
-		ei.cachedObjects[k] = v
+		ei.storeCachedObject(k, v)
+	}
+	if len(ei.Monitor.OnAnnotationChange) > 0 {
+		for i := range objList.Items {
+			obj := &objList.Items[i]
+			if _, has := filteredObjects[resourceId(obj)]; has {
+				ei.annotationsChanged(resourceId(obj), obj)
+			}
+		}
 	}
 
 	ei.cachedObjectsInfo.Count = uint64(len(ei.cachedObjects))
 	ei.metricStorage.GaugeSet("{PREFIX}kube_snapshot_objects", float64(len(ei.cachedObjects)), ei.Monitor.Metadata.MetricLabels)
+	ei.cacheLock.Unlock()
+
+	ei.updateMemoryBudget()
+	ei.syncStoredObjects(stored, listedObjects)
 
 	return nil
 }
 
+// listObjects lists objects page by page if the page size is set.
+func (ei *resourceInformer) listObjects(ctx context.Context) (*unstructured.UnstructuredList, error) {
+	opts := ei.ListOptions
+	var res *unstructured.UnstructuredList
+	for {
+		page, err := ei.KubeClient.Dynamic().
+			Resource(ei.GroupVersionResource).
+			Namespace(ei.Namespace).
+			List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			res = page
+		} else {
+			res.Items = append(res.Items, page.Items...)
+		}
+		if page.GetContinue() == "" {
+			return res, nil
+		}
+		opts.Continue = page.GetContinue()
+	}
+}
+
 func (ei *resourceInformer) OnAdd(obj interface{}, _ bool) {
 	ei.handleWatchEvent(obj, WatchEventAdded)
 }
@@ -277,6 +411,10 @@ func (ei *resourceInformer) handleWatchEvent(object interface{}, eventType Watch
 	}
 	obj := object.(*unstructured.Unstructured)
 
+	if !ei.matchName(obj) {
+		return
+	}
+
 	resourceId := resourceId(obj)
 
 	// Always calculate checksum and update cache, because we need an actual state in ei.cachedObjects.
@@ -311,7 +449,19 @@ func (ei *resourceInformer) handleWatchEvent(object interface{}, eventType Watch
 		ei.cacheLock.Lock()
 		cachedObject, objectInCache := ei.cachedObjects[resourceId]
 		skipEvent := false
-		if objectInCache && cachedObject.Metadata.Checksum == objFilterRes.Metadata.Checksum {
+		skipReason := EventSkippedChecksum
+		if len(ei.Monitor.OnAnnotationChange) > 0 {
+			// Only listed annotations and labels are compared for bindings with onAnnotationChange.
+			if !ei.annotationsChanged(resourceId, obj) && objectInCache {
+				log.Debugf("%s: %s %s: annotations are not changed, no KubeEvent",
+					ei.Monitor.Metadata.DebugName,
+					string(eventType),
+					resourceId,
+				)
+				skipEvent = true
+				skipReason = EventSkippedAnnotation
+			}
+		} else if objectInCache && cachedObject.Metadata.Checksum == objFilterRes.Metadata.Checksum {
 			// update object in cache and do not send event
 			log.Debugf("%s: %s %s: checksum is not changed, no KubeEvent",
 				ei.Monitor.Metadata.DebugName,
@@ -320,7 +470,7 @@ func (ei *resourceInformer) handleWatchEvent(object interface{}, eventType Watch
 			)
 			skipEvent = true
 		}
-		ei.cachedObjects[resourceId] = objFilterRes
+		ei.storeCachedObject(resourceId, objFilterRes)
 		// Update cached objects info.
 		ei.cachedObjectsInfo.Count = uint64(len(ei.cachedObjects))
 		if eventType == WatchEventAdded {
@@ -333,13 +483,17 @@ func (ei *resourceInformer) handleWatchEvent(object interface{}, eventType Watch
 		// Update metrics.
 		ei.metricStorage.GaugeSet("{PREFIX}kube_snapshot_objects", float64(len(ei.cachedObjects)), ei.Monitor.Metadata.MetricLabels)
 		ei.cacheLock.Unlock()
+		ei.updateMemoryBudget()
+		ei.putStoredObject(newStoredObject(obj, objFilterRes))
 		if skipEvent {
+			ei.recordHistory(resourceId, eventType, objFilterRes, skipReason)
 			return
 		}
 
 	case WatchEventDeleted:
 		ei.cacheLock.Lock()
-		delete(ei.cachedObjects, resourceId)
+		ei.deleteCachedObject(resourceId)
+		delete(ei.annotationChecksums, resourceId)
 		// Update cached objects info.
 		ei.cachedObjectsInfo.Count = uint64(len(ei.cachedObjects))
 		if ei.cachedObjectsInfo.Count == 0 {
@@ -351,44 +505,266 @@ func (ei *resourceInformer) handleWatchEvent(object interface{}, eventType Watch
 		// Update metrics.
 		ei.metricStorage.GaugeSet("{PREFIX}kube_snapshot_objects", float64(len(ei.cachedObjects)), ei.Monitor.Metadata.MetricLabels)
 		ei.cacheLock.Unlock()
+		ei.updateMemoryBudget()
+		ei.deleteStoredObject(resourceId)
 	}
 
-	// Fire KubeEvent only if needed.
-	if ei.shouldFireEvent(eventType) {
-		log.Debugf("%s: %s %s: send KubeEvent",
-			ei.Monitor.Metadata.DebugName,
-			string(eventType),
-			resourceId,
-		)
-		// TODO: should be disabled by default and enabled by a debug feature switch
-		// log.Debugf("HandleKubeEvent: obj type is %T, value:\n%#v", obj, obj)
-
-		kubeEvent := KubeEvent{
-			Type:        TypeEvent,
-			MonitorId:   ei.Monitor.Metadata.MonitorId,
-			WatchEvents: []WatchEventType{eventType},
-			Objects:     []ObjectAndFilterResult{*objFilterRes},
+	if ei.eventsFilter != nil && eventType != WatchEventDeleted {
+		if skipped := ei.eventsFilter.check(obj, time.Now()); skipped != "" {
+			ei.dropEvent(resourceId, eventType, objFilterRes, skipped)
+			return
 		}
+	}
+
+	if ei.debouncer != nil {
+		ei.debouncer.add(resourceId, eventType, objFilterRes)
+		return
+	}
+
+	ei.sendEvent(resourceId, eventType, objFilterRes)
+}
+
+// sendEvent fires KubeEvent for the object if event type is enabled in the binding.
+func (ei *resourceInformer) sendEvent(resourceId string, eventType WatchEventType, objFilterRes *ObjectAndFilterResult) {
+	// Fire KubeEvent only if needed.
+	if !ei.shouldFireEvent(eventType) {
+		ei.recordHistory(resourceId, eventType, objFilterRes, EventSkippedNotEnabled)
+		return
+	}
+	ei.recordHistory(resourceId, eventType, objFilterRes, "")
+
+	log.Debugf("%s: %s %s: send KubeEvent",
+		ei.Monitor.Metadata.DebugName,
+		string(eventType),
+		resourceId,
+	)
+	// TODO: should be disabled by default and enabled by a debug feature switch
+	// log.Debugf("HandleKubeEvent: obj type is %T, value:\n%#v", obj, obj)
+
+	ei.deliverEvent(KubeEvent{
+		Type:        TypeEvent,
+		MonitorId:   ei.Monitor.Metadata.MonitorId,
+		WatchEvents: []WatchEventType{eventType},
+		Objects:     []ObjectAndFilterResult{*objFilterRes},
+	})
+}
+
+// dropEvent records the Event object dropped by the events filter.
+func (ei *resourceInformer) dropEvent(resourceId string, eventType WatchEventType, objFilterRes *ObjectAndFilterResult, skipped string) {
+	log.Debugf("%s: %s %s: %s, no KubeEvent",
+		ei.Monitor.Metadata.DebugName,
+		string(eventType),
+		resourceId,
+		skipped,
+	)
+	ei.recordHistory(resourceId, eventType, objFilterRes, skipped)
+
+	reason := "duplicate"
+	if skipped == EventSkippedRateLimit {
+		reason = "rate_limit"
+	}
+	ei.metricStorage.CounterAdd("{PREFIX}kube_events_dropped_total", 1.0, utils.MergeLabels(ei.Monitor.Metadata.MetricLabels, map[string]string{"reason": reason}))
+}
+
+// sendAbsentEvent sends "Absent" event with a stub object that describes the expected object.
+func (ei *resourceInformer) sendAbsentEvent() {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(ei.Monitor.ApiVersion)
+	obj.SetKind(ei.Monitor.Kind)
+	if ei.Namespace != "" {
+		obj.SetNamespace(ei.Namespace)
+	}
+	if ei.Name != "" {
+		obj.SetName(ei.Name)
+	}
 
-		// fix race with enableKubeEventCb.
-		eventCbEnabled := false
+	log.Debugf("%s: no objects for %s in ns/%s: send Absent KubeEvent",
+		ei.Monitor.Metadata.DebugName,
+		ei.Monitor.AbsentAfter.String(),
+		ei.Namespace,
+	)
+
+	ei.deliverEvent(KubeEvent{
+		Type:        TypeEvent,
+		MonitorId:   ei.Monitor.Metadata.MonitorId,
+		WatchEvents: []WatchEventType{WatchEventAbsent},
+		Objects:     []ObjectAndFilterResult{{Object: obj}},
+	})
+}
+
+// deliverEvent passes KubeEvent to the callback or stores it until the callback is enabled.
+func (ei *resourceInformer) deliverEvent(kubeEvent KubeEvent) {
+	// fix race with enableKubeEventCb.
+	eventCbEnabled := false
+	ei.eventBufLock.Lock()
+	eventCbEnabled = ei.eventCbEnabled
+	ei.eventBufLock.Unlock()
+
+	if eventCbEnabled {
+		// Pass event info to callback.
+		ei.putEventWithJitter(kubeEvent)
+	} else {
 		ei.eventBufLock.Lock()
-		eventCbEnabled = ei.eventCbEnabled
+		// Save event in buffer until the callback is enabled.
+		if ei.eventBuf == nil {
+			ei.eventBuf = make([]KubeEvent, 0)
+		}
+		ei.eventBuf = append(ei.eventBuf, kubeEvent)
 		ei.eventBufLock.Unlock()
+	}
+}
+
+// putEventWithJitter delays events during the relist storm. All events of the informer
+// are held until a random delay expires to preserve their order.
+func (ei *resourceInformer) putEventWithJitter(kubeEvent KubeEvent) {
+	ei.heldEventsLock.Lock()
+	defer ei.heldEventsLock.Unlock()
 
-		if eventCbEnabled {
-			// Pass event info to callback.
+	// Final Deleted events of the purged informer are not delayed: held events of
+	// the stopped informer are dropped.
+	if ei.stopped {
+		ei.putEvent(kubeEvent)
+		return
+	}
+	if len(ei.heldEvents) == 0 {
+		delay := relistStorms.jitter(time.Now(), app.KubeRelistJitter)
+		if delay == 0 {
 			ei.putEvent(kubeEvent)
-		} else {
-			ei.eventBufLock.Lock()
-			// Save event in buffer until the callback is enabled.
-			if ei.eventBuf == nil {
-				ei.eventBuf = make([]KubeEvent, 0)
-			}
-			ei.eventBuf = append(ei.eventBuf, kubeEvent)
-			ei.eventBufLock.Unlock()
+			return
 		}
+		time.AfterFunc(delay, ei.flushHeldEvents)
+	}
+	ei.heldEvents = append(ei.heldEvents, kubeEvent)
+}
+
+func (ei *resourceInformer) flushHeldEvents() {
+	ei.heldEventsLock.Lock()
+	defer ei.heldEventsLock.Unlock()
+
+	if ei.stopped {
+		ei.heldEvents = nil
+		return
+	}
+	ei.putHeldEvents()
+}
+
+// putHeldEvents delivers held events. heldEventsLock should be locked.
+func (ei *resourceInformer) putHeldEvents() {
+	for _, kubeEvent := range ei.heldEvents {
+		ei.putEvent(kubeEvent)
+	}
+	ei.heldEvents = nil
+}
+
+// recordHistory saves the event into the object's history if history is enabled.
+func (ei *resourceInformer) recordHistory(resourceId string, eventType WatchEventType, objFilterRes *ObjectAndFilterResult, skipped string) {
+	if ei.history == nil {
+		return
+	}
+	ei.history.record(resourceId, EventHistoryEntry{
+		Time:         time.Now(),
+		WatchEvent:   eventType,
+		Checksum:     objFilterRes.Metadata.Checksum,
+		FilterResult: objFilterRes.Map()["filterResult"],
+		Skipped:      skipped,
+	})
+}
+
+// getEventHistory returns histories of objects or nil if history is disabled.
+func (ei *resourceInformer) getEventHistory() []ObjectHistory {
+	if ei.history == nil {
+		return nil
+	}
+	return ei.history.dump()
+}
+
+// storeCachedObject puts the object into the cache and updates the estimated size.
+// The full object is not stored if full objects are evicted. cacheLock should be held.
+func (ei *resourceInformer) storeCachedObject(resourceId string, obj *ObjectAndFilterResult) {
+	if ei.fullObjectsEvicted && obj.Object != nil {
+		objCopy := *obj
+		objCopy.RemoveFullObject()
+		obj = &objCopy
 	}
+	if old, has := ei.cachedObjects[resourceId]; has {
+		ei.cachedBytes -= cachedObjectSize(old)
+	}
+	ei.cachedBytes += cachedObjectSize(obj)
+	ei.cachedObjects[resourceId] = obj
+}
+
+// deleteCachedObject removes the object from the cache. cacheLock should be held.
+func (ei *resourceInformer) deleteCachedObject(resourceId string) {
+	if old, has := ei.cachedObjects[resourceId]; has {
+		ei.cachedBytes -= cachedObjectSize(old)
+	}
+	delete(ei.cachedObjects, resourceId)
+}
+
+func cachedObjectSize(obj *ObjectAndFilterResult) int64 {
+	if obj.Object == nil {
+		return 0
+	}
+	return int64(obj.Metadata.ObjectSize)
+}
+
+// updateMemoryBudget reports the estimated size of the cache to the memory budget.
+func (ei *resourceInformer) updateMemoryBudget() {
+	if !DefaultSnapshotMemoryBudget.enabled() {
+		return
+	}
+	ei.cacheLock.RLock()
+	bytes := ei.cachedBytes
+	ei.cacheLock.RUnlock()
+	DefaultSnapshotMemoryBudget.update(ei, bytes)
+}
+
+// evictFullObjects removes full objects from the cache, only filter results are kept.
+// New objects are cached without full objects too. It returns the freed size.
+func (ei *resourceInformer) evictFullObjects() int64 {
+	ei.cacheLock.Lock()
+	defer ei.cacheLock.Unlock()
+
+	for resourceId, obj := range ei.cachedObjects {
+		if obj.Object == nil {
+			continue
+		}
+		objCopy := *obj
+		objCopy.RemoveFullObject()
+		ei.cachedObjects[resourceId] = &objCopy
+	}
+	freed := ei.cachedBytes
+	ei.cachedBytes = 0
+	ei.fullObjectsEvicted = true
+	return freed
+}
+
+// restoreFullObjects caches new objects with full objects again.
+// It returns true if full objects were evicted.
+func (ei *resourceInformer) restoreFullObjects() bool {
+	ei.cacheLock.Lock()
+	defer ei.cacheLock.Unlock()
+	evicted := ei.fullObjectsEvicted
+	ei.fullObjectsEvicted = false
+	return evicted
+}
+
+// hasFilter returns true if cached objects have filter results. Without a filter
+// only a checksum of the object is calculated, so the full object is the only data.
+func (ei *resourceInformer) hasFilter() bool {
+	return ei.Monitor.JqFilter != "" || ei.Monitor.FilterFunc != nil
+}
+
+func (ei *resourceInformer) isFullObjectsEvicted() bool {
+	ei.cacheLock.RLock()
+	defer ei.cacheLock.RUnlock()
+	return ei.fullObjectsEvicted
+}
+
+func (ei *resourceInformer) cachedObjectsCount() int {
+	ei.cacheLock.RLock()
+	defer ei.cacheLock.RUnlock()
+	return len(ei.cachedObjects)
 }
 
 func (ei *resourceInformer) adjustFieldSelector(selector *FieldSelector, objName string) *FieldSelector {
@@ -420,6 +796,17 @@ func (ei *resourceInformer) adjustFieldSelector(selector *FieldSelector, objName
 	return selectorCopy
 }
 
+// matchName checks the object name and namespace against matchExpressions
+// and the reason of Event objects for kubernetesEvents bindings.
+func (ei *resourceInformer) matchName(obj *unstructured.Unstructured) bool {
+	return ei.nameMatcher.Match(obj.GetName()) && ei.namespaceMatcher.Match(obj.GetNamespace()) && ei.eventsFilter.matchReason(obj)
+}
+
+// subscriptionName is used in event bus stats.
+func (ei *resourceInformer) subscriptionName() string {
+	return ei.Monitor.Metadata.DebugName
+}
+
 func (ei *resourceInformer) shouldFireEvent(checkEvent WatchEventType) bool {
 	for _, event := range ei.Monitor.EventTypes {
 		if event == checkEvent {
@@ -439,6 +826,10 @@ func (ei *resourceInformer) start() {
 		}
 	}()
 
+	if ei.ctx != nil {
+		go ei.runStorageWriter(ei.ctx)
+	}
+
 	// TODO: separate handler and informer
 	errorHandler := newWatchErrorHandler(ei.Monitor.Metadata.DebugName, ei.Monitor.Kind, ei.Monitor.Metadata.LogLabels, ei.metricStorage)
 	err := DefaultFactoryStore.Start(ei.ctx, ei.id, ei.KubeClient.Dynamic(), ei.FactoryIndex, ei, errorHandler)
@@ -447,9 +838,50 @@ func (ei *resourceInformer) start() {
 		return
 	}
 
+	if ei.absence != nil && ei.ctx != nil {
+		go ei.absence.run(ei.ctx)
+	}
+
 	log.Debugf("%s: informer is ready", ei.Monitor.Metadata.DebugName)
 }
 
+// purgeCachedObjects stops handling of watch events, clears the cache and sends
+// Deleted events for the remaining objects sorted by namespace and name.
+// It is used when the namespace of the informer is deleted. Unlike Stop, events held
+// during the relist storm are delivered before Deleted events, and Deleted events
+// are not delayed.
+func (ei *resourceInformer) purgeCachedObjects() {
+	ei.heldEventsLock.Lock()
+	ei.pauseHandleEvents()
+	ei.putHeldEvents()
+	ei.heldEventsLock.Unlock()
+
+	ei.cacheLock.Lock()
+	objects := make([]ObjectAndFilterResult, 0, len(ei.cachedObjects))
+	for _, obj := range ei.cachedObjects {
+		objects = append(objects, *obj)
+	}
+	ei.cachedObjects = make(map[string]*ObjectAndFilterResult)
+	ei.annotationChecksums = make(map[string]string)
+	ei.cachedBytes = 0
+	ei.cachedObjectsInfo.Count = 0
+	ei.metricStorage.GaugeSet("{PREFIX}kube_snapshot_objects", 0, ei.Monitor.Metadata.MetricLabels)
+	ei.cachedObjectsInfo.Deleted += uint64(len(objects))
+	ei.cachedObjectsIncrement.Deleted += uint64(len(objects))
+	if len(objects) > 0 {
+		ei.cachedObjectsInfo.Cleaned++
+		ei.cachedObjectsIncrement.Cleaned++
+	}
+	ei.cacheLock.Unlock()
+	DefaultSnapshotMemoryBudget.remove(ei)
+	ei.purgeStoredObjects()
+
+	sort.Sort(ByNamespaceAndName(objects))
+	for i := range objects {
+		ei.sendEvent(objects[i].Metadata.ResourceId, WatchEventDeleted, &objects[i])
+	}
+}
+
 func (ei *resourceInformer) pauseHandleEvents() {
 	log.Debugf("%s: PAUSE resource informer", ei.Monitor.Metadata.DebugName)
 	ei.stopped = true
@@ -0,0 +1,63 @@
+package kube_events_manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+func Test_resourceInformer_purgeCachedObjects(t *testing.T) {
+	monitorCfg := &MonitorConfig{}
+	monitorCfg.WithEventTypes(nil)
+
+	events := make([]KubeEvent, 0)
+	informer := newResourceInformer("ns", "", &resourceInformerConfig{
+		monitor: monitorCfg,
+		eventCb: func(ev KubeEvent) {
+			events = append(events, ev)
+		},
+	})
+	informer.eventCbEnabled = true
+
+	for _, id := range []string{"ns/ConfigMap/cm-b", "ns/ConfigMap/cm-a"} {
+		obj := &ObjectAndFilterResult{}
+		obj.Metadata.ResourceId = id
+		informer.cachedObjects[id] = obj
+	}
+
+	// An event held during the relist storm is delivered before Deleted events.
+	informer.heldEvents = []KubeEvent{{WatchEvents: []WatchEventType{WatchEventModified}}}
+
+	informer.purgeCachedObjects()
+
+	assert.True(t, informer.stopped)
+	assert.Empty(t, informer.getCachedObjects())
+	assert.Empty(t, informer.heldEvents)
+	assert.Len(t, events, 3)
+	assert.Equal(t, []WatchEventType{WatchEventModified}, events[0].WatchEvents)
+	assert.Equal(t, []WatchEventType{WatchEventDeleted}, events[1].WatchEvents)
+	assert.Equal(t, "ns/ConfigMap/cm-a", events[1].Objects[0].Metadata.ResourceId, "Deleted events should be sorted")
+	assert.Equal(t, "ns/ConfigMap/cm-b", events[2].Objects[0].Metadata.ResourceId)
+}
+
+func Test_resourceInformer_flushHeldEvents_Stopped(t *testing.T) {
+	monitorCfg := &MonitorConfig{}
+	monitorCfg.WithEventTypes(nil)
+
+	events := make([]KubeEvent, 0)
+	informer := newResourceInformer("ns", "", &resourceInformerConfig{
+		monitor: monitorCfg,
+		eventCb: func(ev KubeEvent) {
+			events = append(events, ev)
+		},
+	})
+	informer.heldEvents = []KubeEvent{{WatchEvents: []WatchEventType{WatchEventModified}}}
+	informer.pauseHandleEvents()
+
+	// Held events of the stopped informer are dropped.
+	informer.flushHeldEvents()
+	assert.Empty(t, events)
+	assert.Empty(t, informer.heldEvents)
+}
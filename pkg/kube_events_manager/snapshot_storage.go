@@ -0,0 +1,362 @@
+package kube_events_manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/flant/shell-operator/pkg/app"
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+// DefaultSnapshotStorage is shared by all informers. Nil means that
+// snapshots are kept only in memory.
+var DefaultSnapshotStorage SnapshotStorage
+
+// SnapshotStorage saves snapshots outside of the operator process. Replicas
+// with the same storage reuse saved filter results after failover instead of
+// running jqFilter for every object. The list request is still made: saved
+// objects are never used as a snapshot without it, they can be stale.
+//
+// Each informer saves its objects under its own key, see snapshotStorageKey.
+// Changes are saved in the background by snapshotStorageWriter, so the storage
+// does not block watch events.
+type SnapshotStorage interface {
+	// Load returns saved objects for the key.
+	Load(key string) ([]StoredObject, error)
+	// Put saves the object.
+	Put(key string, obj StoredObject) error
+	// Delete removes the object.
+	Delete(key string, resourceId string) error
+	// Purge removes all objects for the key.
+	Purge(key string) error
+}
+
+// StoredObject is a cached object with metadata needed to reuse the filter result.
+type StoredObject struct {
+	ResourceId      string                     `json:"resourceId"`
+	UID             string                     `json:"uid"`
+	ResourceVersion string                     `json:"resourceVersion"`
+	JqFilter        string                     `json:"jqFilter,omitempty"`
+	Checksum        string                     `json:"checksum"`
+	ObjectSize      int                        `json:"objectSize"`
+	FilterResult    interface{}                `json:"filterResult,omitempty"`
+	Object          *unstructured.Unstructured `json:"object,omitempty"`
+}
+
+func newStoredObject(obj *unstructured.Unstructured, res *ObjectAndFilterResult) StoredObject {
+	return StoredObject{
+		ResourceId:      res.Metadata.ResourceId,
+		UID:             string(obj.GetUID()),
+		ResourceVersion: obj.GetResourceVersion(),
+		JqFilter:        res.Metadata.JqFilter,
+		Checksum:        res.Metadata.Checksum,
+		ObjectSize:      res.Metadata.ObjectSize,
+		FilterResult:    res.FilterResult,
+		Object:          res.Object,
+	}
+}
+
+// matches returns true if the stored filter result is actual for the object.
+func (s StoredObject) matches(jqFilter string, obj *unstructured.Unstructured) bool {
+	return s.UID != "" && s.ResourceVersion != "" &&
+		s.JqFilter == jqFilter &&
+		s.UID == string(obj.GetUID()) &&
+		s.ResourceVersion == obj.GetResourceVersion()
+}
+
+// objectAndFilterResult restores a cached object. The full object is removed
+// if it was not saved.
+func (s StoredObject) objectAndFilterResult() *ObjectAndFilterResult {
+	res := &ObjectAndFilterResult{
+		Object:       s.Object,
+		FilterResult: s.FilterResult,
+	}
+	res.Metadata.ResourceId = s.ResourceId
+	res.Metadata.JqFilter = s.JqFilter
+	res.Metadata.Checksum = s.Checksum
+	res.Metadata.ObjectSize = s.ObjectSize
+	if s.Object == nil {
+		res.RemoveFullObject()
+	}
+	return res
+}
+
+// NewSnapshotStorage returns a storage configured with flags. It returns nil for the "memory" backend.
+func NewSnapshotStorage() (SnapshotStorage, error) {
+	switch app.KubeSnapshotStorage {
+	case "", app.KubeSnapshotStorageMemory:
+		return nil, nil
+	case app.KubeSnapshotStorageEtcd:
+		if app.KubeSnapshotStorageEndpoint == "" {
+			return nil, fmt.Errorf("kube-snapshot-storage-endpoint is required for '%s' snapshot storage", app.KubeSnapshotStorage)
+		}
+		return NewEtcdSnapshotStorage(app.KubeSnapshotStorageEndpoint, app.KubeSnapshotStoragePrefix, app.KubeSnapshotStorageTimeout), nil
+	}
+	return nil, fmt.Errorf("unknown snapshot storage '%s'", app.KubeSnapshotStorage)
+}
+
+// snapshotStorageKey returns a key of the informer that is the same
+// for all replicas: it depends on the hook, the binding and the informer settings.
+func snapshotStorageKey(monitor *MonitorConfig, index FactoryIndex, name string) string {
+	labels := make([]string, 0, len(monitor.Metadata.MetricLabels))
+	for k, v := range monitor.Metadata.MetricLabels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+
+	h := sha256.New()
+	for _, part := range []string{
+		strings.Join(labels, ","),
+		monitor.Metadata.DebugName,
+		index.GVR.String(),
+		index.Namespace,
+		index.FieldSelector,
+		index.LabelSelector,
+		name,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// loadStoredObjects returns saved objects by resourceId. Errors are logged,
+// the informer works without saved objects in this case.
+func (ei *resourceInformer) loadStoredObjects() map[string]StoredObject {
+	if ei.storage == nil {
+		return nil
+	}
+	objects, err := ei.storage.Load(ei.storageKey)
+	if err != nil {
+		ei.storageError("load", err)
+		return nil
+	}
+	res := make(map[string]StoredObject, len(objects))
+	for _, obj := range objects {
+		res[obj.ResourceId] = obj
+	}
+	log.Debugf("%s: %d objects are loaded from the snapshot storage", ei.Monitor.Metadata.DebugName, len(res))
+	return res
+}
+
+// syncStoredObjects saves listed objects and removes saved objects that are not exist anymore.
+// Objects with the same resourceVersion and jqFilter are not saved again.
+func (ei *resourceInformer) syncStoredObjects(stored map[string]StoredObject, listed map[string]StoredObject) {
+	if ei.storage == nil {
+		return
+	}
+	for id := range stored {
+		if _, has := listed[id]; !has {
+			ei.deleteStoredObject(id)
+		}
+	}
+	for id, obj := range listed {
+		if old, has := stored[id]; has && old.ResourceVersion == obj.ResourceVersion && old.JqFilter == obj.JqFilter && old.Checksum == obj.Checksum {
+			continue
+		}
+		ei.putStoredObject(obj)
+	}
+}
+
+// snapshotStorageFlushInterval is a period to collect changes of the cache into one batch.
+var snapshotStorageFlushInterval = time.Second
+
+// snapshotStorageWriter collects changes of the informer cache. Changes of
+// the same object are coalesced, so the number of pending changes is limited
+// by the number of cached objects even if the storage is not available.
+type snapshotStorageWriter struct {
+	m sync.Mutex
+	// pending are objects to save by resourceId. Nil is for deleted objects.
+	pending map[string]*StoredObject
+	// purge is true if all objects should be removed before saving pending objects.
+	purge bool
+	// closed is true when the informer is stopped and its objects are removed.
+	closed bool
+	notify chan struct{}
+
+	// flushLock serializes requests to the storage.
+	flushLock sync.Mutex
+}
+
+func newSnapshotStorageWriter() *snapshotStorageWriter {
+	return &snapshotStorageWriter{
+		pending: make(map[string]*StoredObject),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+func (w *snapshotStorageWriter) add(resourceId string, obj *StoredObject) {
+	w.m.Lock()
+	if w.closed {
+		w.m.Unlock()
+		return
+	}
+	w.pending[resourceId] = obj
+	w.m.Unlock()
+	w.wakeup()
+}
+
+func (w *snapshotStorageWriter) purgeAll() {
+	w.m.Lock()
+	if w.closed {
+		w.m.Unlock()
+		return
+	}
+	w.pending = make(map[string]*StoredObject)
+	w.purge = true
+	w.m.Unlock()
+	w.wakeup()
+}
+
+func (w *snapshotStorageWriter) wakeup() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the batch of changes and resets it.
+func (w *snapshotStorageWriter) take() (map[string]*StoredObject, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.closed {
+		return nil, false
+	}
+	pending, purge := w.pending, w.purge
+	w.pending = make(map[string]*StoredObject)
+	w.purge = false
+	return pending, purge
+}
+
+// requeue returns not saved changes to the next batch. Newer changes are not overwritten.
+func (w *snapshotStorageWriter) requeue(pending map[string]*StoredObject, purge bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.closed {
+		return
+	}
+	if purge {
+		w.purge = true
+	}
+	for id, obj := range pending {
+		if _, has := w.pending[id]; !has {
+			w.pending[id] = obj
+		}
+	}
+}
+
+func (ei *resourceInformer) putStoredObject(obj StoredObject) {
+	if ei.storage == nil {
+		return
+	}
+	ei.storageWriter.add(obj.ResourceId, &obj)
+}
+
+func (ei *resourceInformer) deleteStoredObject(resourceId string) {
+	if ei.storage == nil {
+		return
+	}
+	ei.storageWriter.add(resourceId, nil)
+}
+
+// purgeStoredObjects removes all saved objects of the informer, e.g. when the namespace is deleted.
+func (ei *resourceInformer) purgeStoredObjects() {
+	if ei.storage == nil {
+		return
+	}
+	ei.storageWriter.purgeAll()
+}
+
+// runStorageWriter saves changes of the cache until the informer is stopped.
+func (ei *resourceInformer) runStorageWriter(ctx context.Context) {
+	if ei.storage == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			ei.flushStoredObjects()
+			return
+		case <-ei.storageWriter.notify:
+		}
+		// Collect changes into one batch.
+		select {
+		case <-ctx.Done():
+		case <-time.After(snapshotStorageFlushInterval):
+		}
+		ei.flushStoredObjects()
+	}
+}
+
+// flushStoredObjects saves pending changes. The batch is stopped on the first error
+// and not saved changes are retried with the next batch.
+func (ei *resourceInformer) flushStoredObjects() {
+	if ei.storage == nil {
+		return
+	}
+	w := ei.storageWriter
+	w.flushLock.Lock()
+	defer w.flushLock.Unlock()
+
+	pending, purge := w.take()
+	if purge {
+		if err := ei.storage.Purge(ei.storageKey); err != nil {
+			ei.storageError("purge", err)
+			w.requeue(pending, true)
+			return
+		}
+	}
+	for id, obj := range pending {
+		var err error
+		operation := "put"
+		if obj == nil {
+			operation = "delete"
+			err = ei.storage.Delete(ei.storageKey, id)
+		} else {
+			err = ei.storage.Put(ei.storageKey, *obj)
+		}
+		if err != nil {
+			ei.storageError(operation, fmt.Errorf("%d changes are postponed: %w", len(pending), err))
+			w.requeue(pending, false)
+			return
+		}
+		delete(pending, id)
+	}
+}
+
+// stopStoredObjects drops pending changes and removes all saved objects of the stopped informer.
+// Objects of a stopped monitor are not needed anymore.
+func (ei *resourceInformer) stopStoredObjects() {
+	if ei.storage == nil {
+		return
+	}
+	w := ei.storageWriter
+	w.m.Lock()
+	w.closed = true
+	w.pending = nil
+	w.m.Unlock()
+
+	w.flushLock.Lock()
+	defer w.flushLock.Unlock()
+	if err := ei.storage.Purge(ei.storageKey); err != nil {
+		ei.storageError("purge", err)
+	}
+}
+
+func (ei *resourceInformer) storageError(operation string, err error) {
+	log.Warnf("%s: snapshot storage %s: %v", ei.Monitor.Metadata.DebugName, operation, err)
+	labels := map[string]string{"operation": operation}
+	for k, v := range ei.Monitor.Metadata.MetricLabels {
+		labels[k] = v
+	}
+	ei.metricStorage.CounterAdd("{PREFIX}kube_snapshot_storage_errors_total", 1.0, labels)
+}
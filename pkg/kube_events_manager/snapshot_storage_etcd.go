@@ -0,0 +1,153 @@
+package kube_events_manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EtcdSnapshotStorage saves snapshots in etcd using the JSON API of
+// etcd v3 gRPC gateway (/v3/kv/*). Objects are saved as JSON values
+// under "<prefix><informer key>/<resourceId>" keys.
+type EtcdSnapshotStorage struct {
+	endpoint string
+	prefix   string
+	client   *http.Client
+	timeout  time.Duration
+}
+
+var _ SnapshotStorage = &EtcdSnapshotStorage{}
+
+func NewEtcdSnapshotStorage(endpoint string, prefix string, timeout time.Duration) *EtcdSnapshotStorage {
+	return &EtcdSnapshotStorage{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		prefix:   prefix,
+		client:   &http.Client{},
+		timeout:  timeout,
+	}
+}
+
+type etcdKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+type etcdRangeRequest struct {
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end,omitempty"`
+}
+
+type etcdRangeResponse struct {
+	Kvs []etcdKeyValue `json:"kvs"`
+}
+
+func (s *EtcdSnapshotStorage) Load(key string) ([]StoredObject, error) {
+	start, end := etcdPrefixRange(s.informerPrefix(key))
+	var resp etcdRangeResponse
+	err := s.call("/v3/kv/range", etcdRangeRequest{Key: b64(start), RangeEnd: b64(end)}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]StoredObject, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		value, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("decode value: %v", err)
+		}
+		var obj StoredObject
+		err = json.Unmarshal(value, &obj)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal stored object: %v", err)
+		}
+		res = append(res, obj)
+	}
+	return res, nil
+}
+
+func (s *EtcdSnapshotStorage) Put(key string, obj StoredObject) error {
+	value, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("marshal stored object: %v", err)
+	}
+	kv := etcdKeyValue{
+		Key:   b64(s.informerPrefix(key) + obj.ResourceId),
+		Value: base64.StdEncoding.EncodeToString(value),
+	}
+	return s.call("/v3/kv/put", kv, nil)
+}
+
+func (s *EtcdSnapshotStorage) Delete(key string, resourceId string) error {
+	return s.call("/v3/kv/deleterange", etcdRangeRequest{Key: b64(s.informerPrefix(key) + resourceId)}, nil)
+}
+
+func (s *EtcdSnapshotStorage) Purge(key string) error {
+	start, end := etcdPrefixRange(s.informerPrefix(key))
+	return s.call("/v3/kv/deleterange", etcdRangeRequest{Key: b64(start), RangeEnd: b64(end)}, nil)
+}
+
+func (s *EtcdSnapshotStorage) informerPrefix(key string) string {
+	return s.prefix + key + "/"
+}
+
+// call sends a request to the gateway and decodes the response into out if it is not nil.
+func (s *EtcdSnapshotStorage) call(path string, in interface{}, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("etcd %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("etcd %s: read response: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd %s: %s: %s", path, resp.Status, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	err = json.Unmarshal(respBody, out)
+	if err != nil {
+		return fmt.Errorf("etcd %s: decode response: %v", path, err)
+	}
+	return nil
+}
+
+// etcdPrefixRange returns a range of keys with the prefix.
+func etcdPrefixRange(prefix string) (string, string) {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return prefix, string(end[:i+1])
+		}
+	}
+	// All bytes are 0xff: range to the end of keys.
+	return prefix, "\x00"
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
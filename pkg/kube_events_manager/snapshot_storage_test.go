@@ -0,0 +1,214 @@
+package kube_events_manager
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/flant/shell-operator/pkg/kube_events_manager/types"
+)
+
+// fakeEtcdGateway implements put, range and deleterange of etcd v3 JSON API.
+func fakeEtcdGateway(t *testing.T) *httptest.Server {
+	var m sync.Mutex
+	kv := map[string]string{}
+
+	decode := func(s string) string {
+		b, err := base64.StdEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return string(b)
+	}
+	inRange := func(k string, req etcdRangeRequest) bool {
+		key := decode(req.Key)
+		if req.RangeEnd == "" {
+			return k == key
+		}
+		return k >= key && k < decode(req.RangeEnd)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+		switch r.URL.Path {
+		case "/v3/kv/put":
+			var req etcdKeyValue
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			kv[decode(req.Key)] = req.Value
+			_, _ = w.Write([]byte("{}"))
+		case "/v3/kv/range":
+			var req etcdRangeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			resp := etcdRangeResponse{}
+			for k, v := range kv {
+				if inRange(k, req) {
+					resp.Kvs = append(resp.Kvs, etcdKeyValue{Key: base64.StdEncoding.EncodeToString([]byte(k)), Value: v})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/v3/kv/deleterange":
+			var req etcdRangeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for k := range kv {
+				if inRange(k, req) {
+					delete(kv, k)
+				}
+			}
+			_, _ = w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func storedIds(objects []StoredObject) []string {
+	ids := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		ids = append(ids, obj.ResourceId)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func Test_EtcdSnapshotStorage(t *testing.T) {
+	srv := fakeEtcdGateway(t)
+	defer srv.Close()
+
+	storage := NewEtcdSnapshotStorage(srv.URL, "/so/", time.Second)
+
+	require.NoError(t, storage.Put("key-1", StoredObject{ResourceId: "ns/Pod/pod-a", Checksum: "a", FilterResult: `{"a":1}`}))
+	require.NoError(t, storage.Put("key-1", StoredObject{ResourceId: "ns/Pod/pod-b", Checksum: "b"}))
+	// Objects of other informers are not loaded.
+	require.NoError(t, storage.Put("key-10", StoredObject{ResourceId: "ns/Pod/pod-c", Checksum: "c"}))
+
+	objects, err := storage.Load("key-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/Pod/pod-a", "ns/Pod/pod-b"}, storedIds(objects))
+
+	require.NoError(t, storage.Delete("key-1", "ns/Pod/pod-a"))
+	objects, err = storage.Load("key-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/Pod/pod-b"}, storedIds(objects))
+
+	require.NoError(t, storage.Purge("key-1"))
+	objects, err = storage.Load("key-1")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	objects, err = storage.Load("key-10")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/Pod/pod-c"}, storedIds(objects))
+}
+
+func Test_StoredObject_matches(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetUID("uid-1")
+	obj.SetResourceVersion("10")
+
+	res := &ObjectAndFilterResult{FilterResult: `"x"`}
+	res.Metadata.ResourceId = "ns/Pod/pod-a"
+	res.Metadata.JqFilter = ".x"
+	res.Metadata.Checksum = "sum"
+	res.RemoveFullObject()
+
+	stored := newStoredObject(obj, res)
+	assert.True(t, stored.matches(".x", obj))
+	assert.False(t, stored.matches(".y", obj), "jqFilter is changed")
+
+	obj.SetResourceVersion("11")
+	assert.False(t, stored.matches(".x", obj), "object is changed")
+
+	restored := stored.objectAndFilterResult()
+	assert.Equal(t, "sum", restored.Metadata.Checksum)
+	assert.True(t, restored.Metadata.RemoveObject)
+	assert.Equal(t, `"x"`, restored.FilterResult)
+}
+
+func Test_resourceInformer_syncStoredObjects(t *testing.T) {
+	srv := fakeEtcdGateway(t)
+	defer srv.Close()
+	storage := NewEtcdSnapshotStorage(srv.URL, "/so/", time.Second)
+
+	monitorCfg := &MonitorConfig{}
+	monitorCfg.WithEventTypes(nil)
+	informer := newResourceInformer("ns", "", &resourceInformerConfig{monitor: monitorCfg})
+	informer.storage = storage
+	informer.storageKey = "key"
+
+	stored := map[string]StoredObject{
+		"ns/Pod/gone":  {ResourceId: "ns/Pod/gone", ResourceVersion: "1"},
+		"ns/Pod/same":  {ResourceId: "ns/Pod/same", ResourceVersion: "1", Checksum: "old"},
+		"ns/Pod/moved": {ResourceId: "ns/Pod/moved", ResourceVersion: "1"},
+	}
+	for _, obj := range stored {
+		require.NoError(t, storage.Put("key", obj))
+	}
+
+	informer.syncStoredObjects(stored, map[string]StoredObject{
+		"ns/Pod/same":  {ResourceId: "ns/Pod/same", ResourceVersion: "1", Checksum: "new"},
+		"ns/Pod/moved": {ResourceId: "ns/Pod/moved", ResourceVersion: "2"},
+		"ns/Pod/new":   {ResourceId: "ns/Pod/new", ResourceVersion: "1"},
+	})
+	informer.flushStoredObjects()
+
+	objects, err := storage.Load("key")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/Pod/moved", "ns/Pod/new", "ns/Pod/same"}, storedIds(objects))
+	for _, obj := range objects {
+		if obj.ResourceId == "ns/Pod/same" {
+			assert.Equal(t, "new", obj.Checksum)
+		}
+	}
+}
+
+func Test_resourceInformer_storageWriter(t *testing.T) {
+	srv := fakeEtcdGateway(t)
+	defer srv.Close()
+	storage := NewEtcdSnapshotStorage(srv.URL, "/so/", time.Second)
+
+	monitorCfg := &MonitorConfig{}
+	monitorCfg.WithEventTypes(nil)
+	informer := newResourceInformer("ns", "", &resourceInformerConfig{monitor: monitorCfg})
+	informer.storage = storage
+	informer.storageKey = "key"
+
+	// Changes are not saved until the flush.
+	informer.putStoredObject(StoredObject{ResourceId: "ns/Pod/pod-a", ResourceVersion: "1"})
+	informer.putStoredObject(StoredObject{ResourceId: "ns/Pod/pod-b", ResourceVersion: "1"})
+	informer.deleteStoredObject("ns/Pod/pod-b")
+	objects, err := storage.Load("key")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	informer.flushStoredObjects()
+	objects, err = storage.Load("key")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/Pod/pod-a"}, storedIds(objects))
+
+	// Changes are retried after errors of the storage.
+	informer.storage = NewEtcdSnapshotStorage("http://127.0.0.1:1", "/so/", time.Second)
+	informer.putStoredObject(StoredObject{ResourceId: "ns/Pod/pod-c", ResourceVersion: "1"})
+	informer.flushStoredObjects()
+	informer.storage = storage
+	informer.flushStoredObjects()
+	objects, err = storage.Load("key")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/Pod/pod-a", "ns/Pod/pod-c"}, storedIds(objects))
+
+	// Objects of the stopped informer are removed and not saved anymore.
+	informer.putStoredObject(StoredObject{ResourceId: "ns/Pod/pod-d", ResourceVersion: "1"})
+	informer.stopStoredObjects()
+	informer.putStoredObject(StoredObject{ResourceId: "ns/Pod/pod-e", ResourceVersion: "1"})
+	informer.flushStoredObjects()
+	objects, err = storage.Load("key")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
@@ -0,0 +1,109 @@
+package kube_events_manager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// DefaultStartupThrottle is shared by all monitors.
+var DefaultStartupThrottle = NewStartupThrottle()
+
+// StartupThrottle smooths the load on the API server when many bindings start at once.
+// Initial LIST requests are limited by a number of concurrent requests and monitors
+// are started in batches with a delay between batches. Limits are applied only
+// until Finish is called, monitors added later are not throttled.
+type StartupThrottle struct {
+	m             sync.Mutex
+	lists         chan struct{}
+	batchSize     int
+	batchDelay    time.Duration
+	started       int
+	finished      bool
+	metricStorage *metric_storage.MetricStorage
+}
+
+func NewStartupThrottle() *StartupThrottle {
+	return &StartupThrottle{}
+}
+
+// SetLimits sets a maximum number of concurrent initial LIST requests and
+// a size of the batch of monitors to start before the delay. Zero disables the limit.
+func (t *StartupThrottle) SetLimits(maxLists int, batchSize int, batchDelay time.Duration, metricStorage *metric_storage.MetricStorage) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.lists = nil
+	if maxLists > 0 {
+		t.lists = make(chan struct{}, maxLists)
+	}
+	t.batchSize = batchSize
+	t.batchDelay = batchDelay
+	t.started = 0
+	t.finished = false
+	t.metricStorage = metricStorage
+}
+
+// Finish disables limits when all bindings are started at startup.
+func (t *StartupThrottle) Finish() {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if !t.finished {
+		log.Debugf("Startup throttle: startup is done after %d monitors, limits are disabled", t.started)
+	}
+	t.finished = true
+}
+
+// acquireList waits for a free slot for the initial LIST request.
+// It returns a function to release the slot.
+func (t *StartupThrottle) acquireList(ctx context.Context) (func(), error) {
+	t.m.Lock()
+	lists := t.lists
+	finished := t.finished
+	metricStorage := t.metricStorage
+	t.m.Unlock()
+
+	if lists == nil || finished {
+		return func() {}, nil
+	}
+
+	select {
+	case lists <- struct{}{}:
+	default:
+		if metricStorage != nil {
+			metricStorage.CounterAdd("{PREFIX}kube_initial_list_throttled_total", 1.0, map[string]string{})
+		}
+		select {
+		case lists <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() { <-lists }, nil
+}
+
+// waitMonitorStart counts started monitors and sleeps after each full batch.
+func (t *StartupThrottle) waitMonitorStart(ctx context.Context) {
+	t.m.Lock()
+	if t.batchSize <= 0 || t.batchDelay <= 0 || t.finished {
+		t.m.Unlock()
+		return
+	}
+	t.started++
+	if t.started%t.batchSize != 0 {
+		t.m.Unlock()
+		return
+	}
+	delay := t.batchDelay
+	started := t.started
+	t.m.Unlock()
+
+	log.Debugf("Startup throttle: %d monitors are started, wait %s before the next batch", started, delay.String())
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,64 @@
+package kube_events_manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StartupThrottle_Lists(t *testing.T) {
+	th := NewStartupThrottle()
+
+	// No limit.
+	release, err := th.acquireList(context.Background())
+	require.NoError(t, err)
+	release()
+
+	th.SetLimits(1, 0, 0, nil)
+	release, err = th.acquireList(context.Background())
+	require.NoError(t, err)
+
+	// Second request waits for a free slot.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = th.acquireList(ctx)
+	assert.Error(t, err)
+
+	release()
+	release, err = th.acquireList(context.Background())
+	require.NoError(t, err)
+	release()
+}
+
+func Test_StartupThrottle_MonitorBatches(t *testing.T) {
+	th := NewStartupThrottle()
+	th.SetLimits(0, 2, 100*time.Millisecond, nil)
+
+	start := time.Now()
+	th.waitMonitorStart(context.Background())
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// The last monitor in the batch waits for the delay.
+	th.waitMonitorStart(context.Background())
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func Test_StartupThrottle_Finish(t *testing.T) {
+	th := NewStartupThrottle()
+	th.SetLimits(1, 1, time.Minute, nil)
+	th.Finish()
+
+	// Limits are not applied after startup.
+	release, err := th.acquireList(context.Background())
+	require.NoError(t, err)
+	_, err = th.acquireList(context.Background())
+	require.NoError(t, err)
+	release()
+
+	start := time.Now()
+	th.waitMonitorStart(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+}
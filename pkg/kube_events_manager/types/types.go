@@ -16,6 +16,8 @@ const (
 	WatchEventAdded    WatchEventType = "Added"
 	WatchEventModified WatchEventType = "Modified"
 	WatchEventDeleted  WatchEventType = "Deleted"
+	// WatchEventAbsent is emitted when expected objects do not exist for longer than a threshold.
+	WatchEventAbsent WatchEventType = "Absent"
 )
 
 type KubeEventType string
@@ -39,6 +41,7 @@ type ObjectAndFilterResult struct {
 		Checksum     string
 		ResourceId   string // Used for sorting
 		RemoveObject bool
+		ObjectSize   int // Size of JSON representation of the Object, used to estimate memory usage
 	}
 	Object       *unstructured.Unstructured // here is a pointer because of MarshalJSON receiver
 	FilterResult interface{}
@@ -100,25 +103,38 @@ func (o *ObjectAndFilterResult) RemoveFullObject() {
 type ObjectAndFilterResults map[string]*ObjectAndFilterResult
 
 // ByNamespaceAndName implements sort.Interface for []ObjectAndFilterResult
-// based on Namespace and Name of Object field.
+// based on Namespace and Name of Object field. Objects without the full
+// object are compared by ResourceId parts, so the order is the same
+// regardless of keepFullObjectsInMemory.
 // TODO use special fields instead of ResourceId. ResourceId can be changed in the future.
 type ByNamespaceAndName []ObjectAndFilterResult
 
 func (a ByNamespaceAndName) Len() int      { return len(a) }
 func (a ByNamespaceAndName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByNamespaceAndName) Less(i, j int) bool {
-	p, q := &a[i], &a[j]
-	if p.Object == nil || q.Object == nil {
-		return p.Metadata.ResourceId < q.Metadata.ResourceId
-	}
+	pNs, pName := a[i].namespaceAndName()
+	qNs, qName := a[j].namespaceAndName()
 	switch {
-	case p.Object.GetNamespace() < q.Object.GetNamespace():
-		return true
-	case p.Object.GetNamespace() > q.Object.GetNamespace():
-		return false
+	case pNs != qNs:
+		return pNs < qNs
+	case pName != qName:
+		return pName < qName
+	}
+	// Namespaces and names are equal, so compare ids with kinds.
+	return a[i].Metadata.ResourceId < a[j].Metadata.ResourceId
+}
+
+// namespaceAndName returns namespace and name of the object. ResourceId
+// in the form "namespace/kind/name" is used if the full object is removed.
+func (o *ObjectAndFilterResult) namespaceAndName() (string, string) {
+	if o.Object != nil {
+		return o.Object.GetNamespace(), o.Object.GetName()
+	}
+	parts := strings.SplitN(o.Metadata.ResourceId, "/", 3)
+	if len(parts) != 3 {
+		return "", o.Metadata.ResourceId
 	}
-	// Namespaces are equal, so compare names.
-	return p.Object.GetName() < q.Object.GetName()
+	return parts[0], parts[2]
 }
 
 // KubeEvent contains MonitorId from monitor configuration, event type
@@ -173,6 +189,26 @@ func (k KubeEvent) String() string {
 
 type NameSelector struct {
 	MatchNames []string `json:"matchNames"`
+	// MatchExpressions are checked for each object, all expressions should match.
+	MatchExpressions []NameSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+type NameSelectorOperator string
+
+const (
+	// NameIn matches names from values.
+	NameIn NameSelectorOperator = "In"
+	// NameNotIn matches names not in values.
+	NameNotIn NameSelectorOperator = "NotIn"
+	// NameRegex matches names that match any of regular expressions in values.
+	NameRegex NameSelectorOperator = "Regex"
+	// NameNotRegex matches names that match none of regular expressions in values.
+	NameNotRegex NameSelectorOperator = "NotRegex"
+)
+
+type NameSelectorRequirement struct {
+	Operator NameSelectorOperator `json:"operator"`
+	Values   []string             `json:"values"`
 }
 
 type FieldSelectorRequirement struct {
@@ -91,3 +91,37 @@ func Test_Sort_ByNamespaceAndName(t *testing.T) {
 	assert.Equal(t, "kube-proxy-lh65x", inputObjs[5].Object.GetName())
 	assert.Equal(t, "kube-proxy-rkrr7", inputObjs[6].Object.GetName())
 }
+
+func Test_Sort_ByNamespaceAndName_WithoutFullObjects(t *testing.T) {
+	withoutObject := func(kind, ns, name string) ObjectAndFilterResult {
+		obj := ObjectAndFilterResult{}
+		obj.Metadata.ResourceId = ns + "/" + kind + "/" + name
+		obj.RemoveFullObject()
+		return obj
+	}
+
+	inputObjs := []ObjectAndFilterResult{
+		withoutObject("Pod", "kube-system", "b-pod"),
+		*newObj("Pod", "kube-system", "c-pod", ``),
+		withoutObject("Pod", "default", "z-pod"),
+		withoutObject("ConfigMap", "kube-system", "a-pod"),
+		withoutObject("Pod", "kube-system", "a-pod"),
+	}
+
+	sort.Sort(ByNamespaceAndName(inputObjs))
+
+	ids := make([]string, 0, len(inputObjs))
+	for _, obj := range inputObjs {
+		ns, name := obj.namespaceAndName()
+		ids = append(ids, ns+"/"+name)
+	}
+	assert.Equal(t, []string{
+		"default/z-pod",
+		"kube-system/a-pod",
+		"kube-system/a-pod",
+		"kube-system/b-pod",
+		"kube-system/c-pod",
+	}, ids)
+	// Objects with the same namespace and name are ordered by kind.
+	assert.Equal(t, "kube-system/ConfigMap/a-pod", inputObjs[1].Metadata.ResourceId)
+}
@@ -0,0 +1,23 @@
+package metric
+
+import (
+	"net/http"
+
+	"github.com/flant/shell-operator/pkg/metric_storage/operation"
+)
+
+// Backend is a destination for metrics from the Storage: the built-in Prometheus
+// registry, a registry of an application that embeds the operator, etc. Metric names
+// are passed with the resolved prefix and labels are passed with applied label rules.
+type Backend interface {
+	RegisterCounter(metric string, labelNames []string) error
+	RegisterGauge(metric string, labelNames []string) error
+	RegisterHistogram(metric string, labelNames []string, buckets []float64) error
+
+	// ApplyOperations applies 'set', 'add' and 'observe' operations. Operations
+	// with the same group replace all previous metrics of the group.
+	ApplyOperations(ops []operation.MetricOperation) error
+
+	// Handler serves metrics for scraping. It may be nil for push-based backends.
+	Handler() http.Handler
+}
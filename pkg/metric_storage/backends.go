@@ -0,0 +1,211 @@
+package metric_storage
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/metric"
+	"github.com/flant/shell-operator/pkg/metric_storage/operation"
+	. "github.com/flant/shell-operator/pkg/utils/labels"
+)
+
+// Backend returns the built-in Prometheus registry of the storage as a backend.
+// The built-in registry is used by default, pass it to SetBackends to keep it
+// along with other backends.
+func (m *MetricStorage) Backend() metric.Backend {
+	return &registryBackend{storage: m}
+}
+
+// SetBackends replaces all destinations of metrics. The built-in registry is used
+// only if the list contains the Backend() of the storage. Handler serves metrics of
+// the built-in registry or of the first backend with a handler. SetBackends should
+// be called before metrics are registered.
+func (m *MetricStorage) SetBackends(backends ...metric.Backend) {
+	m.backendsLock.Lock()
+	defer m.backendsLock.Unlock()
+
+	m.backends = nil
+	m.builtinDisabled = true
+	for _, backend := range backends {
+		if rb, ok := backend.(*registryBackend); ok && rb.storage == m {
+			m.builtinDisabled = false
+			continue
+		}
+		m.backends = append(m.backends, backend)
+	}
+
+	if m.builtinDisabled {
+		// Metric values are still kept in vectors to calculate gauges and
+		// counters, but the built-in registry is not served anymore.
+		registry := prometheus.NewRegistry()
+		m.Registry = registry
+		m.Gatherer = registry
+		m.Registerer = registry
+		m.groupedVault.SetRegisterer(registry)
+	}
+}
+
+// AddBackend adds a backend to receive all metrics registered and updated after this call.
+func (m *MetricStorage) AddBackend(backend metric.Backend) {
+	if rb, ok := backend.(*registryBackend); ok && rb.storage == m {
+		return
+	}
+	m.backendsLock.Lock()
+	defer m.backendsLock.Unlock()
+	m.backends = append(m.backends, backend)
+}
+
+// backendHandler returns the handler of the first backend if the built-in registry is disabled.
+func (m *MetricStorage) backendHandler() (http.Handler, bool) {
+	m.backendsLock.RLock()
+	defer m.backendsLock.RUnlock()
+	if !m.builtinDisabled {
+		return nil, false
+	}
+	for _, backend := range m.backends {
+		if handler := backend.Handler(); handler != nil {
+			return handler, true
+		}
+	}
+	return http.NotFoundHandler(), true
+}
+
+func (m *MetricStorage) getBackends() []metric.Backend {
+	m.backendsLock.RLock()
+	defer m.backendsLock.RUnlock()
+	return m.backends
+}
+
+func (m *MetricStorage) registerInBackends(metricType string, metricName string, labels map[string]string, buckets []float64) {
+	for _, backend := range m.getBackends() {
+		var err error
+		switch metricType {
+		case "counter":
+			err = backend.RegisterCounter(metricName, LabelNames(labels))
+		case "gauge":
+			err = backend.RegisterGauge(metricName, LabelNames(labels))
+		case "histogram":
+			err = backend.RegisterHistogram(metricName, LabelNames(labels), buckets)
+		}
+		if err != nil {
+			log.WithField("operator.component", "metricStorage").
+				Errorf("Register %s %s in backend: %v", metricType, metricName, err)
+		}
+	}
+}
+
+// sendToBackends applies operations with resolved names and final labels.
+func (m *MetricStorage) sendToBackends(ops []operation.MetricOperation) {
+	for _, backend := range m.getBackends() {
+		err := backend.ApplyOperations(ops)
+		if err != nil {
+			log.WithField("operator.component", "metricStorage").
+				Errorf("Apply %d metric operations in backend: %v", len(ops), err)
+		}
+	}
+}
+
+func (m *MetricStorage) sendValueToBackends(action string, metric string, value float64, labels map[string]string, buckets []float64) {
+	if len(m.getBackends()) == 0 {
+		return
+	}
+	m.sendToBackends([]operation.MetricOperation{{
+		Name:    m.resolveMetricName(metric),
+		Action:  action,
+		Value:   &value,
+		Buckets: buckets,
+		Labels:  labels,
+	}})
+}
+
+// sendGaugeToBackends sends the current value of the gauge: operations have no 'add' for gauges.
+func (m *MetricStorage) sendGaugeToBackends(metric string, labels map[string]string) {
+	if len(m.getBackends()) == 0 {
+		return
+	}
+	pb := &dto.Metric{}
+	if err := m.Gauge(metric, labels).With(labels).Write(pb); err != nil {
+		return
+	}
+	m.sendValueToBackends("set", metric, pb.GetGauge().GetValue(), labels, nil)
+}
+
+// sendGroupToBackends sends group operations in one batch to replace the group atomically.
+func (m *MetricStorage) sendGroupToBackends(group string, ops []operation.MetricOperation, commonLabels map[string]string) {
+	if len(m.getBackends()) == 0 {
+		return
+	}
+	backendOps := make([]operation.MetricOperation, 0, len(ops))
+	for _, op := range ops {
+		backendOp := m.backendOperation(op, commonLabels)
+		backendOp.Group = group
+		backendOps = append(backendOps, backendOp)
+	}
+	m.sendToBackends(backendOps)
+}
+
+func (m *MetricStorage) backendOperation(op operation.MetricOperation, commonLabels map[string]string) operation.MetricOperation {
+	res := op
+	res.Name = m.resolveMetricName(op.Name)
+	if op.Action != "expire" && op.Action != "replace" {
+		res.Labels = m.labelRules.Apply(MergeLabels(op.Labels, commonLabels))
+	}
+	if op.Metrics != nil {
+		res.Metrics = make([]operation.MetricOperation, 0, len(op.Metrics))
+		for _, groupOp := range op.Metrics {
+			res.Metrics = append(res.Metrics, m.backendOperation(groupOp, commonLabels))
+		}
+	}
+	return res
+}
+
+// registryBackend sends metrics to the Prometheus registry, e.g. to the registry
+// of controller-runtime.
+type registryBackend struct {
+	storage *MetricStorage
+}
+
+// NewRegistryBackend returns a backend that registers metrics in registerer.
+// Handler serves metrics from gatherer.
+func NewRegistryBackend(ctx context.Context, registerer prometheus.Registerer, gatherer prometheus.Gatherer) metric.Backend {
+	storage := NewMetricStorage(ctx, "", false)
+	storage.Registerer = registerer
+	storage.Gatherer = gatherer
+	storage.groupedVault.SetRegisterer(registerer)
+	return &registryBackend{storage: storage}
+}
+
+func (b *registryBackend) RegisterCounter(metric string, labelNames []string) error {
+	b.storage.RegisterCounter(metric, labelsFromNames(labelNames))
+	return nil
+}
+
+func (b *registryBackend) RegisterGauge(metric string, labelNames []string) error {
+	b.storage.RegisterGauge(metric, labelsFromNames(labelNames))
+	return nil
+}
+
+func (b *registryBackend) RegisterHistogram(metric string, labelNames []string, buckets []float64) error {
+	b.storage.RegisterHistogram(metric, labelsFromNames(labelNames), buckets)
+	return nil
+}
+
+func (b *registryBackend) ApplyOperations(ops []operation.MetricOperation) error {
+	return b.storage.SendBatch(ops, nil)
+}
+
+func (b *registryBackend) Handler() http.Handler {
+	return b.storage.Handler()
+}
+
+func labelsFromNames(labelNames []string) map[string]string {
+	labels := make(map[string]string, len(labelNames))
+	for _, name := range labelNames {
+		labels[name] = ""
+	}
+	return labels
+}
@@ -0,0 +1,122 @@
+package metric_storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/metric_storage/operation"
+)
+
+func Test_MetricStorage_RegistryBackend(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	m := NewMetricStorage(context.Background(), "test_", true)
+	m.AddBackend(NewRegistryBackend(context.Background(), reg, reg))
+
+	m.RegisterCounter("{PREFIX}runs_total", map[string]string{"hook": ""})
+	m.CounterAdd("{PREFIX}runs_total", 2.0, map[string]string{"hook": "hook.sh"})
+	m.GaugeSet("{PREFIX}objects", 3.0, map[string]string{"hook": "hook.sh"})
+	m.GaugeAdd("{PREFIX}objects", 1.0, map[string]string{"hook": "hook.sh"})
+
+	value := 5.0
+	err := m.SendBatch([]operation.MetricOperation{
+		{Name: "hook_metric", Group: "group1", Action: "set", Value: &value, Labels: map[string]string{"kind": "pod"}},
+	}, map[string]string{"hook": "hook.sh"})
+	require.NoError(t, err)
+
+	expect := `
+# HELP test_objects test_objects
+# TYPE test_objects gauge
+test_objects{hook="hook.sh"} 4
+# HELP test_runs_total test_runs_total
+# TYPE test_runs_total counter
+test_runs_total{hook="hook.sh"} 2
+# HELP hook_metric hook_metric
+# TYPE hook_metric gauge
+hook_metric{hook="hook.sh",kind="pod"} 5
+`
+	err = promtest.GatherAndCompare(reg, strings.NewReader(expect), "test_objects", "test_runs_total", "hook_metric")
+	assert.NoError(t, err)
+
+	// Empty replace expires the group in the backend too.
+	err = m.SendBatch([]operation.MetricOperation{
+		{Group: "group1", Action: "replace", Metrics: []operation.MetricOperation{}},
+	}, nil)
+	require.NoError(t, err)
+
+	err = promtest.GatherAndCompare(reg, strings.NewReader(""), "hook_metric")
+	assert.NoError(t, err)
+}
+
+type fakeBackend struct {
+	buckets map[string][]float64
+	ops     []operation.MetricOperation
+}
+
+func (b *fakeBackend) RegisterCounter(string, []string) error { return nil }
+
+func (b *fakeBackend) RegisterGauge(string, []string) error { return nil }
+
+func (b *fakeBackend) RegisterHistogram(metric string, _ []string, buckets []float64) error {
+	b.buckets[metric] = buckets
+	return nil
+}
+
+func (b *fakeBackend) ApplyOperations(ops []operation.MetricOperation) error {
+	if err := operation.ValidateOperations(ops); err != nil {
+		return err
+	}
+	b.ops = append(b.ops, ops...)
+	return nil
+}
+
+func (b *fakeBackend) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("fake"))
+	})
+}
+
+func Test_MetricStorage_Backend_Histogram(t *testing.T) {
+	backend := &fakeBackend{buckets: make(map[string][]float64)}
+	m := NewMetricStorage(context.Background(), "test_", true)
+	m.AddBackend(backend)
+
+	// Built-in histograms are observed without buckets.
+	m.HistogramObserve("{PREFIX}hook_run_seconds", 1.5, map[string]string{"hook": "hook.sh"}, nil)
+
+	assert.Equal(t, prometheus.DefBuckets, backend.buckets["test_hook_run_seconds"])
+	require.Len(t, backend.ops, 1)
+	assert.Equal(t, "observe", backend.ops[0].Action)
+	assert.Equal(t, prometheus.DefBuckets, backend.ops[0].Buckets)
+}
+
+func Test_MetricStorage_SetBackends(t *testing.T) {
+	backend := &fakeBackend{buckets: make(map[string][]float64)}
+	m := NewMetricStorage(context.Background(), "test_", true)
+	m.SetBackends(backend)
+
+	m.CounterAdd("{PREFIX}runs_total", 1.0, map[string]string{"hook": "hook.sh"})
+	require.Len(t, backend.ops, 1)
+
+	// The built-in registry is replaced, metrics are served by the backend.
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "fake", rec.Body.String())
+
+	// Keep the built-in registry along with the backend.
+	m = NewMetricStorage(context.Background(), "test_", true)
+	m.SetBackends(m.Backend(), backend)
+	m.CounterAdd("{PREFIX}runs_total", 1.0, map[string]string{"hook": "hook.sh"})
+	rec = httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `test_runs_total{hook="hook.sh"} 1`)
+	assert.Len(t, backend.ops, 2)
+}
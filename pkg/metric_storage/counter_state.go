@@ -0,0 +1,127 @@
+package metric_storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+// CounterValue is a value of the counter series.
+type CounterValue struct {
+	// Metric is a name used in CounterAdd, it can contain the {PREFIX} template.
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// CounterValues returns values of all series of counters. Grouped counters are not
+// returned as they are reset by each run of the hook.
+func (m *MetricStorage) CounterValues() []CounterValue {
+	m.countersLock.RLock()
+	vecs := make(map[string]*prometheus.CounterVec, len(m.Counters))
+	for metric, vec := range m.Counters {
+		vecs[metric] = vec
+	}
+	m.countersLock.RUnlock()
+
+	res := make([]CounterValue, 0)
+	for metric, vec := range vecs {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			vec.Collect(ch)
+			close(ch)
+		}()
+		for series := range ch {
+			var pb dto.Metric
+			if err := series.Write(&pb); err != nil || pb.GetCounter() == nil {
+				continue
+			}
+			value := CounterValue{Metric: metric, Value: pb.GetCounter().GetValue()}
+			for _, label := range pb.GetLabel() {
+				if value.Labels == nil {
+					value.Labels = make(map[string]string)
+				}
+				value.Labels[label.GetName()] = label.GetValue()
+			}
+			res = append(res, value)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Metric < res[j].Metric
+	})
+	return res
+}
+
+// RestoreCounterValues adds saved values to counters. Labels are not rewritten
+// as the values are saved with rewritten labels.
+func (m *MetricStorage) RestoreCounterValues(values []CounterValue) {
+	if m == nil {
+		return
+	}
+	for _, value := range values {
+		m.restoreCounterValue(value)
+	}
+}
+
+func (m *MetricStorage) restoreCounterValue(value CounterValue) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("operator.component", "metricsStorage").
+				Warnf("Restore metric counter %s with %v: %v", m.resolveMetricName(value.Metric), value.Labels, r)
+		}
+	}()
+	labels := value.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	m.Counter(value.Metric, labels).With(labels).Add(value.Value)
+}
+
+// SaveCounterValues writes values of counters into the file. A temporary file
+// is renamed to not leave a partial file if the process is killed.
+func (m *MetricStorage) SaveCounterValues(path string) error {
+	data, err := json.Marshal(m.CounterValues())
+	if err != nil {
+		return fmt.Errorf("marshal counters: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create counters directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create counters file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write counters file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write counters file: %v", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadCounterValues restores values of counters from the file. A missing file is not an error.
+func (m *MetricStorage) LoadCounterValues(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read counters file: %v", err)
+	}
+	var values []CounterValue
+	if err := json.Unmarshal(data, &values); err != nil {
+		return 0, fmt.Errorf("parse counters file '%s': %v", path, err)
+	}
+	m.RestoreCounterValues(values)
+	return len(values), nil
+}
@@ -0,0 +1,52 @@
+package metric_storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SaveLoadCounterValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "counters.json")
+
+	m := NewMetricStorage(context.Background(), "test_", true)
+	m.CounterAdd("{PREFIX}runs_total", 3, map[string]string{"hook": "hook.sh"})
+	m.CounterAdd("{PREFIX}runs_total", 1, map[string]string{"hook": "other.sh"})
+	m.CounterAdd("events_total", 5, map[string]string{})
+	m.Grouped().CounterAdd("group", "grouped_total", 7, map[string]string{})
+	require.NoError(t, m.SaveCounterValues(path))
+
+	restored := NewMetricStorage(context.Background(), "test_", true)
+	n, err := restored.LoadCounterValues(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	restored.CounterAdd("{PREFIX}runs_total", 1, map[string]string{"hook": "hook.sh"})
+
+	values := map[string]float64{}
+	for _, v := range restored.CounterValues() {
+		values[v.Metric+"/"+v.Labels["hook"]] = v.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"{PREFIX}runs_total/hook.sh":  4,
+		"{PREFIX}runs_total/other.sh": 1,
+		"events_total/":               5,
+	}, values)
+}
+
+func Test_LoadCounterValues_Errors(t *testing.T) {
+	m := NewMetricStorage(context.Background(), "test_", true)
+
+	// No file before the first save.
+	n, err := m.LoadCounterValues(filepath.Join(t.TempDir(), "absent.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	path := filepath.Join(t.TempDir(), "counters.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = m.LoadCounterValues(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package metric_storage
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CardinalityOtherValue replaces values of limited labels above the limit.
+const CardinalityOtherValue = "other"
+
+// CardinalityLimit collapses values of labels into the "other" value when the number
+// of distinct values is above the limit, e.g. for queues named after objects.
+// First values are kept as is, so series of static queues and hooks are not changed.
+type CardinalityLimit struct {
+	Limit  int
+	Labels map[string]struct{}
+
+	m      sync.Mutex
+	values map[string]map[string]struct{}
+}
+
+// NewCardinalityLimit returns nil if limit is not positive or there are no labels.
+// Items of labels can be comma-separated lists.
+func NewCardinalityLimit(limit int, labels []string) *CardinalityLimit {
+	if limit <= 0 {
+		return nil
+	}
+	c := &CardinalityLimit{
+		Limit:  limit,
+		Labels: make(map[string]struct{}),
+		values: make(map[string]map[string]struct{}),
+	}
+	for _, item := range labels {
+		for _, label := range strings.Split(item, ",") {
+			label = strings.TrimSpace(label)
+			if label == "" {
+				continue
+			}
+			c.Labels[label] = struct{}{}
+			c.values[label] = make(map[string]struct{})
+		}
+	}
+	if len(c.Labels) == 0 {
+		return nil
+	}
+	return c
+}
+
+// value returns the value or "other" if the label is limited and the value is new
+// and the limit is reached. Empty values are used for registration and are not counted.
+func (c *CardinalityLimit) value(label string, value string) string {
+	if c == nil || value == "" || value == CardinalityOtherValue {
+		return value
+	}
+	if _, limited := c.Labels[label]; !limited {
+		return value
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	seen := c.values[label]
+	if _, has := seen[value]; has {
+		return value
+	}
+	if len(seen) >= c.Limit {
+		return CardinalityOtherValue
+	}
+	seen[value] = struct{}{}
+	if len(seen) == c.Limit {
+		log.WithField("operator.component", "metricsStorage").
+			Warnf("Label '%s' has reached the cardinality limit %d, new values are reported as '%s'", label, c.Limit, CardinalityOtherValue)
+	}
+	return value
+}
+
+// hasLimitedLabels returns true if some labels should be checked.
+func (c *CardinalityLimit) hasLimitedLabels(labels map[string]string) bool {
+	if c == nil {
+		return false
+	}
+	for name := range labels {
+		if _, limited := c.Labels[name]; limited {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package metric_storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewCardinalityLimit(t *testing.T) {
+	assert.Nil(t, NewCardinalityLimit(0, []string{"queue"}))
+	assert.Nil(t, NewCardinalityLimit(10, nil))
+
+	c := NewCardinalityLimit(10, []string{"queue, hook", "binding"})
+	require.NotNil(t, c)
+	assert.Len(t, c.Labels, 3)
+	assert.Contains(t, c.Labels, "hook")
+}
+
+func Test_LabelRules_CardinalityLimit(t *testing.T) {
+	rules, err := ParseLabelRules("", "queue=shell_queue")
+	require.NoError(t, err)
+	rules = rules.WithCardinalityLimit(NewCardinalityLimit(2, []string{"queue"}))
+
+	// Registration labels are not counted.
+	assert.Equal(t, map[string]string{"shell_queue": "", "hook": ""}, rules.Apply(map[string]string{"queue": "", "hook": ""}))
+
+	apply := func(queue string) string {
+		return rules.Apply(map[string]string{"queue": queue, "hook": "hook.sh"})["shell_queue"]
+	}
+	assert.Equal(t, "main", apply("main"))
+	assert.Equal(t, "pod-a", apply("pod-a"))
+	assert.Equal(t, CardinalityOtherValue, apply("pod-b"))
+	assert.Equal(t, CardinalityOtherValue, apply("pod-c"))
+	// Known values are kept.
+	assert.Equal(t, "main", apply("main"))
+	assert.Equal(t, "pod-a", apply("pod-a"))
+}
+
+func Test_MetricStorage_CardinalityLimit(t *testing.T) {
+	m := NewMetricStorage(context.Background(), "test_", true)
+	m.SetLabelRules((*LabelRules)(nil).WithCardinalityLimit(NewCardinalityLimit(1, []string{"queue"})))
+	m.RegisterCounter("{PREFIX}tasks_total", map[string]string{"queue": ""})
+	for _, queue := range []string{"main", "pod-a", "pod-b", "main"} {
+		m.CounterAdd("{PREFIX}tasks_total", 1.0, map[string]string{"queue": queue})
+	}
+
+	families, err := m.Gatherer.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	values := map[string]float64{}
+	for _, metric := range families[0].GetMetric() {
+		values[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"main": 2, CardinalityOtherValue: 2}, values)
+}
@@ -0,0 +1,99 @@
+package metric_storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LabelRules are applied to labels of all metrics in the storage. They help
+// to distinguish several operator instances in one cluster without relabeling
+// at scrape time.
+type LabelRules struct {
+	// StaticLabels are added to every metric. They override labels with the same name.
+	StaticLabels map[string]string
+	// Rewrites renames labels: old name -> new name.
+	Rewrites map[string]string
+	// Cardinality collapses values of labels above the limit. It is applied before renames.
+	Cardinality *CardinalityLimit
+}
+
+// ParseLabelRules parses comma separated "name=value" pairs for static labels
+// and "old=new" pairs for label renames.
+func ParseLabelRules(staticLabels string, rewrites string) (*LabelRules, error) {
+	static, err := parseLabelPairs(staticLabels)
+	if err != nil {
+		return nil, fmt.Errorf("static labels: %v", err)
+	}
+	renames, err := parseLabelPairs(rewrites)
+	if err != nil {
+		return nil, fmt.Errorf("label rewrites: %v", err)
+	}
+
+	for oldName, newName := range renames {
+		if !labelNameRe.MatchString(newName) {
+			return nil, fmt.Errorf("label rewrites: invalid label name '%s'", newName)
+		}
+		// Rules are applied on both registration and observation, chains are ambiguous.
+		if _, has := renames[newName]; has {
+			return nil, fmt.Errorf("label rewrites: '%s' is renamed to '%s' which is renamed too", oldName, newName)
+		}
+	}
+
+	return &LabelRules{
+		StaticLabels: static,
+		Rewrites:     renames,
+	}, nil
+}
+
+func parseLabelPairs(s string) (map[string]string, error) {
+	res := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("'%s' should be in form name=value", pair)
+		}
+		name := strings.TrimSpace(parts[0])
+		if !labelNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid label name '%s'", name)
+		}
+		res[name] = strings.TrimSpace(parts[1])
+	}
+	return res, nil
+}
+
+// WithCardinalityLimit returns a copy of rules with the cardinality limit.
+func (r *LabelRules) WithCardinalityLimit(limit *CardinalityLimit) *LabelRules {
+	res := &LabelRules{Cardinality: limit}
+	if r != nil {
+		res.StaticLabels = r.StaticLabels
+		res.Rewrites = r.Rewrites
+	}
+	return res
+}
+
+// Apply returns a copy of labels with collapsed values, renamed labels and static labels added.
+func (r *LabelRules) Apply(labels map[string]string) map[string]string {
+	if r == nil || (len(r.StaticLabels) == 0 && len(r.Rewrites) == 0 && !r.Cardinality.hasLimitedLabels(labels)) {
+		return labels
+	}
+
+	res := make(map[string]string, len(labels)+len(r.StaticLabels))
+	for name, value := range labels {
+		value = r.Cardinality.value(name, value)
+		if newName, has := r.Rewrites[name]; has {
+			name = newName
+		}
+		res[name] = value
+	}
+	for name, value := range r.StaticLabels {
+		res[name] = value
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package metric_storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseLabelRules(t *testing.T) {
+	rules, err := ParseLabelRules("instance=first, team=infra", "hook=shell_hook")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"instance": "first", "team": "infra"}, rules.StaticLabels)
+	assert.Equal(t, map[string]string{"hook": "shell_hook"}, rules.Rewrites)
+
+	_, err = ParseLabelRules("instance", "")
+	assert.Error(t, err)
+
+	_, err = ParseLabelRules("", "hook=shell-hook")
+	assert.Error(t, err)
+
+	_, err = ParseLabelRules("", "hook=binding,binding=queue")
+	assert.Error(t, err)
+}
+
+func Test_LabelRules_Apply(t *testing.T) {
+	rules, err := ParseLabelRules("instance=first", "hook=shell_hook")
+	require.NoError(t, err)
+
+	labels := map[string]string{"hook": "hook.sh", "instance": "other"}
+	res := rules.Apply(labels)
+	assert.Equal(t, map[string]string{"shell_hook": "hook.sh", "instance": "first"}, res)
+	// Rules are idempotent and do not change the input.
+	assert.Equal(t, res, rules.Apply(res))
+	assert.Equal(t, "hook.sh", labels["hook"])
+
+	var noRules *LabelRules
+	assert.Equal(t, labels, noRules.Apply(labels))
+}
+
+func Test_MetricStorage_LabelRules(t *testing.T) {
+	rules, err := ParseLabelRules("instance=first", "hook=shell_hook")
+	require.NoError(t, err)
+
+	m := NewMetricStorage(context.Background(), "test_", true)
+	m.SetLabelRules(rules)
+	m.RegisterCounter("{PREFIX}runs_total", map[string]string{"hook": ""})
+	m.CounterAdd("{PREFIX}runs_total", 1.0, map[string]string{"hook": "hook.sh"})
+
+	families, err := m.Gatherer.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, "test_runs_total", families[0].GetName())
+	labels := map[string]string{}
+	for _, l := range families[0].GetMetric()[0].GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	assert.Equal(t, map[string]string{"shell_hook": "hook.sh", "instance": "first"}, labels)
+}
@@ -32,6 +32,8 @@ type MetricStorage struct {
 	Gauges           map[string]*prometheus.GaugeVec
 	Histograms       map[string]*prometheus.HistogramVec
 	HistogramBuckets map[string][]float64
+	// registeredBuckets are buckets of registered histograms, they are sent to backends.
+	registeredBuckets map[string][]float64
 
 	countersLock   sync.RWMutex
 	gaugesLock     sync.RWMutex
@@ -39,6 +41,13 @@ type MetricStorage struct {
 
 	groupedVault *vault.GroupedVault
 
+	labelRules *LabelRules
+
+	backends     []metric.Backend
+	backendsLock sync.RWMutex
+	// builtinDisabled is true if the own registry is not served, see SetBackends.
+	builtinDisabled bool
+
 	Registry   *prometheus.Registry
 	Gatherer   prometheus.Gatherer
 	Registerer prometheus.Registerer
@@ -58,6 +67,8 @@ func NewMetricStorage(ctx context.Context, prefix string, newRegistry bool) *Met
 		groupedVault:     vault.NewGroupedVault(),
 		Gatherer:         prometheus.DefaultGatherer,
 		Registerer:       prometheus.DefaultRegisterer,
+
+		registeredBuckets: make(map[string][]float64),
 	}
 	m.groupedVault.SetRegisterer(m.Registerer)
 
@@ -71,6 +82,12 @@ func NewMetricStorage(ctx context.Context, prefix string, newRegistry bool) *Met
 	return m
 }
 
+// SetLabelRules sets rules for labels of all metrics. It should be called
+// before metrics are registered.
+func (m *MetricStorage) SetLabelRules(rules *LabelRules) {
+	m.labelRules = rules
+}
+
 func (m *MetricStorage) Grouped() metric.GroupedStorage {
 	return m.groupedVault
 }
@@ -95,7 +112,9 @@ func (m *MetricStorage) GaugeSet(metric string, value float64, labels map[string
 		}
 	}()
 
+	labels = m.labelRules.Apply(labels)
 	m.Gauge(metric, labels).With(labels).Set(value)
+	m.sendValueToBackends("set", metric, value, labels, nil)
 }
 
 func (m *MetricStorage) GaugeAdd(metric string, value float64, labels map[string]string) {
@@ -109,7 +128,9 @@ func (m *MetricStorage) GaugeAdd(metric string, value float64, labels map[string
 		}
 	}()
 
+	labels = m.labelRules.Apply(labels)
 	m.Gauge(metric, labels).With(labels).Add(value)
+	m.sendGaugeToBackends(metric, labels)
 }
 
 // Gauge return saved or register a new gauge.
@@ -126,6 +147,7 @@ func (m *MetricStorage) Gauge(metric string, labels map[string]string) *promethe
 
 // RegisterGauge registers a gauge.
 func (m *MetricStorage) RegisterGauge(metric string, labels map[string]string) *prometheus.GaugeVec {
+	labels = m.labelRules.Apply(labels)
 	metricName := m.resolveMetricName(metric)
 
 	defer func() {
@@ -154,6 +176,7 @@ func (m *MetricStorage) RegisterGauge(metric string, labels map[string]string) *
 	)
 	m.Registerer.MustRegister(vec)
 	m.Gauges[metric] = vec
+	m.registerInBackends("gauge", metricName, labels, nil)
 	return vec
 }
 
@@ -169,7 +192,9 @@ func (m *MetricStorage) CounterAdd(metric string, value float64, labels map[stri
 				Errorf("Metric counter add %s %v with %v: %v", m.resolveMetricName(metric), LabelNames(labels), labels, r)
 		}
 	}()
+	labels = m.labelRules.Apply(labels)
 	m.Counter(metric, labels).With(labels).Add(value)
+	m.sendValueToBackends("add", metric, value, labels, nil)
 }
 
 // Counter
@@ -186,6 +211,7 @@ func (m *MetricStorage) Counter(metric string, labels map[string]string) *promet
 
 // RegisterCounter registers a counter.
 func (m *MetricStorage) RegisterCounter(metric string, labels map[string]string) *prometheus.CounterVec {
+	labels = m.labelRules.Apply(labels)
 	metricName := m.resolveMetricName(metric)
 
 	defer func() {
@@ -214,6 +240,7 @@ func (m *MetricStorage) RegisterCounter(metric string, labels map[string]string)
 	)
 	m.Registerer.MustRegister(vec)
 	m.Counters[metric] = vec
+	m.registerInBackends("counter", metricName, labels, nil)
 	return vec
 }
 
@@ -228,7 +255,9 @@ func (m *MetricStorage) HistogramObserve(metric string, value float64, labels ma
 				Errorf("Metric histogram observe %s %v with %v: %v", m.resolveMetricName(metric), LabelNames(labels), labels, r)
 		}
 	}()
+	labels = m.labelRules.Apply(labels)
 	m.Histogram(metric, labels, buckets).With(labels).Observe(value)
+	m.sendValueToBackends("observe", metric, value, labels, m.histogramBuckets(metric))
 }
 
 func (m *MetricStorage) Histogram(metric string, labels map[string]string, buckets []float64) *prometheus.HistogramVec {
@@ -242,6 +271,7 @@ func (m *MetricStorage) Histogram(metric string, labels map[string]string, bucke
 }
 
 func (m *MetricStorage) RegisterHistogram(metric string, labels map[string]string, buckets []float64) *prometheus.HistogramVec {
+	labels = m.labelRules.Apply(labels)
 	metricName := m.resolveMetricName(metric)
 
 	defer func() {
@@ -270,6 +300,10 @@ func (m *MetricStorage) RegisterHistogram(metric string, labels map[string]strin
 	if has {
 		buckets = b
 	}
+	// Backends require buckets, so defaults of the p8s lib are passed explicitly.
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
 
 	vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    metricName,
@@ -279,6 +313,8 @@ func (m *MetricStorage) RegisterHistogram(metric string, labels map[string]strin
 
 	m.Registerer.MustRegister(vec)
 	m.Histograms[metric] = vec
+	m.registeredBuckets[metric] = buckets
+	m.registerInBackends("histogram", metricName, labels, buckets)
 	return vec
 }
 
@@ -375,40 +411,71 @@ func (m *MetricStorage) ApplyOperation(op operation.MetricOperation, commonLabel
 }
 
 // applyGroupOperations set metrics for group to a new state defined by ops.
+// The group is replaced atomically: scrapes observe either old or new metrics.
 func (m *MetricStorage) applyGroupOperations(group string, ops []operation.MetricOperation, commonLabels map[string]string) {
 	// Implicitly expire all metrics for group.
-	m.groupedVault.ExpireGroupMetrics(group)
-
-	// Apply metric operations one-by-one.
-	for _, op := range ops {
-		if op.Action == "expire" {
-			m.groupedVault.ExpireGroupMetrics(group)
-			continue
-		}
-		labels := MergeLabels(op.Labels, commonLabels)
-		if op.Action == "add" && op.Value != nil {
-			m.groupedVault.CounterAdd(group, op.Name, *op.Value, labels)
-		}
-		//nolint:staticcheck
-		if op.Add != nil {
-			m.groupedVault.CounterAdd(group, op.Name, *op.Add, labels)
-		}
-		if op.Action == "set" && op.Value != nil {
-			m.groupedVault.GaugeSet(group, op.Name, *op.Value, labels)
-		}
-		//nolint:staticcheck
-		if op.Set != nil {
-			m.groupedVault.GaugeSet(group, op.Name, *op.Set, labels)
+	m.groupedVault.ReplaceGroupMetrics(group, func() {
+		// Apply metric operations one-by-one.
+		for _, op := range ops {
+			if op.Action == "expire" {
+				m.groupedVault.ExpireGroupMetrics(group)
+				continue
+			}
+			if op.Action == "replace" {
+				m.groupedVault.ExpireGroupMetrics(group)
+				for _, groupOp := range op.Metrics {
+					m.applyGroupOperation(group, groupOp, commonLabels)
+				}
+				continue
+			}
+			m.applyGroupOperation(group, op, commonLabels)
 		}
+	})
+	m.sendGroupToBackends(group, ops, commonLabels)
+}
+
+func (m *MetricStorage) applyGroupOperation(group string, op operation.MetricOperation, commonLabels map[string]string) {
+	labels := m.labelRules.Apply(MergeLabels(op.Labels, commonLabels))
+	if op.Action == "add" && op.Value != nil {
+		m.groupedVault.CounterAdd(group, op.Name, *op.Value, labels)
+	}
+	//nolint:staticcheck
+	if op.Add != nil {
+		m.groupedVault.CounterAdd(group, op.Name, *op.Add, labels)
 	}
+	if op.Action == "set" && op.Value != nil {
+		m.groupedVault.GaugeSet(group, op.Name, *op.Value, labels)
+	}
+	//nolint:staticcheck
+	if op.Set != nil {
+		m.groupedVault.GaugeSet(group, op.Name, *op.Set, labels)
+	}
+}
+
+// histogramBuckets returns buckets of the registered histogram.
+func (m *MetricStorage) histogramBuckets(metric string) []float64 {
+	m.histogramsLock.RLock()
+	defer m.histogramsLock.RUnlock()
+	return m.registeredBuckets[metric]
 }
 
+// Handler serves metrics from the built-in registry. If the built-in registry is
+// replaced with SetBackends, the handler of the first scrapable backend is returned.
 func (m *MetricStorage) Handler() http.Handler {
+	if handler, ok := m.backendHandler(); ok {
+		return handler
+	}
+
+	// Wait for grouped metrics replacements to not expose a half-updated group.
+	gatherer := m.groupedVault.WrapGatherer(m.Gatherer)
+
 	if m.Registry == nil {
-		return promhttp.Handler()
+		return promhttp.InstrumentMetricHandler(
+			prometheus.DefaultRegisterer, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}),
+		)
 	}
 
-	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
 		Registry: m.Registry,
 	})
 }
@@ -22,6 +22,8 @@ type MetricOperation struct {
 	Labels  map[string]string `json:"labels"`
 	Group   string            `json:"group,omitempty"`
 	Action  string            `json:"action,omitempty"`
+	// Metrics is a new set of metrics for the group, used with action 'replace'.
+	Metrics []MetricOperation `json:"metrics,omitempty"`
 }
 
 func (m MetricOperation) String() string {
@@ -52,6 +54,9 @@ func (m MetricOperation) String() string {
 	if m.Labels != nil {
 		parts = append(parts, fmt.Sprintf("labels=%+v", m.Labels))
 	}
+	if m.Metrics != nil {
+		parts = append(parts, fmt.Sprintf("metrics=%d", len(m.Metrics)))
+	}
 
 	return "[" + strings.Join(parts, ", ") + "]"
 }
@@ -68,14 +73,9 @@ func MetricOperationsFromReader(r io.Reader) ([]MetricOperation, error) {
 			return nil, err
 		}
 
-		// shortcut transforms
-		if metricOperation.Set != nil && metricOperation.Add == nil {
-			metricOperation.Action = "set"
-			metricOperation.Value = metricOperation.Set
-		}
-		if metricOperation.Add != nil && metricOperation.Set == nil {
-			metricOperation.Action = "add"
-			metricOperation.Value = metricOperation.Add
+		expandShortcuts(&metricOperation)
+		for i := range metricOperation.Metrics {
+			expandShortcuts(&metricOperation.Metrics[i])
 		}
 
 		operations = append(operations, metricOperation)
@@ -84,6 +84,18 @@ func MetricOperationsFromReader(r io.Reader) ([]MetricOperation, error) {
 	return operations, nil
 }
 
+// expandShortcuts transforms 'set' and 'add' shortcuts to action and value.
+func expandShortcuts(op *MetricOperation) {
+	if op.Set != nil && op.Add == nil {
+		op.Action = "set"
+		op.Value = op.Set
+	}
+	if op.Add != nil && op.Set == nil {
+		op.Action = "add"
+		op.Value = op.Add
+	}
+}
+
 func MetricOperationsFromBytes(data []byte) ([]MetricOperation, error) {
 	return MetricOperationsFromReader(bytes.NewReader(data))
 }
@@ -125,7 +137,7 @@ func ValidateMetricOperation(op MetricOperation) error {
 			opErrs = multierror.Append(opErrs, fmt.Errorf("unsupported action '%s': %s", op.Action, op))
 		}
 	} else {
-		if op.Action != "expire" && op.Action != "set" && op.Action != "add" {
+		if op.Action != "expire" && op.Action != "replace" && op.Action != "set" && op.Action != "add" {
 			opErrs = multierror.Append(opErrs, fmt.Errorf("unsupported action '%s': %s", op.Action, op))
 		}
 	}
@@ -133,8 +145,22 @@ func ValidateMetricOperation(op MetricOperation) error {
 	if op.Name == "" && op.Group == "" {
 		opErrs = multierror.Append(opErrs, fmt.Errorf("'name' is required: %s", op))
 	}
-	if op.Name == "" && op.Group != "" && op.Action != "expire" {
-		opErrs = multierror.Append(opErrs, fmt.Errorf("'name' is required when action is not 'expire': %s", op))
+	if op.Name == "" && op.Group != "" && op.Action != "expire" && op.Action != "replace" {
+		opErrs = multierror.Append(opErrs, fmt.Errorf("'name' is required when action is not 'expire' or 'replace': %s", op))
+	}
+
+	if op.Action == "replace" {
+		for _, m := range op.Metrics {
+			if m.Group != "" || (m.Action != "set" && m.Action != "add") {
+				opErrs = multierror.Append(opErrs, fmt.Errorf("only 'set' and 'add' without 'group' are supported in 'metrics': %s", m))
+				continue
+			}
+			if err := ValidateMetricOperation(m); err != nil {
+				opErrs = multierror.Append(opErrs, err)
+			}
+		}
+	} else if op.Metrics != nil {
+		opErrs = multierror.Append(opErrs, fmt.Errorf("'metrics' is supported only for action 'replace': %s", op))
 	}
 
 	if op.Action == "set" && op.Value == nil {
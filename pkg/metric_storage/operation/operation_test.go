@@ -33,6 +33,26 @@ func Test_ValidateMetricOperations(t *testing.T) {
 			`{"group":"someGroup", "action":"expired"}`,
 			false,
 		},
+		{
+			"replace",
+			`{"group":"someGroup", "action":"replace", "metrics":[{"name":"metric", "action":"set", "value":1}, {"name":"metric_total", "add":1}]}`,
+			true,
+		},
+		{
+			"replace with empty metrics",
+			`{"group":"someGroup", "action":"replace", "metrics":[]}`,
+			true,
+		},
+		{
+			"replace with unsupported action",
+			`{"group":"someGroup", "action":"replace", "metrics":[{"name":"metric", "action":"observe", "value":1, "buckets":[1]}]}`,
+			false,
+		},
+		{
+			"metrics without replace",
+			`{"group":"someGroup", "name":"metric", "action":"set", "value":1, "metrics":[{"name":"metric", "action":"set", "value":1}]}`,
+			false,
+		},
 	}
 
 	for _, tt := range tests {
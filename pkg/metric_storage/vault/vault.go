@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	log "github.com/sirupsen/logrus"
 
 	. "github.com/flant/shell-operator/pkg/utils/labels"
@@ -15,6 +16,9 @@ type GroupedVault struct {
 	collectors map[string]metric.ConstCollector
 	mtx        sync.Mutex
 	registerer prometheus.Registerer
+
+	// swapMtx is held while the group is replaced, gathering waits for it.
+	swapMtx sync.RWMutex
 }
 
 func NewGroupedVault() *GroupedVault {
@@ -40,6 +44,25 @@ func (v *GroupedVault) ExpireGroupMetrics(group string) {
 	}
 }
 
+// ReplaceGroupMetrics expires all metrics of the group and calls apply to set
+// new metrics. Gatherer returned by WrapGatherer never observes a partially
+// replaced group.
+func (v *GroupedVault) ReplaceGroupMetrics(group string, apply func()) {
+	v.swapMtx.Lock()
+	defer v.swapMtx.Unlock()
+	v.ExpireGroupMetrics(group)
+	apply()
+}
+
+// WrapGatherer returns a gatherer that waits for group replacements in progress.
+func (v *GroupedVault) WrapGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		v.swapMtx.RLock()
+		defer v.swapMtx.RUnlock()
+		return g.Gather()
+	})
+}
+
 func (v *GroupedVault) GetOrCreateCounterCollector(name string, labelNames []string) (*metric.ConstCounterCollector, error) {
 	v.mtx.Lock()
 	defer v.mtx.Unlock()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/prometheus/client_golang/prometheus"
@@ -156,3 +157,45 @@ metric_total4{a="", b="", c="", d="", j="j1"} 41
 	err = promtest.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expect), "metric_total1", "metric_total2", "metric_total3", "metric_total4")
 	g.Expect(err).ShouldNot(HaveOccurred())
 }
+
+func Test_ReplaceGroupMetrics(t *testing.T) {
+	g := NewWithT(t)
+
+	reg := prometheus.NewRegistry()
+	v := NewGroupedVault()
+	v.SetRegisterer(reg)
+	gatherer := v.WrapGatherer(reg)
+
+	v.GaugeSet("group1", "replaced_metric", 1.0, map[string]string{"lbl": "old"})
+
+	replacing := make(chan struct{})
+	gathered := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		v.ReplaceGroupMetrics("group1", func() {
+			close(replacing)
+			// Gather should wait for the replacement.
+			select {
+			case <-gathered:
+				t.Error("metrics are gathered during the group replacement")
+			case <-time.After(100 * time.Millisecond):
+			}
+			v.GaugeSet("group1", "replaced_metric", 2.0, map[string]string{"lbl": "new"})
+		})
+		close(done)
+	}()
+
+	<-replacing
+	_, err := gatherer.Gather()
+	close(gathered)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	<-done
+
+	expect := `
+# HELP replaced_metric replaced_metric
+# TYPE replaced_metric gauge
+replaced_metric{lbl="new"} 2
+`
+	err = promtest.GatherAndCompare(gatherer, strings.NewReader(expect), "replaced_metric")
+	g.Expect(err).ShouldNot(HaveOccurred())
+}
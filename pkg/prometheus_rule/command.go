@@ -0,0 +1,48 @@
+package prometheus_rule
+
+import (
+	"fmt"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+// DefinePrometheusRuleCommand defines a command to print recommended alerts for the operator.
+func DefinePrometheusRuleCommand(kpApp *kingpin.Application) {
+	opts := DefaultOptions()
+	opts.MetricsPrefix = app.PrometheusMetricsPrefix
+
+	cmd := app.CommandWithDefaultUsageTemplate(kpApp, "prometheus-rule", "Print a PrometheusRule manifest with recommended alerts for the operator.").
+		Action(func(c *kingpin.ParseContext) error {
+			data, err := New(opts).YAML()
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		})
+	cmd.Flag("name", "A name of the PrometheusRule and of the rule group.").
+		Default(opts.Name).
+		StringVar(&opts.Name)
+	cmd.Flag("namespace", "A namespace of the PrometheusRule. Metrics are filtered by this namespace if --selector is not set. Can be set with $SHELL_OPERATOR_NAMESPACE.").
+		Envar("SHELL_OPERATOR_NAMESPACE").
+		StringVar(&opts.Namespace)
+	cmd.Flag("label", "A label for the PrometheusRule metadata, e.g. 'prometheus=main'. Can be repeated.").
+		StringMapVar(&opts.Labels)
+	cmd.Flag("selector", "A label matcher for metrics, e.g. 'job=shell-operator'. Can be repeated.").
+		StringMapVar(&opts.Selector)
+	cmd.Flag("prometheus-metrics-prefix", "A prefix for metrics names. Can be set with $SHELL_OPERATOR_PROMETHEUS_METRICS_PREFIX.").
+		Envar("SHELL_OPERATOR_PROMETHEUS_METRICS_PREFIX").
+		Default(opts.MetricsPrefix).
+		StringVar(&opts.MetricsPrefix)
+	cmd.Flag("queue-stall-duration", "Alert if a queue has tasks, but no hooks are executed for this duration.").
+		Default(opts.QueueStallDuration.String()).
+		DurationVar(&opts.QueueStallDuration)
+	cmd.Flag("hook-failure-ratio", "Alert if a ratio of failed hook runs is above this value.").
+		Default(formatFloat(opts.HookFailureRatio)).
+		Float64Var(&opts.HookFailureRatio)
+	cmd.Flag("admission-latency", "Alert if the 99th percentile of admission requests duration is above this value.").
+		Default(opts.AdmissionLatencyP99.String()).
+		DurationVar(&opts.AdmissionLatencyP99)
+}
@@ -0,0 +1,172 @@
+package prometheus_rule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Options are parameters of the generated PrometheusRule.
+type Options struct {
+	Name          string
+	Namespace     string
+	Labels        map[string]string
+	MetricsPrefix string
+	// Selector is a set of label matchers added to every metric, e.g. job="shell-operator".
+	Selector map[string]string
+
+	QueueStallDuration  time.Duration
+	HookFailureRatio    float64
+	AdmissionLatencyP99 time.Duration
+}
+
+// DefaultOptions returns options with recommended thresholds.
+func DefaultOptions() Options {
+	return Options{
+		Name:                "shell-operator",
+		MetricsPrefix:       "shell_operator_",
+		QueueStallDuration:  15 * time.Minute,
+		HookFailureRatio:    0.1,
+		AdmissionLatencyP99: time.Second,
+	}
+}
+
+// PrometheusRule is a minimal representation of the monitoring.coreos.com/v1 PrometheusRule.
+type PrometheusRule struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   Metadata           `json:"metadata"`
+	Spec       PrometheusRuleSpec `json:"spec"`
+}
+
+type Metadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type PrometheusRuleSpec struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// New returns a PrometheusRule with alerts for stalled queues, failing hooks and slow admission webhooks.
+func New(opts Options) *PrometheusRule {
+	m := func(name string) string {
+		return opts.MetricsPrefix + name + opts.selector()
+	}
+	stall := promDuration(opts.QueueStallDuration)
+
+	rules := []Rule{
+		{
+			Alert: "ShellOperatorQueueStalled",
+			Expr: fmt.Sprintf("sum by (queue) (min_over_time(%s[%s])) > 0\nunless on (queue)\nsum by (queue) (increase(%s[%s])) > 0",
+				m("tasks_queue_length"), stall, m("hook_run_seconds_count"), stall),
+			For:    "5m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Queue {{ $labels.queue }} is stalled.",
+				"description": fmt.Sprintf("Queue {{ $labels.queue }} has tasks, but no hooks were executed for %s. Check the status page and logs for a hook that blocks the queue.", stall),
+			},
+		},
+		{
+			Alert: "ShellOperatorHookFailureRateHigh",
+			Expr: fmt.Sprintf("sum by (hook) (rate(%s[10m]))\n/\nsum by (hook) (rate(%s[10m])) > %s",
+				m("hook_run_errors_total"), m("hook_run_seconds_count"), formatFloat(opts.HookFailureRatio)),
+			For:    "10m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Hook {{ $labels.hook }} fails too often.",
+				"description": fmt.Sprintf("More than %s of runs of hook {{ $labels.hook }} failed in the last 10 minutes.", formatPercent(opts.HookFailureRatio)),
+			},
+		},
+		{
+			Alert: "ShellOperatorAdmissionLatencyHigh",
+			Expr: fmt.Sprintf("histogram_quantile(0.99, sum by (le, hook, binding) (rate(%s[5m]))) > %s",
+				m("admission_request_duration_seconds_bucket"), formatFloat(opts.AdmissionLatencyP99.Seconds())),
+			For:    "10m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Admission webhook {{ $labels.hook }}/{{ $labels.binding }} is slow.",
+				"description": fmt.Sprintf("99th percentile of admission requests duration is above %s. Slow webhooks delay API requests and may hit the API server timeout.", opts.AdmissionLatencyP99),
+			},
+		},
+	}
+
+	return &PrometheusRule{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata: Metadata{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+			Labels:    opts.Labels,
+		},
+		Spec: PrometheusRuleSpec{
+			Groups: []RuleGroup{{Name: opts.Name, Rules: rules}},
+		},
+	}
+}
+
+// YAML returns the manifest in YAML format.
+func (r *PrometheusRule) YAML() ([]byte, error) {
+	return yaml.Marshal(r)
+}
+
+// selector returns label matchers for metrics. Operator's namespace is used
+// if no explicit selector is set.
+func (opts Options) selector() string {
+	matchers := opts.Selector
+	if len(matchers) == 0 && opts.Namespace != "" {
+		matchers = map[string]string{"namespace": opts.Namespace}
+	}
+	if len(matchers) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(matchers))
+	for name := range matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, matchers[name]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// promDuration formats duration in Prometheus format, e.g. 15m or 90s.
+func promDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+func formatFloat(f float64) string {
+	return fmt.Sprintf("%g", f)
+}
+
+func formatPercent(f float64) string {
+	return fmt.Sprintf("%g%%", f*100)
+}
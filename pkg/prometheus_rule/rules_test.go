@@ -0,0 +1,60 @@
+package prometheus_rule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_New_Defaults(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Namespace = "so-ns"
+	opts.Labels = map[string]string{"prometheus": "main"}
+
+	rule := New(opts)
+
+	assert.Equal(t, "PrometheusRule", rule.Kind)
+	assert.Equal(t, "so-ns", rule.Metadata.Namespace)
+	assert.Equal(t, "main", rule.Metadata.Labels["prometheus"])
+	require.Len(t, rule.Spec.Groups, 1)
+	require.Len(t, rule.Spec.Groups[0].Rules, 3)
+
+	for _, r := range rule.Spec.Groups[0].Rules {
+		// Metrics are filtered by the operator's namespace.
+		assert.Contains(t, r.Expr, `shell_operator_`)
+		assert.Contains(t, r.Expr, `{namespace="so-ns"}`)
+	}
+	assert.Contains(t, rule.Spec.Groups[0].Rules[0].Expr, "[15m]")
+	assert.True(t, strings.HasSuffix(rule.Spec.Groups[0].Rules[1].Expr, "> 0.1"))
+	assert.True(t, strings.HasSuffix(rule.Spec.Groups[0].Rules[2].Expr, "> 1"))
+}
+
+func Test_New_Selector_And_Prefix(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Namespace = "so-ns"
+	opts.MetricsPrefix = "my_operator_"
+	opts.Selector = map[string]string{"job": "my-operator", "env": "prod"}
+	opts.QueueStallDuration = 90 * time.Second
+	opts.AdmissionLatencyP99 = 500 * time.Millisecond
+
+	rules := New(opts).Spec.Groups[0].Rules
+
+	assert.Contains(t, rules[0].Expr, `my_operator_tasks_queue_length{env="prod",job="my-operator"}[90s]`)
+	assert.NotContains(t, rules[0].Expr, "shell_operator_")
+	assert.True(t, strings.HasSuffix(rules[2].Expr, "> 0.5"))
+}
+
+func Test_YAML(t *testing.T) {
+	data, err := New(DefaultOptions()).YAML()
+	require.NoError(t, err)
+
+	parsed := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &parsed))
+	assert.Equal(t, "monitoring.coreos.com/v1", parsed["apiVersion"])
+	// Namespace is omitted if not set.
+	assert.NotContains(t, parsed["metadata"], "namespace")
+}
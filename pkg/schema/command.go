@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+// DefineSchemaCommand defines a command to print schemas for hook developers.
+func DefineSchemaCommand(kpApp *kingpin.Application) {
+	var name string
+	var outputDir string
+
+	schemaCmd := app.CommandWithDefaultUsageTemplate(kpApp, "schema", "Print JSON Schemas to validate hooks. Show available schemas if name is not set.").
+		Action(func(c *kingpin.ParseContext) error {
+			if outputDir != "" {
+				return writeAll(outputDir)
+			}
+			if name == "" {
+				for _, e := range Entries() {
+					fmt.Printf("%-20s %s\n", e.Name, e.Description)
+				}
+				return nil
+			}
+			data, err := JSON(name)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		})
+	schemaCmd.Arg("name", "A name of the schema.").StringVar(&name)
+	schemaCmd.Flag("output-dir", "Write all schemas into the directory as <name>.json files.").
+		Short('d').
+		StringVar(&outputDir)
+}
+
+func writeAll(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory '%s': %v", dir, err)
+	}
+	for _, e := range Entries() {
+		data, err := e.JSON()
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, e.Name+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("write schema '%s': %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flant/shell-operator/pkg/hook/config"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+)
+
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Entry is a schema published for hook developers.
+type Entry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	yaml        string
+}
+
+// Entries returns all published schemas sorted by name.
+func Entries() []Entry {
+	entries := []Entry{
+		{
+			Name:        "hook-config-v0",
+			Description: "Hook configuration with configVersion v0 or without configVersion.",
+			yaml:        config.Schemas["v0"],
+		},
+		{
+			Name:        "hook-config-v1",
+			Description: "Hook configuration with configVersion v1.",
+			yaml:        config.Schemas["v1"],
+		},
+		{
+			Name:        "binding-context-v1",
+			Description: "Binding context passed to hooks in $BINDING_CONTEXT_PATH file.",
+			yaml:        bindingContextV1,
+		},
+		{
+			Name:        "object-patch",
+			Description: "Operation in $KUBERNETES_PATCH_PATH file.",
+			yaml:        object_patch.Schemas["v0"],
+		},
+		{
+			Name:        "metric-operation",
+			Description: "Operation in $METRICS_PATH file.",
+			yaml:        metricOperation,
+		},
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// Names returns names of all published schemas.
+func Names() []string {
+	names := make([]string, 0)
+	for _, e := range Entries() {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// JSON returns a schema by name as an indented JSON Schema document.
+func JSON(name string) ([]byte, error) {
+	for _, e := range Entries() {
+		if e.Name != name {
+			continue
+		}
+		return e.JSON()
+	}
+	return nil, fmt.Errorf("schema '%s' not found, possible names: %v", name, Names())
+}
+
+// JSON converts the schema to a JSON Schema document.
+func (e Entry) JSON() ([]byte, error) {
+	data, err := yaml.YAMLToJSON([]byte(e.yaml))
+	if err != nil {
+		return nil, fmt.Errorf("schema '%s': yaml to json: %v", e.Name, err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("schema '%s': json unmarshal: %v", e.Name, err)
+	}
+	doc["$schema"] = JSONSchemaDraft
+	doc["title"] = e.Name
+	doc["description"] = e.Description
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Entries_JSON(t *testing.T) {
+	for _, e := range Entries() {
+		data, err := e.JSON()
+		require.NoError(t, err, e.Name)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc), e.Name)
+		assert.Equal(t, JSONSchemaDraft, doc["$schema"])
+		assert.Equal(t, e.Name, doc["title"])
+	}
+}
+
+func Test_JSON_Unknown(t *testing.T) {
+	_, err := JSON("unknown")
+	assert.Error(t, err)
+}
+
+func Test_MetricOperation_Replace(t *testing.T) {
+	data, err := JSON("metric-operation")
+	require.NoError(t, err)
+	s := new(spec.Schema)
+	require.NoError(t, json.Unmarshal(data, s))
+	validator := validate.NewSchemaValidator(s, nil, "", strfmt.Default)
+
+	for doc, valid := range map[string]bool{
+		`{"group":"someGroup", "action":"replace", "metrics":[{"name":"metric", "action":"set", "value":1}, {"name":"metric_total", "add":1, "labels":{"a":"b"}}]}`: true,
+		`{"group":"someGroup", "action":"expire"}`: true,
+		`{"group":"someGroup", "action":"replace", "metrics":[{"name":"metric", "action":"observe", "value":1}]}`: false,
+	} {
+		var op map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(doc), &op))
+		assert.Equal(t, valid, validator.Validate(op).IsValid(), doc)
+	}
+}
@@ -0,0 +1,116 @@
+package schema
+
+// bindingContextV1 describes an array of binding contexts for configVersion v1.
+var bindingContextV1 = `
+type: array
+items:
+  type: object
+  required:
+  - binding
+  - type
+  properties:
+    binding:
+      type: string
+    type:
+      type: string
+      enum:
+      - Synchronization
+      - Event
+      - Schedule
+      - Group
+      - Validating
+      - Mutating
+      - Conversion
+    watchEvent:
+      type: string
+      enum:
+      - Added
+      - Modified
+      - Deleted
+    object:
+      type: object
+    filterResult: {}
+    objects:
+      type: array
+      items:
+        type: object
+        properties:
+          object:
+            type: object
+          filterResult: {}
+    snapshots:
+      type: object
+      additionalProperties:
+        type: array
+        items:
+          type: object
+          properties:
+            object:
+              type: object
+            filterResult: {}
+    groupName:
+      type: string
+    review:
+      type: object
+    fromVersion:
+      type: string
+    toVersion:
+      type: string
+`
+
+// metricOperation describes one JSON object in the $METRICS_PATH file.
+var metricOperation = `
+type: object
+additionalProperties: false
+properties:
+  name:
+    type: string
+  group:
+    type: string
+  action:
+    type: string
+    enum:
+    - set
+    - add
+    - observe
+    - expire
+    - replace
+  value:
+    type: number
+  set:
+    type: number
+  add:
+    type: number
+  buckets:
+    type: array
+    items:
+      type: number
+  labels:
+    type: object
+    additionalProperties:
+      type: string
+  metrics:
+    description: A new set of metrics for the group, used with action 'replace'.
+    type: array
+    items:
+      type: object
+      additionalProperties: false
+      properties:
+        name:
+          type: string
+        action:
+          type: string
+          enum:
+          - set
+          - add
+        value:
+          type: number
+        set:
+          type: number
+        add:
+          type: number
+        labels:
+          type: object
+          additionalProperties:
+            type: string
+`
@@ -3,17 +3,32 @@ package shell_operator
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/flant/shell-operator/pkg/alert"
 	"github.com/flant/shell-operator/pkg/app"
 	"github.com/flant/shell-operator/pkg/config"
 	"github.com/flant/shell-operator/pkg/debug"
+	"github.com/flant/shell-operator/pkg/exitcode"
+	"github.com/flant/shell-operator/pkg/golden"
 	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/hook/binding_context"
+	"github.com/flant/shell-operator/pkg/hook_bundle"
 	"github.com/flant/shell-operator/pkg/jq"
+	"github.com/flant/shell-operator/pkg/kube/api_warnings"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
 	"github.com/flant/shell-operator/pkg/kube_events_manager"
+	"github.com/flant/shell-operator/pkg/metric_storage"
 	"github.com/flant/shell-operator/pkg/schedule_manager"
 	"github.com/flant/shell-operator/pkg/task/queue"
+	"github.com/flant/shell-operator/pkg/tracing"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 	utils "github.com/flant/shell-operator/pkg/utils/file"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
 	"github.com/flant/shell-operator/pkg/webhook/conversion"
@@ -25,29 +40,45 @@ func Init() (*ShellOperator, error) {
 	runtimeConfig := config.NewConfig()
 	// Init logging subsystem.
 	app.SetupLogging(runtimeConfig)
+	// Apply changes of safe options from the config file.
+	app.WatchConfigFile(runtimeConfig)
+	// Do not terminate on signals for onSignal bindings before hooks are started.
+	trigger_manager.NotifySignals()
 	// Log version and jq filtering implementation.
 	log.Infof(app.AppStartMessage)
 	log.Debug(jq.FilterInfo())
 
-	hooksDir, err := utils.RequireExistingDirectory(app.HooksDir)
+	err := tuneGoRuntime()
 	if err != nil {
-		log.Errorf("Fatal: hooks directory is required: %s", err)
-		return nil, err
+		log.Errorf("Fatal: go-mem-limit: %s", err)
+		return nil, exitcode.Wrap(exitcode.ConfigError, err)
+	}
+	enableContentionProfiles()
+
+	if app.DebugTraceSpans {
+		tracing.SetTracer(tracing.NewLogTracer())
 	}
 
 	tempDir, err := utils.EnsureTempDirectory(app.TempDir)
 	if err != nil {
 		log.Errorf("Fatal: temp directory: %s", err)
-		return nil, err
+		return nil, exitcode.Wrap(exitcode.ConfigError, err)
+	}
+
+	hooksDir, err := requireHooksDirectory(tempDir)
+	if err != nil {
+		log.Errorf("Fatal: hooks directory is required: %s", err)
+		return nil, exitcode.Wrap(exitcode.ConfigError, err)
 	}
 
 	op := NewShellOperator(context.Background())
+	op.Startup = NewStartupProgress()
 
 	// Debug server.
 	debugServer, err := RunDefaultDebugServer(app.DebugUnixSocket, app.DebugHttpServerAddr)
 	if err != nil {
 		log.Errorf("Fatal: start Debug server: %s", err)
-		return nil, err
+		return nil, exitcode.Wrap(exitcode.DebugServerError, err)
 	}
 
 	err = op.AssembleCommonOperator(app.ListenAddress, app.ListenPort, map[string]string{
@@ -69,28 +100,99 @@ func Init() (*ShellOperator, error) {
 	return op, nil
 }
 
+// requireHooksDirectory returns --hooks-dir or a directory with the bundle
+// pulled from the OCI registry if --hooks-oci-ref is set.
+func requireHooksDirectory(tempDir string) (string, error) {
+	if app.HooksOCIRef == "" {
+		return utils.RequireExistingDirectory(app.HooksDir)
+	}
+
+	dir := filepath.Join(tempDir, "hooks-bundle")
+	// Interrupt the pull on SIGTERM: signals are not handled yet.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	_, err := hook_bundle.Pull(ctx, app.HooksOCIRef, dir, hook_bundle.OptionsFromFlags())
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
 // AssembleCommonOperator instantiate common dependencies. These dependencies
 // may be used for shell-operator derivatives, like addon-operator.
 // requires listenAddress, listenPort to run http server for operator APIs
 func (op *ShellOperator) AssembleCommonOperator(listenAddress, listenPort string, kubeEventsManagerLabels map[string]string) (err error) {
 	op.APIServer = newBaseHTTPServer(listenAddress, listenPort)
+	op.APIServer.socket = app.ListenSocket
 
-	// built-in metrics
-	op.setupMetricStorage(kubeEventsManagerLabels)
+	// Static labels and label renames for all metrics.
+	labelRules, err := metric_storage.ParseLabelRules(app.PrometheusStaticLabels, app.PrometheusLabelRewrite)
+	if err != nil {
+		return exitcode.Wrap(exitcode.ConfigError, err)
+	}
+
+	// built-in metrics, values of dynamic queues and hooks are collapsed above the limit.
+	cardinalityLimit := metric_storage.NewCardinalityLimit(app.PrometheusLabelCardinalityLimit, app.PrometheusLabelCardinalityLabels)
+	op.setupMetricStorage(kubeEventsManagerLabels, labelRules.WithCardinalityLimit(cardinalityLimit))
+
+	// Effective GOMAXPROCS and GOMEMLIMIT.
+	registerGoRuntimeMetrics(op.MetricStorage)
+
+	// Version, commit and build date.
+	buildInfo := app.GetBuildInfo()
+	registerBuildInfoMetric(op.MetricStorage, buildInfo)
+	registerVersionRoute(op, buildInfo)
 
 	// metrics from user's hooks
-	op.setupHookMetricStorage()
+	op.setupHookMetricStorage(labelRules)
 
-	// 'main' Kubernetes client.
-	op.KubeClient, err = initDefaultMainKubeClient(op.MetricStorage)
+	// Memory budget for full objects in snapshots.
+	snapshotMemoryLimit, err := app.KubeSnapshotMemoryLimitBytes()
 	if err != nil {
-		return err
+		return exitcode.Wrap(exitcode.ConfigError, err)
 	}
+	kube_events_manager.DefaultSnapshotMemoryBudget.SetLimit(snapshotMemoryLimit, op.MetricStorage)
 
-	// ObjectPatcher with a separate Kubernetes client.
-	op.ObjectPatcher, err = initDefaultObjectPatcher(op.MetricStorage)
+	// Limits for start-up of many bindings.
+	kube_events_manager.DefaultStartupThrottle.SetLimits(app.KubeMaxConcurrentInitialLists, app.KubeMonitorStartBatchSize, app.KubeMonitorStartBatchDelay, op.MetricStorage)
+
+	// Cache for jqFilter results.
+	kube_events_manager.DefaultFilterCache.SetLimit(app.JqFilterCacheSize, op.MetricStorage)
+
+	// Storage to share snapshots between replicas.
+	kube_events_manager.DefaultSnapshotStorage, err = kube_events_manager.NewSnapshotStorage()
 	if err != nil {
-		return err
+		return exitcode.Wrap(exitcode.ConfigError, err)
+	}
+
+	// Warnings from the API server for all Kubernetes clients.
+	api_warnings.DefaultHandler.Setup(app.KubeAPIWarnings, op.MetricStorage)
+
+	// Disable mutating operations in the read-only mode.
+	read_only.Setup(app.ReadOnly, op.MetricStorage)
+
+	// 'main' Kubernetes client.
+	if op.KubeClient == nil {
+		op.KubeClient, err = initDefaultMainKubeClient(op.MetricStorage)
+		if err != nil {
+			return exitcode.Wrap(exitcode.KubeConnectionError, err)
+		}
+	}
+
+	// ObjectPatcher with a separate Kubernetes client.
+	if op.ObjectPatcher == nil {
+		op.ObjectPatcher, err = initDefaultObjectPatcher(op.MetricStorage)
+		if err != nil {
+			return exitcode.Wrap(exitcode.KubeConnectionError, err)
+		}
+	}
+
+	// Locker for distributed locks of hooks and queues.
+	if op.Locker == nil {
+		op.Locker, err = initDefaultLocker(op.KubeClient, op.MetricStorage)
+		if err != nil {
+			return exitcode.Wrap(exitcode.KubeConnectionError, err)
+		}
 	}
 
 	op.SetupEventManagers()
@@ -112,33 +214,74 @@ func (op *ShellOperator) AssembleCommonOperator(listenAddress, listenPort string
 //   - schedule manager
 func (op *ShellOperator) assembleShellOperator(hooksDir string, tempDir string, debugServer *debug.Server, runtimeConfig *config.Config) (err error) {
 	registerRootRoute(op)
+	registerSchemaRoutes(op)
+	registerWebhookTriggerRoutes(op)
+	registerStatusRoutes(op, app.StatusPageBasicAuth)
+
+	op.faults = newFaultInjector(op.MetricStorage)
+
+	if app.AlertWebhookURL != "" {
+		op.AlertNotifier = alert.NewNotifier(app.AlertWebhookURL, app.AlertWebhookTimeout, app.AlertWebhookRetries)
+	}
+
+	if app.KubeEventsEnabled {
+		object, err := alert.ResolveKubeEventsObject(op.KubeClient, app.Namespace, app.KubeEventsObject)
+		if err != nil {
+			return exitcode.Wrap(exitcode.ConfigError, fmt.Errorf("resolve object for Kubernetes Events: %v", err))
+		}
+		op.KubeEventRecorder = alert.NewKubeEventRecorder(op.KubeClient, object)
+	}
+
+	if app.ShadowHooksDir != "" {
+		op.shadowHooks, err = newShadowHooks(app.ShadowHooksDir, op.MetricStorage)
+		if err != nil {
+			return exitcode.Wrap(exitcode.ConfigError, err)
+		}
+		log.Infof("Shadow hooks directory: %s", op.shadowHooks.dir)
+	}
+
 	// for shell-operator only
 	registerHookMetrics(op.HookMetricStorage)
+	op.restoreHookCounters(app.HookMetricsCountersFile)
 
 	op.RegisterDebugQueueRoutes(debugServer)
 	op.RegisterDebugHookRoutes(debugServer)
+	op.RegisterDebugKubeRoutes(debugServer)
 	op.RegisterDebugConfigRoutes(debugServer, runtimeConfig)
+	op.RegisterDebugStateRoutes(debugServer)
+
+	// Serve startup progress while hooks are loading.
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/startup", op.Startup.Handler)
+	registerSelfTestRoute(op, tempDir)
+	op.APIServer.Start(op.ctx)
 
 	// Create webhookManagers with dependencies.
 	op.setupHookManagers(hooksDir, tempDir)
 
 	// Search and configure all hooks.
+	op.Startup.Begin(StartupPhaseHookDiscovery, 0)
 	err = op.initHookManager()
 	if err != nil {
-		return fmt.Errorf("initialize HookManager fail: %s", err)
+		op.Startup.Fail(StartupPhaseHookDiscovery, err)
+		return exitcode.Wrap(exitcode.ConfigError, fmt.Errorf("initialize HookManager fail: %s", err))
 	}
+	op.Startup.Finish(StartupPhaseHookDiscovery)
 
 	// Load validation hooks.
+	op.Startup.Begin(StartupPhaseWebhookSetup, 0)
 	err = op.initValidatingWebhookManager()
 	if err != nil {
-		return fmt.Errorf("initialize ValidatingWebhookManager fail: %s", err)
+		op.Startup.Fail(StartupPhaseWebhookSetup, err)
+		return exitcode.Wrap(exitcode.WebhookBootstrapError, fmt.Errorf("initialize ValidatingWebhookManager fail: %s", err))
 	}
 
 	// Load conversion hooks.
 	err = op.initConversionWebhookManager()
 	if err != nil {
-		return fmt.Errorf("initialize ConversionWebhookManager fail: %s", err)
+		op.Startup.Fail(StartupPhaseWebhookSetup, err)
+		return exitcode.Wrap(exitcode.WebhookBootstrapError, fmt.Errorf("initialize ConversionWebhookManager fail: %s", err))
 	}
+	op.Startup.Finish(StartupPhaseWebhookSetup)
 
 	return nil
 }
@@ -150,19 +293,35 @@ func (op *ShellOperator) SetupEventManagers() {
 	op.TaskQueues = queue.NewTaskQueueSet()
 	op.TaskQueues.WithContext(op.ctx)
 	op.TaskQueues.WithMetricStorage(op.MetricStorage)
+	op.TaskQueues.WithPanicHandler(func(err error) {
+		op.reportFatal(exitcode.Wrap(exitcode.QueueFatalError, err))
+	})
+	if len(app.QueueFairScheduling) > 0 {
+		op.TaskQueues.WithFairScheduling(app.QueueFairScheduling, fairSchedulingKey)
+	}
 
 	// Initialize schedule manager.
-	op.ScheduleManager = schedule_manager.NewScheduleManager(op.ctx)
+	if op.ScheduleManager == nil {
+		op.ScheduleManager = schedule_manager.NewScheduleManager(op.ctx)
+	}
+
+	// Initialize manager of external sources for trigger bindings.
+	if op.TriggerManager == nil {
+		op.TriggerManager = trigger_manager.NewTriggerManager(op.ctx)
+	}
 
 	// Initialize kubernetes events manager.
-	op.KubeEventsManager = kube_events_manager.NewKubeEventsManager(op.ctx, op.KubeClient)
-	op.KubeEventsManager.WithMetricStorage(op.MetricStorage)
+	if op.KubeEventsManager == nil {
+		op.KubeEventsManager = kube_events_manager.NewKubeEventsManager(op.ctx, op.KubeClient)
+		op.KubeEventsManager.WithMetricStorage(op.MetricStorage)
+	}
 
 	// Initialize events handler that emit tasks to run hooks
 	cfg := &managerEventsHandlerConfig{
 		tqs:  op.TaskQueues,
 		mgr:  op.KubeEventsManager,
 		smgr: op.ScheduleManager,
+		tmgr: op.TriggerManager,
 	}
 	op.ManagerEventsHandler = newManagerEventsHandler(op.ctx, cfg)
 }
@@ -173,6 +332,7 @@ func (op *ShellOperator) setupHookManagers(hooksDir string, tempDir string) {
 	op.AdmissionWebhookManager = admission.NewWebhookManager(op.KubeClient)
 	op.AdmissionWebhookManager.Settings = app.ValidatingWebhookSettings
 	op.AdmissionWebhookManager.Namespace = app.Namespace
+	op.AdmissionWebhookManager.WithMetricStorage(op.MetricStorage)
 
 	// Initialize conversion webhooks manager.
 	op.ConversionWebhookManager = conversion.NewWebhookManager()
@@ -180,14 +340,75 @@ func (op *ShellOperator) setupHookManagers(hooksDir string, tempDir string) {
 	op.ConversionWebhookManager.Settings = app.ConversionWebhookSettings
 	op.ConversionWebhookManager.Namespace = app.Namespace
 
+	// Kubeconfig for kubectl in hooks.
+	var kubeconfigPath string
+	if app.HookKubeconfig {
+		path, err := hook.GenerateKubeconfig(filepath.Join(tempDir, hook.KubeconfigFileName), app.Namespace, nil)
+		if err != nil {
+			log.Errorf("Generate kubeconfig for hooks: %v", err)
+		} else if path != "" {
+			log.Infof("Kubeconfig for hooks is generated in '%s'", path)
+			kubeconfigPath = path
+		}
+	}
+
+	// Recorder of hook runs for golden tests.
+	var recorder *golden.Recorder
+	if app.HookRecordDir != "" {
+		var err error
+		recorder, err = golden.NewRecorder(app.HookRecordDir, app.HookRecordMaxRuns)
+		if err != nil {
+			log.Errorf("Hook runs are not recorded: %v", err)
+		} else {
+			log.Infof("Hook runs are recorded into '%s'", app.HookRecordDir)
+		}
+	}
+
 	// Initialize Hook manager.
 	cfg := &hook.ManagerConfig{
 		WorkingDir: hooksDir,
 		TempDir:    tempDir,
 		Kmgr:       op.KubeEventsManager,
 		Smgr:       op.ScheduleManager,
+		Tmgr:       op.TriggerManager,
 		Wmgr:       op.AdmissionWebhookManager,
 		Cmgr:       op.ConversionWebhookManager,
+
+		ConfigCacheDir:    app.HookConfigCacheDir,
+		ConfigConcurrency: app.HookConfigConcurrency,
+		ConfigTimeout:     app.HookConfigTimeout,
+		KubeconfigPath:    kubeconfigPath,
+		Cluster:           op.clusterMetadata(),
+		Namespace:         app.Namespace,
+		EnableHooks:       app.EnableHooks,
+		DisableHooks:      app.DisableHooks,
+		Profile:           app.HookProfile,
+
+		ChecksumsPath:      app.HooksChecksums,
+		ChecksumsPublicKey: app.HooksChecksumsPublicKey,
+		Recorder:           recorder,
+	}
+	if op.HookManager == nil {
+		op.HookManager = hook.NewHookManager(cfg)
+	}
+}
+
+// clusterMetadata returns metadata of the cluster for binding contexts.
+// The Kubernetes version is omitted if the API server is not available.
+func (op *ShellOperator) clusterMetadata() *binding_context.Cluster {
+	cluster := &binding_context.Cluster{
+		Name:            app.ClusterName,
+		ID:              app.ClusterID,
+		OperatorVersion: app.Version,
+	}
+	if op.KubeClient == nil {
+		return cluster
+	}
+	info, err := op.KubeClient.Discovery().ServerVersion()
+	if err != nil {
+		log.Warnf("Get Kubernetes version for binding contexts: %v", err)
+		return cluster
 	}
-	op.HookManager = hook.NewHookManager(cfg)
+	cluster.KubernetesVersion = info.GitVersion
+	return cluster
 }
@@ -106,6 +106,8 @@ func combineBindingContextForHook(tqs *queue.TaskQueueSet, q *queue.TaskQueue, t
 		groupName := combinedContext[i].Metadata.Group
 		if groupName != "" && (i+1 <= len(combinedContext)-1) && combinedContext[i+1].Metadata.Group == groupName {
 			keep = false
+			// Keep crontabs from the dropped schedule context.
+			combinedContext[i+1].Crontabs = mergeCrontabs(combinedContext[i].Crontabs, combinedContext[i+1].Crontabs)
 		}
 
 		if keep {
@@ -8,15 +8,21 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/flant/shell-operator/pkg/app"
 	"github.com/flant/shell-operator/pkg/config"
 	"github.com/flant/shell-operator/pkg/debug"
+	"github.com/flant/shell-operator/pkg/kube/api_warnings"
+	"github.com/flant/shell-operator/pkg/kube_events_manager"
 	"github.com/flant/shell-operator/pkg/task/dump"
+	"github.com/flant/shell-operator/pkg/utils/headers"
 )
 
 // RunDefaultDebugServer initialized and run default debug server on unix and http sockets
 // This method is also used in addon-operator
 func RunDefaultDebugServer(unixSocket, httpServerAddress string) (*debug.Server, error) {
 	dbgSrv := debug.NewServer("/debug", unixSocket, httpServerAddress)
+	dbgSrv.Router.Use(headers.Security)
+	dbgSrv.Router.Use(headers.Cors(headers.NewCorsConfig(app.CorsAllowedOrigins, app.CorsAllowedMethods, app.CorsAllowedHeaders)))
 
 	dbgSrv.RegisterHandler(http.MethodGet, "/", func(_ *http.Request) (interface{}, error) {
 		return "debug endpoint is alive", nil
@@ -57,6 +63,74 @@ func (op *ShellOperator) RegisterDebugHookRoutes(dbgSrv *debug.Server) {
 		h := op.HookManager.GetHook(hookName)
 		return h.HookController.SnapshotsDump(), nil
 	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/hook/{name}/history.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		hookName := chi.URLParam(r, "name")
+		h := op.HookManager.GetHook(hookName)
+		return h.HookController.EventHistoryDump(), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/hook/{name}/runs.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		hookName := chi.URLParam(r, "name")
+		h := op.HookManager.GetHook(hookName)
+		if h == nil {
+			return nil, &debug.BadRequestError{Msg: fmt.Sprintf("hook '%s' not found", hookName)}
+		}
+		return h.Runs(), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/hook/{name}/shadow.{format:(json|yaml|text)}", func(r *http.Request) (interface{}, error) {
+		return op.shadowHooks.Diffs(chi.URLParam(r, "name")), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodPost, "/hook/{name}/enable", func(r *http.Request) (interface{}, error) {
+		return nil, op.setHookPaused(chi.URLParam(r, "name"), false)
+	})
+
+	dbgSrv.RegisterHandler(http.MethodPost, "/hook/{name}/disable", func(r *http.Request) (interface{}, error) {
+		return nil, op.setHookPaused(chi.URLParam(r, "name"), true)
+	})
+
+	dbgSrv.RegisterHandler(http.MethodPost, "/hook/{name}/unquarantine", func(r *http.Request) (interface{}, error) {
+		hookName := chi.URLParam(r, "name")
+		h := op.HookManager.GetHook(hookName)
+		if h == nil {
+			return nil, &debug.BadRequestError{Msg: fmt.Sprintf("hook '%s' not found", hookName)}
+		}
+		h.Unquarantine()
+		op.MetricStorage.GaugeSet("{PREFIX}hook_quarantined", 0.0, map[string]string{"hook": hookName})
+		return nil, nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodPost, "/hook/{name}/check", func(r *http.Request) (interface{}, error) {
+		status, err := op.checkHook(chi.URLParam(r, "name"))
+		if err != nil {
+			return nil, &debug.BadRequestError{Msg: err.Error()}
+		}
+		return status, nil
+	})
+}
+
+// RegisterDebugKubeRoutes registers routes to inspect Kubernetes informers.
+func (op *ShellOperator) RegisterDebugKubeRoutes(dbgSrv *debug.Server) {
+	dbgSrv.RegisterHandler(http.MethodGet, "/kube/event-bus.{format:(json|yaml|text)}", func(_ *http.Request) (interface{}, error) {
+		return kube_events_manager.EventBusStatsDump(), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/kube/api-warnings.{format:(json|yaml|text)}", func(_ *http.Request) (interface{}, error) {
+		return api_warnings.DefaultHandler.List(), nil
+	})
+
+	dbgSrv.RegisterHandler(http.MethodGet, "/kube/bindings.{format:(json|yaml|text)}", func(_ *http.Request) (interface{}, error) {
+		return op.KubeEventsManager.MonitorConditions(), nil
+	})
+}
+
+func (op *ShellOperator) setHookPaused(hookName string, paused bool) error {
+	if err := op.HookManager.SetHookPaused(hookName, paused); err != nil {
+		return &debug.BadRequestError{Msg: err.Error()}
+	}
+	return nil
 }
 
 // RegisterDebugConfigRoutes registers routes to manage runtime configuration.
@@ -70,7 +144,9 @@ func (op *ShellOperator) RegisterDebugConfigRoutes(dbgSrv *debug.Server, runtime
 		return runtimeConfig.List(), nil
 	})
 
-	dbgSrv.RegisterHandler(http.MethodPost, "/config/set", func(r *http.Request) (interface{}, error) {
+	// Runtime parameters are short strings.
+	configSetLimits := debug.RouteLimits{MaxBodyBytes: 64 << 10, Timeout: 10 * time.Second}
+	dbgSrv.RegisterHandlerWithLimits(http.MethodPost, "/config/set", configSetLimits, func(r *http.Request) (interface{}, error) {
 		err := r.ParseForm()
 		if err != nil {
 			return nil, err
@@ -0,0 +1,104 @@
+package shell_operator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// Kinds of injected faults.
+const (
+	faultHookFailure = "hook_failure"
+	faultHookDelay   = "hook_delay"
+	faultAPIError    = "api_error"
+)
+
+// faultInjector fails and delays hook runs and Kubernetes operations with
+// the configured probabilities. It is a developer mode to check that
+// allowFailure, retries and alerts work as expected.
+// Methods are safe to call on a nil faultInjector.
+type faultInjector struct {
+	HookFailureRate float64
+	HookDelayRate   float64
+	HookDelay       time.Duration
+	APIErrorRate    float64
+	// Hooks limits faults to these hooks. All hooks are affected if empty.
+	Hooks map[string]bool
+
+	metricStorage *metric_storage.MetricStorage
+	// random returns a number in [0.0,1.0).
+	random func() float64
+	sleep  func(time.Duration)
+}
+
+// newFaultInjector returns nil if no faults are configured.
+func newFaultInjector(metricStorage *metric_storage.MetricStorage) *faultInjector {
+	if app.DebugFaultHookFailureRate <= 0 && app.DebugFaultHookDelayRate <= 0 && app.DebugFaultAPIErrorRate <= 0 {
+		return nil
+	}
+	fi := &faultInjector{
+		HookFailureRate: app.DebugFaultHookFailureRate,
+		HookDelayRate:   app.DebugFaultHookDelayRate,
+		HookDelay:       app.DebugFaultHookDelay,
+		APIErrorRate:    app.DebugFaultAPIErrorRate,
+		Hooks:           make(map[string]bool),
+		metricStorage:   metricStorage,
+		random:          rand.Float64,
+		sleep:           time.Sleep,
+	}
+	for _, hookName := range app.DebugFaultHooks {
+		fi.Hooks[hookName] = true
+	}
+	log.Warnf("Fault injection is enabled: hook failure rate %.2f, hook delay rate %.2f with delay %s, API error rate %.2f, hooks %v",
+		fi.HookFailureRate, fi.HookDelayRate, fi.HookDelay.String(), fi.APIErrorRate, app.DebugFaultHooks)
+	return fi
+}
+
+// beforeHookRun delays the hook run or returns an error to fail it.
+func (fi *faultInjector) beforeHookRun(hookName string) error {
+	if !fi.affects(hookName) {
+		return nil
+	}
+	if fi.hit(fi.HookDelayRate) {
+		fi.count(hookName, faultHookDelay)
+		log.Warnf("Fault injection: delay hook '%s' for %s", hookName, fi.HookDelay.String())
+		fi.sleep(fi.HookDelay)
+	}
+	if fi.hit(fi.HookFailureRate) {
+		fi.count(hookName, faultHookFailure)
+		return fmt.Errorf("fault injection: hook '%s' is failed", hookName)
+	}
+	return nil
+}
+
+// beforeAPICall returns an error to fail Kubernetes operations of the hook.
+func (fi *faultInjector) beforeAPICall(hookName string) error {
+	if !fi.affects(hookName) {
+		return nil
+	}
+	if fi.hit(fi.APIErrorRate) {
+		fi.count(hookName, faultAPIError)
+		return fmt.Errorf("fault injection: Kubernetes API error for hook '%s'", hookName)
+	}
+	return nil
+}
+
+func (fi *faultInjector) affects(hookName string) bool {
+	if fi == nil {
+		return false
+	}
+	return len(fi.Hooks) == 0 || fi.Hooks[hookName]
+}
+
+func (fi *faultInjector) hit(rate float64) bool {
+	return rate > 0 && fi.random() < rate
+}
+
+func (fi *faultInjector) count(hookName string, fault string) {
+	fi.metricStorage.CounterAdd("{PREFIX}fault_injections_total", 1.0, map[string]string{"hook": hookName, "fault": fault})
+}
@@ -0,0 +1,54 @@
+package shell_operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFaultInjector(random float64) *faultInjector {
+	return &faultInjector{
+		HookDelay: time.Second,
+		Hooks:     map[string]bool{},
+		random:    func() float64 { return random },
+		sleep:     func(time.Duration) {},
+	}
+}
+
+func Test_FaultInjector_Nil(t *testing.T) {
+	var fi *faultInjector
+	assert.NoError(t, fi.beforeHookRun("hook.sh"))
+	assert.NoError(t, fi.beforeAPICall("hook.sh"))
+}
+
+func Test_FaultInjector_Rates(t *testing.T) {
+	fi := newTestFaultInjector(0.3)
+	fi.HookFailureRate = 0.5
+	fi.APIErrorRate = 0.2
+
+	assert.Error(t, fi.beforeHookRun("hook.sh"))
+	assert.NoError(t, fi.beforeAPICall("hook.sh"))
+
+	fi.APIErrorRate = 0.4
+	assert.Error(t, fi.beforeAPICall("hook.sh"))
+}
+
+func Test_FaultInjector_Delay(t *testing.T) {
+	fi := newTestFaultInjector(0.1)
+	fi.HookDelayRate = 1
+	var slept time.Duration
+	fi.sleep = func(d time.Duration) { slept += d }
+
+	assert.NoError(t, fi.beforeHookRun("hook.sh"))
+	assert.Equal(t, time.Second, slept)
+}
+
+func Test_FaultInjector_Hooks(t *testing.T) {
+	fi := newTestFaultInjector(0)
+	fi.HookFailureRate = 1
+	fi.Hooks["failing.sh"] = true
+
+	assert.Error(t, fi.beforeHookRun("failing.sh"))
+	assert.NoError(t, fi.beforeHookRun("other.sh"))
+}
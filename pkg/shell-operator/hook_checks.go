@@ -0,0 +1,63 @@
+package shell_operator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flant/shell-operator/pkg/hook"
+)
+
+// runHookChecks starts periodic self-checks for hooks with settings.check.
+// The first check is run immediately to surface broken dependencies early.
+func (op *ShellOperator) runHookChecks() {
+	for _, hookName := range op.HookManager.GetHookNames() {
+		h := op.HookManager.GetHook(hookName)
+		if h == nil || !h.HasCheck() {
+			continue
+		}
+		go op.runHookCheckLoop(h, h.Config.Settings.Check.Interval)
+	}
+}
+
+func (op *ShellOperator) runHookCheckLoop(h *hook.Hook, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		op.observeHookCheck(h)
+		select {
+		case <-op.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHook runs the self-check of the hook on demand.
+func (op *ShellOperator) checkHook(hookName string) (*hook.CheckStatus, error) {
+	h := op.HookManager.GetHook(hookName)
+	if h == nil {
+		return nil, fmt.Errorf("hook '%s' not found", hookName)
+	}
+	if !h.HasCheck() {
+		return nil, fmt.Errorf("hook '%s' has no check: settings.check is not set", hookName)
+	}
+	return op.observeHookCheck(h), nil
+}
+
+// observeHookCheck runs the check and reports its result in metrics.
+func (op *ShellOperator) observeHookCheck(h *hook.Hook) *hook.CheckStatus {
+	status, err := h.Check()
+	if err != nil {
+		// Only hooks with checks are checked.
+		return nil
+	}
+	degraded := 0.0
+	result := "success"
+	if status.Degraded {
+		degraded = 1.0
+		result = "failure"
+	}
+	op.MetricStorage.GaugeSet("{PREFIX}hook_degraded", degraded, map[string]string{"hook": h.Name})
+	op.MetricStorage.CounterAdd("{PREFIX}hook_checks_total", 1.0, map[string]string{"hook": h.Name, "result": result})
+	return status
+}
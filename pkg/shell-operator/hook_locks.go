@@ -0,0 +1,96 @@
+package shell_operator
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/kube/lease_lock"
+)
+
+const (
+	queueLeasePrefix = "shell-operator-queue-"
+	hookLeasePrefix  = "shell-operator-hook-"
+)
+
+// isLockedQueue returns true if tasks of the queue should run only in one operator instance at a time.
+// Values of --lock-queues can be comma-separated lists.
+func isLockedQueue(queueName string) bool {
+	for _, value := range app.LockQueues {
+		for _, q := range strings.Split(value, ",") {
+			q = strings.TrimSpace(q)
+			if q == "*" || q == queueName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errLockLost is a cause of the hook run cancellation.
+var errLockLost = errors.New("distributed lock is lost")
+
+// cancelOnLostLocks returns a context that is canceled when any of Leases is lost,
+// so the hook is terminated before another instance runs it.
+func cancelOnLostLocks(ctx context.Context, locks []*lease_lock.Lock) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	for _, lock := range locks {
+		if lock == nil {
+			continue
+		}
+		go func(lost <-chan struct{}) {
+			select {
+			case <-lost:
+				cancel(errLockLost)
+			case <-ctx.Done():
+			}
+		}(lock.LostCh())
+	}
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// acquireHookLocks waits for Leases of the queue and the hook. The queue Lease is acquired first,
+// so instances do not block each other in a different order. Locks should be released with
+// releaseHookLocks even if an error is returned.
+func (op *ShellOperator) acquireHookLocks(ctx context.Context, h *hook.Hook, queueName string) ([]*lease_lock.Lock, error) {
+	locks := make([]*lease_lock.Lock, 0, 2)
+	if op.Locker == nil {
+		return locks, nil
+	}
+
+	if isLockedQueue(queueName) {
+		lock, err := op.Locker.Acquire(ctx, "", lease_lock.LeaseName(queueLeasePrefix, queueName))
+		if err != nil {
+			return locks, err
+		}
+		locks = append(locks, lock)
+	}
+
+	if h.Config != nil && h.Config.Settings != nil && h.Config.Settings.Lock != nil {
+		name := h.Config.Settings.Lock.Name
+		if name == "" {
+			name = lease_lock.LeaseName(hookLeasePrefix, h.Name)
+		}
+		lock, err := op.Locker.Acquire(ctx, h.Config.Settings.Lock.Namespace, name)
+		if err != nil {
+			return locks, err
+		}
+		locks = append(locks, lock)
+	}
+
+	return locks, nil
+}
+
+// releaseHookLocks frees Leases in the reverse order. It returns true if any Lease was lost during the hook run.
+func releaseHookLocks(locks []*lease_lock.Lock) bool {
+	lost := false
+	for i := len(locks) - 1; i >= 0; i-- {
+		if locks[i].Lost() {
+			lost = true
+		}
+		locks[i].Release()
+	}
+	return lost
+}
@@ -0,0 +1,64 @@
+package shell_operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flant/kube-client/fake"
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/kube/lease_lock"
+)
+
+func Test_acquireHookLocks(t *testing.T) {
+	g := NewWithT(t)
+
+	defaultQueues := app.LockQueues
+	app.LockQueues = []string{"main"}
+	defer func() { app.LockQueues = defaultQueues }()
+
+	fc := fake.NewFakeCluster(fake.ClusterVersionV121)
+	op := NewShellOperator(context.Background(), WithLocker(lease_lock.NewLocker(fc.Client, "default", "instance-1", 30*time.Second)))
+
+	h := hook.NewHook("002-app/sync.sh", "/hooks/002-app/sync.sh")
+	h.Config.Settings = &types.Settings{Lock: &types.LockSettings{}}
+
+	locks, err := op.acquireHookLocks(context.Background(), h, "main")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(locks).To(HaveLen(2))
+
+	for _, name := range []string{"shell-operator-queue-main", "shell-operator-hook-002-app-sync.sh"} {
+		lease, err := fc.Client.CoordinationV1().Leases("default").Get(context.Background(), name, metav1.GetOptions{})
+		g.Expect(err).ShouldNot(HaveOccurred())
+		g.Expect(*lease.Spec.HolderIdentity).To(Equal("instance-1"))
+	}
+
+	g.Expect(releaseHookLocks(locks)).To(BeFalse())
+
+	// Other queues are not locked and the hook has no lock settings.
+	locks, err = op.acquireHookLocks(context.Background(), hook.NewHook("hook.sh", "/hooks/hook.sh"), "other")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(locks).To(BeEmpty())
+}
+
+func Test_isLockedQueue(t *testing.T) {
+	g := NewWithT(t)
+
+	defaultQueues := app.LockQueues
+	defer func() { app.LockQueues = defaultQueues }()
+
+	// $LOCK_QUEUES is not split by kingpin.
+	app.LockQueues = []string{"main, sync", "other"}
+	g.Expect(isLockedQueue("main")).To(BeTrue())
+	g.Expect(isLockedQueue("sync")).To(BeTrue())
+	g.Expect(isLockedQueue("other")).To(BeTrue())
+	g.Expect(isLockedQueue("main,sync")).To(BeFalse())
+
+	app.LockQueues = []string{"*"}
+	g.Expect(isLockedQueue("any")).To(BeTrue())
+}
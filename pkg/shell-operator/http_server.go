@@ -3,9 +3,11 @@ package shell_operator
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,6 +15,10 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/schema"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
+	"github.com/flant/shell-operator/pkg/utils/compress"
+	"github.com/flant/shell-operator/pkg/utils/headers"
 )
 
 type baseHTTPServer struct {
@@ -20,10 +26,20 @@ type baseHTTPServer struct {
 
 	address string
 	port    string
+	// socket is a path of the Unix domain socket or "systemd" to listen instead of address:port.
+	socket string
+
+	startOnce sync.Once
 }
 
-// Start runs http server
+// Start runs http server. Server is started once, subsequent calls do nothing.
 func (bhs *baseHTTPServer) Start(ctx context.Context) {
+	bhs.startOnce.Do(func() {
+		bhs.start(ctx)
+	})
+}
+
+func (bhs *baseHTTPServer) start(ctx context.Context) {
 	srv := &http.Server{
 		Addr:         bhs.address + ":" + bhs.port,
 		Handler:      bhs.router,
@@ -31,12 +47,17 @@ func (bhs *baseHTTPServer) Start(ctx context.Context) {
 		WriteTimeout: 90 * time.Second,
 	}
 
+	listener, err := newListener(bhs.address, bhs.port, bhs.socket)
+	if err != nil {
+		log.Fatalf("base http server listen: %s\n", err)
+	}
+
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("base http server listen: %s\n", err)
 		}
 	}()
-	log.Infof("base http server started at %s:%s", bhs.address, bhs.port)
+	log.Infof("base http server started at %s", listener.Addr().String())
 
 	go func() {
 		<-ctx.Done()
@@ -54,6 +75,21 @@ func (bhs *baseHTTPServer) Start(ctx context.Context) {
 	}()
 }
 
+// APIPrefix is a prefix for versioned API routes.
+const APIPrefix = "/api/v1"
+
+// RegisterAPIRoute registers http.HandlerFunc under APIPrefix. The route is also
+// available on the old path without the prefix for compatibility. Responses on the
+// old path have the Deprecation header and the Link to the versioned path.
+func (bhs *baseHTTPServer) RegisterAPIRoute(method, pattern string, h http.HandlerFunc) {
+	bhs.RegisterRoute(method, APIPrefix+pattern, h)
+	bhs.RegisterRoute(method, pattern, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Deprecation", "true")
+		writer.Header().Set("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", APIPrefix, request.URL.Path))
+		h(writer, request)
+	})
+}
+
 // RegisterRoute register http.HandlerFunc
 func (bhs *baseHTTPServer) RegisterRoute(method, pattern string, h http.HandlerFunc) {
 	switch method {
@@ -73,11 +109,14 @@ func (bhs *baseHTTPServer) RegisterRoute(method, pattern string, h http.HandlerF
 
 func newBaseHTTPServer(address, port string) *baseHTTPServer {
 	router := chi.NewRouter()
+	router.Use(headers.Security)
+	router.Use(headers.Cors(headers.NewCorsConfig(app.CorsAllowedOrigins, app.CorsAllowedMethods, app.CorsAllowedHeaders)))
+	router.Use(compress.Middleware())
 
 	// inject pprof
 	router.Mount("/debug", middleware.Profiler())
 
-	router.Get("/discovery", func(writer http.ResponseWriter, request *http.Request) {
+	discoveryHandler := func(writer http.ResponseWriter, request *http.Request) {
 		buf := bytes.NewBuffer(nil)
 		walkFn := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
 			// skip pprof routes
@@ -98,13 +137,14 @@ func newBaseHTTPServer(address, port string) *baseHTTPServer {
 
 		writer.WriteHeader(http.StatusOK)
 		_, _ = writer.Write(buf.Bytes())
-	})
+	}
 
 	srv := &baseHTTPServer{
 		router:  router,
 		address: address,
 		port:    port,
 	}
+	srv.RegisterAPIRoute(http.MethodGet, "/discovery", discoveryHandler)
 
 	return srv
 }
@@ -117,12 +157,59 @@ func registerRootRoute(op *ShellOperator) {
     <h1>Shell operator</h1>
     <dl>
       <dt>Show all possible routes</dt>
-      <dd>- curl http://SHELL_OPERATOR_IP:%[1]s/discovery</dd>
+      <dd>- curl http://SHELL_OPERATOR_IP:%[1]s/api/v1/discovery</dd>
+      <br>
+      <dt>Show startup progress</dt>
+      <dd>- curl http://SHELL_OPERATOR_IP:%[1]s/api/v1/startup</dd>
+      <br>
+      <dt>Show queues, hooks last results and next schedule runs</dt>
+      <dd>- open http://SHELL_OPERATOR_IP:%[1]s/api/v1/status or curl http://SHELL_OPERATOR_IP:%[1]s/api/v1/status.json</dd>
+      <br>
+      <dt>Get JSON Schemas to validate hooks</dt>
+      <dd>- curl http://SHELL_OPERATOR_IP:%[1]s/api/v1/schemas</dd>
       <br>
       <dt>Run golang profiling</dt>
       <dd>- go tool pprof http://SHELL_OPERATOR_IP:%[1]s/debug/pprof/profile</dd>
+      <br>
+      <dt>Run golang profiling for the hook</dt>
+      <dd>- go tool pprof -tagfocus hook=HOOK_NAME http://SHELL_OPERATOR_IP:%[1]s/debug/pprof/profile</dd>
+      <br>
+      <dt>Find lock contention (see --go-mutex-profile-fraction and --go-block-profile-rate)</dt>
+      <dd>- go tool pprof http://SHELL_OPERATOR_IP:%[1]s/debug/pprof/mutex</dd>
     </dl>
   </body>
 </html>`, app.ListenPort)
 	})
 }
+
+// registerWebhookTriggerRoutes serves requests for onWebhook bindings.
+func registerWebhookTriggerRoutes(op *ShellOperator) {
+	op.APIServer.RegisterRoute(http.MethodPost, "/webhooks/*", func(writer http.ResponseWriter, request *http.Request) {
+		trigger_manager.DefaultWebhookRegistry.ServeWebhook(chi.URLParam(request, "*"), writer, request)
+	})
+}
+
+// registerSchemaRoutes publishes JSON Schemas for hook configuration, binding context and hook outputs.
+func registerSchemaRoutes(op *ShellOperator) {
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/schemas", func(writer http.ResponseWriter, request *http.Request) {
+		data, err := json.Marshal(schema.Entries())
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write(data)
+	})
+
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/schemas/{name}", func(writer http.ResponseWriter, request *http.Request) {
+		name := strings.TrimSuffix(chi.URLParam(request, "name"), ".json")
+		data, err := schema.JSON(name)
+		if err != nil {
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte(err.Error()))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/schema+json")
+		_, _ = writer.Write(data)
+	})
+}
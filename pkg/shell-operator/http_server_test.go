@@ -0,0 +1,34 @@
+package shell_operator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_baseHTTPServer_RegisterAPIRoute(t *testing.T) {
+	srv := newBaseHTTPServer("127.0.0.1", "0")
+	srv.RegisterAPIRoute(http.MethodGet, "/schemas/{name}", func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte("schema"))
+	})
+
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schemas/hook-config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "schema", rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Deprecation"))
+
+	// Old path is still served.
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schemas/hook-config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "schema", rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Equal(t, `</api/v1/schemas/hook-config>; rel="successor-version"`, rec.Header().Get("Link"))
+
+	rec = httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/discovery", nil))
+	assert.Contains(t, rec.Body.String(), "GET /api/v1/schemas/{name}\n")
+}
@@ -5,6 +5,7 @@ import (
 
 	klient "github.com/flant/kube-client/client"
 	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/kube/lease_lock"
 	"github.com/flant/shell-operator/pkg/kube/object_patch"
 	"github.com/flant/shell-operator/pkg/metric_storage"
 	utils "github.com/flant/shell-operator/pkg/utils/labels"
@@ -13,6 +14,7 @@ import (
 var (
 	defaultMainKubeClientMetricLabels          = map[string]string{"component": "main"}
 	defaultObjectPatcherKubeClientMetricLabels = map[string]string{"component": "object_patcher"}
+	defaultLockerKubeClientMetricLabels        = map[string]string{"component": "lease_lock"}
 )
 
 // defaultMainKubeClient creates a Kubernetes client for hooks. No timeout specified, because
@@ -58,3 +60,29 @@ func initDefaultObjectPatcher(metricStorage *metric_storage.MetricStorage) (*obj
 	}
 	return object_patch.NewObjectPatcher(patcherKubeClient), nil
 }
+
+// initDefaultLocker creates a Locker for distributed locks. Leases are stored in the cluster
+// from app.LockKubeConfig or in the main cluster if it is not set.
+func initDefaultLocker(mainClient *klient.Client, metricStorage *metric_storage.MetricStorage) (*lease_lock.Locker, error) {
+	lockClient := mainClient
+	if app.LockKubeConfig != "" {
+		lockClient = klient.New()
+		lockClient.WithConfigPath(app.LockKubeConfig)
+		lockClient.WithMetricStorage(metricStorage)
+		lockClient.WithMetricLabels(defaultLockerKubeClientMetricLabels)
+		err := lockClient.Init()
+		if err != nil {
+			return nil, fmt.Errorf("initialize Kubernetes client for Leases: %s\n", err)
+		}
+	}
+
+	namespace := app.LockNamespace
+	if namespace == "" {
+		namespace = app.Namespace
+	}
+	identity := app.LockIdentity
+	if identity == "" {
+		identity = lease_lock.DefaultIdentity()
+	}
+	return lease_lock.NewLocker(lockClient, namespace, identity, app.LockLeaseDuration), nil
+}
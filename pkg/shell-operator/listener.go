@@ -0,0 +1,66 @@
+package shell_operator
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SystemdSocket is a value of --listen-socket to use a socket passed by systemd.
+const SystemdSocket = "systemd"
+
+// systemdListenFdsStart is the first file descriptor passed by systemd.
+const systemdListenFdsStart = 3
+
+// newListener returns a listener for the base http server. socket is a path
+// of the Unix domain socket or "systemd". TCP address is used if socket is empty.
+func newListener(address, port, socket string) (net.Listener, error) {
+	switch {
+	case socket == "":
+		return net.Listen("tcp", net.JoinHostPort(address, port))
+	case socket == SystemdSocket:
+		return systemdListener()
+	default:
+		return unixListener(strings.TrimPrefix(socket, "unix://"))
+	}
+}
+
+// unixListener removes a stale socket file left after the previous run and listens on the path.
+func unixListener(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen on '%s': file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket '%s': %v", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// systemdListener returns the first socket passed with socket activation.
+// See sd_listen_fds(3) for LISTEN_PID and LISTEN_FDS.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, fmt.Errorf("no sockets passed by systemd: LISTEN_PID is '%s'", os.Getenv("LISTEN_PID"))
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, fmt.Errorf("no sockets passed by systemd: LISTEN_FDS is '%s'", os.Getenv("LISTEN_FDS"))
+	}
+	// Do not pass sockets to hooks.
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(systemdListenFdsStart), "LISTEN_FD_3")
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("use socket passed by systemd: %v", err)
+	}
+	return l, nil
+}
@@ -0,0 +1,58 @@
+package shell_operator
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newListener_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shell-operator.sock")
+
+	// Stale socket from the previous run should be replaced.
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	l, err := newListener("", "", "unix://"+path)
+	require.NoError(t, err)
+	defer l.Close()
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", path) },
+	}}
+	resp, err := client.Get("http://unix/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+}
+
+func Test_newListener_NotSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o644))
+
+	_, err := newListener("", "", path)
+	assert.Error(t, err)
+}
+
+func Test_newListener_NoSystemdSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	_, err := newListener("", "", SystemdSocket)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package shell_operator
 
 import (
 	"context"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -10,12 +11,14 @@ import (
 	"github.com/flant/shell-operator/pkg/schedule_manager"
 	"github.com/flant/shell-operator/pkg/task"
 	"github.com/flant/shell-operator/pkg/task/queue"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 )
 
 type managerEventsHandlerConfig struct {
 	tqs  *queue.TaskQueueSet
 	mgr  kube_events_manager.KubeEventsManager
 	smgr schedule_manager.ScheduleManager
+	tmgr trigger_manager.TriggerManager
 }
 
 type ManagerEventsHandler struct {
@@ -24,9 +27,14 @@ type ManagerEventsHandler struct {
 
 	kubeEventsManager kube_events_manager.KubeEventsManager
 	scheduleManager   schedule_manager.ScheduleManager
+	triggerManager    trigger_manager.TriggerManager
 
 	kubeEventCb func(kubeEvent KubeEvent) []task.Task
 	scheduleCb  func(crontab string) []task.Task
+	triggerCb   func(event trigger_manager.Event) []task.Task
+
+	// scheduleCoalesceWindow is a time to wait for other crontabs fired on the same tick.
+	scheduleCoalesceWindow time.Duration
 
 	taskQueues *queue.TaskQueueSet
 }
@@ -41,8 +49,11 @@ func newManagerEventsHandler(ctx context.Context, cfg *managerEventsHandlerConfi
 		ctx:               cctx,
 		cancel:            cancel,
 		scheduleManager:   cfg.smgr,
+		triggerManager:    cfg.tmgr,
 		kubeEventsManager: cfg.mgr,
 		taskQueues:        cfg.tqs,
+
+		scheduleCoalesceWindow: DefaultScheduleCoalesceWindow,
 	}
 }
 
@@ -58,16 +69,29 @@ func (m *ManagerEventsHandler) WithScheduleEventHandler(fn func(crontab string)
 	m.scheduleCb = fn
 }
 
+// WithTriggerEventHandler sets a function to handle events from external sources.
+func (m *ManagerEventsHandler) WithTriggerEventHandler(fn func(event trigger_manager.Event) []task.Task) {
+	m.triggerCb = fn
+}
+
 // Start runs events handler. This function is used in addon-operator
 func (m *ManagerEventsHandler) Start() {
+	// Receiving from nil channel blocks forever, so the case is disabled without trigger manager.
+	var triggerCh chan trigger_manager.Event
+	if m.triggerManager != nil {
+		triggerCh = m.triggerManager.Ch()
+	}
+
 	go func() {
 		for {
 			var tailTasks []task.Task
+			// onQueued is called after tasks are added to queues.
+			var onQueued func()
 			logEntry := log.WithField("operator.component", "handleEvents")
 			select {
 			case crontab := <-m.scheduleManager.Ch():
 				if m.scheduleCb != nil {
-					tailTasks = m.scheduleCb(crontab)
+					tailTasks = m.collectScheduleTasks(crontab)
 				}
 
 			case kubeEvent := <-m.kubeEventsManager.Ch():
@@ -75,6 +99,12 @@ func (m *ManagerEventsHandler) Start() {
 					tailTasks = m.kubeEventCb(kubeEvent)
 				}
 
+			case event := <-triggerCh:
+				if m.triggerCb != nil {
+					tailTasks = m.triggerCb(event)
+				}
+				onQueued = event.Done
+
 			case <-m.ctx.Done():
 				logEntry.Infof("Stop")
 				return
@@ -90,6 +120,32 @@ func (m *ManagerEventsHandler) Start() {
 					}
 				}
 			})
+			if onQueued != nil {
+				onQueued()
+			}
 		}
 	}()
 }
+
+// collectScheduleTasks returns tasks for the crontab. If there are tasks for grouped
+// schedule bindings, crontabs fired during the scheduleCoalesceWindow are collected
+// to run the hook once for the group. Other crontabs do not wait for the window.
+func (m *ManagerEventsHandler) collectScheduleTasks(crontab string) []task.Task {
+	tasks := m.scheduleCb(crontab)
+	if m.scheduleCoalesceWindow <= 0 || !hasGroupedScheduleTasks(tasks) {
+		return tasks
+	}
+
+	timer := time.NewTimer(m.scheduleCoalesceWindow)
+	defer timer.Stop()
+	for {
+		select {
+		case next := <-m.scheduleManager.Ch():
+			tasks = append(tasks, m.scheduleCb(next)...)
+		case <-timer.C:
+			return coalesceScheduleTasks(tasks)
+		case <-m.ctx.Done():
+			return coalesceScheduleTasks(tasks)
+		}
+	}
+}
@@ -2,13 +2,20 @@ package shell_operator
 
 import (
 	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/flant/shell-operator/pkg/app"
 	"github.com/flant/shell-operator/pkg/metric_storage"
 )
 
-func (op *ShellOperator) setupHookMetricStorage() {
-	metricStorage := metric_storage.NewMetricStorage(op.ctx, app.PrometheusMetricsPrefix, true)
+func (op *ShellOperator) setupHookMetricStorage(labelRules *metric_storage.LabelRules) {
+	metricStorage := op.HookMetricStorage
+	if metricStorage == nil {
+		metricStorage = metric_storage.NewMetricStorage(op.ctx, app.PrometheusMetricsPrefix, true)
+		metricStorage.SetLabelRules(labelRules)
+	}
 
 	op.APIServer.RegisterRoute(http.MethodGet, "/metrics/hooks", metricStorage.Handler().ServeHTTP)
 	// create new metric storage for hooks
@@ -71,10 +78,90 @@ func registerHookMetrics(metricStorage *metric_storage.MetricStorage) {
 	)
 	// Max RSS in bytes.
 	metricStorage.RegisterGauge("{PREFIX}hook_run_max_rss_bytes", labels)
+	// Total CPU time and filesystem I/O, registered only if resource accounting is enabled.
+	if app.HookResourceMetrics {
+		metricStorage.RegisterCounter("{PREFIX}hook_run_cpu_seconds_total", labels)
+		metricStorage.RegisterCounter("{PREFIX}hook_run_io_read_bytes_total", labels)
+		metricStorage.RegisterCounter("{PREFIX}hook_run_io_write_bytes_total", labels)
+	}
+
+	// Hook runs with stdout or stderr truncated by the output limit.
+	metricStorage.RegisterCounter("{PREFIX}hook_run_output_truncated_total", map[string]string{
+		"hook":    "",
+		"binding": "",
+		"queue":   "",
+		"output":  "",
+	})
+	// Malformed metrics, patch or webhook response files from successful hook runs.
+	metricStorage.RegisterCounter("{PREFIX}hook_run_output_parse_errors_total", map[string]string{
+		"hook":    "",
+		"binding": "",
+		"queue":   "",
+		"output":  "",
+	})
 
 	metricStorage.RegisterCounter("{PREFIX}hook_run_errors_total", labels)
 	metricStorage.RegisterCounter("{PREFIX}hook_run_allowed_errors_total", labels)
 	metricStorage.RegisterCounter("{PREFIX}hook_run_success_total", labels)
+	metricStorage.RegisterCounter("{PREFIX}hook_run_skipped_total", labels)
 	// hook_run task waiting time
 	metricStorage.RegisterCounter("{PREFIX}task_wait_in_queue_seconds_total", labels)
 }
+
+// Phases of HookRun tasks measured by the operator. Other phases are measured by the hook, see hook.RunPhase.
+const (
+	TaskPhaseQueueWait  = "queue_wait"
+	TaskPhasePatchApply = "patch_apply"
+)
+
+// observeTaskPhase reports the duration of the phase of the HookRun task.
+func (op *ShellOperator) observeTaskPhase(metricLabels map[string]string, phase string, d time.Duration) {
+	labels := map[string]string{"phase": phase}
+	for k, v := range metricLabels {
+		labels[k] = v
+	}
+	op.MetricStorage.HistogramObserve("{PREFIX}task_phase_duration_seconds", d.Seconds(), labels, nil)
+}
+
+// restoreHookCounters restores counters of hooks saved before the restart,
+// so rate() for counters of rarely executed hooks is not broken by deploys.
+func (op *ShellOperator) restoreHookCounters(path string) {
+	op.hookCountersFile = path
+	if path == "" {
+		return
+	}
+	n, err := op.HookMetricStorage.LoadCounterValues(path)
+	if err != nil {
+		log.Warnf("Counters of hooks are not restored: %v", err)
+		return
+	}
+	log.Infof("Restored %d counters of hooks from '%s'", n, path)
+}
+
+// runHookCountersSaver saves counters of hooks periodically.
+func (op *ShellOperator) runHookCountersSaver(interval time.Duration) {
+	if op.hookCountersFile == "" || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-op.ctx.Done():
+				return
+			case <-ticker.C:
+				op.saveHookCounters()
+			}
+		}
+	}()
+}
+
+func (op *ShellOperator) saveHookCounters() {
+	if op.hookCountersFile == "" {
+		return
+	}
+	if err := op.HookMetricStorage.SaveCounterValues(op.hookCountersFile); err != nil {
+		log.Errorf("Save counters of hooks: %v", err)
+	}
+}
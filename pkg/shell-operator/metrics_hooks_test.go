@@ -0,0 +1,61 @@
+package shell_operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+func Test_HookRun_Phases(t *testing.T) {
+	op := newRunHookOnceOperator(t)
+	h := op.HookManager.GetHook("hook.sh")
+
+	res, err := h.Run(types.OnStartup, onStartupContext("onStartup"), map[string]string{"hook": "hook.sh"})
+	require.NoError(t, err)
+	for _, phase := range []hook.RunPhase{hook.RunPhaseContextBuild, hook.RunPhaseExec, hook.RunPhaseOutputParse} {
+		assert.Contains(t, res.Phases, phase)
+	}
+
+	// Output is not parsed if the hook is failed.
+	res, err = h.Run(types.OnStartup, onStartupContext("fail"), map[string]string{"hook": "hook.sh"})
+	require.Error(t, err)
+	assert.Contains(t, res.Phases, hook.RunPhaseExec)
+	assert.NotContains(t, res.Phases, hook.RunPhaseOutputParse)
+}
+
+func Test_ObserveTaskPhase(t *testing.T) {
+	op := NewShellOperator(context.Background())
+	op.MetricStorage = metric_storage.NewMetricStorage(context.Background(), "test_", true)
+	registerTaskQueueMetrics(op.MetricStorage)
+
+	metricLabels := map[string]string{"hook": "hook.sh", "binding": "pods", "queue": "main"}
+	op.observeTaskPhase(metricLabels, TaskPhaseQueueWait, 3*time.Millisecond)
+	op.observeTaskPhase(metricLabels, string(hook.RunPhaseExec), 2*time.Second)
+	op.observeTaskPhase(metricLabels, string(hook.RunPhaseExec), time.Second)
+
+	families, err := op.MetricStorage.Gatherer.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "test_task_phase_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "phase" {
+					counts[label.GetValue()] = m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]uint64{"queue_wait": 1, "exec": 2}, counts)
+	// Labels of the task are not changed.
+	assert.NotContains(t, metricLabels, "phase")
+}
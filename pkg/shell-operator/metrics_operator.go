@@ -5,15 +5,24 @@ import (
 
 	"github.com/flant/shell-operator/pkg/app"
 	"github.com/flant/shell-operator/pkg/metric_storage"
+	utils "github.com/flant/shell-operator/pkg/utils/labels"
 )
 
 // setupMetricStorage creates and initializes metrics storage for built-in operator metrics
-func (op *ShellOperator) setupMetricStorage(kubeEventsManagerLabels map[string]string) {
-	metricStorage := metric_storage.NewMetricStorage(op.ctx, app.PrometheusMetricsPrefix, false)
+func (op *ShellOperator) setupMetricStorage(kubeEventsManagerLabels map[string]string, labelRules *metric_storage.LabelRules) {
+	metricStorage := op.MetricStorage
+	if metricStorage == nil {
+		metricStorage = metric_storage.NewMetricStorage(op.ctx, app.PrometheusMetricsPrefix, false)
+		metricStorage.SetLabelRules(labelRules)
+	}
 
 	registerCommonMetrics(metricStorage)
 	registerTaskQueueMetrics(metricStorage)
 	registerKubeEventsManagerMetrics(metricStorage, kubeEventsManagerLabels)
+	registerAdmissionMetrics(metricStorage)
+	registerSelfTestMetrics(metricStorage)
+	registerShadowHookMetrics(metricStorage)
+	registerHookCheckMetrics(metricStorage)
 
 	op.APIServer.RegisterRoute(http.MethodGet, "/metrics", metricStorage.Handler().ServeHTTP)
 	// create new metric storage for hooks
@@ -46,6 +55,26 @@ func registerTaskQueueMetrics(metricStorage *metric_storage.MetricStorage) {
 	)
 
 	metricStorage.RegisterGauge("{PREFIX}tasks_queue_length", map[string]string{"queue": ""})
+
+	// Durations of phases of HookRun tasks: queue wait, context build, exec, output parse and patch apply.
+	metricStorage.RegisterHistogram(
+		"{PREFIX}task_phase_duration_seconds",
+		map[string]string{
+			"hook":    "",
+			"binding": "",
+			"queue":   "",
+			"phase":   "",
+		},
+		[]float64{
+			0.0,
+			0.001, 0.002, 0.005, // 1,2,5 milliseconds
+			0.01, 0.02, 0.05, // 10,20,50 milliseconds
+			0.1, 0.2, 0.5, // 100,200,500 milliseconds
+			1, 2, 5, // 1,2,5 seconds
+			10, 20, 50, // 10,20,50 seconds
+			100, 200, 500, // 100,200,500 seconds
+		},
+	)
 }
 
 // registerKubeEventsManagerMetrics registers metrics for kube_event_manager
@@ -53,6 +82,12 @@ func registerTaskQueueMetrics(metricStorage *metric_storage.MetricStorage) {
 func registerKubeEventsManagerMetrics(metricStorage *metric_storage.MetricStorage, labels map[string]string) {
 	// Count of objects in snapshot for one kubernets bindings.
 	metricStorage.RegisterGauge("{PREFIX}kube_snapshot_objects", labels)
+	// 1.0 if the monitor of the binding is started, 0.0 if it waits for RBAC permissions.
+	metricStorage.RegisterGauge("{PREFIX}kube_binding_ready", labels)
+	// Evictions of full objects from snapshots by the memory budget.
+	metricStorage.RegisterCounter("{PREFIX}kube_snapshot_evictions_total", labels)
+	// Event objects dropped by deduplication and rate limiting of kubernetesEvents bindings.
+	metricStorage.RegisterCounter("{PREFIX}kube_events_dropped_total", utils.MergeLabels(labels, map[string]string{"reason": ""}))
 	// Duration of jqFilter applying.
 	metricStorage.RegisterHistogram(
 		"{PREFIX}kube_jq_filter_duration_seconds",
@@ -80,4 +115,57 @@ func registerKubeEventsManagerMetrics(metricStorage *metric_storage.MetricStorag
 
 	// Count of watch errors.
 	metricStorage.RegisterCounter("{PREFIX}kubernetes_client_watch_errors_total", map[string]string{"error_type": ""})
+	metricStorage.RegisterCounter("{PREFIX}kubernetes_client_relist_storms_total", map[string]string{})
+
+	// Count of warnings from the API server.
+	metricStorage.RegisterCounter("{PREFIX}kube_api_warnings_total", map[string]string{"warning": ""})
+
+	// Count of mutating operations skipped in the read-only mode.
+	metricStorage.RegisterCounter("{PREFIX}read_only_skipped_operations_total", map[string]string{"component": "", "operation": ""})
+}
+
+// registerSelfTestMetrics registers metrics for runs of /selftest.
+func registerSelfTestMetrics(metricStorage *metric_storage.MetricStorage) {
+	metricStorage.RegisterCounter("{PREFIX}selftest_runs_total", map[string]string{"status": ""})
+	metricStorage.RegisterGauge("{PREFIX}selftest_duration_seconds", map[string]string{})
+}
+
+// registerShadowHookMetrics registers metrics for comparison of shadow hooks with primary hooks.
+func registerShadowHookMetrics(metricStorage *metric_storage.MetricStorage) {
+	metricStorage.RegisterCounter("{PREFIX}shadow_hook_runs_total", map[string]string{"hook": "", "result": ""})
+}
+
+// registerHookCheckMetrics registers metrics for self-checks of hooks.
+func registerHookCheckMetrics(metricStorage *metric_storage.MetricStorage) {
+	metricStorage.RegisterGauge("{PREFIX}hook_degraded", map[string]string{"hook": ""})
+	metricStorage.RegisterCounter("{PREFIX}hook_checks_total", map[string]string{"hook": "", "result": ""})
+}
+
+// registerAdmissionMetrics registers metrics for requests to validating and mutating hooks.
+func registerAdmissionMetrics(metricStorage *metric_storage.MetricStorage) {
+	labels := map[string]string{
+		"hook":      "",
+		"binding":   "",
+		"type":      "",
+		"resource":  "",
+		"operation": "",
+	}
+	metricStorage.RegisterHistogram(
+		"{PREFIX}admission_request_duration_seconds",
+		labels,
+		[]float64{
+			0.0,
+			0.005, 0.01, 0.02, 0.05, // 5,10,20,50 milliseconds
+			0.1, 0.2, 0.5, // 100,200,500 milliseconds
+			1, 2, 5, // 1,2,5 seconds
+			10, 30, // 10,30 seconds
+		},
+	)
+	metricStorage.RegisterCounter("{PREFIX}admission_request_timeouts_total", labels)
+
+	resultLabels := map[string]string{"result": ""}
+	for k := range labels {
+		resultLabels[k] = ""
+	}
+	metricStorage.RegisterCounter("{PREFIX}admission_requests_total", resultLabels)
 }
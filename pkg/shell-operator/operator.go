@@ -3,6 +3,8 @@ package shell_operator
 import (
 	"context"
 	"fmt"
+	"runtime/pprof"
+	"sync"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
@@ -10,11 +12,15 @@ import (
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/alert"
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/executor"
 	"github.com/flant/shell-operator/pkg/hook"
 	"github.com/flant/shell-operator/pkg/hook/binding_context"
 	"github.com/flant/shell-operator/pkg/hook/controller"
 	"github.com/flant/shell-operator/pkg/hook/task_metadata"
 	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/kube/lease_lock"
 	"github.com/flant/shell-operator/pkg/kube/object_patch"
 	"github.com/flant/shell-operator/pkg/kube_events_manager"
 	kemTypes "github.com/flant/shell-operator/pkg/kube_events_manager/types"
@@ -22,6 +28,8 @@ import (
 	"github.com/flant/shell-operator/pkg/schedule_manager"
 	"github.com/flant/shell-operator/pkg/task"
 	"github.com/flant/shell-operator/pkg/task/queue"
+	"github.com/flant/shell-operator/pkg/tracing"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
 	utils "github.com/flant/shell-operator/pkg/utils/labels"
 	"github.com/flant/shell-operator/pkg/utils/measure"
 	"github.com/flant/shell-operator/pkg/webhook/admission"
@@ -43,9 +51,13 @@ type ShellOperator struct {
 	HookMetricStorage *metric_storage.MetricStorage
 	KubeClient        *klient.Client
 	ObjectPatcher     *object_patch.ObjectPatcher
+	// Locker acquires Leases to run hooks only in one operator instance at a time.
+	Locker *lease_lock.Locker
 
 	ScheduleManager   schedule_manager.ScheduleManager
 	KubeEventsManager kube_events_manager.KubeEventsManager
+	// TriggerManager runs external sources of trigger bindings, e.g. message queue subscriptions.
+	TriggerManager trigger_manager.TriggerManager
 
 	TaskQueues *queue.TaskQueueSet
 
@@ -55,16 +67,68 @@ type ShellOperator struct {
 
 	AdmissionWebhookManager  *admission.WebhookManager
 	ConversionWebhookManager *conversion.WebhookManager
+
+	// AlertNotifier sends failure events to the alerting webhook. It is nil if notifications are disabled.
+	AlertNotifier *alert.Notifier
+	// KubeEventRecorder records failure events as Kubernetes Events. It is nil if Events are disabled.
+	KubeEventRecorder *alert.KubeEventRecorder
+
+	// Startup reports progress of startup phases. It is nil for derivatives that do not track startup.
+	Startup *StartupProgress
+
+	// faults injects failures and delays into hook runs in the developer mode.
+	faults *faultInjector
+
+	// impersonatedPatchers are ObjectPatchers for hooks with impersonation settings.
+	impersonatedPatchers   map[string]*object_patch.ObjectPatcher
+	impersonatedPatchersMu sync.Mutex
+
+	// taskHandlers are handlers for custom task types registered by embedders.
+	taskHandlers     map[task.TaskType]TaskHandler
+	taskHandlersLock sync.RWMutex
+
+	// selfTest runs a built-in hook for /selftest. It is nil if self-test is not registered.
+	selfTest *selfTest
+
+	// shadowHooks runs hooks from the shadow directory. It is nil if shadow hooks are disabled.
+	shadowHooks *shadowHooks
+
+	// hookCountersFile is a file to save counters of hooks. Counters are not saved if it is empty.
+	hookCountersFile string
+
+	// fatalErrors receives unrecoverable errors, e.g. panics in task handlers.
+	fatalErrors chan error
 }
 
-func NewShellOperator(ctx context.Context) *ShellOperator {
+// NewShellOperator returns an operator with dependencies from options.
+// Other dependencies are created by Init or AssembleCommonOperator.
+func NewShellOperator(ctx context.Context, opts ...Option) *ShellOperator {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 	cctx, cancel := context.WithCancel(ctx)
-	return &ShellOperator{
-		ctx:    cctx,
-		cancel: cancel,
+	op := &ShellOperator{
+		ctx:         cctx,
+		cancel:      cancel,
+		fatalErrors: make(chan error, 1),
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+	return op
+}
+
+// FatalErrors returns a channel with unrecoverable errors of the operator.
+// The program should stop when an error is received.
+func (op *ShellOperator) FatalErrors() <-chan error {
+	return op.fatalErrors
+}
+
+// reportFatal sends the first unrecoverable error, later errors are only logged.
+func (op *ShellOperator) reportFatal(err error) {
+	select {
+	case op.fatalErrors <- err:
+	default:
 	}
 }
 
@@ -73,6 +137,8 @@ func (op *ShellOperator) Start() {
 	log.Info("start shell-operator")
 
 	op.APIServer.Start(op.ctx)
+	op.AlertNotifier.Start(op.ctx)
+	op.KubeEventRecorder.Start(op.ctx)
 
 	// Create 'main' queue and add onStartup tasks and enable bindings tasks.
 	op.bootstrapMainQueue(op.TaskQueues)
@@ -82,6 +148,8 @@ func (op *ShellOperator) Start() {
 
 	// Start emit "live" metrics
 	op.runMetrics()
+	op.runHookCountersSaver(app.HookMetricsCountersSaveInterval)
+	op.runHookChecks()
 
 	// Managers are generating events. This go-routine handles all events and converts them into queued tasks.
 	// Start it before start all informers to catch all kubernetes events (#42)
@@ -89,6 +157,51 @@ func (op *ShellOperator) Start() {
 
 	// Unlike KubeEventsManager, ScheduleManager has one go-routine.
 	op.ScheduleManager.Start()
+
+	if op.TriggerManager != nil {
+		op.TriggerManager.Start()
+	}
+}
+
+// notifyHookFailed sends an alert on the first failure of the task and when
+// the number of failures reaches the threshold and the queue is considered stalled.
+// Kubernetes Event about the hook failure is recorded after KubeEventsHookFailures attempts.
+// Webhook bindings are reported by webhook handlers.
+func (op *ShellOperator) notifyHookFailed(t task.Task, hookMeta task_metadata.HookMetadata, err error) {
+	switch hookMeta.BindingType {
+	case types.KubernetesValidating, types.KubernetesMutating, types.KubernetesConversion:
+		return
+	}
+
+	failureCount := t.GetFailureCount() + 1
+	event := alert.Event{
+		Hook:         hookMeta.HookName,
+		Binding:      hookMeta.Binding,
+		Queue:        t.GetQueueName(),
+		FailureCount: failureCount,
+		Message:      err.Error(),
+	}
+	if failureCount == 1 {
+		event.Type = alert.HookFailed
+		op.AlertNotifier.Notify(event)
+	}
+	if failureCount == app.KubeEventsHookFailures {
+		event.Type = alert.HookFailed
+		event.Message = fmt.Sprintf("Task is failed %d times: %v", failureCount, err)
+		op.KubeEventRecorder.Record(event)
+	}
+	if failureCount == app.AlertWebhookQueueStallFailures {
+		event.Type = alert.QueueStalled
+		event.Message = fmt.Sprintf("Task is failed %d times, queue is stalled: %v", failureCount, err)
+		op.AlertNotifier.Notify(event)
+		op.KubeEventRecorder.Record(event)
+	}
+}
+
+// notifyWebhookError sends an alert and records Kubernetes Event about the failed webhook request.
+func (op *ShellOperator) notifyWebhookError(event alert.Event) {
+	op.AlertNotifier.Notify(event)
+	op.KubeEventRecorder.Record(event)
 }
 
 func (op *ShellOperator) Stop() {
@@ -109,6 +222,16 @@ func (op *ShellOperator) initHookManager() (err error) {
 		return err
 	}
 
+	// Create impersonating ObjectPatchers before hooks are executed. Patchers of previously
+	// loaded hooks are dropped, so changed impersonation settings are applied.
+	op.resetImpersonatedPatchers()
+	for _, hookName := range op.HookManager.GetHookNames() {
+		if _, err = op.objectPatcherFor(op.HookManager.GetHook(hookName)); err != nil {
+			log.Errorf("MAIN Fatal: initialize hook '%s': %s\n", hookName, err)
+			return err
+		}
+	}
+
 	// Define event handlers for schedule event and kubernetes event.
 	op.ManagerEventsHandler.WithKubeEventHandler(func(kubeEvent kemTypes.KubeEvent) []task.Task {
 		logLabels := map[string]string{
@@ -120,10 +243,57 @@ func (op *ShellOperator) initHookManager() (err error) {
 
 		var tasks []task.Task
 		op.HookManager.HandleKubeEvent(kubeEvent, func(hook *hook.Hook, info controller.BindingExecutionInfo) {
+			if op.skipQuarantinedHook(hook, info, logEntry) {
+				return
+			}
+			// Composite triggers are created from kubernetes events too.
+			bindingType := types.OnKubernetesEvent
+			if len(info.BindingContext) > 0 && info.BindingContext[0].Metadata.BindingType == types.Composite {
+				bindingType = types.Composite
+			}
+			hookMeta := task_metadata.HookMetadata{
+				HookName:       hook.Name,
+				BindingType:    bindingType,
+				BindingContext: info.BindingContext,
+				AllowFailure:   info.AllowFailure,
+				Binding:        info.Binding,
+				Group:          info.Group,
+			}
+			// Synchronization is emitted by KubeEventsManager for the monitor
+			// started after RBAC is fixed. Events are unlocked after this task.
+			if kubeEvent.Type == kemTypes.TypeSynchronization && info.KubernetesBinding.Monitor != nil {
+				hookMeta.MonitorIDs = []string{info.KubernetesBinding.Monitor.Metadata.MonitorId}
+				hookMeta.ExecuteOnSynchronization = info.KubernetesBinding.ExecuteHookOnSynchronization
+			}
+			newTask := task.NewTask(task_metadata.HookRun).
+				WithMetadata(hookMeta).
+				WithLogLabels(logLabels).
+				WithQueueName(info.QueueName)
+			tasks = append(tasks, newTask.WithQueuedAt(time.Now()))
+
+			logEntry.WithField("queue", info.QueueName).
+				Infof("queue task %s", newTask.GetDescription())
+		})
+
+		return tasks
+	})
+	op.ManagerEventsHandler.WithScheduleEventHandler(func(crontab string) []task.Task {
+		logLabels := map[string]string{
+			"event.id": uuid.Must(uuid.NewV4()).String(),
+			"binding":  string(types.Schedule),
+		}
+		logEntry := log.WithFields(utils.LabelsToLogFields(logLabels))
+		logEntry.Debugf("Create tasks for 'schedule' event '%s'", crontab)
+
+		var tasks []task.Task
+		op.HookManager.HandleScheduleEvent(crontab, func(hook *hook.Hook, info controller.BindingExecutionInfo) {
+			if op.skipQuarantinedHook(hook, info, logEntry) {
+				return
+			}
 			newTask := task.NewTask(task_metadata.HookRun).
 				WithMetadata(task_metadata.HookMetadata{
 					HookName:       hook.Name,
-					BindingType:    types.OnKubernetesEvent,
+					BindingType:    types.Schedule,
 					BindingContext: info.BindingContext,
 					AllowFailure:   info.AllowFailure,
 					Binding:        info.Binding,
@@ -139,26 +309,30 @@ func (op *ShellOperator) initHookManager() (err error) {
 
 		return tasks
 	})
-	op.ManagerEventsHandler.WithScheduleEventHandler(func(crontab string) []task.Task {
+	op.ManagerEventsHandler.WithTriggerEventHandler(func(event trigger_manager.Event) []task.Task {
 		logLabels := map[string]string{
 			"event.id": uuid.Must(uuid.NewV4()).String(),
-			"binding":  string(types.Schedule),
 		}
 		logEntry := log.WithFields(utils.LabelsToLogFields(logLabels))
-		logEntry.Debugf("Create tasks for 'schedule' event '%s'", crontab)
+		logEntry.Debugf("Create tasks for event from trigger source '%s'", event.SourceId)
 
 		var tasks []task.Task
-		op.HookManager.HandleScheduleEvent(crontab, func(hook *hook.Hook, info controller.BindingExecutionInfo) {
+		op.HookManager.HandleTriggerEvent(event, func(hook *hook.Hook, info controller.BindingExecutionInfo) {
+			if op.skipQuarantinedHook(hook, info, logEntry) {
+				return
+			}
+			bindingType := info.BindingContext[0].Metadata.BindingType
+			taskLogLabels := utils.MergeLabels(logLabels, map[string]string{"binding": string(bindingType)})
 			newTask := task.NewTask(task_metadata.HookRun).
 				WithMetadata(task_metadata.HookMetadata{
 					HookName:       hook.Name,
-					BindingType:    types.Schedule,
+					BindingType:    bindingType,
 					BindingContext: info.BindingContext,
 					AllowFailure:   info.AllowFailure,
 					Binding:        info.Binding,
 					Group:          info.Group,
 				}).
-				WithLogLabels(logLabels).
+				WithLogLabels(taskLogLabels).
 				WithQueueName(info.QueueName)
 			tasks = append(tasks, newTask.WithQueuedAt(time.Now()))
 
@@ -172,6 +346,73 @@ func (op *ShellOperator) initHookManager() (err error) {
 	return nil
 }
 
+// skipQuarantinedHook returns true if the hook is quarantined and its task should not be queued.
+// If the quarantine is over, monitors with dropped tasks are resynchronized.
+func (op *ShellOperator) skipQuarantinedHook(h *hook.Hook, info controller.BindingExecutionInfo, logEntry *log.Entry) bool {
+	if !h.IsQuarantined() {
+		op.resyncAfterQuarantine(h)
+		return false
+	}
+	logEntry.WithField("hook", h.Name).WithField("queue", info.QueueName).
+		Debugf("Hook is quarantined until %s, skip task for binding '%s'", h.QuarantinedUntil().Format(time.RFC3339), info.Binding)
+	op.MetricStorage.CounterAdd("{PREFIX}hook_quarantine_skipped_tasks_total", 1.0, map[string]string{
+		"hook":    h.Name,
+		"binding": info.Binding,
+		"queue":   info.QueueName,
+	})
+	if info.KubernetesBinding.Monitor != nil {
+		h.AddQuarantineResync(info.KubernetesBinding.Monitor.Metadata.MonitorId)
+	}
+	return true
+}
+
+// quarantineResyncMonitors returns monitors of the kubernetes binding to resynchronize after the quarantine.
+func quarantineResyncMonitors(h *hook.Hook, hookMeta task_metadata.HookMetadata) []string {
+	if len(hookMeta.MonitorIDs) > 0 {
+		return hookMeta.MonitorIDs
+	}
+	if hookMeta.BindingType != types.OnKubernetesEvent {
+		return nil
+	}
+	var monitorIDs []string
+	for _, cfg := range h.GetConfig().OnKubernetesEvents {
+		if cfg.BindingName == hookMeta.Binding && cfg.Monitor != nil {
+			monitorIDs = append(monitorIDs, cfg.Monitor.Metadata.MonitorId)
+		}
+	}
+	return monitorIDs
+}
+
+// scheduleQuarantineResync resynchronizes the hook at the end of the quarantine
+// if no task for the hook comes earlier.
+func (op *ShellOperator) scheduleQuarantineResync(h *hook.Hook) {
+	time.AfterFunc(time.Until(h.QuarantinedUntil()), func() {
+		op.resyncAfterQuarantine(h)
+	})
+}
+
+// resyncAfterQuarantine emits Synchronization for monitors with tasks dropped during the quarantine.
+// It does nothing while the hook is quarantined or if there are no dropped tasks.
+// Events are unlocked again when the new Synchronization task is done.
+func (op *ShellOperator) resyncAfterQuarantine(h *hook.Hook) {
+	monitorIDs := h.TakeQuarantineResync()
+	if len(monitorIDs) == 0 || op.KubeEventsManager == nil {
+		return
+	}
+	for _, monitorID := range monitorIDs {
+		log.WithField("hook", h.Name).Infof("Quarantine is over, resynchronize monitor '%s'", monitorID)
+	}
+	go func() {
+		for _, monitorID := range monitorIDs {
+			select {
+			case op.KubeEventsManager.Ch() <- kemTypes.KubeEvent{MonitorId: monitorID, Type: kemTypes.TypeSynchronization}:
+			case <-op.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
 // initValidatingWebhookManager adds kubernetesValidating hooks
 // to a WebhookManager and set a validating event handler.
 func (op *ShellOperator) initValidatingWebhookManager() (err error) {
@@ -188,7 +429,12 @@ func (op *ShellOperator) initValidatingWebhookManager() (err error) {
 	hookNames = append(hookNames, hookNamesM...)
 
 	if len(hookNames) == 0 {
-		return
+		// Delete webhook configurations left by removed hooks.
+		err = op.AdmissionWebhookManager.Prune()
+		if err != nil {
+			log.Errorf("Prune webhook configurations: %v", err)
+		}
+		return nil
 	}
 
 	err = op.AdmissionWebhookManager.Init()
@@ -233,12 +479,25 @@ func (op *ShellOperator) initValidatingWebhookManager() (err error) {
 			return nil, fmt.Errorf("no hook found for '%s' '%s'", event.ConfigurationId, event.WebhookId)
 		}
 
+		// Disabled hook allows all requests.
+		if h := op.HookManager.GetHook(task_metadata.HookMetadataAccessor(admissionTask).HookName); h != nil && h.IsPaused() {
+			logEntry.Debugf("Hook '%s' is disabled at runtime, allow the request", h.Name)
+			return &admission.Response{Allowed: true}, nil
+		}
+
 		res := op.taskHandler(admissionTask)
 
 		if res.Status == "Fail" {
+			op.notifyWebhookError(alert.Event{
+				Type:    alert.WebhookError,
+				Hook:    task_metadata.HookMetadataAccessor(admissionTask).HookName,
+				Binding: string(eventBindingType),
+				Message: fmt.Sprintf("Hook failed to handle '%s' '%s': %s", event.ConfigurationId, event.WebhookId, admissionTask.GetFailureMessage()),
+			})
 			return &admission.Response{
-				Allowed: false,
-				Message: "Hook failed",
+				Allowed:    false,
+				Message:    "Hook failed",
+				HookFailed: true,
 			}, nil
 		}
 
@@ -337,6 +596,12 @@ func (op *ShellOperator) conversionEventHandler(crdName string, request *v1.Conv
 			res := op.taskHandler(convTask)
 
 			if res.Status == "Fail" {
+				op.notifyWebhookError(alert.Event{
+					Type:    alert.WebhookError,
+					Hook:    task_metadata.HookMetadataAccessor(convTask).HookName,
+					Binding: string(types.KubernetesConversion),
+					Message: fmt.Sprintf("Hook failed to convert crd/%s to %s: %s", crdName, request.DesiredAPIVersion, convTask.GetFailureMessage()),
+				})
 				return &conversion.Response{
 					FailedMessage:    fmt.Sprintf("Hook failed to convert to %s", request.DesiredAPIVersion),
 					ConvertedObjects: nil,
@@ -380,10 +645,17 @@ func (op *ShellOperator) conversionEventHandler(crdName string, request *v1.Conv
 	}, nil
 }
 
-// taskHandler
+// taskHandler runs the handler for the task with pprof labels of the task.
 func (op *ShellOperator) taskHandler(t task.Task) queue.TaskResult {
+	var res queue.TaskResult
+	pprof.Do(context.Background(), taskProfilerLabels(t), func(context.Context) {
+		res = op.handleTask(t)
+	})
+	return res
+}
+
+func (op *ShellOperator) handleTask(t task.Task) queue.TaskResult {
 	logEntry := log.WithField("operator.component", "taskRunner")
-	hookMeta := task_metadata.HookMetadataAccessor(t)
 	var res queue.TaskResult
 
 	switch t.GetType() {
@@ -394,6 +666,7 @@ func (op *ShellOperator) taskHandler(t task.Task) queue.TaskResult {
 		res = op.taskHandleEnableKubernetesBindings(t)
 
 	case task_metadata.EnableScheduleBindings:
+		hookMeta := task_metadata.HookMetadataAccessor(t)
 		hookLogLabels := map[string]string{}
 		hookLogLabels["hook"] = hookMeta.HookName
 		hookLogLabels["binding"] = string(types.Schedule)
@@ -406,6 +679,30 @@ func (op *ShellOperator) taskHandler(t task.Task) queue.TaskResult {
 		taskHook.HookController.EnableScheduleBindings()
 		taskLogEntry.Infof("Schedule binding for hook enabled successfully")
 		res.Status = "Success"
+
+	case task_metadata.EnableTriggerBindings:
+		hookMeta := task_metadata.HookMetadataAccessor(t)
+		hookLogLabels := map[string]string{}
+		hookLogLabels["hook"] = hookMeta.HookName
+		hookLogLabels["task"] = "EnableTriggerBindings"
+		hookLogLabels["queue"] = "main"
+
+		taskLogEntry := logEntry.WithFields(utils.LabelsToLogFields(hookLogLabels))
+
+		taskHook := op.HookManager.GetHook(hookMeta.HookName)
+		taskHook.HookController.EnableTriggerBindings()
+		taskLogEntry.Infof("Trigger bindings for hook enabled successfully")
+		res.Status = "Success"
+
+	case task_metadata.SelfTest:
+		res = op.taskHandleSelfTest(t)
+
+	default:
+		if handler, has := op.getTaskHandler(t.GetType()); has {
+			return op.taskHandleCustom(t, handler)
+		}
+		logEntry.Errorf("No handler for task type '%s', drop task", t.GetType())
+		res.Status = "Success"
 	}
 
 	return res
@@ -471,6 +768,10 @@ func (op *ShellOperator) taskHandleEnableKubernetesBindings(t task.Task) queue.T
 			t.WithQueuedAt(now)
 		}
 		res.HeadTasks = hookRunTasks
+		op.Startup.Step(StartupPhaseMonitorSync)
+		if op.Startup.PhaseDone(StartupPhaseMonitorSync) {
+			kube_events_manager.DefaultStartupThrottle.Finish()
+		}
 	}
 
 	op.MetricStorage.CounterAdd("{PREFIX}hook_enable_kubernetes_bindings_errors_total", errors, metricLabels)
@@ -499,6 +800,7 @@ func (op *ShellOperator) taskHandleHookRun(t task.Task) queue.TaskResult {
 	}
 	taskWaitTime := time.Since(t.GetQueuedAt()).Seconds()
 	op.MetricStorage.CounterAdd("{PREFIX}task_wait_in_queue_seconds_total", taskWaitTime, metricLabels)
+	op.observeTaskPhase(metricLabels, TaskPhaseQueueWait, time.Since(t.GetQueuedAt()))
 
 	defer measure.Duration(func(d time.Duration) {
 		op.MetricStorage.HistogramObserve("{PREFIX}hook_run_seconds", d.Seconds(), metricLabels, nil)
@@ -515,6 +817,8 @@ func (op *ShellOperator) taskHandleHookRun(t task.Task) queue.TaskResult {
 
 	isSynchronization := hookMeta.IsSynchronization()
 	shouldRunHook := true
+	// dropped is true if the task is removed from the queue without a successful run.
+	dropped := false
 	if isSynchronization {
 		// There were no Synchronization for v0 hooks, skip hook execution.
 		if taskHook.Config.Version == "v0" {
@@ -526,6 +830,24 @@ func (op *ShellOperator) taskHandleHookRun(t task.Task) queue.TaskResult {
 		}
 	}
 
+	// Conversion hooks are always executed: the API server cannot serve objects without conversion.
+	if taskHook.IsPaused() && hookMeta.BindingType != types.KubernetesConversion {
+		taskLogEntry.Info("Hook is disabled at runtime, skip execution")
+		shouldRunHook = false
+	}
+
+	// Tasks queued before the quarantine are skipped too.
+	if shouldRunHook && hookMeta.BindingType != types.KubernetesConversion {
+		if taskHook.IsQuarantined() {
+			taskLogEntry.Infof("Hook is quarantined until %s, skip execution", taskHook.QuarantinedUntil().Format(time.RFC3339))
+			shouldRunHook = false
+			dropped = true
+			taskHook.AddQuarantineResync(quarantineResyncMonitors(taskHook, hookMeta)...)
+		} else {
+			op.resyncAfterQuarantine(taskHook)
+		}
+	}
+
 	if shouldRunHook && taskHook.Config.Version == "v1" {
 		// Do not combine Synchronization with Event
 		shouldCombine := true
@@ -548,17 +870,85 @@ func (op *ShellOperator) taskHandleHookRun(t task.Task) queue.TaskResult {
 		}
 	}
 
+	// Skip the schedule binding if snapshots are not changed since the last successful run.
+	snapshotsChecksum := ""
+	if shouldRunHook && hookMeta.BindingType == types.Schedule && taskHook.SkipIfSnapshotsUnchanged(hookMeta.Binding) {
+		var unchanged bool
+		snapshotsChecksum, unchanged = taskHook.SnapshotsUnchanged(hookMeta.Binding, hookMeta.BindingContext)
+		if unchanged {
+			taskLogEntry.Info("Snapshots are not changed since the last successful run, skip execution")
+			op.MetricStorage.CounterAdd("{PREFIX}hook_run_skipped_total", 1.0, metricLabels)
+			shouldRunHook = false
+		}
+	}
+
 	var res queue.TaskResult
 	// Default when shouldRunHook is false.
 	res.Status = "Success"
 
 	if shouldRunHook {
+		// Wait for distributed locks, the queue is blocked until the Leases are free.
+		locks, err := op.acquireHookLocks(op.ctx, taskHook, t.GetQueueName())
+		if err != nil {
+			releaseHookLocks(locks)
+			if op.ctx.Err() != nil {
+				return queue.TaskResult{
+					Status: "Repeat",
+				}
+			}
+			t.UpdateFailureMessage(err.Error())
+			taskLogEntry.Errorf("Acquire distributed lock: %v", err)
+			return queue.TaskResult{
+				Status: "Fail",
+			}
+		}
+		defer func() {
+			if releaseHookLocks(locks) {
+				taskLogEntry.Warn("Distributed lock was lost during the hook run, the hook is terminated")
+			}
+		}()
+		// The hook is terminated if a Lease is lost during the run.
+		runCtx, cancelRun := cancelOnLostLocks(op.ctx, locks)
+		defer cancelRun()
+
 		taskLogEntry.Info("Execute hook")
 
+		// Pass the load of the queue to the hook.
+		backpressure := &binding_context.Backpressure{
+			Queue:           t.GetQueueName(),
+			TaskWaitSeconds: taskWaitTime,
+		}
+		if q := op.TaskQueues.GetByName(t.GetQueueName()); q != nil {
+			backpressure.QueueLength = q.Length()
+		}
+		for i := range hookMeta.BindingContext {
+			hookMeta.BindingContext[i].Metadata.Backpressure = backpressure
+		}
+
 		success := 0.0
 		errors := 0.0
 		allowed := 0.0
-		err = op.handleRunHook(t, taskHook, hookMeta, taskLogEntry, hookLogLabels, metricLabels)
+		runStatus := hook.RunStatus{
+			Binding:     hookMeta.Binding,
+			BindingType: hookMeta.BindingType,
+			StartedAt:   time.Now(),
+		}
+		spanCtx, span := tracing.Start(runCtx, "hook.run", map[string]string{
+			"hook":        hookMeta.HookName,
+			"binding":     hookMeta.Binding,
+			"bindingType": string(hookMeta.BindingType),
+			"queue":       t.GetQueueName(),
+		})
+		err = op.handleRunHook(spanCtx, t, taskHook, hookMeta, taskLogEntry, hookLogLabels, metricLabels, &runStatus)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+		runStatus.Duration = time.Since(runStatus.StartedAt).String()
+		if err != nil {
+			runStatus.Error = err.Error()
+		}
+		taskHook.SetLastRun(runStatus)
 		if err != nil {
 			if hookMeta.AllowFailure {
 				allowed = 1.0
@@ -570,19 +960,39 @@ func (op *ShellOperator) taskHandleHookRun(t task.Task) queue.TaskResult {
 				t.WithQueuedAt(time.Now()) // Reset queueAt for correct results in 'task_wait_in_queue' metric.
 				taskLogEntry.Errorf("Hook failed. Will retry after delay. Failed count is %d. Error: %s", t.GetFailureCount()+1, err)
 				res.Status = "Fail"
+				op.notifyHookFailed(t, hookMeta, err)
 			}
 		} else {
 			success = 1.0
 			taskLogEntry.Infof("Hook executed successfully")
 			res.Status = "Success"
+			if snapshotsChecksum != "" {
+				taskHook.SetSnapshotsChecksum(hookMeta.Binding, snapshotsChecksum)
+			}
+		}
+		if taskHook.ObserveRunResult(errors > 0) {
+			// Drop the failed task to unblock the queue.
+			// Synchronization and events of dropped tasks are resynchronized after the quarantine.
+			taskLogEntry.Warnf("Hook error budget is exhausted, quarantine the hook until %s and drop the failed task",
+				taskHook.QuarantinedUntil().Format(time.RFC3339))
+			res.Status = "Success"
+			dropped = true
+			taskHook.AddQuarantineResync(quarantineResyncMonitors(taskHook, hookMeta)...)
+			op.scheduleQuarantineResync(taskHook)
+			op.MetricStorage.CounterAdd("{PREFIX}hook_quarantines_total", 1.0, map[string]string{"hook": hookMeta.HookName})
+			op.MetricStorage.GaugeSet("{PREFIX}hook_quarantined", 1.0, map[string]string{"hook": hookMeta.HookName})
 		}
 		op.MetricStorage.CounterAdd("{PREFIX}hook_run_allowed_errors_total", allowed, metricLabels)
 		op.MetricStorage.CounterAdd("{PREFIX}hook_run_errors_total", errors, metricLabels)
 		op.MetricStorage.CounterAdd("{PREFIX}hook_run_success_total", success, metricLabels)
 	}
 
+	if hookMeta.BindingType == types.OnStartup && res.Status == "Success" && !dropped {
+		op.Startup.Step(StartupPhaseOnStartup)
+	}
+
 	// Unlock Kubernetes events for all monitors when Synchronization task is done.
-	if isSynchronization && res.Status == "Success" {
+	if isSynchronization && res.Status == "Success" && !dropped {
 		taskLogEntry.Info("Unlock kubernetes.Event tasks")
 		for _, monitorID := range hookMeta.MonitorIDs {
 			taskHook.HookController.UnlockKubernetesEventsFor(monitorID)
@@ -592,32 +1002,76 @@ func (op *ShellOperator) taskHandleHookRun(t task.Task) queue.TaskResult {
 	return res
 }
 
-func (op *ShellOperator) handleRunHook(t task.Task, taskHook *hook.Hook, hookMeta task_metadata.HookMetadata, taskLogEntry *log.Entry, hookLogLabels map[string]string, metricLabels map[string]string) error {
+// handleRunHook executes the hook and applies its outputs. Details of the run are saved into runStatus.
+func (op *ShellOperator) handleRunHook(ctx context.Context, t task.Task, taskHook *hook.Hook, hookMeta task_metadata.HookMetadata, taskLogEntry *log.Entry, hookLogLabels map[string]string, metricLabels map[string]string, runStatus *hook.RunStatus) error {
 	for _, info := range taskHook.HookController.SnapshotsInfo() {
 		taskLogEntry.Debugf("snapshot info: %s", info)
 	}
 
-	result, err := taskHook.Run(hookMeta.BindingType, hookMeta.BindingContext, hookLogLabels)
+	if err := op.faults.beforeHookRun(taskHook.Name); err != nil {
+		return err
+	}
+
+	_, execSpan := tracing.Start(ctx, "hook.exec", map[string]string{"hook": taskHook.Name})
+	result, err := taskHook.RunContext(ctx, hookMeta.BindingType, hookMeta.BindingContext, hookLogLabels)
+	if err != nil {
+		execSpan.RecordError(err)
+	}
+	execSpan.End()
+	if result != nil {
+		for phase, d := range result.Phases {
+			op.observeTaskPhase(metricLabels, string(phase), d)
+		}
+		runStatus.ExitCode = result.ExitCode
+		runStatus.StderrTail = result.StderrTail
+		for _, output := range result.TruncatedOutputs {
+			truncatedLabels := map[string]string{"output": output}
+			for k, v := range metricLabels {
+				truncatedLabels[k] = v
+			}
+			op.MetricStorage.CounterAdd("{PREFIX}hook_run_output_truncated_total", 1.0, truncatedLabels)
+		}
+		for _, output := range result.OutputParseErrors {
+			parseErrorLabels := map[string]string{"output": output}
+			for k, v := range metricLabels {
+				parseErrorLabels[k] = v
+			}
+			op.MetricStorage.CounterAdd("{PREFIX}hook_run_output_parse_errors_total", 1.0, parseErrorLabels)
+		}
+	}
+	objectPatcher, patcherErr := op.objectPatcherFor(taskHook)
+
 	if err != nil {
 		if result != nil && len(result.KubernetesPatchBytes) > 0 {
+			if patcherErr != nil {
+				return fmt.Errorf("%s: couldn't patch status: %s", err, patcherErr)
+			}
 			operations, patchStatusErr := object_patch.ParseOperations(result.KubernetesPatchBytes)
 			if patchStatusErr != nil {
 				return fmt.Errorf("%s: couldn't patch status: %s", err, patchStatusErr)
 			}
 
-			patchStatusErr = op.ObjectPatcher.ExecuteOperations(object_patch.GetPatchStatusOperationsOnHookError(operations))
+			patchStatusErr = objectPatcher.ExecuteOperationsContext(ctx, object_patch.GetPatchStatusOperationsOnHookError(operations))
 			if patchStatusErr != nil {
 				return fmt.Errorf("%s: couldn't patch status: %s", err, patchStatusErr)
 			}
 		}
 		return err
 	}
+	if patcherErr != nil {
+		return patcherErr
+	}
 
 	if result != nil && result.Usage != nil {
 		taskLogEntry.Debugf("Usage: %+v", result.Usage)
 		op.MetricStorage.HistogramObserve("{PREFIX}hook_run_sys_seconds", result.Usage.Sys.Seconds(), metricLabels, nil)
 		op.MetricStorage.HistogramObserve("{PREFIX}hook_run_user_seconds", result.Usage.User.Seconds(), metricLabels, nil)
 		op.MetricStorage.GaugeSet("{PREFIX}hook_run_max_rss_bytes", float64(result.Usage.MaxRss)*1024, metricLabels)
+		if app.HookResourceMetrics {
+			op.MetricStorage.CounterAdd("{PREFIX}hook_run_cpu_seconds_total", (result.Usage.Sys + result.Usage.User).Seconds(), metricLabels)
+			op.MetricStorage.CounterAdd("{PREFIX}hook_run_io_read_bytes_total", float64(result.Usage.InBlock)*512, metricLabels)
+			op.MetricStorage.CounterAdd("{PREFIX}hook_run_io_write_bytes_total", float64(result.Usage.OutBlock)*512, metricLabels)
+		}
 	}
 
 	// Try to apply Kubernetes actions.
@@ -626,7 +1080,16 @@ func (op *ShellOperator) handleRunHook(t task.Task, taskHook *hook.Hook, hookMet
 		if err != nil {
 			return err
 		}
-		err = op.ObjectPatcher.ExecuteOperations(operations)
+		runStatus.PatchOperations = len(operations)
+		if err := op.faults.beforeAPICall(taskHook.Name); err != nil {
+			return err
+		}
+		patchApplyStart := time.Now()
+		results, err := objectPatcher.ExecuteOperationsWithResults(ctx, operations)
+		op.observeTaskPhase(metricLabels, TaskPhasePatchApply, time.Since(patchApplyStart))
+		if resultsErr := taskHook.SetPatchResults(results); resultsErr != nil {
+			taskLogEntry.Warnf("Save kubernetes patch results: %v", resultsErr)
+		}
 		if err != nil {
 			return err
 		}
@@ -652,9 +1115,48 @@ func (op *ShellOperator) handleRunHook(t task.Task, taskHook *hook.Hook, hookMet
 		taskLogEntry.Infof("ConversionResponse from hook: %s", result.ConversionResponse.Dump())
 	}
 
+	// The shadow is started after patches of the primary hook are applied.
+	op.shadowHooks.Run(taskHook, hookMeta, result, hookLogLabels)
+
 	return nil
 }
 
+// objectPatcherFor returns an ObjectPatcher that impersonates the user from the hook settings
+// or the default ObjectPatcher. Patchers are created in initHookManager, so invalid settings
+// are reported at start.
+//
+// Impersonation is not a security boundary: the hook process can still read the mounted
+// ServiceAccount token of the operator and use it directly.
+func (op *ShellOperator) objectPatcherFor(h *hook.Hook) (*object_patch.ObjectPatcher, error) {
+	if h.Config == nil || h.Config.Settings == nil || h.Config.Settings.Impersonate == nil || op.ObjectPatcher == nil {
+		return op.ObjectPatcher, nil
+	}
+
+	op.impersonatedPatchersMu.Lock()
+	defer op.impersonatedPatchersMu.Unlock()
+
+	if patcher, ok := op.impersonatedPatchers[h.Name]; ok {
+		return patcher, nil
+	}
+	imp := h.Config.Settings.Impersonate
+	patcher, err := op.ObjectPatcher.Impersonate(imp.User, imp.Groups)
+	if err != nil {
+		return nil, err
+	}
+	if op.impersonatedPatchers == nil {
+		op.impersonatedPatchers = make(map[string]*object_patch.ObjectPatcher)
+	}
+	op.impersonatedPatchers[h.Name] = patcher
+	return patcher, nil
+}
+
+// resetImpersonatedPatchers drops cached ObjectPatchers when hooks are loaded again.
+func (op *ShellOperator) resetImpersonatedPatchers() {
+	op.impersonatedPatchersMu.Lock()
+	op.impersonatedPatchers = nil
+	op.impersonatedPatchersMu.Unlock()
+}
+
 // combineBindingContextForHook combines binding contexts from a sequence of task with similar
 // hook name and task type into array of binding context and delete excess tasks from queue.
 //
@@ -766,6 +1268,19 @@ func (op *ShellOperator) CombineBindingContextForHook(q *queue.TaskQueue, t task
 	return res
 }
 
+// fairSchedulingKey returns a hook name of the HookRun task for the fair scheduling in queues.
+// OnStartup runs and other tasks are executed in FIFO order.
+func fairSchedulingKey(t task.Task) string {
+	if t.GetType() != task_metadata.HookRun {
+		return ""
+	}
+	hookMeta, ok := t.GetMetadata().(task_metadata.HookMetadata)
+	if !ok || hookMeta.BindingType == types.OnStartup {
+		return ""
+	}
+	return hookMeta.HookName
+}
+
 // bootstrapMainQueue adds tasks to run hooks with OnStartup bindings
 // and tasks to enable kubernetes bindings.
 func (op *ShellOperator) bootstrapMainQueue(tqs *queue.TaskQueueSet) {
@@ -784,6 +1299,11 @@ func (op *ShellOperator) bootstrapMainQueue(tqs *queue.TaskQueueSet) {
 		return
 	}
 
+	op.Startup.Begin(StartupPhaseOnStartup, len(onStartupHooks))
+	if len(onStartupHooks) == 0 {
+		op.Startup.Finish(StartupPhaseOnStartup)
+	}
+
 	for _, hookName := range onStartupHooks {
 		bc := binding_context.BindingContext{
 			Binding: string(types.OnStartup),
@@ -801,6 +1321,13 @@ func (op *ShellOperator) bootstrapMainQueue(tqs *queue.TaskQueueSet) {
 		logEntry.Infof("queue task %s with hook %s", newTask.GetDescription(), hookName)
 	}
 
+	kubernetesHooks, _ := op.HookManager.GetHooksInOrder(types.OnKubernetesEvent)
+	op.Startup.Begin(StartupPhaseMonitorSync, len(kubernetesHooks))
+	if len(kubernetesHooks) == 0 {
+		op.Startup.Finish(StartupPhaseMonitorSync)
+		kube_events_manager.DefaultStartupThrottle.Finish()
+	}
+
 	// Add tasks to enable kubernetes monitors and schedules for each hook
 	for _, hookName := range op.HookManager.GetHookNames() {
 		h := op.HookManager.GetHook(hookName)
@@ -826,6 +1353,17 @@ func (op *ShellOperator) bootstrapMainQueue(tqs *queue.TaskQueueSet) {
 			mainQueue.AddLast(newTask)
 			logEntry.Infof("queue task %s with hook %s", newTask.GetDescription(), hookName)
 		}
+
+		if len(h.GetConfig().Triggers()) > 0 {
+			newTask := task.NewTask(task_metadata.EnableTriggerBindings).
+				WithMetadata(task_metadata.HookMetadata{
+					HookName: hookName,
+					Binding:  string(task_metadata.EnableTriggerBindings),
+				}).
+				WithQueuedAt(time.Now())
+			mainQueue.AddLast(newTask)
+			logEntry.Infof("queue task %s with hook %s", newTask.GetDescription(), hookName)
+		}
 	}
 }
 
@@ -852,6 +1390,16 @@ func (op *ShellOperator) initAndStartHookQueues() {
 			}
 		}
 	}
+
+	for _, hookName := range op.HookManager.GetHookNames() {
+		h := op.HookManager.GetHook(hookName)
+		for _, hookBinding := range h.Config.Triggers() {
+			if op.TaskQueues.GetByName(hookBinding.Queue) == nil {
+				op.TaskQueues.NewNamedQueue(hookBinding.Queue, op.taskHandler)
+				op.TaskQueues.GetByName(hookBinding.Queue).Start()
+			}
+		}
+	}
 }
 
 func (op *ShellOperator) runMetrics() {
@@ -877,13 +1425,52 @@ func (op *ShellOperator) runMetrics() {
 			time.Sleep(5 * time.Second)
 		}
 	}()
+
+	// quarantined hooks
+	if app.HookErrorBudget > 0 && op.HookManager != nil {
+		go func() {
+			for {
+				for _, hookName := range op.HookManager.GetHookNames() {
+					h := op.HookManager.GetHook(hookName)
+					quarantined := 0.0
+					if h.IsQuarantined() {
+						quarantined = 1.0
+					}
+					op.MetricStorage.GaugeSet("{PREFIX}hook_quarantined", quarantined, map[string]string{"hook": hookName})
+				}
+				time.Sleep(5 * time.Second)
+			}
+		}()
+	}
 }
 
 // Shutdown pause kubernetes events handling and stop queues. Wait for queues to stop.
 func (op *ShellOperator) Shutdown() {
+	// Drain admission webhooks first if configurations are updated on shutdown: hooks should
+	// handle requests until then. Otherwise, the server is stopped after queues as before.
+	drainWebhooks := op.AdmissionWebhookManager != nil && op.AdmissionWebhookManager.HasShutdownAction()
+	if drainWebhooks {
+		op.AdmissionWebhookManager.Stop()
+	}
 	op.ScheduleManager.Stop()
+	if op.TriggerManager != nil {
+		op.TriggerManager.Stop()
+	}
 	op.KubeEventsManager.PauseHandleEvents()
 	op.TaskQueues.Stop()
+	// Kill idle warm pool processes first: they are not running hooks and would delay the termination.
+	op.HookManager.Stop()
+	// Forward termination to running hooks without waiting for queues: queues wait for these hooks.
+	terminated := make(chan struct{})
+	go func() {
+		executor.TerminateAll(executor.TerminateGracePeriod)
+		close(terminated)
+	}()
 	// Wait for queues to stop, but no more than 10 seconds
 	op.TaskQueues.WaitStopWithTimeout(WaitQueuesTimeout)
+	<-terminated
+	if op.AdmissionWebhookManager != nil && !drainWebhooks {
+		op.AdmissionWebhookManager.Stop()
+	}
+	op.saveHookCounters()
 }
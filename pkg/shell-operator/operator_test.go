@@ -6,8 +6,11 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	"github.com/flant/shell-operator/pkg/hook"
 	. "github.com/flant/shell-operator/pkg/hook/task_metadata"
 	. "github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+	"github.com/flant/shell-operator/pkg/schedule_manager"
 	"github.com/flant/shell-operator/pkg/task"
 	utils "github.com/flant/shell-operator/pkg/utils/file"
 )
@@ -61,3 +64,40 @@ func Test_Operator_startup_tasks(t *testing.T) {
 		i++
 	})
 }
+
+func Test_Operator_options(t *testing.T) {
+	g := NewWithT(t)
+
+	hooksDir, err := utils.RequireExistingDirectory("testdata/startup_tasks/hooks")
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	ctx := context.Background()
+	metricStorage := metric_storage.NewMetricStorage(ctx, "test_", false)
+	scheduleManager := schedule_manager.NewScheduleManager(ctx)
+	hookManager := hook.NewHookManager(&hook.ManagerConfig{
+		WorkingDir: hooksDir,
+		TempDir:    t.TempDir(),
+		Smgr:       scheduleManager,
+	})
+
+	op := NewShellOperator(ctx,
+		WithMetricStorage(metricStorage),
+		WithScheduleManager(scheduleManager),
+		WithHookManager(hookManager),
+	)
+	g.Expect(op.MetricStorage).Should(BeIdenticalTo(metricStorage))
+
+	// Injected managers should not be replaced during the assembly.
+	op.SetupEventManagers()
+	op.setupHookManagers(hooksDir, "")
+	g.Expect(op.ScheduleManager).Should(BeIdenticalTo(scheduleManager))
+	g.Expect(op.HookManager).Should(BeIdenticalTo(hookManager))
+	g.Expect(op.KubeEventsManager).ShouldNot(BeNil())
+}
+
+func Test_RequireAPIVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(RequireAPIVersion(APIVersion)).Should(Succeed())
+	g.Expect(RequireAPIVersion(APIVersion + 1)).ShouldNot(Succeed())
+}
@@ -0,0 +1,91 @@
+package shell_operator
+
+import (
+	"fmt"
+
+	klient "github.com/flant/kube-client/client"
+
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/kube/lease_lock"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"github.com/flant/shell-operator/pkg/kube_events_manager"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+	"github.com/flant/shell-operator/pkg/schedule_manager"
+	"github.com/flant/shell-operator/pkg/trigger_manager"
+)
+
+// APIVersion is a version of the Go API for programs that embed ShellOperator,
+// e.g. addon-operator: Option functions and interfaces of HookManager,
+// ScheduleManager and KubeEventsManager. It is increased on breaking changes.
+const APIVersion = 1
+
+// RequireAPIVersion returns an error if the embedder is written for another
+// APIVersion, so it fails on start with a clear message instead of misbehaving
+// after an upgrade of shell-operator.
+func RequireAPIVersion(version int) error {
+	if version != APIVersion {
+		return fmt.Errorf("shell-operator Go API version is %d, but version %d is required", APIVersion, version)
+	}
+	return nil
+}
+
+// Option sets a dependency of the ShellOperator. Dependencies that are not
+// set with options are created with default settings during the assembly.
+type Option func(op *ShellOperator)
+
+func WithKubeClient(client *klient.Client) Option {
+	return func(op *ShellOperator) {
+		op.KubeClient = client
+	}
+}
+
+func WithObjectPatcher(patcher *object_patch.ObjectPatcher) Option {
+	return func(op *ShellOperator) {
+		op.ObjectPatcher = patcher
+	}
+}
+
+// WithLocker sets a Locker for distributed locks of hooks and queues.
+func WithLocker(locker *lease_lock.Locker) Option {
+	return func(op *ShellOperator) {
+		op.Locker = locker
+	}
+}
+
+// WithMetricStorage sets a storage for built-in metrics. Built-in metrics are registered in it.
+func WithMetricStorage(storage *metric_storage.MetricStorage) Option {
+	return func(op *ShellOperator) {
+		op.MetricStorage = storage
+	}
+}
+
+// WithHookMetricStorage sets a storage for metrics returned by hooks.
+func WithHookMetricStorage(storage *metric_storage.MetricStorage) Option {
+	return func(op *ShellOperator) {
+		op.HookMetricStorage = storage
+	}
+}
+
+func WithScheduleManager(mgr schedule_manager.ScheduleManager) Option {
+	return func(op *ShellOperator) {
+		op.ScheduleManager = mgr
+	}
+}
+
+func WithTriggerManager(mgr trigger_manager.TriggerManager) Option {
+	return func(op *ShellOperator) {
+		op.TriggerManager = mgr
+	}
+}
+
+func WithKubeEventsManager(mgr kube_events_manager.KubeEventsManager) Option {
+	return func(op *ShellOperator) {
+		op.KubeEventsManager = mgr
+	}
+}
+
+func WithHookManager(mgr *hook.Manager) Option {
+	return func(op *ShellOperator) {
+		op.HookManager = mgr
+	}
+}
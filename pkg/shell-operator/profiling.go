@@ -0,0 +1,40 @@
+package shell_operator
+
+import (
+	"runtime"
+	"runtime/pprof"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/task"
+)
+
+// taskProfilerLabels returns pprof labels for the task: the queue, the task type and the hook.
+// Goroutines started by the handler inherit labels, so CPU and goroutine profiles
+// can be filtered by the hook, e.g. 'go tool pprof -tagfocus hook=hook.sh'.
+func taskProfilerLabels(t task.Task) pprof.LabelSet {
+	labels := []string{"task", string(t.GetType())}
+	if queueName := t.GetQueueName(); queueName != "" {
+		labels = append(labels, "queue", queueName)
+	}
+	if meta, ok := t.GetMetadata().(task_metadata.HookNameAccessor); ok && meta.GetHookName() != "" {
+		labels = append(labels, "hook", meta.GetHookName())
+	}
+	return pprof.Labels(labels...)
+}
+
+// enableContentionProfiles enables mutex and block profiles served on /debug/pprof/mutex
+// and /debug/pprof/block. Profiles are disabled by default as they add an overhead.
+func enableContentionProfiles() {
+	if app.GoMutexProfileFraction > 0 {
+		runtime.SetMutexProfileFraction(app.GoMutexProfileFraction)
+	}
+	if app.GoBlockProfileRate > 0 {
+		runtime.SetBlockProfileRate(app.GoBlockProfileRate)
+	}
+	if app.GoMutexProfileFraction > 0 || app.GoBlockProfileRate > 0 {
+		log.Infof("Go runtime: mutex profile fraction %d, block profile rate %d", app.GoMutexProfileFraction, app.GoBlockProfileRate)
+	}
+}
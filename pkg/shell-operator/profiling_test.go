@@ -0,0 +1,33 @@
+package shell_operator
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/task"
+)
+
+func Test_TaskProfilerLabels(t *testing.T) {
+	labels := func(tsk task.Task) map[string]string {
+		res := map[string]string{}
+		pprof.Do(context.Background(), taskProfilerLabels(tsk), func(ctx context.Context) {
+			pprof.ForLabels(ctx, func(key, value string) bool {
+				res[key] = value
+				return true
+			})
+		})
+		return res
+	}
+
+	hookTask := task.NewTask(task_metadata.HookRun).
+		WithQueueName("pods").
+		WithMetadata(task_metadata.HookMetadata{HookName: "hook.sh"})
+	assert.Equal(t, map[string]string{"task": "HookRun", "queue": "pods", "hook": "hook.sh"}, labels(hookTask))
+
+	// Tasks without a hook have no hook label.
+	assert.Equal(t, map[string]string{"task": "SelfTest"}, labels(task.NewTask(task_metadata.SelfTest)))
+}
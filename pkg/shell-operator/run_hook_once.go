@@ -0,0 +1,80 @@
+package shell_operator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flant/shell-operator/pkg/hook/binding_context"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"github.com/flant/shell-operator/pkg/metric_storage/operation"
+	"github.com/flant/shell-operator/pkg/webhook/admission"
+	"github.com/flant/shell-operator/pkg/webhook/conversion"
+)
+
+// HookRunResult is a result of RunHookOnce.
+type HookRunResult struct {
+	// ExitCode and StderrTail are details of the hook process. They are not set for warm pool runs.
+	ExitCode   int
+	StderrTail string
+	Duration   time.Duration
+	// KubernetesPatch contains operations from $KUBERNETES_PATCH_PATH. They are not executed.
+	KubernetesPatch      []object_patch.Operation
+	KubernetesPatchBytes []byte
+	// Metrics contains operations from $METRICS_PATH. They are not sent to the metric storage.
+	Metrics            []operation.MetricOperation
+	AdmissionResponse  *admission.Response
+	ConversionResponse *conversion.Response
+}
+
+// RunHookOnce runs the loaded hook with binding contexts and waits for the result. It is intended
+// for embedders and integration tests: the hook is executed without the queue and regardless of
+// the pause or the quarantine, outputs are returned as is and are not applied to the cluster
+// or to the metric storage. The hook can run concurrently with tasks from queues.
+//
+// The binding type is taken from the metadata of the first binding context. Snapshots are
+// refreshed as for the usual run. The result is returned with the error if the hook has failed.
+func (op *ShellOperator) RunHookOnce(name string, bindingContext []binding_context.BindingContext) (*HookRunResult, error) {
+	if op.HookManager == nil {
+		return nil, fmt.Errorf("hook manager is not initialized")
+	}
+	if len(bindingContext) == 0 {
+		return nil, fmt.Errorf("run hook '%s': binding context is empty", name)
+	}
+	h := op.HookManager.GetHook(name)
+	if h == nil {
+		return nil, fmt.Errorf("hook '%s' is not found", name)
+	}
+
+	logLabels := map[string]string{
+		"hook":    name,
+		"binding": bindingContext[0].Binding,
+		"event":   string(bindingContext[0].Metadata.BindingType),
+		"task":    "RunHookOnce",
+	}
+
+	start := time.Now()
+	result, err := h.Run(bindingContext[0].Metadata.BindingType, bindingContext, logLabels)
+	res := &HookRunResult{
+		Duration: time.Since(start),
+	}
+	if result == nil {
+		return res, err
+	}
+
+	res.ExitCode = result.ExitCode
+	res.StderrTail = result.StderrTail
+	res.KubernetesPatchBytes = result.KubernetesPatchBytes
+	res.Metrics = result.Metrics
+	res.AdmissionResponse = result.AdmissionResponse
+	res.ConversionResponse = result.ConversionResponse
+
+	if len(result.KubernetesPatchBytes) > 0 {
+		operations, parseErr := object_patch.ParseOperations(result.KubernetesPatchBytes)
+		if parseErr != nil && err == nil {
+			err = parseErr
+		}
+		res.KubernetesPatch = operations
+	}
+
+	return res, err
+}
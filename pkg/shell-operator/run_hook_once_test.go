@@ -0,0 +1,58 @@
+package shell_operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/hook/binding_context"
+	"github.com/flant/shell-operator/pkg/hook/types"
+	utils "github.com/flant/shell-operator/pkg/utils/file"
+)
+
+func newRunHookOnceOperator(t *testing.T) *ShellOperator {
+	hooksDir, err := utils.RequireExistingDirectory("testdata/run_hook_once/hooks")
+	require.NoError(t, err)
+
+	op := NewShellOperator(context.Background())
+	op.SetupEventManagers()
+	op.setupHookManagers(hooksDir, t.TempDir())
+	require.NoError(t, op.initHookManager())
+	return op
+}
+
+func onStartupContext(binding string) []binding_context.BindingContext {
+	bc := binding_context.BindingContext{Binding: binding}
+	bc.Metadata.BindingType = types.OnStartup
+	return []binding_context.BindingContext{bc}
+}
+
+func Test_RunHookOnce(t *testing.T) {
+	op := newRunHookOnceOperator(t)
+
+	res, err := op.RunHookOnce("hook.sh", onStartupContext("onStartup"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.ExitCode)
+	require.Len(t, res.Metrics, 1)
+	assert.Equal(t, "hook_once", res.Metrics[0].Name)
+	require.Len(t, res.KubernetesPatch, 1)
+	assert.Contains(t, string(res.KubernetesPatchBytes), "hook-once")
+}
+
+func Test_RunHookOnce_Errors(t *testing.T) {
+	op := newRunHookOnceOperator(t)
+
+	res, err := op.RunHookOnce("hook.sh", onStartupContext("fail"))
+	assert.Error(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, 3, res.ExitCode)
+	assert.Contains(t, res.StderrTail, "failed on purpose")
+
+	_, err = op.RunHookOnce("absent.sh", onStartupContext("onStartup"))
+	assert.Error(t, err)
+
+	_, err = op.RunHookOnce("hook.sh", nil)
+	assert.Error(t, err)
+}
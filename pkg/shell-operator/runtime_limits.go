@@ -0,0 +1,71 @@
+package shell_operator
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+	"github.com/flant/shell-operator/pkg/utils/cgroup"
+)
+
+// tuneGoRuntime sets GOMAXPROCS and GOMEMLIMIT from flags or from cgroup limits
+// of the container. Values from GOMAXPROCS and GOMEMLIMIT environment variables
+// are respected by the Go runtime and are not changed.
+func tuneGoRuntime() error {
+	limits, err := cgroup.ReadLimits()
+	if err != nil {
+		log.Warnf("Read cgroup limits: %v", err)
+	}
+
+	if _, set := os.LookupEnv("GOMAXPROCS"); !set {
+		procs := app.GoMaxProcs
+		if procs == 0 && limits.CPU > 0 {
+			procs = int(math.Ceil(limits.CPU))
+		}
+		if procs > 0 {
+			runtime.GOMAXPROCS(procs)
+		}
+	}
+
+	if _, set := os.LookupEnv("GOMEMLIMIT"); !set {
+		var memLimit int64
+		if app.GoMemLimit != "" {
+			q, err := resource.ParseQuantity(app.GoMemLimit)
+			if err != nil {
+				return err
+			}
+			memLimit = q.Value()
+		} else if limits.Memory > 0 {
+			memLimit = int64(float64(limits.Memory) * app.GoMemLimitRatio)
+		}
+		if memLimit > 0 {
+			debug.SetMemoryLimit(memLimit)
+		}
+	}
+
+	log.Infof("Go runtime: GOMAXPROCS=%d, GOMEMLIMIT=%s", runtime.GOMAXPROCS(0), memLimitString(debug.SetMemoryLimit(-1)))
+	return nil
+}
+
+// registerGoRuntimeMetrics reports effective GOMAXPROCS and GOMEMLIMIT.
+func registerGoRuntimeMetrics(metricStorage *metric_storage.MetricStorage) {
+	metricStorage.GaugeSet("{PREFIX}go_maxprocs", float64(runtime.GOMAXPROCS(0)), map[string]string{})
+	memLimit := debug.SetMemoryLimit(-1)
+	if memLimit == math.MaxInt64 {
+		memLimit = 0
+	}
+	metricStorage.GaugeSet("{PREFIX}go_memlimit_bytes", float64(memLimit), map[string]string{})
+}
+
+func memLimitString(limit int64) string {
+	if limit == math.MaxInt64 {
+		return "off"
+	}
+	return resource.NewQuantity(limit, resource.BinarySI).String()
+}
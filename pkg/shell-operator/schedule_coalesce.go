@@ -0,0 +1,79 @@
+package shell_operator
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/task"
+)
+
+// DefaultScheduleCoalesceWindow is a time to wait for other crontabs fired
+// on the same tick before queueing tasks for a schedule event.
+const DefaultScheduleCoalesceWindow = 100 * time.Millisecond
+
+// hasGroupedScheduleTasks returns true if there are HookRun tasks for schedule bindings with group.
+func hasGroupedScheduleTasks(tasks []task.Task) bool {
+	for _, t := range tasks {
+		hm, ok := t.GetMetadata().(HookMetadata)
+		if ok && t.GetType() == HookRun && hm.BindingType == types.Schedule && hm.Group != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// coalesceScheduleTasks merges HookRun tasks for schedule bindings with the same group
+// into one task per hook and queue. Crontabs from merged tasks are listed in the binding
+// context of the remaining task. Tasks without group are returned as is.
+func coalesceScheduleTasks(tasks []task.Task) []task.Task {
+	res := make([]task.Task, 0, len(tasks))
+	grouped := make(map[string]task.Task)
+
+	for _, t := range tasks {
+		hm, ok := t.GetMetadata().(HookMetadata)
+		if !ok || t.GetType() != HookRun || hm.BindingType != types.Schedule || hm.Group == "" || len(hm.BindingContext) == 0 {
+			res = append(res, t)
+			continue
+		}
+
+		key := t.GetQueueName() + "/" + hm.HookName + "/" + hm.Group
+		first, has := grouped[key]
+		if !has {
+			grouped[key] = t
+			res = append(res, t)
+			continue
+		}
+
+		firstMeta := first.GetMetadata().(HookMetadata)
+		bcs := append(firstMeta.BindingContext[:0:0], firstMeta.BindingContext...)
+		last := len(bcs) - 1
+		for _, bc := range hm.BindingContext {
+			bcs[last].Crontabs = mergeCrontabs(bcs[last].Crontabs, bc.Crontabs)
+		}
+		firstMeta.BindingContext = bcs
+		first.UpdateMetadata(firstMeta)
+		log.Infof("Schedule task for hook '%s' with group '%s' is coalesced, crontabs: %v", hm.HookName, hm.Group, bcs[last].Crontabs)
+	}
+
+	return res
+}
+
+// mergeCrontabs returns a new list with unique crontabs from both lists.
+func mergeCrontabs(a, b []string) []string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(a)+len(b))
+	seen := make(map[string]bool)
+	for _, crontab := range append(append([]string{}, a...), b...) {
+		if seen[crontab] {
+			continue
+		}
+		seen[crontab] = true
+		res = append(res, crontab)
+	}
+	return res
+}
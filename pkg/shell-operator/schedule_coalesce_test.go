@@ -0,0 +1,120 @@
+package shell_operator
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/flant/shell-operator/pkg/hook/binding_context"
+	. "github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/schedule_manager"
+	"github.com/flant/shell-operator/pkg/task"
+	"github.com/flant/shell-operator/pkg/task/queue"
+)
+
+func scheduleTask(hookName string, group string, crontab string) task.Task {
+	bc := binding_context.BindingContext{
+		Binding:  crontab,
+		Crontabs: []string{crontab},
+	}
+	bc.Metadata.BindingType = types.Schedule
+	bc.Metadata.Group = group
+
+	return task.NewTask(HookRun).
+		WithQueueName("main").
+		WithMetadata(HookMetadata{
+			HookName:       hookName,
+			BindingType:    types.Schedule,
+			BindingContext: []binding_context.BindingContext{bc},
+			Group:          group,
+		})
+}
+
+func Test_CoalesceScheduleTasks(t *testing.T) {
+	g := NewWithT(t)
+
+	tasks := []task.Task{
+		scheduleTask("hook1.sh", "reconcile", "* * * * *"),
+		scheduleTask("hook2.sh", "reconcile", "* * * * *"),
+		scheduleTask("hook1.sh", "", "* * * * *"),
+		scheduleTask("hook1.sh", "reconcile", "*/5 * * * *"),
+		scheduleTask("hook1.sh", "reconcile", "*/5 * * * *"),
+		scheduleTask("hook1.sh", "other", "*/5 * * * *"),
+	}
+
+	res := coalesceScheduleTasks(tasks)
+	g.Expect(res).Should(HaveLen(4))
+
+	hm := res[0].GetMetadata().(HookMetadata)
+	g.Expect(hm.HookName).Should(Equal("hook1.sh"))
+	g.Expect(hm.BindingContext).Should(HaveLen(1))
+	g.Expect(hm.BindingContext[0].Crontabs).Should(Equal([]string{"* * * * *", "*/5 * * * *"}))
+
+	// Tasks for other hooks, without group or with other group are not touched.
+	g.Expect(res[1].GetMetadata().(HookMetadata).HookName).Should(Equal("hook2.sh"))
+	g.Expect(res[2].GetMetadata().(HookMetadata).Group).Should(Equal(""))
+	g.Expect(res[3].GetMetadata().(HookMetadata).Group).Should(Equal("other"))
+	g.Expect(res[3].GetMetadata().(HookMetadata).BindingContext[0].Crontabs).Should(Equal([]string{"*/5 * * * *"}))
+}
+
+func Test_MergeCrontabs(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(mergeCrontabs(nil, nil)).Should(BeNil())
+	g.Expect(mergeCrontabs([]string{"a", "b"}, []string{"b", "c"})).Should(Equal([]string{"a", "b", "c"}))
+}
+
+func Test_CombineBindingContext_Group_Crontabs(t *testing.T) {
+	g := NewWithT(t)
+
+	TaskQueues := queue.NewTaskQueueSet()
+	TaskQueues.WithContext(context.Background())
+	TaskQueues.NewNamedQueue("main", func(tsk task.Task) queue.TaskResult {
+		return queue.TaskResult{
+			Status: "Success",
+		}
+	})
+
+	tasks := []task.Task{
+		scheduleTask("hook1.sh", "reconcile", "* * * * *"),
+		scheduleTask("hook1.sh", "reconcile", "*/5 * * * *"),
+	}
+	for _, tsk := range tasks {
+		TaskQueues.GetByName("main").AddLast(tsk)
+	}
+
+	combineResult := combineBindingContextForHook(TaskQueues, TaskQueues.GetByName("main"), tasks[0], nil)
+	g.Expect(combineResult).ShouldNot(BeNil())
+	g.Expect(combineResult.BindingContexts).Should(HaveLen(1))
+	g.Expect(combineResult.BindingContexts[0].Crontabs).Should(Equal([]string{"* * * * *", "*/5 * * * *"}))
+}
+
+func Test_CollectScheduleTasks(t *testing.T) {
+	g := NewWithT(t)
+
+	groups := map[string]string{
+		"* * * * *":   "reconcile",
+		"*/5 * * * *": "reconcile",
+		"0 * * * *":   "",
+	}
+	sm := schedule_manager.NewScheduleManager(context.Background())
+	m := newManagerEventsHandler(context.Background(), &managerEventsHandlerConfig{smgr: sm})
+	m.WithScheduleEventHandler(func(crontab string) []task.Task {
+		return []task.Task{scheduleTask("hook1.sh", groups[crontab], crontab)}
+	})
+
+	// Crontab without group does not wait for other crontabs.
+	sm.Ch() <- "*/5 * * * *"
+	res := m.collectScheduleTasks("0 * * * *")
+	g.Expect(res).Should(HaveLen(1))
+	g.Expect(sm.Ch()).Should(HaveLen(1))
+
+	// Grouped crontab collects crontabs fired during the window.
+	res = m.collectScheduleTasks("* * * * *")
+	g.Expect(res).Should(HaveLen(1))
+	g.Expect(sm.Ch()).Should(BeEmpty())
+	hm := res[0].GetMetadata().(HookMetadata)
+	g.Expect(hm.BindingContext[0].Crontabs).Should(Equal([]string{"* * * * *", "*/5 * * * *"}))
+}
@@ -0,0 +1,215 @@
+package shell_operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/hook/binding_context"
+	"github.com/flant/shell-operator/pkg/hook/controller"
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/hook/types"
+	"github.com/flant/shell-operator/pkg/task"
+	"github.com/flant/shell-operator/pkg/task/queue"
+)
+
+const (
+	selfTestHookName       = "shell-operator-selftest"
+	selfTestDefaultTimeout = 30 * time.Second
+	selfTestMaxTimeout     = 5 * time.Minute
+)
+
+// selfTestHookScript checks the binding context and writes a metric
+// to pass through the output parsing.
+const selfTestHookScript = `#!/bin/sh
+test -s "$BINDING_CONTEXT_PATH" || exit 1
+echo '{"name":"selftest","action":"set","value":1}' > "$METRICS_PATH"
+`
+
+// SelfTestResult is a response of /selftest.
+type SelfTestResult struct {
+	Status           string  `json:"status"`
+	Error            string  `json:"error,omitempty"`
+	Queue            string  `json:"queue"`
+	QueueLength      int     `json:"queueLength"`
+	QueueWaitSeconds float64 `json:"queueWaitSeconds"`
+	HookRunSeconds   float64 `json:"hookRunSeconds"`
+	TotalSeconds     float64 `json:"totalSeconds"`
+}
+
+const (
+	selfTestStatusOk      = "Ok"
+	selfTestStatusFailed  = "Failed"
+	selfTestStatusTimeout = "Timeout"
+)
+
+// selfTestMetadata is a metadata of the SelfTest task. The result is sent
+// to the buffered channel, so the handler never blocks.
+type selfTestMetadata struct {
+	resultCh chan SelfTestResult
+}
+
+func (m selfTestMetadata) GetHookName() string {
+	return selfTestHookName
+}
+
+func (m selfTestMetadata) GetDescription() string {
+	return selfTestHookName
+}
+
+// selfTest runs a built-in no-op hook through the queue, the executor
+// and the output parsing to check the whole pipeline.
+type selfTest struct {
+	hook *hook.Hook
+	// running allows only one self-test at a time.
+	running sync.Mutex
+}
+
+func newSelfTest(tempDir string) (*selfTest, error) {
+	dir := filepath.Join(tempDir, "selftest")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create directory for self-test hook: %v", err)
+	}
+	path := filepath.Join(dir, "selftest.sh")
+	if err := os.WriteFile(path, []byte(selfTestHookScript), 0o755); err != nil {
+		return nil, fmt.Errorf("write self-test hook: %v", err)
+	}
+
+	h := hook.NewHook(selfTestHookName, path)
+	h.WithTmpDir(tempDir)
+	h.WithHookController(controller.NewHookController())
+	if _, err := h.LoadConfig([]byte(`{"configVersion":"v1","onStartup":1}`)); err != nil {
+		return nil, err
+	}
+	return &selfTest{hook: h}, nil
+}
+
+// registerSelfTestRoute serves /selftest. The request waits for the result
+// until the timeout from the 'timeout' query parameter.
+func registerSelfTestRoute(op *ShellOperator, tempDir string) {
+	st, err := newSelfTest(tempDir)
+	if err != nil {
+		log.Errorf("Self-test is disabled: %v", err)
+		return
+	}
+	op.selfTest = st
+
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/selftest", func(writer http.ResponseWriter, request *http.Request) {
+		queueName := request.URL.Query().Get("queue")
+		if queueName == "" {
+			queueName = "main"
+		}
+		timeout := selfTestDefaultTimeout
+		if v := request.URL.Query().Get("timeout"); v != "" {
+			d, err := time.ParseDuration(v)
+			timeout = d
+			if err != nil || timeout <= 0 || timeout > selfTestMaxTimeout {
+				http.Error(writer, fmt.Sprintf("timeout should be a duration up to %s", selfTestMaxTimeout), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if !st.running.TryLock() {
+			http.Error(writer, "self-test is already running", http.StatusTooManyRequests)
+			return
+		}
+		res := op.runSelfTest(queueName, timeout)
+		st.running.Unlock()
+
+		code := http.StatusOK
+		if res.Status != selfTestStatusOk {
+			code = http.StatusServiceUnavailable
+		}
+		data, _ := json.Marshal(res)
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(code)
+		_, _ = writer.Write(data)
+	})
+}
+
+// runSelfTest adds the SelfTest task to the end of the queue and waits for the result.
+func (op *ShellOperator) runSelfTest(queueName string, timeout time.Duration) SelfTestResult {
+	res := SelfTestResult{Queue: queueName}
+
+	var q *queue.TaskQueue
+	if op.TaskQueues != nil {
+		q = op.TaskQueues.GetByName(queueName)
+	}
+	if q == nil {
+		res.Status = selfTestStatusFailed
+		res.Error = fmt.Sprintf("queue '%s' is not found", queueName)
+		return res
+	}
+
+	start := time.Now()
+	meta := selfTestMetadata{resultCh: make(chan SelfTestResult, 1)}
+	t := task.NewTask(task_metadata.SelfTest).
+		WithQueueName(queueName).
+		WithMetadata(meta).
+		WithQueuedAt(start)
+	q.AddLast(t)
+
+	select {
+	case res = <-meta.resultCh:
+	case <-time.After(timeout):
+		// Remove the task, so it is not handled after the request is done.
+		q.Remove(t.GetId())
+		res.Queue = queueName
+		res.Status = selfTestStatusTimeout
+		res.Error = fmt.Sprintf("task is not handled in %s", timeout)
+	}
+	res.TotalSeconds = time.Since(start).Seconds()
+
+	op.MetricStorage.CounterAdd("{PREFIX}selftest_runs_total", 1.0, map[string]string{"status": res.Status})
+	op.MetricStorage.GaugeSet("{PREFIX}selftest_duration_seconds", res.TotalSeconds, map[string]string{})
+	return res
+}
+
+// taskHandleSelfTest runs the self-test hook. The task is never retried:
+// the failure is reported to the waiting request.
+func (op *ShellOperator) taskHandleSelfTest(t task.Task) queue.TaskResult {
+	meta, _ := t.GetMetadata().(selfTestMetadata)
+
+	res := SelfTestResult{
+		Queue:            t.GetQueueName(),
+		QueueWaitSeconds: time.Since(t.GetQueuedAt()).Seconds(),
+		Status:           selfTestStatusOk,
+	}
+	if q := op.TaskQueues.GetByName(t.GetQueueName()); q != nil {
+		res.QueueLength = q.Length()
+	}
+
+	if op.selfTest == nil {
+		res.Status = selfTestStatusFailed
+		res.Error = "self-test hook is not initialized"
+	} else {
+		bc := binding_context.BindingContext{Binding: string(types.OnStartup)}
+		bc.Metadata.BindingType = types.OnStartup
+
+		start := time.Now()
+		result, err := op.selfTest.hook.Run(types.OnStartup, []binding_context.BindingContext{bc}, map[string]string{"hook": selfTestHookName})
+		res.HookRunSeconds = time.Since(start).Seconds()
+		if err == nil && (result == nil || len(result.Metrics) != 1) {
+			err = fmt.Errorf("hook output is not parsed")
+		}
+		if err != nil {
+			res.Status = selfTestStatusFailed
+			res.Error = err.Error()
+		}
+	}
+
+	if meta.resultCh != nil {
+		select {
+		case meta.resultCh <- res:
+		default:
+		}
+	}
+	return queue.TaskResult{Status: queue.Success}
+}
@@ -0,0 +1,62 @@
+package shell_operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+	"github.com/flant/shell-operator/pkg/task"
+	"github.com/flant/shell-operator/pkg/task/queue"
+)
+
+func Test_TaskHandleSelfTest(t *testing.T) {
+	op := NewShellOperator(context.Background())
+	st, err := newSelfTest(t.TempDir())
+	require.NoError(t, err)
+	op.selfTest = st
+	op.TaskQueues = queue.NewTaskQueueSet()
+	op.TaskQueues.NewNamedQueue("main", op.taskHandler)
+
+	meta := selfTestMetadata{resultCh: make(chan SelfTestResult, 1)}
+	tsk := task.NewTask(task_metadata.SelfTest).
+		WithQueueName("main").
+		WithMetadata(meta).
+		WithQueuedAt(time.Now())
+
+	res := op.taskHandler(tsk)
+	assert.Equal(t, queue.Success, res.Status)
+
+	select {
+	case r := <-meta.resultCh:
+		assert.Equal(t, selfTestStatusOk, r.Status, r.Error)
+		assert.Equal(t, "main", r.Queue)
+		assert.Greater(t, r.HookRunSeconds, 0.0)
+	default:
+		t.Fatal("result should be sent")
+	}
+}
+
+func Test_RunSelfTest_NoQueue(t *testing.T) {
+	op := NewShellOperator(context.Background())
+
+	res := op.runSelfTest("absent", time.Second)
+	assert.Equal(t, selfTestStatusFailed, res.Status)
+	assert.Contains(t, res.Error, "absent")
+}
+
+func Test_RunSelfTest_Timeout(t *testing.T) {
+	op := NewShellOperator(context.Background())
+	op.MetricStorage = metric_storage.NewMetricStorage(context.Background(), "test_", true)
+	op.TaskQueues = queue.NewTaskQueueSet()
+	// The queue is not started, so the task is never handled.
+	op.TaskQueues.NewNamedQueue("main", op.taskHandler)
+
+	res := op.runSelfTest("main", 10*time.Millisecond)
+	assert.Equal(t, selfTestStatusTimeout, res.Status)
+	assert.Equal(t, 0, op.TaskQueues.GetByName("main").Length(), "task should be removed on timeout")
+}
@@ -0,0 +1,227 @@
+package shell_operator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+	utils "github.com/flant/shell-operator/pkg/utils/labels"
+)
+
+// shadowDiffsPerHook is a number of last shadow runs to keep for each hook.
+const shadowDiffsPerHook = 10
+
+const (
+	shadowResultMatch    = "match"
+	shadowResultMismatch = "mismatch"
+	shadowResultError    = "error"
+	// shadowResultSkipped is counted when the previous shadow run is not finished.
+	shadowResultSkipped = "skipped"
+)
+
+// shadowKubeconfigFileName is a kubeconfig without access to the cluster for shadow hooks.
+const shadowKubeconfigFileName = "shadow-kubeconfig"
+
+// ShadowDiff is a comparison of Kubernetes patches from the primary hook and its shadow.
+// Operations are canonical JSON lines, see object_patch.NormalizeOperations.
+type ShadowDiff struct {
+	Time    time.Time `json:"time"`
+	Binding string    `json:"binding"`
+	Result  string    `json:"result"`
+	Error   string    `json:"error,omitempty"`
+	// OnlyPrimary are operations returned only by the primary hook.
+	OnlyPrimary []string `json:"onlyPrimary,omitempty"`
+	// OnlyShadow are operations returned only by the shadow hook.
+	OnlyShadow []string `json:"onlyShadow,omitempty"`
+}
+
+// shadowHooks runs hooks from the shadow directory with binding contexts
+// of primary hooks. Shadow hooks run in background after primary runs, outputs
+// are never applied and the cluster is not accessible.
+type shadowHooks struct {
+	dir           string
+	metricStorage *metric_storage.MetricStorage
+
+	mu sync.Mutex
+	// hooks are shadow hooks by names of primary hooks. The value is nil if there is no shadow.
+	hooks map[string]*hook.Hook
+	// running has names of hooks with the shadow run in progress.
+	running map[string]bool
+	diffs   map[string][]ShadowDiff
+}
+
+func newShadowHooks(dir string, metricStorage *metric_storage.MetricStorage) (*shadowHooks, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(absDir); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("shadow hooks directory '%s' is not found", dir)
+	}
+	return &shadowHooks{
+		dir:           absDir,
+		metricStorage: metricStorage,
+		hooks:         make(map[string]*hook.Hook),
+		running:       make(map[string]bool),
+		diffs:         make(map[string][]ShadowDiff),
+	}, nil
+}
+
+// shadowFor returns a shadow of the primary hook. The shadow is an executable
+// with the same relative path in the shadow directory. It shares the config
+// with the primary hook and gets a kubeconfig without access to the cluster.
+// Command hooks have no shadows.
+func (s *shadowHooks) shadowFor(primary *hook.Hook) *hook.Hook {
+	if h, ok := s.hooks[primary.Name]; ok {
+		return h
+	}
+
+	var shadow *hook.Hook
+	shadowPath := filepath.Join(s.dir, primary.Name)
+	if fi, err := os.Stat(shadowPath); err == nil && !fi.IsDir() && !primary.IsCommandHook() {
+		kubeconfigPath, err := hook.GenerateNoopKubeconfig(filepath.Join(primary.TmpDir, shadowKubeconfigFileName))
+		if err != nil {
+			log.Errorf("Shadow hook for '%s' is disabled: %v", primary.Name, err)
+			s.hooks[primary.Name] = nil
+			return nil
+		}
+		shadow = hook.NewHook(primary.Name, shadowPath)
+		shadow.Config = primary.Config
+		shadow.HookController = primary.HookController
+		shadow.WithTmpDir(primary.TmpDir)
+		shadow.WithKubeconfig(kubeconfigPath)
+		shadow.WithCluster(primary.Cluster)
+		shadow.ValuesPath = primary.ValuesPath
+		shadow.Shadow = true
+		log.Infof("Shadow hook for '%s' is found in '%s'", primary.Name, shadowPath)
+	}
+	s.hooks[primary.Name] = shadow
+	return shadow
+}
+
+// Run starts the shadow of the primary hook in background if it is not running already.
+// It should be called after the primary run is finished. The shadow gets binding contexts
+// with snapshots of the primary run. primaryResult is the result of the successful primary run.
+func (s *shadowHooks) Run(primary *hook.Hook, hookMeta task_metadata.HookMetadata, primaryResult *hook.Result, logLabels map[string]string) {
+	if s == nil || primaryResult == nil {
+		return
+	}
+
+	s.mu.Lock()
+	shadow := s.shadowFor(primary)
+	if shadow == nil {
+		s.mu.Unlock()
+		return
+	}
+	if s.running[primary.Name] {
+		s.mu.Unlock()
+		log.WithFields(utils.LabelsToLogFields(logLabels)).
+			Infof("Shadow hook for '%s' is still running, skip this run", primary.Name)
+		s.metricStorage.CounterAdd("{PREFIX}shadow_hook_runs_total", 1.0, map[string]string{"hook": primary.Name, "result": shadowResultSkipped})
+		return
+	}
+	s.running[primary.Name] = true
+	s.mu.Unlock()
+
+	shadowLabels := utils.MergeLabels(logLabels, map[string]string{"shadow": "true"})
+	bindingContexts := primaryResult.BindingContexts
+	primaryPatch := primaryResult.KubernetesPatchBytes
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, primary.Name)
+			s.mu.Unlock()
+		}()
+
+		diff := ShadowDiff{
+			Time:    time.Now(),
+			Binding: hookMeta.Binding,
+		}
+		result, err := shadow.Run(hookMeta.BindingType, bindingContexts, shadowLabels)
+		if err == nil {
+			diff.OnlyPrimary, diff.OnlyShadow, err = diffPatches(primaryPatch, result.KubernetesPatchBytes)
+		}
+
+		logEntry := log.WithFields(utils.LabelsToLogFields(shadowLabels))
+		switch {
+		case err != nil:
+			diff.Result = shadowResultError
+			diff.Error = err.Error()
+			logEntry.Warnf("Shadow hook failed: %v", err)
+		case len(diff.OnlyPrimary) > 0 || len(diff.OnlyShadow) > 0:
+			diff.Result = shadowResultMismatch
+			logEntry.Warnf("Shadow hook patches differ: only primary: %v, only shadow: %v", diff.OnlyPrimary, diff.OnlyShadow)
+		default:
+			diff.Result = shadowResultMatch
+			logEntry.Debugf("Shadow hook patches match")
+		}
+
+		s.metricStorage.CounterAdd("{PREFIX}shadow_hook_runs_total", 1.0, map[string]string{"hook": primary.Name, "result": diff.Result})
+		s.record(primary.Name, diff)
+	}()
+}
+
+func (s *shadowHooks) record(hookName string, diff ShadowDiff) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	diffs := append(s.diffs[hookName], diff)
+	if len(diffs) > shadowDiffsPerHook {
+		diffs = diffs[len(diffs)-shadowDiffsPerHook:]
+	}
+	s.diffs[hookName] = diffs
+}
+
+// Diffs returns last shadow runs of the hook, the newest first.
+func (s *shadowHooks) Diffs(hookName string) []ShadowDiff {
+	res := make([]ShadowDiff, 0)
+	if s == nil {
+		return res
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	diffs := s.diffs[hookName]
+	for i := len(diffs) - 1; i >= 0; i-- {
+		res = append(res, diffs[i])
+	}
+	return res
+}
+
+// diffPatches compares normalized operations of two Kubernetes patch outputs.
+func diffPatches(primary, shadow []byte) (onlyPrimary []string, onlyShadow []string, err error) {
+	primaryOps, err := object_patch.NormalizeOperations(primary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse primary patch: %v", err)
+	}
+	shadowOps, err := object_patch.NormalizeOperations(shadow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse shadow patch: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, op := range shadowOps {
+		counts[op]++
+	}
+	for _, op := range primaryOps {
+		if counts[op] > 0 {
+			counts[op]--
+			continue
+		}
+		onlyPrimary = append(onlyPrimary, op)
+	}
+	for _, op := range shadowOps {
+		if counts[op] > 0 {
+			counts[op]--
+			onlyShadow = append(onlyShadow, op)
+		}
+	}
+	return onlyPrimary, onlyShadow, nil
+}
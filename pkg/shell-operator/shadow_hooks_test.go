@@ -0,0 +1,101 @@
+package shell_operator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/hook/types"
+)
+
+func Test_diffPatches(t *testing.T) {
+	primary := []byte(`{"operation":"Delete","kind":"ConfigMap","namespace":"default","name":"a"}
+{"operation":"MergePatch","kind":"ConfigMap","namespace":"default","name":"b","mergePatch":{"data":{"x":"1","y":"2"}}}
+`)
+
+	// The same operations in another order and in YAML.
+	shadow := []byte(`
+operation: MergePatch
+kind: ConfigMap
+namespace: default
+name: b
+mergePatch:
+  data:
+    y: "2"
+    x: "1"
+---
+operation: Delete
+kind: ConfigMap
+namespace: default
+name: a
+`)
+	onlyPrimary, onlyShadow, err := diffPatches(primary, shadow)
+	require.NoError(t, err)
+	assert.Empty(t, onlyPrimary)
+	assert.Empty(t, onlyShadow)
+
+	shadow = []byte(`{"operation":"Delete","kind":"ConfigMap","namespace":"default","name":"c"}
+{"operation":"MergePatch","kind":"ConfigMap","namespace":"default","name":"b","mergePatch":{"data":{"x":"1","y":"2"}}}
+`)
+	onlyPrimary, onlyShadow, err = diffPatches(primary, shadow)
+	require.NoError(t, err)
+	require.Len(t, onlyPrimary, 1)
+	assert.Contains(t, onlyPrimary[0], `"name":"a"`)
+	require.Len(t, onlyShadow, 1)
+	assert.Contains(t, onlyShadow[0], `"name":"c"`)
+
+	onlyPrimary, onlyShadow, err = diffPatches(primary, nil)
+	require.NoError(t, err)
+	assert.Len(t, onlyPrimary, 2)
+	assert.Empty(t, onlyShadow)
+
+	_, _, err = diffPatches(primary, []byte("{not a patch"))
+	assert.Error(t, err)
+}
+
+func Test_shadowHooks_Run(t *testing.T) {
+	op := newRunHookOnceOperator(t)
+	primary := op.HookManager.GetHook("hook.sh")
+	require.NotNil(t, primary)
+
+	// The shadow checks that it has no access to the cluster and the input of the primary run.
+	shadowDir := t.TempDir()
+	script := `#!/usr/bin/env bash
+grep -q 'shadow.invalid' "$KUBECONFIG" || exit 1
+grep -q '"primary"' "$BINDING_CONTEXT_PATH" || exit 2
+sleep 0.3
+echo '{"operation":"Delete","kind":"ConfigMap","namespace":"default","name":"shadow"}' > $KUBERNETES_PATCH_PATH
+`
+	require.NoError(t, os.WriteFile(filepath.Join(shadowDir, "hook.sh"), []byte(script), 0o755))
+	shadows, err := newShadowHooks(shadowDir, nil)
+	require.NoError(t, err)
+
+	hookMeta := task_metadata.HookMetadata{
+		HookName:       "hook.sh",
+		Binding:        "onStartup",
+		BindingType:    types.OnStartup,
+		BindingContext: onStartupContext("stale"),
+	}
+	primaryResult := &hook.Result{
+		BindingContexts:      onStartupContext("primary"),
+		KubernetesPatchBytes: []byte(`{"operation":"Delete","kind":"ConfigMap","namespace":"default","name":"primary"}`),
+	}
+
+	shadows.Run(primary, hookMeta, primaryResult, nil)
+	// The previous shadow run is not finished, this run is skipped.
+	shadows.Run(primary, hookMeta, primaryResult, nil)
+
+	require.Eventually(t, func() bool { return len(shadows.Diffs("hook.sh")) > 0 }, 5*time.Second, 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	diffs := shadows.Diffs("hook.sh")
+	require.Len(t, diffs, 1)
+	assert.Equal(t, shadowResultMismatch, diffs[0].Result, diffs[0].Error)
+	assert.Len(t, diffs[0].OnlyPrimary, 1)
+	assert.Len(t, diffs[0].OnlyShadow, 1)
+}
@@ -0,0 +1,209 @@
+package shell_operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Startup phases in the order of execution.
+const (
+	StartupPhaseHookDiscovery = "HookDiscovery"
+	StartupPhaseWebhookSetup  = "WebhookSetup"
+	StartupPhaseOnStartup     = "OnStartup"
+	StartupPhaseMonitorSync   = "MonitorSynchronization"
+)
+
+const (
+	startupStatusPending    = "Pending"
+	startupStatusInProgress = "InProgress"
+	startupStatusDone       = "Done"
+	startupStatusFailed     = "Failed"
+
+	startupPhaseLogField       = "startup.phase"
+	startupPhaseStatusLogField = "startup.status"
+)
+
+// StartupPhaseStatus is a progress of one startup phase.
+type StartupPhaseStatus struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total,omitempty"`
+	Done       int        `json:"done,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// StartupProgress tracks startup phases. Progress is served on /startup
+// and each phase transition is logged with 'startup.phase' and 'startup.status' fields.
+// Methods are safe to call on a nil StartupProgress.
+type StartupProgress struct {
+	m         sync.Mutex
+	startedAt time.Time
+	phases    []*StartupPhaseStatus
+}
+
+func NewStartupProgress() *StartupProgress {
+	p := &StartupProgress{
+		startedAt: time.Now(),
+	}
+	for _, name := range []string{StartupPhaseHookDiscovery, StartupPhaseWebhookSetup, StartupPhaseOnStartup, StartupPhaseMonitorSync} {
+		p.phases = append(p.phases, &StartupPhaseStatus{Name: name, Status: startupStatusPending})
+	}
+	return p
+}
+
+// Begin marks the phase as started. Phase is done after total calls of Step
+// or when Finish is called.
+func (p *StartupProgress) Begin(name string, total int) {
+	if p == nil {
+		return
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	phase := p.phase(name)
+	now := time.Now()
+	phase.Status = startupStatusInProgress
+	phase.StartedAt = &now
+	phase.Total = total
+	phase.Done = 0
+	p.logPhase(phase).Infof("Startup phase %s started", name)
+}
+
+// Step increases a number of completed items in the phase.
+func (p *StartupProgress) Step(name string) {
+	if p == nil {
+		return
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	phase := p.phase(name)
+	if phase.Status != startupStatusInProgress {
+		return
+	}
+	phase.Done++
+	if phase.Total > 0 && phase.Done >= phase.Total {
+		p.finish(phase, startupStatusDone, "")
+	}
+}
+
+// Finish marks the phase as done.
+func (p *StartupProgress) Finish(name string) {
+	if p == nil {
+		return
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	phase := p.phase(name)
+	if phase.Status != startupStatusInProgress {
+		return
+	}
+	p.finish(phase, startupStatusDone, "")
+}
+
+// Fail marks the phase as failed.
+func (p *StartupProgress) Fail(name string, err error) {
+	if p == nil {
+		return
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.finish(p.phase(name), startupStatusFailed, err.Error())
+}
+
+// PhaseDone returns true if the phase is done.
+func (p *StartupProgress) PhaseDone(name string) bool {
+	if p == nil {
+		return false
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.phase(name).Status == startupStatusDone
+}
+
+// Ready returns true if all phases are done.
+func (p *StartupProgress) Ready() bool {
+	if p == nil {
+		return true
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, phase := range p.phases {
+		if phase.Status != startupStatusDone {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler serves the startup progress as JSON.
+func (p *StartupProgress) Handler(writer http.ResponseWriter, _ *http.Request) {
+	ready := p.Ready()
+
+	p.m.Lock()
+	resp := map[string]interface{}{
+		"ready":          ready,
+		"elapsedSeconds": time.Since(p.startedAt).Seconds(),
+		"phases":         p.phases,
+	}
+	data, err := json.Marshal(resp)
+	p.m.Unlock()
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(data)
+}
+
+func (p *StartupProgress) finish(phase *StartupPhaseStatus, status string, message string) {
+	now := time.Now()
+	phase.Status = status
+	phase.Message = message
+	phase.FinishedAt = &now
+
+	logEntry := p.logPhase(phase)
+	if phase.StartedAt != nil {
+		logEntry = logEntry.WithField("duration", now.Sub(*phase.StartedAt).String())
+	}
+	if status == startupStatusFailed {
+		logEntry.Errorf("Startup phase %s failed: %s", phase.Name, message)
+		return
+	}
+	logEntry.Infof("Startup phase %s done", phase.Name)
+
+	for _, phase := range p.phases {
+		if phase.Status != startupStatusDone {
+			return
+		}
+	}
+	log.WithField(startupPhaseStatusLogField, startupStatusDone).
+		Infof("Startup is done in %s", now.Sub(p.startedAt).String())
+}
+
+func (p *StartupProgress) phase(name string) *StartupPhaseStatus {
+	for _, phase := range p.phases {
+		if phase.Name == name {
+			return phase
+		}
+	}
+	phase := &StartupPhaseStatus{Name: name, Status: startupStatusPending}
+	p.phases = append(p.phases, phase)
+	return phase
+}
+
+func (p *StartupProgress) logPhase(phase *StartupPhaseStatus) *log.Entry {
+	return log.WithField(startupPhaseLogField, phase.Name).
+		WithField(startupPhaseStatusLogField, phase.Status)
+}
@@ -0,0 +1,59 @@
+package shell_operator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StartupProgress(t *testing.T) {
+	p := NewStartupProgress()
+	assert.False(t, p.Ready())
+
+	p.Begin(StartupPhaseHookDiscovery, 0)
+	p.Finish(StartupPhaseHookDiscovery)
+	p.Begin(StartupPhaseWebhookSetup, 0)
+	p.Finish(StartupPhaseWebhookSetup)
+	p.Begin(StartupPhaseOnStartup, 2)
+	p.Step(StartupPhaseOnStartup)
+	p.Begin(StartupPhaseMonitorSync, 1)
+	assert.False(t, p.PhaseDone(StartupPhaseMonitorSync))
+	p.Step(StartupPhaseMonitorSync)
+	assert.True(t, p.PhaseDone(StartupPhaseMonitorSync))
+	assert.False(t, p.Ready())
+
+	p.Step(StartupPhaseOnStartup)
+	assert.True(t, p.Ready())
+
+	rec := httptest.NewRecorder()
+	p.Handler(rec, httptest.NewRequest(http.MethodGet, "/startup", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Ready  bool                 `json:"ready"`
+		Phases []StartupPhaseStatus `json:"phases"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Ready)
+	require.Len(t, resp.Phases, 4)
+	assert.Equal(t, StartupPhaseOnStartup, resp.Phases[2].Name)
+	assert.Equal(t, 2, resp.Phases[2].Done)
+}
+
+func Test_StartupProgress_Fail(t *testing.T) {
+	p := NewStartupProgress()
+	p.Begin(StartupPhaseHookDiscovery, 0)
+	p.Fail(StartupPhaseHookDiscovery, errors.New("bad config"))
+	assert.False(t, p.Ready())
+
+	// Methods are no-op for nil progress.
+	var nilProgress *StartupProgress
+	nilProgress.Begin(StartupPhaseOnStartup, 1)
+	nilProgress.Step(StartupPhaseOnStartup)
+	assert.True(t, nilProgress.Ready())
+}
@@ -0,0 +1,78 @@
+package shell_operator
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/debug"
+	"github.com/flant/shell-operator/pkg/kube/api_warnings"
+	"github.com/flant/shell-operator/pkg/kube_events_manager"
+	"github.com/flant/shell-operator/pkg/task/dump"
+)
+
+// DumpState collects the in-memory state of the operator: queues, hook statuses with
+// schedules, snapshots, event histories and last runs of hooks. The archive can be
+// loaded with 'shell-operator state load' to analyze an incident offline.
+func (op *ShellOperator) DumpState() (*debug.StateArchive, error) {
+	now := time.Now()
+	a := debug.NewStateArchive()
+
+	info := debug.StateInfo{
+		Version:     app.Version,
+		GeneratedAt: now,
+		Hooks:       make([]string, 0),
+	}
+	info.Hostname, _ = os.Hostname()
+	if op.HookManager != nil {
+		info.Hooks = op.HookManager.GetHookNames()
+	}
+
+	files := map[string]interface{}{
+		debug.StateInfoFile:     info,
+		debug.StateStatusFile:   op.status(now),
+		debug.StateEventBusFile: kube_events_manager.EventBusStatsDump(),
+		debug.StateWarningsFile: api_warnings.DefaultHandler.List(),
+	}
+	if op.TaskQueues != nil {
+		files[debug.StateQueuesFile] = dump.TaskQueues(op.TaskQueues, "json", true)
+		files[debug.StateMainQueueFile] = dump.TaskMainQueue(op.TaskQueues, "json")
+		a.Add(debug.StateQueuesText, []byte(dump.TaskQueues(op.TaskQueues, "text", true).(string)))
+		a.Add(debug.StateMainQueueText, []byte(dump.TaskMainQueue(op.TaskQueues, "text").(string)))
+	}
+	for _, hookName := range info.Hooks {
+		h := op.HookManager.GetHook(hookName)
+		files[debug.StateHookFile(hookName, "runs")] = h.Runs()
+		if h.HookController != nil {
+			files[debug.StateHookFile(hookName, "snapshots")] = h.HookController.SnapshotsDump()
+			files[debug.StateHookFile(hookName, "history")] = h.HookController.EventHistoryDump()
+		}
+	}
+
+	for name, v := range files {
+		if err := a.AddJSON(name, v); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// RegisterDebugStateRoutes registers a route to download the state archive.
+func (op *ShellOperator) RegisterDebugStateRoutes(dbgSrv *debug.Server) {
+	dbgSrv.Router.Get("/state/dump", func(writer http.ResponseWriter, _ *http.Request) {
+		a, err := op.DumpState()
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/gzip")
+		writer.Header().Set("Content-Disposition", `attachment; filename="shell-operator-state.tar.gz"`)
+		writer.WriteHeader(http.StatusOK)
+		if err := a.Write(writer); err != nil {
+			log.Errorf("Write state archive: %v", err)
+		}
+	})
+}
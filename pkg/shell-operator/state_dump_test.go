@@ -0,0 +1,44 @@
+package shell_operator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/debug"
+	"github.com/flant/shell-operator/pkg/task/queue"
+)
+
+func Test_DumpState(t *testing.T) {
+	op := newRunHookOnceOperator(t)
+	op.TaskQueues = queue.NewTaskQueueSet()
+	op.TaskQueues.NewNamedQueue("main", nil)
+
+	a, err := op.DumpState()
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, a.Write(buf))
+	loaded, err := debug.ReadStateArchive(buf)
+	require.NoError(t, err)
+
+	info, err := loaded.Info()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hook.sh"}, info.Hooks)
+
+	for _, name := range []string{
+		debug.StateStatusFile,
+		debug.StateQueuesFile,
+		debug.StateQueuesText,
+		debug.StateMainQueueFile,
+		debug.StateHookFile("hook.sh", "runs"),
+		debug.StateHookFile("hook.sh", "snapshots"),
+	} {
+		_, has := loaded.File(name)
+		assert.True(t, has, name)
+	}
+	queues, _ := loaded.File(debug.StateMainQueueText)
+	assert.Contains(t, string(queues), "Queue 'main'")
+}
@@ -0,0 +1,201 @@
+package shell_operator
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/robfig/cron.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flant/shell-operator/pkg/hook"
+	"github.com/flant/shell-operator/pkg/kube_events_manager"
+	"github.com/flant/shell-operator/pkg/task"
+	"github.com/flant/shell-operator/pkg/task/queue"
+)
+
+// OperatorStatus is a state of queues and hooks served on /status.
+type OperatorStatus struct {
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Queues      []QueueStatus `json:"queues"`
+	Hooks       []HookStatus  `json:"hooks"`
+}
+
+type QueueStatus struct {
+	Name   string   `json:"name"`
+	Status string   `json:"status,omitempty"`
+	Length int      `json:"length"`
+	Tasks  []string `json:"tasks,omitempty"`
+}
+
+type HookStatus struct {
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// QuarantinedUntil is set if the error budget of the hook is exhausted.
+	QuarantinedUntil *time.Time `json:"quarantinedUntil,omitempty"`
+	// Degraded is set if the last self-check of the hook is failed.
+	Degraded  bool              `json:"degraded,omitempty"`
+	LastCheck *hook.CheckStatus `json:"lastCheck,omitempty"`
+	LastRun   *hook.RunStatus   `json:"lastRun,omitempty"`
+	Schedules []ScheduleStatus  `json:"schedules,omitempty"`
+	// NotReadyBindings are 'kubernetes' bindings with monitors waiting for RBAC permissions.
+	NotReadyBindings []kube_events_manager.MonitorCondition `json:"notReadyBindings,omitempty"`
+}
+
+type ScheduleStatus struct {
+	Binding string    `json:"binding"`
+	Crontab string    `json:"crontab"`
+	NextRun time.Time `json:"nextRun"`
+}
+
+// statusPageMaxTasks limits the number of tasks shown for each queue.
+const statusPageMaxTasks = 50
+
+func (op *ShellOperator) status(now time.Time) OperatorStatus {
+	st := OperatorStatus{
+		GeneratedAt: now,
+		Queues:      make([]QueueStatus, 0),
+		Hooks:       make([]HookStatus, 0),
+	}
+
+	if op.TaskQueues != nil {
+		op.TaskQueues.Iterate(func(q *queue.TaskQueue) {
+			if q == nil {
+				return
+			}
+			qs := QueueStatus{
+				Name:   q.Name,
+				Status: q.Status,
+				Length: q.Length(),
+			}
+			q.Iterate(func(t task.Task) {
+				if len(qs.Tasks) < statusPageMaxTasks {
+					qs.Tasks = append(qs.Tasks, t.GetDescription())
+				}
+			})
+			st.Queues = append(st.Queues, qs)
+		})
+		sort.Slice(st.Queues, func(i, j int) bool {
+			return st.Queues[i].Name < st.Queues[j].Name
+		})
+	}
+
+	if op.HookManager != nil {
+		for _, hookName := range op.HookManager.GetHookNames() {
+			h := op.HookManager.GetHook(hookName)
+			hs := HookStatus{
+				Name:     hookName,
+				Disabled: h.IsPaused(),
+				LastRun:  h.LastRun(),
+			}
+			if check := h.LastCheck(); check != nil {
+				hs.Degraded = check.Degraded
+				hs.LastCheck = check
+			}
+			if until := h.QuarantinedUntil(); !until.IsZero() {
+				hs.QuarantinedUntil = &until
+			}
+			if op.KubeEventsManager != nil {
+				for _, kubeCfg := range h.Config.OnKubernetesEvents {
+					cond := op.KubeEventsManager.MonitorCondition(kubeCfg.Monitor.Metadata.MonitorId)
+					if cond != nil && cond.Status != metav1.ConditionTrue {
+						hs.NotReadyBindings = append(hs.NotReadyBindings, *cond)
+					}
+				}
+			}
+			for _, schCfg := range h.Config.Schedules {
+				sched, err := cron.Parse(schCfg.ScheduleEntry.Crontab)
+				if err != nil {
+					continue
+				}
+				hs.Schedules = append(hs.Schedules, ScheduleStatus{
+					Binding: schCfg.BindingName,
+					Crontab: schCfg.ScheduleEntry.Crontab,
+					NextRun: sched.Next(now),
+				})
+			}
+			st.Hooks = append(st.Hooks, hs)
+		}
+	}
+
+	return st
+}
+
+var statusPageTemplate = template.Must(template.New("status").Parse(`<html>
+  <head><title>Shell operator status</title></head>
+  <body>
+    <h1>Shell operator status</h1>
+    <p>Generated at {{ .GeneratedAt.Format "2006-01-02T15:04:05Z07:00" }}. JSON is available on <a href="status.json">/status.json</a>.</p>
+    <h2>Queues</h2>
+    <table border="1" cellpadding="4">
+      <tr><th>Queue</th><th>Length</th><th>Status</th><th>Tasks</th></tr>
+      {{- range .Queues }}
+      <tr><td>{{ .Name }}</td><td>{{ .Length }}</td><td>{{ .Status }}</td><td>{{ range .Tasks }}{{ . }}<br>{{ end }}</td></tr>
+      {{- end }}
+    </table>
+    <h2>Hooks</h2>
+    <table border="1" cellpadding="4">
+      <tr><th>Hook</th><th>Last run</th><th>Duration</th><th>Result</th><th>Next schedule runs</th></tr>
+      {{- range .Hooks }}
+      <tr>
+        <td>{{ .Name }}{{ if .Disabled }} (disabled){{ end }}{{ if .QuarantinedUntil }} (quarantined until {{ .QuarantinedUntil.Format "2006-01-02T15:04:05Z07:00" }}){{ end }}{{ if .Degraded }} (degraded: {{ .LastCheck.Error }}){{ end }}{{ range .NotReadyBindings }}<br>binding '{{ .Binding }}' is not ready: {{ .Reason }}, {{ .Retries }} retries{{ end }}</td>
+        {{- if .LastRun }}
+        <td>{{ .LastRun.StartedAt.Format "2006-01-02T15:04:05Z07:00" }} {{ .LastRun.BindingType }} '{{ .LastRun.Binding }}'</td>
+        <td>{{ .LastRun.Duration }}</td>
+        <td>{{ if .LastRun.Error }}Error: {{ .LastRun.Error }}{{ else }}Success{{ end }}</td>
+        {{- else }}
+        <td>-</td><td>-</td><td>-</td>
+        {{- end }}
+        <td>{{ range .Schedules }}'{{ .Binding }}' ({{ .Crontab }}): {{ .NextRun.Format "2006-01-02T15:04:05Z07:00" }}<br>{{ end }}</td>
+      </tr>
+      {{- end }}
+    </table>
+  </body>
+</html>`))
+
+// registerStatusRoutes registers the status page with queues, hooks last results
+// and next schedule runs. Routes are protected with the basic auth if credentials are set.
+func registerStatusRoutes(op *ShellOperator, basicAuth string) {
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/status", withBasicAuth(basicAuth, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := statusPageTemplate.Execute(writer, op.status(time.Now()))
+		if err != nil {
+			log.Errorf("Render status page: %v", err)
+		}
+	}))
+
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/status.json", withBasicAuth(basicAuth, func(writer http.ResponseWriter, request *http.Request) {
+		data, err := json.Marshal(op.status(time.Now()))
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write(data)
+	}))
+}
+
+// withBasicAuth checks credentials in "user:password" form. Empty credentials disable the check.
+func withBasicAuth(credentials string, h http.HandlerFunc) http.HandlerFunc {
+	if credentials == "" {
+		return h
+	}
+	expectedUser, expectedPassword, _ := strings.Cut(credentials, ":")
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		user, password, ok := request.BasicAuth()
+		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(expectedUser)) == 1
+		passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(expectedPassword)) == 1
+		if !ok || !userMatch || !passwordMatch {
+			writer.Header().Set("WWW-Authenticate", `Basic realm="shell-operator"`)
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h(writer, request)
+	}
+}
@@ -0,0 +1,73 @@
+package shell_operator
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/hook"
+)
+
+func Test_WithBasicAuth(t *testing.T) {
+	ok := func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}
+	handler := withBasicAuth("admin:secret", ok)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.SetBasicAuth("admin", "wrong")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// No credentials, no auth.
+	rec = httptest.NewRecorder()
+	withBasicAuth("", ok)(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func Test_StatusPageTemplate(t *testing.T) {
+	now := time.Now()
+	st := OperatorStatus{
+		GeneratedAt: now,
+		Queues: []QueueStatus{
+			{Name: "main", Length: 1, Tasks: []string{"HookRun:main:hook.sh"}},
+		},
+		Hooks: []HookStatus{
+			{
+				Name:    "hook.sh",
+				LastRun: &hook.RunStatus{Binding: "pods", BindingType: "kubernetes", StartedAt: now, Duration: "1s", Error: "<exit 1>"},
+				Schedules: []ScheduleStatus{
+					{Binding: "every-minute", Crontab: "* * * * *", NextRun: now.Add(time.Minute)},
+				},
+			},
+			{Name: "never-run.sh"},
+			{Name: "degraded.sh", Degraded: true, LastCheck: &hook.CheckStatus{Degraded: true, Error: "jq not found"}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, statusPageTemplate.Execute(buf, st))
+	page := buf.String()
+	assert.Contains(t, page, "HookRun:main:hook.sh")
+	assert.Contains(t, page, "Error: &lt;exit 1&gt;")
+	assert.Contains(t, page, "'every-minute' (* * * * *)")
+	assert.Contains(t, page, "never-run.sh")
+	assert.Contains(t, page, "degraded.sh (degraded: jq not found)")
+}
@@ -0,0 +1,136 @@
+package shell_operator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/task"
+	"github.com/flant/shell-operator/pkg/task/queue"
+	"github.com/flant/shell-operator/pkg/utils/measure"
+)
+
+// TaskHandler handles tasks of a custom type. Programs that embed ShellOperator
+// register handlers to put their own tasks into the same queues with hook tasks.
+type TaskHandler struct {
+	Handle func(t task.Task) queue.TaskResult
+
+	// MaxRetries is a number of retries for a failed task. The task is dropped
+	// from the queue after the last retry. Zero means retry until success,
+	// as for hook tasks.
+	MaxRetries int
+	// RetryDelay returns a delay before the next retry. The exponential backoff
+	// of the queue is used if nil.
+	RetryDelay func(failureCount int) time.Duration
+
+	// MetricLabels are added to metrics of the task type. All handlers
+	// should have the same label names.
+	MetricLabels map[string]string
+}
+
+func isBuiltinTaskType(taskType task.TaskType) bool {
+	switch taskType {
+	case task_metadata.HookRun, task_metadata.EnableKubernetesBindings, task_metadata.EnableScheduleBindings, task_metadata.EnableTriggerBindings, task_metadata.SelfTest:
+		return true
+	}
+	return false
+}
+
+// RegisterTaskHandler registers a handler for tasks of a custom type.
+// It should be called before Start.
+func (op *ShellOperator) RegisterTaskHandler(taskType task.TaskType, handler TaskHandler) error {
+	if handler.Handle == nil {
+		return fmt.Errorf("register task handler '%s': Handle is required", taskType)
+	}
+	if isBuiltinTaskType(taskType) {
+		return fmt.Errorf("register task handler '%s': task type is built-in", taskType)
+	}
+
+	op.taskHandlersLock.Lock()
+	defer op.taskHandlersLock.Unlock()
+
+	if _, has := op.taskHandlers[taskType]; has {
+		return fmt.Errorf("register task handler '%s': handler is already registered", taskType)
+	}
+	for otherType, other := range op.taskHandlers {
+		if !sameLabelNames(other.MetricLabels, handler.MetricLabels) {
+			return fmt.Errorf("register task handler '%s': metric labels %v differ from labels %v of '%s'",
+				taskType, labelNames(handler.MetricLabels), labelNames(other.MetricLabels), otherType)
+		}
+	}
+
+	if op.taskHandlers == nil {
+		op.taskHandlers = make(map[task.TaskType]TaskHandler)
+	}
+	op.taskHandlers[taskType] = handler
+	return nil
+}
+
+func (op *ShellOperator) getTaskHandler(taskType task.TaskType) (TaskHandler, bool) {
+	op.taskHandlersLock.RLock()
+	defer op.taskHandlersLock.RUnlock()
+	handler, has := op.taskHandlers[taskType]
+	return handler, has
+}
+
+// taskHandleCustom runs a registered handler and applies its retry policy.
+func (op *ShellOperator) taskHandleCustom(t task.Task, handler TaskHandler) queue.TaskResult {
+	taskLogEntry := log.WithField("operator.component", "taskRunner").
+		WithField("task", string(t.GetType())).
+		WithField("queue", t.GetQueueName())
+
+	metricLabels := map[string]string{
+		"task_type": string(t.GetType()),
+		"queue":     t.GetQueueName(),
+	}
+	for k, v := range handler.MetricLabels {
+		metricLabels[k] = v
+	}
+
+	var res queue.TaskResult
+	func() {
+		defer measure.Duration(func(d time.Duration) {
+			op.MetricStorage.HistogramObserve("{PREFIX}custom_task_run_seconds", d.Seconds(), metricLabels, nil)
+		})()
+		res = handler.Handle(t)
+	}()
+
+	if res.Status == queue.Fail {
+		op.MetricStorage.CounterAdd("{PREFIX}custom_task_errors_total", 1.0, metricLabels)
+		// FailureCount is incremented by the queue after the handler returns.
+		if handler.MaxRetries > 0 && t.GetFailureCount() >= handler.MaxRetries {
+			taskLogEntry.Errorf("Drop task after %d retries: %s", t.GetFailureCount(), t.GetFailureMessage())
+			op.MetricStorage.CounterAdd("{PREFIX}custom_task_dropped_total", 1.0, metricLabels)
+			res.Status = queue.Success
+			return res
+		}
+		if handler.RetryDelay != nil && res.DelayBeforeNextTask == 0 {
+			res.DelayBeforeNextTask = handler.RetryDelay(t.GetFailureCount())
+		}
+	}
+	return res
+}
+
+func sameLabelNames(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if _, has := b[k]; !has {
+			return false
+		}
+	}
+	return true
+}
+
+func labelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
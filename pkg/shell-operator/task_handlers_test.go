@@ -0,0 +1,59 @@
+package shell_operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/hook/task_metadata"
+	"github.com/flant/shell-operator/pkg/task"
+	"github.com/flant/shell-operator/pkg/task/queue"
+)
+
+const testTaskType task.TaskType = "TestTask"
+
+func Test_RegisterTaskHandler(t *testing.T) {
+	op := NewShellOperator(context.Background())
+	handle := func(t task.Task) queue.TaskResult { return queue.TaskResult{Status: queue.Success} }
+
+	require.NoError(t, op.RegisterTaskHandler(testTaskType, TaskHandler{Handle: handle, MetricLabels: map[string]string{"module": "a"}}))
+
+	assert.Error(t, op.RegisterTaskHandler(testTaskType, TaskHandler{Handle: handle}), "should not register twice")
+	assert.Error(t, op.RegisterTaskHandler(task_metadata.HookRun, TaskHandler{Handle: handle}), "should not override built-in task")
+	assert.Error(t, op.RegisterTaskHandler("Other", TaskHandler{}), "should require Handle")
+	assert.Error(t, op.RegisterTaskHandler("Other", TaskHandler{Handle: handle, MetricLabels: map[string]string{"kind": "b"}}), "should require the same label names")
+	assert.NoError(t, op.RegisterTaskHandler("Other", TaskHandler{Handle: handle, MetricLabels: map[string]string{"module": "b"}}))
+}
+
+func Test_TaskHandler_Retries(t *testing.T) {
+	op := NewShellOperator(context.Background())
+	calls := 0
+	require.NoError(t, op.RegisterTaskHandler(testTaskType, TaskHandler{
+		Handle: func(t task.Task) queue.TaskResult {
+			calls++
+			return queue.TaskResult{Status: queue.Fail}
+		},
+		MaxRetries: 2,
+		RetryDelay: func(failureCount int) time.Duration { return time.Duration(failureCount+1) * time.Second },
+	}))
+
+	tsk := task.NewTask(testTaskType)
+
+	res := op.taskHandler(tsk)
+	assert.Equal(t, queue.Fail, res.Status)
+	assert.Equal(t, time.Second, res.DelayBeforeNextTask)
+	tsk.IncrementFailureCount()
+
+	res = op.taskHandler(tsk)
+	assert.Equal(t, queue.Fail, res.Status)
+	assert.Equal(t, 2*time.Second, res.DelayBeforeNextTask)
+	tsk.IncrementFailureCount()
+
+	// Task is dropped after the last retry.
+	res = op.taskHandler(tsk)
+	assert.Equal(t, queue.Success, res.Status)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+
+if [[ $1 == "--config" ]] ; then
+cat <<EOF2
+configVersion: v1
+onStartup: 1
+EOF2
+exit 0
+fi
+
+if grep -q '"fail"' $BINDING_CONTEXT_PATH ; then
+  echo "failed on purpose" >&2
+  exit 3
+fi
+
+echo '{"name":"hook_once","action":"set","value":1}' > $METRICS_PATH
+cat > $KUBERNETES_PATCH_PATH <<EOF2
+{"operation":"CreateOrUpdate","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"hook-once","namespace":"default"}}}
+EOF2
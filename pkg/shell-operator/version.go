@@ -0,0 +1,32 @@
+package shell_operator
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/flant/shell-operator/pkg/app"
+	"github.com/flant/shell-operator/pkg/metric_storage"
+)
+
+// registerBuildInfoMetric reports the version of the binary as labels of a constant gauge.
+func registerBuildInfoMetric(metricStorage *metric_storage.MetricStorage, info app.BuildInfo) {
+	metricStorage.GaugeSet("{PREFIX}build_info", 1.0, map[string]string{
+		"version":    info.Version,
+		"git_commit": info.GitCommit,
+		"go_version": info.GoVersion,
+		"build_date": info.BuildDate,
+	})
+}
+
+// registerVersionRoute serves the version of the binary in JSON.
+func registerVersionRoute(op *ShellOperator, info app.BuildInfo) {
+	op.APIServer.RegisterAPIRoute(http.MethodGet, "/version", func(writer http.ResponseWriter, request *http.Request) {
+		data, err := json.Marshal(info)
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write(data)
+	})
+}
@@ -0,0 +1,27 @@
+package shell_operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flant/shell-operator/pkg/app"
+)
+
+func Test_registerVersionRoute(t *testing.T) {
+	op := &ShellOperator{APIServer: newBaseHTTPServer("127.0.0.1", "0")}
+	info := app.BuildInfo{Version: "v1.2.3", GitCommit: "abcdef", GoVersion: "go1.22", BuildDate: "2024-01-01T00:00:00Z"}
+	registerVersionRoute(op, info)
+
+	rec := httptest.NewRecorder()
+	op.APIServer.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got app.BuildInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, info, got)
+}
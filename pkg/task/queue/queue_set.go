@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,12 @@ type TaskQueueSet struct {
 
 	m      sync.Mutex
 	Queues map[string]*TaskQueue
+
+	// fairQueues are names of queues with fair scheduling, "*" means all queues.
+	fairQueues []string
+	fairKeyFn  func(task.Task) string
+
+	panicHandler func(err error)
 }
 
 func NewTaskQueueSet() *TaskQueueSet {
@@ -44,6 +51,35 @@ func (tqs *TaskQueueSet) WithMetricStorage(mstor *metric_storage.MetricStorage)
 	tqs.metricStorage = mstor
 }
 
+// WithFairScheduling enables fair scheduling for queues created with NewNamedQueue.
+// names are names of queues or comma-separated lists of names, "*" enables fair scheduling for all queues.
+func (tqs *TaskQueueSet) WithFairScheduling(names []string, keyFn func(task.Task) string) {
+	tqs.fairQueues = make([]string, 0)
+	for _, value := range names {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				tqs.fairQueues = append(tqs.fairQueues, name)
+			}
+		}
+	}
+	tqs.fairKeyFn = keyFn
+}
+
+// WithPanicHandler sets a function to report panics in handlers of new queues.
+func (tqs *TaskQueueSet) WithPanicHandler(fn func(err error)) {
+	tqs.panicHandler = fn
+}
+
+func (tqs *TaskQueueSet) isFair(name string) bool {
+	for _, fairName := range tqs.fairQueues {
+		if fairName == "*" || fairName == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (tqs *TaskQueueSet) Stop() {
 	if tqs.cancel != nil {
 		tqs.cancel()
@@ -70,6 +106,10 @@ func (tqs *TaskQueueSet) NewNamedQueue(name string, handler func(task.Task) Task
 	q.WithHandler(handler)
 	q.WithContext(tqs.ctx)
 	q.WithMetricStorage(tqs.metricStorage)
+	q.WithPanicHandler(tqs.panicHandler)
+	if tqs.fairKeyFn != nil && tqs.isFair(name) {
+		q.WithFairScheduling(tqs.fairKeyFn)
+	}
 	tqs.m.Lock()
 	tqs.Queues[name] = q
 	tqs.m.Unlock()
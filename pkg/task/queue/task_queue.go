@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"runtime/debug"
+	"runtime/pprof"
 	"strings"
 	"sync"
 	"time"
@@ -77,6 +79,19 @@ type TaskQueue struct {
 	measureActionFn     func()
 	measureActionFnOnce sync.Once
 
+	// fairKeyFn returns a key of the task for fair scheduling, e.g. a hook name.
+	// Fair scheduling is disabled if nil. See WithFairScheduling.
+	fairKeyFn func(task.Task) string
+	// fairPick is true if the next task can be picked fairly, i.e. the head task is not retried
+	// and is not a task added by the previous handler.
+	fairPick bool
+	// fairServed are sequence numbers of the last pick for each key.
+	fairServed map[string]uint64
+	fairSeq    uint64
+
+	// panicHandler is called with an error when the Handler panics. See WithPanicHandler.
+	panicHandler func(err error)
+
 	// Timing settings.
 	WaitLoopCheckInterval time.Duration
 	DelayOnQueueIsEmpty   time.Duration
@@ -115,6 +130,24 @@ func (q *TaskQueue) WithHandler(fn func(task.Task) TaskResult) *TaskQueue {
 	return q
 }
 
+// WithFairScheduling enables round-robin execution of tasks with different keys
+// instead of the strict FIFO order. keyFn returns a key of the task, e.g. a hook name.
+// Tasks with the same key are executed in FIFO order. Tasks with an empty key
+// are never moved: tasks behind them are not reordered.
+func (q *TaskQueue) WithFairScheduling(keyFn func(task.Task) string) *TaskQueue {
+	q.fairKeyFn = keyFn
+	q.fairPick = true
+	q.fairServed = make(map[string]uint64)
+	return q
+}
+
+// WithPanicHandler sets a function to report a panic in the Handler, e.g. to stop
+// the program. The task is failed and the queue continues to work.
+func (q *TaskQueue) WithPanicHandler(fn func(err error)) *TaskQueue {
+	q.panicHandler = fn
+	return q
+}
+
 // MeasureActionTime is a helper to measure execution time of queue's actions
 func (q *TaskQueue) MeasureActionTime(action string) func() {
 	q.measureActionFnOnce.Do(func() {
@@ -385,6 +418,10 @@ func (q *TaskQueue) Start() {
 	}
 
 	go func() {
+		// Label the goroutine to find the queue in goroutine profiles.
+		// Labels are restored after the Handler as it can set its own labels.
+		profilerLabels := pprof.WithLabels(context.Background(), pprof.Labels("queue", q.Name))
+		pprof.SetGoroutineLabels(profilerLabels)
 		q.Status = ""
 		var sleepDelay time.Duration
 		for {
@@ -396,6 +433,10 @@ func (q *TaskQueue) Start() {
 				return
 			}
 
+			if q.fairKeyFn != nil && q.fairPick {
+				t = q.pickFair()
+			}
+
 			// dump task and a whole queue
 			q.debugf("queue %s: tasks after wait %s", q.Name, q.String())
 			q.debugf("queue %s: task to handle '%s'", q.Name, t.GetType())
@@ -403,7 +444,8 @@ func (q *TaskQueue) Start() {
 			// Now the task can be handled!
 			var nextSleepDelay time.Duration
 			q.Status = "run first task"
-			taskRes := q.Handler(t)
+			taskRes := q.handle(t)
+			pprof.SetGoroutineLabels(profilerLabels)
 
 			// Check Done channel after long running operation.
 			select {
@@ -455,6 +497,9 @@ func (q *TaskQueue) Start() {
 
 			sleepDelay = nextSleepDelay
 
+			// Retried tasks and tasks from the handler should be executed first.
+			q.fairPick = taskRes.Status == Success && len(taskRes.HeadTasks) == 0 && len(taskRes.AfterTasks) == 0
+
 			if taskRes.AfterHandle != nil {
 				taskRes.AfterHandle()
 			}
@@ -465,6 +510,65 @@ func (q *TaskQueue) Start() {
 	q.started = true
 }
 
+// pickFair moves the first task of the least recently picked key to the head of the queue
+// and returns it. Tasks behind a task with an empty key are not considered.
+func (q *TaskQueue) pickFair() (t task.Task) {
+	defer q.MeasureActionTime("PickFair")()
+	q.withLock(func() {
+		if q.isEmpty() {
+			return
+		}
+		bestIdx := -1
+		bestKey := ""
+		var bestSeq uint64
+		seen := make(map[string]struct{})
+		for i, item := range q.items {
+			key := q.fairKeyFn(item)
+			if key == "" {
+				break
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			seq := q.fairServed[key]
+			if bestIdx == -1 || seq < bestSeq {
+				bestIdx, bestKey, bestSeq = i, key, seq
+			}
+			// Never picked key is the best one.
+			if seq == 0 {
+				break
+			}
+		}
+		if bestIdx == -1 {
+			t = q.items[0]
+			return
+		}
+
+		t = q.items[bestIdx]
+		copy(q.items[1:bestIdx+1], q.items[:bestIdx])
+		q.items[0] = t
+		q.fairSeq++
+		q.fairServed[bestKey] = q.fairSeq
+	})
+	return t
+}
+
+// handle runs the Handler. A panic in the Handler fails the task and is reported
+// to the panic handler.
+func (q *TaskQueue) handle(t task.Task) (res TaskResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("queue '%s': panic in handler of task '%s': %v\n%s", q.Name, t.GetType(), r, debug.Stack())
+			if q.panicHandler != nil {
+				q.panicHandler(fmt.Errorf("queue '%s': panic in handler of task '%s': %v", q.Name, t.GetType(), r))
+			}
+			res = TaskResult{Status: Fail}
+		}
+	}()
+	return q.Handler(t)
+}
+
 // waitForTask returns a task that can be processed or a nil if context is canceled.
 // sleepDelay is used to sleep before check a task, e.g. in case of failed previous task.
 // If queue is empty, then it will be checked every DelayOnQueueIsEmpty.
@@ -230,3 +230,81 @@ func Test_CancelDelay(t *testing.T) {
 		"Should stop delaying after CancelTaskDelay call. Got delay of %s, expect less than %s. Check cancel delay not broken in Start or waitForTask.",
 		elapsed.String(), (2 * mockExponentialDelay).String())
 }
+
+func Test_FairScheduling(t *testing.T) {
+	g := NewWithT(t)
+	q := NewTasksQueue()
+	q.WithContext(context.TODO())
+	q.WithName("test-queue")
+	q.WaitLoopCheckInterval = 5 * time.Millisecond
+	q.DelayOnQueueIsEmpty = 5 * time.Millisecond
+	q.DelayOnRepeat = 5 * time.Millisecond
+	// Metadata is a key, tasks without metadata are barriers.
+	q.WithFairScheduling(func(t task.Task) string {
+		key, _ := t.GetMetadata().(string)
+		return key
+	})
+
+	addTask := func(id string, key string) {
+		tsk := &task.BaseTask{Id: id}
+		if key != "" {
+			tsk.Metadata = key
+		}
+		q.AddLast(tsk)
+	}
+	addTask("a1", "a")
+	addTask("a2", "a")
+	addTask("a3", "a")
+	addTask("b1", "b")
+	addTask("c1", "c")
+	addTask("a4", "a")
+	addTask("b2", "b")
+	addTask("barrier", "")
+	addTask("c2", "c")
+
+	handled := make([]string, 0)
+	failed := false
+	doneCh := make(chan struct{})
+	q.WithHandler(func(t task.Task) (res TaskResult) {
+		// The failed task is retried before other tasks.
+		if t.GetId() == "b1" && !failed {
+			failed = true
+			res.Status = Fail
+			return
+		}
+		handled = append(handled, t.GetId())
+		res.Status = Success
+		if len(handled) == 9 {
+			res.AfterHandle = func() {
+				close(doneCh)
+			}
+		}
+		return
+	})
+	q.ExponentialBackoffFn = func(failureCount int) time.Duration {
+		return 5 * time.Millisecond
+	}
+
+	q.Start()
+	g.Eventually(doneCh, "5s", "10ms").Should(BeClosed())
+
+	g.Expect(handled).To(Equal([]string{"a1", "b1", "c1", "a2", "b2", "a3", "a4", "barrier", "c2"}))
+}
+
+func Test_TasksQueue_HandlerPanic(t *testing.T) {
+	g := NewWithT(t)
+	q := NewTasksQueue()
+
+	var panicErr error
+	q.WithPanicHandler(func(err error) {
+		panicErr = err
+	})
+	q.WithName("test").WithHandler(func(task.Task) TaskResult {
+		panic("boom")
+	})
+
+	res := q.handle(&task.BaseTask{Id: "panic"})
+	g.Expect(res.Status).To(Equal(Fail))
+	g.Expect(panicErr).Should(HaveOccurred())
+	g.Expect(panicErr.Error()).To(ContainSubstring("boom"))
+}
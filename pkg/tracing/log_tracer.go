@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogTracer writes finished spans to the log. It is useful to find slow
+// operations without a tracing backend.
+type LogTracer struct {
+	logger *log.Entry
+}
+
+func NewLogTracer() *LogTracer {
+	return &LogTracer{
+		logger: log.WithField("operator.component", "tracing"),
+	}
+}
+
+type spanCtxKey struct{}
+
+func (t *LogTracer) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, Span) {
+	span := &logSpan{
+		logger:  t.logger,
+		name:    name,
+		spanID:  newID(8),
+		started: time.Now(),
+		attrs:   make(map[string]string, len(attrs)),
+	}
+	for k, v := range attrs {
+		span.attrs[k] = v
+	}
+	if parent, ok := ctx.Value(spanCtxKey{}).(*logSpan); ok {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		span.traceID = newID(16)
+	}
+	return context.WithValue(ctx, spanCtxKey{}, span), span
+}
+
+type logSpan struct {
+	logger   *log.Entry
+	name     string
+	traceID  string
+	spanID   string
+	parentID string
+	started  time.Time
+
+	m     sync.Mutex
+	attrs map[string]string
+	err   error
+}
+
+func (s *logSpan) SetAttribute(key string, value string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *logSpan) RecordError(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.err = err
+}
+
+func (s *logSpan) End() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	fields := log.Fields{
+		"span.name": s.name,
+		"span.id":   s.spanID,
+		"trace.id":  s.traceID,
+		"duration":  time.Since(s.started).String(),
+	}
+	if s.parentID != "" {
+		fields["span.parent"] = s.parentID
+	}
+	for k, v := range s.attrs {
+		fields["span."+k] = v
+	}
+	entry := s.logger.WithFields(fields)
+	if s.err != nil {
+		entry = entry.WithError(s.err)
+	}
+	entry.Info("span")
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
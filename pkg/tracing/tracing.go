@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// Tracer starts spans. It is a thin layer to plug in a tracing SDK,
+// e.g. an OpenTelemetry tracer in a program that embeds shell-operator.
+type Tracer interface {
+	// Start returns a new span and a context with it. The span is a child
+	// of the span in ctx if there is one.
+	Start(ctx context.Context, name string, attrs map[string]string) (context.Context, Span)
+}
+
+// Span is a timed operation.
+type Span interface {
+	SetAttribute(key string, value string)
+	RecordError(err error)
+	End()
+}
+
+var (
+	tracerMu sync.RWMutex
+	tracer   Tracer = noopTracer{}
+)
+
+// SetTracer sets a global tracer. Nil disables tracing.
+func SetTracer(t Tracer) {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	if t == nil {
+		t = noopTracer{}
+	}
+	tracer = t
+}
+
+// Enabled returns true if a tracer is set. Use it to skip preparing expensive attributes.
+func Enabled() bool {
+	tracerMu.RLock()
+	defer tracerMu.RUnlock()
+	_, noop := tracer.(noopTracer)
+	return !noop
+}
+
+// Start starts a span with the global tracer.
+func Start(ctx context.Context, name string, attrs map[string]string) (context.Context, Span) {
+	tracerMu.RLock()
+	t := tracer
+	tracerMu.RUnlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return t.Start(ctx, name, attrs)
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ map[string]string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, string) {}
+func (noopSpan) RecordError(error)           {}
+func (noopSpan) End()                        {}
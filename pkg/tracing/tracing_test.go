@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Noop(t *testing.T) {
+	SetTracer(nil)
+	assert.False(t, Enabled())
+
+	ctx, span := Start(context.Background(), "noop", nil)
+	assert.NotNil(t, ctx)
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("error"))
+	span.End()
+}
+
+func Test_LogTracer(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer log.SetFormatter(&log.TextFormatter{})
+
+	SetTracer(NewLogTracer())
+	defer SetTracer(nil)
+	assert.True(t, Enabled())
+
+	ctx, parent := Start(context.Background(), "hook.run", map[string]string{"hook": "hook.sh"})
+	_, child := Start(ctx, "object_patch.patch", map[string]string{"kind": "Pod"})
+	child.RecordError(errors.New("not found"))
+	child.End()
+
+	childSpan := child.(*logSpan)
+	parentSpan := parent.(*logSpan)
+	assert.Equal(t, parentSpan.traceID, childSpan.traceID)
+	assert.Equal(t, parentSpan.spanID, childSpan.parentID)
+
+	assert.Contains(t, buf.String(), `"span.name":"object_patch.patch"`)
+	assert.Contains(t, buf.String(), `"span.kind":"Pod"`)
+	assert.Contains(t, buf.String(), `"error":"not found"`)
+	parent.End()
+	assert.Contains(t, buf.String(), `"span.hook":"hook.sh"`)
+}
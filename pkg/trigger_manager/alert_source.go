@@ -0,0 +1,118 @@
+package trigger_manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// AlertmanagerMessage is a payload of the Alertmanager webhook receiver.
+type AlertmanagerMessage struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     string            `json:"startsAt"`
+	EndsAt       string            `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertSource is an Alertmanager-compatible webhook receiver. It emits an event for each
+// alert in the notification that matches the filters. Authorization is the same as for WebhookSource.
+type AlertSource struct {
+	Path      string
+	TokenEnv  string
+	TokenFile string
+	// MaxBodySize is a limit for the request body. DefaultWebhookMaxBodySize is used if zero.
+	MaxBodySize int64
+	// AlertNames filters alerts by the 'alertname' label. All alerts are emitted if empty.
+	AlertNames []string
+	// MatchLabels filters alerts by labels.
+	MatchLabels map[string]string
+	// Statuses filters alerts by status: "firing" or "resolved". All alerts are emitted if empty.
+	Statuses []string
+	// Registry to serve the path. DefaultWebhookRegistry is used if nil.
+	Registry *WebhookRegistry
+}
+
+var _ Source = &AlertSource{}
+
+func (s *AlertSource) Run(ctx context.Context, emit func(data map[string]interface{})) error {
+	token, err := webhookToken(s.TokenFile, s.TokenEnv)
+	if err != nil {
+		return err
+	}
+
+	return serveWebhookPath(ctx, s.Registry, s.Path, func(w http.ResponseWriter, req *http.Request) {
+		s.serve(ctx, token, emit, w, req)
+	})
+}
+
+func (s *AlertSource) serve(ctx context.Context, token string, emit func(data map[string]interface{}), w http.ResponseWriter, req *http.Request) {
+	body, ok := readWebhookBody(w, req, s.MaxBodySize, token)
+	if !ok {
+		return
+	}
+
+	var msg AlertmanagerMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "malformed Alertmanager message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, alert := range msg.Alerts {
+		if !s.match(alert) {
+			continue
+		}
+		emit(map[string]interface{}{
+			"alert":       alert,
+			"receiver":    msg.Receiver,
+			"groupKey":    msg.GroupKey,
+			"groupLabels": msg.GroupLabels,
+			"externalURL": msg.ExternalURL,
+		})
+		if ctx.Err() != nil {
+			// Alertmanager retries the notification.
+			http.Error(w, "alert receiver is stopped", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *AlertSource) match(alert AlertmanagerAlert) bool {
+	if len(s.Statuses) > 0 && !containsString(s.Statuses, alert.Status) {
+		return false
+	}
+	if len(s.AlertNames) > 0 && !containsString(s.AlertNames, alert.Labels["alertname"]) {
+		return false
+	}
+	for k, v := range s.MatchLabels {
+		if alert.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
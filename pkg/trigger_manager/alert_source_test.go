@@ -0,0 +1,78 @@
+package trigger_manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const alertmanagerMessage = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"NodeDiskFull\"}",
+  "status": "firing",
+  "receiver": "shell-operator",
+  "groupLabels": {"alertname": "NodeDiskFull"},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {"status": "firing", "labels": {"alertname": "NodeDiskFull", "node": "n1", "severity": "critical"}, "fingerprint": "a1"},
+    {"status": "resolved", "labels": {"alertname": "NodeDiskFull", "node": "n2", "severity": "critical"}, "fingerprint": "a2"},
+    {"status": "firing", "labels": {"alertname": "NodeDiskFull", "node": "n3", "severity": "warning"}, "fingerprint": "a3"}
+  ]
+}`
+
+func Test_AlertSource(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv("TEST_ALERT_TOKEN", "s3cr3t")
+
+	registry := NewWebhookRegistry()
+	source := &AlertSource{
+		Path:        "alerts/disk",
+		TokenEnv:    "TEST_ALERT_TOKEN",
+		AlertNames:  []string{"NodeDiskFull"},
+		MatchLabels: map[string]string{"severity": "critical"},
+		Registry:    registry,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 3)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	send := func(body string, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/alerts/disk", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		registry.ServeWebhook("alerts/disk", rec, req)
+		return rec.Code
+	}
+
+	// Wait for registration.
+	g.Eventually(func() int {
+		return send("{}", "wrong")
+	}, 5*time.Second, 10*time.Millisecond).Should(Equal(http.StatusUnauthorized))
+
+	g.Expect(send("not a json", "s3cr3t")).To(Equal(http.StatusBadRequest))
+	g.Expect(send(alertmanagerMessage, "s3cr3t")).To(Equal(http.StatusOK))
+
+	fingerprints := []string{}
+	for len(events) > 0 {
+		data := <-events
+		g.Expect(data["receiver"]).To(Equal("shell-operator"))
+		fingerprints = append(fingerprints, data["alert"].(AlertmanagerAlert).Fingerprint)
+	}
+	// Warning alert is filtered out, resolved alerts are passed.
+	g.Expect(fingerprints).To(Equal([]string{"a1", "a2"}))
+
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+}
@@ -0,0 +1,287 @@
+package trigger_manager
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultFileWatchDebounce is a default time to collect changes before the event is emitted.
+const DefaultFileWatchDebounce = 500 * time.Millisecond
+
+// configMapDataDir is a symlink in ConfigMap and Secret volumes. Kubelet replaces
+// it to update files atomically, so there are no events for the files themselves.
+const configMapDataDir = "..data"
+
+// FileWatchOperations are names of operations for the 'events' field of the binding.
+var FileWatchOperations = map[string]fsnotify.Op{
+	"Create": fsnotify.Create,
+	"Write":  fsnotify.Write,
+	"Remove": fsnotify.Remove,
+	"Rename": fsnotify.Rename,
+	"Chmod":  fsnotify.Chmod,
+}
+
+// FileWatchSource watches files matching glob patterns with inotify.
+// Directories of patterns are watched, so files created later are matched too.
+// Parents of directories are watched to add directories that are created
+// or replaced later, e.g. '..data' of ConfigMap volumes. Directories should
+// exist on start: the source fails and is restarted until they appear.
+type FileWatchSource struct {
+	Paths []string
+	// Operations to report. Create, Write, Remove and Rename are reported if empty.
+	Operations []string
+	// Debounce is a time to collect changes into one event. DefaultFileWatchDebounce is used if zero.
+	Debounce time.Duration
+}
+
+var _ Source = &FileWatchSource{}
+
+func (s *FileWatchSource) Run(ctx context.Context, emit func(data map[string]interface{})) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	dirs := make(map[string]struct{})
+	for _, dir := range s.dirs() {
+		err := watcher.Add(dir)
+		if err != nil {
+			return fmt.Errorf("watch directory '%s': %v", dir, err)
+		}
+		dirs[dir] = struct{}{}
+	}
+	parents := make(map[string]struct{})
+	for _, parent := range s.parents() {
+		if _, has := dirs[parent]; !has {
+			if err := watcher.Add(parent); err != nil {
+				continue
+			}
+		}
+		parents[parent] = struct{}{}
+	}
+
+	ops := s.operations()
+	debounce := s.Debounce
+	if debounce <= 0 {
+		debounce = DefaultFileWatchDebounce
+	}
+
+	// changes are operations by file path collected during the debounce.
+	changes := make(map[string]map[string]struct{})
+	// rescan is set to watch new directories after the debounce.
+	rescan := false
+	// updated are directories with a replaced '..data' symlink.
+	updated := make(map[string]struct{})
+	var timer *time.Timer
+	var timerCh <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("file watcher is closed")
+			}
+			return fmt.Errorf("watch files: %v", err)
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("file watcher is closed")
+			}
+			// Inotify watch is dropped with the directory. It is watched again when
+			// it appears in the watched parent, otherwise restart to wait for it.
+			if _, has := dirs[event.Name]; has && event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				if _, has := parents[filepath.Dir(event.Name)]; !has {
+					return fmt.Errorf("watched directory '%s' is removed", event.Name)
+				}
+				delete(dirs, event.Name)
+				_ = watcher.Remove(event.Name)
+				rescan = true
+			}
+			if event.Op.Has(fsnotify.Create) {
+				if filepath.Base(event.Name) == configMapDataDir {
+					updated[filepath.Dir(event.Name)] = struct{}{}
+					rescan = true
+				} else if s.matchDir(event.Name) {
+					rescan = true
+				}
+			}
+			if s.match(event.Name) {
+				for name, op := range ops {
+					if event.Op.Has(op) {
+						addFileChange(changes, event.Name, name)
+					}
+				}
+			}
+			if (len(changes) > 0 || rescan) && timer == nil {
+				timer = time.NewTimer(debounce)
+				timerCh = timer.C
+			}
+
+		case <-timerCh:
+			timer = nil
+			timerCh = nil
+			if rescan {
+				s.rescan(watcher, dirs, updated, ops, changes)
+				rescan = false
+				updated = make(map[string]struct{})
+			}
+			if len(changes) > 0 {
+				emit(map[string]interface{}{"files": fileChangesList(changes)})
+				changes = make(map[string]map[string]struct{})
+			}
+		}
+	}
+}
+
+// rescan watches new directories of patterns and reports their files with
+// the Create operation. Files behind a replaced '..data' symlink are reported
+// with the Write operation.
+func (s *FileWatchSource) rescan(watcher *fsnotify.Watcher, dirs map[string]struct{}, updated map[string]struct{}, ops map[string]fsnotify.Op, changes map[string]map[string]struct{}) {
+	added := make(map[string]struct{})
+	for _, dir := range s.dirs() {
+		if _, has := dirs[dir]; has {
+			continue
+		}
+		// The directory may not exist yet.
+		if err := watcher.Add(dir); err != nil {
+			continue
+		}
+		dirs[dir] = struct{}{}
+		added[dir] = struct{}{}
+	}
+
+	for _, pattern := range s.Paths {
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			dir := filepath.Dir(file)
+			_, isUpdated := updated[dir]
+			if filepath.Base(dir) == configMapDataDir {
+				_, isUpdated = updated[filepath.Dir(dir)]
+			}
+			_, isAdded := added[dir]
+			op := ""
+			switch {
+			case isUpdated:
+				op = "Write"
+			case isAdded:
+				op = "Create"
+			}
+			if _, has := ops[op]; has {
+				addFileChange(changes, file, op)
+			}
+		}
+	}
+}
+
+// dirs returns existing directories for all patterns.
+func (s *FileWatchSource) dirs() []string {
+	res := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, pattern := range s.Paths {
+		dirs, err := filepath.Glob(filepath.Dir(pattern))
+		if err != nil || len(dirs) == 0 {
+			// Non-existent directory is reported by the watcher.
+			dirs = []string{filepath.Dir(pattern)}
+		}
+		for _, dir := range dirs {
+			if _, has := seen[dir]; has {
+				continue
+			}
+			seen[dir] = struct{}{}
+			res = append(res, dir)
+		}
+	}
+	return res
+}
+
+// parents returns existing parent directories of pattern directories.
+func (s *FileWatchSource) parents() []string {
+	res := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, pattern := range s.Paths {
+		parents, _ := filepath.Glob(filepath.Dir(filepath.Dir(pattern)))
+		for _, parent := range parents {
+			if _, has := seen[parent]; has {
+				continue
+			}
+			seen[parent] = struct{}{}
+			res = append(res, parent)
+		}
+	}
+	return res
+}
+
+// matchDir returns true if the path is a directory of some pattern.
+func (s *FileWatchSource) matchDir(path string) bool {
+	for _, pattern := range s.Paths {
+		if matched, _ := filepath.Match(filepath.Dir(pattern), path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *FileWatchSource) match(path string) bool {
+	for _, pattern := range s.Paths {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *FileWatchSource) operations() map[string]fsnotify.Op {
+	names := s.Operations
+	if len(names) == 0 {
+		names = []string{"Create", "Write", "Remove", "Rename"}
+	}
+	res := make(map[string]fsnotify.Op)
+	for _, name := range names {
+		if op, has := FileWatchOperations[name]; has {
+			res[name] = op
+		}
+	}
+	return res
+}
+
+func addFileChange(changes map[string]map[string]struct{}, path string, op string) {
+	if changes[path] == nil {
+		changes[path] = make(map[string]struct{})
+	}
+	changes[path][op] = struct{}{}
+}
+
+// fileChangesList returns changes sorted by path with sorted operations.
+func fileChangesList(changes map[string]map[string]struct{}) []map[string]interface{} {
+	paths := make([]string, 0, len(changes))
+	for path := range changes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	res := make([]map[string]interface{}, 0, len(paths))
+	for _, path := range paths {
+		ops := make([]string, 0, len(changes[path]))
+		for op := range changes[path] {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		res = append(res, map[string]interface{}{
+			"path":       path,
+			"operations": ops,
+		})
+	}
+	return res
+}
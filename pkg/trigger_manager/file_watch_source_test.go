@@ -0,0 +1,138 @@
+package trigger_manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_FileWatchSource(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	source := &FileWatchSource{
+		Paths:    []string{filepath.Join(dir, "*.yaml")},
+		Debounce: 50 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	// Give the watcher time to start.
+	time.Sleep(100 * time.Millisecond)
+
+	g.Expect(os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("a"), 0o644)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("a: 1"), 0o644)).To(Succeed())
+
+	select {
+	case data := <-events:
+		files := data["files"].([]map[string]interface{})
+		g.Expect(files).To(HaveLen(1))
+		g.Expect(files[0]["path"]).To(Equal(filepath.Join(dir, "app.yaml")))
+		g.Expect(files[0]["operations"]).To(ContainElement("Create"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event from file watch source")
+	}
+
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+}
+
+func Test_FileWatchSource_MissingDirectory(t *testing.T) {
+	g := NewWithT(t)
+
+	source := &FileWatchSource{
+		Paths: []string{filepath.Join(t.TempDir(), "missing", "*.conf")},
+	}
+	err := source.Run(context.Background(), func(map[string]interface{}) {})
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func runFileWatchSource(t *testing.T, source *FileWatchSource) (<-chan map[string]interface{}, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	// Give the watcher time to start.
+	time.Sleep(100 * time.Millisecond)
+
+	return events, func() {
+		cancel()
+		NewWithT(t).Expect(<-done).ShouldNot(HaveOccurred())
+	}
+}
+
+// Test_FileWatchSource_ConfigMap updates files as kubelet does in ConfigMap volumes.
+func Test_FileWatchSource_ConfigMap(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	g.Expect(os.Mkdir(filepath.Join(dir, "..v1"), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "..v1", "app.yaml"), []byte("a: 1"), 0o644)).To(Succeed())
+	g.Expect(os.Symlink("..v1", filepath.Join(dir, "..data"))).To(Succeed())
+	g.Expect(os.Symlink(filepath.Join("..data", "app.yaml"), filepath.Join(dir, "app.yaml"))).To(Succeed())
+
+	source := &FileWatchSource{
+		Paths:    []string{filepath.Join(dir, "*.yaml"), filepath.Join(dir, "..data", "*.yaml")},
+		Debounce: 50 * time.Millisecond,
+	}
+	events, stop := runFileWatchSource(t, source)
+	defer stop()
+
+	g.Expect(os.Mkdir(filepath.Join(dir, "..v2"), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "..v2", "app.yaml"), []byte("a: 2"), 0o644)).To(Succeed())
+	g.Expect(os.Symlink("..v2", filepath.Join(dir, "..data_tmp"))).To(Succeed())
+	g.Expect(os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))).To(Succeed())
+	g.Expect(os.RemoveAll(filepath.Join(dir, "..v1"))).To(Succeed())
+
+	select {
+	case data := <-events:
+		files := data["files"].([]map[string]interface{})
+		g.Expect(files).To(ContainElement(map[string]interface{}{
+			"path":       filepath.Join(dir, "app.yaml"),
+			"operations": []string{"Write"},
+		}))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event from file watch source")
+	}
+
+	// The new '..data' directory is watched.
+	g.Eventually(func() error {
+		return os.WriteFile(filepath.Join(dir, "..v2", "new.yaml"), []byte("b: 1"), 0o644)
+	}).Should(Succeed())
+	g.Eventually(events, 5*time.Second).Should(Receive(HaveKeyWithValue("files", ContainElement(HaveKeyWithValue("path", filepath.Join(dir, "..data", "new.yaml"))))))
+}
+
+func Test_FileWatchSource_NewDirectory(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	g.Expect(os.Mkdir(filepath.Join(dir, "a"), 0o755)).To(Succeed())
+
+	source := &FileWatchSource{
+		Paths:    []string{filepath.Join(dir, "*", "*.conf")},
+		Debounce: 50 * time.Millisecond,
+	}
+	events, stop := runFileWatchSource(t, source)
+	defer stop()
+
+	g.Expect(os.Mkdir(filepath.Join(dir, "b"), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "b", "app.conf"), []byte("a"), 0o644)).To(Succeed())
+
+	g.Eventually(events, 5*time.Second).Should(Receive(HaveKeyWithValue("files", ContainElement(HaveKeyWithValue("path", filepath.Join(dir, "b", "app.conf"))))))
+}
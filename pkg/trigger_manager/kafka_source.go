@@ -0,0 +1,189 @@
+package trigger_manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const kafkaContentType = "application/vnd.kafka.v2+json"
+
+// KafkaPollTimeout is a time the REST Proxy waits for new records.
+var KafkaPollTimeout = time.Second
+
+// KafkaSource consumes a Kafka topic via the Confluent REST Proxy API v2,
+// so no Kafka client library is required. Auto-commit is disabled: offsets
+// are committed after tasks for records are queued (at-least-once delivery).
+type KafkaSource struct {
+	RestProxyURL  string
+	Topic         string
+	ConsumerGroup string
+	// InstanceName is a name of the consumer instance in the consumer group.
+	InstanceName string
+
+	client *http.Client
+}
+
+var _ Source = &KafkaSource{}
+
+type kafkaRecord struct {
+	Topic     string  `json:"topic"`
+	Key       *string `json:"key"`
+	Value     *string `json:"value"`
+	Partition int32   `json:"partition"`
+	Offset    int64   `json:"offset"`
+}
+
+func (s *KafkaSource) Run(ctx context.Context, emit func(data map[string]interface{})) error {
+	if s.client == nil {
+		s.client = &http.Client{Timeout: KafkaPollTimeout + 30*time.Second}
+	}
+
+	baseURI, err := s.createConsumer(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Consumer instance should be deleted even if ctx is canceled.
+		delCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = s.do(delCtx, http.MethodDelete, baseURI, "", nil, nil)
+	}()
+
+	err = s.do(ctx, http.MethodPost, baseURI+"/subscription", "", map[string]interface{}{
+		"topics": []string{s.Topic},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("subscribe to Kafka topic '%s': %v", s.Topic, err)
+	}
+
+	recordsURL := fmt.Sprintf("%s/records?timeout=%d", baseURI, KafkaPollTimeout.Milliseconds())
+	for {
+		records := make([]kafkaRecord, 0)
+		err := s.do(ctx, http.MethodGet, recordsURL, "application/vnd.kafka.binary.v2+json", nil, &records)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("poll Kafka topic '%s': %v", s.Topic, err)
+		}
+		offsets := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			msg := map[string]interface{}{
+				"source":    "kafka",
+				"topic":     record.Topic,
+				"partition": record.Partition,
+				"offset":    record.Offset,
+			}
+			if record.Key != nil {
+				msg["key"] = decodeKafkaBinary(*record.Key)
+			}
+			if record.Value != nil {
+				msg["data"] = decodeKafkaBinary(*record.Value)
+			}
+			emit(map[string]interface{}{"message": msg})
+			// emit returns before the task is queued only if the source is stopped.
+			// The record is not committed then and is delivered again.
+			if ctx.Err() != nil {
+				break
+			}
+			offsets = append(offsets, map[string]interface{}{
+				"topic":     record.Topic,
+				"partition": record.Partition,
+				"offset":    record.Offset,
+			})
+		}
+		if len(offsets) > 0 {
+			// Consumer instance may be already deleted on stop, so commit with a separate context.
+			commitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := s.do(commitCtx, http.MethodPost, baseURI+"/offsets", "", map[string]interface{}{"offsets": offsets}, nil)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("commit offsets of Kafka topic '%s': %v", s.Topic, err)
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// createConsumer creates a consumer instance and returns its base URI.
+func (s *KafkaSource) createConsumer(ctx context.Context) (string, error) {
+	consumer := map[string]interface{}{
+		"format":             "binary",
+		"auto.offset.reset":  "latest",
+		"auto.commit.enable": "false",
+	}
+	if s.InstanceName != "" {
+		consumer["name"] = s.InstanceName
+	}
+	resp := struct {
+		InstanceId string `json:"instance_id"`
+		BaseURI    string `json:"base_uri"`
+	}{}
+	u := fmt.Sprintf("%s/consumers/%s", strings.TrimRight(s.RestProxyURL, "/"), s.ConsumerGroup)
+	err := s.do(ctx, http.MethodPost, u, "", consumer, &resp)
+	if err != nil {
+		return "", fmt.Errorf("create Kafka consumer in group '%s': %v", s.ConsumerGroup, err)
+	}
+	if resp.BaseURI == "" {
+		return "", fmt.Errorf("create Kafka consumer in group '%s': empty base_uri in response", s.ConsumerGroup)
+	}
+	return resp.BaseURI, nil
+}
+
+func (s *KafkaSource) do(ctx context.Context, method string, u string, accept string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", kafkaContentType)
+	}
+	if accept == "" {
+		accept = kafkaContentType
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// decodeKafkaBinary decodes base64 key or value of the record in binary format.
+func decodeKafkaBinary(s string) string {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return s
+	}
+	return string(data)
+}
@@ -0,0 +1,118 @@
+package trigger_manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Signals are POSIX signals allowed for the signal binding.
+// SIGINT and SIGTERM are reserved for the graceful shutdown.
+var Signals = map[string]syscall.Signal{
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGHUP":  syscall.SIGHUP,
+}
+
+// signalHub receives Signals once for the process and fans them out to
+// subscribed sources, so a signal does not terminate the process while
+// sources are not started or are restarted.
+type signalHub struct {
+	once        sync.Once
+	mu          sync.Mutex
+	subscribers map[os.Signal]map[chan os.Signal]struct{}
+}
+
+var defaultSignalHub = &signalHub{
+	subscribers: make(map[os.Signal]map[chan os.Signal]struct{}),
+}
+
+// NotifySignals starts to receive Signals for signal sources. It should be
+// called on start: the default action of these signals terminates the process.
+// Signals without running sources are ignored.
+func NotifySignals() {
+	defaultSignalHub.start()
+}
+
+func (h *signalHub) start() {
+	h.once.Do(func() {
+		sigs := make([]os.Signal, 0, len(Signals))
+		for _, sig := range Signals {
+			sigs = append(sigs, sig)
+		}
+		ch := make(chan os.Signal, len(sigs))
+		signal.Notify(ch, sigs...)
+		go func() {
+			for sig := range ch {
+				h.broadcast(sig)
+			}
+		}()
+	})
+}
+
+func (h *signalHub) broadcast(sig os.Signal) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subscribers[sig]) == 0 {
+		log.Infof("Signal %s is ignored: no running onSignal bindings", sig)
+		return
+	}
+	for ch := range h.subscribers[sig] {
+		// Signals received while the source is busy are merged.
+		select {
+		case ch <- sig:
+		default:
+		}
+	}
+}
+
+func (h *signalHub) subscribe(sig os.Signal) chan os.Signal {
+	h.start()
+	ch := make(chan os.Signal, 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[sig] == nil {
+		h.subscribers[sig] = make(map[chan os.Signal]struct{})
+	}
+	h.subscribers[sig][ch] = struct{}{}
+	return ch
+}
+
+func (h *signalHub) unsubscribe(sig os.Signal, ch chan os.Signal) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[sig], ch)
+}
+
+// SignalSource emits an event when the operator process receives the signal.
+// Each source receives its own copy of the signal, so several bindings can use the same signal.
+type SignalSource struct {
+	// Signal is a name of the signal from Signals.
+	Signal string
+}
+
+var _ Source = &SignalSource{}
+
+func (s *SignalSource) Run(ctx context.Context, emit func(data map[string]interface{})) error {
+	sig, has := Signals[s.Signal]
+	if !has {
+		return fmt.Errorf("signal '%s' is not supported", s.Signal)
+	}
+
+	ch := defaultSignalHub.subscribe(sig)
+	defer defaultSignalHub.unsubscribe(sig, ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ch:
+			emit(map[string]interface{}{"signal": s.Signal})
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package trigger_manager
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_SignalSource(t *testing.T) {
+	g := NewWithT(t)
+
+	// Guard the test process from the default action of the signal.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR1)
+	defer signal.Stop(guard)
+
+	source := &SignalSource{Signal: "SIGUSR1"}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	// Retry until the source is subscribed.
+	g.Eventually(func() bool {
+		g.Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)).To(Succeed())
+		select {
+		case data := <-events:
+			return data["signal"] == "SIGUSR1"
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second).Should(BeTrue())
+
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+
+	err := (&SignalSource{Signal: "SIGKILL"}).Run(context.Background(), func(map[string]interface{}) {})
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func Test_SignalSource_NoSubscribers(t *testing.T) {
+	g := NewWithT(t)
+
+	NotifySignals()
+
+	// The signal is ignored instead of terminating the test process.
+	g.Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+	time.Sleep(100 * time.Millisecond)
+
+	source := &SignalSource{Signal: "SIGUSR2"}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	g.Eventually(func() bool {
+		g.Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+		select {
+		case data := <-events:
+			return data["signal"] == "SIGUSR2"
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second).Should(BeTrue())
+
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+}
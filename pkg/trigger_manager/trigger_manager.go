@@ -0,0 +1,167 @@
+package trigger_manager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/utils/exponential_backoff"
+)
+
+// SourceRestartDelay is an initial delay to restart a failed source.
+var SourceRestartDelay = time.Second
+
+// Source produces data for bindings that are triggered by events outside of Kubernetes,
+// e.g. messages from a message queue. Run blocks until the context is canceled or an error occurs.
+// emit returns after tasks for the data are queued, unless the context is canceled.
+// Failed sources are restarted with exponential backoff.
+type Source interface {
+	Run(ctx context.Context, emit func(data map[string]interface{})) error
+}
+
+// Event is data produced by the source with the id.
+type Event struct {
+	SourceId string
+	Data     map[string]interface{}
+
+	// queued is closed when tasks for the event are queued.
+	queued chan struct{}
+}
+
+// Done reports that tasks for the event are queued. It unblocks emit in the
+// source, so sources with acknowledgements can commit the message.
+func (e Event) Done() {
+	if e.queued != nil {
+		close(e.queued)
+	}
+}
+
+type TriggerManager interface {
+	Start()
+	Stop()
+	Add(id string, source Source)
+	Remove(id string)
+	Ch() chan Event
+}
+
+type triggerManager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	m       sync.Mutex
+	started bool
+	// sources are added sources by id.
+	sources map[string]Source
+	// cancels stop running sources by id.
+	cancels map[string]context.CancelFunc
+
+	// restartDelay is an initial delay to restart a failed source.
+	restartDelay time.Duration
+
+	TriggerCh chan Event
+}
+
+var _ TriggerManager = &triggerManager{}
+
+func NewTriggerManager(ctx context.Context) *triggerManager {
+	cctx, cancel := context.WithCancel(ctx)
+	return &triggerManager{
+		ctx:       cctx,
+		cancel:    cancel,
+		sources:   make(map[string]Source),
+		cancels:   make(map[string]context.CancelFunc),
+		TriggerCh: make(chan Event, 1),
+
+		restartDelay: SourceRestartDelay,
+	}
+}
+
+// Start runs all added sources. Sources added after Start are run immediately.
+func (tm *triggerManager) Start() {
+	tm.m.Lock()
+	defer tm.m.Unlock()
+	tm.started = true
+	for id, source := range tm.sources {
+		tm.run(id, source)
+	}
+}
+
+func (tm *triggerManager) Stop() {
+	if tm.cancel != nil {
+		tm.cancel()
+	}
+}
+
+// Add registers the source with the id. A source with the same id is replaced.
+func (tm *triggerManager) Add(id string, source Source) {
+	tm.m.Lock()
+	defer tm.m.Unlock()
+	if cancel, has := tm.cancels[id]; has {
+		cancel()
+		delete(tm.cancels, id)
+	}
+	tm.sources[id] = source
+	if tm.started {
+		tm.run(id, source)
+	}
+}
+
+// Remove stops the source with the id.
+func (tm *triggerManager) Remove(id string) {
+	tm.m.Lock()
+	defer tm.m.Unlock()
+	if cancel, has := tm.cancels[id]; has {
+		cancel()
+		delete(tm.cancels, id)
+	}
+	delete(tm.sources, id)
+}
+
+func (tm *triggerManager) Ch() chan Event {
+	return tm.TriggerCh
+}
+
+// run starts a goroutine that restarts the source until it is removed. Lock should be held.
+func (tm *triggerManager) run(id string, source Source) {
+	ctx, cancel := context.WithCancel(tm.ctx)
+	tm.cancels[id] = cancel
+
+	logEntry := log.WithField("operator.component", "triggerManager").WithField("trigger", id)
+	// emit returns when tasks for the event are queued or the source is stopped.
+	emit := func(data map[string]interface{}) {
+		ev := Event{SourceId: id, Data: data, queued: make(chan struct{})}
+		select {
+		case tm.TriggerCh <- ev:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-ev.queued:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		failures := 0
+		for {
+			err := source.Run(ctx, emit)
+			if ctx.Err() != nil {
+				logEntry.Debugf("trigger source is stopped")
+				return
+			}
+			if err == nil {
+				failures = 0
+			}
+			delay := exponential_backoff.CalculateDelay(tm.restartDelay, failures)
+			failures++
+			logEntry.Errorf("trigger source is failed, restart in %s: %v", delay.String(), err)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,215 @@
+package trigger_manager
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type funcSource func(ctx context.Context, emit func(data map[string]interface{})) error
+
+func (f funcSource) Run(ctx context.Context, emit func(data map[string]interface{})) error {
+	return f(ctx, emit)
+}
+
+func Test_TriggerManager_RestartFailedSource(t *testing.T) {
+	g := NewWithT(t)
+
+	runs := 0
+	tm := NewTriggerManager(context.Background())
+	tm.restartDelay = 10 * time.Millisecond
+	defer tm.Stop()
+	tm.Add("src", funcSource(func(ctx context.Context, emit func(data map[string]interface{})) error {
+		runs++
+		emit(map[string]interface{}{"run": runs})
+		return errors.New("connection lost")
+	}))
+	tm.Start()
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case ev := <-tm.Ch():
+			g.Expect(ev.SourceId).To(Equal("src"))
+			g.Expect(ev.Data).To(Equal(map[string]interface{}{"run": i}))
+			ev.Done()
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event from run %d", i)
+		}
+	}
+
+	tm.Remove("src")
+}
+
+func Test_NatsSource(t *testing.T) {
+	g := NewWithT(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		lines := make([]string, 0)
+		for len(lines) < 3 {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		received <- strings.Join(lines, "\n")
+		fmt.Fprint(conn, "PONG\r\nMSG orders.new 1 reply.1 7\r\n{\"a\":1}\r\n")
+		// Wait for the client to disconnect.
+		_, _ = r.ReadString('\n')
+	}()
+
+	source := &NatsSource{
+		URL:        "nats://s3cr3t@" + ln.Addr().String(),
+		Subject:    "orders.>",
+		QueueGroup: "workers",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	select {
+	case data := <-events:
+		g.Expect(data).To(Equal(map[string]interface{}{
+			"message": map[string]interface{}{
+				"source":  "nats",
+				"subject": "orders.new",
+				"replyTo": "reply.1",
+				"data":    `{"a":1}`,
+			},
+		}))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message from NATS source")
+	}
+
+	handshake := <-received
+	g.Expect(handshake).To(ContainSubstring(`"auth_token":"s3cr3t"`))
+	g.Expect(handshake).To(ContainSubstring("SUB orders.> workers 1\nPING"))
+
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+}
+
+func Test_KafkaSource(t *testing.T) {
+	g := NewWithT(t)
+
+	var srv *httptest.Server
+	polls := 0
+	deleted := make(chan struct{})
+	committed := make(chan string, 1)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/consumers/group":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"instance_id": "c1",
+				"base_uri":    srv.URL + "/consumers/group/instances/c1",
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/consumers/group/instances/c1/subscription":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/consumers/group/instances/c1/records":
+			polls++
+			if polls > 1 {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{{
+				"topic":     "events",
+				"key":       base64.StdEncoding.EncodeToString([]byte("k1")),
+				"value":     base64.StdEncoding.EncodeToString([]byte("hello")),
+				"partition": 0,
+				"offset":    42,
+			}})
+		case r.Method == http.MethodPost && r.URL.Path == "/consumers/group/instances/c1/offsets":
+			body, _ := io.ReadAll(r.Body)
+			committed <- string(body)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && r.URL.Path == "/consumers/group/instances/c1":
+			w.WriteHeader(http.StatusNoContent)
+			close(deleted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	source := &KafkaSource{RestProxyURL: srv.URL, Topic: "events", ConsumerGroup: "group"}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	select {
+	case data := <-events:
+		g.Expect(data).To(Equal(map[string]interface{}{
+			"message": map[string]interface{}{
+				"source":    "kafka",
+				"topic":     "events",
+				"partition": int32(0),
+				"offset":    int64(42),
+				"key":       "k1",
+				"data":      "hello",
+			},
+		}))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message from Kafka source")
+	}
+
+	// Offset is committed after emit returns.
+	select {
+	case body := <-committed:
+		g.Expect(body).To(MatchJSON(`{"offsets":[{"topic":"events","partition":0,"offset":42}]}`))
+	case <-time.After(5 * time.Second):
+		t.Fatal("offset is not committed")
+	}
+
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer instance is not deleted")
+	}
+}
+
+func Test_ReadNatsMsg_MaxPayload(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := readNatsMsg(bufio.NewReader(strings.NewReader("0123456789\r\n")), "MSG orders.new 1 10", 5)
+	g.Expect(err).Should(MatchError(ContainSubstring("exceeds max payload")))
+
+	data, err := readNatsMsg(bufio.NewReader(strings.NewReader("0123456789\r\n")), "MSG orders.new 1 10", 10)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(data["message"]).To(HaveKeyWithValue("data", "0123456789"))
+}
@@ -0,0 +1,209 @@
+package trigger_manager
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultWebhookMaxBodySize is a default limit for the request body of the webhook binding.
+const DefaultWebhookMaxBodySize int64 = 1024 * 1024
+
+// DefaultWebhookRegistry is used by webhook sources without a registry.
+var DefaultWebhookRegistry = NewWebhookRegistry()
+
+// WebhookRegistry dispatches incoming requests to running webhook sources by path.
+type WebhookRegistry struct {
+	m        sync.RWMutex
+	handlers map[string]http.HandlerFunc
+}
+
+func NewWebhookRegistry() *WebhookRegistry {
+	return &WebhookRegistry{
+		handlers: make(map[string]http.HandlerFunc),
+	}
+}
+
+// ServeWebhook runs a handler of the source registered for the path.
+func (r *WebhookRegistry) ServeWebhook(path string, w http.ResponseWriter, req *http.Request) {
+	r.m.RLock()
+	h, has := r.handlers[strings.Trim(path, "/")]
+	r.m.RUnlock()
+	if !has {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	h(w, req)
+}
+
+func (r *WebhookRegistry) register(path string, h http.HandlerFunc) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, has := r.handlers[path]; has {
+		return fmt.Errorf("webhook path '%s' is already used by another binding", path)
+	}
+	r.handlers[path] = h
+	return nil
+}
+
+func (r *WebhookRegistry) unregister(path string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.handlers, path)
+}
+
+// WebhookSource receives HTTP requests on the path in the registry.
+// Requests are authorized with the bearer token or with the HMAC-SHA256
+// signature of the body in the X-Hub-Signature-256 header as GitHub does.
+type WebhookSource struct {
+	Path string
+	// TokenEnv is a name of the environment variable with the token.
+	TokenEnv string
+	// TokenFile is a path of the file with the token. It takes precedence over TokenEnv.
+	TokenFile string
+	// MaxBodySize is a limit for the request body. DefaultWebhookMaxBodySize is used if zero.
+	MaxBodySize int64
+	// Registry to serve the path. DefaultWebhookRegistry is used if nil.
+	Registry *WebhookRegistry
+}
+
+var _ Source = &WebhookSource{}
+
+func (s *WebhookSource) Run(ctx context.Context, emit func(data map[string]interface{})) error {
+	token, err := s.token()
+	if err != nil {
+		return err
+	}
+
+	return serveWebhookPath(ctx, s.Registry, s.Path, func(w http.ResponseWriter, req *http.Request) {
+		s.serve(ctx, token, emit, w, req)
+	})
+}
+
+// serveWebhookPath registers the handler for the path until the context is canceled.
+func serveWebhookPath(ctx context.Context, registry *WebhookRegistry, path string, h http.HandlerFunc) error {
+	if registry == nil {
+		registry = DefaultWebhookRegistry
+	}
+	path = strings.Trim(path, "/")
+	err := registry.register(path, h)
+	if err != nil {
+		return err
+	}
+	defer registry.unregister(path)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (s *WebhookSource) serve(ctx context.Context, token string, emit func(data map[string]interface{}), w http.ResponseWriter, req *http.Request) {
+	body, ok := readWebhookBody(w, req, s.MaxBodySize, token)
+	if !ok {
+		return
+	}
+
+	headers := make(map[string]string)
+	for name := range req.Header {
+		if name == "Authorization" {
+			continue
+		}
+		headers[name] = req.Header.Get(name)
+	}
+	query := make(map[string]string)
+	for name := range req.URL.Query() {
+		query[name] = req.URL.Query().Get(name)
+	}
+	request := map[string]interface{}{
+		"method":  req.Method,
+		"path":    req.URL.Path,
+		"headers": headers,
+		"query":   query,
+		"body":    string(body),
+	}
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) == nil {
+		request["json"] = parsed
+	}
+
+	emit(map[string]interface{}{"request": request})
+	if ctx.Err() != nil {
+		http.Error(w, "webhook is stopped", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *WebhookSource) token() (string, error) {
+	return webhookToken(s.TokenFile, s.TokenEnv)
+}
+
+// webhookToken reads the token from the file or from the environment variable.
+func webhookToken(tokenFile string, tokenEnv string) (string, error) {
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("read webhook token: %v", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("webhook token file '%s' is empty", tokenFile)
+		}
+		return token, nil
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return "", fmt.Errorf("webhook token env '%s' is empty", tokenEnv)
+	}
+	return token, nil
+}
+
+// readWebhookBody reads the body within the limit and authorizes the request.
+// It writes the error response and returns false if the request should not be handled.
+func readWebhookBody(w http.ResponseWriter, req *http.Request, maxBodySize int64, token string) ([]byte, bool) {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultWebhookMaxBodySize
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("request body is larger than %d bytes", maxBodySize), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		http.Error(w, "read request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !authorizeWebhook(req, body, token) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+// authorizeWebhook checks the bearer token or the signature of the body.
+func authorizeWebhook(req *http.Request, body []byte, token string) bool {
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+	}
+	if sig := req.Header.Get("X-Hub-Signature-256"); strings.HasPrefix(sig, "sha256=") {
+		expected, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(token))
+		mac.Write(body)
+		return hmac.Equal(mac.Sum(nil), expected)
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package trigger_manager
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_WebhookSource(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv("TEST_WEBHOOK_TOKEN", "s3cr3t")
+
+	registry := NewWebhookRegistry()
+	source := &WebhookSource{
+		Path:        "github/push",
+		TokenEnv:    "TEST_WEBHOOK_TOKEN",
+		MaxBodySize: 32,
+		Registry:    registry,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan map[string]interface{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- source.Run(ctx, func(data map[string]interface{}) {
+			events <- data
+		})
+	}()
+
+	// Wait for registration.
+	g.Eventually(func() int {
+		rec := httptest.NewRecorder()
+		registry.ServeWebhook("github/push", rec, httptest.NewRequest(http.MethodPost, "/webhooks/github/push", nil))
+		return rec.Code
+	}, 5*time.Second, 10*time.Millisecond).Should(Equal(http.StatusUnauthorized))
+
+	send := func(path string, body string, header string, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/"+path+"?ref=main", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		registry.ServeWebhook(path, rec, req)
+		return rec.Code
+	}
+
+	g.Expect(send("unknown", "{}", "Authorization", "Bearer s3cr3t")).To(Equal(http.StatusNotFound))
+	g.Expect(send("github/push", "{}", "Authorization", "Bearer wrong")).To(Equal(http.StatusUnauthorized))
+	g.Expect(send("github/push", strings.Repeat("x", 33), "Authorization", "Bearer s3cr3t")).To(Equal(http.StatusRequestEntityTooLarge))
+
+	g.Expect(send("github/push", `{"action":"push"}`, "Authorization", "Bearer s3cr3t")).To(Equal(http.StatusAccepted))
+	data := <-events
+	request := data["request"].(map[string]interface{})
+	g.Expect(request["body"]).To(Equal(`{"action":"push"}`))
+	g.Expect(request["json"]).To(Equal(map[string]interface{}{"action": "push"}))
+	g.Expect(request["query"]).To(Equal(map[string]string{"ref": "main"}))
+	g.Expect(request["headers"]).ToNot(HaveKey("Authorization"))
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte("payload"))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	g.Expect(send("github/push", "payload", "X-Hub-Signature-256", signature)).To(Equal(http.StatusAccepted))
+	data = <-events
+	g.Expect(data["request"].(map[string]interface{})).ToNot(HaveKey("json"))
+	g.Expect(send("github/push", "tampered", "X-Hub-Signature-256", signature)).To(Equal(http.StatusUnauthorized))
+
+	// Path is released when the source is stopped.
+	cancel()
+	g.Expect(<-done).ShouldNot(HaveOccurred())
+	g.Expect(send("github/push", "{}", "Authorization", "Bearer s3cr3t")).To(Equal(http.StatusNotFound))
+}
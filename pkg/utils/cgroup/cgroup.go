@@ -0,0 +1,122 @@
+package cgroup
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Root is a mount point of the cgroup filesystem.
+var Root = "/sys/fs/cgroup"
+
+// Limits of the current container. Zero value means no limit.
+type Limits struct {
+	// CPU is a number of CPUs allowed by the CFS quota.
+	CPU float64
+	// Memory is a memory limit in bytes.
+	Memory int64
+}
+
+// ReadLimits returns CPU and memory limits from cgroup v2 or cgroup v1 files.
+func ReadLimits() (Limits, error) {
+	if _, err := os.Stat(filepath.Join(Root, "cgroup.controllers")); err == nil {
+		return readV2(Root)
+	}
+	return readV1(Root)
+}
+
+// readV2 parses cpu.max ("max 100000" or "200000 100000") and memory.max.
+func readV2(root string) (Limits, error) {
+	limits := Limits{}
+
+	cpuMax, err := readFile(filepath.Join(root, "cpu.max"))
+	if err != nil {
+		return limits, err
+	}
+	if fields := strings.Fields(cpuMax); len(fields) == 2 && fields[0] != "max" {
+		limits.CPU, err = cpuFromQuota(fields[0], fields[1])
+		if err != nil {
+			return limits, fmt.Errorf("parse cpu.max: %v", err)
+		}
+	}
+
+	memMax, err := readFile(filepath.Join(root, "memory.max"))
+	if err != nil {
+		return limits, err
+	}
+	if memMax != "" && memMax != "max" {
+		limits.Memory, err = strconv.ParseInt(memMax, 10, 64)
+		if err != nil {
+			return limits, fmt.Errorf("parse memory.max: %v", err)
+		}
+	}
+
+	return limits, nil
+}
+
+// readV1 parses cpu.cfs_quota_us, cpu.cfs_period_us and memory.limit_in_bytes.
+func readV1(root string) (Limits, error) {
+	limits := Limits{}
+
+	quota, err := readFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	if err != nil {
+		return limits, err
+	}
+	period, err := readFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return limits, err
+	}
+	if quota != "" && quota != "-1" {
+		limits.CPU, err = cpuFromQuota(quota, period)
+		if err != nil {
+			return limits, fmt.Errorf("parse cpu.cfs_quota_us: %v", err)
+		}
+	}
+
+	memLimit, err := readFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	if err != nil {
+		return limits, err
+	}
+	if memLimit != "" {
+		limits.Memory, err = strconv.ParseInt(memLimit, 10, 64)
+		if err != nil {
+			return limits, fmt.Errorf("parse memory.limit_in_bytes: %v", err)
+		}
+		// cgroup v1 reports a huge number if memory is not limited.
+		if limits.Memory >= math.MaxInt64/4096*4096 {
+			limits.Memory = 0
+		}
+	}
+
+	return limits, nil
+}
+
+func cpuFromQuota(quota, period string) (float64, error) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, err
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil {
+		return 0, err
+	}
+	if p <= 0 {
+		return 0, fmt.Errorf("period should be positive, got %s", period)
+	}
+	return q / p, nil
+}
+
+// readFile returns trimmed content of the file or an empty string if the file does not exist.
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,74 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func Test_ReadLimits_V2(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "/sys/fs/cgroup" }()
+
+	writeFiles(t, Root, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.max":            "150000 100000\n",
+		"memory.max":         "536870912\n",
+	})
+
+	limits, err := ReadLimits()
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, limits.CPU)
+	assert.Equal(t, int64(536870912), limits.Memory)
+}
+
+func Test_ReadLimits_V2_Unlimited(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "/sys/fs/cgroup" }()
+
+	writeFiles(t, Root, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.max":            "max 100000\n",
+		"memory.max":         "max\n",
+	})
+
+	limits, err := ReadLimits()
+	require.NoError(t, err)
+	assert.Equal(t, Limits{}, limits)
+}
+
+func Test_ReadLimits_V1(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "/sys/fs/cgroup" }()
+
+	writeFiles(t, Root, map[string]string{
+		"cpu/cpu.cfs_quota_us":         "200000\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+	})
+
+	limits, err := ReadLimits()
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, limits.CPU)
+	assert.Equal(t, int64(0), limits.Memory)
+}
+
+func Test_ReadLimits_NoCgroup(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "/sys/fs/cgroup" }()
+
+	limits, err := ReadLimits()
+	require.NoError(t, err)
+	assert.Equal(t, Limits{}, limits)
+}
@@ -0,0 +1,24 @@
+package compress
+
+import (
+	"compress/flate"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ContentTypes are types of responses to compress: metrics, status pages and dumps.
+var ContentTypes = []string{
+	"text/plain",
+	"text/html",
+	"application/json",
+	"application/schema+json",
+	"application/yaml",
+}
+
+// Middleware compresses responses with gzip or deflate if the client sends
+// a suitable Accept-Encoding header. Responses that are already compressed,
+// e.g. /metrics from the Prometheus handler, are passed as is.
+func Middleware() func(next http.Handler) http.Handler {
+	return middleware.Compress(flate.DefaultCompression, ContentTypes...)
+}
@@ -20,6 +20,9 @@ func FileExists(path string) (bool, error) {
 	return true, nil
 }
 
+// CommandHookSuffixes are suffixes of YAML files with configs of command hooks.
+var CommandHookSuffixes = []string{".hook.yaml", ".hook.yml"}
+
 func IsFileExecutable(f os.FileInfo) bool {
 	return f.Mode()&0o111 != 0
 }
@@ -42,6 +45,10 @@ func RecursiveGetExecutablePaths(dir string) ([]string, error) {
 			return nil
 		}
 
+		if IsCommandHookFile(f.Name()) {
+			return nil
+		}
+
 		if !isExecutableHookFile(f) {
 			log.Warnf("File '%s' is skipped: no executable permissions, chmod +x is required to run this hook", path)
 			return nil
@@ -57,6 +64,48 @@ func RecursiveGetExecutablePaths(dir string) ([]string, error) {
 	return paths, nil
 }
 
+// RecursiveGetCommandHookPaths finds recursively all command hook files
+// inside a dir directory. Hidden directories and files are ignored.
+func RecursiveGetCommandHookPaths(dir string) ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if f.IsDir() {
+			// Skip hidden and lib directories inside initial directory
+			if strings.HasPrefix(f.Name(), ".") || f.Name() == "lib" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if strings.HasPrefix(f.Name(), ".") || !IsCommandHookFile(f.Name()) {
+			return nil
+		}
+
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// IsCommandHookFile returns true if the file name has a suffix of the command hook.
+func IsCommandHookFile(name string) bool {
+	for _, suffix := range CommandHookSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
 // RecursiveCheckLibDirectory finds recursively all executable files
 // inside a lib directory. And will log warning with these files.
 func RecursiveCheckLibDirectory(dir string) error {
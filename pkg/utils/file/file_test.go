@@ -141,3 +141,31 @@ func TestRecursiveCheckLibDirectory(t *testing.T) {
 
 	os.RemoveAll(dir)
 }
+
+func TestRecursiveGetCommandHookPaths(t *testing.T) {
+	dir, err := prepareTestDirTree()
+	if err != nil {
+		t.Fatalf("error creating temp directory: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"aa/label.hook.yaml", "b.hook.yml", "values.yaml", "lib/lib.hook.yaml", ".hidden.hook.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("configVersion: v1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := RecursiveGetCommandHookPaths(dir)
+	if err != nil {
+		t.Fatalf("RecursiveGetCommandHookPaths() error = %v", err)
+	}
+	want := []string{"aa/label.hook.yaml", "b.hook.yml"}
+	if len(got) != len(want) {
+		t.Fatalf("RecursiveGetCommandHookPaths() got = %v, want %v", got, want)
+	}
+	for i := range got {
+		if !strings.HasSuffix(got[i], want[i]) {
+			t.Errorf("RecursiveGetCommandHookPaths() got = %v, want %v", got, want)
+		}
+	}
+}
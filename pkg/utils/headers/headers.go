@@ -0,0 +1,94 @@
+package headers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CorsConfig is a set of rules for cross-origin requests.
+type CorsConfig struct {
+	// AllowedOrigins is a list of origins or "*" to allow any origin.
+	// Cross-origin requests are not allowed if empty.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// MaxAge is a number of seconds to cache the preflight response.
+	MaxAge int
+}
+
+// NewCorsConfig returns a config from comma-separated lists.
+func NewCorsConfig(origins, methods, headers string) CorsConfig {
+	return CorsConfig{
+		AllowedOrigins: SplitList(origins),
+		AllowedMethods: SplitList(methods),
+		AllowedHeaders: SplitList(headers),
+		MaxAge:         600,
+	}
+}
+
+func (c CorsConfig) originAllowed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cors adds CORS headers for requests from allowed origins and answers preflight requests.
+func Cors(cfg CorsConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.AllowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			if origin == "" || !cfg.originAllowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+
+			// Preflight request.
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+				if len(cfg.AllowedHeaders) > 0 {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+				}
+				if cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Security adds headers to prevent content sniffing, framing and leaking of URLs
+// in the Referer header.
+func Security(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SplitList splits a comma-separated list and drops empty items.
+func SplitList(s string) []string {
+	res := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
@@ -0,0 +1,218 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/admission/v1"
+)
+
+// RedactedValue replaces values of redacted fields in archived requests.
+const RedactedValue = "<redacted>"
+
+// DefaultArchiveQueueSize is a number of records waiting to be written by the AsyncArchiver.
+const DefaultArchiveQueueSize = 1024
+
+// DefaultArchiveHTTPTimeout limits the time to send a record with the HTTPArchiver.
+const DefaultArchiveHTTPTimeout = 10 * time.Second
+
+// ErrArchiveQueueFull is returned when the record is dropped to not delay the admission response.
+var ErrArchiveQueueFull = errors.New("archive queue is full, record is dropped")
+
+// ArchiveRecord is a handled AdmissionReview request with the response.
+type ArchiveRecord struct {
+	Time            time.Time              `json:"time"`
+	ConfigurationId string                 `json:"configurationId"`
+	WebhookId       string                 `json:"webhookId"`
+	Request         map[string]interface{} `json:"request"`
+	Response        *v1.AdmissionResponse  `json:"response"`
+}
+
+// Archiver persists handled requests for audit. FileArchiver is used for
+// the local disk and HTTPArchiver sends records to the external storage.
+// Other implementations can be set with WebhookManager.WithArchiver.
+type Archiver interface {
+	Archive(record *ArchiveRecord) error
+}
+
+// NewArchiveRecord converts request to a generic map and redacts fields by dot-separated paths,
+// e.g. "object.data" or "userInfo.extra".
+func NewArchiveRecord(configurationID string, webhookID string, request *v1.AdmissionRequest, response *v1.AdmissionResponse, redactPaths []string) (*ArchiveRecord, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req := make(map[string]interface{})
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+
+	for _, p := range redactPaths {
+		redact(req, strings.Split(p, "."))
+	}
+
+	return &ArchiveRecord{
+		Time:            time.Now(),
+		ConfigurationId: configurationID,
+		WebhookId:       webhookID,
+		Request:         req,
+		Response:        response,
+	}, nil
+}
+
+func redact(obj map[string]interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	v, has := obj[path[0]]
+	if !has {
+		return
+	}
+	if len(path) == 1 {
+		obj[path[0]] = RedactedValue
+		return
+	}
+	if nested, ok := v.(map[string]interface{}); ok {
+		redact(nested, path[1:])
+	}
+}
+
+// FileArchiver appends records as JSON lines to daily files in the directory.
+type FileArchiver struct {
+	Dir string
+
+	m sync.Mutex
+}
+
+func NewFileArchiver(dir string) *FileArchiver {
+	return &FileArchiver{Dir: dir}
+}
+
+func (a *FileArchiver) Archive(record *ArchiveRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	err = os.MkdirAll(a.Dir, 0o750)
+	if err != nil {
+		return fmt.Errorf("create archive dir: %v", err)
+	}
+
+	fileName := filepath.Join(a.Dir, fmt.Sprintf("admission-%s.jsonl", record.Time.UTC().Format("2006-01-02")))
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	return err
+}
+
+// HTTPArchiver sends every record as a JSON document with a POST request to the URL,
+// e.g. to a log collector or to an object store gateway.
+type HTTPArchiver struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPArchiver(url string) *HTTPArchiver {
+	return &HTTPArchiver{
+		URL:    url,
+		Client: &http.Client{Timeout: DefaultArchiveHTTPTimeout},
+	}
+}
+
+func (a *HTTPArchiver) Archive(record *ArchiveRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.Client.Post(a.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("archive storage responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// AsyncArchiver passes records to the Archiver in the background goroutine,
+// so a slow disk or storage does not delay admission responses.
+// Records are dropped when the queue is full.
+type AsyncArchiver struct {
+	archiver Archiver
+	records  chan *ArchiveRecord
+	done     chan struct{}
+
+	m       sync.RWMutex
+	stopped bool
+}
+
+func NewAsyncArchiver(archiver Archiver, queueSize int) *AsyncArchiver {
+	a := &AsyncArchiver{
+		archiver: archiver,
+		records:  make(chan *ArchiveRecord, queueSize),
+		done:     make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Archive queues the record without blocking.
+func (a *AsyncArchiver) Archive(record *ArchiveRecord) error {
+	a.m.RLock()
+	defer a.m.RUnlock()
+	if a.stopped {
+		return errors.New("archiver is stopped")
+	}
+	select {
+	case a.records <- record:
+		return nil
+	default:
+		return ErrArchiveQueueFull
+	}
+}
+
+// Stop waits until queued records are written. Records archived after Stop are dropped.
+func (a *AsyncArchiver) Stop() {
+	a.m.Lock()
+	if !a.stopped {
+		a.stopped = true
+		close(a.records)
+	}
+	a.m.Unlock()
+	<-a.done
+}
+
+func (a *AsyncArchiver) run() {
+	defer close(a.done)
+	for record := range a.records {
+		err := a.archiver.Archive(record)
+		if err != nil {
+			var uid string
+			if record.Response != nil {
+				uid = string(record.Response.UID)
+			}
+			log.Errorf("Archive AdmissionReview request '%s': %v", uid, err)
+		}
+	}
+}
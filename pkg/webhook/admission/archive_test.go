@@ -0,0 +1,101 @@
+package admission
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_FileArchiver_Redact(t *testing.T) {
+	request := &v1.AdmissionRequest{
+		UID:    "req-1",
+		Object: runtime.RawExtension{Raw: []byte(`{"kind":"Secret","data":{"password":"c2VjcmV0"}}`)},
+	}
+	response := &v1.AdmissionResponse{UID: "req-1", Allowed: true}
+
+	record, err := NewArchiveRecord("hooks", "policy.example.com", request, response, []string{"object.data", "oldObject.data"})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, NewFileArchiver(dir).Archive(record))
+
+	files, err := filepath.Glob(filepath.Join(dir, "admission-*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	var stored ArchiveRecord
+	require.NoError(t, json.Unmarshal(data, &stored))
+	assert.Equal(t, "policy.example.com", stored.WebhookId)
+	assert.True(t, stored.Response.Allowed)
+	obj := stored.Request["object"].(map[string]interface{})
+	assert.Equal(t, "Secret", obj["kind"])
+	assert.Equal(t, RedactedValue, obj["data"])
+	assert.NotContains(t, string(data), "c2VjcmV0")
+}
+
+type blockingArchiver struct {
+	release chan struct{}
+	records []*ArchiveRecord
+}
+
+func (a *blockingArchiver) Archive(record *ArchiveRecord) error {
+	<-a.release
+	a.records = append(a.records, record)
+	return nil
+}
+
+func Test_AsyncArchiver(t *testing.T) {
+	archiver := &blockingArchiver{release: make(chan struct{})}
+	a := NewAsyncArchiver(archiver, 1)
+
+	// The first record is taken by the writer, the second waits in the queue.
+	require.NoError(t, a.Archive(&ArchiveRecord{WebhookId: "first"}))
+	require.Eventually(t, func() bool { return len(a.records) == 0 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, a.Archive(&ArchiveRecord{WebhookId: "second"}))
+	assert.ErrorIs(t, a.Archive(&ArchiveRecord{WebhookId: "third"}), ErrArchiveQueueFull)
+
+	close(archiver.release)
+	a.Stop()
+
+	require.Len(t, archiver.records, 2)
+	assert.Equal(t, "first", archiver.records[0].WebhookId)
+	assert.Equal(t, "second", archiver.records[1].WebhookId)
+	assert.Error(t, a.Archive(&ArchiveRecord{}))
+}
+
+func Test_HTTPArchiver(t *testing.T) {
+	records := make(chan ArchiveRecord, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var record ArchiveRecord
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+		records <- record
+		if record.WebhookId == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	a := NewHTTPArchiver(srv.URL)
+
+	require.NoError(t, a.Archive(&ArchiveRecord{WebhookId: "policy.example.com", Response: &v1.AdmissionResponse{Allowed: true}}))
+	stored := <-records
+	assert.Equal(t, "policy.example.com", stored.WebhookId)
+	assert.True(t, stored.Response.Allowed)
+
+	require.Error(t, a.Archive(&ArchiveRecord{WebhookId: "fail"}))
+	<-records
+}
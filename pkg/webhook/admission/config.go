@@ -18,6 +18,21 @@ type Metadata struct {
 	DebugName       string
 	LogLabels       map[string]string
 	MetricLabels    map[string]string
+	// Serving is a dedicated Service and certificate for the webhook. Nil means global settings.
+	Serving *ServingConfig
+	// Policy is a set of CEL validations for a ValidatingAdmissionPolicy. Only for validating webhooks.
+	Policy *PolicyConfig
+	// Limits protects the webhook from overload. Nil means no limits.
+	Limits *LimitsConfig
+}
+
+// ServingConfig defines a Service for the webhook clientConfig and a certificate
+// that is served by SNI for the hostname of this Service.
+type ServingConfig struct {
+	ServiceName    string
+	ServerCertPath string
+	ServerKeyPath  string
+	CAPath         string
 }
 
 type IWebhookConfig interface {
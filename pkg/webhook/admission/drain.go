@@ -0,0 +1,90 @@
+package admission
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Actions for webhook configurations on the operator shutdown.
+const (
+	ShutdownActionNone       = "none"
+	ShutdownActionUnregister = "unregister"
+	ShutdownActionFailOpen   = "fail-open"
+)
+
+// serverStopTimeout is a time to wait for in-flight requests.
+const serverStopTimeout = 10 * time.Second
+
+// Stop drains the webhook server before the operator exits. Webhook configurations
+// are deleted or switched to the Ignore failure policy, so the API server does not
+// reject requests while the operator is restarted. The server keeps serving requests
+// during the drain delay and then stops after in-flight requests are handled.
+func (m *WebhookManager) Stop() {
+	if m.Server == nil {
+		return
+	}
+
+	if m.HasShutdownAction() {
+		m.applyShutdownAction(m.Settings.ShutdownAction)
+		if m.Settings.ShutdownDrainDelay > 0 {
+			log.Infof("Serve admission requests for %s before stopping the webhook server", m.Settings.ShutdownDrainDelay.String())
+			time.Sleep(m.Settings.ShutdownDrainDelay)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverStopTimeout)
+	defer cancel()
+	err := m.Server.Stop(ctx)
+	if err != nil {
+		log.Errorf("Stop webhook server: %v", err)
+	} else {
+		log.Info("Webhook server stopped")
+	}
+
+	// Write requests handled before the stop.
+	if m.archiver != nil {
+		m.archiver.Stop()
+	}
+}
+
+// HasShutdownAction returns true if webhook configurations are updated on shutdown.
+// Requests should be drained before the queues are stopped in this case.
+func (m *WebhookManager) HasShutdownAction() bool {
+	if m.Settings == nil {
+		return false
+	}
+	action := m.Settings.ShutdownAction
+	return action == ShutdownActionUnregister || action == ShutdownActionFailOpen
+}
+
+func (m *WebhookManager) applyShutdownAction(action string) {
+	for confID, r := range m.ValidatingResources {
+		var err error
+		if action == ShutdownActionUnregister {
+			err = r.DeleteConfiguration()
+		} else {
+			err = r.FailOpen()
+		}
+		if err != nil {
+			log.Errorf("Shutdown: %s ValidatingWebhookConfiguration/%s: %v", action, r.opts.ConfigurationName, err)
+			continue
+		}
+		log.Infof("Shutdown: %s ValidatingWebhookConfiguration/%s for configuration '%s'", action, r.opts.ConfigurationName, confID)
+	}
+
+	for confID, r := range m.MutatingResources {
+		var err error
+		if action == ShutdownActionUnregister {
+			err = r.Unregister()
+		} else {
+			err = r.FailOpen()
+		}
+		if err != nil {
+			log.Errorf("Shutdown: %s MutatingWebhookConfiguration/%s: %v", action, r.opts.ConfigurationName, err)
+			continue
+		}
+		log.Infof("Shutdown: %s MutatingWebhookConfiguration/%s for configuration '%s'", action, r.opts.ConfigurationName, confID)
+	}
+}
@@ -0,0 +1,76 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flant/kube-client/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestManager(t *testing.T, action string) *WebhookManager {
+	fc := fake.NewFakeCluster(fake.ClusterVersionV121)
+
+	m := NewWebhookManager(fc.Client)
+	m.Namespace = "default"
+	vs := &WebhookSettings{}
+	vs.ConfigurationName = "webhook-configuration"
+	vs.ServiceName = "webhook-service"
+	vs.ServerKeyPath = "testdata/demo-certs/server-key.pem"
+	vs.ServerCertPath = "testdata/demo-certs/server.crt"
+	vs.CAPath = "testdata/demo-certs/ca.pem"
+	vs.ShutdownAction = action
+	m.Settings = vs
+	require.NoError(t, m.Init())
+
+	fail := v1.Fail
+	none := v1.SideEffectClassNone
+	m.AddValidatingWebhook(&ValidatingWebhookConfig{
+		ValidatingWebhook: &v1.ValidatingWebhook{
+			Name:          "test-validating",
+			FailurePolicy: &fail,
+			SideEffects:   &none,
+		},
+	})
+	for _, r := range m.ValidatingResources {
+		require.NoError(t, r.Register())
+	}
+	return m
+}
+
+func Test_Manager_Stop_FailOpen(t *testing.T) {
+	m := newTestManager(t, ShutdownActionFailOpen)
+
+	m.Stop()
+
+	conf, err := m.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+		Get(context.TODO(), "webhook-configuration-hooks", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, conf.Webhooks, 1)
+	assert.Equal(t, v1.Ignore, *conf.Webhooks[0].FailurePolicy)
+}
+
+func Test_Manager_Stop_Unregister(t *testing.T) {
+	m := newTestManager(t, ShutdownActionUnregister)
+
+	m.Stop()
+
+	list, err := m.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+		List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 0)
+}
+
+func Test_Manager_Stop_None(t *testing.T) {
+	m := newTestManager(t, ShutdownActionNone)
+
+	m.Stop()
+
+	conf, err := m.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+		Get(context.TODO(), "webhook-configuration-hooks", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.Fail, *conf.Webhooks[0].FailurePolicy)
+}
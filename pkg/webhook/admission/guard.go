@@ -0,0 +1,140 @@
+package admission
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/admissionregistration/v1"
+)
+
+// LimitsConfig protects API server admission latency from a slow or failing hook.
+// Zero values disable corresponding limits.
+type LimitsConfig struct {
+	// MaxConcurrent is a maximum number of requests handled by the hook at once.
+	MaxConcurrent int
+	// Rate is a number of requests per second, Burst is a bucket size for the rate limiter.
+	Rate  float64
+	Burst int
+	// FailureThreshold is a number of consecutive hook failures to open the circuit.
+	FailureThreshold int
+	// OpenDuration is a time to reject requests when the circuit is open.
+	OpenDuration time.Duration
+}
+
+// Kinds of admission webhooks.
+const (
+	WebhookKindValidating = "validating"
+	WebhookKindMutating   = "mutating"
+)
+
+// webhookKinds are kinds in the order of the hook lookup, see hook.Manager.DetectAdmissionEventType.
+var webhookKinds = []string{WebhookKindValidating, WebhookKindMutating}
+
+// Reasons to reject a request without running the hook.
+const (
+	RejectConcurrency = "concurrency"
+	RejectRate        = "rate"
+	RejectCircuitOpen = "circuit_open"
+)
+
+// webhookGuard applies LimitsConfig to requests for one webhook.
+type webhookGuard struct {
+	limits        LimitsConfig
+	failurePolicy v1.FailurePolicyType
+
+	sem     chan struct{}
+	limiter *rate.Limiter
+
+	m         sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func newWebhookGuard(limits LimitsConfig, failurePolicy *v1.FailurePolicyType) *webhookGuard {
+	g := &webhookGuard{
+		limits:        limits,
+		failurePolicy: v1.Fail,
+	}
+	if failurePolicy != nil {
+		g.failurePolicy = *failurePolicy
+	}
+	if limits.MaxConcurrent > 0 {
+		g.sem = make(chan struct{}, limits.MaxConcurrent)
+	}
+	if limits.Rate > 0 {
+		burst := limits.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		g.limiter = rate.NewLimiter(rate.Limit(limits.Rate), burst)
+	}
+	return g
+}
+
+// acquire returns a release function or a reason to reject the request.
+func (g *webhookGuard) acquire() (func(), string) {
+	if g.isOpen() {
+		return nil, RejectCircuitOpen
+	}
+	if g.limiter != nil && !g.limiter.Allow() {
+		return nil, RejectRate
+	}
+	if g.sem == nil {
+		return func() {}, ""
+	}
+	select {
+	case g.sem <- struct{}{}:
+		return func() { <-g.sem }, ""
+	default:
+		return nil, RejectConcurrency
+	}
+}
+
+func (g *webhookGuard) isOpen() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return time.Now().Before(g.openUntil)
+}
+
+// report counts consecutive failures and opens the circuit when the threshold is reached.
+// After the open period the next failure opens the circuit again.
+func (g *webhookGuard) report(failed bool) {
+	if g.limits.FailureThreshold <= 0 {
+		return
+	}
+	g.m.Lock()
+	defer g.m.Unlock()
+	if !failed {
+		g.failures = 0
+		return
+	}
+	g.failures++
+	if g.failures >= g.limits.FailureThreshold {
+		g.openUntil = time.Now().Add(g.limits.OpenDuration)
+	}
+}
+
+// rejectedResponse returns a response for the rejected request according to the failurePolicy.
+func (g *webhookGuard) rejectedResponse(reason string) *Response {
+	msg := fmt.Sprintf("hook is not called: %s limit is reached", reason)
+	code := int32(http.StatusTooManyRequests)
+	if reason == RejectCircuitOpen {
+		msg = "hook is not called: circuit is open after repeated hook failures"
+		code = http.StatusServiceUnavailable
+	}
+
+	if g.failurePolicy == v1.Ignore {
+		return &Response{
+			Allowed:  true,
+			Warnings: []string{msg},
+		}
+	}
+	return &Response{
+		Allowed: false,
+		Message: msg,
+		Code:    code,
+	}
+}
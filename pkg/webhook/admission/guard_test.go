@@ -0,0 +1,100 @@
+package admission
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/admissionregistration/v1"
+)
+
+func Test_WebhookGuard_Concurrency(t *testing.T) {
+	g := newWebhookGuard(LimitsConfig{MaxConcurrent: 1}, nil)
+
+	release, reason := g.acquire()
+	assert.Empty(t, reason)
+
+	_, reason = g.acquire()
+	assert.Equal(t, RejectConcurrency, reason)
+
+	release()
+	_, reason = g.acquire()
+	assert.Empty(t, reason)
+}
+
+func Test_WebhookGuard_CircuitBreaker(t *testing.T) {
+	g := newWebhookGuard(LimitsConfig{FailureThreshold: 2, OpenDuration: 50 * time.Millisecond}, nil)
+
+	g.report(true)
+	_, reason := g.acquire()
+	assert.Empty(t, reason, "circuit should be closed after one failure")
+
+	g.report(true)
+	_, reason = g.acquire()
+	assert.Equal(t, RejectCircuitOpen, reason)
+
+	time.Sleep(60 * time.Millisecond)
+	_, reason = g.acquire()
+	assert.Empty(t, reason, "circuit should be closed after open duration")
+
+	// The next failure opens the circuit again.
+	g.report(true)
+	_, reason = g.acquire()
+	assert.Equal(t, RejectCircuitOpen, reason)
+}
+
+func Test_WebhookGuard_FailurePolicy(t *testing.T) {
+	ignore := v1.Ignore
+	resp := newWebhookGuard(LimitsConfig{}, &ignore).rejectedResponse(RejectRate)
+	assert.True(t, resp.Allowed)
+	assert.Len(t, resp.Warnings, 1)
+
+	resp = newWebhookGuard(LimitsConfig{}, nil).rejectedResponse(RejectCircuitOpen)
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+}
+
+func Test_WebhookHandler_Limits(t *testing.T) {
+	runs := 0
+	h := &WebhookHandler{
+		Handler: func(_ Event) (*Response, error) {
+			runs++
+			return nil, fmt.Errorf("hook error")
+		},
+	}
+	h.SetLimits(WebhookKindValidating, "hooks", "policy.example.com", LimitsConfig{FailureThreshold: 1, OpenDuration: time.Minute}, nil)
+
+	event := Event{ConfigurationId: "hooks", WebhookId: "policy.example.com"}
+	_, rejected, err := h.handleEvent(event)
+	assert.Error(t, err)
+	assert.False(t, rejected)
+
+	resp, rejected, err := h.handleEvent(event)
+	assert.NoError(t, err)
+	assert.True(t, rejected)
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, 1, runs, "hook should not run when the circuit is open")
+}
+
+func Test_WebhookHandler_Limits_Kinds(t *testing.T) {
+	runs := 0
+	h := &WebhookHandler{
+		Handler: func(_ Event) (*Response, error) {
+			runs++
+			return &Response{Allowed: true}, nil
+		},
+	}
+	// Limits of the mutating webhook do not replace the validating webhook with the same ids.
+	h.SetLimits(WebhookKindValidating, "hooks", "policy.example.com", LimitsConfig{}, nil)
+	h.SetLimits(WebhookKindMutating, "hooks", "policy.example.com", LimitsConfig{Rate: 0.001, Burst: 1}, nil)
+
+	event := Event{ConfigurationId: "hooks", WebhookId: "policy.example.com"}
+	for i := 0; i < 3; i++ {
+		_, rejected, err := h.handleEvent(event)
+		assert.NoError(t, err)
+		assert.False(t, rejected)
+	}
+	assert.Equal(t, 3, runs)
+}
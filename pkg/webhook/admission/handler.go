@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/flant/shell-operator/pkg/metric_storage"
 	structured_logger "github.com/flant/shell-operator/pkg/utils/structured-logger"
 )
 
@@ -20,6 +23,23 @@ type EventHandlerFn func(event Event) (*Response, error)
 type WebhookHandler struct {
 	Router  chi.Router
 	Handler EventHandlerFn
+	// Archiver stores handled requests if set.
+	Archiver    Archiver
+	RedactPaths []string
+
+	// guards apply limits to webhooks by "kind/configurationId/webhookId".
+	guards map[string]*webhookGuard
+
+	// MetricStorage is used to expose request metrics if set.
+	MetricStorage *metric_storage.MetricStorage
+	// webhookMetrics are metric labels and timeouts by "configurationId/webhookId".
+	webhookMetrics map[string]webhookMetrics
+}
+
+type webhookMetrics struct {
+	webhookType string
+	labels      map[string]string
+	timeout     time.Duration
 }
 
 func NewWebhookHandler() *WebhookHandler {
@@ -70,6 +90,7 @@ func (h *WebhookHandler) serveReviewRequest(w http.ResponseWriter, r *http.Reque
 	}
 
 	admissionReview.Response.UID = admissionReview.Request.UID
+	h.archive(r.URL.Path, admissionReview.Request, admissionReview.Response)
 	admissionReview.Request = nil
 
 	w.Header().Set("Content-type", "application/json")
@@ -96,7 +117,9 @@ func (h *WebhookHandler) handleReviewRequest(path string, request *v1.AdmissionR
 		Request:         request,
 	}
 
-	admissionResponse, err := h.Handler(event)
+	start := time.Now()
+	admissionResponse, rejected, err := h.handleEvent(event)
+	h.recordMetrics(event, request, time.Since(start), admissionResponse, rejected, err)
 	if err != nil {
 		return nil, err
 	}
@@ -109,8 +132,12 @@ func (h *WebhookHandler) handleReviewRequest(path string, request *v1.AdmissionR
 	}
 
 	if !admissionResponse.Allowed {
+		code := int32(http.StatusForbidden)
+		if admissionResponse.Code != 0 {
+			code = admissionResponse.Code
+		}
 		response.Result = &metav1.Status{
-			Code:    http.StatusForbidden,
+			Code:    code,
 			Message: admissionResponse.Message,
 		}
 	}
@@ -128,6 +155,116 @@ func (h *WebhookHandler) handleReviewRequest(path string, request *v1.AdmissionR
 	return response, nil
 }
 
+// SetLimits protects the webhook of the kind with concurrency, rate and circuit breaker limits.
+// Empty limits only register the webhook of the kind.
+func (h *WebhookHandler) SetLimits(kind string, configurationID string, webhookID string, limits LimitsConfig, failurePolicy *admissionregistrationv1.FailurePolicyType) {
+	if h.guards == nil {
+		h.guards = make(map[string]*webhookGuard)
+	}
+	h.guards[kind+"/"+configurationID+"/"+webhookID] = newWebhookGuard(limits, failurePolicy)
+}
+
+// eventGuard returns a guard of the webhook for the event. Requests have no webhook kind,
+// so a validating webhook is preferred if both kinds share ids, the same way as hooks are found.
+func (h *WebhookHandler) eventGuard(event Event) (*webhookGuard, bool) {
+	for _, kind := range webhookKinds {
+		if guard, has := h.guards[kind+"/"+event.ConfigurationId+"/"+event.WebhookId]; has {
+			return guard, true
+		}
+	}
+	return nil, false
+}
+
+// handleEvent runs the handler if limits for the webhook allow it.
+// It returns true if the request is rejected by limits.
+func (h *WebhookHandler) handleEvent(event Event) (*Response, bool, error) {
+	guard, has := h.eventGuard(event)
+	if !has {
+		admissionResponse, err := h.Handler(event)
+		return admissionResponse, false, err
+	}
+
+	release, rejectReason := guard.acquire()
+	if rejectReason != "" {
+		log.Warnf("AdmissionReview request for confId='%s' webhookId='%s' is rejected: %s", event.ConfigurationId, event.WebhookId, rejectReason)
+		return guard.rejectedResponse(rejectReason), true, nil
+	}
+	defer release()
+
+	admissionResponse, err := h.Handler(event)
+	guard.report(err != nil || (admissionResponse != nil && admissionResponse.HookFailed))
+	return admissionResponse, false, err
+}
+
+// SetMetricLabels defines labels for request metrics of the webhook. The timeout is used
+// to count requests that were handled longer than API server waits for the response.
+func (h *WebhookHandler) SetMetricLabels(configurationID string, webhookID string, webhookType string, labels map[string]string, timeout time.Duration) {
+	if h.webhookMetrics == nil {
+		h.webhookMetrics = make(map[string]webhookMetrics)
+	}
+	h.webhookMetrics[configurationID+"/"+webhookID] = webhookMetrics{
+		webhookType: webhookType,
+		labels:      labels,
+		timeout:     timeout,
+	}
+}
+
+func (h *WebhookHandler) recordMetrics(event Event, request *v1.AdmissionRequest, duration time.Duration, response *Response, rejected bool, err error) {
+	if h.MetricStorage == nil {
+		return
+	}
+	info := h.webhookMetrics[event.ConfigurationId+"/"+event.WebhookId]
+
+	labels := map[string]string{
+		"hook":      info.labels["hook"],
+		"binding":   info.labels["binding"],
+		"type":      info.webhookType,
+		"resource":  requestResource(request),
+		"operation": string(request.Operation),
+	}
+	h.MetricStorage.HistogramObserve("{PREFIX}admission_request_duration_seconds", duration.Seconds(), labels, nil)
+	if info.timeout > 0 && duration > info.timeout {
+		h.MetricStorage.CounterAdd("{PREFIX}admission_request_timeouts_total", 1.0, labels)
+	}
+
+	result := "allowed"
+	switch {
+	case err != nil || (response != nil && response.HookFailed):
+		result = "error"
+	case rejected:
+		result = "rejected"
+	case response == nil || !response.Allowed:
+		result = "denied"
+	}
+	resultLabels := map[string]string{"result": result}
+	for k, v := range labels {
+		resultLabels[k] = v
+	}
+	h.MetricStorage.CounterAdd("{PREFIX}admission_requests_total", 1.0, resultLabels)
+}
+
+// requestResource returns a resource in the "group/resource" form or just a resource for the core group.
+func requestResource(request *v1.AdmissionRequest) string {
+	if request.Resource.Group == "" {
+		return request.Resource.Resource
+	}
+	return request.Resource.Group + "/" + request.Resource.Resource
+}
+
+func (h *WebhookHandler) archive(path string, request *v1.AdmissionRequest, response *v1.AdmissionResponse) {
+	if h.Archiver == nil {
+		return
+	}
+	configurationID, webhookID := detectConfigurationAndWebhook(path)
+	record, err := NewArchiveRecord(configurationID, webhookID, request, response, h.RedactPaths)
+	if err == nil {
+		err = h.Archiver.Archive(record)
+	}
+	if err != nil {
+		log.Errorf("Archive AdmissionReview request '%s': %v", request.UID, err)
+	}
+}
+
 // detectConfigurationAndWebhook extracts configurationID and a webhookID from the url path.
 func detectConfigurationAndWebhook(path string) (configurationID string, webhookID string) {
 	parts := strings.Split(path, "/")
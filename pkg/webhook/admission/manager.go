@@ -1,11 +1,14 @@
 package admission
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/metric_storage"
 	"github.com/flant/shell-operator/pkg/webhook/server"
 )
 
@@ -31,6 +34,10 @@ type WebhookManager struct {
 	ValidatingResources map[string]*ValidatingWebhookResource
 	MutatingResources   map[string]*MutatingWebhookResource
 	Handler             *WebhookHandler
+
+	archiver      *AsyncArchiver
+	storage       Archiver
+	metricStorage *metric_storage.MetricStorage
 }
 
 func NewWebhookManager(kubeClient *klient.Client) *WebhookManager {
@@ -51,6 +58,12 @@ func (m *WebhookManager) WithAdmissionEventHandler(handler EventHandlerFn) {
 	}
 }
 
+// WithArchiver sets a custom storage for handled requests. It takes precedence over
+// ArchiveDir and ArchiveURL settings.
+func (m *WebhookManager) WithArchiver(archiver Archiver) {
+	m.storage = archiver
+}
+
 // Init creates dependencies
 func (m *WebhookManager) Init() error {
 	log.Info("Initialize admission webhooks manager. Load certificates.")
@@ -66,6 +79,15 @@ func (m *WebhookManager) Init() error {
 	m.Settings.CABundle = caBundleBytes
 
 	m.Handler = NewWebhookHandler()
+	storage, err := m.archiveStorage()
+	if err != nil {
+		return err
+	}
+	if storage != nil {
+		m.archiver = NewAsyncArchiver(storage, DefaultArchiveQueueSize)
+		m.Handler.Archiver = m.archiver
+		m.Handler.RedactPaths = m.Settings.ArchiveRedactPaths
+	}
 
 	m.Server = &server.WebhookServer{
 		Settings:  &m.Settings.Settings,
@@ -76,6 +98,26 @@ func (m *WebhookManager) Init() error {
 	return nil
 }
 
+// archiveStorage returns a storage for handled requests or nil if archiving is disabled.
+func (m *WebhookManager) archiveStorage() (Archiver, error) {
+	if m.storage != nil {
+		log.Info("Archive admission requests to the custom storage")
+		return m.storage, nil
+	}
+	if m.Settings.ArchiveDir != "" && m.Settings.ArchiveURL != "" {
+		return nil, fmt.Errorf("archive dir and archive URL should not be set together")
+	}
+	if m.Settings.ArchiveDir != "" {
+		log.Infof("Archive admission requests to '%s'", m.Settings.ArchiveDir)
+		return NewFileArchiver(m.Settings.ArchiveDir), nil
+	}
+	if m.Settings.ArchiveURL != "" {
+		log.Infof("Archive admission requests to '%s'", m.Settings.ArchiveURL)
+		return NewHTTPArchiver(m.Settings.ArchiveURL), nil
+	}
+	return nil, nil
+}
+
 func (m *WebhookManager) AddValidatingWebhook(config *ValidatingWebhookConfig) {
 	confId := config.Metadata.ConfigurationId
 	if confId == "" {
@@ -90,6 +132,8 @@ func (m *WebhookManager) AddValidatingWebhook(config *ValidatingWebhookConfig) {
 				m.Settings.ConfigurationName + "-" + confId,
 				m.Settings.ServiceName,
 				m.Settings.CABundle,
+				m.Settings.AdmissionPolicies,
+				m.managedLabels(),
 			},
 		)
 		m.ValidatingResources[confId] = r
@@ -112,6 +156,8 @@ func (m *WebhookManager) AddMutatingWebhook(config *MutatingWebhookConfig) {
 				m.Settings.ConfigurationName + "-" + confId,
 				m.Settings.ServiceName,
 				m.Settings.CABundle,
+				false,
+				m.managedLabels(),
 			},
 		)
 		m.MutatingResources[confId] = r
@@ -120,6 +166,9 @@ func (m *WebhookManager) AddMutatingWebhook(config *MutatingWebhookConfig) {
 }
 
 func (m *WebhookManager) Start() error {
+	m.addSNICertificates()
+	m.setupHandler()
+
 	err := m.Server.Start()
 	if err != nil {
 		return err
@@ -139,5 +188,69 @@ func (m *WebhookManager) Start() error {
 		}
 	}
 
-	return nil
+	return m.Prune()
+}
+
+// addSNICertificates adds certificates of webhooks with dedicated Services to the server.
+func (m *WebhookManager) addSNICertificates() {
+	servings := make([]*ServingConfig, 0)
+	for _, r := range m.ValidatingResources {
+		for _, webhook := range r.hooks {
+			servings = append(servings, webhook.Metadata.Serving)
+		}
+	}
+	for _, r := range m.MutatingResources {
+		for _, webhook := range r.hooks {
+			servings = append(servings, webhook.Metadata.Serving)
+		}
+	}
+
+	for _, serving := range servings {
+		if serving == nil {
+			continue
+		}
+		m.Server.AddSNICertificate(server.SNICertificate{
+			ServiceName:    serving.ServiceName,
+			ServerCertPath: serving.ServerCertPath,
+			ServerKeyPath:  serving.ServerKeyPath,
+		})
+	}
+}
+
+// setupHandler configures the handler to protect webhooks with limits and to expose metrics.
+func (m *WebhookManager) setupHandler() {
+	m.Handler.MetricStorage = m.metricStorage
+	for confID, r := range m.ValidatingResources {
+		for _, webhook := range r.hooks {
+			m.Handler.SetLimits(WebhookKindValidating, confID, webhook.Metadata.WebhookId, webhookLimits(webhook.Metadata), webhook.FailurePolicy)
+			m.Handler.SetMetricLabels(confID, webhook.Metadata.WebhookId, "validating", webhook.Metadata.MetricLabels, timeoutSeconds(webhook.TimeoutSeconds))
+		}
+	}
+	for confID, r := range m.MutatingResources {
+		for _, webhook := range r.hooks {
+			m.Handler.SetLimits(WebhookKindMutating, confID, webhook.Metadata.WebhookId, webhookLimits(webhook.Metadata), webhook.FailurePolicy)
+			m.Handler.SetMetricLabels(confID, webhook.Metadata.WebhookId, "mutating", webhook.Metadata.MetricLabels, timeoutSeconds(webhook.TimeoutSeconds))
+		}
+	}
+}
+
+// webhookLimits returns limits of the webhook. Webhooks without limits are registered
+// with empty limits, so their requests are not limited by a webhook of another kind.
+func webhookLimits(meta Metadata) LimitsConfig {
+	if meta.Limits == nil {
+		return LimitsConfig{}
+	}
+	return *meta.Limits
+}
+
+func timeoutSeconds(seconds *int32) time.Duration {
+	if seconds == nil {
+		return 0
+	}
+	return time.Duration(*seconds) * time.Second
+}
+
+// WithMetricStorage sets a storage for admission request metrics.
+func (m *WebhookManager) WithMetricStorage(mstor *metric_storage.MetricStorage) {
+	m.metricStorage = mstor
 }
@@ -0,0 +1,175 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ValidatingAdmissionPolicy and its binding are available in admissionregistration.k8s.io/v1 since Kubernetes 1.30.
+var (
+	policyGVR        = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingadmissionpolicies"}
+	policyBindingGVR = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingadmissionpolicybindings"}
+)
+
+const policyManagedByLabel = "app.kubernetes.io/managed-by"
+
+// PolicyConfig is a set of CEL validations compiled into a ValidatingAdmissionPolicy.
+type PolicyConfig struct {
+	Validations []PolicyValidation
+	// SkipWebhook disables the webhook for the binding: all checks are made by the policy.
+	SkipWebhook bool
+}
+
+// PolicyValidation is a CEL expression with a message for denied requests.
+type PolicyValidation struct {
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type policySpec struct {
+	FailurePolicy    *v1.FailurePolicyType `json:"failurePolicy,omitempty"`
+	MatchConstraints policyMatchResources  `json:"matchConstraints"`
+	Validations      []PolicyValidation    `json:"validations"`
+}
+
+type policyMatchResources struct {
+	NamespaceSelector *metav1.LabelSelector   `json:"namespaceSelector,omitempty"`
+	ObjectSelector    *metav1.LabelSelector   `json:"objectSelector,omitempty"`
+	ResourceRules     []v1.RuleWithOperations `json:"resourceRules"`
+	MatchPolicy       *v1.MatchPolicyType     `json:"matchPolicy,omitempty"`
+}
+
+type policyBindingSpec struct {
+	PolicyName        string   `json:"policyName"`
+	ValidationActions []string `json:"validationActions"`
+}
+
+// policyName returns a name for ValidatingAdmissionPolicy and ValidatingAdmissionPolicyBinding.
+func (w *ValidatingWebhookResource) policyName(webhook *ValidatingWebhookConfig) string {
+	return w.opts.ConfigurationName + "-" + webhook.Metadata.WebhookId
+}
+
+// registerPolicies creates or updates policies for webhooks with CEL validations.
+// It returns false if ValidatingAdmissionPolicy API is not available in the cluster.
+func (w *ValidatingWebhookResource) registerPolicies() (bool, error) {
+	for _, webhook := range w.hooks {
+		if webhook.Metadata.Policy == nil {
+			continue
+		}
+		name := w.policyName(webhook)
+
+		policy, err := toUnstructured("ValidatingAdmissionPolicy", name, policySpec{
+			FailurePolicy: webhook.FailurePolicy,
+			MatchConstraints: policyMatchResources{
+				NamespaceSelector: webhook.NamespaceSelector,
+				ObjectSelector:    webhook.ObjectSelector,
+				ResourceRules:     webhook.Rules,
+				MatchPolicy:       webhook.MatchPolicy,
+			},
+			Validations: webhook.Metadata.Policy.Validations,
+		})
+		if err != nil {
+			return true, err
+		}
+		binding, err := toUnstructured("ValidatingAdmissionPolicyBinding", name, policyBindingSpec{
+			PolicyName:        name,
+			ValidationActions: []string{"Deny"},
+		})
+		if err != nil {
+			return true, err
+		}
+
+		err = applyUnstructured(w.opts.KubeClient.Dynamic().Resource(policyGVR), policy)
+		if isNoAPIError(err) {
+			return false, nil
+		}
+		if err != nil {
+			return true, fmt.Errorf("apply ValidatingAdmissionPolicy/%s: %v", name, err)
+		}
+		err = applyUnstructured(w.opts.KubeClient.Dynamic().Resource(policyBindingGVR), binding)
+		if err != nil {
+			return true, fmt.Errorf("apply ValidatingAdmissionPolicyBinding/%s: %v", name, err)
+		}
+		log.Infof("Apply ValidatingAdmissionPolicy/%s with %d validations", name, len(webhook.Metadata.Policy.Validations))
+	}
+	return true, nil
+}
+
+// unregisterPolicies deletes policies created for webhooks.
+func (w *ValidatingWebhookResource) unregisterPolicies() error {
+	for _, webhook := range w.hooks {
+		if webhook.Metadata.Policy == nil {
+			continue
+		}
+		name := w.policyName(webhook)
+		for _, gvr := range []schema.GroupVersionResource{policyBindingGVR, policyGVR} {
+			err := w.opts.KubeClient.Dynamic().Resource(gvr).Delete(context.TODO(), name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) && !isNoAPIError(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func toUnstructured(kind string, name string, spec interface{}) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	specMap := make(map[string]interface{})
+	if err := json.Unmarshal(data, &specMap); err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": policyGVR.GroupVersion().String(),
+		"kind":       kind,
+		"spec":       specMap,
+	}}
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{policyManagedByLabel: "shell-operator"})
+	return obj, nil
+}
+
+func applyUnstructured(client dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+	existing, err := client.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) && !isNoAPIError(err) {
+		_, err = client.Create(context.TODO(), obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = client.Update(context.TODO(), obj, metav1.UpdateOptions{})
+	return err
+}
+
+// isNoAPIError returns true if the resource is not served by the API server.
+func isNoAPIError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if meta.IsNoMatchError(err) {
+		return true
+	}
+	// Dynamic client returns NotFound without details for unknown resources.
+	var statusErr *apierrors.StatusError
+	if apierrors.IsNotFound(err) && errors.As(err, &statusErr) {
+		return statusErr.ErrStatus.Details == nil || statusErr.ErrStatus.Details.Name == ""
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_PolicyToUnstructured(t *testing.T) {
+	fail := v1.Fail
+	obj, err := toUnstructured("ValidatingAdmissionPolicy", "shell-operator-hooks-replicas.example.com", policySpec{
+		FailurePolicy: &fail,
+		MatchConstraints: policyMatchResources{
+			ResourceRules: []v1.RuleWithOperations{{
+				Operations: []v1.OperationType{v1.Create},
+				Rule: v1.Rule{
+					APIGroups:   []string{"apps"},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"deployments"},
+				},
+			}},
+		},
+		Validations: []PolicyValidation{{Expression: "object.spec.replicas <= 5"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "admissionregistration.k8s.io/v1", obj.GetAPIVersion())
+	assert.Equal(t, "shell-operator", obj.GetLabels()[policyManagedByLabel])
+
+	rules, found, err := unstructured.NestedSlice(obj.Object, "spec", "matchConstraints", "resourceRules")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, rules, 1)
+	// Rule fields are inlined as in NamedRuleWithOperations.
+	assert.Equal(t, []interface{}{"deployments"}, rules[0].(map[string]interface{})["resources"])
+
+	validations, _, _ := unstructured.NestedSlice(obj.Object, "spec", "validations")
+	assert.Equal(t, "object.spec.replicas <= 5", validations[0].(map[string]interface{})["expression"])
+}
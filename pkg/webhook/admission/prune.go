@@ -0,0 +1,87 @@
+package admission
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/flant/shell-operator/pkg/kube/read_only"
+)
+
+// Labels for webhook configurations created by the operator.
+const (
+	ManagedByLabel         = "app.kubernetes.io/managed-by"
+	ManagedByValue         = "shell-operator"
+	ConfigurationNameLabel = "shell-operator.flant.com/configuration-name"
+)
+
+// managedLabels returns nil if the configuration name is not a valid label value.
+func (m *WebhookManager) managedLabels() map[string]string {
+	if len(validation.IsValidLabelValue(m.Settings.ConfigurationName)) > 0 {
+		return nil
+	}
+	return map[string]string{
+		ManagedByLabel:         ManagedByValue,
+		ConfigurationNameLabel: m.Settings.ConfigurationName,
+	}
+}
+
+// Prune deletes webhook configurations created by the operator for configuration ids
+// that are no longer used by hooks. It is called on Start and should be called after
+// webhooks are changed, so removed hooks do not leave orphaned webhooks that block the cluster.
+func (m *WebhookManager) Prune() error {
+	if read_only.Skip("admission", "prune", "stale webhook configurations") {
+		return nil
+	}
+
+	if m.KubeClient == nil || m.managedLabels() == nil {
+		return nil
+	}
+	selector := labels.SelectorFromSet(m.managedLabels()).String()
+
+	validating := m.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	vList, err := validating.List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	expected := make(map[string]bool)
+	for _, r := range m.ValidatingResources {
+		expected[r.opts.ConfigurationName] = true
+	}
+	for _, item := range vList.Items {
+		if expected[item.Name] {
+			continue
+		}
+		err = validating.Delete(context.TODO(), item.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Infof("Prune stale ValidatingWebhookConfiguration/%s", item.Name)
+	}
+
+	mutating := m.KubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	mList, err := mutating.List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	expected = make(map[string]bool)
+	for _, r := range m.MutatingResources {
+		expected[r.opts.ConfigurationName] = true
+	}
+	for _, item := range mList.Items {
+		if expected[item.Name] {
+			continue
+		}
+		err = mutating.Delete(context.TODO(), item.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Infof("Prune stale MutatingWebhookConfiguration/%s", item.Name)
+	}
+
+	return nil
+}
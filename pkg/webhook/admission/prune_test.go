@@ -0,0 +1,45 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Manager_Prune(t *testing.T) {
+	m := newTestManager(t, ShutdownActionNone)
+	client := m.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+
+	// Registered configuration is labeled.
+	conf, err := client.Get(context.TODO(), "webhook-configuration-hooks", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ManagedByValue, conf.Labels[ManagedByLabel])
+	assert.Equal(t, "webhook-configuration", conf.Labels[ConfigurationNameLabel])
+
+	// A configuration for removed hooks and a configuration of another operator.
+	stale := &v1.ValidatingWebhookConfiguration{}
+	stale.Name = "webhook-configuration-removed"
+	stale.Labels = m.managedLabels()
+	_, err = client.Create(context.TODO(), stale, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	foreign := &v1.ValidatingWebhookConfiguration{}
+	foreign.Name = "other-operator-hooks"
+	foreign.Labels = map[string]string{ManagedByLabel: ManagedByValue, ConfigurationNameLabel: "other-operator"}
+	_, err = client.Create(context.TODO(), foreign, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Prune())
+
+	list, err := client.List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	names := make([]string, 0)
+	for _, item := range list.Items {
+		names = append(names, item.Name)
+	}
+	assert.ElementsMatch(t, []string{"webhook-configuration-hooks", "other-operator-hooks"}, names)
+}
@@ -2,6 +2,8 @@ package admission
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -9,6 +11,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
 )
 
 type WebhookResourceOptions struct {
@@ -17,6 +20,10 @@ type WebhookResourceOptions struct {
 	ConfigurationName string
 	ServiceName       string
 	CABundle          []byte
+	// AdmissionPolicies enables ValidatingAdmissionPolicy generation for webhooks with CEL validations.
+	AdmissionPolicies bool
+	// Labels mark configurations created by the operator to prune stale ones.
+	Labels map[string]string
 }
 
 type ValidatingWebhookResource struct {
@@ -40,23 +47,40 @@ func (w *ValidatingWebhookResource) Get(id string) *ValidatingWebhookConfig {
 }
 
 func (w *ValidatingWebhookResource) Register() error {
+	if read_only.Skip("admission", "register", "ValidatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
+
 	configuration := &v1.ValidatingWebhookConfiguration{
 		Webhooks: []v1.ValidatingWebhook{},
 	}
 	configuration.Name = w.opts.ConfigurationName
 
+	policiesAvailable := false
+	if w.opts.AdmissionPolicies {
+		var err error
+		policiesAvailable, err = w.registerPolicies()
+		if err != nil {
+			return err
+		}
+		if !policiesAvailable {
+			log.Warnf("ValidatingAdmissionPolicy API is not available, use webhooks for all bindings in '%s'", w.opts.ConfigurationName)
+		}
+	}
+
 	for _, webhook := range w.hooks {
+		if policiesAvailable && webhook.Metadata.Policy != nil && webhook.Metadata.Policy.SkipWebhook {
+			log.Infof("Skip webhook '%s' in '%s': it is handled by the ValidatingAdmissionPolicy", webhook.Metadata.WebhookId, w.opts.ConfigurationName)
+			continue
+		}
 		equivalent := v1.Equivalent
 		webhook.MatchPolicy = &equivalent
 		webhook.AdmissionReviewVersions = []string{"v1", "v1beta1"}
-		webhook.ClientConfig = v1.WebhookClientConfig{
-			Service: &v1.ServiceReference{
-				Namespace: w.opts.Namespace,
-				Name:      w.opts.ServiceName,
-				Path:      createWebhookPath(IWebhookConfig(webhook)),
-			},
-			CABundle: w.opts.CABundle,
+		clientConfig, err := w.opts.clientConfig(IWebhookConfig(webhook))
+		if err != nil {
+			return err
 		}
+		webhook.ClientConfig = clientConfig
 
 		log.Infof("Add '%s' path to '%s'", *webhook.ClientConfig.Service.Path, w.opts.ConfigurationName)
 
@@ -67,10 +91,71 @@ func (w *ValidatingWebhookResource) Register() error {
 }
 
 func (w *ValidatingWebhookResource) Unregister() error {
+	if read_only.Skip("admission", "unregister", "ValidatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
+
+	if w.opts.AdmissionPolicies {
+		err := w.unregisterPolicies()
+		if err != nil {
+			return err
+		}
+	}
+	return w.DeleteConfiguration()
+}
+
+// DeleteConfiguration deletes ValidatingWebhookConfiguration and keeps policies.
+func (w *ValidatingWebhookResource) DeleteConfiguration() error {
+	if read_only.Skip("admission", "delete", "ValidatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
 	return w.opts.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().
 		Delete(context.TODO(), w.opts.ConfigurationName, metav1.DeleteOptions{})
 }
 
+// FailOpen sets the Ignore failure policy for all webhooks in the ValidatingWebhookConfiguration.
+func (w *ValidatingWebhookResource) FailOpen() error {
+	if read_only.Skip("admission", "fail-open", "ValidatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
+
+	client := w.opts.KubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	conf, err := client.Get(context.TODO(), w.opts.ConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	ignore := v1.Ignore
+	for i := range conf.Webhooks {
+		conf.Webhooks[i].FailurePolicy = &ignore
+	}
+	_, err = client.Update(context.TODO(), conf, metav1.UpdateOptions{})
+	return err
+}
+
+// clientConfig returns a clientConfig with the global Service and CA bundle
+// or with the dedicated ones if webhook has its own serving settings.
+func (o WebhookResourceOptions) clientConfig(webhook IWebhookConfig) (v1.WebhookClientConfig, error) {
+	serviceName := o.ServiceName
+	caBundle := o.CABundle
+	if serving := webhook.GetMeta().Serving; serving != nil {
+		ca, err := os.ReadFile(serving.CAPath)
+		if err != nil {
+			return v1.WebhookClientConfig{}, fmt.Errorf("load CA for webhook '%s': %v", webhook.GetMeta().WebhookId, err)
+		}
+		serviceName = serving.ServiceName
+		caBundle = ca
+	}
+
+	return v1.WebhookClientConfig{
+		Service: &v1.ServiceReference{
+			Namespace: o.Namespace,
+			Name:      serviceName,
+			Path:      createWebhookPath(webhook),
+		},
+		CABundle: caBundle,
+	}, nil
+}
+
 func createWebhookPath(webhook IWebhookConfig) *string {
 	s := new(strings.Builder)
 
@@ -99,6 +184,7 @@ func (w *ValidatingWebhookResource) submit(conf *v1.ValidatingWebhookConfigurati
 		return err
 	}
 	if len(list.Items) == 0 {
+		conf.Labels = w.opts.Labels
 		_, err = client.Create(context.TODO(), conf, metav1.CreateOptions{})
 		if err != nil {
 			log.Errorf("Create ValidatingWebhookConfiguration/%s: %v", conf.Name, err)
@@ -106,6 +192,7 @@ func (w *ValidatingWebhookResource) submit(conf *v1.ValidatingWebhookConfigurati
 	} else {
 		newConf := list.Items[0]
 		newConf.Webhooks = conf.Webhooks
+		newConf.Labels = mergeLabels(newConf.Labels, w.opts.Labels)
 		_, err = client.Update(context.TODO(), &newConf, metav1.UpdateOptions{})
 		if err != nil {
 			log.Errorf("Replace ValidatingWebhookConfiguration/%s: %v", conf.Name, err)
@@ -135,6 +222,10 @@ func (w *MutatingWebhookResource) Get(id string) *MutatingWebhookConfig {
 }
 
 func (w *MutatingWebhookResource) Register() error {
+	if read_only.Skip("admission", "register", "MutatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
+
 	configuration := &v1.MutatingWebhookConfiguration{
 		Webhooks: []v1.MutatingWebhook{},
 	}
@@ -144,14 +235,11 @@ func (w *MutatingWebhookResource) Register() error {
 		equivalent := v1.Equivalent
 		webhook.MatchPolicy = &equivalent
 		webhook.AdmissionReviewVersions = []string{"v1", "v1beta1"}
-		webhook.ClientConfig = v1.WebhookClientConfig{
-			Service: &v1.ServiceReference{
-				Namespace: w.opts.Namespace,
-				Name:      w.opts.ServiceName,
-				Path:      createWebhookPath(IWebhookConfig(webhook)),
-			},
-			CABundle: w.opts.CABundle,
+		clientConfig, err := w.opts.clientConfig(IWebhookConfig(webhook))
+		if err != nil {
+			return err
 		}
+		webhook.ClientConfig = clientConfig
 
 		log.Infof("Add '%s' path to '%s'", *webhook.ClientConfig.Service.Path, w.opts.ConfigurationName)
 
@@ -162,10 +250,32 @@ func (w *MutatingWebhookResource) Register() error {
 }
 
 func (w *MutatingWebhookResource) Unregister() error {
+	if read_only.Skip("admission", "unregister", "MutatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
 	return w.opts.KubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations().
 		Delete(context.TODO(), w.opts.ConfigurationName, metav1.DeleteOptions{})
 }
 
+// FailOpen sets the Ignore failure policy for all webhooks in the MutatingWebhookConfiguration.
+func (w *MutatingWebhookResource) FailOpen() error {
+	if read_only.Skip("admission", "fail-open", "MutatingWebhookConfiguration/"+w.opts.ConfigurationName) {
+		return nil
+	}
+
+	client := w.opts.KubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	conf, err := client.Get(context.TODO(), w.opts.ConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	ignore := v1.Ignore
+	for i := range conf.Webhooks {
+		conf.Webhooks[i].FailurePolicy = &ignore
+	}
+	_, err = client.Update(context.TODO(), conf, metav1.UpdateOptions{})
+	return err
+}
+
 func (w *MutatingWebhookResource) submit(conf *v1.MutatingWebhookConfiguration) error {
 	client := w.opts.KubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations()
 
@@ -177,6 +287,7 @@ func (w *MutatingWebhookResource) submit(conf *v1.MutatingWebhookConfiguration)
 		return err
 	}
 	if len(list.Items) == 0 {
+		conf.Labels = w.opts.Labels
 		_, err = client.Create(context.TODO(), conf, metav1.CreateOptions{})
 		if err != nil {
 			log.Errorf("Create MutatingWebhookConfiguration/%s: %v", conf.Name, err)
@@ -184,6 +295,7 @@ func (w *MutatingWebhookResource) submit(conf *v1.MutatingWebhookConfiguration)
 	} else {
 		newConf := list.Items[0]
 		newConf.Webhooks = conf.Webhooks
+		newConf.Labels = mergeLabels(newConf.Labels, w.opts.Labels)
 		_, err = client.Update(context.TODO(), &newConf, metav1.UpdateOptions{})
 		if err != nil {
 			log.Errorf("Replace MutatingWebhookConfiguration/%s: %v", conf.Name, err)
@@ -191,3 +303,16 @@ func (w *MutatingWebhookResource) submit(conf *v1.MutatingWebhookConfiguration)
 	}
 	return nil
 }
+
+func mergeLabels(labels map[string]string, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return labels
+	}
+	if labels == nil {
+		labels = make(map[string]string, len(extra))
+	}
+	for k, v := range extra {
+		labels[k] = v
+	}
+	return labels
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"sigs.k8s.io/yaml"
 )
 
 type Response struct {
@@ -15,6 +17,17 @@ type Response struct {
 	Message  string   `json:"message,omitempty"`
 	Warnings []string `json:"warnings,omitempty"`
 	Patch    []byte   `json:"patch,omitempty"`
+	// Code is an HTTP status code for a denied request. Default is 403.
+	Code int32 `json:"code,omitempty"`
+	// HookFailed is set if the hook execution is failed. It is not a part of the hook output.
+	HookFailed bool `json:"-"`
+}
+
+// hookResponse is a format of the response file. It is a JSON or a YAML document
+// with "allowed" or a shorter "allow" field.
+type hookResponse struct {
+	Response
+	Allow *bool `json:"allow,omitempty"`
 }
 
 func ResponseFromFile(filePath string) (*Response, error) {
@@ -35,12 +48,34 @@ func ResponseFromBytes(data []byte) (*Response, error) {
 }
 
 func FromReader(r io.Reader) (*Response, error) {
-	response := new(Response)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is a subset of YAML, so both formats are converted to JSON.
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	// Whitespaces and comments are converted to null, it is not a response.
+	if string(bytes.TrimSpace(jsonData)) == "null" {
+		return nil, fmt.Errorf("response is empty")
+	}
 
-	if err := json.NewDecoder(r).Decode(response); err != nil {
+	hookResp := new(hookResponse)
+	if err := json.Unmarshal(jsonData, hookResp); err != nil {
 		return nil, err
 	}
 
+	response := &hookResp.Response
+	if hookResp.Allow != nil {
+		response.Allowed = *hookResp.Allow
+	}
+
+	if response.Code != 0 && (response.Code < 400 || response.Code > 599) {
+		return nil, fmt.Errorf("code %d is invalid: should be an HTTP error code from 400 to 599", response.Code)
+	}
+
 	return response, nil
 }
 
@@ -52,6 +87,10 @@ func (r *Response) Dump() string {
 		b.WriteString(",patch=")
 		b.Write(r.Patch)
 	}
+	if r.Code != 0 {
+		b.WriteString(",code=")
+		b.WriteString(strconv.Itoa(int(r.Code)))
+	}
 	if r.Message != "" {
 		b.WriteString(",msg=")
 		b.WriteString(r.Message)
@@ -77,3 +77,39 @@ func Test_AdmissionResponseFromFile_NotAllowed_WithoutMessage(t *testing.T) {
 		t.Fatalf("ValidatingResponse should have no message: %#v", r)
 	}
 }
+
+func Test_AdmissionResponseFromFile_YAML(t *testing.T) {
+	r, err := ResponseFromFile("testdata/response/good_deny_yaml.yaml")
+
+	if err != nil {
+		t.Fatalf("ValidatingResponse should be loaded from file: %v", err)
+	}
+
+	if r == nil {
+		t.Fatalf("ValidatingResponse should not be nil")
+	}
+
+	if r.Allowed {
+		t.Fatalf("ValidatingResponse should have allowed=false: %#v", r)
+	}
+
+	if r.Code != 422 || r.Message == "" || len(r.Warnings) != 1 {
+		t.Fatalf("ValidatingResponse should have code, message and warnings: %#v", r)
+	}
+}
+
+func Test_AdmissionResponseFromBytes_BadCode(t *testing.T) {
+	_, err := ResponseFromBytes([]byte("allow: false\ncode: 200\n"))
+
+	if err == nil {
+		t.Fatalf("ValidatingResponse with code 200 should not be loaded")
+	}
+}
+
+func Test_AdmissionResponseFromBytes_Whitespace(t *testing.T) {
+	_, err := ResponseFromBytes([]byte(" \n  # comment\n"))
+
+	if err == nil {
+		t.Fatalf("ValidatingResponse from whitespace should not be loaded")
+	}
+}
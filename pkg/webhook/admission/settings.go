@@ -1,6 +1,10 @@
 package admission
 
-import "github.com/flant/shell-operator/pkg/webhook/server"
+import (
+	"time"
+
+	"github.com/flant/shell-operator/pkg/webhook/server"
+)
 
 type WebhookSettings struct {
 	server.Settings
@@ -8,4 +12,16 @@ type WebhookSettings struct {
 	CABundle             []byte
 	ConfigurationName    string
 	DefaultFailurePolicy string
+	// ArchiveDir is a directory to store handled requests. Archiving is disabled if empty.
+	ArchiveDir string
+	// ArchiveURL is an HTTP endpoint to send handled requests to. It can't be used with ArchiveDir.
+	ArchiveURL string
+	// ArchiveRedactPaths are dot-separated paths of request fields to redact before archiving.
+	ArchiveRedactPaths []string
+	// AdmissionPolicies enables ValidatingAdmissionPolicy generation from validating bindings.
+	AdmissionPolicies bool
+	// ShutdownAction is applied to webhook configurations before the server stops: none, unregister or fail-open.
+	ShutdownAction string
+	// ShutdownDrainDelay is a time to serve requests after ShutdownAction is applied.
+	ShutdownDrainDelay time.Duration
 }
@@ -0,0 +1,81 @@
+package conversion
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ResultCache is an LRU cache of converted objects. Objects are keyed by uid, resourceVersion
+// and a desired apiVersion: an object with the same resourceVersion is always converted the same way.
+type ResultCache struct {
+	size int
+
+	m     sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type cacheItem struct {
+	key string
+	obj runtime.RawExtension
+}
+
+func NewResultCache(size int) *ResultCache {
+	return &ResultCache{
+		size:  size,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *ResultCache) Get(key string) (runtime.RawExtension, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	el, has := c.items[key]
+	if !has {
+		return runtime.RawExtension{}, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheItem).obj, true
+}
+
+func (c *ResultCache) Put(key string, obj runtime.RawExtension) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if el, has := c.items[key]; has {
+		el.Value.(*cacheItem).obj = obj
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.lru.PushFront(&cacheItem{key: key, obj: obj})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
+
+func (c *ResultCache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.lru.Len()
+}
+
+// CacheKey returns a cache key for the object or an empty string if the object
+// has no uid or resourceVersion, e.g. for objects in create requests.
+func CacheKey(obj runtime.RawExtension, desiredAPIVersion string) string {
+	var o struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(obj.Raw, &o); err != nil {
+		return ""
+	}
+	if o.Metadata.UID == "" || o.Metadata.ResourceVersion == "" {
+		return ""
+	}
+	return string(o.Metadata.UID) + "/" + o.Metadata.ResourceVersion + "/" + desiredAPIVersion
+}
@@ -8,6 +8,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	klient "github.com/flant/kube-client/client"
+	"github.com/flant/shell-operator/pkg/kube/read_only"
 )
 
 // A clientConfig for a particular CRD.
@@ -23,6 +24,10 @@ type CrdClientConfig struct {
 var SupportedConversionReviewVersions = []string{"v1", "v1beta1"}
 
 func (c *CrdClientConfig) Update(ctx context.Context) error {
+	if read_only.Skip("conversion", "update", "CustomResourceDefinition/"+c.CrdName) {
+		return nil
+	}
+
 	var (
 		retryTimeout = 15 * time.Second
 		retryBudget  = 60 // 60 times * 15 sec = 15 min
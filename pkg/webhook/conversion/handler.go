@@ -19,6 +19,8 @@ import (
 type WebhookHandler struct {
 	Manager *WebhookManager
 	Router  chi.Router
+	// Cache stores converted objects if set.
+	Cache *ResultCache
 }
 
 func NewWebhookHandler() *WebhookHandler {
@@ -91,6 +93,55 @@ func (h *WebhookHandler) handleReviewRequest(crdName string, request *v1.Convers
 		return nil, fmt.Errorf("ConversionReview handler is not defined")
 	}
 
+	if h.Cache == nil {
+		convertedObjects, err := h.convert(crdName, request)
+		if err != nil {
+			return nil, err
+		}
+		return successResponse(request, convertedObjects), nil
+	}
+
+	// Convert objects that are not in the cache with a single hook run.
+	convertedObjects := make([]runtime.RawExtension, len(request.Objects))
+	keys := make([]string, len(request.Objects))
+	missed := make([]int, 0)
+	for i, obj := range request.Objects {
+		keys[i] = CacheKey(obj, request.DesiredAPIVersion)
+		if keys[i] != "" {
+			if cached, has := h.Cache.Get(keys[i]); has {
+				convertedObjects[i] = cached
+				continue
+			}
+		}
+		missed = append(missed, i)
+	}
+
+	log.Debugf("ConversionReview for crd/%s: %d objects from cache, %d objects to convert", crdName, len(request.Objects)-len(missed), len(missed))
+	if len(missed) > 0 {
+		batch := request.DeepCopy()
+		batch.Objects = make([]runtime.RawExtension, 0, len(missed))
+		for _, i := range missed {
+			batch.Objects = append(batch.Objects, request.Objects[i])
+		}
+
+		converted, err := h.convert(crdName, batch)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range missed {
+			convertedObjects[i] = converted[j]
+			if keys[i] != "" {
+				h.Cache.Put(keys[i], converted[j])
+			}
+		}
+	}
+
+	return successResponse(request, convertedObjects), nil
+}
+
+// convert runs hooks to convert all objects in the request.
+func (h *WebhookHandler) convert(crdName string, request *v1.ConversionRequest) ([]runtime.RawExtension, error) {
+	objectsCount := len(request.Objects)
 	conversionResponse, err := h.Manager.EventHandlerFn(crdName, request)
 	if err != nil {
 		return nil, err
@@ -100,16 +151,21 @@ func (h *WebhookHandler) handleReviewRequest(crdName string, request *v1.Convers
 		return nil, fmt.Errorf(conversionResponse.FailedMessage)
 	}
 
-	if len(request.Objects) != len(conversionResponse.ConvertedObjects) {
-		return nil, fmt.Errorf("hook returned %d objects instead of %d", len(conversionResponse.ConvertedObjects), len(request.Objects))
+	if objectsCount != len(conversionResponse.ConvertedObjects) {
+		return nil, fmt.Errorf("hook returned %d objects instead of %d", len(conversionResponse.ConvertedObjects), objectsCount)
 	}
 
+	return conversionResponse.ConvertedObjects, nil
+}
+
+func successResponse(request *v1.ConversionRequest, convertedObjects []runtime.RawExtension) *v1.ConversionResponse {
 	return &v1.ConversionResponse{
-		ConvertedObjects: conversionResponse.ConvertedObjects,
+		ConvertedObjects: convertedObjects,
 		UID:              request.UID,
 		Result: metav1.Status{
 			Status: metav1.StatusSuccess,
-		}}, nil
+		},
+	}
 }
 
 // detectCrdName extracts crdName from the url path.
@@ -0,0 +1,74 @@
+package conversion
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func rawObject(apiVersion string, uid string, rv string) runtime.RawExtension {
+	return runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"apiVersion":"%s","metadata":{"uid":"%s","resourceVersion":"%s"}}`, apiVersion, uid, rv))}
+}
+
+func Test_HandleReviewRequest_Cache(t *testing.T) {
+	g := NewWithT(t)
+
+	runs := 0
+	converted := 0
+	m := &WebhookManager{
+		EventHandlerFn: func(_ string, request *v1.ConversionRequest) (*Response, error) {
+			runs++
+			converted += len(request.Objects)
+			objs := make([]runtime.RawExtension, 0, len(request.Objects))
+			for range request.Objects {
+				objs = append(objs, rawObject(request.DesiredAPIVersion, "", ""))
+			}
+			return &Response{ConvertedObjects: objs}, nil
+		},
+	}
+	h := &WebhookHandler{Manager: m, Cache: NewResultCache(10)}
+
+	request := &v1.ConversionRequest{
+		DesiredAPIVersion: "example.com/v2",
+		Objects: []runtime.RawExtension{
+			rawObject("example.com/v1", "uid-1", "1"),
+			rawObject("example.com/v1", "uid-2", "1"),
+			// No uid: should not be cached.
+			rawObject("example.com/v1", "", ""),
+		},
+	}
+
+	resp, err := h.handleReviewRequest("crontabs.example.com", request.DeepCopy())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(resp.ConvertedObjects).Should(HaveLen(3))
+	g.Expect(runs).Should(Equal(1))
+	g.Expect(converted).Should(Equal(3))
+	g.Expect(h.Cache.Len()).Should(Equal(2))
+
+	// Only the object without uid and the new resourceVersion are converted by the hook.
+	request.Objects = append(request.Objects, rawObject("example.com/v1", "uid-1", "2"))
+	resp, err = h.handleReviewRequest("crontabs.example.com", request.DeepCopy())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(resp.ConvertedObjects).Should(HaveLen(4))
+	g.Expect(runs).Should(Equal(2))
+	g.Expect(converted).Should(Equal(5))
+}
+
+func Test_ResultCache_Evict(t *testing.T) {
+	g := NewWithT(t)
+
+	c := NewResultCache(2)
+	c.Put("a", runtime.RawExtension{})
+	c.Put("b", runtime.RawExtension{})
+	_, _ = c.Get("a")
+	c.Put("c", runtime.RawExtension{})
+
+	_, hasA := c.Get("a")
+	_, hasB := c.Get("b")
+	g.Expect(hasA).Should(BeTrue())
+	g.Expect(hasB).Should(BeFalse(), "least recently used item should be evicted")
+	g.Expect(c.Len()).Should(Equal(2))
+}
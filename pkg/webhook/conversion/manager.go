@@ -55,6 +55,9 @@ func (m *WebhookManager) Init() error {
 
 	m.Handler = NewWebhookHandler()
 	m.Handler.Manager = m
+	if m.Settings.CacheSize > 0 {
+		m.Handler.Cache = NewResultCache(m.Settings.CacheSize)
+	}
 
 	m.Server = &server.WebhookServer{
 		Settings:  &m.Settings.Settings,
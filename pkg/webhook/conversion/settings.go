@@ -6,4 +6,6 @@ type WebhookSettings struct {
 	server.Settings
 	CAPath   string
 	CABundle []byte
+	// CacheSize is a number of converted objects to cache. Zero disables the cache.
+	CacheSize int
 }
@@ -1,22 +1,154 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/flant/shell-operator/pkg/exitcode"
 )
 
 type WebhookServer struct {
 	Settings  *Settings
 	Namespace string
 	Router    chi.Router
+
+	// sniCerts are additional certificates for Services with dedicated certificates.
+	sniCerts []SNICertificate
+
+	srv *http.Server
+}
+
+// SNICertificate is a certificate served for a client that requests the Service hostname via SNI.
+type SNICertificate struct {
+	ServiceName    string
+	ServerCertPath string
+	ServerKeyPath  string
+}
+
+// SNICertificateCheckInterval is a minimal interval between checks of SNI certificate files for changes.
+var SNICertificateCheckInterval = 10 * time.Second
+
+// sniKeyPair is a key pair loaded from files of the SNICertificate. It is loaded again
+// when files are changed, e.g. when a Secret with the certificate is renewed.
+type sniKeyPair struct {
+	cert SNICertificate
+
+	m           sync.Mutex
+	keyPair     *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	checkedAt   time.Time
+}
+
+func newSNIKeyPair(cert SNICertificate) (*sniKeyPair, error) {
+	p := &sniKeyPair{cert: cert}
+	certModTime, keyModTime, err := p.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.load(certModTime, keyModTime); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// get returns the key pair and loads it again if files are changed.
+// The previous key pair is used if files can't be loaded.
+func (p *sniKeyPair) get() *tls.Certificate {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if time.Since(p.checkedAt) < SNICertificateCheckInterval {
+		return p.keyPair
+	}
+	p.checkedAt = time.Now()
+
+	certModTime, keyModTime, err := p.modTimes()
+	if err == nil && certModTime.Equal(p.certModTime) && keyModTime.Equal(p.keyModTime) {
+		return p.keyPair
+	}
+	if err == nil {
+		err = p.load(certModTime, keyModTime)
+	}
+	if err != nil {
+		log.Errorf("Webhook server: reload TLS certs for service '%s', use previous certificate: %v", p.cert.ServiceName, err)
+		return p.keyPair
+	}
+	log.Infof("Webhook server: TLS certs for service '%s' are reloaded", p.cert.ServiceName)
+	return p.keyPair
+}
+
+func (p *sniKeyPair) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(p.cert.ServerCertPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(p.cert.ServerKeyPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (p *sniKeyPair) load(certModTime, keyModTime time.Time) error {
+	keyPair, err := tls.LoadX509KeyPair(p.cert.ServerCertPath, p.cert.ServerKeyPath)
+	if err != nil {
+		return err
+	}
+	p.keyPair = &keyPair
+	p.certModTime = certModTime
+	p.keyModTime = keyModTime
+	p.checkedAt = time.Now()
+	return nil
+}
+
+// AddSNICertificate adds a certificate for the Service. It should be called before Start.
+// The first certificate is used if several certificates are added for the same Service.
+func (s *WebhookServer) AddSNICertificate(cert SNICertificate) {
+	for _, c := range s.sniCerts {
+		if c.ServiceName != cert.ServiceName {
+			continue
+		}
+		if c != cert {
+			log.Warnf("Webhook server: certificate for service '%s' is already added, ignore certificate '%s'", cert.ServiceName, cert.ServerCertPath)
+		}
+		return
+	}
+	s.sniCerts = append(s.sniCerts, cert)
+}
+
+// serviceHostnames returns hostnames that can be used by API-server to connect to the Service.
+func (s *WebhookServer) serviceHostnames(serviceName string) []string {
+	return []string{
+		fmt.Sprintf("%s.%s", serviceName, s.Namespace),
+		fmt.Sprintf("%s.%s.svc", serviceName, s.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, s.Namespace),
+	}
+}
+
+// loadSNICertificates returns certificates by hostnames.
+func (s *WebhookServer) loadSNICertificates() (map[string]*sniKeyPair, error) {
+	res := make(map[string]*sniKeyPair)
+	for _, sniCert := range s.sniCerts {
+		keyPair, err := newSNIKeyPair(sniCert)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS certs for service '%s': %v", sniCert.ServiceName, err)
+		}
+		for _, hostname := range s.serviceHostnames(sniCert.ServiceName) {
+			res[hostname] = keyPair
+		}
+	}
+	return res, nil
 }
 
 // Start runs https server to listen for AdmissionReview requests from the API-server.
@@ -41,6 +173,21 @@ func (s *WebhookServer) Start() error {
 		ServerName:   host,
 	}
 
+	// Select certificate by the requested hostname, use default certificate if there is no match.
+	// SNI certificates are reloaded when their files are changed.
+	sniCerts, err := s.loadSNICertificates()
+	if err != nil {
+		return err
+	}
+	if len(sniCerts) > 0 {
+		tlsConf.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert, has := sniCerts[hello.ServerName]; has {
+				return cert.get(), nil
+			}
+			return &keyPair, nil
+		}
+	}
+
 	// Load client CA if defined
 	if len(s.Settings.ClientCAPaths) > 0 {
 		roots := x509.NewCertPool()
@@ -79,15 +226,24 @@ func (s *WebhookServer) Start() error {
 		ReadHeaderTimeout: timeout,
 	}
 
+	s.srv = srv
+
 	go func() {
 		log.Infof("Webhook server listens on %s", listenAddr)
 		err := srv.ServeTLS(listener, "", "")
-		if err != nil {
-			log.Errorf("Error starting Webhook https server: %v", err)
+		if err != nil && err != http.ErrServerClosed {
 			// Stop process if server can't start.
-			os.Exit(1)
+			exitcode.Exit(exitcode.WebhookBootstrapError, fmt.Errorf("start Webhook https server: %v", err))
 		}
 	}()
 
 	return nil
 }
+
+// Stop gracefully stops the server: it waits for in-flight requests until ctx is done.
+func (s *WebhookServer) Stop(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.Shutdown(ctx)
+}
@@ -3,7 +3,9 @@ package server
 import (
 	"crypto/x509"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -47,3 +49,82 @@ func Test_Client_CA(t *testing.T) {
 		}
 	}
 }
+
+func Test_LoadSNICertificates(t *testing.T) {
+	srv := &WebhookServer{Namespace: "ns"}
+	cert := SNICertificate{
+		ServiceName:    "team-a-webhook",
+		ServerCertPath: "testdata/demo-certs/server.crt",
+		ServerKeyPath:  "testdata/demo-certs/server-key.pem",
+	}
+	srv.AddSNICertificate(cert)
+	// The same certificate for the same service is added only once.
+	srv.AddSNICertificate(cert)
+	if len(srv.sniCerts) != 1 {
+		t.Fatalf("Certificate should be added once, got %d", len(srv.sniCerts))
+	}
+
+	certs, err := srv.loadSNICertificates()
+	if err != nil {
+		t.Fatalf("Certificates should be loaded: %v", err)
+	}
+	for _, host := range []string{"team-a-webhook.ns", "team-a-webhook.ns.svc", "team-a-webhook.ns.svc.cluster.local"} {
+		if certs[host] == nil || certs[host].get() == nil {
+			t.Fatalf("Certificate for '%s' should be loaded", host)
+		}
+	}
+}
+
+func Test_SNIKeyPair_Reload(t *testing.T) {
+	dir := t.TempDir()
+	cert := SNICertificate{
+		ServiceName:    "team-a-webhook",
+		ServerCertPath: filepath.Join(dir, "tls.crt"),
+		ServerKeyPath:  filepath.Join(dir, "tls.key"),
+	}
+	copyFile(t, "testdata/demo-certs/server.crt", cert.ServerCertPath)
+	copyFile(t, "testdata/demo-certs/server-key.pem", cert.ServerKeyPath)
+
+	checkInterval := SNICertificateCheckInterval
+	SNICertificateCheckInterval = 0
+	defer func() { SNICertificateCheckInterval = checkInterval }()
+
+	p, err := newSNIKeyPair(cert)
+	if err != nil {
+		t.Fatalf("Certificate should be loaded: %v", err)
+	}
+	first := p.get()
+
+	// A broken certificate is ignored, the previous one is served.
+	if err := os.WriteFile(cert.ServerCertPath, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, cert.ServerCertPath, time.Now().Add(time.Second))
+	if p.get() != first {
+		t.Fatalf("Previous certificate should be served if files can't be loaded")
+	}
+
+	copyFile(t, "testdata/demo-certs/server.crt", cert.ServerCertPath)
+	touch(t, cert.ServerCertPath, time.Now().Add(2*time.Second))
+	if p.get() == first {
+		t.Fatalf("Certificate should be reloaded after files are changed")
+	}
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func touch(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}